@@ -35,10 +35,11 @@ type Envelope struct {
 
 // MessageData represents the serialized form of a Message.
 type MessageData struct {
-	Role      string                 `json:"role"`
-	Content   string                 `json:"content"`
-	Metadata  map[string]interface{} `json:"metadata"`
-	Timestamp string                 `json:"timestamp"`
+	Role        string                 `json:"role"`
+	Content     string                 `json:"content"`
+	Attachments []agenkit.Attachment   `json:"attachments,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Timestamp   string                 `json:"timestamp"`
 }
 
 // ToolResultData represents the serialized form of a ToolResult.
@@ -52,10 +53,11 @@ type ToolResultData struct {
 // EncodeMessage converts a Message to its serializable form.
 func EncodeMessage(msg *agenkit.Message) MessageData {
 	return MessageData{
-		Role:      msg.Role,
-		Content:   msg.Content,
-		Metadata:  msg.Metadata,
-		Timestamp: msg.Timestamp.Format(time.RFC3339Nano),
+		Role:        msg.Role,
+		Content:     msg.Content,
+		Attachments: msg.Attachments,
+		Metadata:    msg.Metadata,
+		Timestamp:   msg.Timestamp.Format(time.RFC3339Nano),
 	}
 }
 
@@ -67,10 +69,11 @@ func DecodeMessage(data MessageData) (*agenkit.Message, error) {
 	}
 
 	return &agenkit.Message{
-		Role:      data.Role,
-		Content:   data.Content,
-		Metadata:  data.Metadata,
-		Timestamp: timestamp,
+		Role:        data.Role,
+		Content:     data.Content,
+		Attachments: data.Attachments,
+		Metadata:    data.Metadata,
+		Timestamp:   timestamp,
 	}, nil
 }
 
@@ -0,0 +1,85 @@
+package agenkit
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff computes exponentially growing delays with full jitter, for
+// retries, re-probes and rate-limit waits.
+//
+// The delay before attempt n+1 is drawn uniformly from [0, min(max,
+// base*factor^(n-1))]. Backoff is safe for concurrent use.
+type Backoff struct {
+	base   time.Duration
+	max    time.Duration
+	factor float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewExponentialBackoff creates a backoff growing from base by factor per
+// attempt, capped at maxDelay. Non-positive base and maxDelay default to
+// 100ms and 10s, and a factor below 1 defaults to 2.
+func NewExponentialBackoff(base, maxDelay time.Duration, factor float64) *Backoff {
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+	if factor < 1 {
+		factor = 2
+	}
+	return &Backoff{base: base, max: max(base, maxDelay), factor: factor}
+}
+
+// WithSource sets the source of jitter and returns the backoff for
+// chaining. A seeded source makes the delays deterministic. By default the
+// global math/rand source is used.
+func (b *Backoff) WithSource(source rand.Source) *Backoff {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rng = rand.New(source)
+	return b
+}
+
+// Next returns the delay to wait after the given failed attempt (1-based).
+func (b *Backoff) Next(attempt int) time.Duration {
+	ceiling := b.Ceiling(attempt)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rng != nil {
+		return time.Duration(b.rng.Int63n(int64(ceiling) + 1))
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// Ceiling returns the longest delay Next may return for attempt.
+func (b *Backoff) Ceiling(attempt int) time.Duration {
+	delay := float64(b.base)
+	for i := 1; i < attempt && delay < float64(b.max); i++ {
+		delay *= b.factor
+	}
+	if delay >= float64(b.max) {
+		return b.max
+	}
+	return time.Duration(delay)
+}
+
+// Sleep waits for Next(attempt), returning early with the context's error
+// if ctx is done first.
+func (b *Backoff) Sleep(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(b.Next(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package agenkit
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBackoffCeilingGrowsToMax(t *testing.T) {
+	b := NewExponentialBackoff(100*time.Millisecond, time.Second, 2)
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+	}
+	for i, want := range expected {
+		if got := b.Ceiling(i + 1); got != want {
+			t.Errorf("Expected ceiling %v for attempt %d, got %v", want, i+1, got)
+		}
+	}
+	if got := b.Ceiling(1000); got != time.Second {
+		t.Errorf("Expected large attempts to stay capped, got %v", got)
+	}
+}
+
+func TestBackoffNextIsDeterministicWithSource(t *testing.T) {
+	first := NewExponentialBackoff(10*time.Millisecond, time.Second, 3).WithSource(rand.NewSource(42))
+	second := NewExponentialBackoff(10*time.Millisecond, time.Second, 3).WithSource(rand.NewSource(42))
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		a, b := first.Next(attempt), second.Next(attempt)
+		if a != b {
+			t.Fatalf("Expected equal delays from equal seeds at attempt %d, got %v and %v", attempt, a, b)
+		}
+		if a < 0 || a > first.Ceiling(attempt) {
+			t.Errorf("Delay %v for attempt %d outside [0, %v]", a, attempt, first.Ceiling(attempt))
+		}
+	}
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	b := NewExponentialBackoff(0, 0, 0)
+	if b.Ceiling(1) != 100*time.Millisecond || b.Ceiling(2) != 200*time.Millisecond || b.Ceiling(100) != 10*time.Second {
+		t.Errorf("Expected 100ms base, factor 2 and 10s cap, got %v, %v, %v", b.Ceiling(1), b.Ceiling(2), b.Ceiling(100))
+	}
+}
+
+func TestBackoffSleepHonorsContext(t *testing.T) {
+	b := NewExponentialBackoff(time.Hour, time.Hour, 2).WithSource(rand.NewSource(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := b.Sleep(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Sleep did not return when the context expired")
+	}
+
+	short := NewExponentialBackoff(time.Millisecond, time.Millisecond, 2)
+	if err := short.Sleep(context.Background(), 1); err != nil {
+		t.Errorf("Expected a completed sleep, got %v", err)
+	}
+}
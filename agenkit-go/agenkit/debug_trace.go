@@ -0,0 +1,176 @@
+package agenkit
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Step is one agent call recorded in a Trace.
+type Step struct {
+	// Index is the step's position in the trace; steps are numbered in
+	// the order their calls started.
+	Index int `json:"index"`
+
+	// Parent is the index of the step whose agent made this call, or -1
+	// for the outermost call.
+	Parent int `json:"parent"`
+
+	// Depth is the number of enclosing steps.
+	Depth int `json:"depth"`
+
+	// Agent is the name of the agent called.
+	Agent string `json:"agent"`
+
+	// Input is the message the agent received.
+	Input *Message `json:"input"`
+
+	// Output is the message the agent returned, including metadata such
+	// as the reasoning artifacts of techniques. It is nil if the call
+	// failed or has not returned.
+	Output *Message `json:"output,omitempty"`
+
+	// Error is the error the call failed with.
+	Error string `json:"error,omitempty"`
+
+	// Events are the events published during the call itself, such as
+	// tool calls and thoughts, but not those of the calls it made.
+	Events []Event `json:"events,omitempty"`
+
+	// Start is when the call started, and Duration how long it took.
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Trace records the agent calls of a run with their full payloads, for
+// stepping through the run afterwards. Unlike spans and metrics, which
+// summarize calls, a trace keeps every message, so it is meant for local
+// debugging and is only collected when enabled with WithTracing.
+//
+// Messages are copied when recorded; metadata values are shared with the
+// run.
+type Trace struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+type traceKey struct{}
+
+type traceStepKey struct{}
+
+// traceStep identifies the step running in a context. It names its trace,
+// since a traced run may start another trace with WithTracing.
+type traceStep struct {
+	trace *Trace
+	index int
+}
+
+// WithTracing returns a context in which agent calls are recorded to a new
+// Trace, available from TraceFromContext. Calls are recorded wherever
+// TrackAgent or ProcessWithSpan report them.
+func WithTracing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceKey{}, &Trace{})
+}
+
+// TraceFromContext returns the context's trace, or nil if tracing is not
+// enabled.
+func TraceFromContext(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// Len returns the number of steps recorded.
+func (t *Trace) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.steps)
+}
+
+// Steps returns a copy of the recorded steps.
+func (t *Trace) Steps() []Step {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steps := make([]Step, len(t.steps))
+	for i, step := range t.steps {
+		step.Events = append([]Event(nil), step.Events...)
+		steps[i] = step
+	}
+	return steps
+}
+
+// Walk calls fn with each recorded step, in the order the calls started.
+func (t *Trace) Walk(fn func(step Step)) {
+	for _, step := range t.Steps() {
+		fn(step)
+	}
+}
+
+// ToJSON encodes the recorded steps as a JSON array.
+func (t *Trace) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(t.Steps(), "", "  ")
+}
+
+// begin records the start of a call and returns the context to run it in.
+func (t *Trace) begin(ctx context.Context, agent string, message *Message) (context.Context, int) {
+	// A step of another trace is not a parent; the call starts this one
+	parent, depth := -1, 0
+	if current, ok := ctx.Value(traceStepKey{}).(traceStep); ok && current.trace == t {
+		parent = current.index
+	}
+
+	t.mu.Lock()
+	if parent >= 0 {
+		depth = t.steps[parent].Depth + 1
+	}
+	index := len(t.steps)
+	t.steps = append(t.steps, Step{
+		Index:  index,
+		Parent: parent,
+		Depth:  depth,
+		Agent:  agent,
+		Input:  copyTracedMessage(message),
+		Start:  time.Now(),
+	})
+	t.mu.Unlock()
+	return context.WithValue(ctx, traceStepKey{}, traceStep{trace: t, index: index}), index
+}
+
+// end records how the call of step index finished.
+func (t *Trace) end(index int, result *Message, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	step := &t.steps[index]
+	step.Duration = time.Since(step.Start)
+	if err != nil {
+		step.Error = err.Error()
+		return
+	}
+	step.Output = copyTracedMessage(result)
+}
+
+// record adds event to the step running in ctx, if any.
+func (t *Trace) record(ctx context.Context, event Event) {
+	current, ok := ctx.Value(traceStepKey{}).(traceStep)
+	if !ok || current.trace != t {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.steps[current.index].Events = append(t.steps[current.index].Events, event)
+}
+
+// copyTracedMessage returns a copy of message with its own metadata map
+// and attachment slice.
+func copyTracedMessage(message *Message) *Message {
+	if message == nil {
+		return nil
+	}
+	copied := *message
+	copied.Metadata = make(map[string]interface{}, len(message.Metadata))
+	for k, v := range message.Metadata {
+		copied.Metadata[k] = v
+	}
+	copied.Attachments = append([]Attachment(nil), message.Attachments...)
+	return &copied
+}
@@ -0,0 +1,147 @@
+package agenkit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// pipelineAgent calls its children in turn, like a sequential pattern.
+type pipelineAgent struct {
+	children []Agent
+}
+
+func (a *pipelineAgent) Name() string           { return "pipeline" }
+func (a *pipelineAgent) Capabilities() []string { return nil }
+
+func (a *pipelineAgent) Process(ctx context.Context, message *Message) (result *Message, err error) {
+	ctx, finish := TrackAgent(ctx, "pipeline", message)
+	defer func() { finish(result, err) }()
+	Emit(ctx, EventToolCalled, "", map[string]interface{}{"tool": "lookup"})
+	current := message
+	for _, child := range a.children {
+		current, err = ProcessWithSpan(ctx, child, current)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return current, nil
+}
+
+func TestTraceRecordsNestedSteps(t *testing.T) {
+	ctx := WithTracing(context.Background())
+	pipeline := &pipelineAgent{children: []Agent{&trackedAgent{}, &failingAgent{}}}
+
+	if _, err := pipeline.Process(ctx, NewMessage("user", "hi")); err == nil {
+		t.Fatal("Expected the failing child's error")
+	}
+
+	var steps []Step
+	TraceFromContext(ctx).Walk(func(step Step) { steps = append(steps, step) })
+	if len(steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %d", len(steps))
+	}
+	root, tracked, failing := steps[0], steps[1], steps[2]
+	if root.Agent != "pipeline" || root.Parent != -1 || root.Depth != 0 {
+		t.Errorf("Expected pipeline as the root step, got %+v", root)
+	}
+	if tracked.Agent != "tracked" || tracked.Parent != 0 || tracked.Depth != 1 {
+		t.Errorf("Expected tracked nested in the pipeline, got %+v", tracked)
+	}
+	if tracked.Input.Content != "hi" || tracked.Output == nil || tracked.Output.Content != "hi" {
+		t.Errorf("Expected tracked's input and output, got %+v / %+v", tracked.Input, tracked.Output)
+	}
+	if failing.Error != "upstream down" || failing.Output != nil {
+		t.Errorf("Expected the failing step's error, got %+v", failing)
+	}
+
+	// Events belong to the step that published them
+	if len(root.Events) != 1 || root.Events[0].Type != EventToolCalled {
+		t.Errorf("Expected the tool call on the pipeline step, got %+v", root.Events)
+	}
+	if len(tracked.Events) != 1 || tracked.Events[0].Type != EventThoughtGenerated {
+		t.Errorf("Expected only the thought on the tracked step, got %+v", tracked.Events)
+	}
+}
+
+func TestTraceCopiesMessages(t *testing.T) {
+	ctx := WithTracing(context.Background())
+	message := NewMessage("user", "hi").WithMetadata("k", "before")
+	if _, err := ProcessWithSpan(ctx, &trackedAgent{}, message); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	message.Metadata["k"] = "after"
+
+	steps := TraceFromContext(ctx).Steps()
+	if len(steps) != 1 {
+		t.Fatalf("Expected the call to be recorded once, got %d steps", len(steps))
+	}
+	if steps[0].Input.Metadata["k"] != "before" {
+		t.Errorf("Expected the input as it entered the agent, got %v", steps[0].Input.Metadata["k"])
+	}
+}
+
+func TestTraceToJSON(t *testing.T) {
+	ctx := WithTracing(context.Background())
+	if _, err := (&trackedAgent{}).Process(ctx, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	data, err := TraceFromContext(ctx).ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	var decoded []Step
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected a JSON array of steps, got %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Agent != "tracked" || decoded[0].Output.Content != "hi" {
+		t.Errorf("Expected the tracked step, got %+v", decoded)
+	}
+}
+
+func TestTracingOffByDefault(t *testing.T) {
+	ctx := context.Background()
+	if TraceFromContext(ctx) != nil {
+		t.Fatal("Expected no trace without WithTracing")
+	}
+	if _, err := (&trackedAgent{}).Process(ctx, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+}
+
+// retracingAgent runs its child under a trace of its own.
+type retracingAgent struct {
+	child Agent
+	inner *Trace
+}
+
+func (a *retracingAgent) Name() string           { return "retracing" }
+func (a *retracingAgent) Capabilities() []string { return nil }
+
+func (a *retracingAgent) Process(ctx context.Context, message *Message) (result *Message, err error) {
+	ctx, finish := TrackAgent(ctx, "retracing", message)
+	defer func() { finish(result, err) }()
+	ctx = WithTracing(ctx)
+	a.inner = TraceFromContext(ctx)
+	Emit(ctx, EventThoughtGenerated, "", map[string]interface{}{"thought": "outside any inner step"})
+	return ProcessWithSpan(ctx, a.child, message)
+}
+
+func TestNestedTracing(t *testing.T) {
+	ctx := WithTracing(context.Background())
+	retracing := &retracingAgent{child: &trackedAgent{}}
+	pipeline := &pipelineAgent{children: []Agent{&trackedAgent{}, retracing}}
+	if _, err := pipeline.Process(ctx, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	outer := TraceFromContext(ctx).Steps()
+	if len(outer) != 3 || outer[2].Agent != "retracing" || len(outer[2].Events) != 0 {
+		t.Errorf("Expected pipeline, tracked and retracing in the outer trace, got %+v", outer)
+	}
+	inner := retracing.inner.Steps()
+	if len(inner) != 1 || inner[0].Agent != "tracked" || inner[0].Parent != -1 || inner[0].Depth != 0 {
+		t.Errorf("Expected the child as the inner trace's root, got %+v", inner)
+	}
+}
@@ -0,0 +1,223 @@
+package agenkit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RateLimitError reports that an upstream service rejected a call for
+// exceeding its rate limit.
+type RateLimitError struct {
+	// Provider names the service that rate limited the call.
+	Provider string
+
+	// RetryAfter is how long the service asked callers to wait, or zero if
+	// it did not say.
+	RetryAfter time.Duration
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("%s: rate limited", e.Provider)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %v)", e.RetryAfter)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderError reports a failed call to an upstream model or service.
+type ProviderError struct {
+	// Provider names the service that failed.
+	Provider string
+
+	// StatusCode is the HTTP status of the response, or zero if the call
+	// failed before a response was received.
+	StatusCode int
+
+	// Message is the service's error message.
+	Message string
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ProviderError) Error() string {
+	msg := e.Provider + ": "
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf("status %d: ", e.StatusCode)
+	}
+	msg += e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether repeating the call might succeed: network
+// failures, timeouts, rate limits and server errors are retryable; other
+// client errors are not.
+func (e *ProviderError) Retryable() bool {
+	switch {
+	case e.StatusCode == 0,
+		e.StatusCode == http.StatusRequestTimeout,
+		e.StatusCode == http.StatusTooManyRequests,
+		e.StatusCode >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// ContextLengthError reports that a request exceeded the model's context
+// window. Retrying the same request cannot succeed.
+type ContextLengthError struct {
+	// Provider names the service that rejected the request.
+	Provider string
+
+	// Limit is the model's context size in tokens, if known.
+	Limit int
+
+	// Requested is the number of tokens in the request, if known.
+	Requested int
+
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ContextLengthError) Error() string {
+	msg := fmt.Sprintf("%s: context length exceeded", e.Provider)
+	if e.Limit > 0 {
+		msg += fmt.Sprintf(" (%d tokens requested, limit %d)", e.Requested, e.Limit)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ContextLengthError) Unwrap() error {
+	return e.Err
+}
+
+// ToolError reports that a tool failed to execute.
+type ToolError struct {
+	// ToolName is the name of the tool that failed.
+	ToolName string
+
+	// Err is the error returned by the tool.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("tool '%s' failed: %v", e.ToolName, e.Err)
+}
+
+// Unwrap returns the error returned by the tool.
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedAttachmentError reports that a provider cannot send an
+// attachment to its model.
+type UnsupportedAttachmentError struct {
+	// Provider names the provider that rejected the attachment.
+	Provider string
+
+	// MIMEType is the attachment's MIME type.
+	MIMEType string
+
+	// Reason explains the rejection when the MIME type alone does not,
+	// such as an attachment on a message role that cannot carry one.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedAttachmentError) Error() string {
+	msg := fmt.Sprintf("%s: unsupported attachment type '%s'", e.Provider, e.MIMEType)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// InvalidRequestError reports that a request was rejected before it was
+// sent, such as one that cannot be encoded or asks for a response format a
+// provider cannot enforce. Retrying the same request cannot succeed.
+type InvalidRequestError struct {
+	// Provider names the provider that rejected the request.
+	Provider string
+
+	// Err is the reason for the rejection.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *InvalidRequestError) Error() string {
+	return fmt.Sprintf("%s: invalid request: %v", e.Provider, e.Err)
+}
+
+// Unwrap returns the reason for the rejection.
+func (e *InvalidRequestError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is worth retrying. Context length errors,
+// unsupported attachments, invalid requests and non-retryable provider
+// errors are not; any other error is assumed to be transient.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var contextErr *ContextLengthError
+	if errors.As(err, &contextErr) {
+		return false
+	}
+	var attachmentErr *UnsupportedAttachmentError
+	if errors.As(err, &attachmentErr) {
+		return false
+	}
+	var requestErr *InvalidRequestError
+	if errors.As(err, &requestErr) {
+		return false
+	}
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return true
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Retryable()
+	}
+	return true
+}
+
+// RetryAfter returns the wait requested by a RateLimitError in err's chain.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) && rateErr.RetryAfter > 0 {
+		return rateErr.RetryAfter, true
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package agenkit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedErrorsUnwrap(t *testing.T) {
+	cause := errors.New("upstream said no")
+	err := fmt.Errorf("agent x: %w", &RateLimitError{Provider: "openai", RetryAfter: 2 * time.Second, Err: cause})
+
+	var rl *RateLimitError
+	if !errors.As(err, &rl) {
+		t.Fatal("Expected errors.As to find the RateLimitError")
+	}
+	if rl.RetryAfter != 2*time.Second {
+		t.Errorf("Expected RetryAfter 2s, got %v", rl.RetryAfter)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to be reachable with errors.Is")
+	}
+	if !strings.Contains(err.Error(), "retry after 2s") {
+		t.Errorf("Expected message to mention the wait, got '%s'", err.Error())
+	}
+
+	var te *ToolError
+	if !errors.As(fmt.Errorf("wrapped: %w", &ToolError{ToolName: "search", Err: cause}), &te) || te.ToolName != "search" {
+		t.Errorf("Expected ToolError for 'search', got %+v", te)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", errors.New("boom"), true},
+		{"rate limit", &RateLimitError{Provider: "p"}, true},
+		{"server error", &ProviderError{Provider: "p", StatusCode: http.StatusBadGateway}, true},
+		{"network error", &ProviderError{Provider: "p", Err: errors.New("reset")}, true},
+		{"bad request", &ProviderError{Provider: "p", StatusCode: http.StatusBadRequest}, false},
+		{"context length", fmt.Errorf("x: %w", &ContextLengthError{Provider: "p", Limit: 10, Requested: 20}), false},
+		{"unsupported attachment", &UnsupportedAttachmentError{Provider: "p", MIMEType: "video/mp4"}, false},
+		{"invalid request", &ProviderError{Provider: "p", Err: &InvalidRequestError{Provider: "p", Err: errors.New("bad")}}, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: expected IsRetryable %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if _, ok := RetryAfter(errors.New("boom")); ok {
+		t.Error("Expected no RetryAfter for a plain error")
+	}
+	d, ok := RetryAfter(fmt.Errorf("x: %w", &RateLimitError{RetryAfter: time.Second}))
+	if !ok || d != time.Second {
+		t.Errorf("Expected 1s, got %v (%v)", d, ok)
+	}
+}
@@ -0,0 +1,243 @@
+package agenkit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies the kind of an Event.
+type EventType string
+
+// Event types published by agents, patterns, tools and reasoning techniques.
+const (
+	// EventAgentStarted is published when an agent begins processing.
+	// Payload: "input" (string).
+	EventAgentStarted EventType = "agent_started"
+
+	// EventAgentFinished is published when an agent returns.
+	// Payload: "duration" (time.Duration), and "output" (string) or "error" (string).
+	EventAgentFinished EventType = "agent_finished"
+
+	// EventToolCalled is published before a tool runs.
+	// Payload: "tool" (string), "parameters" (map[string]interface{}).
+	EventToolCalled EventType = "tool_called"
+
+	// EventToolReturned is published after a tool runs.
+	// Payload: "tool", "success" (bool), "duration", and "error" on failure.
+	EventToolReturned EventType = "tool_returned"
+
+	// EventThoughtGenerated is published by reasoning techniques for each
+	// intermediate thought. Payload: "thought" (string) plus technique details.
+	EventThoughtGenerated EventType = "thought_generated"
+)
+
+// Event describes something that happened during a run.
+type Event struct {
+	Type      EventType              `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	AgentName string                 `json:"agent_name"`
+	Payload   map[string]interface{} `json:"payload,omitempty"`
+
+	// Metadata is the request-scoped metadata of the publishing context
+	// (see WithMetadata).
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// EventSink receives published events. Publish is called synchronously by
+// the publishing agent and must not block.
+type EventSink interface {
+	Publish(event Event)
+}
+
+type eventSinkKey struct{}
+
+// WithEventSink returns a context in which agents, patterns and tools
+// publish events to sink. Events are opt-in: without a sink, publishing is
+// a no-op.
+func WithEventSink(ctx context.Context, sink EventSink) context.Context {
+	return context.WithValue(ctx, eventSinkKey{}, sink)
+}
+
+// EventSinkFromContext returns the context's event sink, or nil.
+func EventSinkFromContext(ctx context.Context) EventSink {
+	sink, _ := ctx.Value(eventSinkKey{}).(EventSink)
+	return sink
+}
+
+// Emit publishes an event to the context's sink, if any, and records it in
+// the context's Trace, if tracing is enabled. An empty agentName defaults
+// to the agent currently running in ctx.
+func Emit(ctx context.Context, eventType EventType, agentName string, payload map[string]interface{}) {
+	sink := EventSinkFromContext(ctx)
+	trace := TraceFromContext(ctx)
+	if sink == nil && trace == nil {
+		return
+	}
+	if agentName == "" {
+		agentName = CurrentAgent(ctx)
+	}
+	event := Event{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		AgentName: agentName,
+		Payload:   payload,
+		Metadata:  MetadataFrom(ctx),
+	}
+	// A trace records calls as steps of their own
+	if trace != nil && eventType != EventAgentStarted && eventType != EventAgentFinished {
+		trace.record(ctx, event)
+	}
+	if sink != nil {
+		sink.Publish(event)
+	}
+}
+
+type currentAgentKey struct{}
+
+type announcedAgentKey struct{}
+
+// CurrentAgent returns the name of the innermost agent tracked in ctx, or "".
+func CurrentAgent(ctx context.Context) string {
+	name, _ := ctx.Value(currentAgentKey{}).(string)
+	return name
+}
+
+// TrackAgent publishes EventAgentStarted for an agent and returns the
+// context to run it in along with a function that publishes
+// EventAgentFinished. With a logger in ctx (see WithLogger), the call is
+// logged as well, and with tracing enabled (see WithTracing) it is
+// recorded as a Step. Agents call it at the top of Process so they report
+// progress when run standalone; when the agent is invoked through
+// ProcessWithSpan, which already reports it, TrackAgent reports nothing.
+func TrackAgent(ctx context.Context, name string, message *Message) (context.Context, func(result *Message, err error)) {
+	announced, _ := ctx.Value(announcedAgentKey{}).(string)
+	if announced != "" {
+		ctx = context.WithValue(ctx, announcedAgentKey{}, "")
+	}
+	sink := EventSinkFromContext(ctx) != nil
+	logging := loggerValue(ctx) != nil
+	trace := TraceFromContext(ctx)
+	if (!sink && !logging && trace == nil) || announced == name {
+		return ctx, func(*Message, error) {}
+	}
+
+	ctx = context.WithValue(ctx, currentAgentKey{}, name)
+	if sink {
+		Emit(ctx, EventAgentStarted, name, map[string]interface{}{"input": message.Content})
+	}
+	if logging {
+		Logger(ctx).DebugContext(ctx, "agent call started", slog.String(LogKeyAgent, name))
+	}
+	step := -1
+	if trace != nil {
+		ctx, step = trace.begin(ctx, name, message)
+	}
+	start := time.Now()
+	return ctx, func(result *Message, err error) {
+		duration := time.Since(start)
+		if trace != nil {
+			trace.end(step, result, err)
+		}
+		if sink {
+			payload := map[string]interface{}{"duration": duration}
+			if err != nil {
+				payload["error"] = err.Error()
+			} else if result != nil {
+				payload["output"] = result.Content
+			}
+			Emit(ctx, EventAgentFinished, name, payload)
+		}
+		if !logging {
+			return
+		}
+		if err != nil {
+			Logger(ctx).ErrorContext(ctx, "agent call failed",
+				slog.String(LogKeyAgent, name),
+				slog.Duration(LogKeyDuration, duration),
+				slog.String(LogKeyError, err.Error()),
+			)
+			return
+		}
+		Logger(ctx).InfoContext(ctx, "agent call completed",
+			slog.String(LogKeyAgent, name),
+			slog.Duration(LogKeyDuration, duration),
+		)
+	}
+}
+
+// Subscription is a bounded buffer of events from an EventBus.
+type Subscription struct {
+	events  chan Event
+	dropped atomic.Int64
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription is removed from its bus.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events discarded because the buffer was full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// EventBus fans events out to any number of subscriptions.
+//
+// Publishing never blocks: an event that does not fit in a subscription's
+// buffer is dropped for that subscription and counted in its Dropped total.
+// An EventBus is safe for concurrent use.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs []*Subscription
+}
+
+// Verify that EventBus implements EventSink interface.
+var _ EventSink = (*EventBus)(nil)
+
+// NewEventBus creates an event bus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe adds a subscription buffering up to buffer events.
+// A buffer of zero or less defaults to 64.
+func (b *EventBus) Subscribe(buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	sub := &Subscription{events: make(chan Event, buffer)}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes sub from the bus and closes its channel.
+func (b *EventBus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			close(sub.events)
+			return
+		}
+	}
+}
+
+// Publish delivers event to every subscription without blocking.
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subs {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
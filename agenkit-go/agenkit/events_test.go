@@ -0,0 +1,108 @@
+package agenkit
+
+import (
+	"context"
+	"testing"
+)
+
+// trackedAgent reports its own events and echoes its input.
+type trackedAgent struct{}
+
+func (a *trackedAgent) Name() string           { return "tracked" }
+func (a *trackedAgent) Capabilities() []string { return nil }
+
+func (a *trackedAgent) Process(ctx context.Context, message *Message) (result *Message, err error) {
+	ctx, finish := TrackAgent(ctx, "tracked", message)
+	defer func() { finish(result, err) }()
+	Emit(ctx, EventThoughtGenerated, "", map[string]interface{}{"thought": "hmm"})
+	return NewMessage("agent", message.Content), nil
+}
+
+// drainEvents returns the events currently buffered in sub.
+func drainEvents(sub *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case e := <-sub.Events():
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventBusDropsWhenFull(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(2)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(Event{Type: EventThoughtGenerated})
+	}
+	if got := len(drainEvents(sub)); got != 2 {
+		t.Errorf("Expected 2 buffered events, got %d", got)
+	}
+	if sub.Dropped() != 3 {
+		t.Errorf("Expected 3 dropped events, got %d", sub.Dropped())
+	}
+
+	bus.Unsubscribe(sub)
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected channel to be closed after Unsubscribe")
+	}
+	bus.Publish(Event{Type: EventThoughtGenerated}) // must not panic
+}
+
+func TestTrackAgentStandalone(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(0)
+	ctx := WithEventSink(context.Background(), bus)
+
+	if _, err := (&trackedAgent{}).Process(ctx, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	events := drainEvents(sub)
+	if len(events) != 3 {
+		t.Fatalf("Expected started, thought and finished events, got %+v", events)
+	}
+	want := []EventType{EventAgentStarted, EventThoughtGenerated, EventAgentFinished}
+	for i, e := range events {
+		if e.Type != want[i] || e.AgentName != "tracked" {
+			t.Errorf("Event %d: expected %s from 'tracked', got %s from '%s'", i, want[i], e.Type, e.AgentName)
+		}
+		if e.Timestamp.IsZero() {
+			t.Errorf("Event %d: expected a timestamp", i)
+		}
+	}
+	if events[2].Payload["output"] != "hi" {
+		t.Errorf("Expected finished event to carry the output, got %v", events[2].Payload)
+	}
+}
+
+func TestProcessWithSpanReportsOnce(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(0)
+	ctx := WithEventSink(context.Background(), bus)
+
+	ProcessWithSpan(ctx, &trackedAgent{}, NewMessage("user", "hi"))
+	ProcessWithSpan(ctx, &echoAgent{}, NewMessage("user", "hi"))
+
+	var started []string
+	for _, e := range drainEvents(sub) {
+		if e.Type == EventAgentStarted {
+			started = append(started, e.AgentName)
+		}
+	}
+	if len(started) != 2 || started[0] != "tracked" || started[1] != "echo" {
+		t.Errorf("Expected one start event per agent, got %v", started)
+	}
+}
+
+func TestEmitWithoutSinkIsNoop(t *testing.T) {
+	Emit(context.Background(), EventToolCalled, "x", nil)
+	ctx, finish := TrackAgent(context.Background(), "x", NewMessage("user", "hi"))
+	finish(nil, nil)
+	if CurrentAgent(ctx) != "" {
+		t.Error("Expected no agent tracking without a sink")
+	}
+}
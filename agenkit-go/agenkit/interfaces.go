@@ -3,15 +3,27 @@ package agenkit
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 // Message represents a message exchanged between agents or tools.
 type Message struct {
-	Role      string                 `json:"role"`
-	Content   string                 `json:"content"`
-	Metadata  map[string]interface{} `json:"metadata"`
-	Timestamp time.Time              `json:"timestamp"`
+	Role        string                 `json:"role"`
+	Content     string                 `json:"content"`
+	Attachments []Attachment           `json:"attachments,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// Attachment is non-text content sent with a message, such as an image
+// for a vision model. It carries either the raw bytes in Data or a URL
+// the provider can fetch, not both.
+type Attachment struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data,omitempty"`
+	URL      string `json:"url,omitempty"`
 }
 
 // NewMessage creates a new message with the given role and content.
@@ -30,6 +42,13 @@ func (m *Message) WithMetadata(key string, value interface{}) *Message {
 	return m
 }
 
+// WithAttachment adds an attachment to the message and returns the message
+// for chaining.
+func (m *Message) WithAttachment(attachment Attachment) *Message {
+	m.Attachments = append(m.Attachments, attachment)
+	return m
+}
+
 // ToolResult represents the result of a tool execution.
 type ToolResult struct {
 	Success  bool                   `json:"success"`
@@ -62,8 +81,51 @@ func (t *ToolResult) WithMetadata(key string, value interface{}) *ToolResult {
 	return t
 }
 
+// ForModel renders the result as text for a model's observation. Data that
+// implements ModelFormatter renders itself, strings are used as-is, and any
+// other data is encoded as JSON. Failures render as "Error: <message>".
+func (t *ToolResult) ForModel() string {
+	if !t.Success {
+		return "Error: " + t.Error
+	}
+	switch data := t.Data.(type) {
+	case nil:
+		return ""
+	case ModelFormatter:
+		return data.ForModel()
+	case string:
+		return data
+	}
+	encoded, err := json.Marshal(t.Data)
+	if err != nil {
+		return fmt.Sprintf("%v", t.Data)
+	}
+	return string(encoded)
+}
+
+// ModelFormatter is implemented by structured tool result data that controls
+// how it is shown to a model, such as a compact list of search hits while
+// the full results stay available to the application.
+type ModelFormatter interface {
+	ForModel() string
+}
+
+// ToolCall represents a request to execute a tool.
+type ToolCall struct {
+	// ID optionally identifies the call so its result can be correlated.
+	ID         string                 `json:"id,omitempty"`
+	ToolName   string                 `json:"tool_name"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
 // Agent is the core interface that all agents must implement.
 // Agents process messages and optionally support streaming responses.
+//
+// Agents must be safe for concurrent use: patterns such as ParallelAgent
+// and MapReduceAgent call one agent instance from several goroutines, and
+// servers call an agent once per request. An agent that keeps mutable state
+// without synchronizing it can be made safe with middleware.Serialize, at
+// the cost of running one call at a time.
 type Agent interface {
 	// Name returns the unique identifier for this agent.
 	Name() string
@@ -89,6 +151,19 @@ type StreamingAgent interface {
 	Stream(ctx context.Context, message *Message) (<-chan *Message, <-chan error)
 }
 
+// ChunkStreamingAgent extends Agent to stream responses as typed chunks.
+// Chunks carry incremental text deltas and tool calls; the final chunk has
+// Done set and carries either the assembled message or an error.
+type ChunkStreamingAgent interface {
+	Agent
+
+	// ProcessStream handles a message and streams response chunks.
+	// The returned channel is closed when streaming completes or ctx is cancelled.
+	// Errors during processing are delivered through the final chunk's Err field;
+	// the returned error only reports failures to start the stream.
+	ProcessStream(ctx context.Context, message *Message) (<-chan StreamChunk, error)
+}
+
 // Tool represents an executable capability that agents can use.
 type Tool interface {
 	// Name returns the unique identifier for this tool.
@@ -100,3 +175,35 @@ type Tool interface {
 	// Execute runs the tool with the given parameters and returns a result.
 	Execute(ctx context.Context, params map[string]interface{}) (*ToolResult, error)
 }
+
+// ToolUser is implemented by agents that make tools available, so that
+// tooling such as composition.DryRun can report them without running the
+// agent.
+type ToolUser interface {
+	// Tools returns the tools the agent may call.
+	Tools() []Tool
+}
+
+// Lifecycle is implemented by agents and tools that hold resources, such
+// as connections, needing setup before use and teardown afterwards.
+// composition.Runtime starts and stops every Lifecycle in an agent tree.
+type Lifecycle interface {
+	// Start acquires the resources. It is called before the first use.
+	Start(ctx context.Context) error
+
+	// Stop releases the resources. It is called after the last use.
+	Stop(ctx context.Context) error
+}
+
+// DeterminismReporter is implemented by agents that know whether they
+// always answer the same input the same way, such as LLM agents sampling
+// at temperature 0. A report of false covers everything the agent
+// delegates to; an agent reporting true is still searched through, so it
+// only vouches for the randomness it adds itself, and
+// composition.MarkDeterministic vouches for a whole subtree.
+// composition.CachedAgent refuses to cache subtrees containing an agent
+// that reports itself non-deterministic.
+type DeterminismReporter interface {
+	// Deterministic reports whether equal inputs yield equal outputs.
+	Deterministic() bool
+}
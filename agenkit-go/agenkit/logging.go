@@ -0,0 +1,64 @@
+package agenkit
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// Attribute keys used in agenkit's log records, so records from different
+// layers can be filtered alike.
+const (
+	LogKeyAgent    = "agent"
+	LogKeyPattern  = "pattern"
+	LogKeyModel    = "model"
+	LogKeyTokens   = "tokens"
+	LogKeyDuration = "duration"
+	LogKeyError    = "error"
+)
+
+// loggerKey is the context key for the logger.
+type loggerKey struct{}
+
+// discardLogger is returned by Logger when ctx has no logger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// WithLogger returns a context in which agents, composition patterns and
+// the llm package log to logger: agent calls at Debug when they start and
+// at Info or Error when they finish, pattern decisions such as routes and
+// retries at Debug or Info, and model calls with their token usage.
+//
+// Logging is opt-in: without a logger in context nothing is logged.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the context's logger, or one that discards every record
+// if there is none. Metadata in ctx (see WithMetadata) is attached to its
+// records as a "metadata" group, so lines from one run can be correlated.
+func Logger(ctx context.Context) *slog.Logger {
+	logger := loggerValue(ctx)
+	if logger == nil {
+		return discardLogger
+	}
+	metadata := metadataValue(ctx)
+	if len(metadata) == 0 {
+		return logger
+	}
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.String(k, metadata[k])
+	}
+	return logger.With(slog.Group("metadata", attrs...))
+}
+
+// loggerValue returns the logger stored in ctx, or nil.
+func loggerValue(ctx context.Context) *slog.Logger {
+	logger, _ := ctx.Value(loggerKey{}).(*slog.Logger)
+	return logger
+}
@@ -0,0 +1,73 @@
+package agenkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// logRecords decodes the JSON log lines in buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// failingAgent always fails.
+type failingAgent struct{}
+
+func (a *failingAgent) Name() string           { return "failing" }
+func (a *failingAgent) Capabilities() []string { return nil }
+func (a *failingAgent) Process(ctx context.Context, message *Message) (*Message, error) {
+	return nil, errors.New("upstream down")
+}
+
+func TestLoggerLogsAgentCallsOnceWithMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	ctx = WithMetadata(ctx, map[string]string{"trace_id": "t-1"})
+
+	// The agent tracks itself, but is reported only once
+	if _, err := ProcessWithSpan(ctx, &trackedAgent{}, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	ProcessWithSpan(ctx, &failingAgent{}, NewMessage("user", "hi"))
+
+	records := logRecords(t, &buf)
+	var got []string
+	for _, r := range records {
+		got = append(got, r["level"].(string)+" "+r["msg"].(string)+" "+r[LogKeyAgent].(string))
+		metadata, _ := r["metadata"].(map[string]interface{})
+		if metadata["trace_id"] != "t-1" {
+			t.Errorf("Expected request metadata on every record, got %v", r)
+		}
+	}
+	want := "DEBUG agent call started tracked,INFO agent call completed tracked,DEBUG agent call started failing,ERROR agent call failed failing"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+	if records[3][LogKeyError] != "upstream down" || records[3][LogKeyDuration] == nil {
+		t.Errorf("Expected the error and duration, got %v", records[3])
+	}
+}
+
+func TestLoggerDefaultsToDiscarding(t *testing.T) {
+	logger := Logger(context.Background())
+	if logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Expected the default logger to discard records")
+	}
+}
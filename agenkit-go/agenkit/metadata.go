@@ -0,0 +1,47 @@
+package agenkit
+
+import "context"
+
+// metadataKey is the context key for request-scoped metadata.
+type metadataKey struct{}
+
+// WithMetadata returns a context carrying metadata, such as trace or user
+// IDs, merged over any metadata already in ctx.
+//
+// Metadata flows to every agent called with the returned context, so
+// patterns and middleware pass it to their children unchanged, and spans,
+// events, LoggingMiddleware and MetricsMiddleware record it. The maps are
+// copied: adding keys for a child's context never changes what the parent
+// sees, and later changes to metadata do not affect the context.
+func WithMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	parent := metadataValue(ctx)
+	merged := make(map[string]string, len(parent)+len(metadata))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// MetadataFrom returns a copy of the metadata in ctx, or nil if there is
+// none.
+func MetadataFrom(ctx context.Context) map[string]string {
+	metadata := metadataValue(ctx)
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		result[k] = v
+	}
+	return result
+}
+
+// metadataValue returns the metadata map stored in ctx. It must not be
+// modified.
+func metadataValue(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(metadataKey{}).(map[string]string)
+	return metadata
+}
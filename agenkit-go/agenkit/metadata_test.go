@@ -0,0 +1,55 @@
+package agenkit
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMetadataCopyOnWrite(t *testing.T) {
+	input := map[string]string{"trace_id": "t-1"}
+	parent := WithMetadata(context.Background(), input)
+	input["trace_id"] = "changed"
+
+	child := WithMetadata(parent, map[string]string{"user_id": "u-7"})
+	MetadataFrom(child)["trace_id"] = "mutated"
+
+	if got := MetadataFrom(parent); len(got) != 1 || got["trace_id"] != "t-1" {
+		t.Errorf("Expected the parent's metadata unchanged, got %v", got)
+	}
+	if got := MetadataFrom(child); got["trace_id"] != "t-1" || got["user_id"] != "u-7" {
+		t.Errorf("Expected the child to see inherited and added keys, got %v", got)
+	}
+	if MetadataFrom(context.Background()) != nil {
+		t.Error("Expected nil metadata for a bare context")
+	}
+}
+
+func TestMetadataRecordedOnSpansAndEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	bus := NewEventBus()
+	sub := bus.Subscribe(0)
+	ctx := WithTracerProvider(context.Background(), sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	ctx = WithMetadata(WithEventSink(ctx, bus), map[string]string{"trace_id": "t-1"})
+
+	if _, err := ProcessWithSpan(ctx, &echoAgent{}, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("ProcessWithSpan failed: %v", err)
+	}
+
+	found := false
+	for _, attr := range recorder.Ended()[0].Attributes() {
+		if attr.Key == "metadata.trace_id" && attr.Value.AsString() == "t-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a metadata.trace_id span attribute, got %v", recorder.Ended()[0].Attributes())
+	}
+	for _, e := range drainEvents(sub) {
+		if e.Metadata["trace_id"] != "t-1" {
+			t.Errorf("Expected %s event to carry the metadata, got %v", e.Type, e.Metadata)
+		}
+	}
+}
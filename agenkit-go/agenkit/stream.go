@@ -0,0 +1,115 @@
+package agenkit
+
+import (
+	"context"
+	"strings"
+)
+
+// StreamChunk is a single increment of a streamed agent response.
+type StreamChunk struct {
+	// Delta is the incremental text produced since the previous chunk.
+	Delta string `json:"delta,omitempty"`
+
+	// ToolCall is set when the chunk carries a completed tool call.
+	ToolCall *ToolCall `json:"tool_call,omitempty"`
+
+	// ToolCallDelta is set when the chunk carries a fragment of a tool
+	// call the model is still producing.
+	ToolCallDelta *ToolCallDelta `json:"tool_call_delta,omitempty"`
+
+	// Done marks the terminal chunk of the stream.
+	Done bool `json:"done,omitempty"`
+
+	// Message is the final assembled response, set on the terminal chunk.
+	Message *Message `json:"message,omitempty"`
+
+	// Err is set on the terminal chunk when the stream failed.
+	Err error `json:"-"`
+}
+
+// ToolCallDelta is a fragment of a streamed tool call. Fragments of one
+// call share an Index, numbering the calls of a response from 0, so calls
+// streamed interleaved can be told apart. ID and Name are set on the
+// first fragment of a call; ArgumentsDelta continues its JSON arguments,
+// which are only valid once the call is complete.
+type ToolCallDelta struct {
+	Index          int    `json:"index"`
+	ID             string `json:"id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	ArgumentsDelta string `json:"arguments_delta,omitempty"`
+}
+
+// SendChunk delivers a chunk, giving up if ctx is cancelled first.
+// It reports whether the chunk was delivered. Once ctx is cancelled no
+// chunk is delivered, even to a reader that is still receiving.
+func SendChunk(ctx context.Context, out chan<- StreamChunk, chunk StreamChunk) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ProcessStream streams a response from any agent.
+//
+// Agents implementing ChunkStreamingAgent are streamed natively. Other agents
+// are processed normally and their response is delivered as a single delta
+// followed by the terminal chunk, so callers can treat every agent uniformly.
+func ProcessStream(ctx context.Context, agent Agent, message *Message) (<-chan StreamChunk, error) {
+	if streamer, ok := agent.(ChunkStreamingAgent); ok {
+		return streamer.ProcessStream(ctx, message)
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+
+		response, err := agent.Process(ctx, message)
+		if err != nil {
+			SendChunk(ctx, out, StreamChunk{Done: true, Err: err})
+			return
+		}
+		if response != nil && response.Content != "" {
+			if !SendChunk(ctx, out, StreamChunk{Delta: response.Content}) {
+				return
+			}
+		}
+		SendChunk(ctx, out, StreamChunk{Done: true, Message: response})
+	}()
+	return out, nil
+}
+
+// CollectStream drains a chunk stream and returns the final message.
+//
+// If the stream ends without a terminal message, the deltas received so far
+// are assembled into one. If ctx is cancelled, its error is returned.
+func CollectStream(ctx context.Context, chunks <-chan StreamChunk) (*Message, error) {
+	var content strings.Builder
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				return NewMessage("agent", content.String()), nil
+			}
+			if chunk.Err != nil {
+				return nil, chunk.Err
+			}
+			content.WriteString(chunk.Delta)
+			if chunk.Done {
+				if chunk.Message != nil {
+					return chunk.Message, nil
+				}
+				return NewMessage("agent", content.String()), nil
+			}
+		}
+	}
+}
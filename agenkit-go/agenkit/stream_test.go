@@ -0,0 +1,118 @@
+package agenkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type echoAgent struct {
+	err error
+}
+
+func (e *echoAgent) Name() string           { return "echo" }
+func (e *echoAgent) Capabilities() []string { return nil }
+
+func (e *echoAgent) Process(ctx context.Context, message *Message) (*Message, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return NewMessage("agent", message.Content), nil
+}
+
+func TestProcessStreamBuffersNonStreamingAgent(t *testing.T) {
+	ctx := context.Background()
+	chunks, err := ProcessStream(ctx, &echoAgent{}, NewMessage("user", "hello"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+
+	var received []StreamChunk
+	for chunk := range chunks {
+		received = append(received, chunk)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("Expected delta and terminal chunk, got %d chunks", len(received))
+	}
+	if received[0].Delta != "hello" {
+		t.Errorf("Expected delta 'hello', got '%s'", received[0].Delta)
+	}
+	if !received[1].Done || received[1].Message == nil || received[1].Message.Content != "hello" {
+		t.Errorf("Expected terminal chunk with final message, got %+v", received[1])
+	}
+}
+
+func TestProcessStreamDeliversErrorInFinalChunk(t *testing.T) {
+	ctx := context.Background()
+	chunks, err := ProcessStream(ctx, &echoAgent{err: errors.New("boom")}, NewMessage("user", "hello"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+
+	_, err = CollectStream(ctx, chunks)
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("Expected 'boom' error, got %v", err)
+	}
+}
+
+// emptyAgent returns neither a response nor an error.
+type emptyAgent struct{}
+
+func (e *emptyAgent) Name() string           { return "empty" }
+func (e *emptyAgent) Capabilities() []string { return nil }
+
+func (e *emptyAgent) Process(ctx context.Context, message *Message) (*Message, error) {
+	return nil, nil
+}
+
+func TestProcessStreamNilResponse(t *testing.T) {
+	chunks, _ := ProcessStream(context.Background(), &emptyAgent{}, NewMessage("user", "hello"))
+
+	var received []StreamChunk
+	for chunk := range chunks {
+		received = append(received, chunk)
+	}
+	if len(received) != 1 || !received[0].Done || received[0].Message != nil {
+		t.Errorf("Expected only an empty terminal chunk, got %+v", received)
+	}
+}
+
+func TestProcessStreamClosesOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, _ := ProcessStream(ctx, &echoAgent{}, NewMessage("user", "hello"))
+	cancel()
+
+	select {
+	case <-drain(chunks):
+	case <-time.After(time.Second):
+		t.Fatal("Stream was not closed after cancellation")
+	}
+}
+
+func TestCollectStreamAssemblesDeltas(t *testing.T) {
+	chunks := make(chan StreamChunk, 3)
+	chunks <- StreamChunk{Delta: "foo"}
+	chunks <- StreamChunk{Delta: "bar"}
+	chunks <- StreamChunk{Done: true}
+	close(chunks)
+
+	msg, err := CollectStream(context.Background(), chunks)
+	if err != nil {
+		t.Fatalf("CollectStream failed: %v", err)
+	}
+	if msg.Content != "foobar" {
+		t.Errorf("Expected 'foobar', got '%s'", msg.Content)
+	}
+}
+
+func drain(chunks <-chan StreamChunk) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range chunks {
+		}
+		close(done)
+	}()
+	return done
+}
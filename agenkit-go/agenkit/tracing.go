@@ -0,0 +1,120 @@
+package agenkit
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope used for agenkit spans.
+const tracerName = "github.com/agenkit/agenkit-go"
+
+// tracerProviderKey is the context key for the tracer provider.
+type tracerProviderKey struct{}
+
+// WithTracerProvider returns a context in which agents and composition
+// patterns record OpenTelemetry spans using tp.
+//
+// Tracing is opt-in: without a provider in context, spans are no-ops and
+// no OpenTelemetry SDK is required.
+func WithTracerProvider(ctx context.Context, tp trace.TracerProvider) context.Context {
+	return context.WithValue(ctx, tracerProviderKey{}, tp)
+}
+
+// TracerProviderFromContext returns the context's tracer provider, or a
+// no-op provider if none is set.
+func TracerProviderFromContext(ctx context.Context) trace.TracerProvider {
+	if tp, ok := ctx.Value(tracerProviderKey{}).(trace.TracerProvider); ok && tp != nil {
+		return tp
+	}
+	return noop.NewTracerProvider()
+}
+
+// StartSpan starts a span using the context's tracer provider.
+// The returned context carries the span, so spans started from it nest
+// beneath it. Metadata in ctx (see WithMetadata) is recorded as
+// "metadata.<key>" attributes.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := TracerProviderFromContext(ctx).Tracer(tracerName)
+	if metadata := metadataValue(ctx); len(metadata) > 0 {
+		// Copy before appending so the caller's slice is left untouched
+		attrs = attrs[:len(attrs):len(attrs)]
+		for k, v := range metadata {
+			attrs = append(attrs, attribute.String("metadata."+k, v))
+		}
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+// ProcessWithSpan calls agent.Process inside an "agent.<name>.process" span.
+// Patterns use it to invoke child agents so each child appears as a child
+// span of the pattern. It also reports the child's call as TrackAgent does,
+// publishing EventAgentStarted and EventAgentFinished events if the context
+// has an EventSink, logging it if the context has a logger, and recording
+// it if tracing is enabled.
+func ProcessWithSpan(ctx context.Context, agent Agent, message *Message, attrs ...attribute.KeyValue) (*Message, error) {
+	attrs = append([]attribute.KeyValue{attribute.String("agent.name", agent.Name())}, attrs...)
+	ctx, span := StartSpan(ctx, fmt.Sprintf("agent.%s.process", agent.Name()), attrs...)
+	ctx, finish := TrackAgent(ctx, agent.Name(), message)
+	if EventSinkFromContext(ctx) != nil || loggerValue(ctx) != nil || TraceFromContext(ctx) != nil {
+		// The child's own TrackAgent call must not report it a second time
+		ctx = context.WithValue(ctx, announcedAgentKey{}, agent.Name())
+	}
+	response, err := agent.Process(ctx, message)
+	finish(response, err)
+	EndSpan(span, err)
+	return response, err
+}
+
+// ProcessStreamWithSpan streams a response from agent as ProcessStream
+// does, reporting the call as ProcessWithSpan does. The call ends with the
+// stream's terminal chunk, or when ctx is cancelled.
+func ProcessStreamWithSpan(ctx context.Context, agent Agent, message *Message, attrs ...attribute.KeyValue) (<-chan StreamChunk, error) {
+	attrs = append([]attribute.KeyValue{attribute.String("agent.name", agent.Name())}, attrs...)
+	ctx, span := StartSpan(ctx, fmt.Sprintf("agent.%s.process", agent.Name()), attrs...)
+	ctx, finish := TrackAgent(ctx, agent.Name(), message)
+	if EventSinkFromContext(ctx) != nil || loggerValue(ctx) != nil || TraceFromContext(ctx) != nil {
+		ctx = context.WithValue(ctx, announcedAgentKey{}, agent.Name())
+	}
+	chunks, err := ProcessStream(ctx, agent, message)
+	if err != nil {
+		finish(nil, err)
+		EndSpan(span, err)
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		for chunk := range chunks {
+			if chunk.Done {
+				// End the call before the reader can see the terminal chunk
+				finish(chunk.Message, chunk.Err)
+				EndSpan(span, chunk.Err)
+				SendChunk(ctx, out, chunk)
+				return
+			}
+			if !SendChunk(ctx, out, chunk) {
+				break
+			}
+		}
+		finish(nil, ctx.Err())
+		EndSpan(span, ctx.Err())
+	}()
+	return out, nil
+}
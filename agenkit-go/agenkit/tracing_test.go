@@ -0,0 +1,46 @@
+package agenkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartSpanNoopWithoutProvider(t *testing.T) {
+	_, span := StartSpan(context.Background(), "test")
+	defer span.End()
+
+	if span.IsRecording() {
+		t.Error("Expected no-op span when no tracer provider is in context")
+	}
+}
+
+func TestProcessWithSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	ctx := WithTracerProvider(context.Background(), sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	if _, err := ProcessWithSpan(ctx, &echoAgent{}, NewMessage("user", "hi")); err != nil {
+		t.Fatalf("ProcessWithSpan failed: %v", err)
+	}
+	if _, err := ProcessWithSpan(ctx, &echoAgent{err: errors.New("boom")}, NewMessage("user", "hi")); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "agent.echo.process" {
+		t.Errorf("Expected span name 'agent.echo.process', got '%s'", spans[0].Name())
+	}
+	if spans[0].Status().Code != codes.Ok {
+		t.Errorf("Expected Ok status, got %v", spans[0].Status().Code)
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("Expected Error status, got %v", spans[1].Status().Code)
+	}
+}
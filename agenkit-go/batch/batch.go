@@ -0,0 +1,131 @@
+// Package batch pushes many messages through an agent with bounded
+// concurrency.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrSkipped marks inputs that were never processed because the batch
+// stopped early, through cancellation or FailFast.
+var ErrSkipped = errors.New("skipped: batch stopped before this input was processed")
+
+// BatchOptions configures a batch run.
+type BatchOptions struct {
+	// Concurrency is the number of inputs processed at once.
+	// Default: 4
+	Concurrency int
+
+	// OnProgress, if set, is called after each input finishes, successfully
+	// or not, with the number finished so far. Calls are serialized.
+	OnProgress func(done, total int)
+
+	// FailFast stops the batch at the first failure. In-flight inputs are
+	// cancelled and the remaining inputs are skipped.
+	FailFast bool
+}
+
+// Result is the outcome of one input.
+type Result struct {
+	// Index is the position of the input in the batch.
+	Index int
+
+	// Input is the message that was processed.
+	Input *agenkit.Message
+
+	// Output is the agent's response, if it succeeded.
+	Output *agenkit.Message
+
+	// Err is the agent's error, or ErrSkipped if the input never ran.
+	Err error
+}
+
+// BatchRunner processes batches of messages through an agent. The zero
+// value is ready to use.
+type BatchRunner struct{}
+
+// NewBatchRunner creates a batch runner.
+func NewBatchRunner() *BatchRunner {
+	return &BatchRunner{}
+}
+
+// Run processes every input through agent and returns one Result per input,
+// in input order.
+//
+// Individual failures are recorded in their Result and do not stop the
+// batch unless FailFast is set, in which case Run returns the first failure.
+// Inputs are handed to workers only as they free up, so a cancelled context
+// stops new work immediately; Run waits for in-flight inputs, then returns
+// the results so far along with the context's error.
+func (b *BatchRunner) Run(ctx context.Context, agent agenkit.Agent, inputs []*agenkit.Message, opts BatchOptions) ([]Result, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	concurrency = min(concurrency, max(len(inputs), 1))
+
+	results := make([]Result, len(inputs))
+	for i, input := range inputs {
+		results[i] = Result{Index: i, Input: input, Err: ErrSkipped}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		done     int
+		firstErr error
+	)
+	finish := func(i int, output *agenkit.Message, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[i].Output = output
+		results[i].Err = err
+		done++
+		if err != nil && opts.FailFast && firstErr == nil {
+			firstErr = fmt.Errorf("batch input %d failed: %w", i, err)
+			cancel()
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(done, len(inputs))
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				output, err := agent.Process(runCtx, inputs[i])
+				finish(i, output, err)
+			}
+		}()
+	}
+
+feed:
+	for i := range inputs {
+		select {
+		case jobs <- i:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
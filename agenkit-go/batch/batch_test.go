@@ -0,0 +1,142 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// upperAgent uppercases its input after a delay, tracking peak concurrency.
+type upperAgent struct {
+	active atomic.Int64
+	peak   atomic.Int64
+	fail   string
+	delay  time.Duration
+}
+
+func (u *upperAgent) Name() string           { return "upper" }
+func (u *upperAgent) Capabilities() []string { return nil }
+func (u *upperAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	n := u.active.Add(1)
+	defer u.active.Add(-1)
+	for {
+		peak := u.peak.Load()
+		if n <= peak || u.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(u.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if message.Content == u.fail {
+		return nil, errors.New("cannot process " + message.Content)
+	}
+	return agenkit.NewMessage("agent", strings.ToUpper(message.Content)), nil
+}
+
+func inputs(n int) []*agenkit.Message {
+	messages := make([]*agenkit.Message, n)
+	for i := range messages {
+		messages[i] = agenkit.NewMessage("user", fmt.Sprintf("doc%d", i))
+	}
+	return messages
+}
+
+func TestBatchRunnerPreservesOrder(t *testing.T) {
+	agent := &upperAgent{delay: time.Millisecond}
+
+	var progress []int
+	results, err := NewBatchRunner().Run(context.Background(), agent, inputs(20), BatchOptions{
+		Concurrency: 5,
+		OnProgress:  func(done, total int) { progress = append(progress, done) },
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for i, result := range results {
+		if result.Index != i || result.Output.Content != fmt.Sprintf("DOC%d", i) {
+			t.Errorf("Result %d: Expected DOC%d, got %+v", i, i, result)
+		}
+	}
+	if len(progress) != 20 || progress[19] != 20 {
+		t.Errorf("Expected 20 progress reports ending at 20, got %v", progress)
+	}
+	if peak := agent.peak.Load(); peak > 5 {
+		t.Errorf("Expected at most 5 concurrent calls, got %d", peak)
+	}
+}
+
+func TestBatchRunnerCapturesFailures(t *testing.T) {
+	results, err := NewBatchRunner().Run(context.Background(), &upperAgent{fail: "doc3"}, inputs(6), BatchOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Expected failures to be captured per result, got %v", err)
+	}
+	if results[3].Err == nil {
+		t.Error("Expected failure on input 3")
+	}
+	for i, result := range results {
+		if i != 3 && result.Err != nil {
+			t.Errorf("Result %d: Expected success, got %v", i, result.Err)
+		}
+	}
+}
+
+func TestBatchRunnerFailFast(t *testing.T) {
+	results, err := NewBatchRunner().Run(context.Background(), &upperAgent{fail: "doc0", delay: time.Millisecond}, inputs(50), BatchOptions{
+		Concurrency: 2,
+		FailFast:    true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "batch input 0 failed") {
+		t.Fatalf("Expected first failure, got %v", err)
+	}
+	if !errors.Is(results[49].Err, ErrSkipped) {
+		t.Errorf("Expected later inputs to be skipped, got %v", results[49].Err)
+	}
+}
+
+func TestBatchRunnerCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	agent := &upperAgent{delay: 10 * time.Millisecond}
+
+	var completed atomic.Int64
+	time.AfterFunc(25*time.Millisecond, cancel)
+	results, err := NewBatchRunner().Run(ctx, agent, inputs(100), BatchOptions{
+		Concurrency: 2,
+		OnProgress:  func(done, total int) { completed.Store(int64(done)) },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if agent.active.Load() != 0 {
+		t.Error("Expected no in-flight calls after Run returned")
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Err == nil {
+			succeeded++
+		}
+	}
+	if succeeded == 0 || succeeded >= 100 {
+		t.Errorf("Expected some but not all inputs to complete, got %d", succeeded)
+	}
+	if !errors.Is(results[99].Err, ErrSkipped) {
+		t.Errorf("Expected unprocessed inputs to be skipped, got %v", results[99].Err)
+	}
+}
+
+func TestBatchRunnerEmpty(t *testing.T) {
+	results, err := NewBatchRunner().Run(context.Background(), &upperAgent{}, nil, BatchOptions{})
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected empty results, got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,197 @@
+package composition
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Cache stores responses by key. Implementations of llm.Cache, such as
+// llm.LRUCache, satisfy it.
+type Cache interface {
+	// Get returns the cached message for key, if present and unexpired.
+	Get(key string) (*agenkit.Message, bool)
+
+	// Set stores message under key. A ttl of zero or less never expires.
+	Set(key string, message *agenkit.Message, ttl time.Duration)
+}
+
+// CachedAgent caches the complete responses of an agent subtree, such as
+// a pipeline of deterministic LLM calls, so a repeated input skips the
+// whole subtree instead of only its individual model calls.
+//
+// Inputs are matched by a key function, which can normalize them; the
+// default hashes the role, content and attachments. Keys are namespaced by
+// the inner agent's name. A hit returns a copy of the cached response with
+// "cache_hit" set in its metadata, without running the subtree. Failed
+// runs are not cached.
+type CachedAgent struct {
+	inner agenkit.Agent
+	cache Cache
+	keyFn func(message *agenkit.Message) string
+	ttl   time.Duration
+}
+
+// Verify that CachedAgent implements Agent interface.
+var _ agenkit.Agent = (*CachedAgent)(nil)
+
+// NewCachedAgent caches inner's responses in cache. A nil keyFn uses the
+// default key.
+//
+// It fails if any agent in inner's subtree reports itself
+// non-deterministic (see agenkit.DeterminismReporter), naming those
+// agents; MarkDeterministic overrides such reports where caching is known
+// to be safe. Middleware is searched through its Unwrap method, and
+// agents that report nothing are assumed deterministic.
+func NewCachedAgent(inner agenkit.Agent, cache Cache, keyFn func(message *agenkit.Message) string) (*CachedAgent, error) {
+	if inner == nil {
+		return nil, fmt.Errorf("cached agent requires an inner agent")
+	}
+	if cache == nil {
+		return nil, fmt.Errorf("cached agent requires a cache")
+	}
+	if names := nonDeterministicAgents(inner); len(names) > 0 {
+		return nil, fmt.Errorf("cannot cache %s: non-deterministic agents in subtree: %s", inner.Name(), strings.Join(names, ", "))
+	}
+	if keyFn == nil {
+		keyFn = defaultCacheKey
+	}
+	return &CachedAgent{inner: inner, cache: cache, keyFn: keyFn}, nil
+}
+
+// SetTTL sets how long responses stay cached. Default: 0 (never expire).
+func (c *CachedAgent) SetTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+// Name returns the inner agent's name.
+func (c *CachedAgent) Name() string {
+	return c.inner.Name()
+}
+
+// Capabilities returns the inner agent's capabilities.
+func (c *CachedAgent) Capabilities() []string {
+	return c.inner.Capabilities()
+}
+
+// Unwrap returns the inner agent.
+func (c *CachedAgent) Unwrap() agenkit.Agent {
+	return c.inner
+}
+
+// Process returns the cached response for message, or runs the subtree
+// and caches its response.
+func (c *CachedAgent) Process(ctx context.Context, message *agenkit.Message) (response *agenkit.Message, err error) {
+	key := c.inner.Name() + ":" + c.keyFn(message)
+	cached, hit := c.cache.Get(key)
+	ctx, span := agenkit.StartSpan(ctx, "pattern.cached",
+		attribute.String("agent.name", c.inner.Name()),
+		attribute.String("pattern.type", "cached"),
+		attribute.Bool("cache.hit", hit),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+
+	if hit {
+		response := copyResponse(cached)
+		response.Metadata["cache_hit"] = true
+		return response, nil
+	}
+
+	response, err = agenkit.ProcessWithSpan(ctx, c.inner, message)
+	if err != nil {
+		return nil, err
+	}
+	if response != nil {
+		c.cache.Set(key, copyResponse(response), c.ttl)
+	}
+	return response, nil
+}
+
+// defaultCacheKey hashes the message's role, content and attachments.
+func defaultCacheKey(message *agenkit.Message) string {
+	data, _ := json.Marshal(struct {
+		Role        string               `json:"role"`
+		Content     string               `json:"content"`
+		Attachments []agenkit.Attachment `json:"attachments,omitempty"`
+	}{message.Role, message.Content, message.Attachments})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// copyResponse returns a copy of message with its own metadata map.
+func copyResponse(message *agenkit.Message) *agenkit.Message {
+	copied := *message
+	copied.Metadata = make(map[string]interface{}, len(message.Metadata)+1)
+	for k, v := range message.Metadata {
+		copied.Metadata[k] = v
+	}
+	return &copied
+}
+
+// nonDeterministicAgents returns the names of the agents in root's subtree
+// that report themselves non-deterministic. The subtree of such an agent,
+// or of one marked deterministic, is not searched further.
+func nonDeterministicAgents(root agenkit.Agent) []string {
+	var names []string
+	visited := make(map[agenkit.Agent]bool)
+	var walk func(agent agenkit.Agent)
+	walk = func(agent agenkit.Agent) {
+		if agent == nil {
+			return
+		}
+		if reflect.TypeOf(agent).Comparable() {
+			if visited[agent] {
+				return
+			}
+			visited[agent] = true
+		}
+		if reporter, ok := agent.(agenkit.DeterminismReporter); ok {
+			if !reporter.Deterministic() {
+				names = append(names, agent.Name())
+				return
+			}
+			if _, vouched := agent.(*deterministicMark); vouched {
+				return
+			}
+		}
+		for _, child := range childAgents(agent) {
+			walk(child)
+		}
+	}
+	walk(root)
+	return names
+}
+
+// deterministicMark overrides what an agent reports about its determinism;
+// see MarkDeterministic.
+type deterministicMark struct {
+	agenkit.Agent
+	deterministic bool
+}
+
+// Deterministic returns the marked determinism.
+func (m *deterministicMark) Deterministic() bool {
+	return m.deterministic
+}
+
+// Unwrap returns the marked agent.
+func (m *deterministicMark) Unwrap() agenkit.Agent {
+	return m.Agent
+}
+
+// MarkDeterministic returns agent annotated as deterministic or not, for
+// agents that cannot report it themselves, such as ones calling external
+// services, or to vouch for a subtree whose members report otherwise. The
+// annotation covers everything agent delegates to.
+func MarkDeterministic(agent agenkit.Agent, deterministic bool) agenkit.Agent {
+	return &deterministicMark{Agent: agent, deterministic: deterministic}
+}
@@ -0,0 +1,95 @@
+package composition
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/middleware"
+	"github.com/agenkit/agenkit-go/reasoning"
+)
+
+// sampledAgent reports whether it is deterministic.
+type sampledAgent struct {
+	TestAgent
+	deterministic bool
+}
+
+func (s *sampledAgent) Deterministic() bool { return s.deterministic }
+
+func TestCachedAgentSkipsSubtreeOnHit(t *testing.T) {
+	first := &TestAgent{name: "first", response: "draft"}
+	second := &sampledAgent{TestAgent: TestAgent{name: "second", response: "final"}, deterministic: true}
+	pipeline, _ := NewSequentialAgent("pipeline", first, second)
+
+	normalize := func(m *agenkit.Message) string { return strings.ToLower(strings.TrimSpace(m.Content)) }
+	cached, err := NewCachedAgent(pipeline, llm.NewLRUCache(10), normalize)
+	if err != nil {
+		t.Fatalf("NewCachedAgent failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := cached.Process(ctx, agenkit.NewMessage("user", "Hello")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	response, err := cached.Process(ctx, agenkit.NewMessage("user", " hello "))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "final" || response.Metadata["cache_hit"] != true {
+		t.Errorf("Expected the cached response, got '%s' %v", response.Content, response.Metadata)
+	}
+	if first.calls != 1 || second.calls != 1 {
+		t.Errorf("Expected the subtree to run once, got %d and %d calls", first.calls, second.calls)
+	}
+
+	// Cached responses are copies
+	response.Metadata["changed"] = true
+	again, _ := cached.Process(ctx, agenkit.NewMessage("user", "hello"))
+	if _, ok := again.Metadata["changed"]; ok {
+		t.Error("Expected changes to a hit not to reach the cache")
+	}
+}
+
+func TestCachedAgentRefusesNonDeterministicSubtrees(t *testing.T) {
+	sampled := &sampledAgent{TestAgent: TestAgent{name: "creative"}}
+	pipeline, _ := NewSequentialAgent("pipeline", &TestAgent{name: "plain"}, sampled)
+
+	_, err := NewCachedAgent(pipeline, llm.NewLRUCache(10), nil)
+	if err == nil || !strings.Contains(err.Error(), "creative") {
+		t.Fatalf("Expected the non-deterministic agent to be named, got %v", err)
+	}
+
+	vouched, _ := NewSequentialAgent("pipeline", &TestAgent{name: "plain"}, MarkDeterministic(sampled, true))
+	if _, err := NewCachedAgent(vouched, llm.NewLRUCache(10), nil); err != nil {
+		t.Errorf("Expected MarkDeterministic to allow caching, got %v", err)
+	}
+	external, _ := NewSequentialAgent("pipeline", MarkDeterministic(&TestAgent{name: "search"}, false))
+	if _, err := NewCachedAgent(external, llm.NewLRUCache(10), nil); err == nil {
+		t.Error("Expected an agent marked non-deterministic to prevent caching")
+	}
+}
+
+func TestCachedAgentSearchesWrappersAndTechniques(t *testing.T) {
+	sampled := &sampledAgent{TestAgent: TestAgent{name: "creative"}}
+	retried := middleware.NewRetryDecorator(sampled, middleware.RetryConfig{})
+	if _, err := NewCachedAgent(retried, llm.NewLRUCache(10), nil); err == nil {
+		t.Error("Expected middleware not to hide a non-deterministic agent")
+	}
+
+	tree, _ := reasoning.NewTreeOfThought("tot", sampled, reasoning.TreeOfThoughtConfig{})
+	if _, err := NewCachedAgent(tree, llm.NewLRUCache(10), nil); err == nil {
+		t.Error("Expected a technique not to hide its non-deterministic model")
+	}
+
+	hot, _ := reasoning.NewSelfConsistency("vote", &TestAgent{name: "chain"}, reasoning.SelfConsistencyConfig{Temperature: 0.8})
+	if _, err := NewCachedAgent(hot, llm.NewLRUCache(10), nil); err == nil || !strings.Contains(err.Error(), "vote") {
+		t.Errorf("Expected self-consistency sampling at a raised temperature to be refused, got %v", err)
+	}
+	cold, _ := reasoning.NewSelfConsistency("vote", &TestAgent{name: "chain"}, reasoning.SelfConsistencyConfig{})
+	if _, err := NewCachedAgent(cold, llm.NewLRUCache(10), nil); err != nil {
+		t.Errorf("Expected self-consistency over a deterministic chain to be cached, got %v", err)
+	}
+}
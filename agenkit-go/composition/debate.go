@@ -0,0 +1,239 @@
+package composition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// DebatePosition is one agent's answer in one round of a debate.
+type DebatePosition struct {
+	AgentName string `json:"agent_name"`
+	Content   string `json:"content"`
+}
+
+// DebateAgent has several agents argue toward a better answer.
+//
+// In the opening round every agent answers the question independently. In
+// each of the following rounds, every agent is shown the other agents'
+// positions from the previous round and revises its own; agents within a
+// round run concurrently. After the final round, the judge selects or
+// synthesizes the answer from the final positions. Without a judge, the
+// majority position wins, using the agreement function to group positions
+// (ties go to the position held by the earliest agent).
+//
+// The response metadata records:
+//
+//   - "debate_transcript": the [][]DebatePosition for every round, opening first
+//   - "debate_rounds": the number of revision rounds
+//   - "debate_majority": the size of the winning group, when there is no judge
+type DebateAgent struct {
+	name   string
+	agents []agenkit.Agent
+	rounds int
+	judge  agenkit.Agent
+	agree  func(a, b *agenkit.Message) bool
+}
+
+// Verify that DebateAgent implements Agent interface.
+var _ agenkit.Agent = (*DebateAgent)(nil)
+
+// NewDebateAgent creates a debate between agents over rounds revision
+// rounds, decided by judge. The judge may be nil.
+func NewDebateAgent(name string, agents []agenkit.Agent, rounds int, judge agenkit.Agent) (*DebateAgent, error) {
+	if len(agents) < 2 {
+		return nil, fmt.Errorf("debate requires at least two agents, got %d", len(agents))
+	}
+	if rounds < 0 {
+		return nil, fmt.Errorf("rounds must be non-negative, got %d", rounds)
+	}
+	return &DebateAgent{
+		name:   name,
+		agents: agents,
+		rounds: rounds,
+		judge:  judge,
+		agree:  sameContent,
+	}, nil
+}
+
+// SetAgreement sets how positions are compared when picking the majority
+// without a judge. Default: equal trimmed content.
+func (d *DebateAgent) SetAgreement(agree func(a, b *agenkit.Message) bool) {
+	if agree != nil {
+		d.agree = agree
+	}
+}
+
+// Name returns the name of the debate agent.
+func (d *DebateAgent) Name() string {
+	return d.name
+}
+
+// Capabilities returns combined capabilities of all agents.
+func (d *DebateAgent) Capabilities() []string {
+	capsSet := make(map[string]bool)
+	for _, agent := range d.agents {
+		for _, cap := range agent.Capabilities() {
+			capsSet[cap] = true
+		}
+	}
+
+	caps := make([]string, 0, len(capsSet))
+	for cap := range capsSet {
+		caps = append(caps, cap)
+	}
+	caps = append(caps, "debate")
+	return caps
+}
+
+// Process runs the debate and returns the decided answer.
+func (d *DebateAgent) Process(ctx context.Context, message *agenkit.Message) (response *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "pattern.debate",
+		attribute.String("agent.name", d.name),
+		attribute.String("pattern.type", "debate"),
+		attribute.Int("pattern.branches", len(d.agents)),
+		attribute.Int("pattern.rounds", d.rounds),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, d.name, message)
+	defer func() { finish(response, err) }()
+
+	positions, err := d.runRound(ctx, 0, func(int) *agenkit.Message { return message })
+	if err != nil {
+		return nil, err
+	}
+	transcript := [][]*agenkit.Message{positions}
+
+	for round := 1; round <= d.rounds; round++ {
+		previous := positions
+		positions, err = d.runRound(ctx, round, func(i int) *agenkit.Message {
+			return agenkit.NewMessage(message.Role, d.buildRevisionPrompt(message.Content, i, previous))
+		})
+		if err != nil {
+			return nil, err
+		}
+		transcript = append(transcript, positions)
+	}
+
+	if d.judge != nil {
+		response, err = agenkit.ProcessWithSpan(ctx, d.judge, agenkit.NewMessage("user", d.buildJudgePrompt(message.Content, positions)),
+			attribute.String("debate.role", "judge"))
+		if err != nil {
+			return nil, fmt.Errorf("debate judge %s failed: %w", d.judge.Name(), err)
+		}
+	} else {
+		var size int
+		response, size = d.majority(positions)
+		response.Metadata["debate_majority"] = size
+	}
+
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
+	}
+	response.Metadata["debate_transcript"] = d.recordTranscript(transcript)
+	response.Metadata["debate_rounds"] = d.rounds
+	return response, nil
+}
+
+// runRound asks every agent for its position concurrently. input builds the
+// message for the agent at each index.
+func (d *DebateAgent) runRound(ctx context.Context, round int, input func(i int) *agenkit.Message) ([]*agenkit.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("debate cancelled at round %d: %w", round, err)
+	}
+
+	positions := make([]*agenkit.Message, len(d.agents))
+	errs := make([]error, len(d.agents))
+	var wg sync.WaitGroup
+	for i, agent := range d.agents {
+		wg.Add(1)
+		go func(i int, agent agenkit.Agent) {
+			defer wg.Done()
+			positions[i], errs[i] = agenkit.ProcessWithSpan(ctx, agent, input(i), attribute.Int("debate.round", round))
+		}(i, agent)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("debate round %d: agent %s failed: %w", round, d.agents[i].Name(), err)
+		}
+	}
+	return positions, nil
+}
+
+// majority returns a copy of the position held by the largest group of
+// agreeing agents, and the group's size.
+func (d *DebateAgent) majority(positions []*agenkit.Message) (*agenkit.Message, int) {
+	best, bestSize := 0, 0
+	for i, candidate := range positions {
+		size := 0
+		for _, other := range positions {
+			if d.agree(candidate, other) {
+				size++
+			}
+		}
+		if size > bestSize {
+			best, bestSize = i, size
+		}
+	}
+
+	winner := *positions[best]
+	winner.Metadata = make(map[string]interface{}, len(positions[best].Metadata)+3)
+	for k, v := range positions[best].Metadata {
+		winner.Metadata[k] = v
+	}
+	return &winner, bestSize
+}
+
+// buildRevisionPrompt shows agent i its previous position and the others'.
+func (d *DebateAgent) buildRevisionPrompt(question string, i int, previous []*agenkit.Message) string {
+	var sb strings.Builder
+	sb.WriteString(question)
+	sb.WriteString("\n\nYour previous answer was:\n")
+	sb.WriteString(previous[i].Content)
+	sb.WriteString("\n\nOther participants answered:\n")
+	for j, position := range previous {
+		if j == i {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n[%s]: %s\n", d.agents[j].Name(), position.Content))
+	}
+	sb.WriteString("\nConsider their reasoning, then give your revised answer. Keep your position if you still believe it is correct.")
+	return sb.String()
+}
+
+// buildJudgePrompt asks the judge to decide from the final positions.
+func (d *DebateAgent) buildJudgePrompt(question string, positions []*agenkit.Message) string {
+	var sb strings.Builder
+	sb.WriteString("Several participants debated the question below. Select the best final answer, or synthesize a better one from their positions. Reply with the answer only.\n\n")
+	sb.WriteString("Question:\n")
+	sb.WriteString(question)
+	sb.WriteString("\n\nFinal positions:\n")
+	for i, position := range positions {
+		sb.WriteString(fmt.Sprintf("\n[%s]: %s\n", d.agents[i].Name(), position.Content))
+	}
+	return sb.String()
+}
+
+// recordTranscript converts the rounds of messages into positions.
+func (d *DebateAgent) recordTranscript(rounds [][]*agenkit.Message) [][]DebatePosition {
+	transcript := make([][]DebatePosition, len(rounds))
+	for r, positions := range rounds {
+		transcript[r] = make([]DebatePosition, len(positions))
+		for i, position := range positions {
+			transcript[r][i] = DebatePosition{AgentName: d.agents[i].Name(), Content: position.Content}
+		}
+	}
+	return transcript
+}
+
+// GetAgents returns the debating agents.
+func (d *DebateAgent) GetAgents() []agenkit.Agent {
+	return d.agents
+}
@@ -0,0 +1,130 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// barrierAgent only answers once every participant in the round has started,
+// so a debate that ran agents sequentially would time out.
+type barrierAgent struct {
+	name    string
+	barrier *sync.WaitGroup
+}
+
+func (b *barrierAgent) Name() string           { return b.name }
+func (b *barrierAgent) Capabilities() []string { return nil }
+func (b *barrierAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	b.barrier.Done()
+	done := make(chan struct{})
+	go func() { b.barrier.Wait(); close(done) }()
+	select {
+	case <-done:
+		return agenkit.NewMessage("agent", "agreed"), nil
+	case <-time.After(time.Second):
+		return nil, errors.New("participants did not run concurrently")
+	}
+}
+
+func TestNewDebateAgentValidation(t *testing.T) {
+	if _, err := NewDebateAgent("debate", []agenkit.Agent{testutil.NewMockAgent(t, "solo")}, 1, nil); err == nil {
+		t.Error("Expected error with fewer than two agents")
+	}
+	a, b := testutil.NewMockAgent(t, "a"), testutil.NewMockAgent(t, "b")
+	if _, err := NewDebateAgent("debate", []agenkit.Agent{a, b}, -1, nil); err == nil {
+		t.Error("Expected error for negative rounds")
+	}
+}
+
+func TestDebateAgentRevisesAndJudges(t *testing.T) {
+	a := testutil.NewMockAgent(t, "a")
+	a.Expect("What is 6*7?", "42")
+	a.Expect("", "42")
+	b := testutil.NewMockAgent(t, "b")
+	b.Expect("What is 6*7?", "48")
+	b.Expect("", "42, I was wrong")
+	judge := testutil.NewMockAgent(t, "judge")
+	judge.Expect("", "42")
+
+	debate, _ := NewDebateAgent("debate", []agenkit.Agent{a, b}, 1, judge)
+	response, err := debate.Process(context.Background(), agenkit.NewMessage("user", "What is 6*7?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "42" {
+		t.Errorf("Expected judge's answer '42', got '%s'", response.Content)
+	}
+
+	revision := b.Calls()[1].Content
+	if !strings.Contains(revision, "Your previous answer was:\n48") || !strings.Contains(revision, "[a]: 42") {
+		t.Errorf("Expected revision prompt with own and others' positions, got:\n%s", revision)
+	}
+	if judging := judge.Calls()[0].Content; !strings.Contains(judging, "[b]: 42, I was wrong") {
+		t.Errorf("Expected judge to see final positions, got:\n%s", judging)
+	}
+
+	transcript := response.Metadata["debate_transcript"].([][]DebatePosition)
+	if len(transcript) != 2 || transcript[0][1].Content != "48" || transcript[1][1].Content != "42, I was wrong" {
+		t.Errorf("Expected transcript of both rounds, got %+v", transcript)
+	}
+	a.AssertExpectationsMet()
+	b.AssertExpectationsMet()
+}
+
+func TestDebateAgentMajorityWithoutJudge(t *testing.T) {
+	agents := make([]agenkit.Agent, 3)
+	for i, answer := range []string{"Paris", "paris", "Lyon"} {
+		agent := testutil.NewMockAgent(t, string(rune('a'+i)))
+		agent.Expect("", answer)
+		agents[i] = agent
+	}
+
+	debate, _ := NewDebateAgent("debate", agents, 0, nil)
+	debate.SetAgreement(func(x, y *agenkit.Message) bool { return strings.EqualFold(x.Content, y.Content) })
+
+	response, err := debate.Process(context.Background(), agenkit.NewMessage("user", "Capital of France?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "Paris" {
+		t.Errorf("Expected majority position 'Paris', got '%s'", response.Content)
+	}
+	if response.Metadata["debate_majority"] != 2 {
+		t.Errorf("Expected majority of 2, got %v", response.Metadata["debate_majority"])
+	}
+}
+
+func TestDebateAgentRunsRoundConcurrently(t *testing.T) {
+	var barrier sync.WaitGroup
+	barrier.Add(3)
+	agents := []agenkit.Agent{
+		&barrierAgent{name: "a", barrier: &barrier},
+		&barrierAgent{name: "b", barrier: &barrier},
+		&barrierAgent{name: "c", barrier: &barrier},
+	}
+
+	debate, _ := NewDebateAgent("debate", agents, 0, nil)
+	if _, err := debate.Process(context.Background(), agenkit.NewMessage("user", "?")); err != nil {
+		t.Fatalf("Expected agents in a round to run concurrently, got: %v", err)
+	}
+}
+
+func TestDebateAgentParticipantError(t *testing.T) {
+	a := testutil.NewMockAgent(t, "a")
+	a.Expect("", "fine")
+	b := testutil.NewMockAgent(t, "b")
+	b.Expect("", "").WithError(errors.New("boom"))
+
+	debate, _ := NewDebateAgent("debate", []agenkit.Agent{a, b}, 2, nil)
+	_, err := debate.Process(context.Background(), agenkit.NewMessage("user", "?"))
+	if err == nil || !strings.Contains(err.Error(), "agent b failed: boom") {
+		t.Fatalf("Expected participant error, got %v", err)
+	}
+}
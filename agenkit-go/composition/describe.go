@@ -0,0 +1,197 @@
+package composition
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Description summarizes what an agent tree supports. It is produced by
+// Describe, and every node describes its whole subtree.
+type Description struct {
+	// Name is the agent's name.
+	Name string `json:"name"`
+
+	// Type is the pattern the agent implements, as in PlanNode.Type.
+	Type string `json:"type"`
+
+	// Capabilities are the agent's own capability markers.
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Tools are the tools available anywhere in the subtree, sorted by
+	// name.
+	Tools []ToolDescription `json:"tools,omitempty"`
+
+	// Streaming is set when the agent streams its reply as it is
+	// generated. A SequentialAgent streams when its last stage does.
+	Streaming bool `json:"streaming"`
+
+	// Deterministic is set unless an agent in the subtree reports itself
+	// non-deterministic (see agenkit.DeterminismReporter), as in
+	// CachedAgent.
+	Deterministic bool `json:"deterministic"`
+
+	// Models are the models requested anywhere in the subtree, sorted.
+	Models []string `json:"models,omitempty"`
+
+	// Children describe the agents the node delegates to.
+	Children []*Description `json:"children,omitempty"`
+}
+
+// ToolDescription describes one tool available to an agent tree.
+type ToolDescription struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema,omitempty"`
+}
+
+// Describe walks agent's tree and describes what it supports: the tools
+// available, whether it streams, whether it is deterministic, which models
+// it needs, and how its patterns nest. Like DryRun, it runs nothing.
+//
+// Middleware is looked through as in DryRun, contributing the tools it
+// adds. Agents report their models by implementing Model() string, as
+// llm.Agent does. An agent that contains itself is listed again as a
+// "cycle" node without children.
+func Describe(agent agenkit.Agent) *Description {
+	d := &describer{visiting: make(map[agenkit.Agent]bool)}
+	return d.describe(agent)
+}
+
+// describer holds the state of one Describe walk.
+type describer struct {
+	visiting map[agenkit.Agent]bool
+}
+
+// describe describes agent and its subtree.
+func (d *describer) describe(agent agenkit.Agent) *Description {
+	outer := agent
+	tools := make(map[string]ToolDescription)
+	var reported *bool
+	vouched := false
+	for {
+		if user, ok := agent.(agenkit.ToolUser); ok {
+			addTools(tools, user.Tools())
+		}
+		if reporter, ok := agent.(agenkit.DeterminismReporter); ok && reported == nil {
+			deterministic := reporter.Deterministic()
+			reported = &deterministic
+			_, vouched = agent.(*deterministicMark)
+		}
+		wrapper, ok := agent.(interface{ Unwrap() agenkit.Agent })
+		if !ok {
+			break
+		}
+		agent = wrapper.Unwrap()
+	}
+
+	desc := &Description{
+		Name:          agent.Name(),
+		Type:          patternType(agent),
+		Capabilities:  agent.Capabilities(),
+		Deterministic: true,
+	}
+	models := make(map[string]bool)
+	if m, ok := agent.(interface{ Model() string }); ok && m.Model() != "" {
+		models[m.Model()] = true
+	}
+
+	cycle := false
+	if reflect.TypeOf(agent).Comparable() {
+		if d.visiting[agent] {
+			desc.Type = "cycle"
+			cycle = true
+		} else {
+			d.visiting[agent] = true
+			defer delete(d.visiting, agent)
+		}
+	}
+	if !cycle {
+		for _, child := range childAgents(agent) {
+			if child == nil {
+				continue
+			}
+			childDesc := d.describe(child)
+			desc.Children = append(desc.Children, childDesc)
+			desc.Deterministic = desc.Deterministic && childDesc.Deterministic
+			for _, tool := range childDesc.Tools {
+				tools[tool.Name] = tool
+			}
+			for _, model := range childDesc.Models {
+				models[model] = true
+			}
+		}
+	}
+	if reported != nil && (vouched || !*reported) {
+		desc.Deterministic = *reported
+	}
+
+	switch a := agent.(type) {
+	case *SequentialAgent:
+		// Only the last stage is streamed
+		desc.Streaming = outer == agent && len(desc.Children) == len(a.agents) && desc.Children[len(desc.Children)-1].Streaming
+	default:
+		_, chunks := outer.(agenkit.ChunkStreamingAgent)
+		_, messages := outer.(agenkit.StreamingAgent)
+		desc.Streaming = chunks || messages
+	}
+
+	for _, name := range sortedKeys(tools) {
+		desc.Tools = append(desc.Tools, tools[name])
+	}
+	for _, model := range sortedKeys(models) {
+		desc.Models = append(desc.Models, model)
+	}
+	return desc
+}
+
+// addTools records tools by name.
+func addTools(into map[string]ToolDescription, tools []agenkit.Tool) {
+	for _, tool := range tools {
+		td := ToolDescription{Name: tool.Name(), Description: tool.Description()}
+		if s, ok := tool.(interface{ InputSchema() map[string]any }); ok {
+			td.Schema = s.InputSchema()
+		}
+		into[td.Name] = td
+	}
+}
+
+// patternType names the pattern agent implements, as PlanNode.Type does.
+func patternType(agent agenkit.Agent) string {
+	switch agent.(type) {
+	case *SequentialAgent:
+		return "sequential"
+	case *TransformAgent:
+		return "transform"
+	case *ParallelAgent:
+		return "parallel"
+	case *FallbackAgent:
+		return "fallback"
+	case *EnsembleAgent:
+		return "ensemble"
+	case *RetryAgent:
+		return "retry"
+	case *LoopAgent:
+		return "loop"
+	case *MapReduceAgent:
+		return "mapreduce"
+	case *DebateAgent:
+		return "debate"
+	case *RouterAgent:
+		return "router"
+	case *ConditionalAgent:
+		return "conditional"
+	}
+	return "agent"
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
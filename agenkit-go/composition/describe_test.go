@@ -0,0 +1,77 @@
+package composition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/middleware"
+	"github.com/agenkit/agenkit-go/reasoning"
+)
+
+// modelProvider is a provider that is never called.
+type modelProvider string
+
+func (m modelProvider) Model() string { return string(m) }
+
+func (m modelProvider) Complete(ctx context.Context, request *llm.Request) (*llm.Response, error) {
+	return nil, nil
+}
+
+func TestDescribeAggregatesChildren(t *testing.T) {
+	research := &toolAgent{TestAgent: TestAgent{name: "research"}, tools: []agenkit.Tool{namedTool("web_search")}}
+	writer := llm.NewAgent("writer", modelProvider("model-a"), llm.AgentConfig{Temperature: 0.7})
+	pipeline, _ := NewSequentialAgent("pipeline", research, writer)
+
+	desc := Describe(pipeline)
+	if desc.Type != "sequential" || len(desc.Children) != 2 {
+		t.Fatalf("Expected a sequential node with two children, got %+v", desc)
+	}
+	if len(desc.Tools) != 1 || desc.Tools[0].Name != "web_search" {
+		t.Errorf("Expected the children's tools, got %+v", desc.Tools)
+	}
+	if len(desc.Models) != 1 || desc.Models[0] != "model-a" {
+		t.Errorf("Expected the writer's model, got %v", desc.Models)
+	}
+	if !desc.Streaming {
+		t.Error("Expected the pipeline to stream through its last stage")
+	}
+	if desc.Deterministic {
+		t.Error("Expected a sampling writer to make the pipeline non-deterministic")
+	}
+
+	// A last stage that does not stream stops the pipeline streaming
+	reversed, _ := NewSequentialAgent("reversed", writer, research)
+	if desc := Describe(reversed); desc.Streaming || desc.Children[0].Type != "agent" {
+		t.Errorf("Expected a non-streaming pipeline, got %+v", desc)
+	}
+}
+
+func TestDescribeLooksThroughMiddleware(t *testing.T) {
+	writer := llm.NewAgent("writer", modelProvider("model-b"), llm.AgentConfig{})
+	wrapped := middleware.Serialize(writer)
+	parallel, _ := NewParallelAgent("both", wrapped, &TestAgent{name: "plain"})
+
+	desc := Describe(parallel)
+	if desc.Type != "parallel" || desc.Children[0].Name != "writer" || !desc.Deterministic {
+		t.Errorf("Expected the wrapped writer to be described, got %+v", desc.Children[0])
+	}
+	if desc.Children[0].Streaming {
+		t.Error("Expected middleware that does not stream to hide streaming")
+	}
+
+	hot := llm.NewAgent("writer", modelProvider("model-b"), llm.AgentConfig{Temperature: 0.9})
+	if desc := Describe(middleware.NewRetryDecorator(hot, middleware.RetryConfig{})); desc.Deterministic {
+		t.Error("Expected a retried sampling writer to be non-deterministic")
+	}
+	vote, _ := reasoning.NewSelfConsistency("vote", hot, reasoning.SelfConsistencyConfig{})
+	if desc := Describe(vote); desc.Deterministic || len(desc.Children) != 1 {
+		t.Errorf("Expected the technique's sampling chain to be described, got %+v", desc)
+	}
+
+	marked := Describe(MarkDeterministic(parallel, false))
+	if marked.Deterministic || marked.Name != "both" {
+		t.Errorf("Expected the mark to override the tree, got %+v", marked)
+	}
+}
@@ -0,0 +1,364 @@
+package composition
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ExecutionPlan describes which agents and tools running an agent tree
+// would involve. It is produced by DryRun.
+type ExecutionPlan struct {
+	// Root is the agent DryRun was given.
+	Root *PlanNode `json:"root"`
+}
+
+// PlanNode is one agent in an ExecutionPlan.
+type PlanNode struct {
+	// Name is the agent's name.
+	Name string `json:"name"`
+
+	// Type is the pattern the agent implements, as in PipelineSpec
+	// ("sequential", "router", ...), plus "conditional", "loop",
+	// "mapreduce", "ensemble" and "transform". Other agents are "agent"
+	// leaves.
+	Type string `json:"type"`
+
+	// Label describes the node's role in its parent: "classifier",
+	// "judge", a route label or "default". Empty for ordinary children.
+	Label string `json:"label,omitempty"`
+
+	// Tools lists the names of the tools the agent makes available,
+	// sorted.
+	Tools []string `json:"tools,omitempty"`
+
+	// MaxRuns is how many times the children may run, for retry and loop
+	// nodes.
+	MaxRuns int `json:"max_runs,omitempty"`
+
+	// Children are the agents the node runs.
+	Children []*PlanNode `json:"children,omitempty"`
+
+	// Conditional is set when the branch taken depends on data only
+	// known at run time, such as a classifier's reply. Exactly one of
+	// Branches runs.
+	Conditional bool `json:"conditional,omitempty"`
+
+	// Branches are the agents a conditional node may hand the message to.
+	Branches []*PlanNode `json:"branches,omitempty"`
+}
+
+// DryRun plans how agent would handle message without running it.
+//
+// The agent tree is walked statically: no agent's Process is called, so no
+// provider requests are made and no tools are executed. Middleware added
+// with middleware.Wrap is looked through, and tools are reported for agents
+// implementing agenkit.ToolUser. A ConditionalAgent that receives message
+// itself is resolved by evaluating its conditions; one that receives
+// another agent's output, and every RouterAgent, is marked Conditional with
+// all of its routes listed as Branches. An agent that contains itself is
+// listed again as a "cycle" node without children.
+//
+// DryRun returns an error if ctx is done or if message matches no route
+// of a ConditionalAgent that has no default.
+func DryRun(ctx context.Context, agent agenkit.Agent, message *agenkit.Message) (ExecutionPlan, error) {
+	p := &planner{ctx: ctx, visiting: make(map[agenkit.Agent]bool)}
+	root, err := p.plan(agent, message)
+	if err != nil {
+		return ExecutionPlan{}, err
+	}
+	return ExecutionPlan{Root: root}, nil
+}
+
+// Agents returns the names of every agent in the plan, in tree order
+// without duplicates.
+func (p ExecutionPlan) Agents() []string {
+	var names []string
+	seen := make(map[string]bool)
+	p.walk(func(node *PlanNode) {
+		if !seen[node.Name] {
+			seen[node.Name] = true
+			names = append(names, node.Name)
+		}
+	})
+	return names
+}
+
+// Tools returns the names of every tool available anywhere in the plan,
+// sorted.
+func (p ExecutionPlan) Tools() []string {
+	seen := make(map[string]bool)
+	p.walk(func(node *PlanNode) {
+		for _, tool := range node.Tools {
+			seen[tool] = true
+		}
+	})
+	tools := make([]string, 0, len(seen))
+	for tool := range seen {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// String renders the plan as an indented tree, one agent per line.
+func (p ExecutionPlan) String() string {
+	var sb strings.Builder
+	var write func(node *PlanNode, depth int)
+	write = func(node *PlanNode, depth int) {
+		sb.WriteString(strings.Repeat("  ", depth))
+		if node.Label != "" {
+			sb.WriteString("[" + node.Label + "] ")
+		}
+		fmt.Fprintf(&sb, "%s (%s)", node.Name, node.Type)
+		if node.MaxRuns > 0 {
+			fmt.Fprintf(&sb, " up to %d runs", node.MaxRuns)
+		}
+		if node.Conditional {
+			sb.WriteString(" conditional")
+		}
+		if len(node.Tools) > 0 {
+			sb.WriteString(" tools: " + strings.Join(node.Tools, ", "))
+		}
+		sb.WriteString("\n")
+		for _, child := range node.Children {
+			write(child, depth+1)
+		}
+		for _, branch := range node.Branches {
+			write(branch, depth+1)
+		}
+	}
+	if p.Root != nil {
+		write(p.Root, 0)
+	}
+	return sb.String()
+}
+
+// walk calls fn for every node in the plan, parents first.
+func (p ExecutionPlan) walk(fn func(node *PlanNode)) {
+	var visit func(node *PlanNode)
+	visit = func(node *PlanNode) {
+		fn(node)
+		for _, child := range node.Children {
+			visit(child)
+		}
+		for _, branch := range node.Branches {
+			visit(branch)
+		}
+	}
+	if p.Root != nil {
+		visit(p.Root)
+	}
+}
+
+// planner holds the state of one DryRun walk.
+type planner struct {
+	ctx      context.Context
+	visiting map[agenkit.Agent]bool
+}
+
+// plan describes agent. input is the message the agent would receive, or
+// nil when it depends on other agents' output.
+func (p *planner) plan(agent agenkit.Agent, input *agenkit.Message) (*PlanNode, error) {
+	if err := p.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("dry run cancelled: %w", err)
+	}
+
+	// Look through middleware, keeping the tools wrappers add
+	var tools []agenkit.Tool
+	for {
+		if user, ok := agent.(agenkit.ToolUser); ok {
+			tools = append(tools, user.Tools()...)
+		}
+		wrapper, ok := agent.(interface{ Unwrap() agenkit.Agent })
+		if !ok {
+			break
+		}
+		agent = wrapper.Unwrap()
+	}
+	node := &PlanNode{Name: agent.Name(), Type: "agent", Tools: toolNames(tools)}
+
+	// Agents of non-comparable types cannot be tracked, but such values
+	// cannot contain themselves either
+	if reflect.TypeOf(agent).Comparable() {
+		if p.visiting[agent] {
+			node.Type = "cycle"
+			return node, nil
+		}
+		p.visiting[agent] = true
+		defer delete(p.visiting, agent)
+	}
+
+	children := func(agents []agenkit.Agent, input func(i int) *agenkit.Message) error {
+		for i, child := range agents {
+			plan, err := p.plan(child, input(i))
+			if err != nil {
+				return err
+			}
+			node.Children = append(node.Children, plan)
+		}
+		return nil
+	}
+	same := func(int) *agenkit.Message { return input }
+	unknown := func(int) *agenkit.Message { return nil }
+	labelled := func(agent agenkit.Agent, label string, input *agenkit.Message) (*PlanNode, error) {
+		plan, err := p.plan(agent, input)
+		if err != nil {
+			return nil, err
+		}
+		plan.Label = label
+		return plan, nil
+	}
+
+	var err error
+	switch a := agent.(type) {
+	case *SequentialAgent:
+		node.Type = "sequential"
+		err = children(a.agents, func(i int) *agenkit.Message {
+			if i == 0 {
+				return input
+			}
+			return nil
+		})
+
+	case *TransformAgent:
+		node.Type = "transform"
+
+	case *ParallelAgent:
+		node.Type = "parallel"
+		err = children(a.agents, same)
+
+	case *FallbackAgent:
+		node.Type = "fallback"
+		err = children(a.agents, same)
+
+	case *EnsembleAgent:
+		node.Type = "ensemble"
+		err = children(a.agents, same)
+
+	case *RetryAgent:
+		node.Type = "retry"
+		node.MaxRuns = a.options.MaxAttempts
+		err = children([]agenkit.Agent{a.agent}, same)
+
+	case *LoopAgent:
+		node.Type = "loop"
+		node.MaxRuns = a.maxIterations
+		err = children([]agenkit.Agent{a.body}, unknown)
+
+	case *MapReduceAgent:
+		node.Type = "mapreduce"
+		err = children([]agenkit.Agent{a.mapper}, unknown)
+
+	case *DebateAgent:
+		node.Type = "debate"
+		if err = children(a.agents, unknown); err == nil && a.judge != nil {
+			var judge *PlanNode
+			if judge, err = labelled(a.judge, "judge", nil); err == nil {
+				node.Children = append(node.Children, judge)
+			}
+		}
+
+	case *RouterAgent:
+		node.Type = "router"
+		node.Conditional = true
+		var classifier *PlanNode
+		if classifier, err = labelled(a.classifier, "classifier", input); err != nil {
+			return nil, err
+		}
+		node.Children = []*PlanNode{classifier}
+		for _, label := range sortedRoutes(a.routes) {
+			branch, err := labelled(a.routes[label], label, input)
+			if err != nil {
+				return nil, err
+			}
+			node.Branches = append(node.Branches, branch)
+		}
+		if a.defaultAgent != nil {
+			var branch *PlanNode
+			if branch, err = labelled(a.defaultAgent, "default", input); err == nil {
+				node.Branches = append(node.Branches, branch)
+			}
+		}
+
+	case *ConditionalAgent:
+		node.Type = "conditional"
+		err = p.planConditional(node, a, input, labelled)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// planConditional fills node for a ConditionalAgent, resolving the route
+// when the input is known.
+func (p *planner) planConditional(node *PlanNode, c *ConditionalAgent, input *agenkit.Message,
+	labelled func(agent agenkit.Agent, label string, input *agenkit.Message) (*PlanNode, error)) error {
+	if input != nil {
+		label, agent := "default", c.defaultAgent
+		for i, route := range c.routes {
+			if route.Condition(input) {
+				label, agent = fmt.Sprintf("route %d", i+1), route.Agent
+				break
+			}
+		}
+		if agent == nil {
+			return fmt.Errorf("conditional %s: no condition matched and no default agent configured", c.name)
+		}
+		child, err := labelled(agent, label, input)
+		if err != nil {
+			return err
+		}
+		node.Children = []*PlanNode{child}
+		return nil
+	}
+
+	node.Conditional = true
+	for i, route := range c.routes {
+		branch, err := labelled(route.Agent, fmt.Sprintf("route %d", i+1), nil)
+		if err != nil {
+			return err
+		}
+		node.Branches = append(node.Branches, branch)
+	}
+	if c.defaultAgent != nil {
+		branch, err := labelled(c.defaultAgent, "default", nil)
+		if err != nil {
+			return err
+		}
+		node.Branches = append(node.Branches, branch)
+	}
+	return nil
+}
+
+// toolNames returns the sorted, distinct names of tools.
+func toolNames(tools []agenkit.Tool) []string {
+	if len(tools) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(tools))
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if !seen[tool.Name()] {
+			seen[tool.Name()] = true
+			names = append(names, tool.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedRoutes returns the labels of routes in a stable order.
+func sortedRoutes(routes map[string]agenkit.Agent) []string {
+	labels := make([]string, 0, len(routes))
+	for label := range routes {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
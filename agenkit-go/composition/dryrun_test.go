@@ -0,0 +1,143 @@
+package composition
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/middleware"
+)
+
+// namedTool is a tool that does nothing.
+type namedTool string
+
+func (n namedTool) Name() string        { return string(n) }
+func (n namedTool) Description() string { return "does nothing" }
+
+func (n namedTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	return agenkit.NewToolResult(nil), nil
+}
+
+// toolAgent is a TestAgent offering tools.
+type toolAgent struct {
+	TestAgent
+	tools []agenkit.Tool
+}
+
+func (t *toolAgent) Tools() []agenkit.Tool { return t.tools }
+
+func TestDryRunWalksTreeWithoutCalls(t *testing.T) {
+	research := &toolAgent{TestAgent: TestAgent{name: "research"}, tools: []agenkit.Tool{namedTool("web_search"), namedTool("calculator")}}
+	writer := &TestAgent{name: "writer"}
+	editor := &TestAgent{name: "editor"}
+	retry, _ := NewRetryAgent(editor, RetryOptions{MaxAttempts: 2})
+	review, _ := NewParallelAgent("review", middleware.Serialize(writer), retry)
+	pipeline, _ := NewSequentialAgent("pipeline", research, review)
+
+	plan, err := DryRun(context.Background(), pipeline, agenkit.NewMessage("user", "write a report"))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if research.calls+writer.calls+editor.calls != 0 {
+		t.Error("Expected no agent to be called")
+	}
+	if got := plan.Agents(); !reflect.DeepEqual(got, []string{"pipeline", "research", "review", "writer", "editor"}) {
+		t.Errorf("Expected agents in tree order, got %v", got)
+	}
+	if got := plan.Tools(); !reflect.DeepEqual(got, []string{"calculator", "web_search"}) {
+		t.Errorf("Expected the research tools, got %v", got)
+	}
+
+	parallel := plan.Root.Children[1]
+	if parallel.Type != "parallel" || parallel.Children[0].Type != "agent" || parallel.Children[1].MaxRuns != 2 {
+		t.Errorf("Expected middleware looked through and retry attempts recorded, got %s", plan)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil || !strings.Contains(string(data), `"type":"sequential"`) {
+		t.Errorf("Expected the plan to serialize, got %s (%v)", data, err)
+	}
+}
+
+func TestDryRunMarksRoutersConditional(t *testing.T) {
+	classifier := &TestAgent{name: "classifier", response: "billing"}
+	router, _ := NewRouterAgent("support", classifier, map[string]agenkit.Agent{
+		"technical": &TestAgent{name: "tech"},
+		"billing":   &TestAgent{name: "billing"},
+	})
+	router.SetDefault(&TestAgent{name: "general"})
+
+	plan, err := DryRun(context.Background(), router, agenkit.NewMessage("user", "refund please"))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	root := plan.Root
+	if !root.Conditional || root.Children[0].Label != "classifier" {
+		t.Fatalf("Expected a conditional router with its classifier, got %s", plan)
+	}
+	var labels []string
+	for _, branch := range root.Branches {
+		labels = append(labels, branch.Label+"="+branch.Name)
+	}
+	if !reflect.DeepEqual(labels, []string{"billing=billing", "technical=tech", "default=general"}) {
+		t.Errorf("Expected every route listed, got %v", labels)
+	}
+	if classifier.calls != 0 {
+		t.Error("Expected the classifier not to be called")
+	}
+}
+
+func TestDryRunResolvesConditionsOnKnownInput(t *testing.T) {
+	conditional := NewConditionalAgent("triage", &TestAgent{name: "general"})
+	conditional.AddRoute(ContentContains("urgent"), &TestAgent{name: "oncall"})
+
+	plan, err := DryRun(context.Background(), conditional, agenkit.NewMessage("user", "urgent: site down"))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if plan.Root.Conditional || len(plan.Root.Children) != 1 || plan.Root.Children[0].Name != "oncall" {
+		t.Errorf("Expected the matching route resolved, got %s", plan)
+	}
+
+	// After another agent the input is unknown, so every route is possible
+	pipeline, _ := NewSequentialAgent("pipeline", &TestAgent{name: "rewrite"}, conditional)
+	plan, err = DryRun(context.Background(), pipeline, agenkit.NewMessage("user", "urgent: site down"))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if triage := plan.Root.Children[1]; !triage.Conditional || len(triage.Branches) != 2 {
+		t.Errorf("Expected an unresolved conditional with both branches, got %s", plan)
+	}
+
+	// A known input matching nothing would fail at run time
+	strict := NewConditionalAgent("strict", nil)
+	strict.AddRoute(ContentContains("urgent"), &TestAgent{name: "oncall"})
+	if _, err := DryRun(context.Background(), strict, agenkit.NewMessage("user", "hello")); err == nil {
+		t.Error("Expected an error when no route matches")
+	}
+}
+
+func TestDryRunHandlesCyclesAndCancellation(t *testing.T) {
+	router, _ := NewRouterAgent("router", &TestAgent{name: "classifier"}, map[string]agenkit.Agent{"done": &TestAgent{name: "done"}})
+	router.SetDefault(router)
+
+	plan, err := DryRun(context.Background(), router, agenkit.NewMessage("user", "x"))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if last := plan.Root.Branches[1]; last.Type != "cycle" || last.Name != "router" {
+		t.Errorf("Expected the self-reference listed as a cycle, got %s", plan)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := DryRun(ctx, router, agenkit.NewMessage("user", "x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package composition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// EnsembleResult is the outcome of one ensemble member.
+type EnsembleResult struct {
+	AgentName string
+	Message   *agenkit.Message
+	Error     error
+	Duration  time.Duration
+}
+
+// EnsembleReduceFunc combines the members' results, given in member
+// order, into one message. Failed members are included with Error set, so
+// the reducer decides whether a partial set is good enough.
+type EnsembleReduceFunc func(ctx context.Context, results []EnsembleResult) (*agenkit.Message, error)
+
+// EnsembleAgent runs several agents on the same input and combines their
+// responses with a reducer, such as ConcatResults, BestResult or
+// SynthesizeResults.
+//
+// Unlike ParallelAgent, member failures do not fail the run; they are
+// handed to the reducer. The response metadata records
+// "ensemble_durations", each member's duration keyed by agent name, to help
+// judge which members are worth keeping.
+type EnsembleAgent struct {
+	name        string
+	agents      []agenkit.Agent
+	reduce      EnsembleReduceFunc
+	concurrency int
+}
+
+// Verify that EnsembleAgent implements Agent interface.
+var _ agenkit.Agent = (*EnsembleAgent)(nil)
+
+// NewEnsembleAgent creates an ensemble of agents combined by reduce.
+func NewEnsembleAgent(name string, agents []agenkit.Agent, reduce EnsembleReduceFunc) (*EnsembleAgent, error) {
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("ensemble requires at least one agent")
+	}
+	if reduce == nil {
+		return nil, fmt.Errorf("ensemble requires a reduce function")
+	}
+	return &EnsembleAgent{
+		name:   name,
+		agents: agents,
+		reduce: reduce,
+	}, nil
+}
+
+// SetConcurrency caps the number of members running at once. Values below
+// one remove the cap. Default: 0 (every member at once).
+func (e *EnsembleAgent) SetConcurrency(n int) {
+	e.concurrency = n
+}
+
+// Name returns the name of the ensemble.
+func (e *EnsembleAgent) Name() string {
+	return e.name
+}
+
+// Capabilities returns the ensemble marker.
+func (e *EnsembleAgent) Capabilities() []string {
+	return []string{"ensemble"}
+}
+
+// GetAgents returns the ensemble's members.
+func (e *EnsembleAgent) GetAgents() []agenkit.Agent {
+	return e.agents
+}
+
+// Process runs every member and reduces their results.
+func (e *EnsembleAgent) Process(ctx context.Context, message *agenkit.Message) (response *agenkit.Message, err error) {
+	workers := len(e.agents)
+	if e.concurrency > 0 && e.concurrency < workers {
+		workers = e.concurrency
+	}
+	ctx, span := agenkit.StartSpan(ctx, "pattern.ensemble",
+		attribute.String("agent.name", e.name),
+		attribute.String("pattern.type", "ensemble"),
+		attribute.Int("pattern.branches", len(e.agents)),
+		attribute.Int("pattern.concurrency", workers),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, e.name, message)
+	defer func() { finish(response, err) }()
+
+	results := make([]EnsembleResult, len(e.agents))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				agent := e.agents[i]
+				start := time.Now()
+				output, err := agenkit.ProcessWithSpan(ctx, agent, message, attribute.Int("pattern.member", i))
+				results[i] = EnsembleResult{
+					AgentName: agent.Name(),
+					Message:   output,
+					Error:     err,
+					Duration:  time.Since(start),
+				}
+			}
+		}()
+	}
+	for i := range e.agents {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("ensemble cancelled: %w", err)
+	}
+
+	reduced, err := e.reduce(ctx, results)
+	if err != nil {
+		return nil, fmt.Errorf("ensemble reduce: %w", err)
+	}
+	if reduced == nil {
+		return nil, fmt.Errorf("reduce returned no message")
+	}
+
+	durations := make(map[string]time.Duration, len(results))
+	for _, result := range results {
+		durations[result.AgentName] = result.Duration
+	}
+	out := *reduced
+	out.Metadata = make(map[string]interface{}, len(reduced.Metadata)+1)
+	for k, v := range reduced.Metadata {
+		out.Metadata[k] = v
+	}
+	out.Metadata["ensemble_durations"] = durations
+	return &out, nil
+}
+
+// ensembleFailures reports the failed results when no member succeeded.
+func ensembleFailures(results []EnsembleResult) error {
+	var errs []string
+	for _, result := range results {
+		if result.Error == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", result.AgentName, result.Error))
+	}
+	return fmt.Errorf("every ensemble member failed: %s", strings.Join(errs, "; "))
+}
+
+// ConcatResults is an EnsembleReduceFunc that joins the successful
+// responses, labelled "[name]: content" as in ParallelAgent, skipping
+// failed members. It fails only if every member failed.
+func ConcatResults(ctx context.Context, results []EnsembleResult) (*agenkit.Message, error) {
+	if err := ensembleFailures(results); err != nil {
+		return nil, err
+	}
+	var parts []string
+	for _, result := range results {
+		if result.Error == nil && result.Message != nil {
+			parts = append(parts, fmt.Sprintf("[%s]: %s", result.AgentName, result.Message.Content))
+		}
+	}
+	return agenkit.NewMessage("agent", strings.Join(parts, "\n")), nil
+}
+
+// BestResult returns an EnsembleReduceFunc that picks the successful
+// response with the highest score, preferring earlier members on ties. The
+// winner is recorded in the "ensemble_winner" metadata.
+func BestResult(score func(message *agenkit.Message) float64) EnsembleReduceFunc {
+	return func(ctx context.Context, results []EnsembleResult) (*agenkit.Message, error) {
+		if err := ensembleFailures(results); err != nil {
+			return nil, err
+		}
+		best := -1
+		var bestScore float64
+		for i, result := range results {
+			if result.Error != nil || result.Message == nil {
+				continue
+			}
+			if s := score(result.Message); best < 0 || s > bestScore {
+				best, bestScore = i, s
+			}
+		}
+		if best < 0 {
+			return nil, fmt.Errorf("no ensemble member returned a message")
+		}
+		winner := *results[best].Message
+		winner.Metadata = make(map[string]interface{}, len(results[best].Message.Metadata)+1)
+		for k, v := range results[best].Message.Metadata {
+			winner.Metadata[k] = v
+		}
+		winner.Metadata["ensemble_winner"] = results[best].AgentName
+		return &winner, nil
+	}
+}
+
+// SynthesizeResults returns an EnsembleReduceFunc that sends the
+// successful responses, joined as by ConcatResults, to synthesizer and
+// returns its answer. It fails only if every member failed.
+func SynthesizeResults(synthesizer agenkit.Agent) EnsembleReduceFunc {
+	return func(ctx context.Context, results []EnsembleResult) (*agenkit.Message, error) {
+		joined, err := ConcatResults(ctx, results)
+		if err != nil {
+			return nil, err
+		}
+		joined.Role = "user"
+		return agenkit.ProcessWithSpan(ctx, synthesizer, joined)
+	}
+}
@@ -0,0 +1,93 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestEnsemblePassesFailuresToReducer(t *testing.T) {
+	agents := []agenkit.Agent{
+		&TestAgent{name: "a", response: "alpha"},
+		&TestAgent{name: "b", err: errors.New("b is down")},
+		&TestAgent{name: "c", response: "gamma", delay: 20 * time.Millisecond},
+	}
+	var seen []EnsembleResult
+	ensemble, err := NewEnsembleAgent("ensemble", agents, func(ctx context.Context, results []EnsembleResult) (*agenkit.Message, error) {
+		seen = results
+		return ConcatResults(ctx, results)
+	})
+	if err != nil {
+		t.Fatalf("NewEnsembleAgent failed: %v", err)
+	}
+
+	response, err := ensemble.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "[a]: alpha\n[c]: gamma" {
+		t.Errorf("Expected the successful responses in member order, got '%s'", response.Content)
+	}
+	if len(seen) != 3 || seen[1].AgentName != "b" || seen[1].Error == nil {
+		t.Errorf("Expected the failure to reach the reducer, got %+v", seen)
+	}
+	durations := response.Metadata["ensemble_durations"].(map[string]time.Duration)
+	if len(durations) != 3 || durations["c"] < 20*time.Millisecond {
+		t.Errorf("Expected per-member durations, got %v", durations)
+	}
+}
+
+func TestEnsembleConcurrencyCap(t *testing.T) {
+	mapper := &shardMapper{delay: func(string) time.Duration { return 10 * time.Millisecond }}
+	ensemble, _ := NewEnsembleAgent("ensemble", []agenkit.Agent{mapper, mapper, mapper, mapper}, ConcatResults)
+	ensemble.SetConcurrency(2)
+
+	if _, err := ensemble.Process(context.Background(), agenkit.NewMessage("user", "x")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if peak := mapper.peak.Load(); peak != 2 {
+		t.Errorf("Expected at most 2 members at once, got %d", peak)
+	}
+}
+
+func TestEnsembleReducers(t *testing.T) {
+	agents := []agenkit.Agent{
+		&TestAgent{name: "short", response: "ok"},
+		&TestAgent{name: "long", response: "a longer answer"},
+	}
+	best, _ := NewEnsembleAgent("best", agents, BestResult(func(m *agenkit.Message) float64 {
+		return float64(len(m.Content))
+	}))
+	response, err := best.Process(context.Background(), agenkit.NewMessage("user", "x"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "a longer answer" || response.Metadata["ensemble_winner"] != "long" {
+		t.Errorf("Expected the highest-scoring response, got '%s' %v", response.Content, response.Metadata)
+	}
+
+	synthesizer := &TestAgent{name: "synth", response: "combined"}
+	synth, _ := NewEnsembleAgent("synth", agents, SynthesizeResults(synthesizer))
+	if response, err := synth.Process(context.Background(), agenkit.NewMessage("user", "x")); err != nil || response.Content != "combined" {
+		t.Errorf("Expected the synthesizer's answer, got %v, %v", response, err)
+	}
+
+	failing := []agenkit.Agent{&TestAgent{name: "x", err: errors.New("boom")}}
+	all, _ := NewEnsembleAgent("all", failing, ConcatResults)
+	if _, err := all.Process(context.Background(), agenkit.NewMessage("user", "x")); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected every member failing to fail the run, got %v", err)
+	}
+}
+
+func TestEnsembleValidation(t *testing.T) {
+	if _, err := NewEnsembleAgent("e", nil, ConcatResults); err == nil {
+		t.Error("Expected an error without agents")
+	}
+	if _, err := NewEnsembleAgent("e", []agenkit.Agent{&TestAgent{name: "a"}}, nil); err == nil {
+		t.Error("Expected an error without a reducer")
+	}
+}
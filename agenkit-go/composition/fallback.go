@@ -2,17 +2,23 @@ package composition
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
 
 	"github.com/agenkit/agenkit-go/agenkit"
 )
 
 // FallbackAgent tries agents in order until one succeeds.
 // This implements the Fallback/Retry pattern for reliability.
+//
+// By default the next agent is tried only when the current one returns an
+// error; SetShouldFallback customizes that decision. Context cancellation
+// stops the cascade immediately.
 type FallbackAgent struct {
-	name   string
-	agents []agenkit.Agent
+	name           string
+	agents         []agenkit.Agent
+	shouldFallback func(*agenkit.Message, error) bool
 }
 
 // Verify that FallbackAgent implements Agent interface.
@@ -51,9 +57,21 @@ func (f *FallbackAgent) Capabilities() []string {
 	return caps
 }
 
+// SetShouldFallback sets the predicate deciding whether an attempt's outcome
+// should fall through to the next agent. It sees both the response and the
+// error, so a successful but unusable response can fall back too, and an
+// error it rejects is returned without trying further agents.
+// If unset, every error falls back and every response is accepted.
+func (f *FallbackAgent) SetShouldFallback(shouldFallback func(*agenkit.Message, error) bool) {
+	f.shouldFallback = shouldFallback
+}
+
 // Process tries each agent in order until one succeeds.
+//
+// If every agent fails, the returned error joins each attempt's error with
+// errors.Join, so errors.Is and errors.As see all of them.
 func (f *FallbackAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
-	var errors []string
+	var errs []error
 
 	for i, agent := range f.agents {
 		// Check context cancellation
@@ -65,19 +83,50 @@ func (f *FallbackAgent) Process(ctx context.Context, message *agenkit.Message) (
 
 		// Try this agent
 		result, err := agent.Process(ctx, message)
-		if err == nil {
+
+		// Don't move on to the next agent once the caller has given up
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("fallback execution cancelled at attempt %d: %w", i+1, ctxErr)
+		}
+
+		if !f.fallback(result, err) {
+			if err != nil {
+				return nil, fmt.Errorf("agent %d (%s): %w", i+1, agent.Name(), err)
+			}
+
 			// Success! Add metadata about which agent was used
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]interface{})
+			}
 			result.Metadata["fallback_agent_used"] = agent.Name()
 			result.Metadata["fallback_attempt"] = i + 1
 			return result, nil
 		}
 
 		// Record error and try next agent
-		errors = append(errors, fmt.Sprintf("agent %d (%s): %v", i+1, agent.Name(), err))
+		if err == nil {
+			err = fmt.Errorf("response rejected by fallback predicate")
+		}
+		errs = append(errs, fmt.Errorf("agent %d (%s): %w", i+1, agent.Name(), err))
+		agenkit.Logger(ctx).InfoContext(ctx, "falling back to next agent",
+			slog.String(agenkit.LogKeyPattern, "fallback"),
+			slog.String(agenkit.LogKeyAgent, f.name),
+			slog.Int("attempt", i+1),
+			slog.String("failed_agent", agent.Name()),
+			slog.String(agenkit.LogKeyError, err.Error()),
+		)
 	}
 
 	// All agents failed
-	return nil, fmt.Errorf("all %d agents failed: %s", len(f.agents), strings.Join(errors, "; "))
+	return nil, fmt.Errorf("all %d agents failed: %w", len(f.agents), errors.Join(errs...))
+}
+
+// fallback applies the configured predicate, defaulting to fallback-on-error.
+func (f *FallbackAgent) fallback(result *agenkit.Message, err error) bool {
+	if f.shouldFallback != nil {
+		return f.shouldFallback(result, err)
+	}
+	return err != nil
 }
 
 // GetAgents returns the list of fallback agents.
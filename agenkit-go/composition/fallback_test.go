@@ -0,0 +1,76 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestFallbackAgentJoinsErrors(t *testing.T) {
+	errPrimary := errors.New("primary down")
+	errBackup := errors.New("backup down")
+	fallback, _ := NewFallbackAgent("fallback",
+		&TestAgent{name: "primary", err: errPrimary},
+		&TestAgent{name: "backup", err: errBackup},
+	)
+
+	_, err := fallback.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if !errors.Is(err, errPrimary) || !errors.Is(err, errBackup) {
+		t.Errorf("Expected joined error to match every attempt, got: %v", err)
+	}
+}
+
+func TestFallbackAgentShouldFallbackOnResponse(t *testing.T) {
+	primary := &TestAgent{name: "primary", response: ""}
+	backup := &TestAgent{name: "backup", response: "answer"}
+	fallback, _ := NewFallbackAgent("fallback", primary, backup)
+	fallback.SetShouldFallback(func(resp *agenkit.Message, err error) bool {
+		return err != nil || resp.Content == ""
+	})
+
+	result, err := fallback.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "answer" {
+		t.Errorf("Expected backup's answer, got '%s'", result.Content)
+	}
+	if result.Metadata["fallback_agent_used"] != "backup" || result.Metadata["fallback_attempt"] != 2 {
+		t.Errorf("Expected metadata to name the backup, got %v", result.Metadata)
+	}
+}
+
+func TestFallbackAgentShouldFallbackRejectsError(t *testing.T) {
+	primary := &TestAgent{name: "primary", err: errors.New("invalid request")}
+	backup := &TestAgent{name: "backup", response: "answer"}
+	fallback, _ := NewFallbackAgent("fallback", primary, backup)
+	fallback.SetShouldFallback(func(resp *agenkit.Message, err error) bool { return false })
+
+	_, err := fallback.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil {
+		t.Fatal("Expected non-fallback error to be returned")
+	}
+	if backup.calls != 0 {
+		t.Errorf("Expected backup not to be called, got %d calls", backup.calls)
+	}
+}
+
+func TestFallbackAgentCancellationStopsCascade(t *testing.T) {
+	primary := &TestAgent{name: "primary", delay: time.Hour}
+	backup := &TestAgent{name: "backup", response: "answer"}
+	fallback, _ := NewFallbackAgent("fallback", primary, backup)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := fallback.Process(ctx, agenkit.NewMessage("user", "hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got: %v", err)
+	}
+	if backup.calls != 0 {
+		t.Errorf("Expected cancellation to stop before the backup, got %d calls", backup.calls)
+	}
+}
@@ -0,0 +1,134 @@
+package composition
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// LoopCondition decides, from the body's latest output, whether a loop
+// should run another iteration.
+type LoopCondition func(ctx context.Context, output *agenkit.Message) (bool, error)
+
+// LoopAgent runs an agent repeatedly, feeding each output back in as the
+// next input, while a condition holds.
+//
+// The body always runs at least once; the condition is checked after each
+// iteration and the loop stops when it returns false or after maxIterations
+// iterations, whichever comes first. The last output is returned, and its
+// metadata records:
+//
+//   - "loop_iterations": the number of iterations run
+//   - "loop_outputs": the []*agenkit.Message output of every iteration
+//   - "loop_limit_reached": whether the loop stopped at maxIterations while
+//     the condition still held
+type LoopAgent struct {
+	name          string
+	body          agenkit.Agent
+	condition     LoopCondition
+	maxIterations int
+}
+
+// Verify that LoopAgent implements Agent interface.
+var _ agenkit.Agent = (*LoopAgent)(nil)
+
+// NewLoopAgent creates a loop that runs body while condition holds, for at
+// most maxIterations iterations.
+func NewLoopAgent(name string, body agenkit.Agent, condition LoopCondition, maxIterations int) (*LoopAgent, error) {
+	if body == nil {
+		return nil, fmt.Errorf("loop agent requires a body agent")
+	}
+	if condition == nil {
+		return nil, fmt.Errorf("loop agent requires a condition")
+	}
+	if maxIterations <= 0 {
+		return nil, fmt.Errorf("max iterations must be positive, got %d", maxIterations)
+	}
+	return &LoopAgent{
+		name:          name,
+		body:          body,
+		condition:     condition,
+		maxIterations: maxIterations,
+	}, nil
+}
+
+// Name returns the name of the loop agent.
+func (l *LoopAgent) Name() string {
+	return l.name
+}
+
+// Capabilities returns the body's capabilities plus the loop marker.
+func (l *LoopAgent) Capabilities() []string {
+	return append(l.body.Capabilities(), "loop")
+}
+
+// Process runs the body until the condition fails or the cap is reached.
+func (l *LoopAgent) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "pattern.loop",
+		attribute.String("agent.name", l.name),
+		attribute.String("pattern.type", "loop"),
+		attribute.Int("pattern.max_iterations", l.maxIterations),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, l.name, message)
+	defer func() { finish(result, err) }()
+
+	current := message
+	var outputs []*agenkit.Message
+	limitReached := true
+
+	for i := 1; i <= l.maxIterations; i++ {
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("loop cancelled at iteration %d: %w", i, ctx.Err())
+		default:
+		}
+
+		output, err := agenkit.ProcessWithSpan(ctx, l.body, current, attribute.Int("pattern.iteration", i))
+		if err != nil {
+			return nil, fmt.Errorf("iteration %d (%s) failed: %w", i, l.body.Name(), err)
+		}
+		outputs = append(outputs, output)
+		current = output
+		agenkit.Logger(ctx).DebugContext(ctx, "loop iteration completed",
+			slog.String(agenkit.LogKeyPattern, "loop"),
+			slog.String(agenkit.LogKeyAgent, l.name),
+			slog.Int("iteration", i),
+		)
+
+		more, err := l.condition(ctx, output)
+		if err != nil {
+			return nil, fmt.Errorf("loop condition failed at iteration %d: %w", i, err)
+		}
+		if !more {
+			limitReached = false
+			break
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("pattern.iterations", len(outputs)),
+		attribute.Bool("pattern.limit_reached", limitReached),
+	)
+
+	result = &agenkit.Message{}
+	*result = *current
+	result.Metadata = make(map[string]interface{}, len(current.Metadata)+3)
+	for k, v := range current.Metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata["loop_iterations"] = len(outputs)
+	result.Metadata["loop_outputs"] = outputs
+	result.Metadata["loop_limit_reached"] = limitReached
+	return result, nil
+}
+
+// GetBody returns the agent run on each iteration.
+func (l *LoopAgent) GetBody() agenkit.Agent {
+	return l.body
+}
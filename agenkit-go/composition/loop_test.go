@@ -0,0 +1,121 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// untilContains keeps looping while the output lacks marker.
+func untilContains(marker string) LoopCondition {
+	return func(ctx context.Context, output *agenkit.Message) (bool, error) {
+		return !strings.Contains(output.Content, marker), nil
+	}
+}
+
+func TestLoopFeedsOutputBackUntilConditionFails(t *testing.T) {
+	body := testutil.NewMockAgent(t, "body")
+	body.Expect("draft", "draft v1")
+	body.Expect("draft v1", "draft v2")
+	body.Expect("draft v2", "draft v3 DONE")
+
+	loop, err := NewLoopAgent("loop", body, untilContains("DONE"), 5)
+	if err != nil {
+		t.Fatalf("Failed to create loop: %v", err)
+	}
+
+	result, err := loop.Process(context.Background(), agenkit.NewMessage("user", "draft"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "draft v3 DONE" {
+		t.Errorf("Expected final output 'draft v3 DONE', got '%s'", result.Content)
+	}
+	if result.Metadata["loop_iterations"] != 3 {
+		t.Errorf("Expected 3 iterations, got %v", result.Metadata["loop_iterations"])
+	}
+	if result.Metadata["loop_limit_reached"] != false {
+		t.Errorf("Expected limit not reached, got %v", result.Metadata["loop_limit_reached"])
+	}
+	outputs := result.Metadata["loop_outputs"].([]*agenkit.Message)
+	if len(outputs) != 3 || outputs[0].Content != "draft v1" {
+		t.Errorf("Expected all three outputs recorded, got %v", outputs)
+	}
+	body.AssertExpectationsMet()
+}
+
+func TestLoopStopsAtMaxIterations(t *testing.T) {
+	body := testutil.NewMockAgent(t, "body")
+	body.Expect("", "again")
+	body.Expect("", "again")
+
+	loop, _ := NewLoopAgent("loop", body, untilContains("DONE"), 2)
+	result, err := loop.Process(context.Background(), agenkit.NewMessage("user", "start"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Metadata["loop_iterations"] != 2 {
+		t.Errorf("Expected 2 iterations, got %v", result.Metadata["loop_iterations"])
+	}
+	if result.Metadata["loop_limit_reached"] != true {
+		t.Errorf("Expected limit reached, got %v", result.Metadata["loop_limit_reached"])
+	}
+	body.AssertExpectationsMet()
+}
+
+func TestLoopRequiresPositiveMax(t *testing.T) {
+	body := testutil.NewMockAgent(t, "body")
+	for _, max := range []int{0, -1} {
+		if _, err := NewLoopAgent("loop", body, untilContains("DONE"), max); err == nil {
+			t.Errorf("Expected error for max iterations %d", max)
+		}
+	}
+	if _, err := NewLoopAgent("loop", body, nil, 3); err == nil {
+		t.Error("Expected error for nil condition")
+	}
+}
+
+func TestLoopConditionAndBodyErrors(t *testing.T) {
+	body := testutil.NewMockAgent(t, "body")
+	body.Expect("", "out")
+	failing := func(ctx context.Context, output *agenkit.Message) (bool, error) {
+		return false, errors.New("judge unavailable")
+	}
+
+	loop, _ := NewLoopAgent("loop", body, failing, 3)
+	_, err := loop.Process(context.Background(), agenkit.NewMessage("user", "start"))
+	if err == nil || !strings.Contains(err.Error(), "judge unavailable") {
+		t.Errorf("Expected condition error, got %v", err)
+	}
+
+	broken := testutil.NewMockAgent(t, "broken")
+	broken.Expect("", "").WithError(errors.New("boom"))
+	loop, _ = NewLoopAgent("loop", broken, untilContains("DONE"), 3)
+	_, err = loop.Process(context.Background(), agenkit.NewMessage("user", "start"))
+	if err == nil || !strings.Contains(err.Error(), "iteration 1 (broken) failed") {
+		t.Errorf("Expected wrapped body error, got %v", err)
+	}
+}
+
+func TestLoopCancellationBetweenIterations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	body := testutil.NewMockAgent(t, "body")
+	body.Expect("", "again")
+	cancelAfterFirst := func(ctx context.Context, output *agenkit.Message) (bool, error) {
+		cancel()
+		return true, nil
+	}
+
+	loop, _ := NewLoopAgent("loop", body, cancelAfterFirst, 5)
+	_, err := loop.Process(ctx, agenkit.NewMessage("user", "start"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(body.Calls()) != 1 {
+		t.Errorf("Expected 1 body call before cancellation, got %d", len(body.Calls()))
+	}
+}
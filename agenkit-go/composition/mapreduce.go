@@ -0,0 +1,233 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// SplitFunc fans an input message out into shards.
+type SplitFunc func(message *agenkit.Message) []*agenkit.Message
+
+// ReduceFunc combines shard outputs, given in shard order, into one message.
+type ReduceFunc func(outputs []*agenkit.Message) *agenkit.Message
+
+// ShardError records the failure of one shard.
+type ShardError struct {
+	Index int
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *ShardError) Error() string {
+	return fmt.Sprintf("shard %d failed: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ShardError) Unwrap() error {
+	return e.Err
+}
+
+// MapReduceError reports the shards that failed a map-reduce run.
+type MapReduceError struct {
+	Failures []*ShardError
+}
+
+// Error implements the error interface.
+func (e *MapReduceError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		parts[i] = failure.Error()
+	}
+	return "map-reduce failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the shard errors, so errors.Is and errors.As see each one.
+func (e *MapReduceError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+	return errs
+}
+
+// MapReduceAgent splits its input into shards, processes the shards
+// concurrently with a mapper agent, and reduces the outputs into one message.
+//
+// At most the concurrency limit of shards run at once, on a fixed pool of
+// workers, so very large shard counts do not start a goroutine each. By
+// default the first shard failure cancels the remaining shards and the run
+// fails with a *MapReduceError; with SetAllowPartial, failed shards are left
+// out and the rest are reduced. Reduce always receives outputs in shard
+// order. The response metadata records:
+//
+//   - "mapreduce_shards": the number of shards
+//   - "mapreduce_failures": the []*ShardError left out, when partial results are allowed
+type MapReduceAgent struct {
+	name         string
+	split        SplitFunc
+	mapper       agenkit.Agent
+	reduce       ReduceFunc
+	concurrency  int
+	allowPartial bool
+}
+
+// Verify that MapReduceAgent implements Agent interface.
+var _ agenkit.Agent = (*MapReduceAgent)(nil)
+
+// NewMapReduceAgent creates a map-reduce over mapper.
+func NewMapReduceAgent(name string, split SplitFunc, mapper agenkit.Agent, reduce ReduceFunc) (*MapReduceAgent, error) {
+	if split == nil {
+		return nil, fmt.Errorf("map-reduce requires a split function")
+	}
+	if mapper == nil {
+		return nil, fmt.Errorf("map-reduce requires a mapper agent")
+	}
+	if reduce == nil {
+		return nil, fmt.Errorf("map-reduce requires a reduce function")
+	}
+	return &MapReduceAgent{
+		name:        name,
+		split:       split,
+		mapper:      mapper,
+		reduce:      reduce,
+		concurrency: 4,
+	}, nil
+}
+
+// SetConcurrency sets the number of shards processed at once. Values below
+// one are ignored. Default: 4.
+func (m *MapReduceAgent) SetConcurrency(n int) {
+	if n > 0 {
+		m.concurrency = n
+	}
+}
+
+// SetAllowPartial controls whether failed shards are left out of the reduce
+// instead of failing the run. The run still fails if every shard fails.
+func (m *MapReduceAgent) SetAllowPartial(allowPartial bool) {
+	m.allowPartial = allowPartial
+}
+
+// Name returns the name of the map-reduce agent.
+func (m *MapReduceAgent) Name() string {
+	return m.name
+}
+
+// Capabilities returns the mapper's capabilities plus the mapreduce marker.
+func (m *MapReduceAgent) Capabilities() []string {
+	return append(m.mapper.Capabilities(), "mapreduce")
+}
+
+// Process splits the message, maps every shard, and reduces the outputs.
+func (m *MapReduceAgent) Process(ctx context.Context, message *agenkit.Message) (response *agenkit.Message, err error) {
+	shards := m.split(message)
+	ctx, span := agenkit.StartSpan(ctx, "pattern.mapreduce",
+		attribute.String("agent.name", m.name),
+		attribute.String("pattern.type", "mapreduce"),
+		attribute.Int("pattern.shards", len(shards)),
+		attribute.Int("pattern.concurrency", m.concurrency),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, m.name, message)
+	defer func() { finish(response, err) }()
+
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("split produced no shards")
+	}
+
+	outputs, failures := m.mapShards(ctx, shards)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("map-reduce cancelled: %w", err)
+	}
+	if len(failures) > 0 && (!m.allowPartial || len(failures) == len(shards)) {
+		return nil, &MapReduceError{Failures: failures}
+	}
+
+	successful := make([]*agenkit.Message, 0, len(shards)-len(failures))
+	for _, output := range outputs {
+		if output != nil {
+			successful = append(successful, output)
+		}
+	}
+	reduced := m.reduce(successful)
+	if reduced == nil {
+		return nil, fmt.Errorf("reduce returned no message")
+	}
+
+	result := *reduced
+	result.Metadata = make(map[string]interface{}, len(reduced.Metadata)+2)
+	for k, v := range reduced.Metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata["mapreduce_shards"] = len(shards)
+	if m.allowPartial {
+		result.Metadata["mapreduce_failures"] = failures
+	}
+	return &result, nil
+}
+
+// mapShards runs the mapper over every shard on a bounded pool of workers.
+// outputs[i] is nil for shards that failed or did not run; failures are in
+// shard order and leave out shards cancelled because another failed.
+func (m *MapReduceAgent) mapShards(ctx context.Context, shards []*agenkit.Message) ([]*agenkit.Message, []*ShardError) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outputs := make([]*agenkit.Message, len(shards))
+	shardErrs := make([]error, len(shards))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(m.concurrency, len(shards)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if runCtx.Err() != nil {
+					continue
+				}
+				output, err := agenkit.ProcessWithSpan(runCtx, m.mapper, shards[i], attribute.Int("pattern.shard", i))
+				if err != nil {
+					shardErrs[i] = err
+					if !m.allowPartial {
+						cancel()
+					}
+					continue
+				}
+				outputs[i] = output
+			}
+		}()
+	}
+
+feed:
+	for i := range shards {
+		select {
+		case indexes <- i:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failures []*ShardError
+	cancelled := runCtx.Err() != nil && ctx.Err() == nil
+	for i, err := range shardErrs {
+		if err == nil {
+			continue
+		}
+		if cancelled && errors.Is(err, context.Canceled) {
+			// Stopped because another shard failed
+			continue
+		}
+		failures = append(failures, &ShardError{Index: i, Err: err})
+	}
+	return outputs, failures
+}
@@ -0,0 +1,191 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// splitLines makes one shard per line.
+func splitLines(message *agenkit.Message) []*agenkit.Message {
+	var shards []*agenkit.Message
+	for _, line := range strings.Split(message.Content, "\n") {
+		shards = append(shards, agenkit.NewMessage("user", line))
+	}
+	return shards
+}
+
+// joinOutputs concatenates shard outputs with commas.
+func joinOutputs(outputs []*agenkit.Message) *agenkit.Message {
+	parts := make([]string, len(outputs))
+	for i, output := range outputs {
+		parts[i] = output.Content
+	}
+	return agenkit.NewMessage("agent", strings.Join(parts, ","))
+}
+
+// shardMapper upper-cases its input after a delay, failing on inputs in
+// fail, and tracks peak concurrency.
+type shardMapper struct {
+	delay   func(input string) time.Duration
+	fail    map[string]bool
+	running atomic.Int32
+	peak    atomic.Int32
+	calls   atomic.Int32
+}
+
+func (s *shardMapper) Name() string           { return "mapper" }
+func (s *shardMapper) Capabilities() []string { return []string{"test"} }
+
+func (s *shardMapper) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	s.calls.Add(1)
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	if s.delay != nil {
+		select {
+		case <-time.After(s.delay(message.Content)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.fail[message.Content] {
+		return nil, fmt.Errorf("cannot map %s", message.Content)
+	}
+	return agenkit.NewMessage("agent", strings.ToUpper(message.Content)), nil
+}
+
+func TestMapReducePreservesShardOrder(t *testing.T) {
+	// Earlier shards finish last
+	mapper := &shardMapper{delay: func(input string) time.Duration {
+		return time.Duration(5-len(input)) * 5 * time.Millisecond
+	}}
+	mr, err := NewMapReduceAgent("mr", splitLines, mapper, joinOutputs)
+	if err != nil {
+		t.Fatalf("Failed to create map-reduce: %v", err)
+	}
+
+	result, err := mr.Process(context.Background(), agenkit.NewMessage("user", "a\nbb\nccc\ndddd"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "A,BB,CCC,DDDD" {
+		t.Errorf("Expected outputs in shard order, got '%s'", result.Content)
+	}
+	if result.Metadata["mapreduce_shards"] != 4 {
+		t.Errorf("Expected 4 shards recorded, got %v", result.Metadata["mapreduce_shards"])
+	}
+}
+
+func TestMapReduceConcurrencyLimit(t *testing.T) {
+	mapper := &shardMapper{delay: func(string) time.Duration { return 5 * time.Millisecond }}
+	mr, _ := NewMapReduceAgent("mr", splitLines, mapper, joinOutputs)
+	mr.SetConcurrency(3)
+
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line%d", i)
+	}
+	if _, err := mr.Process(context.Background(), agenkit.NewMessage("user", strings.Join(lines, "\n"))); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if peak := mapper.peak.Load(); peak > 3 {
+		t.Errorf("Expected at most 3 shards at once, got %d", peak)
+	}
+	if calls := mapper.calls.Load(); calls != 30 {
+		t.Errorf("Expected every shard to run, got %d", calls)
+	}
+}
+
+func TestMapReduceFailsOnShardError(t *testing.T) {
+	mapper := &shardMapper{
+		fail: map[string]bool{"b": true},
+		delay: func(input string) time.Duration {
+			if input == "b" {
+				return 0
+			}
+			return 50 * time.Millisecond
+		},
+	}
+	mr, _ := NewMapReduceAgent("mr", splitLines, mapper, joinOutputs)
+	mr.SetConcurrency(2)
+
+	start := time.Now()
+	_, err := mr.Process(context.Background(), agenkit.NewMessage("user", "a\nb\nc\nd\ne"))
+	var mrErr *MapReduceError
+	if !errors.As(err, &mrErr) {
+		t.Fatalf("Expected MapReduceError, got %v", err)
+	}
+	if len(mrErr.Failures) != 1 || mrErr.Failures[0].Index != 1 {
+		t.Errorf("Expected only shard 1 to be reported, got %v", mrErr)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected the failure to cancel the remaining shards, took %v", elapsed)
+	}
+	if calls := mapper.calls.Load(); calls > 3 {
+		t.Errorf("Expected queued shards not to start after the failure, got %d calls", calls)
+	}
+}
+
+func TestMapReduceAllowPartial(t *testing.T) {
+	mapper := &shardMapper{fail: map[string]bool{"b": true, "d": true}}
+	var reduced []*agenkit.Message
+	var mu sync.Mutex
+	reduce := func(outputs []*agenkit.Message) *agenkit.Message {
+		mu.Lock()
+		defer mu.Unlock()
+		reduced = outputs
+		return joinOutputs(outputs)
+	}
+	mr, _ := NewMapReduceAgent("mr", splitLines, mapper, reduce)
+	mr.SetAllowPartial(true)
+
+	result, err := mr.Process(context.Background(), agenkit.NewMessage("user", "a\nb\nc\nd"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "A,C" || len(reduced) != 2 {
+		t.Errorf("Expected the successful shards reduced in order, got '%s'", result.Content)
+	}
+	failures := result.Metadata["mapreduce_failures"].([]*ShardError)
+	if len(failures) != 2 || failures[0].Index != 1 || failures[1].Index != 3 {
+		t.Errorf("Expected failures for shards 1 and 3, got %v", failures)
+	}
+
+	mapper.fail = map[string]bool{"x": true, "y": true}
+	if _, err := mr.Process(context.Background(), agenkit.NewMessage("user", "x\ny")); err == nil {
+		t.Error("Expected an error when every shard fails")
+	}
+}
+
+func TestMapReduceValidation(t *testing.T) {
+	mapper := &shardMapper{}
+	if _, err := NewMapReduceAgent("mr", nil, mapper, joinOutputs); err == nil {
+		t.Error("Expected error for nil split")
+	}
+	if _, err := NewMapReduceAgent("mr", splitLines, nil, joinOutputs); err == nil {
+		t.Error("Expected error for nil mapper")
+	}
+	if _, err := NewMapReduceAgent("mr", splitLines, mapper, nil); err == nil {
+		t.Error("Expected error for nil reduce")
+	}
+
+	none := func(*agenkit.Message) []*agenkit.Message { return nil }
+	mr, _ := NewMapReduceAgent("mr", none, mapper, joinOutputs)
+	if _, err := mr.Process(context.Background(), agenkit.NewMessage("user", "")); err == nil {
+		t.Error("Expected error when split produces no shards")
+	}
+}
@@ -2,17 +2,85 @@ package composition
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/agenkit/agenkit-go/agenkit"
 )
 
+// ErrNoQuorum is returned in quorum mode when the agents finish without
+// enough of them agreeing on an answer.
+var ErrNoQuorum = errors.New("no quorum reached")
+
+// errNoResponse stands in for the error of an agent that returned neither a
+// response nor an error, which cannot win, agree or feed a next step.
+var errNoResponse = errors.New("agent returned no response")
+
+// parallelModeKind enumerates the aggregation strategies.
+type parallelModeKind int
+
+const (
+	modeAll parallelModeKind = iota
+	modeFirst
+	modeQuorum
+)
+
+// ParallelMode selects how ParallelAgent aggregates its agents' responses.
+type ParallelMode struct {
+	kind   parallelModeKind
+	quorum int
+	equal  func(a, b *agenkit.Message) bool
+}
+
+var (
+	// ModeAll waits for every agent and combines all responses (the default).
+	ModeAll = ParallelMode{kind: modeAll}
+
+	// ModeFirst returns the first successful response and cancels the rest.
+	ModeFirst = ParallelMode{kind: modeFirst}
+)
+
+// ModeQuorum returns once n agents have produced responses that equal
+// considers the same answer, cancelling the rest. If equal is nil,
+// responses are compared by their trimmed content.
+func ModeQuorum(n int, equal func(a, b *agenkit.Message) bool) ParallelMode {
+	if n < 1 {
+		n = 1
+	}
+	if equal == nil {
+		equal = sameContent
+	}
+	return ParallelMode{kind: modeQuorum, quorum: n, equal: equal}
+}
+
+// String returns the mode's name.
+func (m ParallelMode) String() string {
+	switch m.kind {
+	case modeFirst:
+		return "first"
+	case modeQuorum:
+		return fmt.Sprintf("quorum(%d)", m.quorum)
+	default:
+		return "all"
+	}
+}
+
 // ParallelAgent executes multiple agents concurrently and combines their results.
+//
+// By default every agent runs to completion and all errors are reported
+// together. With SetCancelOnError, the first error cancels the context
+// shared by the remaining agents. SetMode selects first-to-complete or
+// quorum aggregation instead. In every mode, Process does not return until
+// every agent has returned, so no goroutines outlive the call.
 type ParallelAgent struct {
-	name   string
-	agents []agenkit.Agent
+	name          string
+	agents        []agenkit.Agent
+	cancelOnError bool
+	mode          ParallelMode
 }
 
 // Verify that ParallelAgent implements Agent interface.
@@ -51,6 +119,22 @@ func (p *ParallelAgent) Capabilities() []string {
 	return caps
 }
 
+// SetCancelOnError controls whether the first failing agent cancels its
+// in-flight siblings. When enabled, Process returns that first error.
+func (p *ParallelAgent) SetCancelOnError(cancelOnError bool) {
+	p.cancelOnError = cancelOnError
+}
+
+// SetMode sets how responses are aggregated. Default: ModeAll.
+//
+// In ModeFirst and ModeQuorum, failed agents do not cancel their siblings;
+// the call fails only once no agent can still produce the required result.
+// Quorum responses carry "quorum_agents" (the names of the agreeing agents)
+// in their metadata, and first-mode responses carry "parallel_winner".
+func (p *ParallelAgent) SetMode(mode ParallelMode) {
+	p.mode = mode
+}
+
 // AgentResult holds the result from a single agent execution.
 type AgentResult struct {
 	AgentName string
@@ -59,7 +143,21 @@ type AgentResult struct {
 }
 
 // Process executes all agents in parallel and combines their results.
-func (p *ParallelAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+func (p *ParallelAgent) Process(ctx context.Context, message *agenkit.Message) (response *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "pattern.parallel",
+		attribute.String("agent.name", p.name),
+		attribute.String("pattern.type", "parallel"),
+		attribute.String("pattern.mode", p.mode.String()),
+		attribute.Int("pattern.branches", len(p.agents)),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, p.name, message)
+	defer func() { finish(response, err) }()
+
+	// Siblings share a derived context so an early result can cancel them
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	results := make(chan *AgentResult, len(p.agents))
 	var wg sync.WaitGroup
 
@@ -69,7 +167,7 @@ func (p *ParallelAgent) Process(ctx context.Context, message *agenkit.Message) (
 		go func(a agenkit.Agent) {
 			defer wg.Done()
 
-			result, err := a.Process(ctx, message)
+			result, err := agenkit.ProcessWithSpan(runCtx, a, message)
 			results <- &AgentResult{
 				AgentName: a.Name(),
 				Message:   result,
@@ -78,28 +176,41 @@ func (p *ParallelAgent) Process(ctx context.Context, message *agenkit.Message) (
 		}(agent)
 	}
 
-	// Wait for all agents to complete
+	// Close results once every agent has returned, even when cancelling early
 	go func() {
 		wg.Wait()
 		close(results)
 	}()
 
-	// Collect results
+	switch p.mode.kind {
+	case modeFirst:
+		return p.collectFirst(results, cancel)
+	case modeQuorum:
+		return p.collectQuorum(results, cancel)
+	default:
+		return p.collectAll(results, cancel)
+	}
+}
+
+// collectAll waits for every agent and combines their responses.
+func (p *ParallelAgent) collectAll(results <-chan *AgentResult, cancel context.CancelFunc) (*agenkit.Message, error) {
 	var responses []*AgentResult
+	var firstErr *AgentResult
 	for result := range results {
+		if result.Error != nil && p.cancelOnError && firstErr == nil {
+			firstErr = result
+			cancel()
+		}
 		responses = append(responses, result)
 	}
 
-	// Check for errors
-	var errors []string
-	for _, result := range responses {
-		if result.Error != nil {
-			errors = append(errors, fmt.Sprintf("%s: %v", result.AgentName, result.Error))
-		}
+	if firstErr != nil {
+		return nil, fmt.Errorf("parallel execution cancelled after %s failed: %w", firstErr.AgentName, firstErr.Error)
 	}
 
-	if len(errors) > 0 {
-		return nil, fmt.Errorf("parallel execution had errors: %s", strings.Join(errors, "; "))
+	// Check for errors
+	if err := joinErrors(responses); err != nil {
+		return nil, err
 	}
 
 	// Combine all responses
@@ -107,6 +218,124 @@ func (p *ParallelAgent) Process(ctx context.Context, message *agenkit.Message) (
 	return combined, nil
 }
 
+// collectFirst returns the first successful response, cancelling the rest.
+func (p *ParallelAgent) collectFirst(results <-chan *AgentResult, cancel context.CancelFunc) (*agenkit.Message, error) {
+	var winner *AgentResult
+	var failures []*AgentResult
+	for result := range results {
+		switch {
+		case winner != nil:
+			// Drain the cancelled stragglers
+		case result.Error != nil:
+			failures = append(failures, result)
+		case result.Message == nil:
+			failures = append(failures, &AgentResult{AgentName: result.AgentName, Error: errNoResponse})
+		default:
+			winner = result
+			cancel()
+		}
+	}
+
+	if winner == nil {
+		return nil, joinErrors(failures)
+	}
+	response := copyResponse(winner.Message)
+	response.Metadata["parallel_winner"] = winner.AgentName
+	return response, nil
+}
+
+// collectQuorum returns once enough agents agree, cancelling the rest.
+func (p *ParallelAgent) collectQuorum(results <-chan *AgentResult, cancel context.CancelFunc) (*agenkit.Message, error) {
+	var groups [][]*AgentResult
+	var agreed []*AgentResult
+	var failures []*AgentResult
+	pending := len(p.agents)
+	decided := false
+
+	for result := range results {
+		pending--
+		if decided {
+			// Drain the cancelled stragglers
+			continue
+		}
+
+		if result.Error != nil {
+			failures = append(failures, result)
+		} else if result.Message == nil {
+			failures = append(failures, &AgentResult{AgentName: result.AgentName, Error: errNoResponse})
+		} else {
+			placed := false
+			for i, group := range groups {
+				if p.mode.equal(group[0].Message, result.Message) {
+					groups[i] = append(group, result)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				groups = append(groups, []*AgentResult{result})
+			}
+		}
+
+		for _, group := range groups {
+			if len(group) >= p.mode.quorum {
+				agreed = group
+				break
+			}
+		}
+		// Stop early once quorum is reached, or once it is out of reach even
+		// if every pending agent agreed with the largest group
+		if agreed != nil || maxGroupSize(groups)+pending < p.mode.quorum {
+			decided = true
+			cancel()
+		}
+	}
+
+	if agreed == nil {
+		err := fmt.Errorf("%w (%d of %d needed)", ErrNoQuorum, maxGroupSize(groups), p.mode.quorum)
+		if failed := joinErrors(failures); failed != nil {
+			err = fmt.Errorf("%w: %w", err, failed)
+		}
+		return nil, err
+	}
+
+	names := make([]string, len(agreed))
+	for i, result := range agreed {
+		names[i] = result.AgentName
+	}
+	response := copyResponse(agreed[0].Message)
+	response.Metadata["quorum_agents"] = names
+	return response, nil
+}
+
+// sameContent reports whether two messages have the same trimmed content.
+func sameContent(a, b *agenkit.Message) bool {
+	return strings.TrimSpace(a.Content) == strings.TrimSpace(b.Content)
+}
+
+// maxGroupSize returns the size of the largest group of agreeing responses.
+func maxGroupSize(groups [][]*AgentResult) int {
+	largest := 0
+	for _, group := range groups {
+		largest = max(largest, len(group))
+	}
+	return largest
+}
+
+// joinErrors reports the failed results, if any.
+func joinErrors(results []*AgentResult) error {
+	var errs []string
+	for _, result := range results {
+		if result.Error != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", result.AgentName, result.Error))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("parallel execution had errors: %s", strings.Join(errs, "; "))
+}
+
 // combineResponses combines multiple agent responses into a single message.
 func (p *ParallelAgent) combineResponses(results []*AgentResult) *agenkit.Message {
 	var contentParts []string
@@ -0,0 +1,276 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// countingAgent tracks how many of its calls are in flight.
+type countingAgent struct {
+	name      string
+	active    *int64
+	delay     time.Duration
+	err       error
+	reply     string
+	cancelled atomic.Bool
+}
+
+func (c *countingAgent) Name() string           { return c.name }
+func (c *countingAgent) Capabilities() []string { return nil }
+
+func (c *countingAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	atomic.AddInt64(c.active, 1)
+	defer atomic.AddInt64(c.active, -1)
+
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		c.cancelled.Store(true)
+		return nil, ctx.Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.reply != "" {
+		return agenkit.NewMessage("agent", c.reply), nil
+	}
+	return agenkit.NewMessage("agent", c.name), nil
+}
+
+// metadataAgent replies with the request metadata it sees.
+type metadataAgent struct{ name string }
+
+func (m *metadataAgent) Name() string           { return m.name }
+func (m *metadataAgent) Capabilities() []string { return nil }
+
+func (m *metadataAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return agenkit.NewMessage("agent", agenkit.MetadataFrom(ctx)["trace_id"]), nil
+}
+
+func TestParallelAgentPropagatesMetadata(t *testing.T) {
+	parallel, _ := NewParallelAgent("fanout", &metadataAgent{name: "a"}, &metadataAgent{name: "b"})
+	ctx := agenkit.WithMetadata(context.Background(), map[string]string{"trace_id": "t-1"})
+
+	result, err := parallel.Process(ctx, agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if strings.Count(result.Content, "t-1") != 2 {
+		t.Errorf("Expected every branch to see the trace ID, got '%s'", result.Content)
+	}
+}
+
+func TestParallelAgentCancelOnError(t *testing.T) {
+	var active int64
+	failing := &countingAgent{name: "failing", active: &active, delay: time.Millisecond, err: errors.New("boom")}
+	slow1 := &countingAgent{name: "slow1", active: &active, delay: time.Hour}
+	slow2 := &countingAgent{name: "slow2", active: &active, delay: time.Hour}
+
+	parallel, _ := NewParallelAgent("fanout", failing, slow1, slow2)
+	parallel.SetCancelOnError(true)
+
+	start := time.Now()
+	_, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil || !strings.Contains(err.Error(), "failing failed: boom") {
+		t.Fatalf("Expected the first error to be reported, got: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected siblings to abort early")
+	}
+	if !slow1.cancelled.Load() || !slow2.cancelled.Load() {
+		t.Error("Expected in-flight siblings to observe cancellation")
+	}
+	if n := atomic.LoadInt64(&active); n != 0 {
+		t.Errorf("Expected no goroutines still running after Process returned, got %d", n)
+	}
+}
+
+func TestParallelAgentAggregateWaitsForAll(t *testing.T) {
+	var active int64
+	failing := &countingAgent{name: "failing", active: &active, delay: time.Millisecond, err: errors.New("boom")}
+	slow := &countingAgent{name: "slow", active: &active, delay: 30 * time.Millisecond}
+	other := &countingAgent{name: "other", active: &active, delay: 20 * time.Millisecond, err: errors.New("bang")}
+
+	parallel, _ := NewParallelAgent("fanout", failing, slow, other)
+
+	_, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil || !strings.Contains(err.Error(), "boom") || !strings.Contains(err.Error(), "bang") {
+		t.Fatalf("Expected every error to be collected, got: %v", err)
+	}
+	if slow.cancelled.Load() {
+		t.Error("Expected aggregate mode not to cancel siblings")
+	}
+	if n := atomic.LoadInt64(&active); n != 0 {
+		t.Errorf("Expected no goroutines still running after Process returned, got %d", n)
+	}
+}
+
+func TestParallelAgentModeFirst(t *testing.T) {
+	var active int64
+	failing := &countingAgent{name: "failing", active: &active, delay: time.Millisecond, err: errors.New("boom")}
+	fast := &countingAgent{name: "fast", active: &active, delay: 10 * time.Millisecond}
+	slow := &countingAgent{name: "slow", active: &active, delay: time.Hour}
+
+	parallel, _ := NewParallelAgent("race", failing, fast, slow)
+	parallel.SetMode(ModeFirst)
+
+	start := time.Now()
+	response, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Expected first success despite a failure, got: %v", err)
+	}
+	if response.Content != "fast" || response.Metadata["parallel_winner"] != "fast" {
+		t.Errorf("Expected fast agent to win, got '%s' (%v)", response.Content, response.Metadata)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected slower agents to be cancelled")
+	}
+	if !slow.cancelled.Load() {
+		t.Error("Expected slow agent to observe cancellation")
+	}
+	if n := atomic.LoadInt64(&active); n != 0 {
+		t.Errorf("Expected no goroutines still running after Process returned, got %d", n)
+	}
+}
+
+func TestParallelAgentModeFirstAllFail(t *testing.T) {
+	var active int64
+	a := &countingAgent{name: "a", active: &active, err: errors.New("boom")}
+	b := &countingAgent{name: "b", active: &active, err: errors.New("bang")}
+
+	parallel, _ := NewParallelAgent("race", a, b)
+	parallel.SetMode(ModeFirst)
+
+	_, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil || !strings.Contains(err.Error(), "boom") || !strings.Contains(err.Error(), "bang") {
+		t.Fatalf("Expected every error when all agents fail, got: %v", err)
+	}
+}
+
+// replyAgent returns the same reply message on every call, which may be nil.
+type replyAgent struct {
+	name  string
+	delay time.Duration
+	reply *agenkit.Message
+}
+
+func (r *replyAgent) Name() string           { return r.name }
+func (r *replyAgent) Capabilities() []string { return nil }
+
+func (r *replyAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	time.Sleep(r.delay)
+	return r.reply, nil
+}
+
+func TestParallelAgentModeFirstSkipsEmptyReplies(t *testing.T) {
+	reply := agenkit.NewMessage("agent", "cached")
+	empty := &replyAgent{name: "empty"}
+	shared := &replyAgent{name: "shared", delay: 10 * time.Millisecond, reply: reply}
+
+	parallel, _ := NewParallelAgent("race", empty, shared)
+	parallel.SetMode(ModeFirst)
+
+	response, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Expected the agent with a reply to win, got: %v", err)
+	}
+	if response.Metadata["parallel_winner"] != "shared" {
+		t.Errorf("Expected shared to win, got %v", response.Metadata)
+	}
+	if _, ok := reply.Metadata["parallel_winner"]; ok {
+		t.Error("Expected the agent's own message to be left unchanged")
+	}
+
+	parallel, _ = NewParallelAgent("race", empty, &replyAgent{name: "also-empty"})
+	parallel.SetMode(ModeFirst)
+	if _, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi")); err == nil || !strings.Contains(err.Error(), "no response") {
+		t.Errorf("Expected an error when no agent replies, got: %v", err)
+	}
+}
+
+func TestParallelAgentModeQuorum(t *testing.T) {
+	var active int64
+	a := &countingAgent{name: "a", active: &active, delay: time.Millisecond, reply: "42"}
+	b := &countingAgent{name: "b", active: &active, delay: 5 * time.Millisecond, reply: "41"}
+	c := &countingAgent{name: "c", active: &active, delay: 10 * time.Millisecond, reply: " 42 "}
+	slow := &countingAgent{name: "slow", active: &active, delay: time.Hour, reply: "42"}
+
+	parallel, _ := NewParallelAgent("vote", a, b, c, slow)
+	parallel.SetMode(ModeQuorum(2, nil))
+
+	response, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Expected quorum, got: %v", err)
+	}
+	if response.Content != "42" {
+		t.Errorf("Expected agreed answer '42', got '%s'", response.Content)
+	}
+	names, _ := response.Metadata["quorum_agents"].([]string)
+	if len(names) != 2 || names[0] != "a" || names[1] != "c" {
+		t.Errorf("Expected quorum agents [a c], got %v", response.Metadata["quorum_agents"])
+	}
+	if !slow.cancelled.Load() {
+		t.Error("Expected remaining agent to be cancelled once quorum was reached")
+	}
+	if n := atomic.LoadInt64(&active); n != 0 {
+		t.Errorf("Expected no goroutines still running after Process returned, got %d", n)
+	}
+}
+
+func TestParallelAgentModeQuorumCustomEqual(t *testing.T) {
+	var active int64
+	a := &countingAgent{name: "a", active: &active, reply: "YES"}
+	b := &countingAgent{name: "b", active: &active, reply: "yes"}
+
+	parallel, _ := NewParallelAgent("vote", a, b)
+	parallel.SetMode(ModeQuorum(2, func(x, y *agenkit.Message) bool {
+		return strings.EqualFold(x.Content, y.Content)
+	}))
+
+	if _, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Expected case-insensitive quorum, got: %v", err)
+	}
+}
+
+func TestParallelAgentNoQuorum(t *testing.T) {
+	var active int64
+	a := &countingAgent{name: "a", active: &active, reply: "1"}
+	b := &countingAgent{name: "b", active: &active, reply: "2"}
+	c := &countingAgent{name: "c", active: &active, err: errors.New("boom")}
+
+	parallel, _ := NewParallelAgent("vote", a, b, c)
+	parallel.SetMode(ModeQuorum(2, nil))
+
+	_, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("Expected ErrNoQuorum, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected failures to be reported alongside ErrNoQuorum, got: %v", err)
+	}
+}
+
+func TestParallelAgentNoQuorumStopsEarly(t *testing.T) {
+	var active int64
+	a := &countingAgent{name: "a", active: &active, reply: "1"}
+	b := &countingAgent{name: "b", active: &active, delay: time.Millisecond, reply: "2"}
+	slow := &countingAgent{name: "slow", active: &active, delay: time.Hour, reply: "3"}
+
+	parallel, _ := NewParallelAgent("vote", a, b, slow)
+	parallel.SetMode(ModeQuorum(3, nil))
+
+	start := time.Now()
+	_, err := parallel.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("Expected ErrNoQuorum, got: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Expected to give up once quorum was out of reach")
+	}
+}
@@ -0,0 +1,371 @@
+package composition
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrUnknownAgent is returned when a pipeline names an agent with no
+// registered factory.
+var ErrUnknownAgent = errors.New("unknown agent")
+
+// ErrUnknownPattern is returned when a pipeline uses an unsupported type.
+var ErrUnknownPattern = errors.New("unknown pattern type")
+
+// AgentFactory builds a named leaf agent of a pipeline from its config.
+type AgentFactory func(name string, config map[string]interface{}) (agenkit.Agent, error)
+
+// PipelineSpec is the declarative description of an agent tree.
+//
+// Type is one of "agent", "sequential", "parallel", "fallback", "retry",
+// "router" or "debate". An "agent" node builds a leaf with the factory
+// registered under Agent, passing Name (Agent if empty) and Config.
+// Pattern nodes list their children in Agents; "retry" takes exactly one
+// child, and "router" and "debate" use Classifier, Routes, Default and Judge.
+//
+// Specs are read as JSON only: agenkit takes no YAML dependency, so
+// LoadPipeline does not accept YAML. The spec carries yaml tags, so a
+// caller depending on a YAML library can decode into a PipelineSpec and
+// build it with BuildPipeline.
+type PipelineSpec struct {
+	Type   string                 `json:"type" yaml:"type"`
+	Name   string                 `json:"name,omitempty" yaml:"name,omitempty"`
+	Agent  string                 `json:"agent,omitempty" yaml:"agent,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+	Agents []*PipelineSpec        `json:"agents,omitempty" yaml:"agents,omitempty"`
+
+	// Sequential options. StepWeights is passed to SetStepWeights.
+	StepWeights []float64 `json:"step_weights,omitempty" yaml:"step_weights,omitempty"`
+
+	// Parallel options. Mode is "all" (the default), "first" or "quorum".
+	Mode          string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Quorum        int    `json:"quorum,omitempty" yaml:"quorum,omitempty"`
+	CancelOnError bool   `json:"cancel_on_error,omitempty" yaml:"cancel_on_error,omitempty"`
+
+	// Retry options.
+	MaxAttempts int `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+
+	// Router options.
+	Classifier *PipelineSpec            `json:"classifier,omitempty" yaml:"classifier,omitempty"`
+	Routes     map[string]*PipelineSpec `json:"routes,omitempty" yaml:"routes,omitempty"`
+	Default    *PipelineSpec            `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Debate options.
+	Rounds int           `json:"rounds,omitempty" yaml:"rounds,omitempty"`
+	Judge  *PipelineSpec `json:"judge,omitempty" yaml:"judge,omitempty"`
+}
+
+// PipelineError reports a problem at a path in a pipeline, such as
+// "$.agents[1].routes.billing".
+type PipelineError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("pipeline %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// LoadPipeline decodes a JSON pipeline spec from r and builds it with the
+// registered factories. Unknown fields are rejected. YAML is not accepted;
+// see PipelineSpec.
+func LoadPipeline(r io.Reader, registry map[string]AgentFactory) (agenkit.Agent, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var spec PipelineSpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to decode pipeline: %w", err)
+	}
+	return BuildPipeline(&spec, registry)
+}
+
+// BuildPipeline builds the agent tree described by spec.
+func BuildPipeline(spec *PipelineSpec, registry map[string]AgentFactory) (agenkit.Agent, error) {
+	return buildNode(spec, "$", registry)
+}
+
+// buildNode builds the node at path.
+func buildNode(spec *PipelineSpec, path string, registry map[string]AgentFactory) (agenkit.Agent, error) {
+	if spec == nil {
+		return nil, &PipelineError{Path: path, Err: errors.New("missing node")}
+	}
+	fail := func(err error) (agenkit.Agent, error) {
+		var pipelineErr *PipelineError
+		if errors.As(err, &pipelineErr) {
+			return nil, err
+		}
+		return nil, &PipelineError{Path: path, Err: err}
+	}
+
+	switch spec.Type {
+	case "agent":
+		factory, ok := registry[spec.Agent]
+		if !ok {
+			return fail(fmt.Errorf("%w %q", ErrUnknownAgent, spec.Agent))
+		}
+		name := spec.Name
+		if name == "" {
+			name = spec.Agent
+		}
+		agent, err := factory(name, spec.Config)
+		if err != nil {
+			return fail(fmt.Errorf("failed to build agent %q: %w", spec.Agent, err))
+		}
+		return agent, nil
+
+	case "sequential", "parallel", "fallback", "debate":
+		agents, err := buildChildren(spec.Agents, path, registry)
+		if err != nil {
+			return fail(err)
+		}
+		switch spec.Type {
+		case "sequential":
+			agent, err := NewSequentialAgent(spec.Name, agents...)
+			if err != nil {
+				return fail(err)
+			}
+			if spec.StepWeights != nil {
+				if err := agent.SetStepWeights(spec.StepWeights...); err != nil {
+					return fail(err)
+				}
+			}
+			return agent, nil
+		case "parallel":
+			agent, err := NewParallelAgent(spec.Name, agents...)
+			if err != nil {
+				return fail(err)
+			}
+			mode, err := parseParallelMode(spec.Mode, spec.Quorum)
+			if err != nil {
+				return fail(err)
+			}
+			agent.SetMode(mode)
+			agent.SetCancelOnError(spec.CancelOnError)
+			return agent, nil
+		case "fallback":
+			agent, err := NewFallbackAgent(spec.Name, agents...)
+			if err != nil {
+				return fail(err)
+			}
+			return agent, nil
+		default:
+			var judge agenkit.Agent
+			if spec.Judge != nil {
+				if judge, err = buildNode(spec.Judge, path+".judge", registry); err != nil {
+					return fail(err)
+				}
+			}
+			agent, err := NewDebateAgent(spec.Name, agents, spec.Rounds, judge)
+			if err != nil {
+				return fail(err)
+			}
+			return agent, nil
+		}
+
+	case "retry":
+		if len(spec.Agents) != 1 {
+			return fail(fmt.Errorf("retry requires exactly one agent, got %d", len(spec.Agents)))
+		}
+		inner, err := buildNode(spec.Agents[0], path+".agents[0]", registry)
+		if err != nil {
+			return fail(err)
+		}
+		agent, err := NewRetryAgent(inner, RetryOptions{MaxAttempts: spec.MaxAttempts})
+		if err != nil {
+			return fail(err)
+		}
+		return agent, nil
+
+	case "router":
+		classifier, err := buildNode(spec.Classifier, path+".classifier", registry)
+		if err != nil {
+			return fail(err)
+		}
+		routes := make(map[string]agenkit.Agent, len(spec.Routes))
+		for _, label := range sortedLabels(spec.Routes) {
+			route, err := buildNode(spec.Routes[label], path+".routes."+label, registry)
+			if err != nil {
+				return fail(err)
+			}
+			routes[label] = route
+		}
+		agent, err := NewRouterAgent(spec.Name, classifier, routes)
+		if err != nil {
+			return fail(err)
+		}
+		if spec.Default != nil {
+			defaultAgent, err := buildNode(spec.Default, path+".default", registry)
+			if err != nil {
+				return fail(err)
+			}
+			agent.SetDefault(defaultAgent)
+		}
+		return agent, nil
+
+	default:
+		return fail(fmt.Errorf("%w %q", ErrUnknownPattern, spec.Type))
+	}
+}
+
+// buildChildren builds the nodes listed under path.agents.
+func buildChildren(specs []*PipelineSpec, path string, registry map[string]AgentFactory) ([]agenkit.Agent, error) {
+	agents := make([]agenkit.Agent, len(specs))
+	for i, child := range specs {
+		agent, err := buildNode(child, fmt.Sprintf("%s.agents[%d]", path, i), registry)
+		if err != nil {
+			return nil, err
+		}
+		agents[i] = agent
+	}
+	return agents, nil
+}
+
+// parseParallelMode converts a spec's mode name to a ParallelMode.
+func parseParallelMode(mode string, quorum int) (ParallelMode, error) {
+	switch mode {
+	case "", "all":
+		return ModeAll, nil
+	case "first":
+		return ModeFirst, nil
+	case "quorum":
+		if quorum < 1 {
+			return ParallelMode{}, fmt.Errorf("quorum mode requires a positive quorum, got %d", quorum)
+		}
+		return ModeQuorum(quorum, nil), nil
+	default:
+		return ParallelMode{}, fmt.Errorf("unknown parallel mode %q", mode)
+	}
+}
+
+// DumpPipeline serializes an agent tree as a JSON pipeline spec that
+// LoadPipeline can rebuild.
+//
+// Agents other than the supported patterns are written as "agent" nodes
+// named by Name(), so rebuilding requires a factory registered under each
+// leaf's name; leaf config is not recovered. Behaviour set through
+// functions, such as custom retry predicates, quorum comparisons or a
+// SequentialAgent's step estimator, cannot be serialized and is dropped;
+// step weights are kept. Early stops with StopSequence are requested by
+// the agents at run time and need no serializing. Patterns with no declarative form, such as
+// ConditionalAgent, LoopAgent, MapReduceAgent, EnsembleAgent and
+// TransformAgent, return an error, as does CachedAgent, whose cache cannot
+// be described in a spec.
+func DumpPipeline(agent agenkit.Agent) ([]byte, error) {
+	spec, err := dumpNode(agent, "$")
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(spec, "", "  ")
+}
+
+// dumpNode describes the agent at path.
+func dumpNode(agent agenkit.Agent, path string) (*PipelineSpec, error) {
+	children := func(agents []agenkit.Agent) ([]*PipelineSpec, error) {
+		specs := make([]*PipelineSpec, len(agents))
+		for i, child := range agents {
+			spec, err := dumpNode(child, fmt.Sprintf("%s.agents[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			specs[i] = spec
+		}
+		return specs, nil
+	}
+
+	switch a := agent.(type) {
+	case *SequentialAgent:
+		agents, err := children(a.agents)
+		if err != nil {
+			return nil, err
+		}
+		return &PipelineSpec{Type: "sequential", Name: a.name, Agents: agents, StepWeights: a.weights}, nil
+
+	case *ParallelAgent:
+		agents, err := children(a.agents)
+		if err != nil {
+			return nil, err
+		}
+		spec := &PipelineSpec{Type: "parallel", Name: a.name, Agents: agents, CancelOnError: a.cancelOnError}
+		switch a.mode.kind {
+		case modeFirst:
+			spec.Mode = "first"
+		case modeQuorum:
+			spec.Mode = "quorum"
+			spec.Quorum = a.mode.quorum
+		}
+		return spec, nil
+
+	case *FallbackAgent:
+		agents, err := children(a.agents)
+		if err != nil {
+			return nil, err
+		}
+		return &PipelineSpec{Type: "fallback", Name: a.name, Agents: agents}, nil
+
+	case *DebateAgent:
+		agents, err := children(a.agents)
+		if err != nil {
+			return nil, err
+		}
+		spec := &PipelineSpec{Type: "debate", Name: a.name, Agents: agents, Rounds: a.rounds}
+		if a.judge != nil {
+			if spec.Judge, err = dumpNode(a.judge, path+".judge"); err != nil {
+				return nil, err
+			}
+		}
+		return spec, nil
+
+	case *RetryAgent:
+		inner, err := dumpNode(a.agent, path+".agents[0]")
+		if err != nil {
+			return nil, err
+		}
+		return &PipelineSpec{Type: "retry", Agents: []*PipelineSpec{inner}, MaxAttempts: a.options.MaxAttempts}, nil
+
+	case *RouterAgent:
+		classifier, err := dumpNode(a.classifier, path+".classifier")
+		if err != nil {
+			return nil, err
+		}
+		spec := &PipelineSpec{Type: "router", Name: a.name, Classifier: classifier, Routes: make(map[string]*PipelineSpec, len(a.routes))}
+		for label, route := range a.routes {
+			if spec.Routes[label], err = dumpNode(route, path+".routes."+label); err != nil {
+				return nil, err
+			}
+		}
+		if a.defaultAgent != nil {
+			if spec.Default, err = dumpNode(a.defaultAgent, path+".default"); err != nil {
+				return nil, err
+			}
+		}
+		return spec, nil
+
+	case *ConditionalAgent, *LoopAgent, *MapReduceAgent, *EnsembleAgent, *TransformAgent, *CachedAgent:
+		return nil, &PipelineError{Path: path, Err: fmt.Errorf("%T has no declarative form", agent)}
+
+	default:
+		return &PipelineSpec{Type: "agent", Agent: agent.Name()}, nil
+	}
+}
+
+// sortedLabels returns the route labels in a stable order, so build errors
+// are reported deterministically.
+func sortedLabels(routes map[string]*PipelineSpec) []string {
+	labels := make([]string, 0, len(routes))
+	for label := range routes {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
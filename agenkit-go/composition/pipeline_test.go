@@ -0,0 +1,190 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// echoRegistry registers factories that build TestAgents replying with
+// their name and the "reply" config value.
+func echoRegistry(names ...string) map[string]AgentFactory {
+	registry := make(map[string]AgentFactory, len(names))
+	for _, key := range names {
+		registry[key] = func(name string, config map[string]interface{}) (agenkit.Agent, error) {
+			reply, _ := config["reply"].(string)
+			return &TestAgent{name: name, response: name + ":" + reply}, nil
+		}
+	}
+	return registry
+}
+
+const nestedPipeline = `{
+  "type": "sequential",
+  "name": "pipeline",
+  "agents": [
+    {"type": "agent", "agent": "writer", "config": {"reply": "draft"}},
+    {
+      "type": "parallel",
+      "name": "reviews",
+      "mode": "first",
+      "agents": [
+        {"type": "agent", "agent": "reviewer", "name": "strict"},
+        {"type": "retry", "max_attempts": 2, "agents": [{"type": "agent", "agent": "reviewer", "name": "lenient"}]}
+      ]
+    }
+  ]
+}`
+
+func TestLoadPipelineNested(t *testing.T) {
+	agent, err := LoadPipeline(strings.NewReader(nestedPipeline), echoRegistry("writer", "reviewer"))
+	if err != nil {
+		t.Fatalf("LoadPipeline failed: %v", err)
+	}
+
+	seq, ok := agent.(*SequentialAgent)
+	if !ok || seq.Name() != "pipeline" || len(seq.GetAgents()) != 2 {
+		t.Fatalf("Expected a two-step sequential pipeline, got %T", agent)
+	}
+	if writer := seq.GetAgents()[0]; writer.Name() != "writer" {
+		t.Errorf("Expected the leaf name to default to its factory, got '%s'", writer.Name())
+	}
+	par, ok := seq.GetAgents()[1].(*ParallelAgent)
+	if !ok || par.mode.kind != modeFirst {
+		t.Fatalf("Expected a first-mode parallel step, got %T", seq.GetAgents()[1])
+	}
+	retry, ok := par.GetAgents()[1].(*RetryAgent)
+	if !ok || retry.options.MaxAttempts != 2 || retry.GetAgent().Name() != "lenient" {
+		t.Errorf("Expected a retry of 'lenient' with 2 attempts, got %T", par.GetAgents()[1])
+	}
+
+	result, err := agent.Process(context.Background(), agenkit.NewMessage("user", "go"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if !strings.HasPrefix(result.Content, "strict:") && !strings.HasPrefix(result.Content, "lenient:") {
+		t.Errorf("Expected a reviewer's response, got '%s'", result.Content)
+	}
+}
+
+func TestLoadPipelineErrorsIncludePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		path   string
+		target error
+	}{
+		{
+			name:   "unknown agent",
+			config: `{"type": "sequential", "agents": [{"type": "agent", "agent": "writer"}, {"type": "agent", "agent": "ghost"}]}`,
+			path:   "$.agents[1]",
+			target: ErrUnknownAgent,
+		},
+		{
+			name:   "unknown pattern",
+			config: `{"type": "router", "classifier": {"type": "agent", "agent": "writer"}, "routes": {"billing": {"type": "swarm"}}}`,
+			path:   "$.routes.billing",
+			target: ErrUnknownPattern,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPipeline(strings.NewReader(tt.config), echoRegistry("writer"))
+			var pipelineErr *PipelineError
+			if !errors.As(err, &pipelineErr) || !errors.Is(err, tt.target) {
+				t.Fatalf("Expected a PipelineError wrapping %v, got %v", tt.target, err)
+			}
+			if pipelineErr.Path != tt.path {
+				t.Errorf("Expected path '%s', got '%s'", tt.path, pipelineErr.Path)
+			}
+		})
+	}
+
+	if _, err := LoadPipeline(strings.NewReader(`{"type": "agent", "agnet": "writer"}`), echoRegistry("writer")); err == nil {
+		t.Error("Expected error for an unknown field")
+	}
+}
+
+func TestLoadPipelineFactoryError(t *testing.T) {
+	registry := map[string]AgentFactory{
+		"broken": func(string, map[string]interface{}) (agenkit.Agent, error) {
+			return nil, fmt.Errorf("missing api key")
+		},
+	}
+	_, err := LoadPipeline(strings.NewReader(`{"type": "fallback", "agents": [{"type": "agent", "agent": "broken"}]}`), registry)
+	if err == nil || !strings.Contains(err.Error(), "$.agents[0]") || !strings.Contains(err.Error(), "missing api key") {
+		t.Errorf("Expected the factory error with its path, got %v", err)
+	}
+}
+
+func TestDumpPipelineRoundTrip(t *testing.T) {
+	classifier := &TestAgent{name: "classifier"}
+	billing := &TestAgent{name: "billing"}
+	support := &TestAgent{name: "support"}
+	router, _ := NewRouterAgent("router", classifier, map[string]agenkit.Agent{"billing": billing})
+	router.SetDefault(support)
+	par, _ := NewParallelAgent("votes", &TestAgent{name: "a"}, &TestAgent{name: "b"})
+	par.SetMode(ModeQuorum(2, nil))
+	par.SetCancelOnError(true)
+	retry, _ := NewRetryAgent(par, RetryOptions{MaxAttempts: 5})
+	original, _ := NewSequentialAgent("pipeline", router, retry)
+	original.SetStepWeights(1, 3)
+
+	data, err := DumpPipeline(original)
+	if err != nil {
+		t.Fatalf("DumpPipeline failed: %v", err)
+	}
+	rebuilt, err := LoadPipeline(strings.NewReader(string(data)), echoRegistry("classifier", "billing", "support", "a", "b"))
+	if err != nil {
+		t.Fatalf("LoadPipeline failed on dumped spec: %v\n%s", err, data)
+	}
+
+	again, err := DumpPipeline(rebuilt)
+	if err != nil {
+		t.Fatalf("DumpPipeline failed: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("Expected the rebuilt pipeline to dump identically, got:\n%s\nwant:\n%s", again, data)
+	}
+	rebuiltPar := rebuilt.(*SequentialAgent).GetAgents()[1].(*RetryAgent).GetAgent().(*ParallelAgent)
+	if rebuiltPar.mode.quorum != 2 || !rebuiltPar.cancelOnError {
+		t.Errorf("Expected parallel options to survive the round trip, got %v", rebuiltPar.mode)
+	}
+	if weights := rebuilt.(*SequentialAgent).weights; len(weights) != 2 || weights[1] != 3 {
+		t.Errorf("Expected step weights to survive the round trip, got %v", weights)
+	}
+}
+
+func TestDumpPipelineRejectsFunctionPatterns(t *testing.T) {
+	loop, _ := NewLoopAgent("loop", &TestAgent{name: "body"}, func(context.Context, *agenkit.Message) (bool, error) {
+		return false, nil
+	}, 3)
+	seq, _ := NewSequentialAgent("pipeline", &TestAgent{name: "first"}, loop)
+
+	_, err := DumpPipeline(seq)
+	var pipelineErr *PipelineError
+	if !errors.As(err, &pipelineErr) || pipelineErr.Path != "$.agents[1]" {
+		t.Errorf("Expected a PipelineError at $.agents[1], got %v", err)
+	}
+}
+
+func TestDumpPipelineRejectsOpaquePatterns(t *testing.T) {
+	ensemble, _ := NewEnsembleAgent("ensemble", []agenkit.Agent{&TestAgent{name: "a"}}, ConcatResults)
+	transform := NewTransform("upper", func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+		return message, nil
+	})
+
+	cached, _ := NewCachedAgent(&TestAgent{name: "slow"}, llm.NewLRUCache(10), nil)
+
+	for _, agent := range []agenkit.Agent{ensemble, transform, cached} {
+		if _, err := DumpPipeline(agent); err == nil {
+			t.Errorf("Expected %T to have no declarative form", agent)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package composition
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// RetryOptions configures the retry pattern.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts (including the initial attempt).
+	// Default: 3
+	MaxAttempts int
+
+	// Backoff returns the delay to wait after the given failed attempt (1-based).
+	// If nil, exponential backoff with full jitter is used. A longer wait
+	// requested by an agenkit.RateLimitError takes precedence.
+	Backoff func(attempt int) time.Duration
+
+	// ShouldRetry decides whether the outcome of an attempt warrants another try.
+	// It sees both the response and the error, so a successful but unusable
+	// response can be retried too. Returning false stops immediately.
+	// If nil, errors are retried when agenkit.IsRetryable reports true and
+	// every response is accepted.
+	ShouldRetry func(resp *agenkit.Message, err error) bool
+}
+
+// RetryAgent re-invokes a wrapped agent until it succeeds or attempts are exhausted.
+type RetryAgent struct {
+	agent   agenkit.Agent
+	options RetryOptions
+}
+
+// Verify that RetryAgent implements Agent interface.
+var _ agenkit.Agent = (*RetryAgent)(nil)
+
+// NewRetryAgent creates a new retry agent around the given agent.
+func NewRetryAgent(agent agenkit.Agent, options RetryOptions) (*RetryAgent, error) {
+	if agent == nil {
+		return nil, fmt.Errorf("retry agent requires an agent")
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 3
+	}
+	if options.Backoff == nil {
+		options.Backoff = defaultRetryBackoff
+	}
+	return &RetryAgent{
+		agent:   agent,
+		options: options,
+	}, nil
+}
+
+// Name returns the name of the wrapped agent.
+func (r *RetryAgent) Name() string {
+	return r.agent.Name()
+}
+
+// Capabilities returns the wrapped agent's capabilities plus "retry".
+func (r *RetryAgent) Capabilities() []string {
+	return append(r.agent.Capabilities(), "retry")
+}
+
+// Process invokes the wrapped agent, retrying according to the configured options.
+func (r *RetryAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	var lastErr error
+
+	for attempt := 1; attempt <= r.options.MaxAttempts; attempt++ {
+		response, err := r.agent.Process(ctx, message)
+
+		if !r.shouldRetry(response, err) {
+			if err != nil {
+				return nil, fmt.Errorf("non-retryable error on attempt %d/%d: %w", attempt, r.options.MaxAttempts, err)
+			}
+			return response, nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("response rejected by retry predicate")
+		}
+
+		// Don't sleep after the last attempt
+		if attempt == r.options.MaxAttempts {
+			break
+		}
+		agenkit.Logger(ctx).InfoContext(ctx, "retrying agent",
+			slog.String(agenkit.LogKeyPattern, "retry"),
+			slog.String(agenkit.LogKeyAgent, r.agent.Name()),
+			slog.Int("attempt", attempt),
+			slog.String(agenkit.LogKeyError, lastErr.Error()),
+		)
+
+		// Wait before retrying, honoring cancellation
+		delay := r.options.Backoff(attempt)
+		if after, ok := agenkit.RetryAfter(err); ok && after > delay {
+			delay = after
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("retry cancelled after %d attempts: %w", attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	return nil, fmt.Errorf("all %d attempts failed: %w", r.options.MaxAttempts, lastErr)
+}
+
+// shouldRetry applies the configured predicate, defaulting to retrying
+// retryable errors.
+func (r *RetryAgent) shouldRetry(response *agenkit.Message, err error) bool {
+	if r.options.ShouldRetry != nil {
+		return r.options.ShouldRetry(response, err)
+	}
+	return agenkit.IsRetryable(err)
+}
+
+// GetAgent returns the wrapped agent.
+func (r *RetryAgent) GetAgent() agenkit.Agent {
+	return r.agent
+}
+
+// defaultRetryBackoff returns exponential backoff (100ms base, 10s cap) with full jitter.
+var defaultRetryBackoff = agenkit.NewExponentialBackoff(100*time.Millisecond, 10*time.Second, 2).Next
@@ -0,0 +1,183 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// flakyAgent fails a fixed number of times before succeeding.
+type flakyAgent struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyAgent) Name() string           { return "flaky" }
+func (f *flakyAgent) Capabilities() []string { return []string{"test"} }
+
+func (f *flakyAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("transient failure")
+	}
+	return agenkit.NewMessage("agent", "ok"), nil
+}
+
+func noBackoff(int) time.Duration { return 0 }
+
+func TestRetryAgentEventualSuccess(t *testing.T) {
+	inner := &flakyAgent{failures: 2}
+	retry, err := NewRetryAgent(inner, RetryOptions{MaxAttempts: 3, Backoff: noBackoff})
+	if err != nil {
+		t.Fatalf("Failed to create retry agent: %v", err)
+	}
+
+	result, err := retry.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "ok" {
+		t.Errorf("Expected 'ok', got '%s'", result.Content)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+}
+
+func TestRetryAgentExhausted(t *testing.T) {
+	inner := &flakyAgent{failures: 10}
+	retry, _ := NewRetryAgent(inner, RetryOptions{MaxAttempts: 3, Backoff: noBackoff})
+
+	_, err := retry.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil {
+		t.Fatal("Expected error after exhausting attempts")
+	}
+	if !strings.Contains(err.Error(), "3 attempts") {
+		t.Errorf("Expected attempt count in error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "transient failure") {
+		t.Errorf("Expected wrapped last error, got: %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+}
+
+func TestRetryAgentPredicateShortCircuits(t *testing.T) {
+	inner := &flakyAgent{failures: 10}
+	retry, _ := NewRetryAgent(inner, RetryOptions{
+		MaxAttempts: 5,
+		Backoff:     noBackoff,
+		ShouldRetry: func(resp *agenkit.Message, err error) bool { return false },
+	})
+
+	_, err := retry.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected a single call when predicate rejects retry, got %d", inner.calls)
+	}
+}
+
+func TestRetryAgentRetriesRejectedResponse(t *testing.T) {
+	inner := &TestAgent{name: "empty", response: ""}
+	retry, _ := NewRetryAgent(inner, RetryOptions{
+		MaxAttempts: 3,
+		Backoff:     noBackoff,
+		ShouldRetry: func(resp *agenkit.Message, err error) bool {
+			return err != nil || resp.Content == ""
+		},
+	})
+
+	_, err := retry.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil {
+		t.Fatal("Expected error when every response is rejected")
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", inner.calls)
+	}
+}
+
+func TestRetryAgentContextCancelledDuringBackoff(t *testing.T) {
+	inner := &flakyAgent{failures: 10}
+	retry, _ := NewRetryAgent(inner, RetryOptions{
+		MaxAttempts: 5,
+		Backoff:     func(int) time.Duration { return time.Hour },
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := retry.Process(ctx, agenkit.NewMessage("user", "hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Backoff sleep did not honor context cancellation")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 call before cancellation, got %d", inner.calls)
+	}
+}
+
+func TestRetryAgentDefaultBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 20; attempt++ {
+		d := defaultRetryBackoff(attempt)
+		if d < 0 || d > 10*time.Second {
+			t.Errorf("Backoff for attempt %d out of range: %v", attempt, d)
+		}
+	}
+}
+
+func TestRetryAgentNilAgent(t *testing.T) {
+	if _, err := NewRetryAgent(nil, RetryOptions{}); err == nil {
+		t.Fatal("Expected error when creating retry agent without an agent")
+	}
+}
+
+// errAgent always fails with the same error.
+type errAgent struct {
+	err   error
+	calls int
+}
+
+func (e *errAgent) Name() string           { return "err" }
+func (e *errAgent) Capabilities() []string { return nil }
+func (e *errAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	e.calls++
+	return nil, e.err
+}
+
+func TestRetryAgentSkipsNonRetryableErrors(t *testing.T) {
+	inner := &errAgent{err: &agenkit.ContextLengthError{Provider: "p", Limit: 8, Requested: 9}}
+	retry, _ := NewRetryAgent(inner, RetryOptions{MaxAttempts: 3, Backoff: noBackoff})
+
+	_, err := retry.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	var contextErr *agenkit.ContextLengthError
+	if !errors.As(err, &contextErr) {
+		t.Fatalf("Expected ContextLengthError, got %v", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected no retries, got %d calls", inner.calls)
+	}
+}
+
+func TestRetryAgentHonorsRetryAfter(t *testing.T) {
+	inner := &errAgent{err: &agenkit.RateLimitError{Provider: "p", RetryAfter: 50 * time.Millisecond}}
+	retry, _ := NewRetryAgent(inner, RetryOptions{MaxAttempts: 2, Backoff: noBackoff})
+
+	start := time.Now()
+	retry.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait at least RetryAfter, waited %v", elapsed)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", inner.calls)
+	}
+}
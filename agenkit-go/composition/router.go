@@ -0,0 +1,218 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrNoRoute is returned when the classifier's label matches no route and no
+// default agent is configured.
+var ErrNoRoute = errors.New("no route for classifier label")
+
+// routerCacheSize bounds the number of cached classifications per router.
+const routerCacheSize = 1024
+
+// RouterAgent dispatches each message to a specialist agent chosen by a classifier.
+//
+// The classifier agent receives the original message and must reply with a
+// label naming one of the routes. The router then forwards the original
+// message (not the classifier's reply) to the matching agent. Labels are
+// matched case-insensitively after trimming whitespace.
+//
+// Classifications are cached per session (the "session_id" metadata key) and
+// message content, so repeating an identical input within a session does not
+// call the classifier again.
+type RouterAgent struct {
+	name         string
+	classifier   agenkit.Agent
+	routes       map[string]agenkit.Agent
+	defaultAgent agenkit.Agent
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// Verify that RouterAgent implements Agent interface.
+var _ agenkit.Agent = (*RouterAgent)(nil)
+
+// NewRouterAgent creates a new router agent.
+func NewRouterAgent(name string, classifier agenkit.Agent, routes map[string]agenkit.Agent) (*RouterAgent, error) {
+	if classifier == nil {
+		return nil, fmt.Errorf("router agent requires a classifier")
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("router agent requires at least one route")
+	}
+
+	normalized := make(map[string]agenkit.Agent, len(routes))
+	for label, agent := range routes {
+		normalized[normalizeLabel(label)] = agent
+	}
+
+	return &RouterAgent{
+		name:       name,
+		classifier: classifier,
+		routes:     normalized,
+		cache:      make(map[string]string),
+	}, nil
+}
+
+// SetDefault sets the agent used when the classifier returns an unknown label.
+func (r *RouterAgent) SetDefault(agent agenkit.Agent) {
+	r.defaultAgent = agent
+}
+
+// Name returns the name of the router agent.
+func (r *RouterAgent) Name() string {
+	return r.name
+}
+
+// Capabilities returns combined capabilities of all route agents.
+func (r *RouterAgent) Capabilities() []string {
+	capsSet := make(map[string]bool)
+	for _, agent := range r.routes {
+		for _, cap := range agent.Capabilities() {
+			capsSet[cap] = true
+		}
+	}
+	if r.defaultAgent != nil {
+		for _, cap := range r.defaultAgent.Capabilities() {
+			capsSet[cap] = true
+		}
+	}
+
+	caps := make([]string, 0, len(capsSet))
+	for cap := range capsSet {
+		caps = append(caps, cap)
+	}
+	caps = append(caps, "router")
+	return caps
+}
+
+// Process classifies the message and forwards it to the selected route.
+func (r *RouterAgent) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "pattern.router",
+		attribute.String("agent.name", r.name),
+		attribute.String("pattern.type", "router"),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, r.name, message)
+	defer func() { finish(result, err) }()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("routing cancelled: %w", ctx.Err())
+	default:
+	}
+
+	label, err := r.classify(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	agent, ok := r.routes[label]
+	route := label
+	if !ok {
+		if r.defaultAgent == nil {
+			return nil, fmt.Errorf("%w: %q", ErrNoRoute, label)
+		}
+		agent = r.defaultAgent
+		route = "default"
+	}
+	span.SetAttributes(
+		attribute.String("router.label", label),
+		attribute.String("router.route", route),
+	)
+	agenkit.Logger(ctx).DebugContext(ctx, "route selected",
+		slog.String(agenkit.LogKeyPattern, "router"),
+		slog.String(agenkit.LogKeyAgent, r.name),
+		slog.String("label", label),
+		slog.String("route", route),
+	)
+
+	result, err = agenkit.ProcessWithSpan(ctx, agent, message)
+	if err != nil {
+		return nil, fmt.Errorf("route %q (%s) failed: %w", route, agent.Name(), err)
+	}
+
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["router_route"] = route
+	result.Metadata["router_label"] = label
+	result.Metadata["router_agent_used"] = agent.Name()
+	return result, nil
+}
+
+// classify returns the normalized label for a message, consulting the cache first.
+func (r *RouterAgent) classify(ctx context.Context, message *agenkit.Message) (string, error) {
+	key := routerCacheKey(message)
+
+	r.mu.Lock()
+	label, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return label, nil
+	}
+
+	response, err := agenkit.ProcessWithSpan(ctx, r.classifier, message, attribute.String("router.role", "classifier"))
+	if err != nil {
+		return "", fmt.Errorf("classifier (%s) failed: %w", r.classifier.Name(), err)
+	}
+	label = normalizeLabel(response.Content)
+
+	r.mu.Lock()
+	if len(r.cache) >= routerCacheSize {
+		r.cache = make(map[string]string)
+	}
+	r.cache[key] = label
+	r.mu.Unlock()
+
+	return label, nil
+}
+
+// ClearCache discards all cached classifications.
+func (r *RouterAgent) ClearCache() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = make(map[string]string)
+}
+
+// GetRoutes returns the route agents keyed by normalized label.
+func (r *RouterAgent) GetRoutes() map[string]agenkit.Agent {
+	return r.routes
+}
+
+// GetClassifier returns the classifier agent.
+func (r *RouterAgent) GetClassifier() agenkit.Agent {
+	return r.classifier
+}
+
+// GetDefaultAgent returns the default agent.
+func (r *RouterAgent) GetDefaultAgent() agenkit.Agent {
+	return r.defaultAgent
+}
+
+// routerCacheKey identifies an input within its session.
+func routerCacheKey(message *agenkit.Message) string {
+	session := ""
+	if message.Metadata != nil {
+		if id, ok := message.Metadata["session_id"]; ok {
+			session = fmt.Sprint(id)
+		}
+	}
+	return session + "\x00" + message.Role + "\x00" + message.Content
+}
+
+// normalizeLabel makes classifier labels comparable with route keys.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
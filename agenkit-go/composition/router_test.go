@@ -0,0 +1,121 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestRouterAgentRoutesByLabel(t *testing.T) {
+	ctx := context.Background()
+
+	classifier := &TestAgent{name: "classifier", response: " Billing\n"}
+	billing := &TestAgent{name: "billing", response: "billing answer"}
+	support := &TestAgent{name: "support", response: "support answer"}
+
+	router, err := NewRouterAgent("router", classifier, map[string]agenkit.Agent{
+		"billing": billing,
+		"support": support,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	result, err := router.Process(ctx, agenkit.NewMessage("user", "my invoice is wrong"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "billing answer" {
+		t.Errorf("Expected billing answer, got '%s'", result.Content)
+	}
+	if result.Metadata["router_route"] != "billing" {
+		t.Errorf("Expected router_route=billing, got %v", result.Metadata["router_route"])
+	}
+	if result.Metadata["router_agent_used"] != "billing" {
+		t.Errorf("Expected router_agent_used=billing, got %v", result.Metadata["router_agent_used"])
+	}
+	if support.calls != 0 {
+		t.Error("Expected support agent not to be called")
+	}
+}
+
+func TestRouterAgentUnknownLabel(t *testing.T) {
+	ctx := context.Background()
+
+	classifier := &TestAgent{name: "classifier", response: "weather"}
+	router, _ := NewRouterAgent("router", classifier, map[string]agenkit.Agent{
+		"billing": &TestAgent{name: "billing", response: "billing answer"},
+	})
+
+	_, err := router.Process(ctx, agenkit.NewMessage("user", "is it raining?"))
+	if !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("Expected ErrNoRoute, got %v", err)
+	}
+
+	fallback := &TestAgent{name: "generalist", response: "general answer"}
+	router.SetDefault(fallback)
+
+	result, err := router.Process(ctx, agenkit.NewMessage("user", "is it raining?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "general answer" {
+		t.Errorf("Expected default answer, got '%s'", result.Content)
+	}
+	if result.Metadata["router_route"] != "default" {
+		t.Errorf("Expected router_route=default, got %v", result.Metadata["router_route"])
+	}
+}
+
+func TestRouterAgentCachesClassificationPerSession(t *testing.T) {
+	ctx := context.Background()
+
+	classifier := &TestAgent{name: "classifier", response: "billing"}
+	router, _ := NewRouterAgent("router", classifier, map[string]agenkit.Agent{
+		"billing": &TestAgent{name: "billing", response: "billing answer"},
+	})
+
+	msg := func(session string) *agenkit.Message {
+		return agenkit.NewMessage("user", "refund please").WithMetadata("session_id", session)
+	}
+
+	_, _ = router.Process(ctx, msg("s1"))
+	_, _ = router.Process(ctx, msg("s1"))
+	if classifier.calls != 1 {
+		t.Errorf("Expected classifier called once within a session, got %d", classifier.calls)
+	}
+
+	_, _ = router.Process(ctx, msg("s2"))
+	if classifier.calls != 2 {
+		t.Errorf("Expected classifier called again for a new session, got %d", classifier.calls)
+	}
+
+	router.ClearCache()
+	_, _ = router.Process(ctx, msg("s1"))
+	if classifier.calls != 3 {
+		t.Errorf("Expected classifier called after cache clear, got %d", classifier.calls)
+	}
+}
+
+func TestRouterAgentClassifierError(t *testing.T) {
+	classifier := &TestAgent{name: "classifier", err: errors.New("classifier down")}
+	router, _ := NewRouterAgent("router", classifier, map[string]agenkit.Agent{
+		"billing": &TestAgent{name: "billing", response: "billing answer"},
+	})
+
+	_, err := router.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil {
+		t.Fatal("Expected classifier error to propagate")
+	}
+}
+
+func TestRouterAgentValidation(t *testing.T) {
+	if _, err := NewRouterAgent("router", nil, map[string]agenkit.Agent{"a": &TestAgent{}}); err == nil {
+		t.Error("Expected error without classifier")
+	}
+	if _, err := NewRouterAgent("router", &TestAgent{}, nil); err == nil {
+		t.Error("Expected error without routes")
+	}
+}
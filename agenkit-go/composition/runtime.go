@@ -0,0 +1,201 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Runtime starts and stops the components of an agent tree that hold
+// resources: every agent, middleware wrapper and tool in the tree that
+// implements agenkit.Lifecycle, plus any extra components given, such as a
+// tool provider whose connection the tree's tools share.
+//
+// Start starts the extra components first, in order, then the tree from
+// the leaves up, so each agent starts after the agents and tools it uses.
+// If a component fails to start, the ones already started are stopped in
+// reverse order before Start returns. Stop stops everything in the reverse
+// of start order. A component appearing more than once is started once.
+//
+// Runtime itself implements agenkit.Lifecycle, so a server can drive it:
+//
+//	runtime := composition.NewRuntime(agent, mcpProvider)
+//	srv := server.NewServer(agent)
+//	srv.SetLifecycle(runtime)
+type Runtime struct {
+	components []agenkit.Lifecycle
+
+	mu      sync.Mutex
+	started []agenkit.Lifecycle
+}
+
+// Verify that Runtime implements agenkit.Lifecycle interface.
+var _ agenkit.Lifecycle = (*Runtime)(nil)
+
+// NewRuntime creates a runtime for the tree under root and the extra
+// components. root may be nil to manage only the extra components.
+func NewRuntime(root agenkit.Agent, components ...agenkit.Lifecycle) *Runtime {
+	c := &collector{added: make(map[interface{}]bool), walked: make(map[agenkit.Agent]bool)}
+	for _, component := range components {
+		c.add(component)
+	}
+	if root != nil {
+		c.walk(root)
+	}
+	return &Runtime{components: c.components}
+}
+
+// Components returns the managed components in start order.
+func (r *Runtime) Components() []agenkit.Lifecycle {
+	return append([]agenkit.Lifecycle(nil), r.components...)
+}
+
+// Start starts every component, rolling back on failure. It returns an
+// error if the runtime is already started.
+func (r *Runtime) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started != nil {
+		return fmt.Errorf("runtime already started")
+	}
+
+	started := make([]agenkit.Lifecycle, 0, len(r.components))
+	for _, component := range r.components {
+		if err := component.Start(ctx); err != nil {
+			err = fmt.Errorf("start %s: %w", componentName(component), err)
+			// Roll back even if ctx is what made the start fail
+			rollback := stopAll(context.WithoutCancel(ctx), started)
+			return errors.Join(err, rollback)
+		}
+		started = append(started, component)
+	}
+	r.started = started
+	return nil
+}
+
+// Stop stops the started components in reverse order, returning every
+// failure joined. Stopping a runtime that is not started does nothing.
+func (r *Runtime) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	started := r.started
+	r.started = nil
+	return stopAll(ctx, started)
+}
+
+// stopAll stops components in reverse order, carrying on past failures.
+func stopAll(ctx context.Context, components []agenkit.Lifecycle) error {
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		if err := components[i].Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", componentName(components[i]), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// componentName identifies a component in errors.
+func componentName(component agenkit.Lifecycle) string {
+	switch c := component.(type) {
+	case agenkit.Agent:
+		return c.Name()
+	case agenkit.Tool:
+		return c.Name()
+	}
+	return fmt.Sprintf("%T", component)
+}
+
+// collector gathers the Lifecycle components of an agent tree in start
+// order.
+type collector struct {
+	added      map[interface{}]bool
+	walked     map[agenkit.Agent]bool
+	components []agenkit.Lifecycle
+}
+
+// add records component unless it has been seen. Values of non-comparable
+// types cannot be tracked and are always added.
+func (c *collector) add(component agenkit.Lifecycle) {
+	if reflect.TypeOf(component).Comparable() {
+		if c.added[component] {
+			return
+		}
+		c.added[component] = true
+	}
+	c.components = append(c.components, component)
+}
+
+// walk records the components under agent, children first.
+func (c *collector) walk(agent agenkit.Agent) {
+	if reflect.TypeOf(agent).Comparable() {
+		if c.walked[agent] {
+			return
+		}
+		c.walked[agent] = true
+	}
+
+	if user, ok := agent.(agenkit.ToolUser); ok {
+		for _, tool := range user.Tools() {
+			if component, ok := tool.(agenkit.Lifecycle); ok {
+				c.add(component)
+			}
+		}
+	}
+	for _, child := range childAgents(agent) {
+		if child != nil {
+			c.walk(child)
+		}
+	}
+	if component, ok := agent.(agenkit.Lifecycle); ok {
+		c.add(component)
+	}
+}
+
+// childAgents returns the agents agent delegates to: the inner agent of
+// middleware, the members of this package's patterns, or the agents of a
+// custom agent with a GetAgents method.
+func childAgents(agent agenkit.Agent) []agenkit.Agent {
+	switch a := agent.(type) {
+	case *SequentialAgent:
+		return a.agents
+	case *ParallelAgent:
+		return a.agents
+	case *FallbackAgent:
+		return a.agents
+	case *EnsembleAgent:
+		return a.agents
+	case *RetryAgent:
+		return []agenkit.Agent{a.agent}
+	case *LoopAgent:
+		return []agenkit.Agent{a.body}
+	case *MapReduceAgent:
+		return []agenkit.Agent{a.mapper}
+	case *DebateAgent:
+		return append(append([]agenkit.Agent(nil), a.agents...), a.judge)
+	case *RouterAgent:
+		agents := []agenkit.Agent{a.classifier}
+		for _, label := range sortedRoutes(a.routes) {
+			agents = append(agents, a.routes[label])
+		}
+		return append(agents, a.defaultAgent)
+	case *ConditionalAgent:
+		var agents []agenkit.Agent
+		for _, route := range a.routes {
+			agents = append(agents, route.Agent)
+		}
+		return append(agents, a.defaultAgent)
+	case *TransformAgent:
+		return nil
+	}
+	if wrapper, ok := agent.(interface{ Unwrap() agenkit.Agent }); ok {
+		return []agenkit.Agent{wrapper.Unwrap()}
+	}
+	if group, ok := agent.(interface{ GetAgents() []agenkit.Agent }); ok {
+		return group.GetAgents()
+	}
+	return nil
+}
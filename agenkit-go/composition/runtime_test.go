@@ -0,0 +1,114 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/middleware"
+)
+
+// lifecycleLog records Start and Stop calls in order.
+type lifecycleLog struct {
+	calls []string
+}
+
+// lifecycleAgent is a TestAgent holding a resource.
+type lifecycleAgent struct {
+	TestAgent
+	log      *lifecycleLog
+	startErr error
+}
+
+func (a *lifecycleAgent) Start(ctx context.Context) error {
+	a.log.calls = append(a.log.calls, "start:"+a.name)
+	return a.startErr
+}
+
+func (a *lifecycleAgent) Stop(ctx context.Context) error {
+	a.log.calls = append(a.log.calls, "stop:"+a.name)
+	return nil
+}
+
+// lifecycleTool is a namedTool holding a resource.
+type lifecycleTool struct {
+	namedTool
+	log *lifecycleLog
+}
+
+func (t *lifecycleTool) Start(ctx context.Context) error {
+	t.log.calls = append(t.log.calls, "start:"+t.Name())
+	return nil
+}
+
+func (t *lifecycleTool) Stop(ctx context.Context) error {
+	t.log.calls = append(t.log.calls, "stop:"+t.Name())
+	return nil
+}
+
+func TestRuntimeStartsTreeChildrenFirst(t *testing.T) {
+	log := &lifecycleLog{}
+	db := &lifecycleAgent{TestAgent: TestAgent{name: "db"}, log: log}
+	search := &lifecycleTool{namedTool: "search", log: log}
+	research := &toolAgent{TestAgent: TestAgent{name: "research"}, tools: []agenkit.Tool{search, namedTool("calculator")}}
+	writer := &TestAgent{name: "writer"}
+	review, _ := NewParallelAgent("review", middleware.Serialize(db), research)
+	pipeline, _ := NewSequentialAgent("pipeline", writer, review, db)
+	provider := &lifecycleAgent{TestAgent: TestAgent{name: "provider"}, log: log}
+
+	runtime := NewRuntime(pipeline, provider)
+	if err := runtime.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := runtime.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	// db appears twice but starts once
+	want := "start:provider start:db start:search stop:search stop:db stop:provider"
+	if got := strings.Join(log.calls, " "); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestRuntimeRollsBackFailedStart(t *testing.T) {
+	log := &lifecycleLog{}
+	errDown := errors.New("connection refused")
+	first := &lifecycleAgent{TestAgent: TestAgent{name: "first"}, log: log}
+	second := &lifecycleAgent{TestAgent: TestAgent{name: "second"}, log: log}
+	broken := &lifecycleAgent{TestAgent: TestAgent{name: "broken"}, log: log, startErr: errDown}
+	last := &lifecycleAgent{TestAgent: TestAgent{name: "last"}, log: log}
+	pipeline, _ := NewSequentialAgent("pipeline", first, second, broken, last)
+
+	runtime := NewRuntime(pipeline)
+	err := runtime.Start(context.Background())
+	if !errors.Is(err, errDown) || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("Expected the start error naming the component, got %v", err)
+	}
+	want := "start:first start:second start:broken stop:second stop:first"
+	if got := strings.Join(log.calls, " "); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Nothing is left running, so Stop has nothing to do
+	log.calls = nil
+	if err := runtime.Stop(context.Background()); err != nil || len(log.calls) != 0 {
+		t.Errorf("Expected Stop to do nothing after a failed Start, got %v (%v)", log.calls, err)
+	}
+}
+
+func TestRuntimeRejectsSecondStart(t *testing.T) {
+	log := &lifecycleLog{}
+	runtime := NewRuntime(&lifecycleAgent{TestAgent: TestAgent{name: "db"}, log: log})
+	if err := runtime.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := runtime.Start(context.Background()); err == nil {
+		t.Error("Expected error starting a started runtime")
+	}
+	if len(runtime.Components()) != 1 || len(log.calls) != 1 {
+		t.Errorf("Expected one component started once, got %v", log.calls)
+	}
+}
@@ -3,20 +3,94 @@ package composition
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/agenkit/agenkit-go/agenkit"
 )
 
+// StepEstimator predicts the duration of the next step from the durations
+// of the steps already run in the current execution, oldest first.
+type StepEstimator func(durations []time.Duration) time.Duration
+
+// MovingAverageEstimator returns a StepEstimator that averages the last
+// window step durations (all of them if window <= 0). Before any step has
+// run it estimates zero, so the first step always runs.
+func MovingAverageEstimator(window int) StepEstimator {
+	return func(durations []time.Duration) time.Duration {
+		if window > 0 && len(durations) > window {
+			durations = durations[len(durations)-window:]
+		}
+		if len(durations) == 0 {
+			return 0
+		}
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		return total / time.Duration(len(durations))
+	}
+}
+
+// StepTimeoutError reports a SequentialAgent step that overran the share
+// of the time budget SetStepWeights gave it.
+type StepTimeoutError struct {
+	Step   int // 1-based
+	Agent  string
+	Budget time.Duration
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *StepTimeoutError) Error() string {
+	return fmt.Sprintf("step %d (%s) exceeded its %v time budget: %v", e.Step, e.Agent, e.Budget, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *StepTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// StopSequenceKey is the message metadata flag with which an agent ends the
+// enclosing SequentialAgent early; see StopSequence.
+const StopSequenceKey = "stop_sequence"
+
+// StopSequence marks message as the final answer of the enclosing
+// SequentialAgent, so that the agents after the one returning it are not
+// run. It sets StopSequenceKey in the message metadata and returns message.
+func StopSequence(message *agenkit.Message) *agenkit.Message {
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]interface{})
+	}
+	return message.WithMetadata(StopSequenceKey, true)
+}
+
 // SequentialAgent executes multiple agents in sequence, passing output from
 // one agent as input to the next.
+//
+// An agent can end the sequence early by returning a message marked with
+// StopSequence. That message is returned as the final answer, without the
+// StopSequenceKey flag so that it does not also stop an enclosing
+// sequence, and its metadata has "short_circuited" set to true,
+// "stopped_by" naming the agent, and "skipped_agents" listing the names of
+// the agents that did not run.
 type SequentialAgent struct {
-	name   string
-	agents []agenkit.Agent
+	name      string
+	agents    []agenkit.Agent
+	estimator StepEstimator
+	weights   []float64
 }
 
-// Verify that SequentialAgent implements Agent interface.
-var _ agenkit.Agent = (*SequentialAgent)(nil)
+// Verify that SequentialAgent implements Agent and ChunkStreamingAgent interfaces.
+var (
+	_ agenkit.Agent               = (*SequentialAgent)(nil)
+	_ agenkit.ChunkStreamingAgent = (*SequentialAgent)(nil)
+)
 
 // NewSequentialAgent creates a new sequential agent.
 func NewSequentialAgent(name string, agents ...agenkit.Agent) (*SequentialAgent, error) {
@@ -51,9 +125,83 @@ func (s *SequentialAgent) Capabilities() []string {
 	return caps
 }
 
+// SetStepEstimator makes the sequence deadline-aware.
+//
+// Before each step, if the context has a deadline and the time remaining is
+// less than the estimator's prediction, the sequence stops and returns the
+// result accumulated so far instead of starting a step that would likely
+// time out. The returned message's metadata then has "truncated" set to true
+// and "skipped_agents" listing the names of the agents that did not run.
+// A nil estimator (the default) disables truncation.
+func (s *SequentialAgent) SetStepEstimator(estimator StepEstimator) {
+	s.estimator = estimator
+}
+
+// SetStepWeights divides the context's remaining time among the steps in
+// proportion to weights, one per agent.
+//
+// Each step runs under its own deadline, its weight's share of the time
+// left when it starts, so a step finishing early leaves its unused time to
+// the steps after it. For weights [1, 2, 1] with nothing left over, the
+// middle step gets half the budget. A step overrunning its share fails the
+// sequence with a StepTimeoutError rather than eating into its siblings'
+// time. Without a context deadline the weights have no effect. Nil weights
+// (the default) give every step the full remaining deadline.
+func (s *SequentialAgent) SetStepWeights(weights ...float64) error {
+	if weights == nil {
+		s.weights = nil
+		return nil
+	}
+	if len(weights) != len(s.agents) {
+		return fmt.Errorf("expected %d step weights, got %d", len(s.agents), len(weights))
+	}
+	for i, w := range weights {
+		if !(w > 0) || math.IsInf(w, 0) {
+			return fmt.Errorf("step %d weight must be positive, got %v", i+1, w)
+		}
+	}
+	s.weights = append([]float64(nil), weights...)
+	return nil
+}
+
+// stepContext returns the context step i runs under and the time budget
+// it was given, zero when the step is not budgeted.
+func (s *SequentialAgent) stepContext(ctx context.Context, i int) (context.Context, context.CancelFunc, time.Duration) {
+	deadline, ok := ctx.Deadline()
+	if s.weights == nil || !ok {
+		return ctx, func() {}, 0
+	}
+	var rest float64
+	for _, w := range s.weights[i:] {
+		rest += w
+	}
+	budget := time.Duration(float64(time.Until(deadline)) * s.weights[i] / rest)
+	stepCtx, cancel := context.WithTimeout(ctx, budget)
+	return stepCtx, cancel, budget
+}
+
+// stepError wraps the failure of step i, reporting an overrun of its time
+// budget as a StepTimeoutError.
+func stepError(stepCtx context.Context, i int, agent agenkit.Agent, budget time.Duration, err error) error {
+	if budget > 0 && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return &StepTimeoutError{Step: i + 1, Agent: agent.Name(), Budget: budget, Err: err}
+	}
+	return fmt.Errorf("step %d (%s) failed: %w", i+1, agent.Name(), err)
+}
+
 // Process executes all agents in sequence.
-func (s *SequentialAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+func (s *SequentialAgent) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "pattern.sequential",
+		attribute.String("agent.name", s.name),
+		attribute.String("pattern.type", "sequential"),
+		attribute.Int("pattern.steps", len(s.agents)),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, s.name, message)
+	defer func() { finish(result, err) }()
+
 	current := message
+	var durations []time.Duration
 
 	for i, agent := range s.agents {
 		// Check context cancellation
@@ -63,10 +211,34 @@ func (s *SequentialAgent) Process(ctx context.Context, message *agenkit.Message)
 		default:
 		}
 
+		if s.outOfTime(ctx, durations) {
+			span.SetAttributes(attribute.Int("pattern.truncated_at", i+1))
+			return truncate(current, s.agents[i:]), nil
+		}
+
 		// Process through agent
-		result, err := agent.Process(ctx, current)
+		start := time.Now()
+		stepCtx, cancel, budget := s.stepContext(ctx, i)
+		result, err := agenkit.ProcessWithSpan(stepCtx, agent, current, attribute.Int("pattern.step", i+1))
+		cancel()
+		if err == nil && result == nil {
+			err = errNoResponse
+		}
 		if err != nil {
-			return nil, fmt.Errorf("step %d (%s) failed: %w", i+1, agent.Name(), err)
+			return nil, stepError(stepCtx, i, agent, budget, err)
+		}
+		durations = append(durations, time.Since(start))
+		agenkit.Logger(ctx).DebugContext(ctx, "sequential step completed",
+			slog.String(agenkit.LogKeyPattern, "sequential"),
+			slog.String(agenkit.LogKeyAgent, s.name),
+			slog.Int("step", i+1),
+			slog.String("step_agent", agent.Name()),
+			slog.Duration(agenkit.LogKeyDuration, durations[i]),
+		)
+
+		if stopRequested(result) {
+			span.SetAttributes(attribute.Int("pattern.stopped_at", i+1))
+			return shortCircuit(result, agent, s.agents[i+1:]), nil
 		}
 
 		// Output becomes input for next agent
@@ -76,6 +248,168 @@ func (s *SequentialAgent) Process(ctx context.Context, message *agenkit.Message)
 	return current, nil
 }
 
+// ProcessStream executes all agents in sequence, streaming the final agent's output.
+//
+// Intermediate agents are processed normally since each needs the complete
+// output of its predecessor. The last agent is streamed natively if it
+// implements ChunkStreamingAgent; otherwise its response is buffered and
+// delivered as a single chunk.
+func (s *SequentialAgent) ProcessStream(ctx context.Context, message *agenkit.Message) (<-chan agenkit.StreamChunk, error) {
+	out := make(chan agenkit.StreamChunk)
+
+	go func() {
+		defer close(out)
+		ctx, span := agenkit.StartSpan(ctx, "pattern.sequential",
+			attribute.String("agent.name", s.name),
+			attribute.String("pattern.type", "sequential"),
+			attribute.Int("pattern.steps", len(s.agents)),
+		)
+		ctx, finish := agenkit.TrackAgent(ctx, s.name, message)
+		ended := false
+		end := func(result *agenkit.Message, err error) {
+			if !ended {
+				ended = true
+				finish(result, err)
+				agenkit.EndSpan(span, err)
+			}
+		}
+		defer func() { end(nil, ctx.Err()) }()
+		// send delivers a chunk, ending the call first if it is the terminal
+		// one so the call is complete by the time the reader sees it
+		send := func(chunk agenkit.StreamChunk) bool {
+			if chunk.Done {
+				end(chunk.Message, chunk.Err)
+			}
+			return agenkit.SendChunk(ctx, out, chunk)
+		}
+
+		current := message
+		last := len(s.agents) - 1
+		var durations []time.Duration
+
+		for i, agent := range s.agents {
+			select {
+			case <-ctx.Done():
+				send(agenkit.StreamChunk{
+					Done: true,
+					Err:  fmt.Errorf("sequential execution cancelled at step %d: %w", i+1, ctx.Err()),
+				})
+				return
+			default:
+			}
+
+			if s.outOfTime(ctx, durations) {
+				span.SetAttributes(attribute.Int("pattern.truncated_at", i+1))
+				send(agenkit.StreamChunk{Done: true, Message: truncate(current, s.agents[i:])})
+				return
+			}
+			if i == last {
+				break
+			}
+
+			start := time.Now()
+			stepCtx, cancel, budget := s.stepContext(ctx, i)
+			result, err := agenkit.ProcessWithSpan(stepCtx, agent, current, attribute.Int("pattern.step", i+1))
+			cancel()
+			if err == nil && result == nil {
+				err = errNoResponse
+			}
+			if err != nil {
+				send(agenkit.StreamChunk{Done: true, Err: stepError(stepCtx, i, agent, budget, err)})
+				return
+			}
+			durations = append(durations, time.Since(start))
+			if stopRequested(result) {
+				span.SetAttributes(attribute.Int("pattern.stopped_at", i+1))
+				send(agenkit.StreamChunk{Done: true, Message: shortCircuit(result, agent, s.agents[i+1:])})
+				return
+			}
+			current = result
+		}
+
+		finalAgent := s.agents[last]
+		stepCtx, cancel, budget := s.stepContext(ctx, last)
+		defer cancel()
+		chunks, err := agenkit.ProcessStreamWithSpan(stepCtx, finalAgent, current, attribute.Int("pattern.step", last+1))
+		if err != nil {
+			send(agenkit.StreamChunk{Done: true, Err: stepError(stepCtx, last, finalAgent, budget, err)})
+			return
+		}
+
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				chunk.Err = stepError(stepCtx, last, finalAgent, budget, chunk.Err)
+			}
+			if !send(chunk) {
+				return
+			}
+			if chunk.Done {
+				return
+			}
+		}
+		// The step's stream may close without a terminal chunk once its
+		// own deadline passes
+		if err := stepCtx.Err(); err != nil {
+			send(agenkit.StreamChunk{Done: true, Err: stepError(stepCtx, last, finalAgent, budget, err)})
+		}
+	}()
+
+	return out, nil
+}
+
+// outOfTime reports whether the context's remaining time is less than the
+// estimated duration of the next step.
+func (s *SequentialAgent) outOfTime(ctx context.Context, durations []time.Duration) bool {
+	if s.estimator == nil {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return false
+	}
+	return time.Until(deadline) < s.estimator(durations)
+}
+
+// truncate marks current as a partial result, recording the skipped agents.
+func truncate(current *agenkit.Message, skipped []agenkit.Agent) *agenkit.Message {
+	result := withSkipped(current, skipped)
+	result.Metadata["truncated"] = true
+	return result
+}
+
+// stopRequested reports whether message asks to end the sequence.
+func stopRequested(message *agenkit.Message) bool {
+	stop, _ := message.Metadata[StopSequenceKey].(bool)
+	return stop
+}
+
+// shortCircuit returns the final answer of a sequence that agent stopped,
+// recording the agent and the skipped agents.
+func shortCircuit(current *agenkit.Message, agent agenkit.Agent, skipped []agenkit.Agent) *agenkit.Message {
+	result := withSkipped(current, skipped)
+	delete(result.Metadata, StopSequenceKey)
+	result.Metadata["short_circuited"] = true
+	result.Metadata["stopped_by"] = agent.Name()
+	return result
+}
+
+// withSkipped returns a copy of current whose metadata lists the names of
+// the skipped agents.
+func withSkipped(current *agenkit.Message, skipped []agenkit.Agent) *agenkit.Message {
+	names := make([]string, len(skipped))
+	for i, agent := range skipped {
+		names[i] = agent.Name()
+	}
+
+	result := *current
+	result.Metadata = make(map[string]interface{}, len(current.Metadata)+3)
+	for k, v := range current.Metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata["skipped_agents"] = names
+	return &result
+}
+
 // GetAgents returns the list of agents in the sequence.
 func (s *SequentialAgent) GetAgents() []agenkit.Agent {
 	return s.agents
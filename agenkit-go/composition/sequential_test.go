@@ -0,0 +1,496 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// streamingTestAgent emits its response one word at a time.
+type streamingTestAgent struct {
+	name  string
+	words []string
+	err   error
+}
+
+func (s *streamingTestAgent) Name() string           { return s.name }
+func (s *streamingTestAgent) Capabilities() []string { return []string{"streaming"} }
+
+func (s *streamingTestAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	chunks, _ := s.ProcessStream(ctx, message)
+	return agenkit.CollectStream(ctx, chunks)
+}
+
+func (s *streamingTestAgent) ProcessStream(ctx context.Context, message *agenkit.Message) (<-chan agenkit.StreamChunk, error) {
+	out := make(chan agenkit.StreamChunk)
+	go func() {
+		defer close(out)
+		for _, word := range s.words {
+			if !agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Delta: word}) {
+				return
+			}
+		}
+		if s.err != nil {
+			agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Err: s.err})
+			return
+		}
+		final := agenkit.NewMessage("agent", strings.Join(s.words, ""))
+		agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Message: final})
+	}()
+	return out, nil
+}
+
+func TestSequentialProcessStreamForwardsChunks(t *testing.T) {
+	ctx := context.Background()
+
+	first := &TestAgent{name: "first", response: "prepared"}
+	last := &streamingTestAgent{name: "last", words: []string{"a", "b", "c"}}
+
+	seq, _ := NewSequentialAgent("sequential", first, last)
+	chunks, err := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+
+	var deltas []string
+	var final *agenkit.Message
+	for chunk := range chunks {
+		if chunk.Delta != "" {
+			deltas = append(deltas, chunk.Delta)
+		}
+		if chunk.Done {
+			final = chunk.Message
+		}
+	}
+
+	if strings.Join(deltas, ",") != "a,b,c" {
+		t.Errorf("Expected deltas a,b,c, got %v", deltas)
+	}
+	if final == nil || final.Content != "abc" {
+		t.Errorf("Expected final message 'abc', got %+v", final)
+	}
+	if first.calls != 1 {
+		t.Errorf("Expected first agent called once, got %d", first.calls)
+	}
+}
+
+func TestSequentialProcessStreamTraced(t *testing.T) {
+	ctx := agenkit.WithTracing(context.Background())
+
+	seq, _ := NewSequentialAgent("sequential",
+		&TestAgent{name: "first", response: "prepared"},
+		&streamingTestAgent{name: "last", words: []string{"a", "b"}},
+	)
+	chunks, _ := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+	if _, err := agenkit.CollectStream(ctx, chunks); err != nil {
+		t.Fatalf("CollectStream failed: %v", err)
+	}
+
+	steps := agenkit.TraceFromContext(ctx).Steps()
+	if len(steps) != 3 {
+		t.Fatalf("Expected the pattern and both steps traced, got %+v", steps)
+	}
+	if steps[0].Agent != "sequential" || steps[0].Output == nil || steps[0].Output.Content != "ab" {
+		t.Errorf("Expected the sequential call with the streamed output, got %+v", steps[0])
+	}
+	for _, step := range steps[1:] {
+		if step.Parent != 0 {
+			t.Errorf("Expected %s nested in the sequential call, got parent %d", step.Agent, step.Parent)
+		}
+	}
+	if steps[1].Agent != "first" || steps[2].Agent != "last" || steps[2].Output == nil || steps[2].Output.Content != "ab" {
+		t.Errorf("Expected first then last with its output, got %+v / %+v", steps[1], steps[2])
+	}
+}
+
+func TestSequentialProcessStreamBuffersNonStreamingAgent(t *testing.T) {
+	ctx := context.Background()
+
+	seq, _ := NewSequentialAgent("sequential",
+		&TestAgent{name: "agent1", response: "step1"},
+		&TestAgent{name: "agent2", response: "step2"},
+	)
+	chunks, _ := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+
+	result, err := agenkit.CollectStream(ctx, chunks)
+	if err != nil {
+		t.Fatalf("CollectStream failed: %v", err)
+	}
+	if result.Content != "step2" {
+		t.Errorf("Expected 'step2', got '%s'", result.Content)
+	}
+}
+
+func TestSequentialProcessStreamErrorChunk(t *testing.T) {
+	ctx := context.Background()
+
+	seq, _ := NewSequentialAgent("sequential",
+		&TestAgent{name: "agent1", err: errors.New("agent1 failed")},
+		&streamingTestAgent{name: "last", words: []string{"never"}},
+	)
+	chunks, _ := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+
+	var terminal agenkit.StreamChunk
+	count := 0
+	for chunk := range chunks {
+		terminal = chunk
+		count++
+	}
+
+	if count != 1 || !terminal.Done || terminal.Err == nil {
+		t.Fatalf("Expected a single terminal error chunk, got %d chunks (last: %+v)", count, terminal)
+	}
+	if !strings.Contains(terminal.Err.Error(), "agent1") {
+		t.Errorf("Expected error to name failing step, got: %v", terminal.Err)
+	}
+}
+
+func TestSequentialProcessStreamFinalAgentError(t *testing.T) {
+	ctx := context.Background()
+
+	seq, _ := NewSequentialAgent("sequential",
+		&streamingTestAgent{name: "last", words: []string{"partial"}, err: errors.New("stream broke")},
+	)
+	chunks, _ := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+
+	_, err := agenkit.CollectStream(ctx, chunks)
+	if err == nil || !strings.Contains(err.Error(), "stream broke") {
+		t.Fatalf("Expected wrapped stream error, got: %v", err)
+	}
+}
+
+func TestMovingAverageEstimator(t *testing.T) {
+	estimate := MovingAverageEstimator(2)
+	if d := estimate(nil); d != 0 {
+		t.Errorf("Expected zero estimate before any step, got %v", d)
+	}
+	if d := estimate([]time.Duration{100 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond}); d != 20*time.Millisecond {
+		t.Errorf("Expected average of last 2 steps (20ms), got %v", d)
+	}
+	if d := MovingAverageEstimator(0)([]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}); d != 20*time.Millisecond {
+		t.Errorf("Expected average of all steps (20ms), got %v", d)
+	}
+}
+
+func newDelayedSequence(t *testing.T, delay time.Duration, names ...string) *SequentialAgent {
+	t.Helper()
+	agents := make([]agenkit.Agent, len(names))
+	for i, name := range names {
+		agent := testutil.NewMockAgent(t, name)
+		agent.Expect("", name).WithDelay(delay)
+		agents[i] = agent
+	}
+	seq, err := NewSequentialAgent("seq", agents...)
+	if err != nil {
+		t.Fatalf("NewSequentialAgent failed: %v", err)
+	}
+	return seq
+}
+
+func TestSequentialTruncatesBeforeDeadline(t *testing.T) {
+	seq := newDelayedSequence(t, 50*time.Millisecond, "a", "b", "c")
+	seq.SetStepEstimator(MovingAverageEstimator(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 130*time.Millisecond)
+	defer cancel()
+
+	result, err := seq.Process(ctx, agenkit.NewMessage("user", "start"))
+	if err != nil {
+		t.Fatalf("Expected partial result, got error: %v", err)
+	}
+	if result.Content != "b" {
+		t.Errorf("Expected result of last completed step 'b', got '%s'", result.Content)
+	}
+	if result.Metadata["truncated"] != true {
+		t.Error("Expected truncated metadata")
+	}
+	skipped, _ := result.Metadata["skipped_agents"].([]string)
+	if len(skipped) != 1 || skipped[0] != "c" {
+		t.Errorf("Expected skipped agents [c], got %v", result.Metadata["skipped_agents"])
+	}
+}
+
+func TestSequentialRunsAllStepsWithEnoughTime(t *testing.T) {
+	seq := newDelayedSequence(t, 5*time.Millisecond, "a", "b", "c")
+	seq.SetStepEstimator(MovingAverageEstimator(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result, err := seq.Process(ctx, agenkit.NewMessage("user", "start"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "c" {
+		t.Errorf("Expected 'c', got '%s'", result.Content)
+	}
+	if _, ok := result.Metadata["truncated"]; ok {
+		t.Error("Expected no truncated metadata when all steps ran")
+	}
+}
+
+func TestSequentialStreamTruncates(t *testing.T) {
+	seq := newDelayedSequence(t, 50*time.Millisecond, "a", "b", "c")
+	seq.SetStepEstimator(MovingAverageEstimator(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 130*time.Millisecond)
+	defer cancel()
+
+	chunks, err := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+	result, err := agenkit.CollectStream(ctx, chunks)
+	if err != nil {
+		t.Fatalf("Expected partial result, got error: %v", err)
+	}
+	if result.Content != "b" || result.Metadata["truncated"] != true {
+		t.Errorf("Expected truncated result 'b', got '%s' with %v", result.Content, result.Metadata)
+	}
+}
+
+func TestSequentialPublishesEvents(t *testing.T) {
+	first := testutil.NewMockAgent(t, "first")
+	first.Expect("", "a")
+	second := testutil.NewMockAgent(t, "second")
+	second.Expect("", "b")
+	seq, _ := NewSequentialAgent("pipeline", first, second)
+
+	bus := agenkit.NewEventBus()
+	sub := bus.Subscribe(0)
+	ctx := agenkit.WithEventSink(context.Background(), bus)
+
+	// Standalone, then nested inside another sequence
+	if _, err := seq.Process(ctx, agenkit.NewMessage("user", "go")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	standalone := collectEvents(sub)
+
+	first.Expect("", "a")
+	second.Expect("", "b")
+	outer, _ := NewSequentialAgent("outer", seq)
+	if _, err := outer.Process(ctx, agenkit.NewMessage("user", "go")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	nested := collectEvents(sub)
+
+	want := "agent_started:pipeline agent_started:first agent_finished:first agent_started:second agent_finished:second agent_finished:pipeline"
+	if standalone != want {
+		t.Errorf("Expected standalone events\n  %s\ngot\n  %s", want, standalone)
+	}
+	if nested != "agent_started:outer "+want+" agent_finished:outer" {
+		t.Errorf("Expected nested events wrapped by outer, got\n  %s", nested)
+	}
+}
+
+// collectEvents renders the buffered events as "type:agent" pairs.
+func collectEvents(sub *agenkit.Subscription) string {
+	var parts []string
+	for {
+		select {
+		case e := <-sub.Events():
+			parts = append(parts, string(e.Type)+":"+e.AgentName)
+		default:
+			return strings.Join(parts, " ")
+		}
+	}
+}
+
+// scopeGuard stops the sequence when the input is out of scope.
+type scopeGuard struct{}
+
+func (g *scopeGuard) Name() string           { return "guard" }
+func (g *scopeGuard) Capabilities() []string { return nil }
+
+func (g *scopeGuard) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	if strings.Contains(message.Content, "weather") {
+		return StopSequence(agenkit.NewMessage("agent", "I can only answer billing questions.")), nil
+	}
+	return message, nil
+}
+
+func TestSequentialStopSequenceShortCircuits(t *testing.T) {
+	downstream := &TestAgent{name: "billing", response: "answered"}
+	seq, _ := NewSequentialAgent("pipeline", &scopeGuard{}, downstream, &TestAgent{name: "format", response: "formatted"})
+
+	result, err := seq.Process(context.Background(), agenkit.NewMessage("user", "what's the weather?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "I can only answer billing questions." {
+		t.Errorf("Expected the guard's answer, got '%s'", result.Content)
+	}
+	if downstream.calls != 0 {
+		t.Errorf("Expected downstream agents not to run, got %d calls", downstream.calls)
+	}
+	if result.Metadata["short_circuited"] != true || result.Metadata["stopped_by"] != "guard" {
+		t.Errorf("Expected short-circuit metadata naming the guard, got %v", result.Metadata)
+	}
+	skipped, _ := result.Metadata["skipped_agents"].([]string)
+	if len(skipped) != 2 || skipped[0] != "billing" || skipped[1] != "format" {
+		t.Errorf("Expected skipped agents [billing format], got %v", result.Metadata["skipped_agents"])
+	}
+	if _, ok := result.Metadata[StopSequenceKey]; ok {
+		t.Error("Expected the stop flag not to leak to an enclosing sequence")
+	}
+
+	result, err = seq.Process(context.Background(), agenkit.NewMessage("user", "refund my invoice"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "formatted" || result.Metadata["short_circuited"] != nil {
+		t.Errorf("Expected in-scope input to run all steps, got '%s' with %v", result.Content, result.Metadata)
+	}
+}
+
+func TestSequentialStopSequenceNestedAndStreaming(t *testing.T) {
+	inner, _ := NewSequentialAgent("inner", &scopeGuard{}, &TestAgent{name: "billing", response: "answered"})
+	after := &TestAgent{name: "after", response: "after"}
+	outer, _ := NewSequentialAgent("outer", inner, after)
+
+	result, err := outer.Process(context.Background(), agenkit.NewMessage("user", "weather?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "after" || after.calls != 1 {
+		t.Errorf("Expected the stop to end only the inner sequence, got '%s'", result.Content)
+	}
+
+	downstream := &streamingTestAgent{name: "last", words: []string{"x"}}
+	seq, _ := NewSequentialAgent("pipeline", &scopeGuard{}, downstream)
+	chunks, err := seq.ProcessStream(context.Background(), agenkit.NewMessage("user", "weather?"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+	streamed, err := agenkit.CollectStream(context.Background(), chunks)
+	if err != nil {
+		t.Fatalf("CollectStream failed: %v", err)
+	}
+	if streamed.Content != "I can only answer billing questions." || streamed.Metadata["stopped_by"] != "guard" {
+		t.Errorf("Expected streamed short-circuit, got '%s' with %v", streamed.Content, streamed.Metadata)
+	}
+}
+
+// silentAgent returns neither a response nor an error.
+type silentAgent struct{}
+
+func (s *silentAgent) Name() string           { return "silent" }
+func (s *silentAgent) Capabilities() []string { return nil }
+
+func (s *silentAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return nil, nil
+}
+
+func TestSequentialRejectsStepWithoutResponse(t *testing.T) {
+	next := &TestAgent{name: "next", response: "next"}
+	seq, _ := NewSequentialAgent("pipeline", &silentAgent{}, next)
+
+	_, err := seq.Process(context.Background(), agenkit.NewMessage("user", "go"))
+	if !errors.Is(err, errNoResponse) {
+		t.Errorf("Expected errNoResponse, got %v", err)
+	}
+
+	chunks, err := seq.ProcessStream(context.Background(), agenkit.NewMessage("user", "go"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+	if _, err := agenkit.CollectStream(context.Background(), chunks); !errors.Is(err, errNoResponse) {
+		t.Errorf("Expected streamed errNoResponse, got %v", err)
+	}
+	if next.calls != 0 {
+		t.Errorf("Expected the next step not to run, got %d calls", next.calls)
+	}
+}
+
+// budgetAgent records the time it was given before replying.
+type budgetAgent struct {
+	TestAgent
+	budget time.Duration
+}
+
+func (b *budgetAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		b.budget = time.Until(deadline)
+	}
+	return b.TestAgent.Process(ctx, message)
+}
+
+func TestSequentialSplitsDeadlineByWeight(t *testing.T) {
+	agents := []*budgetAgent{{TestAgent: TestAgent{name: "a"}}, {TestAgent: TestAgent{name: "b"}}, {TestAgent: TestAgent{name: "c"}}}
+	seq, _ := NewSequentialAgent("weighted", agents[0], agents[1], agents[2])
+	if err := seq.SetStepWeights(1, 2, 1); err != nil {
+		t.Fatalf("SetStepWeights failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := seq.Process(ctx, agenkit.NewMessage("user", "start")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	// The first step gets a quarter; as it returns at once, the second
+	// gets two thirds of the rest and the third everything left
+	if b := agents[0].budget; b < 200*time.Millisecond || b > 250*time.Millisecond {
+		t.Errorf("Expected about 250ms for the first step, got %v", b)
+	}
+	if b := agents[1].budget; b < 600*time.Millisecond || b > 670*time.Millisecond {
+		t.Errorf("Expected about 667ms for the middle step, got %v", b)
+	}
+	if b := agents[2].budget; b < 200*time.Millisecond {
+		t.Errorf("Expected the last step to get the remaining time, got %v", b)
+	}
+}
+
+func TestSequentialStepOverrunsItsBudget(t *testing.T) {
+	slow := &TestAgent{name: "slow", response: "x", delay: time.Second}
+	after := &TestAgent{name: "after", response: "y"}
+	seq, _ := NewSequentialAgent("weighted", slow, after)
+	seq.SetStepWeights(1, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := seq.Process(ctx, agenkit.NewMessage("user", "start"))
+
+	var timeoutErr *StepTimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Step != 1 || timeoutErr.Agent != "slow" {
+		t.Fatalf("Expected a StepTimeoutError for step 1, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded underneath, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected the step stopped at its 50ms share, took %v", elapsed)
+	}
+
+	// The streaming path reports overruns the same way
+	seq, _ = NewSequentialAgent("weighted", after, slow, &TestAgent{name: "last"})
+	seq.SetStepWeights(1, 1, 2)
+	ctx, cancel = context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	chunks, _ := seq.ProcessStream(ctx, agenkit.NewMessage("user", "start"))
+	if _, err := agenkit.CollectStream(context.Background(), chunks); !errors.As(err, &timeoutErr) || timeoutErr.Step != 2 {
+		t.Errorf("Expected a StepTimeoutError for step 2 from the stream, got %v", err)
+	}
+}
+
+func TestSequentialWeightsWithoutDeadline(t *testing.T) {
+	seq := newDelayedSequence(t, 0, "a", "b")
+	if err := seq.SetStepWeights(1); err == nil {
+		t.Error("Expected an error for the wrong number of weights")
+	}
+	if err := seq.SetStepWeights(1, 0); err == nil {
+		t.Error("Expected an error for a zero weight")
+	}
+	seq.SetStepWeights(1, 1)
+
+	result, err := seq.Process(context.Background(), agenkit.NewMessage("user", "start"))
+	if err != nil || result.Content != "b" {
+		t.Errorf("Expected weights to have no effect without a deadline, got %v (%v)", result, err)
+	}
+}
@@ -0,0 +1,116 @@
+package composition
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func tracedContext() (context.Context, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return agenkit.WithTracerProvider(context.Background(), tp), recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) (string, bool) {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+func TestSequentialAgentChildSpans(t *testing.T) {
+	ctx, recorder := tracedContext()
+	seq, _ := NewSequentialAgent("pipeline",
+		&TestAgent{name: "first", response: "a"},
+		&TestAgent{name: "second", response: "b"},
+	)
+
+	if _, err := seq.Process(ctx, agenkit.NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+
+	var parent sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() == "pattern.sequential" {
+			parent = span
+		}
+	}
+	if parent == nil {
+		t.Fatal("Expected a pattern.sequential span")
+	}
+	if v, _ := spanAttr(parent, "pattern.type"); v != "sequential" {
+		t.Errorf("Expected pattern.type 'sequential', got '%s'", v)
+	}
+
+	for i, name := range []string{"agent.first.process", "agent.second.process"} {
+		child := spans[i]
+		if child.Name() != name {
+			t.Errorf("Expected span %d to be '%s', got '%s'", i, name, child.Name())
+		}
+		if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("Expected '%s' to be a child of the sequence span", child.Name())
+		}
+	}
+}
+
+func TestParallelAgentChildSpans(t *testing.T) {
+	ctx, recorder := tracedContext()
+	par, _ := NewParallelAgent("fanout",
+		&TestAgent{name: "a", response: "a"},
+		&TestAgent{name: "b", response: "b"},
+	)
+
+	if _, err := par.Process(ctx, agenkit.NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	parent := spans[2]
+	if parent.Name() != "pattern.parallel" {
+		t.Fatalf("Expected last span to be pattern.parallel, got '%s'", parent.Name())
+	}
+	for _, child := range spans[:2] {
+		if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("Expected '%s' to be a child of the parallel span", child.Name())
+		}
+	}
+}
+
+func TestRouterAgentSpanAttributes(t *testing.T) {
+	ctx, recorder := tracedContext()
+	router, _ := NewRouterAgent("router",
+		&TestAgent{name: "classifier", response: "billing"},
+		map[string]agenkit.Agent{"billing": &TestAgent{name: "billing-agent", response: "ok"}},
+	)
+
+	if _, err := router.Process(ctx, agenkit.NewMessage("user", "invoice?")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	parent := spans[len(spans)-1]
+	if parent.Name() != "pattern.router" {
+		t.Fatalf("Expected last span to be pattern.router, got '%s'", parent.Name())
+	}
+	if v, _ := spanAttr(parent, "router.route"); v != "billing" {
+		t.Errorf("Expected router.route 'billing', got '%s'", v)
+	}
+	if len(spans) != 3 {
+		t.Errorf("Expected classifier, route, and router spans, got %d", len(spans))
+	}
+}
@@ -0,0 +1,66 @@
+package composition
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// TransformFunc rewrites a message without calling a model.
+type TransformFunc func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error)
+
+// TransformAgent adapts a function to the Agent interface, for stages of a
+// composition that only reshape messages: extracting a field, changing
+// format, normalizing whitespace. It is traced and publishes events like
+// any other agent, and returns the function's errors wrapped with its name.
+type TransformAgent struct {
+	name string
+	fn   TransformFunc
+}
+
+// Verify that TransformAgent implements Agent interface.
+var _ agenkit.Agent = (*TransformAgent)(nil)
+
+// NewTransform creates a transform agent named name that applies fn.
+// A nil fn passes messages through unchanged.
+func NewTransform(name string, fn TransformFunc) *TransformAgent {
+	if fn == nil {
+		fn = func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+			return message, nil
+		}
+	}
+	return &TransformAgent{name: name, fn: fn}
+}
+
+// Name returns the name of the transform agent.
+func (t *TransformAgent) Name() string {
+	return t.name
+}
+
+// Capabilities returns the capabilities of the transform agent.
+func (t *TransformAgent) Capabilities() []string {
+	return []string{"transform"}
+}
+
+// Process applies the function to message.
+func (t *TransformAgent) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "pattern.transform",
+		attribute.String("agent.name", t.name),
+		attribute.String("pattern.type", "transform"),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, t.name, message)
+	defer func() { finish(result, err) }()
+
+	result, err = t.fn(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("transform %s failed: %w", t.name, err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("transform %s returned no message", t.name)
+	}
+	return result, nil
+}
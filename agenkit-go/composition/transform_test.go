@@ -0,0 +1,53 @@
+package composition
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestTransformInSequence(t *testing.T) {
+	normalize := NewTransform("normalize", func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+		return agenkit.NewMessage(message.Role, strings.Join(strings.Fields(message.Content), " ")), nil
+	})
+	upper := NewTransform("upper", func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+		return agenkit.NewMessage(message.Role, strings.ToUpper(message.Content)), nil
+	})
+	seq, _ := NewSequentialAgent("pipeline", normalize, upper)
+
+	bus := agenkit.NewEventBus()
+	sub := bus.Subscribe(0)
+	ctx := agenkit.WithEventSink(context.Background(), bus)
+
+	result, err := seq.Process(ctx, agenkit.NewMessage("user", "  hello \n  world "))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "HELLO WORLD" {
+		t.Errorf("Expected 'HELLO WORLD', got '%s'", result.Content)
+	}
+	want := "agent_started:pipeline agent_started:normalize agent_finished:normalize agent_started:upper agent_finished:upper agent_finished:pipeline"
+	if got := collectEvents(sub); got != want {
+		t.Errorf("Expected events\n  %s\ngot\n  %s", want, got)
+	}
+}
+
+func TestTransformPropagatesErrors(t *testing.T) {
+	errBad := errors.New("missing field")
+	extract := NewTransform("extract", func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+		return nil, errBad
+	})
+
+	_, err := extract.Process(context.Background(), agenkit.NewMessage("user", "{}"))
+	if !errors.Is(err, errBad) || !strings.Contains(err.Error(), "extract") {
+		t.Errorf("Expected the error wrapped with the transform's name, got %v", err)
+	}
+
+	identity := NewTransform("identity", nil)
+	if result, err := identity.Process(context.Background(), agenkit.NewMessage("user", "same")); err != nil || result.Content != "same" {
+		t.Errorf("Expected a nil function to pass the message through, got %v (%v)", result, err)
+	}
+}
@@ -0,0 +1,340 @@
+// Package guardrail filters the messages flowing into and out of an agent.
+//
+// A Guardrail runs input rules before the wrapped agent sees a message and
+// output rules before its response is returned. Rules can allow, block, or
+// rewrite a message:
+//
+//	denylist, _ := guardrail.DenyPatterns(`(?i)\bpassword\b`)
+//	safe := guardrail.NewGuardrail(agent, guardrail.GuardrailConfig{
+//		InputRules:  []guardrail.Rule{denylist, guardrail.MaxLength(4000)},
+//		OutputRules: []guardrail.Rule{guardrail.Redact(emailPattern, "[email]")},
+//	})
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Stage identifies which side of the agent a rule ran on.
+type Stage string
+
+// Guardrail stages.
+const (
+	StageInput  Stage = "input"
+	StageOutput Stage = "output"
+)
+
+// DefaultRefusal is the reply returned when a message is blocked.
+const DefaultRefusal = "I'm sorry, but I can't help with that request."
+
+// Rule checks a message. It returns allowed=false with a reason to block
+// the message; a non-nil error aborts processing. A reason given while
+// allowing the message is kept in the verdict for auditing.
+//
+// Each rule receives the Guardrail's own copy of the message, so a rule may
+// rewrite it in place; Rewrite adapts functions that return a modified
+// message instead.
+type Rule func(ctx context.Context, message *agenkit.Message) (allowed bool, reason string, err error)
+
+// Action is what a rule, or a set of rules combined, decided about a
+// message.
+type Action string
+
+// Rule actions, from weakest to strongest.
+const (
+	ActionAllow   Action = "allow"
+	ActionRewrite Action = "rewrite"
+	ActionBlock   Action = "block"
+)
+
+// actionRank orders actions for combining; the strongest wins.
+var actionRank = map[Action]int{ActionAllow: 0, ActionRewrite: 1, ActionBlock: 2}
+
+// RuleResult is what one rule decided.
+type RuleResult struct {
+	// Rule is the rule's position in its stage, starting at 1.
+	Rule int `json:"rule"`
+
+	// Action is ActionBlock if the rule blocked the message, ActionRewrite
+	// if it changed the message's content or attachments, and ActionAllow
+	// otherwise.
+	Action Action `json:"action"`
+
+	// Reason is the reason the rule gave, if any.
+	Reason string `json:"reason,omitempty"`
+}
+
+// GuardrailVerdict accumulates the results of the rules run on a message
+// and the action they add up to: any block wins, otherwise any rewrite,
+// otherwise allow.
+type GuardrailVerdict struct {
+	// Stage is the side of the agent the rules ran on.
+	Stage Stage `json:"stage"`
+
+	// Action is the combined action.
+	Action Action `json:"action"`
+
+	// Results are the rules' results, in the order the rules ran.
+	Results []RuleResult `json:"results"`
+
+	// Message is the message after every rewrite, applied in rule order.
+	Message *agenkit.Message `json:"-"`
+}
+
+// Add records a rule's result and updates the combined action.
+func (v *GuardrailVerdict) Add(result RuleResult) {
+	v.Results = append(v.Results, result)
+	if v.Action == "" || actionRank[result.Action] > actionRank[v.Action] {
+		v.Action = result.Action
+	}
+}
+
+// Blocked reports whether any rule blocked the message.
+func (v *GuardrailVerdict) Blocked() bool {
+	return v.Action == ActionBlock
+}
+
+// Reasons returns every reason the rules gave, in rule order.
+func (v *GuardrailVerdict) Reasons() []string {
+	var reasons []string
+	for _, result := range v.Results {
+		if result.Reason != "" {
+			reasons = append(reasons, result.Reason)
+		}
+	}
+	return reasons
+}
+
+// blockReason joins the reasons of the rules that blocked the message.
+func (v *GuardrailVerdict) blockReason() string {
+	var reasons []string
+	for _, result := range v.Results {
+		if result.Action == ActionBlock && result.Reason != "" {
+			reasons = append(reasons, result.Reason)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Evaluate runs every rule on a copy of message, in order, and combines
+// their results. Rules after a block still run, so the verdict shows every
+// reason the message was flagged; each rule sees the rewrites of the rules
+// before it. A rule error aborts the evaluation.
+func Evaluate(ctx context.Context, stage Stage, rules []Rule, message *agenkit.Message) (*GuardrailVerdict, error) {
+	verdict := &GuardrailVerdict{Stage: stage, Action: ActionAllow, Message: copyMessage(message)}
+	for i, rule := range rules {
+		content, attachments := verdict.Message.Content, verdict.Message.Attachments
+		allowed, reason, err := rule(ctx, verdict.Message)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail %s rule %d: %w", stage, i+1, err)
+		}
+		result := RuleResult{Rule: i + 1, Action: ActionAllow, Reason: reason}
+		switch {
+		case !allowed:
+			result.Action = ActionBlock
+		case verdict.Message.Content != content || !reflect.DeepEqual(verdict.Message.Attachments, attachments):
+			result.Action = ActionRewrite
+		}
+		verdict.Add(result)
+	}
+	return verdict, nil
+}
+
+// BlockedEvent describes a message that a rule blocked.
+type BlockedEvent struct {
+	AgentName string
+	Stage     Stage
+
+	// Reason joins the reasons of every rule that blocked the message.
+	Reason  string
+	Message *agenkit.Message
+
+	// Verdict holds every rule's result, including those that allowed or
+	// rewrote the message.
+	Verdict *GuardrailVerdict
+}
+
+// GuardrailConfig configures a Guardrail.
+type GuardrailConfig struct {
+	// InputRules run, in order, before the wrapped agent is called.
+	InputRules []Rule
+
+	// OutputRules run, in order, on the wrapped agent's response.
+	OutputRules []Rule
+
+	// Refusal is the reply content returned in place of a blocked message.
+	// Default: DefaultRefusal
+	Refusal string
+
+	// OnBlocked, if set, is called for every blocked message, e.g. for
+	// audit logging.
+	OnBlocked func(event BlockedEvent)
+
+	// OnVerdict, if set, is called with the verdict of every stage that
+	// has rules, whatever its action.
+	OnVerdict func(verdict *GuardrailVerdict)
+}
+
+// Guardrail wraps an agent with input and output rules.
+//
+// Every rule of a stage runs, even after one blocks, and their results are
+// combined into a GuardrailVerdict (see Evaluate). A blocked input is
+// answered with the refusal without calling the agent; a blocked output is
+// replaced by the refusal. Refusals carry "guardrail_blocked" (the stage),
+// "guardrail_reason" (the blocking reasons) and "guardrail_reasons" (every
+// reason given) in their metadata.
+type Guardrail struct {
+	agent  agenkit.Agent
+	config GuardrailConfig
+}
+
+// Verify that Guardrail implements Agent interface.
+var _ agenkit.Agent = (*Guardrail)(nil)
+
+// NewGuardrail wraps agent with the configured rules.
+func NewGuardrail(agent agenkit.Agent, config GuardrailConfig) *Guardrail {
+	if config.Refusal == "" {
+		config.Refusal = DefaultRefusal
+	}
+	return &Guardrail{agent: agent, config: config}
+}
+
+// Name returns the wrapped agent's name.
+func (g *Guardrail) Name() string {
+	return g.agent.Name()
+}
+
+// Capabilities returns the wrapped agent's capabilities plus "guardrail".
+func (g *Guardrail) Capabilities() []string {
+	return append(g.agent.Capabilities(), "guardrail")
+}
+
+// Process checks the input, calls the wrapped agent, and checks its output.
+func (g *Guardrail) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	input, blocked, err := g.check(ctx, StageInput, g.config.InputRules, message)
+	if err != nil || blocked != nil {
+		return blocked, err
+	}
+
+	response, err := g.agent.Process(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output, blocked, err := g.check(ctx, StageOutput, g.config.OutputRules, response)
+	if err != nil || blocked != nil {
+		return blocked, err
+	}
+	return output, nil
+}
+
+// check runs rules against message. It returns the (possibly rewritten)
+// message, or a refusal if a rule blocked it.
+func (g *Guardrail) check(ctx context.Context, stage Stage, rules []Rule, message *agenkit.Message) (*agenkit.Message, *agenkit.Message, error) {
+	if len(rules) == 0 {
+		return message, nil, nil
+	}
+
+	verdict, err := Evaluate(ctx, stage, rules, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	if g.config.OnVerdict != nil {
+		g.config.OnVerdict(verdict)
+	}
+	if !verdict.Blocked() {
+		return verdict.Message, nil, nil
+	}
+
+	reason := verdict.blockReason()
+	if g.config.OnBlocked != nil {
+		g.config.OnBlocked(BlockedEvent{
+			AgentName: g.agent.Name(),
+			Stage:     stage,
+			Reason:    reason,
+			Message:   message,
+			Verdict:   verdict,
+		})
+	}
+	refusal := agenkit.NewMessage("agent", g.config.Refusal)
+	refusal.Metadata["guardrail_blocked"] = string(stage)
+	refusal.Metadata["guardrail_reason"] = reason
+	refusal.Metadata["guardrail_reasons"] = verdict.Reasons()
+	return nil, refusal, nil
+}
+
+// Rewrite adapts a function returning a modified message into a Rule that
+// never blocks, e.g. for redacting sensitive content. Returning nil leaves
+// the message unchanged.
+func Rewrite(rewrite func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error)) Rule {
+	return func(ctx context.Context, message *agenkit.Message) (bool, string, error) {
+		rewritten, err := rewrite(ctx, message)
+		if err != nil {
+			return false, "", err
+		}
+		if rewritten != nil && rewritten != message {
+			*message = *copyMessage(rewritten)
+		}
+		return true, "", nil
+	}
+}
+
+// DenyPatterns returns a Rule that blocks messages whose content matches
+// any of the regular expressions.
+func DenyPatterns(patterns ...string) (Rule, error) {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny pattern %q: %w", pattern, err)
+		}
+		compiled[i] = re
+	}
+
+	return func(ctx context.Context, message *agenkit.Message) (bool, string, error) {
+		for _, re := range compiled {
+			if re.MatchString(message.Content) {
+				return false, fmt.Sprintf("content matches denied pattern %q", re.String()), nil
+			}
+		}
+		return true, "", nil
+	}, nil
+}
+
+// MaxLength returns a Rule that blocks messages longer than n characters.
+func MaxLength(n int) Rule {
+	return func(ctx context.Context, message *agenkit.Message) (bool, string, error) {
+		if length := utf8.RuneCountInString(message.Content); length > n {
+			return false, fmt.Sprintf("content length %d exceeds maximum of %d", length, n), nil
+		}
+		return true, "", nil
+	}
+}
+
+// Redact returns a Rule that replaces every match of pattern with
+// replacement. The replacement may reference groups as in
+// regexp.Regexp.ReplaceAllString.
+func Redact(pattern *regexp.Regexp, replacement string) Rule {
+	return Rewrite(func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+		redacted := copyMessage(message)
+		redacted.Content = pattern.ReplaceAllString(message.Content, replacement)
+		return redacted, nil
+	})
+}
+
+// copyMessage returns a copy of message with its own metadata map.
+func copyMessage(message *agenkit.Message) *agenkit.Message {
+	copied := *message
+	copied.Metadata = make(map[string]interface{}, len(message.Metadata))
+	for k, v := range message.Metadata {
+		copied.Metadata[k] = v
+	}
+	return &copied
+}
@@ -0,0 +1,223 @@
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+func TestGuardrailAllowsCleanMessages(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "assistant")
+	agent.Expect("hello", "hi there")
+
+	deny, err := DenyPatterns(`(?i)forbidden`)
+	if err != nil {
+		t.Fatalf("DenyPatterns failed: %v", err)
+	}
+	guarded := NewGuardrail(agent, GuardrailConfig{InputRules: []Rule{deny}, OutputRules: []Rule{deny}})
+
+	response, err := guarded.Process(context.Background(), agenkit.NewMessage("user", "hello"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "hi there" {
+		t.Errorf("Expected 'hi there', got '%s'", response.Content)
+	}
+	agent.AssertExpectationsMet()
+}
+
+func TestGuardrailBlocksInputWithoutCallingAgent(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "assistant")
+	deny, _ := DenyPatterns(`(?i)forbidden`)
+
+	var events []BlockedEvent
+	guarded := NewGuardrail(agent, GuardrailConfig{
+		InputRules: []Rule{deny},
+		OnBlocked:  func(e BlockedEvent) { events = append(events, e) },
+	})
+
+	response, err := guarded.Process(context.Background(), agenkit.NewMessage("user", "something FORBIDDEN"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != DefaultRefusal {
+		t.Errorf("Expected refusal, got '%s'", response.Content)
+	}
+	if response.Metadata["guardrail_blocked"] != "input" {
+		t.Errorf("Expected input block metadata, got %v", response.Metadata)
+	}
+	if len(agent.Calls()) != 0 {
+		t.Error("Expected wrapped agent not to be called")
+	}
+	if len(events) != 1 || events[0].Stage != StageInput || !strings.Contains(events[0].Reason, "forbidden") {
+		t.Errorf("Expected one input blocked event, got %+v", events)
+	}
+}
+
+func TestGuardrailReplacesBlockedOutput(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "assistant")
+	agent.Expect("", strings.Repeat("x", 20))
+
+	var events []BlockedEvent
+	guarded := NewGuardrail(agent, GuardrailConfig{
+		OutputRules: []Rule{MaxLength(10)},
+		Refusal:     "Response withheld.",
+		OnBlocked:   func(e BlockedEvent) { events = append(events, e) },
+	})
+
+	response, err := guarded.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "Response withheld." {
+		t.Errorf("Expected custom refusal, got '%s'", response.Content)
+	}
+	if len(events) != 1 || events[0].Stage != StageOutput {
+		t.Errorf("Expected one output blocked event, got %+v", events)
+	}
+}
+
+func TestGuardrailRewritesMessages(t *testing.T) {
+	email := regexp.MustCompile(`[\w.]+@[\w.]+`)
+	agent := testutil.NewMockAgent(t, "assistant")
+	agent.Expect("contact [email]", "reply to bob@example.com")
+
+	guarded := NewGuardrail(agent, GuardrailConfig{
+		InputRules:  []Rule{Redact(email, "[email]")},
+		OutputRules: []Rule{Redact(email, "[email]")},
+	})
+
+	original := agenkit.NewMessage("user", "contact alice@example.com")
+	response, err := guarded.Process(context.Background(), original)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "reply to [email]" {
+		t.Errorf("Expected redacted output, got '%s'", response.Content)
+	}
+	if original.Content != "contact alice@example.com" {
+		t.Errorf("Expected caller's message to be left untouched, got '%s'", original.Content)
+	}
+	agent.AssertExpectationsMet()
+}
+
+func TestGuardrailRulesSeeRewrites(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "assistant")
+	secret := regexp.MustCompile(`secret`)
+	deny, _ := DenyPatterns(`secret`)
+	agent.Expect("", "ok")
+
+	guarded := NewGuardrail(agent, GuardrailConfig{InputRules: []Rule{Redact(secret, "***"), deny}})
+	response, _ := guarded.Process(context.Background(), agenkit.NewMessage("user", "my secret"))
+	if response.Content != "ok" {
+		t.Errorf("Expected later rule to see redacted content and allow it, got '%s'", response.Content)
+	}
+}
+
+func TestGuardrailRuleError(t *testing.T) {
+	failing := func(ctx context.Context, message *agenkit.Message) (bool, string, error) {
+		return false, "", errors.New("classifier unavailable")
+	}
+	guarded := NewGuardrail(testutil.NewMockAgent(t, "assistant"), GuardrailConfig{InputRules: []Rule{failing}})
+
+	_, err := guarded.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil || !strings.Contains(err.Error(), "classifier unavailable") {
+		t.Errorf("Expected rule error, got %v", err)
+	}
+}
+
+func TestDenyPatternsInvalid(t *testing.T) {
+	if _, err := DenyPatterns(`(`); err == nil {
+		t.Fatal("Expected error for invalid pattern")
+	}
+}
+
+func TestMaxLengthCountsCharacters(t *testing.T) {
+	allowed, _, _ := MaxLength(3)(context.Background(), agenkit.NewMessage("user", "héé"))
+	if !allowed {
+		t.Error("Expected multi-byte characters to count once")
+	}
+}
+
+func TestGuardrailRunsAllRules(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "assistant")
+	deny, _ := DenyPatterns(`(?i)forbidden`)
+	ran := false
+	last := func(ctx context.Context, message *agenkit.Message) (bool, string, error) {
+		ran = true
+		return true, "checked tone", nil
+	}
+
+	var events []BlockedEvent
+	var verdicts []*GuardrailVerdict
+	guarded := NewGuardrail(agent, GuardrailConfig{
+		InputRules: []Rule{deny, MaxLength(5), last},
+		OnBlocked:  func(e BlockedEvent) { events = append(events, e) },
+		OnVerdict:  func(v *GuardrailVerdict) { verdicts = append(verdicts, v) },
+	})
+
+	response, err := guarded.Process(context.Background(), agenkit.NewMessage("user", "forbidden words"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if !ran {
+		t.Error("Expected rules after a block to run")
+	}
+	if len(events) != 1 || len(verdicts) != 1 {
+		t.Fatalf("Expected one blocked event and one verdict, got %d and %d", len(events), len(verdicts))
+	}
+	reason := events[0].Reason
+	if !strings.Contains(reason, "forbidden") || !strings.Contains(reason, "exceeds maximum") {
+		t.Errorf("Expected both block reasons, got '%s'", reason)
+	}
+	if response.Metadata["guardrail_reason"] != reason {
+		t.Errorf("Expected refusal to carry the reason, got %v", response.Metadata["guardrail_reason"])
+	}
+	reasons := verdicts[0].Reasons()
+	if len(reasons) != 3 || reasons[2] != "checked tone" {
+		t.Errorf("Expected every reason in the verdict, got %v", reasons)
+	}
+}
+
+func TestEvaluateCombinesActions(t *testing.T) {
+	secret := regexp.MustCompile(`secret`)
+	upper := Rewrite(func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+		rewritten := copyMessage(message)
+		rewritten.Content = strings.ToUpper(message.Content)
+		return rewritten, nil
+	})
+	message := agenkit.NewMessage("user", "my secret")
+
+	verdict, err := Evaluate(context.Background(), StageInput, []Rule{Redact(secret, "***"), upper, MaxLength(100)}, message)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if verdict.Action != ActionRewrite {
+		t.Errorf("Expected rewrite, got %s", verdict.Action)
+	}
+	if verdict.Message.Content != "MY ***" {
+		t.Errorf("Expected rewrites applied in order, got '%s'", verdict.Message.Content)
+	}
+	actions := []Action{verdict.Results[0].Action, verdict.Results[1].Action, verdict.Results[2].Action}
+	if actions[0] != ActionRewrite || actions[1] != ActionRewrite || actions[2] != ActionAllow {
+		t.Errorf("Expected rewrite, rewrite, allow, got %v", actions)
+	}
+	if message.Content != "my secret" {
+		t.Errorf("Expected caller's message to be left untouched, got '%s'", message.Content)
+	}
+
+	verdict, _ = Evaluate(context.Background(), StageOutput, []Rule{MaxLength(2), Redact(secret, "***")}, message)
+	if !verdict.Blocked() {
+		t.Errorf("Expected block to win over rewrite, got %s", verdict.Action)
+	}
+
+	verdict, _ = Evaluate(context.Background(), StageOutput, nil, message)
+	if verdict.Action != ActionAllow {
+		t.Errorf("Expected allow without rules, got %s", verdict.Action)
+	}
+}
@@ -0,0 +1,149 @@
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// InjectionScoreKey is the metadata key under which InjectionDetector
+// records the risk score of messages it flags without blocking.
+const InjectionScoreKey = "risk.injection_score"
+
+// InjectionPattern is one sign of prompt injection that InjectionDetector
+// looks for.
+type InjectionPattern struct {
+	// Name identifies the pattern in block reasons.
+	Name string
+
+	// Pattern matches the suspicious text.
+	Pattern *regexp.Regexp
+
+	// Weight, in (0, 1], is how strongly a match suggests injection.
+	Weight float64
+}
+
+// DefaultInjectionPatterns returns the patterns InjectionDetector uses by
+// default: instructions to ignore earlier instructions, role directives
+// embedded in content, chat-template delimiters, attempts to redefine the
+// assistant, and requests to reveal the system prompt.
+func DefaultInjectionPatterns() []InjectionPattern {
+	return []InjectionPattern{
+		{
+			Name:    "ignore_instructions",
+			Pattern: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,40}\b(previous|prior|above|earlier|preceding|all|your)\b.{0,40}\b(instructions?|prompts?|rules|directions|guidelines)\b`),
+			Weight:  0.9,
+		},
+		{
+			Name:    "role_directive",
+			Pattern: regexp.MustCompile(`(?im)^\s*(system|assistant|developer)\s*:`),
+			Weight:  0.5,
+		},
+		{
+			Name:    "suspicious_delimiter",
+			Pattern: regexp.MustCompile(`(?i)(<\|(im_start|im_end|system|endoftext)\|>|\[/?(INST|SYS)\]|<</?SYS>>|#{2,}\s*(system|instructions?)\b)`),
+			Weight:  0.7,
+		},
+		{
+			Name:    "new_instructions",
+			Pattern: regexp.MustCompile(`(?i)\b(new|updated|real|actual)\s+(instructions?|system prompt)\s*:`),
+			Weight:  0.6,
+		},
+		{
+			Name:    "role_override",
+			Pattern: regexp.MustCompile(`(?i)(\byou are now\b|\bfrom now on,? you\b|\bdeveloper mode\b|\bjailbr(eak|oken)\b)`),
+			Weight:  0.5,
+		},
+		{
+			Name:    "reveal_prompt",
+			Pattern: regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b.{0,40}\b(system prompt|hidden instructions|initial instructions|your instructions)\b`),
+			Weight:  0.6,
+		},
+	}
+}
+
+// InjectionDetectorConfig configures InjectionDetector.
+type InjectionDetectorConfig struct {
+	// Patterns are the signs of injection to look for.
+	// Default: DefaultInjectionPatterns()
+	Patterns []InjectionPattern
+
+	// Threshold is the score, in (0, 1], at which a message is blocked.
+	// Default: 0.7
+	Threshold float64
+
+	// FlagOnly records the score of every suspicious message instead of
+	// blocking those at or above Threshold.
+	// Default: false
+	FlagOnly bool
+
+	// AttachmentText returns the text extracted from an attachment, which
+	// is scanned along with the message content.
+	// Default: the data of text/* attachments
+	AttachmentText func(attachment agenkit.Attachment) string
+}
+
+// InjectionDetector returns an input Rule that scores messages for prompt
+// injection. Each pattern found in the content or the attachments' text
+// adds its weight to the score, combined so that the score stays in [0, 1]
+// and rises with every further sign: 1 - (1-w1)(1-w2)...
+//
+// A message scoring at or above Threshold is blocked, with the names of
+// the matching patterns in the reason. A message scoring above zero but
+// not blocked passes through with the score in its metadata under
+// InjectionScoreKey, so downstream agents can trust it less.
+func InjectionDetector(config InjectionDetectorConfig) Rule {
+	if config.Patterns == nil {
+		config.Patterns = DefaultInjectionPatterns()
+	}
+	if config.Threshold <= 0 || config.Threshold > 1 {
+		config.Threshold = 0.7
+	}
+	if config.AttachmentText == nil {
+		config.AttachmentText = textAttachment
+	}
+
+	return func(ctx context.Context, message *agenkit.Message) (bool, string, error) {
+		texts := []string{message.Content}
+		for _, attachment := range message.Attachments {
+			if text := config.AttachmentText(attachment); text != "" {
+				texts = append(texts, text)
+			}
+		}
+
+		clean := 1.0
+		var matched []string
+		for _, pattern := range config.Patterns {
+			for _, text := range texts {
+				if pattern.Pattern.MatchString(text) {
+					clean *= 1 - pattern.Weight
+					matched = append(matched, pattern.Name)
+					break
+				}
+			}
+		}
+		if len(matched) == 0 {
+			return true, "", nil
+		}
+		score := 1 - clean
+		if !config.FlagOnly && score >= config.Threshold {
+			return false, fmt.Sprintf("possible prompt injection (score %.2f): %s", score, strings.Join(matched, ", ")), nil
+		}
+		if message.Metadata == nil {
+			message.Metadata = make(map[string]interface{})
+		}
+		message.Metadata[InjectionScoreKey] = score
+		return true, "", nil
+	}
+}
+
+// textAttachment returns the data of a text/* attachment as a string.
+func textAttachment(attachment agenkit.Attachment) string {
+	if strings.HasPrefix(attachment.MIMEType, "text/") {
+		return string(attachment.Data)
+	}
+	return ""
+}
@@ -0,0 +1,372 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ContextMetadataKey is the message metadata key holding supplementary
+// context for a message, such as memories retrieved by
+// memory.MemoryAugment. Agent sends the context as a system message after
+// the system prompt, leaving the message content itself unmodified.
+const ContextMetadataKey = "augmented_context"
+
+// AgentConfig configures an LLM-backed agent.
+type AgentConfig struct {
+	// Model overrides the provider's model for this agent's requests.
+	// Default: "" (the provider's model)
+	Model string
+
+	// SystemPrompt is prepended to every request as a system message.
+	SystemPrompt string
+
+	// Temperature controls sampling randomness.
+	// Default: 0
+	Temperature float64
+
+	// MaxTokens caps the number of generated tokens.
+	// Default: 0 (provider default)
+	MaxTokens int
+
+	// ContextWindow is the model's context size in tokens. When set, the
+	// request is fitted with FitToWindow to leave MaxTokens free for the
+	// reply, dropping the oldest history first.
+	// Default: 0 (no fitting)
+	ContextWindow int
+
+	// Tokenizer counts tokens when fitting the context window, checking
+	// the token budget, and estimating usage the provider does not report.
+	// Default: the tokenizer Tokenizers has for the provider's model
+	Tokenizer Tokenizer
+
+	// Tokenizers resolves the tokenizer by model when Tokenizer is unset.
+	// Default: DefaultTokenizers()
+	Tokenizers *TokenizerRegistry
+
+	// Tools are offered to the model on every call. Calls the model makes
+	// are returned on the reply under ToolCallsMetadataKey, ready for
+	// tools.ToolAgent.
+	Tools []agenkit.Tool
+
+	// ResponseFormat constrains every reply; WithResponseFormat overrides
+	// it per call through the context. Providers that are not
+	// FormatProviders, or do not support the format, get instructions in
+	// the prompt instead, unless StrictFormat is set.
+	// Default: FormatText
+	ResponseFormat ResponseFormat
+
+	// ForceTool, if set, names one of Tools that the model must call.
+	ForceTool string
+
+	// StrictFormat fails calls with ErrFormatUnsupported when the provider
+	// cannot enforce ResponseFormat or ForceTool natively.
+	// Default: false
+	StrictFormat bool
+}
+
+// Agent adapts a Provider to the agenkit.Agent interface.
+//
+// Each call sends the configured system prompt, then any context attached
+// to the message under ContextMetadataKey, then any History attached to
+// the context, then the incoming message, trimmed to ContextWindow if
+// set. If a TokenBudget is attached to the context, the agent refuses
+// calls the budget cannot cover and charges the budget with actual usage.
+// When the provider reports no usage, it is counted with the tokenizer
+// and the reply carries "usage_estimated" metadata.
+//
+// ProcessStream streams the reply as it is generated when the provider is
+// a StreamingProvider, including fragments of tool calls.
+type Agent struct {
+	name     string
+	provider Provider
+	config   AgentConfig
+}
+
+// Verify that Agent implements agenkit.Agent interface.
+var _ agenkit.Agent = (*Agent)(nil)
+
+// Verify that Agent implements agenkit.ToolUser interface.
+var _ agenkit.ToolUser = (*Agent)(nil)
+
+// Verify that Agent implements agenkit.ChunkStreamingAgent interface.
+var _ agenkit.ChunkStreamingAgent = (*Agent)(nil)
+
+// Verify that Agent implements agenkit.DeterminismReporter interface.
+var _ agenkit.DeterminismReporter = (*Agent)(nil)
+
+// NewAgent creates a new LLM-backed agent. NewLLMAgent builds one from
+// validated options instead.
+func NewAgent(name string, provider Provider, config AgentConfig) *Agent {
+	return &Agent{
+		name:     name,
+		provider: provider,
+		config:   config,
+	}
+}
+
+// Name returns the name of the agent.
+func (a *Agent) Name() string {
+	return a.name
+}
+
+// Capabilities returns the capabilities of the agent.
+func (a *Agent) Capabilities() []string {
+	return []string{"llm"}
+}
+
+// Provider returns the underlying provider.
+func (a *Agent) Provider() Provider {
+	return a.provider
+}
+
+// Model returns the model the agent requests: the configured Model, or
+// the provider's.
+func (a *Agent) Model() string {
+	if a.config.Model != "" {
+		return a.config.Model
+	}
+	return a.provider.Model()
+}
+
+// Deterministic reports whether the agent is configured to sample at
+// temperature 0. A temperature raised per call with WithTemperatureOverride is up
+// to the agent raising it: such agents, like reasoning.SelfConsistency,
+// report themselves non-deterministic.
+func (a *Agent) Deterministic() bool {
+	return a.config.Temperature == 0
+}
+
+// Config returns the agent's effective configuration, with Model set to
+// the provider's model when not overridden.
+func (a *Agent) Config() AgentConfig {
+	config := a.config
+	config.Model = a.Model()
+	config.Tools = append([]agenkit.Tool(nil), a.config.Tools...)
+	return config
+}
+
+// Tools returns the tools offered to the model.
+func (a *Agent) Tools() []agenkit.Tool {
+	return a.config.Tools
+}
+
+// Process sends the message to the provider and returns its reply.
+func (a *Agent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return a.process(ctx, message, nil)
+}
+
+// ProcessStream sends the message to the provider and streams its reply.
+//
+// With a StreamingProvider, text deltas and tool call fragments
+// (ToolCallDelta) are delivered as the model produces them; other
+// providers' replies arrive as a single delta. Each tool call is then
+// delivered complete, with its arguments parsed, before the terminal chunk
+// carrying the same reply Process returns.
+//
+// Cancelling ctx aborts the provider request, so no more tokens are
+// generated or billed, and closes the channel without further chunks.
+func (a *Agent) ProcessStream(ctx context.Context, message *agenkit.Message) (<-chan agenkit.StreamChunk, error) {
+	out := make(chan agenkit.StreamChunk)
+	go func() {
+		defer close(out)
+
+		streamed := false
+		emit := func(chunk agenkit.StreamChunk) {
+			streamed = true
+			agenkit.SendChunk(ctx, out, chunk)
+		}
+		result, err := a.process(ctx, message, emit)
+		if err != nil {
+			agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Err: err})
+			return
+		}
+		if !streamed && result.Content != "" {
+			if !agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Delta: result.Content}) {
+				return
+			}
+		}
+		for _, call := range ToolCallsFromMessage(result) {
+			call := call
+			if !agenkit.SendChunk(ctx, out, agenkit.StreamChunk{ToolCall: &call}) {
+				return
+			}
+		}
+		agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Message: result})
+	}()
+	return out, nil
+}
+
+// process implements Process and ProcessStream. When emit is set and the
+// provider can stream, chunks are passed to emit as they arrive.
+func (a *Agent) process(ctx context.Context, message *agenkit.Message, emit func(agenkit.StreamChunk)) (result *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "llm.complete",
+		attribute.String("agent.name", a.name),
+		attribute.String("llm.model", a.Model()),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, a.name, message)
+	defer func() { finish(result, err) }()
+
+	request, err := a.buildRequest(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: %w", a.name, err)
+	}
+
+	budget := TokenBudgetFromContext(ctx)
+	if budget != nil {
+		if err := budget.Check(CountRequestTokens(request, a.tokenizer())); err != nil {
+			return nil, fmt.Errorf("agent %s: %w", a.name, err)
+		}
+	}
+
+	var response *Response
+	start := time.Now()
+	if streamer, ok := a.provider.(StreamingProvider); ok && emit != nil {
+		response, err = streamer.Stream(ctx, request, emit)
+	} else {
+		response, err = a.provider.Complete(ctx, request)
+	}
+	duration := time.Since(start)
+	if err == nil && (response == nil || response.Message == nil) {
+		err = &agenkit.ProviderError{Provider: a.provider.Model(), Message: "empty response"}
+	}
+	if err != nil {
+		agenkit.Logger(ctx).ErrorContext(ctx, "llm call failed",
+			slog.String(agenkit.LogKeyAgent, a.name),
+			slog.String(agenkit.LogKeyModel, a.Model()),
+			slog.Duration(agenkit.LogKeyDuration, duration),
+			slog.String(agenkit.LogKeyError, err.Error()),
+		)
+		return nil, fmt.Errorf("agent %s: completion failed: %w", a.name, err)
+	}
+
+	estimated := false
+	if response.Usage.TotalTokens() == 0 {
+		response.Usage = estimateUsage(request, response.Message, a.tokenizer())
+		estimated = true
+	}
+	span.SetAttributes(
+		attribute.Int("llm.usage.input_tokens", response.Usage.InputTokens),
+		attribute.Int("llm.usage.output_tokens", response.Usage.OutputTokens),
+	)
+
+	if budget != nil {
+		budget.Consume(response.Usage.TotalTokens())
+	}
+
+	model := response.Model
+	if model == "" {
+		model = a.Model()
+	}
+	if tracker := CostTrackerFromContext(ctx); tracker != nil {
+		tracker.Record(model, response.Usage)
+	}
+	agenkit.Logger(ctx).InfoContext(ctx, "llm call completed",
+		slog.String(agenkit.LogKeyAgent, a.name),
+		slog.String(agenkit.LogKeyModel, model),
+		slog.Group(agenkit.LogKeyTokens,
+			slog.Int("input", response.Usage.InputTokens),
+			slog.Int("output", response.Usage.OutputTokens),
+		),
+		slog.Duration(agenkit.LogKeyDuration, duration),
+	)
+
+	result = response.Message
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["model"] = model
+	result.Metadata["usage"] = response.Usage
+	if estimated {
+		result.Metadata["usage_estimated"] = true
+	}
+	if request.Seed != nil {
+		result.Metadata["seed"] = *request.Seed
+		if _, ok := result.Metadata["seed_honored"]; !ok {
+			result.Metadata["seed_honored"] = false
+		}
+	}
+	return result, nil
+}
+
+// tokenizer returns the configured tokenizer, or the one registered for
+// the provider's model.
+func (a *Agent) tokenizer() Tokenizer {
+	if a.config.Tokenizer != nil {
+		return a.config.Tokenizer
+	}
+	registry := a.config.Tokenizers
+	if registry == nil {
+		registry = defaultTokenizers
+	}
+	return registry.Lookup(a.Model())
+}
+
+// buildRequest assembles the provider request for a message.
+func (a *Agent) buildRequest(ctx context.Context, message *agenkit.Message) (*Request, error) {
+	messages, err := a.requestMessages(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.config.ContextWindow > 0 {
+		fitted, err := FitToWindow(messages, a.config.ContextWindow-a.config.MaxTokens, a.tokenizer())
+		if err != nil {
+			return nil, err
+		}
+		messages = fitted
+	}
+
+	temperature := a.config.Temperature
+	if override, ok := TemperatureOverrideFromContext(ctx); ok {
+		temperature = override
+	}
+
+	request := &Request{
+		Messages:       messages,
+		Model:          a.config.Model,
+		Temperature:    temperature,
+		MaxTokens:      a.config.MaxTokens,
+		Tools:          a.config.Tools,
+		ResponseFormat: a.config.ResponseFormat,
+		ForceTool:      a.config.ForceTool,
+		StrictFormat:   a.config.StrictFormat,
+	}
+	if seed, ok := SeedFromContext(ctx); ok {
+		request.Seed = &seed
+	}
+	if format, ok := ResponseFormatFromContext(ctx); ok {
+		request.ResponseFormat = format
+	}
+	adapted, err := adaptFormat(a.provider, request)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: a.provider.Model(), Err: err}
+	}
+	return adapted, nil
+}
+
+// requestMessages returns the messages sent for message, before fitting
+// them to the context window.
+func (a *Agent) requestMessages(ctx context.Context, message *agenkit.Message) ([]*agenkit.Message, error) {
+	messages := make([]*agenkit.Message, 0, 2)
+	if a.config.SystemPrompt != "" {
+		messages = append(messages, agenkit.NewMessage("system", a.config.SystemPrompt))
+	}
+	if extra, _ := message.Metadata[ContextMetadataKey].(string); extra != "" {
+		messages = append(messages, agenkit.NewMessage("system", extra))
+	}
+	if history := HistoryFromContext(ctx); history != nil {
+		past, err := history.Messages(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history: %w", err)
+		}
+		messages = append(messages, past...)
+	}
+	return append(messages, message), nil
+}
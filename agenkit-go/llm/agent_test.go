@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// fakeProvider echoes the last message and reports fixed usage.
+type fakeProvider struct {
+	mu       sync.Mutex
+	usage    Usage
+	err      error
+	requests []*Request
+}
+
+func (f *fakeProvider) Model() string { return "fake-model" }
+
+func (f *fakeProvider) Complete(ctx context.Context, request *Request) (*Response, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, request)
+	f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	last := request.Messages[len(request.Messages)-1]
+	return &Response{
+		Message: agenkit.NewMessage("agent", "echo: "+last.Content),
+		Usage:   f.usage,
+		Model:   f.Model(),
+	}, nil
+}
+
+// emptyProvider answers without a message.
+type emptyProvider struct{}
+
+func (e *emptyProvider) Model() string { return "empty-model" }
+
+func (e *emptyProvider) Complete(ctx context.Context, request *Request) (*Response, error) {
+	return &Response{}, nil
+}
+
+func TestAgentRejectsEmptyResponse(t *testing.T) {
+	agent := NewAgent("assistant", &emptyProvider{}, AgentConfig{})
+
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	var providerErr *agenkit.ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("Expected a ProviderError, got %v", err)
+	}
+	if providerErr.Provider != "empty-model" {
+		t.Errorf("Expected the provider's model in the error, got %q", providerErr.Provider)
+	}
+}
+
+func TestAgentProcess(t *testing.T) {
+	provider := &fakeProvider{usage: Usage{InputTokens: 3, OutputTokens: 2}}
+	agent := NewAgent("assistant", provider, AgentConfig{SystemPrompt: "be brief", MaxTokens: 50})
+
+	result, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if result.Content != "echo: hi" {
+		t.Errorf("Expected 'echo: hi', got '%s'", result.Content)
+	}
+	if result.Metadata["model"] != "fake-model" {
+		t.Errorf("Expected model metadata, got %v", result.Metadata["model"])
+	}
+
+	req := provider.requests[0]
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != "be brief" {
+		t.Errorf("Expected system prompt to be prepended, got %+v", req.Messages)
+	}
+	if req.MaxTokens != 50 {
+		t.Errorf("Expected MaxTokens 50, got %d", req.MaxTokens)
+	}
+}
+
+func TestAgentProcessProviderError(t *testing.T) {
+	provider := &fakeProvider{err: errors.New("provider down")}
+	agent := NewAgent("assistant", provider, AgentConfig{})
+
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err == nil {
+		t.Fatal("Expected error from failing provider")
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if EstimateTokens("") != 0 {
+		t.Error("Expected 0 tokens for empty text")
+	}
+	if got := EstimateTokens("abcdefgh"); got != 2 {
+		t.Errorf("Expected 2 tokens, got %d", got)
+	}
+	if got := EstimateTokens("abcde"); got != 2 {
+		t.Errorf("Expected partial tokens to round up, got %d", got)
+	}
+}
+
+func TestAgentTemperatureOverride(t *testing.T) {
+	provider := &fakeProvider{}
+	agent := NewAgent("assistant", provider, AgentConfig{Temperature: 0.2})
+
+	_, _ = agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	_, _ = agent.Process(WithTemperatureOverride(context.Background(), 0.9), agenkit.NewMessage("user", "hi"))
+
+	if provider.requests[0].Temperature != 0.2 {
+		t.Errorf("Expected configured temperature 0.2, got %v", provider.requests[0].Temperature)
+	}
+	if provider.requests[1].Temperature != 0.9 {
+		t.Errorf("Expected overridden temperature 0.9, got %v", provider.requests[1].Temperature)
+	}
+}
+
+func TestAgentRecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx := agenkit.WithTracerProvider(context.Background(), tp)
+
+	provider := &fakeProvider{usage: Usage{InputTokens: 7, OutputTokens: 4}}
+	agent := NewAgent("assistant", provider, AgentConfig{})
+	if _, err := agent.Process(ctx, agenkit.NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := make(map[string]string)
+	for _, attr := range spans[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["llm.model"] != "fake-model" {
+		t.Errorf("Expected llm.model 'fake-model', got '%s'", attrs["llm.model"])
+	}
+	if attrs["llm.usage.input_tokens"] != "7" || attrs["llm.usage.output_tokens"] != "4" {
+		t.Errorf("Expected token counts 7/4, got %s/%s", attrs["llm.usage.input_tokens"], attrs["llm.usage.output_tokens"])
+	}
+}
+
+func TestAgentSeed(t *testing.T) {
+	provider := &fakeProvider{}
+	agent := NewAgent("llm", provider, AgentConfig{})
+
+	result, err := agent.Process(WithSeed(context.Background(), 42), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if seed := provider.requests[0].Seed; seed == nil || *seed != 42 {
+		t.Errorf("Expected seed 42 on the request, got %v", seed)
+	}
+	if result.Metadata["seed_honored"] != false {
+		t.Errorf("Expected seed_honored false for a provider that does not confirm seeding, got %v", result.Metadata["seed_honored"])
+	}
+
+	unseeded, _ := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if _, ok := unseeded.Metadata["seed_honored"]; ok || provider.requests[1].Seed != nil {
+		t.Error("Expected no seed without WithSeed")
+	}
+}
@@ -0,0 +1,569 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// anthropicVersion is the Messages API version sent with every request.
+const anthropicVersion = "2023-06-01"
+
+// AnthropicConfig configures an Anthropic Messages API provider.
+type AnthropicConfig struct {
+	// APIKey authenticates requests.
+	// Default: the ANTHROPIC_API_KEY environment variable
+	APIKey string
+
+	// Model is the model to call.
+	// Default: "claude-sonnet-4-20250514"
+	Model string
+
+	// MaxTokens is used when a request does not set MaxTokens, since the
+	// API requires a limit.
+	// Default: 1024
+	MaxTokens int
+
+	// BaseURL is the API root.
+	// Default: "https://api.anthropic.com"
+	BaseURL string
+
+	// HTTPClient sends the requests.
+	// Default: http.DefaultClient
+	HTTPClient *http.Client
+
+	// StreamRetries is how many times Stream retries a request whose
+	// connection fails before any output was emitted. Negative values
+	// disable retrying.
+	// Default: 2
+	StreamRetries int
+
+	// StreamBackoff spaces the retries of Stream.
+	// Default: exponential from 200ms up to 5s
+	StreamBackoff *agenkit.Backoff
+}
+
+// AnthropicProvider calls the Anthropic Messages API.
+//
+// System messages are sent as the system prompt and messages with role
+// "agent" as assistant turns. Tool calls are translated to and from
+// tool_use and tool_result content blocks; text interleaved with tool_use
+// blocks is joined into the reply's content and the calls are returned in
+// block order, exactly as OpenAIProvider returns them. Failed calls return
+// *agenkit.RateLimitError, *agenkit.ContextLengthError or
+// *agenkit.ProviderError.
+type AnthropicProvider struct {
+	config AnthropicConfig
+}
+
+// Verify that AnthropicProvider implements ToolCaller interface.
+var _ ToolCaller = (*AnthropicProvider)(nil)
+
+// Verify that AnthropicProvider implements FormatProvider interface.
+var _ FormatProvider = (*AnthropicProvider)(nil)
+
+// Verify that AnthropicProvider implements StreamingProvider interface.
+var _ StreamingProvider = (*AnthropicProvider)(nil)
+
+// NewAnthropicProvider creates a new Anthropic provider.
+func NewAnthropicProvider(config AnthropicConfig) *AnthropicProvider {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if config.Model == "" {
+		config.Model = "claude-sonnet-4-20250514"
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = 1024
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.anthropic.com"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.StreamRetries == 0 {
+		config.StreamRetries = 2
+	}
+	if config.StreamBackoff == nil {
+		config.StreamBackoff = agenkit.NewExponentialBackoff(200*time.Millisecond, 5*time.Second, 2)
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &AnthropicProvider{config: config}
+}
+
+// Model returns the configured model.
+func (p *AnthropicProvider) Model() string {
+	return p.config.Model
+}
+
+// SupportsFormat reports whether format is FormatText: the Messages API
+// has no JSON mode, so JSON formats are requested in the prompt.
+func (p *AnthropicProvider) SupportsFormat(format ResponseFormat) bool {
+	return format.IsText()
+}
+
+// SupportsForceTool reports true: forced tools are sent as tool_choice.
+func (p *AnthropicProvider) SupportsForceTool() bool {
+	return true
+}
+
+// CallWithTools completes messages, letting the model call any of tools.
+func (p *AnthropicProvider) CallWithTools(ctx context.Context, messages []*agenkit.Message, tools []agenkit.Tool) (*Response, error) {
+	return p.Complete(ctx, &Request{Messages: messages, Tools: tools})
+}
+
+// anthropicBlock is a content block in Anthropic's wire format.
+type anthropicBlock struct {
+	Type      string           `json:"type"`
+	Text      string           `json:"text,omitempty"`
+	ID        string           `json:"id,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Input     json.RawMessage  `json:"input,omitempty"`
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   string           `json:"content,omitempty"`
+	Source    *anthropicSource `json:"source,omitempty"`
+}
+
+// anthropicSource is the source of an image or document block.
+type anthropicSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	System      string               `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature float64              `json:"temperature"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicStreamEvent is one event of a streamed message. Which fields are
+// set depends on Type.
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	ContentBlock anthropicBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Complete sends the request to the Messages endpoint.
+func (p *AnthropicProvider) Complete(ctx context.Context, request *Request) (*Response, error) {
+	wire, err := p.wireRequest(request)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: "anthropic", Err: err}
+	}
+	resp, err := p.post(ctx, wire)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: "failed to read response", Err: err}
+	}
+
+	var decoded anthropicResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: "invalid response", Err: err}
+	}
+	response, err := decodeAnthropicResponse(&decoded)
+	if err != nil {
+		return nil, err
+	}
+	if request.Seed != nil {
+		// The Messages API has no seed parameter
+		response.Message.Metadata["seed_honored"] = false
+	}
+	return response, nil
+}
+
+// Stream sends the request with streaming enabled, emitting text and tool
+// call fragments as they arrive.
+//
+// If the connection fails before anything was emitted, the request is
+// retried up to StreamRetries times with StreamBackoff; if it fails later,
+// Stream returns a *PartialStreamError. Errors returned by the API are not
+// retried.
+func (p *AnthropicProvider) Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	wire, err := p.wireRequest(request)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: "anthropic", Err: err}
+	}
+	wire.Stream = true
+	return retryStream(ctx, "anthropic", p.config.StreamRetries, p.config.StreamBackoff, emit, func(emit func(agenkit.StreamChunk)) (*Response, bool, error) {
+		return p.stream(ctx, request, wire, emit)
+	})
+}
+
+// stream makes one streaming request. transport reports whether it failed
+// because the connection did, rather than with an error from the API.
+func (p *AnthropicProvider) stream(ctx context.Context, request *Request, wire *anthropicRequest, emit func(agenkit.StreamChunk)) (response *Response, transport bool, err error) {
+	resp, err := p.post(ctx, wire)
+	if err != nil {
+		return nil, isTransportError(err), err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var calls ToolCallAccumulator
+	var model, stopReason string
+	var usage Usage
+	// Tool calls are numbered among themselves, not among all blocks
+	callIndex := make(map[int]int)
+	done := false
+	err = readSSE(ctx, resp.Body, func(_, data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: "invalid stream event", Err: err}
+		}
+		switch event.Type {
+		case "message_start":
+			model = event.Message.Model
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				index := len(callIndex)
+				callIndex[event.Index] = index
+				delta := agenkit.ToolCallDelta{Index: index, ID: event.ContentBlock.ID, Name: event.ContentBlock.Name}
+				calls.Add(delta)
+				emit(agenkit.StreamChunk{ToolCallDelta: &delta})
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				content.WriteString(event.Delta.Text)
+				emit(agenkit.StreamChunk{Delta: event.Delta.Text})
+			case "input_json_delta":
+				index, ok := callIndex[event.Index]
+				if !ok {
+					return &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: fmt.Sprintf("input delta for unknown block %d", event.Index)}
+				}
+				delta := agenkit.ToolCallDelta{Index: index, ArgumentsDelta: event.Delta.PartialJSON}
+				calls.Add(delta)
+				emit(agenkit.StreamChunk{ToolCallDelta: &delta})
+			}
+		case "message_delta":
+			stopReason = event.Delta.StopReason
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "message_stop":
+			done = true
+		case "error":
+			return &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: event.Error.Message}
+		}
+		return nil
+	})
+	if err != nil {
+		var providerErr *agenkit.ProviderError
+		if errors.As(err, &providerErr) {
+			return nil, false, err
+		}
+		// A dropped connection is not the request's fault, so it carries no
+		// status and stays retryable
+		return nil, true, &agenkit.ProviderError{Provider: "anthropic", Message: "failed to read stream", Err: err}
+	}
+	if !done {
+		return nil, true, &agenkit.ProviderError{Provider: "anthropic", Message: "stream ended before message_stop"}
+	}
+
+	toolCalls, err := calls.ToolCalls()
+	if err != nil {
+		return nil, false, &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: "invalid streamed tool call", Err: err}
+	}
+	message := agenkit.NewMessage("agent", content.String())
+	message.Metadata["finish_reason"] = stopReason
+	if len(toolCalls) > 0 {
+		message.Metadata[ToolCallsMetadataKey] = toolCalls
+	}
+	if request.Seed != nil {
+		message.Metadata["seed_honored"] = false
+	}
+	return &Response{Message: message, Usage: usage, Model: model, ToolCalls: toolCalls}, false, nil
+}
+
+// post sends an encoded request to the Messages endpoint. Error statuses
+// are returned as typed errors.
+func (p *AnthropicProvider) post(ctx context.Context, wire *anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: "anthropic", Err: err}
+	}
+	url := p.config.BaseURL + "/v1/messages"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &agenkit.ProviderError{Provider: "anthropic", Message: "failed to create request", Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Api-Key", p.config.APIKey)
+	httpReq.Header.Set("Anthropic-Version", anthropicVersion)
+
+	raw := startRawIO(ctx, "anthropic", wire.Model, url, body)
+	resp, err := p.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		raw.finish(0, nil, err)
+		return nil, &agenkit.ProviderError{Provider: "anthropic", Message: "request failed", Err: err}
+	}
+	raw.capture(resp)
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: "failed to read response", Err: err}
+		}
+		return nil, anthropicStatusError(resp, data)
+	}
+	return resp, nil
+}
+
+// wireRequest converts a request to Anthropic's format.
+func (p *AnthropicProvider) wireRequest(request *Request) (*anthropicRequest, error) {
+	request, err := adaptFormat(p, request)
+	if err != nil {
+		return nil, err
+	}
+	out := &anthropicRequest{
+		Model:       p.config.Model,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+	}
+	if request.Model != "" {
+		out.Model = request.Model
+	}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = p.config.MaxTokens
+	}
+
+	var system []string
+	for _, msg := range request.Messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		wire, err := encodeAnthropicMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		// Consecutive turns from the same role, such as the results of
+		// parallel tool calls, must share one message
+		if last := len(out.Messages) - 1; last >= 0 && out.Messages[last].Role == wire.Role {
+			out.Messages[last].Content = append(out.Messages[last].Content, wire.Content...)
+			continue
+		}
+		out.Messages = append(out.Messages, wire)
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, tool := range request.Tools {
+		out.Tools = append(out.Tools, anthropicTool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: ToolSchema(tool),
+		})
+	}
+	if request.ForceTool != "" {
+		out.ToolChoice = &anthropicToolChoice{Type: "tool", Name: request.ForceTool}
+	}
+	return out, nil
+}
+
+// encodeAnthropicMessage converts an agenkit message to Anthropic's format.
+func encodeAnthropicMessage(msg *agenkit.Message) (anthropicMessage, error) {
+	if len(msg.Attachments) > 0 && msg.Role != "user" {
+		return anthropicMessage{}, &agenkit.UnsupportedAttachmentError{
+			Provider: "anthropic",
+			MIMEType: msg.Attachments[0].MIMEType,
+			Reason:   fmt.Sprintf("%s messages cannot carry attachments", msg.Role),
+		}
+	}
+	switch msg.Role {
+	case "agent", "assistant":
+		wire := anthropicMessage{Role: "assistant"}
+		if msg.Content != "" {
+			wire.Content = append(wire.Content, anthropicBlock{Type: "text", Text: msg.Content})
+		}
+		for _, call := range ToolCallsFromMessage(msg) {
+			params := call.Parameters
+			if params == nil {
+				params = map[string]interface{}{}
+			}
+			input, err := json.Marshal(params)
+			if err != nil {
+				return wire, err
+			}
+			wire.Content = append(wire.Content, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.ToolName, Input: input})
+		}
+		return wire, nil
+	case "tool":
+		id, _ := msg.Metadata[ToolCallIDMetadataKey].(string)
+		return anthropicMessage{
+			Role:    "user",
+			Content: []anthropicBlock{{Type: "tool_result", ToolUseID: id, Content: msg.Content}},
+		}, nil
+	default:
+		if len(msg.Attachments) == 0 {
+			return anthropicMessage{
+				Role:    "user",
+				Content: []anthropicBlock{{Type: "text", Text: msg.Content}},
+			}, nil
+		}
+		wire := anthropicMessage{Role: "user"}
+		for _, attachment := range msg.Attachments {
+			block, err := encodeAnthropicAttachment(attachment)
+			if err != nil {
+				return wire, err
+			}
+			wire.Content = append(wire.Content, block)
+		}
+		if msg.Content != "" {
+			wire.Content = append(wire.Content, anthropicBlock{Type: "text", Text: msg.Content})
+		}
+		return wire, nil
+	}
+}
+
+// encodeAnthropicAttachment converts an attachment to an image block, or a
+// document block for PDFs.
+func encodeAnthropicAttachment(attachment agenkit.Attachment) (anthropicBlock, error) {
+	var block anthropicBlock
+	switch {
+	case imageMIMETypes[attachment.MIMEType]:
+		block.Type = "image"
+	case attachment.MIMEType == "application/pdf":
+		block.Type = "document"
+	default:
+		return block, &agenkit.UnsupportedAttachmentError{Provider: "anthropic", MIMEType: attachment.MIMEType}
+	}
+	if err := checkAttachment(attachment); err != nil {
+		return block, err
+	}
+	if attachment.URL != "" {
+		block.Source = &anthropicSource{Type: "url", URL: attachment.URL}
+	} else {
+		block.Source = &anthropicSource{
+			Type:      "base64",
+			MediaType: attachment.MIMEType,
+			Data:      base64.StdEncoding.EncodeToString(attachment.Data),
+		}
+	}
+	return block, nil
+}
+
+// decodeAnthropicResponse converts a Messages API reply to a Response.
+func decodeAnthropicResponse(decoded *anthropicResponse) (*Response, error) {
+	var text strings.Builder
+	var calls []agenkit.ToolCall
+	for _, block := range decoded.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			params, err := decodeArguments(block.Input)
+			if err != nil {
+				return nil, &agenkit.ProviderError{Provider: "anthropic", Message: "invalid input for tool call " + block.Name, Err: err}
+			}
+			calls = append(calls, agenkit.ToolCall{ID: block.ID, ToolName: block.Name, Parameters: params})
+		}
+	}
+
+	message := agenkit.NewMessage("agent", text.String())
+	message.Metadata["finish_reason"] = decoded.StopReason
+	if len(calls) > 0 {
+		message.Metadata[ToolCallsMetadataKey] = calls
+	}
+	return &Response{
+		Message: message,
+		Usage: Usage{
+			InputTokens:  decoded.Usage.InputTokens,
+			OutputTokens: decoded.Usage.OutputTokens,
+		},
+		Model:     decoded.Model,
+		ToolCalls: calls,
+	}, nil
+}
+
+// anthropicStatusError maps an error response to the typed error set.
+func anthropicStatusError(resp *http.Response, data []byte) error {
+	var body anthropicError
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		message = body.Error.Message
+	}
+	cause := &agenkit.ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Message: message}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &agenkit.RateLimitError{Provider: "anthropic", RetryAfter: parseRetryAfter(resp.Header), Err: cause}
+	case resp.StatusCode == http.StatusBadRequest && strings.Contains(message, "prompt is too long"):
+		return &agenkit.ContextLengthError{Provider: "anthropic", Err: cause}
+	default:
+		return cause
+	}
+}
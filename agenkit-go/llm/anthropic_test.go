@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+const anthropicToolReply = `{
+	"model": "claude-test",
+	"stop_reason": "tool_use",
+	"content": [
+		{"type": "text", "text": "Let me check. "},
+		{"type": "tool_use", "id": "call_1", "name": "get_weather", "input": {"city": "Paris"}},
+		{"type": "text", "text": "And Rome."},
+		{"type": "tool_use", "id": "call_2", "name": "get_weather", "input": {"city": "Rome"}}
+	],
+	"usage": {"input_tokens": 12, "output_tokens": 7}
+}`
+
+func TestAnthropicCallWithTools(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, anthropicToolReply, &body)
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL})
+
+	response, err := provider.CallWithTools(context.Background(), []*agenkit.Message{
+		agenkit.NewMessage("system", "Be helpful"),
+		agenkit.NewMessage("user", "Weather in Paris and Rome?"),
+	}, []agenkit.Tool{weatherTool{}})
+	if err != nil {
+		t.Fatalf("CallWithTools failed: %v", err)
+	}
+
+	if body["system"] != "Be helpful" || body["max_tokens"] != float64(1024) {
+		t.Errorf("Expected system prompt and default max_tokens, got %v / %v", body["system"], body["max_tokens"])
+	}
+	tool := body["tools"].([]any)[0].(map[string]any)
+	if tool["name"] != "get_weather" || tool["input_schema"] == nil {
+		t.Errorf("Expected tool with input_schema, got %v", tool)
+	}
+
+	if response.Message.Content != "Let me check. And Rome." {
+		t.Errorf("Expected interleaved text joined, got '%s'", response.Message.Content)
+	}
+	if len(response.ToolCalls) != 2 || response.ToolCalls[0].ID != "call_1" || response.ToolCalls[1].Parameters["city"] != "Rome" {
+		t.Errorf("Expected both calls in block order, got %+v", response.ToolCalls)
+	}
+}
+
+func TestAnthropicMergesParallelToolResults(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"content":[{"type":"text","text":"Both sunny"}]}`, &body)
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL})
+
+	calls := []agenkit.ToolCall{
+		{ID: "call_1", ToolName: "get_weather", Parameters: map[string]interface{}{"city": "Paris"}},
+		{ID: "call_2", ToolName: "get_weather", Parameters: map[string]interface{}{"city": "Rome"}},
+	}
+	_, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{
+		agenkit.NewMessage("user", "Weather?"),
+		agenkit.NewMessage("agent", "").WithMetadata(ToolCallsMetadataKey, calls),
+		NewToolResultMessage(calls[0], "sunny"),
+		NewToolResultMessage(calls[1], "sunny"),
+	}})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	messages := body["messages"].([]any)
+	if len(messages) != 3 {
+		t.Fatalf("Expected user, assistant and one merged user turn, got %d messages", len(messages))
+	}
+	assistant := messages[1].(map[string]any)["content"].([]any)
+	if len(assistant) != 2 || assistant[0].(map[string]any)["type"] != "tool_use" {
+		t.Errorf("Expected two tool_use blocks, got %v", assistant)
+	}
+	results := messages[2].(map[string]any)["content"].([]any)
+	if len(results) != 2 || results[1].(map[string]any)["tool_use_id"] != "call_2" {
+		t.Errorf("Expected both tool results in one turn, in order, got %v", results)
+	}
+}
+
+func TestProvidersNormalizeToolCallsIdentically(t *testing.T) {
+	openAI := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: captureServer(t, http.StatusOK, nil, openAIToolReply, nil).URL})
+	anthropic := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: captureServer(t, http.StatusOK, nil, anthropicToolReply, nil).URL})
+
+	messages := []*agenkit.Message{agenkit.NewMessage("user", "Weather?")}
+	var results [][]agenkit.ToolCall
+	for _, provider := range []ToolCaller{openAI, anthropic} {
+		response, err := provider.CallWithTools(context.Background(), messages, []agenkit.Tool{weatherTool{}})
+		if err != nil {
+			t.Fatalf("%s: CallWithTools failed: %v", provider.Model(), err)
+		}
+		results = append(results, response.ToolCalls)
+	}
+	if !reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("Expected identical tool calls, got\n  %+v\n  %+v", results[0], results[1])
+	}
+}
+
+func TestAnthropicTypedErrors(t *testing.T) {
+	server := captureServer(t, http.StatusBadRequest, nil,
+		`{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 250000 tokens > 200000 maximum"}}`, nil)
+	_, err := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL}).
+		Complete(context.Background(), &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "hi")}})
+	if !errors.As(err, new(*agenkit.ContextLengthError)) {
+		t.Errorf("Expected ContextLengthError, got %v", err)
+	}
+
+	server = captureServer(t, 529, nil, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, nil)
+	_, err = NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL}).
+		Complete(context.Background(), &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "hi")}})
+	var pe *agenkit.ProviderError
+	if !errors.As(err, &pe) || pe.StatusCode != 529 || !agenkit.IsRetryable(err) {
+		t.Errorf("Expected retryable ProviderError 529, got %v", err)
+	}
+}
+
+func TestAnthropicSeedNotHonored(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"content":[{"type":"text","text":"hi"}]}`, &body)
+	agent := NewAgent("llm", NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	result, err := agent.Process(WithSeed(context.Background(), 7), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if _, ok := body["seed"]; ok {
+		t.Error("Expected no seed field in the Anthropic request")
+	}
+	if result.Metadata["seed_honored"] != false {
+		t.Errorf("Expected seed_honored false, got %v", result.Metadata["seed_honored"])
+	}
+}
+
+func TestAnthropicEncodesAttachments(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"content":[{"type":"text","text":"A contract"}]}`, &body)
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL})
+
+	question := agenkit.NewMessage("user", "What are these?").
+		WithAttachment(agenkit.Attachment{MIMEType: "image/webp", URL: "https://example.com/a.webp"}).
+		WithAttachment(agenkit.Attachment{MIMEType: "application/pdf", Data: []byte("%PDF")})
+	if _, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{question}}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	blocks := body["messages"].([]any)[0].(map[string]any)["content"].([]any)
+	if len(blocks) != 3 {
+		t.Fatalf("Expected image, document and text blocks, got %v", blocks)
+	}
+	image := blocks[0].(map[string]any)
+	if image["type"] != "image" || image["source"].(map[string]any)["url"] != "https://example.com/a.webp" {
+		t.Errorf("Expected a URL image block, got %v", image)
+	}
+	document := blocks[1].(map[string]any)
+	source := document["source"].(map[string]any)
+	if document["type"] != "document" || source["type"] != "base64" || source["media_type"] != "application/pdf" || source["data"] != "JVBERg==" {
+		t.Errorf("Expected a base64 document block, got %v", document)
+	}
+	if text := blocks[2].(map[string]any); text["text"] != "What are these?" {
+		t.Errorf("Expected the text after the attachments, got %v", text)
+	}
+}
+
+func TestAnthropicRejectsUnsupportedAttachments(t *testing.T) {
+	server := captureServer(t, http.StatusOK, nil, `{"content":[{"type":"text","text":"ok"}]}`, nil)
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL})
+
+	video := agenkit.NewMessage("user", "Watch").WithAttachment(agenkit.Attachment{MIMEType: "video/mp4", URL: "https://example.com/v.mp4"})
+	_, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{video}})
+	var attachmentErr *agenkit.UnsupportedAttachmentError
+	if !errors.As(err, &attachmentErr) || attachmentErr.MIMEType != "video/mp4" {
+		t.Errorf("Expected UnsupportedAttachmentError for video, got %v", err)
+	}
+
+	both := agenkit.NewMessage("user", "Look").WithAttachment(agenkit.Attachment{MIMEType: "image/png", Data: []byte("x"), URL: "https://example.com/a.png"})
+	if _, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{both}}); err == nil {
+		t.Error("Expected error for an attachment with both data and a URL")
+	}
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// imageMIMETypes are the image formats accepted by the vision models of
+// the supported providers.
+var imageMIMETypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// checkAttachment reports an attachment that does not carry exactly one of
+// Data and URL.
+func checkAttachment(attachment agenkit.Attachment) error {
+	if (len(attachment.Data) == 0) == (attachment.URL == "") {
+		return fmt.Errorf("attachment of type '%s' must have either data or a URL", attachment.MIMEType)
+	}
+	return nil
+}
+
+// dataURL returns the attachment's URL, or its data encoded as a data URL.
+func dataURL(attachment agenkit.Attachment) string {
+	if attachment.URL != "" {
+		return attachment.URL
+	}
+	return "data:" + attachment.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(attachment.Data)
+}
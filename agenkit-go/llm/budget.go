@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned when a call would exceed the remaining token budget.
+var ErrBudgetExceeded = errors.New("token budget exceeded")
+
+// TokenBudgetConfig configures a token budget.
+type TokenBudgetConfig struct {
+	// Limit is the total number of tokens available to the run.
+	Limit int
+
+	// LowThreshold is the remaining-token level at which OnBudgetLow fires.
+	// Default: 0 (disabled)
+	LowThreshold int
+
+	// OnBudgetLow is called once, the first time the remaining budget drops
+	// below LowThreshold. It runs synchronously in the consuming goroutine.
+	OnBudgetLow func(remaining int)
+}
+
+// TokenBudget is a token allowance shared by every agent in a run.
+//
+// A budget is safe for concurrent use, so a single budget can be shared
+// across the branches of a ParallelAgent. Checks are advisory: concurrent
+// calls that each pass Check may together overshoot the limit slightly,
+// since usage is only known once a call completes.
+type TokenBudget struct {
+	mu       sync.Mutex
+	config   TokenBudgetConfig
+	used     int
+	lowFired bool
+}
+
+// NewTokenBudget creates a new token budget.
+func NewTokenBudget(config TokenBudgetConfig) *TokenBudget {
+	return &TokenBudget{config: config}
+}
+
+// Limit returns the total number of tokens in the budget.
+func (b *TokenBudget) Limit() int {
+	return b.config.Limit
+}
+
+// Used returns the number of tokens consumed so far.
+func (b *TokenBudget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Remaining returns the number of tokens left (never negative).
+func (b *TokenBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining()
+}
+
+// remaining computes the remaining budget (must be called with lock held).
+func (b *TokenBudget) remaining() int {
+	if b.used >= b.config.Limit {
+		return 0
+	}
+	return b.config.Limit - b.used
+}
+
+// Check returns ErrBudgetExceeded if the estimated cost exceeds the remaining budget.
+func (b *TokenBudget) Check(estimated int) error {
+	remaining := b.Remaining()
+	if estimated > remaining {
+		return fmt.Errorf("%w: need ~%d tokens, %d remaining", ErrBudgetExceeded, estimated, remaining)
+	}
+	return nil
+}
+
+// Consume charges tokens against the budget.
+func (b *TokenBudget) Consume(tokens int) {
+	b.mu.Lock()
+	b.used += tokens
+	remaining := b.remaining()
+
+	fire := false
+	if b.config.OnBudgetLow != nil && !b.lowFired && remaining < b.config.LowThreshold {
+		b.lowFired = true
+		fire = true
+	}
+	b.mu.Unlock()
+
+	// Call outside the lock so the callback may inspect the budget
+	if fire {
+		b.config.OnBudgetLow(remaining)
+	}
+}
+
+type budgetContextKey struct{}
+
+// WithTokenBudget attaches a token budget to the context.
+func WithTokenBudget(ctx context.Context, budget *TokenBudget) context.Context {
+	return context.WithValue(ctx, budgetContextKey{}, budget)
+}
+
+// TokenBudgetFromContext returns the token budget attached to ctx, or nil.
+func TokenBudgetFromContext(ctx context.Context) *TokenBudget {
+	budget, _ := ctx.Value(budgetContextKey{}).(*TokenBudget)
+	return budget
+}
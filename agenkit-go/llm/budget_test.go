@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/composition"
+)
+
+func TestTokenBudgetConsume(t *testing.T) {
+	budget := NewTokenBudget(TokenBudgetConfig{Limit: 100})
+
+	budget.Consume(30)
+	if budget.Remaining() != 70 {
+		t.Errorf("Expected 70 remaining, got %d", budget.Remaining())
+	}
+	if budget.Used() != 30 {
+		t.Errorf("Expected 30 used, got %d", budget.Used())
+	}
+
+	budget.Consume(200)
+	if budget.Remaining() != 0 {
+		t.Errorf("Expected remaining to floor at 0, got %d", budget.Remaining())
+	}
+}
+
+func TestTokenBudgetCheck(t *testing.T) {
+	budget := NewTokenBudget(TokenBudgetConfig{Limit: 10})
+
+	if err := budget.Check(10); err != nil {
+		t.Errorf("Expected check within budget to pass, got %v", err)
+	}
+	if err := budget.Check(11); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded, got %v", err)
+	}
+}
+
+func TestTokenBudgetOnBudgetLowFiresOnce(t *testing.T) {
+	var calls []int
+	budget := NewTokenBudget(TokenBudgetConfig{
+		Limit:        100,
+		LowThreshold: 50,
+		OnBudgetLow:  func(remaining int) { calls = append(calls, remaining) },
+	})
+
+	budget.Consume(40)
+	budget.Consume(20)
+	budget.Consume(20)
+
+	if len(calls) != 1 {
+		t.Fatalf("Expected OnBudgetLow to fire once, fired %d times", len(calls))
+	}
+	if calls[0] != 40 {
+		t.Errorf("Expected callback with 40 remaining, got %d", calls[0])
+	}
+}
+
+func TestAgentRefusesCallOverBudget(t *testing.T) {
+	provider := &fakeProvider{usage: Usage{InputTokens: 10, OutputTokens: 10}}
+	agent := NewAgent("assistant", provider, AgentConfig{MaxTokens: 100})
+
+	budget := NewTokenBudget(TokenBudgetConfig{Limit: 50})
+	ctx := WithTokenBudget(context.Background(), budget)
+
+	_, err := agent.Process(ctx, agenkit.NewMessage("user", "hi"))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+	}
+	if len(provider.requests) != 0 {
+		t.Error("Expected provider not to be called when over budget")
+	}
+}
+
+func TestBudgetSharedAcrossSequential(t *testing.T) {
+	provider := &fakeProvider{usage: Usage{InputTokens: 20, OutputTokens: 20}}
+	agents := make([]agenkit.Agent, 5)
+	for i := range agents {
+		agents[i] = NewAgent("step", provider, AgentConfig{MaxTokens: 10})
+	}
+	seq, _ := composition.NewSequentialAgent("pipeline", agents...)
+
+	budget := NewTokenBudget(TokenBudgetConfig{Limit: 100})
+	ctx := WithTokenBudget(context.Background(), budget)
+
+	_, err := seq.Process(ctx, agenkit.NewMessage("user", "go"))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected run to stop with ErrBudgetExceeded, got %v", err)
+	}
+	if got := len(provider.requests); got != 3 {
+		t.Errorf("Expected 3 calls before budget ran out, got %d", got)
+	}
+}
+
+func TestBudgetConcurrentParallel(t *testing.T) {
+	provider := &fakeProvider{usage: Usage{InputTokens: 1, OutputTokens: 1}}
+	agents := make([]agenkit.Agent, 20)
+	for i := range agents {
+		agents[i] = NewAgent("branch", provider, AgentConfig{})
+	}
+	par, _ := composition.NewParallelAgent("fanout", agents...)
+
+	budget := NewTokenBudget(TokenBudgetConfig{Limit: 10000})
+	ctx := WithTokenBudget(context.Background(), budget)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = par.Process(ctx, agenkit.NewMessage("user", "go"))
+		}()
+	}
+	wg.Wait()
+
+	if budget.Used() != 200 {
+		t.Errorf("Expected 200 tokens used, got %d", budget.Used())
+	}
+}
+
+func TestTokenBudgetFromContextMissing(t *testing.T) {
+	if TokenBudgetFromContext(context.Background()) != nil {
+		t.Error("Expected nil budget for bare context")
+	}
+}
@@ -0,0 +1,266 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Cache stores completion responses by key.
+type Cache interface {
+	// Get returns the cached message for key, if present and unexpired.
+	Get(key string) (*agenkit.Message, bool)
+
+	// Set stores message under key. A ttl of zero or less never expires.
+	Set(key string, message *agenkit.Message, ttl time.Duration)
+}
+
+// CacheConfig configures CacheMiddleware.
+type CacheConfig struct {
+	// Cache stores the responses.
+	// Default: an LRU cache of 1000 entries
+	Cache Cache
+
+	// TTL is how long responses stay cached.
+	// Default: 0 (never expire)
+	TTL time.Duration
+
+	// CacheNonDeterministic also caches requests with Temperature > 0.
+	// Such requests are meant to vary, so they bypass the cache by default.
+	CacheNonDeterministic bool
+}
+
+// CacheMiddleware is a Provider that serves repeated requests from a cache.
+//
+// Keys hash the request's messages (role, content, attachments, tool calls
+// and tool call IDs), temperature, max tokens, tool names, seed, response
+// format and forced tool, and are namespaced by model so switching models
+// never returns another model's answer. StrictFormat is left out: it only
+// decides whether an unsupported format fails, not what a reply contains.
+// A hit returns the cached reply with zero usage and "cache_hit" set in its
+// metadata, without calling the wrapped provider.
+type CacheMiddleware struct {
+	provider Provider
+	config   CacheConfig
+}
+
+// Verify that CacheMiddleware implements Provider, StreamingProvider and
+// FormatProvider interfaces.
+var (
+	_ Provider          = (*CacheMiddleware)(nil)
+	_ StreamingProvider = (*CacheMiddleware)(nil)
+	_ FormatProvider    = (*CacheMiddleware)(nil)
+)
+
+// NewCacheMiddleware wraps provider with response caching.
+func NewCacheMiddleware(provider Provider, config CacheConfig) *CacheMiddleware {
+	if config.Cache == nil {
+		config.Cache = NewLRUCache(1000)
+	}
+	return &CacheMiddleware{provider: provider, config: config}
+}
+
+// Model returns the wrapped provider's model.
+func (c *CacheMiddleware) Model() string {
+	return c.provider.Model()
+}
+
+// SupportsFormat reports whether the wrapped provider supports format.
+func (c *CacheMiddleware) SupportsFormat(format ResponseFormat) bool {
+	fp, ok := c.provider.(FormatProvider)
+	return ok && fp.SupportsFormat(format)
+}
+
+// SupportsForceTool reports whether the wrapped provider can force tools.
+func (c *CacheMiddleware) SupportsForceTool() bool {
+	fp, ok := c.provider.(FormatProvider)
+	return ok && fp.SupportsForceTool()
+}
+
+// Complete returns a cached response if one exists, otherwise calls the
+// wrapped provider and caches its reply.
+func (c *CacheMiddleware) Complete(ctx context.Context, request *Request) (*Response, error) {
+	return c.serve(ctx, request, c.provider.Complete)
+}
+
+// Stream returns a cached response if one exists, without calling emit;
+// otherwise it streams the reply from the wrapped provider and caches it.
+func (c *CacheMiddleware) Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	return c.serve(ctx, request, func(ctx context.Context, request *Request) (*Response, error) {
+		return streamFrom(ctx, c.provider, request, emit)
+	})
+}
+
+// serve implements Complete and Stream, calling the wrapped provider with
+// call on a miss.
+func (c *CacheMiddleware) serve(ctx context.Context, request *Request, call func(ctx context.Context, request *Request) (*Response, error)) (*Response, error) {
+	if request.Temperature > 0 && !c.config.CacheNonDeterministic {
+		return call(ctx, request)
+	}
+
+	model := request.Model
+	if model == "" {
+		model = c.provider.Model()
+	}
+	key, err := CacheKey(model, request)
+	if err != nil {
+		return call(ctx, request)
+	}
+
+	if cached, ok := c.config.Cache.Get(key); ok {
+		message := copyMessage(cached)
+		message.Metadata["cache_hit"] = true
+		return &Response{Message: message, Model: model, ToolCalls: ToolCallsFromMessage(message)}, nil
+	}
+
+	response, err := call(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Message != nil {
+		c.config.Cache.Set(key, copyMessage(response.Message), c.config.TTL)
+	}
+	return response, nil
+}
+
+// CacheKey returns the cache key for request against model.
+func CacheKey(model string, request *Request) (string, error) {
+	type keyMessage struct {
+		Role        string               `json:"role"`
+		Content     string               `json:"content"`
+		Attachments []agenkit.Attachment `json:"attachments,omitempty"`
+		ToolCalls   []agenkit.ToolCall   `json:"tool_calls,omitempty"`
+		ToolCallID  string               `json:"tool_call_id,omitempty"`
+	}
+	messages := make([]keyMessage, len(request.Messages))
+	for i, msg := range request.Messages {
+		id, _ := msg.Metadata[ToolCallIDMetadataKey].(string)
+		messages[i] = keyMessage{Role: msg.Role, Content: msg.Content, Attachments: msg.Attachments, ToolCalls: ToolCallsFromMessage(msg), ToolCallID: id}
+	}
+	var tools []string
+	for _, tool := range request.Tools {
+		tools = append(tools, tool.Name())
+	}
+
+	var format *ResponseFormat
+	if !request.ResponseFormat.IsText() {
+		format = &request.ResponseFormat
+	}
+
+	data, err := json.Marshal(struct {
+		Messages    []keyMessage    `json:"messages"`
+		Temperature float64         `json:"temperature"`
+		MaxTokens   int             `json:"max_tokens"`
+		Tools       []string        `json:"tools,omitempty"`
+		Seed        *int64          `json:"seed,omitempty"`
+		Format      *ResponseFormat `json:"format,omitempty"`
+		ForceTool   string          `json:"force_tool,omitempty"`
+	}{messages, request.Temperature, request.MaxTokens, tools, request.Seed, format, request.ForceTool})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return model + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// copyMessage returns a copy of message with its own metadata map, so
+// callers that annotate responses cannot corrupt the cache.
+func copyMessage(message *agenkit.Message) *agenkit.Message {
+	copied := *message
+	copied.Metadata = make(map[string]interface{}, len(message.Metadata))
+	for k, v := range message.Metadata {
+		copied.Metadata[k] = v
+	}
+	return &copied
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used
+// entry once full. It is safe for concurrent use.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+// lruEntry is an element of LRUCache.order.
+type lruEntry struct {
+	key       string
+	message   *agenkit.Message
+	expiresAt time.Time
+}
+
+// Verify that LRUCache implements Cache interface.
+var _ Cache = (*LRUCache)(nil)
+
+// NewLRUCache creates an LRU cache holding up to capacity entries.
+// A capacity of zero or less defaults to 1000.
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key and marks it as recently used.
+func (c *LRUCache) Get(key string) (*agenkit.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.message, true
+}
+
+// Set stores an entry, evicting the least recently used one if full.
+func (c *LRUCache) Set(key string, message *agenkit.Message, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.message = message
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, message: message, expiresAt: expiresAt})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of entries, including any not yet evicted after
+// expiring.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// modelProvider is a fakeProvider reporting a configurable model.
+type modelProvider struct {
+	fakeProvider
+	model string
+}
+
+func (m *modelProvider) Model() string { return m.model }
+
+func cacheRequest(content string, temperature float64) *Request {
+	return &Request{
+		Messages:    []*agenkit.Message{agenkit.NewMessage("user", content)},
+		Temperature: temperature,
+	}
+}
+
+func TestCacheMiddlewareHit(t *testing.T) {
+	provider := &fakeProvider{usage: Usage{InputTokens: 10, OutputTokens: 5}}
+	cached := NewCacheMiddleware(provider, CacheConfig{})
+
+	first, err := cached.Complete(context.Background(), cacheRequest("hello", 0))
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	second, err := cached.Complete(context.Background(), cacheRequest("hello", 0))
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if len(provider.requests) != 1 {
+		t.Errorf("Expected provider to be called once, got %d", len(provider.requests))
+	}
+	if second.Message.Content != first.Message.Content {
+		t.Errorf("Expected cached content '%s', got '%s'", first.Message.Content, second.Message.Content)
+	}
+	if second.Message.Metadata["cache_hit"] != true {
+		t.Error("Expected cache_hit metadata on hit")
+	}
+	if second.Usage.TotalTokens() != 0 {
+		t.Errorf("Expected zero usage on hit, got %d", second.Usage.TotalTokens())
+	}
+	if _, ok := first.Message.Metadata["cache_hit"]; ok {
+		t.Error("Expected no cache_hit metadata on miss")
+	}
+}
+
+func TestCacheMiddlewareStream(t *testing.T) {
+	provider := &streamingFake{}
+	cached := NewCacheMiddleware(provider, CacheConfig{})
+
+	if deltas := streamDeltas(t, cached, cacheRequest("hello", 0)); len(deltas) != 2 {
+		t.Errorf("Expected the miss to stream from the provider, got %q", deltas)
+	}
+	response, err := cached.Stream(context.Background(), cacheRequest("hello", 0), func(agenkit.StreamChunk) {
+		t.Error("Expected no chunks for a hit")
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if len(provider.requests) != 1 || response.Message.Metadata["cache_hit"] != true {
+		t.Errorf("Expected the streamed reply to be cached, got %d calls and %v", len(provider.requests), response.Message.Metadata)
+	}
+}
+
+func TestCacheMiddlewareKeyIncludesParameters(t *testing.T) {
+	provider := &fakeProvider{}
+	cached := NewCacheMiddleware(provider, CacheConfig{})
+
+	cached.Complete(context.Background(), cacheRequest("hello", 0))
+	cached.Complete(context.Background(), cacheRequest("goodbye", 0))
+	withMax := cacheRequest("hello", 0)
+	withMax.MaxTokens = 50
+	cached.Complete(context.Background(), withMax)
+	withImage := cacheRequest("hello", 0)
+	withImage.Messages[0].WithAttachment(agenkit.Attachment{MIMEType: "image/png", URL: "https://example.com/a.png"})
+	cached.Complete(context.Background(), withImage)
+
+	if len(provider.requests) != 4 {
+		t.Errorf("Expected 4 distinct requests to reach the provider, got %d", len(provider.requests))
+	}
+}
+
+func TestCacheMiddlewareSkipsNonDeterministic(t *testing.T) {
+	provider := &fakeProvider{}
+	cached := NewCacheMiddleware(provider, CacheConfig{})
+
+	cached.Complete(context.Background(), cacheRequest("hello", 0.7))
+	cached.Complete(context.Background(), cacheRequest("hello", 0.7))
+	if len(provider.requests) != 2 {
+		t.Errorf("Expected sampled requests to bypass the cache, got %d provider calls", len(provider.requests))
+	}
+
+	provider = &fakeProvider{}
+	cached = NewCacheMiddleware(provider, CacheConfig{CacheNonDeterministic: true})
+	cached.Complete(context.Background(), cacheRequest("hello", 0.7))
+	cached.Complete(context.Background(), cacheRequest("hello", 0.7))
+	if len(provider.requests) != 1 {
+		t.Errorf("Expected override to cache sampled requests, got %d provider calls", len(provider.requests))
+	}
+}
+
+func TestCacheMiddlewareNamespacesByModel(t *testing.T) {
+	cache := NewLRUCache(10)
+	a := &modelProvider{model: "model-a"}
+	b := &modelProvider{model: "model-b"}
+
+	NewCacheMiddleware(a, CacheConfig{Cache: cache}).Complete(context.Background(), cacheRequest("hello", 0))
+	NewCacheMiddleware(b, CacheConfig{Cache: cache}).Complete(context.Background(), cacheRequest("hello", 0))
+
+	if len(b.requests) != 1 {
+		t.Errorf("Expected a different model to miss the cache, got %d calls", len(b.requests))
+	}
+}
+
+func TestCacheMiddlewareIsolatesCachedMessages(t *testing.T) {
+	cached := NewCacheMiddleware(&fakeProvider{}, CacheConfig{})
+
+	// The agent annotates response metadata; that must not leak into the cache
+	agent := NewAgent("cached", cached, AgentConfig{})
+	agent.Process(context.Background(), agenkit.NewMessage("user", "hello"))
+
+	hit, _ := cached.Complete(context.Background(), cacheRequest("hello", 0))
+	if _, ok := hit.Message.Metadata["usage"]; ok {
+		t.Error("Expected cached message to be unaffected by caller mutations")
+	}
+}
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", agenkit.NewMessage("agent", "a"), 0)
+	cache.Set("b", agenkit.NewMessage("agent", "b"), 0)
+	cache.Get("a")
+	cache.Set("c", agenkit.NewMessage("agent", "c"), 0)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("Expected recently used entry to survive")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", agenkit.NewMessage("agent", "a"), 10*time.Millisecond)
+
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("Expected entry before expiry")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected entry to expire")
+	}
+}
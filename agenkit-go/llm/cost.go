@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// UnpricedBucket is the Breakdown key under which usage of models missing
+// from the price table is reported.
+const UnpricedBucket = "unpriced"
+
+// ModelPricing is the price of a model in dollars per million tokens.
+type ModelPricing struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost returns the dollar cost of usage at this pricing.
+func (p ModelPricing) Cost(usage Usage) float64 {
+	return (float64(usage.InputTokens)*p.InputPerMillion + float64(usage.OutputTokens)*p.OutputPerMillion) / 1e6
+}
+
+// PriceTable maps model names to their pricing.
+type PriceTable map[string]ModelPricing
+
+// ModelCost is the accumulated usage and cost of one model (or the
+// unpriced bucket).
+type ModelCost struct {
+	Calls        int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64
+}
+
+// CostTracker accumulates token usage per model and converts it to dollars.
+//
+// A tracker is safe for concurrent use: it may be read while the branches of
+// a ParallelAgent are still recording into it.
+type CostTracker struct {
+	mu       sync.Mutex
+	prices   PriceTable
+	costs    map[string]*ModelCost
+	unpriced map[string]bool
+}
+
+// NewCostTracker creates a tracker that prices usage with prices.
+// The table is copied, so later changes to it have no effect.
+func NewCostTracker(prices PriceTable) *CostTracker {
+	copied := make(PriceTable, len(prices))
+	for model, pricing := range prices {
+		copied[model] = pricing
+	}
+	return &CostTracker{
+		prices:   copied,
+		costs:    make(map[string]*ModelCost),
+		unpriced: make(map[string]bool),
+	}
+}
+
+// Record adds one call's usage for model.
+func (c *CostTracker) Record(model string, usage Usage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := model
+	pricing, ok := c.prices[model]
+	if !ok {
+		key = UnpricedBucket
+		c.unpriced[model] = true
+	}
+
+	cost, exists := c.costs[key]
+	if !exists {
+		cost = &ModelCost{}
+		c.costs[key] = cost
+	}
+	cost.Calls++
+	cost.InputTokens += usage.InputTokens
+	cost.OutputTokens += usage.OutputTokens
+	cost.Cost += pricing.Cost(usage)
+}
+
+// Total returns the dollar cost of all priced usage so far.
+func (c *CostTracker) Total() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total float64
+	for _, cost := range c.costs {
+		total += cost.Cost
+	}
+	return total
+}
+
+// Breakdown returns a snapshot of usage and cost keyed by model. Usage of
+// models without pricing is aggregated under UnpricedBucket with zero cost.
+func (c *CostTracker) Breakdown() map[string]ModelCost {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breakdown := make(map[string]ModelCost, len(c.costs))
+	for model, cost := range c.costs {
+		breakdown[model] = *cost
+	}
+	return breakdown
+}
+
+// UnpricedModels returns the sorted names of models recorded without pricing.
+func (c *CostTracker) UnpricedModels() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	models := make([]string, 0, len(c.unpriced))
+	for model := range c.unpriced {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+type costTrackerContextKey struct{}
+
+// WithCostTracker attaches a cost tracker to the context. LLM agents called
+// with ctx record their usage into it.
+func WithCostTracker(ctx context.Context, tracker *CostTracker) context.Context {
+	return context.WithValue(ctx, costTrackerContextKey{}, tracker)
+}
+
+// CostTrackerFromContext returns the cost tracker attached to ctx, or nil.
+func CostTrackerFromContext(ctx context.Context) *CostTracker {
+	tracker, _ := ctx.Value(costTrackerContextKey{}).(*CostTracker)
+	return tracker
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+var testPrices = PriceTable{
+	"fake-model": {InputPerMillion: 3, OutputPerMillion: 15},
+}
+
+func TestCostTrackerRecord(t *testing.T) {
+	tracker := NewCostTracker(testPrices)
+	tracker.Record("fake-model", Usage{InputTokens: 1000, OutputTokens: 500})
+	tracker.Record("fake-model", Usage{InputTokens: 1000, OutputTokens: 500})
+
+	// 2 * (1000*3 + 500*15) / 1e6 = 0.021
+	if math.Abs(tracker.Total()-0.021) > 1e-12 {
+		t.Errorf("Expected total $0.021, got $%f", tracker.Total())
+	}
+
+	cost := tracker.Breakdown()["fake-model"]
+	if cost.Calls != 2 || cost.InputTokens != 2000 || cost.OutputTokens != 1000 {
+		t.Errorf("Unexpected breakdown: %+v", cost)
+	}
+}
+
+func TestCostTrackerUnpriced(t *testing.T) {
+	tracker := NewCostTracker(testPrices)
+	tracker.Record("mystery-a", Usage{InputTokens: 10, OutputTokens: 5})
+	tracker.Record("mystery-b", Usage{InputTokens: 20, OutputTokens: 5})
+
+	if tracker.Total() != 0 {
+		t.Errorf("Expected unpriced usage to cost nothing, got $%f", tracker.Total())
+	}
+
+	breakdown := tracker.Breakdown()
+	unpriced, ok := breakdown[UnpricedBucket]
+	if !ok {
+		t.Fatal("Expected an unpriced bucket")
+	}
+	if unpriced.Calls != 2 || unpriced.InputTokens != 30 {
+		t.Errorf("Unexpected unpriced bucket: %+v", unpriced)
+	}
+	if _, ok := breakdown["mystery-a"]; ok {
+		t.Error("Expected unpriced models not to get their own bucket")
+	}
+
+	models := tracker.UnpricedModels()
+	if len(models) != 2 || models[0] != "mystery-a" || models[1] != "mystery-b" {
+		t.Errorf("Expected unpriced model names, got %v", models)
+	}
+}
+
+func TestCostTrackerViaContext(t *testing.T) {
+	tracker := NewCostTracker(testPrices)
+	ctx := WithCostTracker(context.Background(), tracker)
+
+	provider := &fakeProvider{usage: Usage{InputTokens: 100, OutputTokens: 100}}
+	agent := NewAgent("assistant", provider, AgentConfig{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := agent.Process(ctx, agenkit.NewMessage("user", "hi")); err != nil {
+				t.Errorf("Process failed: %v", err)
+			}
+			// Reading while other goroutines write must be safe
+			_ = tracker.Total()
+			_ = tracker.Breakdown()
+		}()
+	}
+	wg.Wait()
+
+	cost := tracker.Breakdown()["fake-model"]
+	if cost.Calls != 10 || cost.InputTokens != 1000 || cost.OutputTokens != 1000 {
+		t.Errorf("Expected 10 calls of 100/100 tokens, got %+v", cost)
+	}
+}
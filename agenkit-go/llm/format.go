@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrFormatUnsupported is returned for a request with StrictFormat set
+// when its provider cannot enforce the requested ResponseFormat or
+// ForceTool natively.
+var ErrFormatUnsupported = errors.New("response format not supported by provider")
+
+// FormatKind names a kind of ResponseFormat.
+type FormatKind string
+
+// Response format kinds.
+const (
+	FormatKindText       FormatKind = "text"
+	FormatKindJSON       FormatKind = "json"
+	FormatKindJSONSchema FormatKind = "json_schema"
+)
+
+// ResponseFormat constrains the content of a model's reply. The zero value
+// is FormatText.
+type ResponseFormat struct {
+	// Kind is the kind of content required.
+	Kind FormatKind `json:"kind"`
+
+	// Schema is the JSON Schema replies must match, for FormatKindJSONSchema.
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+// Response formats.
+var (
+	// FormatText places no constraint on the reply.
+	FormatText = ResponseFormat{Kind: FormatKindText}
+
+	// FormatJSON requires the reply to be a JSON value.
+	FormatJSON = ResponseFormat{Kind: FormatKindJSON}
+)
+
+// FormatJSONSchema requires the reply to be JSON matching schema.
+func FormatJSONSchema(schema map[string]any) ResponseFormat {
+	return ResponseFormat{Kind: FormatKindJSONSchema, Schema: schema}
+}
+
+// IsText reports whether the format places no constraint on the reply.
+func (f ResponseFormat) IsText() bool {
+	return f.Kind == "" || f.Kind == FormatKindText
+}
+
+// FormatProvider is implemented by providers that enforce some response
+// formats or tool choices natively. For other providers, Agent emulates
+// them with instructions.
+type FormatProvider interface {
+	Provider
+
+	// SupportsFormat reports whether the provider enforces format natively.
+	SupportsFormat(format ResponseFormat) bool
+
+	// SupportsForceTool reports whether the provider can natively force the
+	// model to call a given tool.
+	SupportsForceTool() bool
+}
+
+// adaptFormat returns request with the ResponseFormat and ForceTool that
+// provider cannot enforce natively replaced by instructions in a trailing
+// system message, or an error wrapping ErrFormatUnsupported if the request
+// is strict. request itself is not modified.
+func adaptFormat(provider Provider, request *Request) (*Request, error) {
+	if request.ForceTool != "" && !offersTool(request, request.ForceTool) {
+		return nil, fmt.Errorf("forced tool %q is not among the request's tools", request.ForceTool)
+	}
+	fp, native := provider.(FormatProvider)
+
+	adapted := *request
+	var instructions []string
+	if !request.ResponseFormat.IsText() && !(native && fp.SupportsFormat(request.ResponseFormat)) {
+		if request.StrictFormat {
+			return nil, fmt.Errorf("%w: %s", ErrFormatUnsupported, request.ResponseFormat.Kind)
+		}
+		instruction, err := formatInstructions(request.ResponseFormat)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, instruction)
+		adapted.ResponseFormat = FormatText
+	}
+	if request.ForceTool != "" && !(native && fp.SupportsForceTool()) {
+		if request.StrictFormat {
+			return nil, fmt.Errorf("%w: forcing tool %q", ErrFormatUnsupported, request.ForceTool)
+		}
+		instructions = append(instructions, fmt.Sprintf("You must respond by calling the tool %q.", request.ForceTool))
+		adapted.ForceTool = ""
+	}
+	if len(instructions) == 0 {
+		return request, nil
+	}
+
+	adapted.Messages = make([]*agenkit.Message, 0, len(request.Messages)+1)
+	adapted.Messages = append(adapted.Messages, request.Messages...)
+	adapted.Messages = append(adapted.Messages, agenkit.NewMessage("system", strings.Join(instructions, "\n\n")))
+	return &adapted, nil
+}
+
+// formatInstructions describes format in words, for models that cannot
+// enforce it.
+func formatInstructions(format ResponseFormat) (string, error) {
+	switch format.Kind {
+	case FormatKindJSON:
+		return "Respond only with a valid JSON value, without any other text.", nil
+	case FormatKindJSONSchema:
+		schema, err := json.MarshalIndent(format.Schema, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode response schema: %w", err)
+		}
+		return "Respond only with a JSON value matching this JSON Schema, without any other text:\n" + string(schema), nil
+	}
+	return "", fmt.Errorf("unknown response format %q", format.Kind)
+}
+
+// offersTool reports whether request offers a tool named name.
+func offersTool(request *Request, name string) bool {
+	for _, tool := range request.Tools {
+		if tool.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+var personSchema = map[string]any{
+	"type":       "object",
+	"properties": map[string]any{"name": map[string]any{"type": "string"}},
+}
+
+func TestOpenAISendsResponseFormatAndToolChoice(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, openAIToolReply, &body)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL})
+
+	_, err := provider.Complete(context.Background(), &Request{
+		Messages:       []*agenkit.Message{agenkit.NewMessage("user", "Who?")},
+		Tools:          []agenkit.Tool{weatherTool{}},
+		ResponseFormat: FormatJSONSchema(personSchema),
+		ForceTool:      "get_weather",
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	format := body["response_format"].(map[string]any)
+	if format["type"] != "json_schema" || format["json_schema"].(map[string]any)["schema"] == nil {
+		t.Errorf("Expected json_schema response_format, got %v", format)
+	}
+	choice := body["tool_choice"].(map[string]any)
+	if choice["type"] != "function" || choice["function"].(map[string]any)["name"] != "get_weather" {
+		t.Errorf("Expected forced function tool_choice, got %v", choice)
+	}
+	if len(body["messages"].([]any)) != 1 {
+		t.Errorf("Expected no instructions added for native formats, got %v", body["messages"])
+	}
+}
+
+func TestOpenAIOmitsTextFormat(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, openAIToolReply, &body)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL})
+
+	if _, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "Hi")}}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if _, ok := body["response_format"]; ok {
+		t.Errorf("Expected no response_format for text, got %v", body["response_format"])
+	}
+	if _, ok := body["tool_choice"]; ok {
+		t.Errorf("Expected no tool_choice, got %v", body["tool_choice"])
+	}
+}
+
+func TestAnthropicEmulatesJSONAndForcesTool(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, anthropicToolReply, &body)
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL})
+
+	_, err := provider.Complete(context.Background(), &Request{
+		Messages:       []*agenkit.Message{agenkit.NewMessage("user", "Weather?")},
+		Tools:          []agenkit.Tool{weatherTool{}},
+		ResponseFormat: FormatJSON,
+		ForceTool:      "get_weather",
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	choice := body["tool_choice"].(map[string]any)
+	if choice["type"] != "tool" || choice["name"] != "get_weather" {
+		t.Errorf("Expected forced tool_choice, got %v", choice)
+	}
+	if system, _ := body["system"].(string); !strings.Contains(system, "valid JSON") {
+		t.Errorf("Expected JSON instructions in the system prompt, got %q", system)
+	}
+}
+
+func TestAnthropicStrictFormatUnsupported(t *testing.T) {
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: "http://unused"})
+	_, err := provider.Complete(context.Background(), &Request{
+		Messages:       []*agenkit.Message{agenkit.NewMessage("user", "Hi")},
+		ResponseFormat: FormatJSON,
+		StrictFormat:   true,
+	})
+	if !errors.Is(err, ErrFormatUnsupported) {
+		t.Errorf("Expected ErrFormatUnsupported, got %v", err)
+	}
+	if agenkit.IsRetryable(err) {
+		t.Errorf("Expected the rejection not to be retryable, got %v", err)
+	}
+}
+
+func TestPoolStrictFormatRejectionLeavesHealth(t *testing.T) {
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: "http://unused"})
+	other := &fakeProvider{}
+	pool, _ := NewProviderPool([]Provider{provider, other}, ProviderPoolConfig{FailureThreshold: 1})
+
+	_, err := pool.Complete(context.Background(), &Request{
+		Messages:       []*agenkit.Message{agenkit.NewMessage("user", "Hi")},
+		ResponseFormat: FormatJSON,
+		StrictFormat:   true,
+	})
+	if !errors.Is(err, ErrFormatUnsupported) {
+		t.Fatalf("Expected ErrFormatUnsupported, got %v", err)
+	}
+	if len(other.requests) != 0 {
+		t.Error("Expected no failover for a rejected request")
+	}
+	if health := pool.Health(); !health[0].Healthy || health[0].Failures != 0 {
+		t.Errorf("Expected the provider's health unchanged, got %+v", health[0])
+	}
+}
+
+func TestAgentEmulatesFormatForPlainProviders(t *testing.T) {
+	provider := &fakeProvider{}
+	agent, err := NewLLMAgent(provider, WithDefaultResponseFormat(FormatJSONSchema(personSchema)))
+	if err != nil {
+		t.Fatalf("NewLLMAgent failed: %v", err)
+	}
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "Who?")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	request := provider.requests[0]
+	if !request.ResponseFormat.IsText() {
+		t.Errorf("Expected the emulated format to be cleared, got %+v", request.ResponseFormat)
+	}
+	last := request.Messages[len(request.Messages)-1]
+	if last.Role != "system" || !strings.Contains(last.Content, `"properties"`) {
+		t.Errorf("Expected schema instructions in a trailing system message, got %+v", last)
+	}
+}
+
+func TestAgentStrictFormat(t *testing.T) {
+	provider := &fakeProvider{}
+	agent, _ := NewLLMAgent(provider, WithStrictFormat())
+
+	ctx := WithResponseFormat(context.Background(), FormatJSON)
+	_, err := agent.Process(ctx, agenkit.NewMessage("user", "Hi"))
+	if !errors.Is(err, ErrFormatUnsupported) {
+		t.Fatalf("Expected ErrFormatUnsupported, got %v", err)
+	}
+	if agenkit.IsRetryable(err) {
+		t.Errorf("Expected the rejection not to be retryable, got %v", err)
+	}
+	if len(provider.requests) != 0 {
+		t.Error("Expected the provider not to be called")
+	}
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "Hi")); err != nil {
+		t.Errorf("Expected text replies to need no support, got %v", err)
+	}
+}
+
+func TestForceToolOptionValidation(t *testing.T) {
+	if _, err := NewLLMAgent(&fakeProvider{}, WithForceTool("get_weather")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a forced tool that was not added, got %v", err)
+	}
+	if _, err := NewLLMAgent(&fakeProvider{}, WithForceTool("get_weather"), WithTools(weatherTool{})); err != nil {
+		t.Errorf("Expected forced tool to be accepted, got %v", err)
+	}
+	if _, err := NewLLMAgent(&fakeProvider{}, WithDefaultResponseFormat(ResponseFormat{Kind: FormatKindJSONSchema})); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a schema format without schema, got %v", err)
+	}
+}
+
+func TestCacheKeyIncludesFormat(t *testing.T) {
+	request := &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "Hi")}}
+	text, _ := CacheKey("m", request)
+	request.ResponseFormat = FormatJSON
+	json, _ := CacheKey("m", request)
+	if text == json {
+		t.Error("Expected the response format to change the cache key")
+	}
+}
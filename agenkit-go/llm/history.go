@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// History supplies the conversation history for LLM agents.
+type History interface {
+	// Messages returns the history to send before the incoming message,
+	// oldest first.
+	Messages(ctx context.Context) ([]*agenkit.Message, error)
+}
+
+type historyContextKey struct{}
+
+// WithHistory attaches a conversation history to ctx. LLM agents called
+// with ctx send the history between the system prompt and the incoming
+// message.
+func WithHistory(ctx context.Context, history History) context.Context {
+	return context.WithValue(ctx, historyContextKey{}, history)
+}
+
+// HistoryFromContext returns the history attached to ctx, or nil.
+func HistoryFromContext(ctx context.Context) History {
+	history, _ := ctx.Value(historyContextKey{}).(History)
+	return history
+}
@@ -0,0 +1,564 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// OpenAIConfig configures an OpenAI chat completions provider.
+type OpenAIConfig struct {
+	// APIKey authenticates requests.
+	// Default: the OPENAI_API_KEY environment variable
+	APIKey string
+
+	// Model is the model to call.
+	// Default: "gpt-4o"
+	Model string
+
+	// BaseURL is the API root, for proxies and compatible servers.
+	// Default: "https://api.openai.com/v1"
+	BaseURL string
+
+	// HTTPClient sends the requests.
+	// Default: http.DefaultClient
+	HTTPClient *http.Client
+
+	// StreamRetries is how many times Stream retries a request whose
+	// connection fails before any output was emitted. Negative values
+	// disable retrying.
+	// Default: 2
+	StreamRetries int
+
+	// StreamBackoff spaces the retries of Stream.
+	// Default: exponential from 200ms up to 5s
+	StreamBackoff *agenkit.Backoff
+}
+
+// OpenAIProvider calls the OpenAI chat completions API.
+//
+// Messages with role "agent" are sent as assistant messages. Tool calls are
+// translated to and from OpenAI's function-calling format; see
+// ToolCallsMetadataKey for how calls and results travel on messages. Failed
+// calls return *agenkit.RateLimitError, *agenkit.ContextLengthError or
+// *agenkit.ProviderError.
+type OpenAIProvider struct {
+	config OpenAIConfig
+}
+
+// Verify that OpenAIProvider implements ToolCaller interface.
+var _ ToolCaller = (*OpenAIProvider)(nil)
+
+// Verify that OpenAIProvider implements StreamingProvider interface.
+var _ StreamingProvider = (*OpenAIProvider)(nil)
+
+// Verify that OpenAIProvider implements FormatProvider interface.
+var _ FormatProvider = (*OpenAIProvider)(nil)
+
+// NewOpenAIProvider creates a new OpenAI provider.
+func NewOpenAIProvider(config OpenAIConfig) *OpenAIProvider {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if config.Model == "" {
+		config.Model = "gpt-4o"
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.openai.com/v1"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	if config.StreamRetries == 0 {
+		config.StreamRetries = 2
+	}
+	if config.StreamBackoff == nil {
+		config.StreamBackoff = agenkit.NewExponentialBackoff(200*time.Millisecond, 5*time.Second, 2)
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &OpenAIProvider{config: config}
+}
+
+// Model returns the configured model.
+func (p *OpenAIProvider) Model() string {
+	return p.config.Model
+}
+
+// SupportsFormat reports true: JSON formats are sent as response_format.
+func (p *OpenAIProvider) SupportsFormat(format ResponseFormat) bool {
+	return true
+}
+
+// SupportsForceTool reports true: forced tools are sent as tool_choice.
+func (p *OpenAIProvider) SupportsForceTool() bool {
+	return true
+}
+
+// CallWithTools completes messages, letting the model call any of tools.
+func (p *OpenAIProvider) CallWithTools(ctx context.Context, messages []*agenkit.Message, tools []agenkit.Tool) (*Response, error) {
+	return p.Complete(ctx, &Request{Messages: messages, Tools: tools})
+}
+
+// openAIMessage is a chat message in OpenAI's wire format. Content is a
+// string, a []openAIPart for messages with attachments, or nil.
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openAIPart is one part of a multimodal message.
+type openAIPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Parameters  map[string]any `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+	Seed        *int64          `json:"seed,omitempty"`
+
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	ToolChoice     *openAIToolChoice     `json:"tool_choice,omitempty"`
+
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+}
+
+type openAIToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIResponse struct {
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openAIStreamChunk is one event of a streamed completion. The last event
+// before [DONE] has no choices and carries the usage.
+type openAIStreamChunk struct {
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// Complete sends the request to the chat completions endpoint.
+func (p *OpenAIProvider) Complete(ctx context.Context, request *Request) (*Response, error) {
+	wire, err := p.wireRequest(request)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: "openai", Err: err}
+	}
+	resp, err := p.post(ctx, wire)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: "failed to read response", Err: err}
+	}
+
+	var decoded openAIResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: "invalid response", Err: err}
+	}
+	if len(decoded.Choices) == 0 {
+		return nil, &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: "response has no choices"}
+	}
+	response, err := decodeOpenAIResponse(&decoded)
+	if err != nil {
+		return nil, err
+	}
+	if request.Seed != nil {
+		// Outputs are only reproducible while the fingerprint is unchanged
+		response.Message.Metadata["seed_honored"] = true
+		response.Message.Metadata["system_fingerprint"] = decoded.SystemFingerprint
+	}
+	return response, nil
+}
+
+// Stream sends the request with streaming enabled, emitting text and tool
+// call fragments as they arrive.
+//
+// If the connection fails before anything was emitted, the request is
+// retried up to StreamRetries times with StreamBackoff; if it fails later,
+// Stream returns a *PartialStreamError. Errors returned by the API are not
+// retried.
+func (p *OpenAIProvider) Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	wire, err := p.wireRequest(request)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: "openai", Err: err}
+	}
+	wire.Stream = true
+	wire.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	return retryStream(ctx, "openai", p.config.StreamRetries, p.config.StreamBackoff, emit, func(emit func(agenkit.StreamChunk)) (*Response, bool, error) {
+		return p.stream(ctx, request, wire, emit)
+	})
+}
+
+// stream makes one streaming request. transport reports whether it failed
+// because the connection did, rather than with an error from the API.
+func (p *OpenAIProvider) stream(ctx context.Context, request *Request, wire *openAIRequest, emit func(agenkit.StreamChunk)) (response *Response, transport bool, err error) {
+	resp, err := p.post(ctx, wire)
+	if err != nil {
+		return nil, isTransportError(err), err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var calls ToolCallAccumulator
+	var model, fingerprint, finishReason string
+	var usage Usage
+	done := false
+	err = readSSE(ctx, resp.Body, func(_, data string) error {
+		if data == "[DONE]" {
+			done = true
+			return nil
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: "invalid stream event", Err: err}
+		}
+		if chunk.Error != nil {
+			return &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: chunk.Error.Message}
+		}
+		if chunk.Model != "" {
+			model, fingerprint = chunk.Model, chunk.SystemFingerprint
+		}
+		if chunk.Usage != nil {
+			usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+		if choice.Delta.Content != "" {
+			content.WriteString(choice.Delta.Content)
+			emit(agenkit.StreamChunk{Delta: choice.Delta.Content})
+		}
+		for _, call := range choice.Delta.ToolCalls {
+			delta := agenkit.ToolCallDelta{
+				Index:          call.Index,
+				ID:             call.ID,
+				Name:           call.Function.Name,
+				ArgumentsDelta: call.Function.Arguments,
+			}
+			calls.Add(delta)
+			emit(agenkit.StreamChunk{ToolCallDelta: &delta})
+		}
+		return nil
+	})
+	if err != nil {
+		var providerErr *agenkit.ProviderError
+		if errors.As(err, &providerErr) {
+			return nil, false, err
+		}
+		// A dropped connection is not the request's fault, so it carries no
+		// status and stays retryable
+		return nil, true, &agenkit.ProviderError{Provider: "openai", Message: "failed to read stream", Err: err}
+	}
+	if !done {
+		return nil, true, &agenkit.ProviderError{Provider: "openai", Message: "stream ended before [DONE]"}
+	}
+
+	toolCalls, err := calls.ToolCalls()
+	if err != nil {
+		return nil, false, &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: "invalid streamed tool call", Err: err}
+	}
+	message := agenkit.NewMessage("agent", content.String())
+	message.Metadata["finish_reason"] = finishReason
+	if len(toolCalls) > 0 {
+		message.Metadata[ToolCallsMetadataKey] = toolCalls
+	}
+	if request.Seed != nil {
+		message.Metadata["seed_honored"] = true
+		message.Metadata["system_fingerprint"] = fingerprint
+	}
+	return &Response{Message: message, Usage: usage, Model: model, ToolCalls: toolCalls}, false, nil
+}
+
+// post sends an encoded request to the chat completions endpoint. Error
+// statuses are returned as typed errors.
+func (p *OpenAIProvider) post(ctx context.Context, wire *openAIRequest) (*http.Response, error) {
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return nil, &agenkit.InvalidRequestError{Provider: "openai", Err: err}
+	}
+	url := p.config.BaseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &agenkit.ProviderError{Provider: "openai", Message: "failed to create request", Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	raw := startRawIO(ctx, "openai", wire.Model, url, body)
+	resp, err := p.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		raw.finish(0, nil, err)
+		return nil, &agenkit.ProviderError{Provider: "openai", Message: "request failed", Err: err}
+	}
+	raw.capture(resp)
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: "failed to read response", Err: err}
+		}
+		return nil, openAIStatusError(resp, data)
+	}
+	return resp, nil
+}
+
+// wireRequest converts a request to OpenAI's format.
+func (p *OpenAIProvider) wireRequest(request *Request) (*openAIRequest, error) {
+	request, err := adaptFormat(p, request)
+	if err != nil {
+		return nil, err
+	}
+	out := &openAIRequest{
+		Model:       p.config.Model,
+		Temperature: request.Temperature,
+		MaxTokens:   request.MaxTokens,
+		Seed:        request.Seed,
+	}
+	if request.Model != "" {
+		out.Model = request.Model
+	}
+	for _, msg := range request.Messages {
+		wire, err := encodeOpenAIMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		out.Messages = append(out.Messages, wire)
+	}
+	for _, tool := range request.Tools {
+		var wire openAITool
+		wire.Type = "function"
+		wire.Function.Name = tool.Name()
+		wire.Function.Description = tool.Description()
+		wire.Function.Parameters = ToolSchema(tool)
+		out.Tools = append(out.Tools, wire)
+	}
+	if request.ForceTool != "" {
+		out.ToolChoice = &openAIToolChoice{Type: "function"}
+		out.ToolChoice.Function.Name = request.ForceTool
+	}
+	switch request.ResponseFormat.Kind {
+	case FormatKindJSON:
+		out.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	case FormatKindJSONSchema:
+		out.ResponseFormat = &openAIResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &openAIJSONSchema{Name: "response", Schema: request.ResponseFormat.Schema},
+		}
+	}
+	return out, nil
+}
+
+// encodeOpenAIMessage converts an agenkit message to OpenAI's format.
+func encodeOpenAIMessage(msg *agenkit.Message) (openAIMessage, error) {
+	content := msg.Content
+	wire := openAIMessage{Role: msg.Role, Content: content}
+	if len(msg.Attachments) > 0 {
+		parts, err := encodeOpenAIParts(msg)
+		if err != nil {
+			return wire, err
+		}
+		wire.Content = parts
+	}
+	switch msg.Role {
+	case "agent", "assistant":
+		wire.Role = "assistant"
+		for _, call := range ToolCallsFromMessage(msg) {
+			args, err := json.Marshal(call.Parameters)
+			if err != nil {
+				return wire, fmt.Errorf("tool call %s: %w", call.ToolName, err)
+			}
+			var wireCall openAIToolCall
+			wireCall.ID = call.ID
+			wireCall.Type = "function"
+			wireCall.Function.Name = call.ToolName
+			wireCall.Function.Arguments = string(args)
+			wire.ToolCalls = append(wire.ToolCalls, wireCall)
+		}
+		if len(wire.ToolCalls) > 0 && content == "" {
+			wire.Content = nil
+		}
+	case "tool":
+		wire.ToolCallID, _ = msg.Metadata[ToolCallIDMetadataKey].(string)
+	}
+	return wire, nil
+}
+
+// encodeOpenAIParts converts a message with attachments to content parts.
+// Only user messages may carry them, and only images are supported.
+func encodeOpenAIParts(msg *agenkit.Message) ([]openAIPart, error) {
+	var parts []openAIPart
+	if msg.Content != "" {
+		parts = append(parts, openAIPart{Type: "text", Text: msg.Content})
+	}
+	for _, attachment := range msg.Attachments {
+		if msg.Role != "user" {
+			return nil, &agenkit.UnsupportedAttachmentError{
+				Provider: "openai",
+				MIMEType: attachment.MIMEType,
+				Reason:   fmt.Sprintf("%s messages cannot carry attachments", msg.Role),
+			}
+		}
+		if !imageMIMETypes[attachment.MIMEType] {
+			return nil, &agenkit.UnsupportedAttachmentError{Provider: "openai", MIMEType: attachment.MIMEType}
+		}
+		if err := checkAttachment(attachment); err != nil {
+			return nil, err
+		}
+		parts = append(parts, openAIPart{Type: "image_url", ImageURL: &openAIImageURL{URL: dataURL(attachment)}})
+	}
+	return parts, nil
+}
+
+// decodeOpenAIResponse converts the first choice to a Response.
+func decodeOpenAIResponse(decoded *openAIResponse) (*Response, error) {
+	choice := decoded.Choices[0]
+	content, _ := choice.Message.Content.(string)
+	message := agenkit.NewMessage("agent", content)
+	message.Metadata["finish_reason"] = choice.FinishReason
+
+	var calls []agenkit.ToolCall
+	for _, wire := range choice.Message.ToolCalls {
+		params, err := decodeArguments([]byte(wire.Function.Arguments))
+		if err != nil {
+			return nil, &agenkit.ProviderError{
+				Provider: "openai",
+				Message:  fmt.Sprintf("invalid arguments for tool call %s", wire.Function.Name),
+				Err:      err,
+			}
+		}
+		calls = append(calls, agenkit.ToolCall{ID: wire.ID, ToolName: wire.Function.Name, Parameters: params})
+	}
+	if len(calls) > 0 {
+		message.Metadata[ToolCallsMetadataKey] = calls
+	}
+
+	return &Response{
+		Message: message,
+		Usage: Usage{
+			InputTokens:  decoded.Usage.PromptTokens,
+			OutputTokens: decoded.Usage.CompletionTokens,
+		},
+		Model:     decoded.Model,
+		ToolCalls: calls,
+	}, nil
+}
+
+// openAIStatusError maps an error response to the typed error set.
+func openAIStatusError(resp *http.Response, data []byte) error {
+	var body openAIError
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		message = body.Error.Message
+	}
+	cause := &agenkit.ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Message: message}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &agenkit.RateLimitError{Provider: "openai", RetryAfter: parseRetryAfter(resp.Header), Err: cause}
+	case body.Error.Code == "context_length_exceeded":
+		return &agenkit.ContextLengthError{Provider: "openai", Err: cause}
+	default:
+		return cause
+	}
+}
@@ -0,0 +1,237 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// weatherTool is a tool with a parameter schema.
+type weatherTool struct{}
+
+func (weatherTool) Name() string        { return "get_weather" }
+func (weatherTool) Description() string { return "Looks up the weather" }
+func (weatherTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	return agenkit.NewToolResult("sunny"), nil
+}
+func (weatherTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		"required":   []any{"city"},
+	}
+}
+
+// captureServer records the decoded request body and replies with status and reply.
+func captureServer(t *testing.T, status int, header http.Header, reply string, captured *map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if captured != nil {
+			json.NewDecoder(r.Body).Decode(captured)
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+const openAIToolReply = `{
+	"model": "gpt-4o-2024",
+	"choices": [{
+		"finish_reason": "tool_calls",
+		"message": {
+			"role": "assistant",
+			"content": null,
+			"tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Rome\"}"}}
+			]
+		}
+	}],
+	"usage": {"prompt_tokens": 12, "completion_tokens": 7}
+}`
+
+func TestOpenAICallWithTools(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, openAIToolReply, &body)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL})
+
+	response, err := provider.CallWithTools(context.Background(),
+		[]*agenkit.Message{agenkit.NewMessage("user", "Weather in Paris and Rome?")},
+		[]agenkit.Tool{weatherTool{}})
+	if err != nil {
+		t.Fatalf("CallWithTools failed: %v", err)
+	}
+
+	tools := body["tools"].([]any)
+	function := tools[0].(map[string]any)["function"].(map[string]any)
+	if function["name"] != "get_weather" || function["parameters"].(map[string]any)["required"] == nil {
+		t.Errorf("Expected tool translated to a function with its schema, got %v", function)
+	}
+
+	if len(response.ToolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls, got %+v", response.ToolCalls)
+	}
+	if response.ToolCalls[0].ID != "call_1" || response.ToolCalls[1].Parameters["city"] != "Rome" {
+		t.Errorf("Expected calls in order, got %+v", response.ToolCalls)
+	}
+	if calls := ToolCallsFromMessage(response.Message); len(calls) != 2 {
+		t.Errorf("Expected tool calls on the message metadata, got %v", response.Message.Metadata)
+	}
+	if response.Usage.InputTokens != 12 || response.Usage.OutputTokens != 7 || response.Model != "gpt-4o-2024" {
+		t.Errorf("Expected usage and model to be decoded, got %+v %s", response.Usage, response.Model)
+	}
+}
+
+func TestOpenAIEncodesToolRoundTrip(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"choices":[{"message":{"role":"assistant","content":"Sunny"}}]}`, &body)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL})
+
+	call := agenkit.ToolCall{ID: "call_1", ToolName: "get_weather", Parameters: map[string]interface{}{"city": "Paris"}}
+	assistant := agenkit.NewMessage("agent", "").WithMetadata(ToolCallsMetadataKey, []agenkit.ToolCall{call})
+	response, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{
+		agenkit.NewMessage("user", "Weather?"),
+		assistant,
+		NewToolResultMessage(call, "sunny"),
+	}})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if response.Message.Content != "Sunny" {
+		t.Errorf("Expected 'Sunny', got '%s'", response.Message.Content)
+	}
+
+	messages := body["messages"].([]any)
+	sent := messages[1].(map[string]any)
+	if sent["role"] != "assistant" || sent["content"] != nil {
+		t.Errorf("Expected assistant tool-call turn with null content, got %v", sent)
+	}
+	wireCall := sent["tool_calls"].([]any)[0].(map[string]any)["function"].(map[string]any)
+	if wireCall["arguments"] != `{"city":"Paris"}` {
+		t.Errorf("Expected arguments as a JSON string, got %v", wireCall["arguments"])
+	}
+	result := messages[2].(map[string]any)
+	if result["role"] != "tool" || result["tool_call_id"] != "call_1" {
+		t.Errorf("Expected tool result message, got %v", result)
+	}
+}
+
+func TestOpenAITypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		reply  string
+		check  func(error) bool
+	}{
+		{"rate limit", http.StatusTooManyRequests, http.Header{"Retry-After": {"3"}}, `{"error":{"message":"slow down"}}`,
+			func(err error) bool {
+				var rl *agenkit.RateLimitError
+				return errors.As(err, &rl) && rl.RetryAfter == 3*time.Second
+			}},
+		{"context length", http.StatusBadRequest, nil, `{"error":{"message":"too long","code":"context_length_exceeded"}}`,
+			func(err error) bool { return errors.As(err, new(*agenkit.ContextLengthError)) }},
+		{"server error", http.StatusInternalServerError, nil, `{"error":{"message":"oops"}}`,
+			func(err error) bool {
+				var pe *agenkit.ProviderError
+				return errors.As(err, &pe) && pe.StatusCode == 500 && pe.Message == "oops" && pe.Retryable()
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := captureServer(t, tt.status, tt.header, tt.reply, nil)
+			_, err := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}).
+				Complete(context.Background(), &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "hi")}})
+			if !tt.check(err) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenAISeed(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"system_fingerprint":"fp_1","choices":[{"message":{"role":"assistant","content":"hi"}}]}`, &body)
+	agent := NewAgent("llm", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	result, err := agent.Process(WithSeed(context.Background(), 7), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if body["seed"] != float64(7) {
+		t.Errorf("Expected seed 7 in the request, got %v", body["seed"])
+	}
+	if result.Metadata["seed_honored"] != true || result.Metadata["system_fingerprint"] != "fp_1" {
+		t.Errorf("Expected seed_honored and fingerprint, got %v", result.Metadata)
+	}
+}
+
+func TestOpenAIEncodesImageAttachments(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"choices":[{"message":{"role":"assistant","content":"A cat"}}]}`, &body)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL})
+
+	question := agenkit.NewMessage("user", "What is this?").
+		WithAttachment(agenkit.Attachment{MIMEType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}}).
+		WithAttachment(agenkit.Attachment{MIMEType: "image/jpeg", URL: "https://example.com/cat.jpg"})
+	response, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{
+		agenkit.NewMessage("system", "Describe images"),
+		question,
+	}})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if response.Message.Content != "A cat" {
+		t.Errorf("Expected 'A cat', got '%s'", response.Message.Content)
+	}
+
+	messages := body["messages"].([]any)
+	if messages[0].(map[string]any)["content"] != "Describe images" {
+		t.Errorf("Expected text-only messages to keep string content, got %v", messages[0])
+	}
+	parts := messages[1].(map[string]any)["content"].([]any)
+	if len(parts) != 3 || parts[0].(map[string]any)["text"] != "What is this?" {
+		t.Fatalf("Expected a text part and two image parts, got %v", parts)
+	}
+	inline := parts[1].(map[string]any)["image_url"].(map[string]any)["url"]
+	if inline != "data:image/png;base64,iVBORw==" {
+		t.Errorf("Expected inline data as a data URL, got %v", inline)
+	}
+	if url := parts[2].(map[string]any)["image_url"].(map[string]any)["url"]; url != "https://example.com/cat.jpg" {
+		t.Errorf("Expected the URL passed through, got %v", url)
+	}
+}
+
+func TestOpenAIRejectsUnsupportedAttachments(t *testing.T) {
+	server := captureServer(t, http.StatusOK, nil, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`, nil)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL})
+
+	tests := []struct {
+		name    string
+		message *agenkit.Message
+	}{
+		{"pdf", agenkit.NewMessage("user", "Summarize").WithAttachment(agenkit.Attachment{MIMEType: "application/pdf", Data: []byte("%PDF")})},
+		{"assistant image", agenkit.NewMessage("agent", "Here").WithAttachment(agenkit.Attachment{MIMEType: "image/png", URL: "https://example.com/a.png"})},
+	}
+	for _, tt := range tests {
+		_, err := provider.Complete(context.Background(), &Request{Messages: []*agenkit.Message{tt.message}})
+		var attachmentErr *agenkit.UnsupportedAttachmentError
+		if !errors.As(err, &attachmentErr) || attachmentErr.Provider != "openai" {
+			t.Errorf("%s: expected UnsupportedAttachmentError, got %v", tt.name, err)
+		}
+		if agenkit.IsRetryable(err) {
+			t.Errorf("%s: expected the error not to be retryable", tt.name)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrInvalidOption is returned by NewLLMAgent when an option is invalid.
+var ErrInvalidOption = errors.New("invalid agent option")
+
+// MaxTemperature is the highest temperature WithTemperature accepts.
+const MaxTemperature = 2.0
+
+// LLMOption configures an agent built by NewLLMAgent.
+type LLMOption func(*llmOptions) error
+
+// llmOptions collects the settings applied by LLMOptions.
+type llmOptions struct {
+	name   string
+	config AgentConfig
+}
+
+// NewLLMAgent creates an LLM-backed agent from options, validating each.
+//
+// Unset options keep these defaults: the provider's model, no system
+// prompt, temperature 0, the provider's max tokens, no tools, text
+// replies, and the model as the agent's name. Invalid options return an
+// error wrapping ErrInvalidOption. The agent's Config reports the result.
+func NewLLMAgent(provider Provider, opts ...LLMOption) (*Agent, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("%w: provider is required", ErrInvalidOption)
+	}
+	var o llmOptions
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	if o.config.ForceTool != "" && !offersTool(&Request{Tools: o.config.Tools}, o.config.ForceTool) {
+		return nil, fmt.Errorf("%w: forced tool %q was not added with WithTools", ErrInvalidOption, o.config.ForceTool)
+	}
+	agent := NewAgent(o.name, provider, o.config)
+	if agent.name == "" {
+		agent.name = agent.Model()
+	}
+	return agent, nil
+}
+
+// WithName sets the agent's name.
+func WithName(name string) LLMOption {
+	return func(o *llmOptions) error {
+		if name == "" {
+			return fmt.Errorf("%w: name must not be empty", ErrInvalidOption)
+		}
+		o.name = name
+		return nil
+	}
+}
+
+// WithModel overrides the provider's model.
+func WithModel(model string) LLMOption {
+	return func(o *llmOptions) error {
+		if model == "" {
+			return fmt.Errorf("%w: model must not be empty", ErrInvalidOption)
+		}
+		o.config.Model = model
+		return nil
+	}
+}
+
+// WithTemperature sets the sampling temperature, between 0 and
+// MaxTemperature. WithTemperatureOverride still overrides it per call
+// through the context.
+func WithTemperature(temperature float64) LLMOption {
+	return func(o *llmOptions) error {
+		if math.IsNaN(temperature) || temperature < 0 || temperature > MaxTemperature {
+			return fmt.Errorf("%w: temperature must be between 0 and %v, got %v", ErrInvalidOption, MaxTemperature, temperature)
+		}
+		o.config.Temperature = temperature
+		return nil
+	}
+}
+
+// WithSystemPrompt sets the system prompt sent with every request.
+func WithSystemPrompt(prompt string) LLMOption {
+	return func(o *llmOptions) error {
+		o.config.SystemPrompt = prompt
+		return nil
+	}
+}
+
+// WithMaxTokens caps the number of generated tokens.
+func WithMaxTokens(maxTokens int) LLMOption {
+	return func(o *llmOptions) error {
+		if maxTokens <= 0 {
+			return fmt.Errorf("%w: max tokens must be positive, got %d", ErrInvalidOption, maxTokens)
+		}
+		o.config.MaxTokens = maxTokens
+		return nil
+	}
+}
+
+// WithDefaultResponseFormat sets the format of every reply.
+// WithResponseFormat still overrides it per call through the context.
+func WithDefaultResponseFormat(format ResponseFormat) LLMOption {
+	return func(o *llmOptions) error {
+		if format.Kind == FormatKindJSONSchema && format.Schema == nil {
+			return fmt.Errorf("%w: JSON schema format requires a schema", ErrInvalidOption)
+		}
+		o.config.ResponseFormat = format
+		return nil
+	}
+}
+
+// WithForceTool makes the model call the named tool, which must be added
+// with WithTools.
+func WithForceTool(name string) LLMOption {
+	return func(o *llmOptions) error {
+		if name == "" {
+			return fmt.Errorf("%w: forced tool name must not be empty", ErrInvalidOption)
+		}
+		o.config.ForceTool = name
+		return nil
+	}
+}
+
+// WithStrictFormat makes calls fail with ErrFormatUnsupported rather than
+// emulate a response format or forced tool the provider cannot enforce.
+func WithStrictFormat() LLMOption {
+	return func(o *llmOptions) error {
+		o.config.StrictFormat = true
+		return nil
+	}
+}
+
+// WithTools adds tools offered to the model. Tool names must be unique.
+func WithTools(tools ...agenkit.Tool) LLMOption {
+	return func(o *llmOptions) error {
+		seen := make(map[string]bool, len(o.config.Tools))
+		for _, tool := range o.config.Tools {
+			seen[tool.Name()] = true
+		}
+		for _, tool := range tools {
+			if tool == nil {
+				return fmt.Errorf("%w: nil tool", ErrInvalidOption)
+			}
+			if seen[tool.Name()] {
+				return fmt.Errorf("%w: duplicate tool %q", ErrInvalidOption, tool.Name())
+			}
+			seen[tool.Name()] = true
+			o.config.Tools = append(o.config.Tools, tool)
+		}
+		return nil
+	}
+}
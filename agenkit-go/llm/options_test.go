@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestNewLLMAgentAppliesOptions(t *testing.T) {
+	provider := &fakeProvider{}
+	agent, err := NewLLMAgent(provider,
+		WithModel("fake-large"),
+		WithTemperature(0.7),
+		WithSystemPrompt("be brief"),
+		WithMaxTokens(100),
+		WithTools(weatherTool{}),
+	)
+	if err != nil {
+		t.Fatalf("NewLLMAgent failed: %v", err)
+	}
+
+	config := agent.Config()
+	if config.Model != "fake-large" || config.Temperature != 0.7 || config.SystemPrompt != "be brief" || config.MaxTokens != 100 || len(config.Tools) != 1 {
+		t.Errorf("Expected the options in the config, got %+v", config)
+	}
+	if agent.Name() != "fake-large" {
+		t.Errorf("Expected the model as default name, got %s", agent.Name())
+	}
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	request := provider.requests[0]
+	if request.Model != "fake-large" || request.Temperature != 0.7 || request.MaxTokens != 100 || request.Messages[0].Content != "be brief" {
+		t.Errorf("Expected the options in the request, got %+v", request)
+	}
+}
+
+func TestNewLLMAgentDefaults(t *testing.T) {
+	agent, err := NewLLMAgent(&fakeProvider{}, WithName("assistant"))
+	if err != nil {
+		t.Fatalf("NewLLMAgent failed: %v", err)
+	}
+	if config := agent.Config(); config.Model != "fake-model" || config.Temperature != 0 || config.MaxTokens != 0 {
+		t.Errorf("Expected the provider's defaults, got %+v", config)
+	}
+	if agent.Name() != "assistant" {
+		t.Errorf("Expected assistant, got %s", agent.Name())
+	}
+}
+
+func TestNewLLMAgentRejectsInvalidOptions(t *testing.T) {
+	cases := map[string][]LLMOption{
+		"temperature": {WithTemperature(2.5)},
+		"negative":    {WithTemperature(-0.1)},
+		"max tokens":  {WithMaxTokens(0)},
+		"model":       {WithModel("")},
+		"duplicate":   {WithTools(weatherTool{}), WithTools(weatherTool{})},
+	}
+	for name, opts := range cases {
+		if _, err := NewLLMAgent(&fakeProvider{}, opts...); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s: Expected ErrInvalidOption, got %v", name, err)
+		}
+	}
+	if _, err := NewLLMAgent(nil); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a nil provider, got %v", err)
+	}
+}
+
+func TestWithModelOverridesProviderModel(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, nil, `{"model":"gpt-4o-mini","choices":[{"message":{"content":"hi"}}]}`, &body)
+	agent, _ := NewLLMAgent(NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}), WithModel("gpt-4o-mini"))
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if body["model"] != "gpt-4o-mini" {
+		t.Errorf("Expected the overridden model sent, got %v", body["model"])
+	}
+}
@@ -0,0 +1,55 @@
+package llm
+
+import "context"
+
+type temperatureContextKey struct{}
+
+// WithTemperatureOverride overrides the sampling temperature for LLM agents
+// called with ctx.
+//
+// Techniques that sample the same agent repeatedly (e.g., self-consistency)
+// use this to raise the temperature of an otherwise deterministic agent
+// without reconfiguring it.
+func WithTemperatureOverride(ctx context.Context, temperature float64) context.Context {
+	return context.WithValue(ctx, temperatureContextKey{}, temperature)
+}
+
+// TemperatureOverrideFromContext returns the temperature override attached
+// to ctx, if any.
+func TemperatureOverrideFromContext(ctx context.Context) (float64, bool) {
+	temperature, ok := ctx.Value(temperatureContextKey{}).(float64)
+	return temperature, ok
+}
+
+type responseFormatContextKey struct{}
+
+// WithResponseFormat overrides the response format for LLM agents called
+// with ctx, for wrappers such as structured.StructuredAgent that need a
+// particular format from whatever agent they wrap.
+func WithResponseFormat(ctx context.Context, format ResponseFormat) context.Context {
+	return context.WithValue(ctx, responseFormatContextKey{}, format)
+}
+
+// ResponseFormatFromContext returns the response format override attached
+// to ctx, if any.
+func ResponseFormatFromContext(ctx context.Context) (ResponseFormat, bool) {
+	format, ok := ctx.Value(responseFormatContextKey{}).(ResponseFormat)
+	return format, ok
+}
+
+type seedContextKey struct{}
+
+// WithSeed requests seeded sampling from LLM agents called with ctx, so
+// repeated runs can reproduce the same outputs.
+//
+// Seeding is best effort: replies carry "seed_honored" metadata, true only
+// when the provider confirms it passed the seed along.
+func WithSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, seedContextKey{}, seed)
+}
+
+// SeedFromContext returns the sampling seed attached to ctx, if any.
+func SeedFromContext(ctx context.Context) (int64, bool) {
+	seed, ok := ctx.Value(seedContextKey{}).(int64)
+	return seed, ok
+}
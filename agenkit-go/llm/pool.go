@@ -0,0 +1,315 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrNoHealthyProvider is returned by ProviderPool when no provider is
+// available for a call.
+var ErrNoHealthyProvider = errors.New("no healthy provider")
+
+// PoolStrategy selects how ProviderPool picks among available providers.
+type PoolStrategy int
+
+const (
+	// PoolRoundRobin rotates through the providers (the default).
+	PoolRoundRobin PoolStrategy = iota
+
+	// PoolLeastLoaded picks the provider with the fewest calls in flight.
+	PoolLeastLoaded
+)
+
+// ProviderPoolConfig configures a ProviderPool.
+type ProviderPoolConfig struct {
+	// Strategy selects among the available providers.
+	// Default: PoolRoundRobin
+	Strategy PoolStrategy
+
+	// FailureThreshold is the number of consecutive failures after which a
+	// provider is marked unhealthy.
+	// Default: 3
+	FailureThreshold int
+
+	// ProbeInterval is how long an unhealthy provider is left out before a
+	// call is sent to it again as a probe.
+	// Default: 30s
+	ProbeInterval time.Duration
+
+	// ProbeBackoff, if set, replaces ProbeInterval with a delay that grows
+	// with each failed probe.
+	ProbeBackoff *agenkit.Backoff
+}
+
+// ProviderHealth is a snapshot of one pooled provider's state.
+type ProviderHealth struct {
+	// Index is the provider's position in the pool.
+	Index int
+
+	// Healthy is false once the provider has failed FailureThreshold times
+	// in a row, until a call to it succeeds.
+	Healthy bool
+
+	// Failures is the number of consecutive failures.
+	Failures int
+
+	// InFlight is the number of calls currently running.
+	InFlight int
+
+	// PausedUntil is when a rate limit on the provider ends, if it has one.
+	PausedUntil time.Time
+}
+
+// ProviderPool spreads calls over several providers for the same model,
+// such as endpoints or API keys, and fails over between them.
+//
+// Each call goes to an available provider chosen by the strategy. If the
+// call fails with a retryable error, the next available provider is tried,
+// until one succeeds or every provider has been tried; the error then
+// wraps ErrNoHealthyProvider and joins every provider's error. Errors that
+// another provider would repeat, such as a context length error or a bad
+// request, are returned immediately.
+//
+// A provider that fails FailureThreshold times in a row is unhealthy and
+// left out of rotation. After ProbeInterval (or ProbeBackoff), one call is
+// sent to it as a probe: success makes it healthy again, failure leaves it
+// out for another interval. A RateLimitError with a RetryAfter takes the provider out of
+// rotation for that long without counting as a failure.
+type ProviderPool struct {
+	config ProviderPoolConfig
+
+	mu      sync.Mutex
+	members []*poolMember
+	next    int
+}
+
+// poolMember is the state of one pooled provider.
+type poolMember struct {
+	provider    Provider
+	failures    int
+	unhealthy   bool
+	probeAt     time.Time
+	probing     bool
+	pausedUntil time.Time
+	inFlight    int
+}
+
+// Verify that ProviderPool implements Provider, StreamingProvider and
+// FormatProvider interfaces.
+var (
+	_ Provider          = (*ProviderPool)(nil)
+	_ StreamingProvider = (*ProviderPool)(nil)
+	_ FormatProvider    = (*ProviderPool)(nil)
+)
+
+// NewProviderPool creates a pool over providers.
+func NewProviderPool(providers []Provider, config ProviderPoolConfig) (*ProviderPool, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("provider pool requires at least one provider")
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 3
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = 30 * time.Second
+	}
+
+	members := make([]*poolMember, len(providers))
+	for i, provider := range providers {
+		if provider == nil {
+			return nil, fmt.Errorf("provider %d is nil", i)
+		}
+		members[i] = &poolMember{provider: provider}
+	}
+	return &ProviderPool{config: config, members: members}, nil
+}
+
+// Model returns the model of the first provider.
+func (p *ProviderPool) Model() string {
+	return p.members[0].provider.Model()
+}
+
+// SupportsFormat reports whether every pooled provider supports format,
+// since any of them may serve a call.
+func (p *ProviderPool) SupportsFormat(format ResponseFormat) bool {
+	for _, m := range p.members {
+		if fp, ok := m.provider.(FormatProvider); !ok || !fp.SupportsFormat(format) {
+			return false
+		}
+	}
+	return true
+}
+
+// SupportsForceTool reports whether every pooled provider can force tools.
+func (p *ProviderPool) SupportsForceTool() bool {
+	for _, m := range p.members {
+		if fp, ok := m.provider.(FormatProvider); !ok || !fp.SupportsForceTool() {
+			return false
+		}
+	}
+	return true
+}
+
+// Complete sends the request to an available provider, failing over to
+// the others on retryable errors.
+func (p *ProviderPool) Complete(ctx context.Context, request *Request) (*Response, error) {
+	return p.call(ctx, func(provider Provider) (*Response, bool, error) {
+		response, err := provider.Complete(ctx, request)
+		return response, false, err
+	})
+}
+
+// Stream streams the request from an available provider, failing over to
+// the others on retryable errors as Complete does until a provider has
+// emitted a chunk; a stream failing after that is not repeated elsewhere.
+// A provider that cannot stream completes the request without emitting.
+func (p *ProviderPool) Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	return p.call(ctx, func(provider Provider) (*Response, bool, error) {
+		started := false
+		response, err := streamFrom(ctx, provider, request, func(chunk agenkit.StreamChunk) {
+			started = true
+			emit(chunk)
+		})
+		return response, started, err
+	})
+}
+
+// call runs attempt against available providers until one succeeds, the
+// error is not worth failing over, or attempt reports that it started
+// delivering output.
+func (p *ProviderPool) call(ctx context.Context, attempt func(provider Provider) (*Response, bool, error)) (*Response, error) {
+	tried := make(map[*poolMember]bool, len(p.members))
+	var errs []error
+	for {
+		member := p.acquire(tried)
+		if member == nil {
+			if len(errs) == 0 {
+				return nil, fmt.Errorf("provider pool: %w", ErrNoHealthyProvider)
+			}
+			return nil, fmt.Errorf("provider pool: %w: %w", ErrNoHealthyProvider, errors.Join(errs...))
+		}
+		tried[member] = true
+
+		response, started, err := attempt(member.provider)
+		p.release(member, err, ctx.Err() != nil)
+		if err == nil {
+			return response, nil
+		}
+		if started || ctx.Err() != nil || !agenkit.IsRetryable(err) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+}
+
+// Health returns a snapshot of every provider's state, in pool order.
+func (p *ProviderPool) Health() []ProviderHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	health := make([]ProviderHealth, len(p.members))
+	for i, member := range p.members {
+		health[i] = ProviderHealth{
+			Index:    i,
+			Healthy:  !member.unhealthy,
+			Failures: member.failures,
+			InFlight: member.inFlight,
+		}
+		if member.pausedUntil.After(now) {
+			health[i].PausedUntil = member.pausedUntil
+		}
+	}
+	return health
+}
+
+// acquire picks an available provider not in tried and marks the call as
+// started, or returns nil if there is none.
+func (p *ProviderPool) acquire(tried map[*poolMember]bool) *poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var chosen *poolMember
+	chosenIndex := -1
+	for offset := range p.members {
+		i := (p.next + offset) % len(p.members)
+		member := p.members[i]
+		if tried[member] || !member.available(now) {
+			continue
+		}
+		if chosen == nil || (p.config.Strategy == PoolLeastLoaded && member.inFlight < chosen.inFlight) {
+			chosen, chosenIndex = member, i
+		}
+		if p.config.Strategy == PoolRoundRobin {
+			break
+		}
+	}
+	if chosen == nil {
+		return nil
+	}
+
+	p.next = (chosenIndex + 1) % len(p.members)
+	chosen.inFlight++
+	if chosen.unhealthy {
+		chosen.probing = true
+	}
+	return chosen
+}
+
+// release records the outcome of a call to member. A call that failed
+// after the caller's context was done says nothing about the provider, so
+// it leaves member's health alone.
+func (p *ProviderPool) release(member *poolMember, err error, abandoned bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	member.inFlight--
+	member.probing = false
+	if err != nil && abandoned {
+		return
+	}
+	if err == nil {
+		member.failures = 0
+		member.unhealthy = false
+		return
+	}
+
+	if wait, ok := agenkit.RetryAfter(err); ok {
+		member.pausedUntil = time.Now().Add(wait)
+		return
+	}
+	if !agenkit.IsRetryable(err) {
+		// The request was at fault, not the provider
+		return
+	}
+	member.failures++
+	if member.failures >= p.config.FailureThreshold {
+		member.unhealthy = true
+		member.probeAt = time.Now().Add(p.probeDelay(member.failures - p.config.FailureThreshold + 1))
+	}
+}
+
+// probeDelay returns how long to wait before the given probe (1-based).
+func (p *ProviderPool) probeDelay(probe int) time.Duration {
+	if p.config.ProbeBackoff != nil {
+		return p.config.ProbeBackoff.Next(probe)
+	}
+	return p.config.ProbeInterval
+}
+
+// available reports whether member can take a call at now.
+func (m *poolMember) available(now time.Time) bool {
+	if now.Before(m.pausedUntil) {
+		return false
+	}
+	if m.unhealthy {
+		return !m.probing && !now.Before(m.probeAt)
+	}
+	return true
+}
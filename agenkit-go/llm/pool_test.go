@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// blockingProvider is a fakeProvider whose calls wait for release.
+type blockingProvider struct {
+	fakeProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingProvider) Complete(ctx context.Context, request *Request) (*Response, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.fakeProvider.Complete(ctx, request)
+}
+
+func poolRequest() *Request {
+	return &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "hi")}}
+}
+
+func TestProviderPoolRoundRobin(t *testing.T) {
+	a, b := &fakeProvider{}, &fakeProvider{}
+	pool, err := NewProviderPool([]Provider{a, b}, ProviderPoolConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.Complete(context.Background(), poolRequest()); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	if len(a.requests) != 2 || len(b.requests) != 2 {
+		t.Errorf("Expected calls split evenly, got %d and %d", len(a.requests), len(b.requests))
+	}
+}
+
+func TestProviderPoolFailover(t *testing.T) {
+	down := &fakeProvider{err: &agenkit.ProviderError{Provider: "a", StatusCode: http.StatusBadGateway}}
+	up := &fakeProvider{}
+	pool, _ := NewProviderPool([]Provider{down, up}, ProviderPoolConfig{FailureThreshold: 2, ProbeInterval: 20 * time.Millisecond})
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.Complete(context.Background(), poolRequest()); err != nil {
+			t.Fatalf("Expected failover to the healthy provider, got %v", err)
+		}
+	}
+	if len(down.requests) != 2 {
+		t.Errorf("Expected the failing provider to be dropped after 2 failures, got %d calls", len(down.requests))
+	}
+	if health := pool.Health(); health[0].Healthy || !health[1].Healthy {
+		t.Errorf("Expected only the first provider to be unhealthy, got %+v", health)
+	}
+
+	// After the probe interval a successful call restores the provider
+	time.Sleep(30 * time.Millisecond)
+	down.err = nil
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Complete(context.Background(), poolRequest()); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	if len(down.requests) != 3 {
+		t.Errorf("Expected one probe of the recovered provider, got %d calls", len(down.requests)-2)
+	}
+	if health := pool.Health(); !health[0].Healthy || health[0].Failures != 0 {
+		t.Errorf("Expected the probed provider to be healthy again, got %+v", health[0])
+	}
+}
+
+func TestProviderPoolStreamFailover(t *testing.T) {
+	down := &streamingFake{fakeProvider{err: &agenkit.ProviderError{Provider: "a", StatusCode: http.StatusBadGateway}}}
+	up := &streamingFake{}
+	pool, _ := NewProviderPool([]Provider{down, up}, ProviderPoolConfig{})
+
+	deltas := streamDeltas(t, pool, poolRequest())
+	if len(deltas) != 2 || deltas[0] != "echo: " {
+		t.Errorf("Expected the healthy provider's stream, got %q", deltas)
+	}
+	if health := pool.Health(); health[0].Failures != 1 {
+		t.Errorf("Expected the failed stream to count against its provider, got %+v", health[0])
+	}
+}
+
+func TestProviderPoolStreamFailsOverDroppedConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Drop the connection before any event
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	defer server.Close()
+	broken := NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL, StreamRetries: -1})
+	up := &streamingFake{}
+	pool, _ := NewProviderPool([]Provider{broken, up}, ProviderPoolConfig{})
+
+	if deltas := streamDeltas(t, pool, poolRequest()); len(deltas) != 2 {
+		t.Errorf("Expected the stream to fail over to the next provider, got %q", deltas)
+	}
+	if health := pool.Health(); health[0].Failures != 1 {
+		t.Errorf("Expected the dropped stream to count as a failure, got %+v", health[0])
+	}
+}
+
+func TestProviderPoolAllUnhealthy(t *testing.T) {
+	errA := &agenkit.ProviderError{Provider: "a", StatusCode: http.StatusServiceUnavailable}
+	errB := &agenkit.ProviderError{Provider: "b", Err: errors.New("connection reset")}
+	pool, _ := NewProviderPool([]Provider{&fakeProvider{err: errA}, &fakeProvider{err: errB}}, ProviderPoolConfig{FailureThreshold: 1})
+
+	_, err := pool.Complete(context.Background(), poolRequest())
+	if !errors.Is(err, ErrNoHealthyProvider) || !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Expected an aggregated error of both providers, got %v", err)
+	}
+
+	// Both are now out of rotation until their probe is due
+	_, err = pool.Complete(context.Background(), poolRequest())
+	if !errors.Is(err, ErrNoHealthyProvider) {
+		t.Errorf("Expected ErrNoHealthyProvider, got %v", err)
+	}
+}
+
+func TestProviderPoolNonRetryableError(t *testing.T) {
+	badRequest := &agenkit.ProviderError{Provider: "a", StatusCode: http.StatusBadRequest}
+	a := &fakeProvider{err: badRequest}
+	b := &fakeProvider{}
+	pool, _ := NewProviderPool([]Provider{a, b}, ProviderPoolConfig{FailureThreshold: 1})
+
+	if _, err := pool.Complete(context.Background(), poolRequest()); !errors.Is(err, badRequest) {
+		t.Errorf("Expected the bad request error, got %v", err)
+	}
+	if len(b.requests) != 0 {
+		t.Error("Expected a bad request not to be retried on another provider")
+	}
+	if health := pool.Health(); !health[0].Healthy {
+		t.Error("Expected a bad request not to count against the provider")
+	}
+}
+
+func TestProviderPoolIgnoresAbandonedCalls(t *testing.T) {
+	timedOut := &fakeProvider{err: &agenkit.ProviderError{Provider: "a", Message: "request failed", Err: context.DeadlineExceeded}}
+	pool, _ := NewProviderPool([]Provider{timedOut}, ProviderPoolConfig{FailureThreshold: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, err := pool.Complete(ctx, poolRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+	if health := pool.Health(); !health[0].Healthy || health[0].Failures != 0 {
+		t.Errorf("Expected the caller's deadline not to count against the provider, got %+v", health[0])
+	}
+}
+
+func TestProviderPoolRateLimitPause(t *testing.T) {
+	limited := &fakeProvider{err: &agenkit.RateLimitError{Provider: "a", RetryAfter: 30 * time.Millisecond}}
+	other := &fakeProvider{}
+	pool, _ := NewProviderPool([]Provider{limited, other}, ProviderPoolConfig{FailureThreshold: 1})
+
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Complete(context.Background(), poolRequest()); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	if len(limited.requests) != 1 {
+		t.Errorf("Expected the rate-limited provider to be paused, got %d calls", len(limited.requests))
+	}
+	if health := pool.Health(); !health[0].Healthy || health[0].PausedUntil.IsZero() {
+		t.Errorf("Expected a pause without marking the provider unhealthy, got %+v", health[0])
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	limited.err = nil
+	for i := 0; i < 2; i++ {
+		pool.Complete(context.Background(), poolRequest())
+	}
+	if len(limited.requests) != 2 {
+		t.Errorf("Expected the provider back in rotation after RetryAfter, got %d calls", len(limited.requests))
+	}
+}
+
+func TestProviderPoolLeastLoaded(t *testing.T) {
+	busy := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	idle := &fakeProvider{}
+	pool, _ := NewProviderPool([]Provider{busy, idle}, ProviderPoolConfig{Strategy: PoolLeastLoaded})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := pool.Complete(context.Background(), poolRequest())
+		done <- err
+	}()
+	<-busy.started
+
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Complete(context.Background(), poolRequest()); err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+	}
+	if len(idle.requests) != 3 {
+		t.Errorf("Expected calls to avoid the busy provider, got %d on the idle one", len(idle.requests))
+	}
+	close(busy.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+}
+
+func TestProviderPoolValidation(t *testing.T) {
+	if _, err := NewProviderPool(nil, ProviderPoolConfig{}); err == nil {
+		t.Error("Expected error for an empty pool")
+	}
+	if _, err := NewProviderPool([]Provider{nil}, ProviderPoolConfig{}); err == nil {
+		t.Error("Expected error for a nil provider")
+	}
+}
@@ -0,0 +1,102 @@
+// Package llm provides a provider-agnostic interface to large language models
+// and an agent that turns any provider into an agenkit.Agent.
+//
+// The interface is intentionally minimal: a provider only has to report its
+// model and produce a completion for a conversation. Everything else (budgets,
+// cost tracking, caching) is layered on top through context and wrappers.
+// OpenAIProvider and AnthropicProvider are included; both implement
+// ToolCaller and StreamingProvider and normalize tool calls to the same
+// []agenkit.ToolCall.
+package llm
+
+import (
+	"context"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Provider is the minimal contract that all LLM adapters must implement.
+type Provider interface {
+	// Model returns the model identifier (e.g., "gpt-4o", "claude-sonnet-4").
+	Model() string
+
+	// Complete generates a single completion for the request.
+	Complete(ctx context.Context, request *Request) (*Response, error)
+}
+
+// Request describes a single completion request.
+type Request struct {
+	// Messages is the conversation history, oldest first.
+	Messages []*agenkit.Message
+
+	// Model overrides the provider's configured model (empty = provider
+	// default).
+	Model string
+
+	// Temperature controls sampling randomness (0 = deterministic).
+	Temperature float64
+
+	// MaxTokens caps the number of generated tokens (0 = provider default).
+	MaxTokens int
+
+	// Tools are the tools the model may call. Providers that do not support
+	// tool calling ignore them.
+	Tools []agenkit.Tool
+
+	// Seed, if set, requests deterministic sampling. Providers that pass it
+	// along set "seed_honored" to true in the reply's metadata.
+	Seed *int64
+
+	// ResponseFormat constrains the reply's content.
+	// Default: FormatText
+	ResponseFormat ResponseFormat
+
+	// ForceTool, if set, names one of Tools that the model must call.
+	ForceTool string
+
+	// StrictFormat makes providers that cannot enforce ResponseFormat or
+	// ForceTool natively fail with ErrFormatUnsupported, instead of asking
+	// for them in the prompt.
+	StrictFormat bool
+}
+
+// Usage reports token consumption for a completion.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// TotalTokens returns input plus output tokens.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Response is the result of a completion.
+type Response struct {
+	// Message is the generated reply.
+	Message *agenkit.Message
+
+	// Usage reports the tokens consumed by the call.
+	Usage Usage
+
+	// Model is the model that actually served the request.
+	Model string
+
+	// ToolCalls are the tool calls requested by the model, in order. They
+	// are also attached to Message under ToolCallsMetadataKey.
+	ToolCalls []agenkit.ToolCall
+}
+
+// EstimateTokens returns a rough token count for text (about 4 characters per token).
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
+
+// EstimateRequestTokens returns a rough upper bound on the tokens a request
+// will consume, using HeuristicTokenizer. See CountRequestTokens.
+func EstimateRequestTokens(request *Request) int {
+	return CountRequestTokens(request, HeuristicTokenizer{})
+}
@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RawRequest is the exact payload a provider sends.
+type RawRequest struct {
+	// ID correlates the request with its RawResponse.
+	ID       string
+	Provider string
+	Model    string
+	URL      string
+	Body     []byte
+	Time     time.Time
+}
+
+// RawResponse is the exact payload a provider received, or the error that
+// prevented it. Streamed responses hold the whole event stream.
+type RawResponse struct {
+	ID         string
+	Provider   string
+	Model      string
+	StatusCode int
+	Body       []byte
+	Duration   time.Duration
+	Err        error
+}
+
+// RawIOHook observes the raw payloads of provider calls, for debugging.
+//
+// Hooks are called from a goroutine of their own, never from the provider
+// call, so a slow hook delays only its own later calls: for each provider
+// call OnRequest runs before OnResponse, but calls may interleave. Request
+// headers, which carry API keys, are not included.
+type RawIOHook interface {
+	OnRequest(ctx context.Context, request RawRequest)
+	OnResponse(ctx context.Context, response RawResponse)
+}
+
+type rawIOHookContextKey struct{}
+
+// WithRawIOHook attaches a hook that the providers in this package call
+// with the raw payloads of every call made with ctx.
+func WithRawIOHook(ctx context.Context, hook RawIOHook) context.Context {
+	return context.WithValue(ctx, rawIOHookContextKey{}, hook)
+}
+
+// RawIOHookFromContext returns the hook attached to ctx, if any.
+func RawIOHookFromContext(ctx context.Context) RawIOHook {
+	hook, _ := ctx.Value(rawIOHookContextKey{}).(RawIOHook)
+	return hook
+}
+
+// Redaction replaces the matches of Pattern with Replacement, which may
+// refer to submatches as in regexp.Regexp.ReplaceAll.
+type Redaction struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// RedactingHook applies redactions to payloads before passing them to
+// another hook, so personal data never reaches its logs.
+type RedactingHook struct {
+	next       RawIOHook
+	redactions []Redaction
+}
+
+// Verify that RedactingHook implements RawIOHook interface.
+var _ RawIOHook = (*RedactingHook)(nil)
+
+// NewRedactingHook wraps next with redactions, applied in order.
+func NewRedactingHook(next RawIOHook, redactions ...Redaction) *RedactingHook {
+	return &RedactingHook{next: next, redactions: redactions}
+}
+
+// OnRequest redacts the request body and passes the request on.
+func (h *RedactingHook) OnRequest(ctx context.Context, request RawRequest) {
+	request.Body = h.redact(request.Body)
+	h.next.OnRequest(ctx, request)
+}
+
+// OnResponse redacts the response body and passes the response on.
+func (h *RedactingHook) OnResponse(ctx context.Context, response RawResponse) {
+	response.Body = h.redact(response.Body)
+	h.next.OnResponse(ctx, response)
+}
+
+// redact returns body with every redaction applied.
+func (h *RedactingHook) redact(body []byte) []byte {
+	for _, r := range h.redactions {
+		body = r.Pattern.ReplaceAll(body, []byte(r.Replacement))
+	}
+	return body
+}
+
+// LogRawIO returns a hook logging payloads to logger at debug level.
+func LogRawIO(logger *slog.Logger) RawIOHook {
+	return slogRawIOHook{logger: logger}
+}
+
+// slogRawIOHook is the RawIOHook returned by LogRawIO.
+type slogRawIOHook struct {
+	logger *slog.Logger
+}
+
+func (h slogRawIOHook) OnRequest(ctx context.Context, request RawRequest) {
+	h.logger.DebugContext(ctx, "llm raw request",
+		"id", request.ID,
+		"provider", request.Provider,
+		"model", request.Model,
+		"url", request.URL,
+		"body", string(request.Body),
+	)
+}
+
+func (h slogRawIOHook) OnResponse(ctx context.Context, response RawResponse) {
+	attrs := []any{
+		"id", response.ID,
+		"provider", response.Provider,
+		"model", response.Model,
+		"status", response.StatusCode,
+		"duration", response.Duration,
+		"body", string(response.Body),
+	}
+	if response.Err != nil {
+		attrs = append(attrs, "error", response.Err)
+	}
+	h.logger.DebugContext(ctx, "llm raw response", attrs...)
+}
+
+// rawIOCall reports one provider call to a hook. Its methods do nothing
+// on a nil call, so providers need not check for a hook.
+type rawIOCall struct {
+	request   RawRequest
+	responses chan RawResponse
+	once      sync.Once
+}
+
+// startRawIO reports the request to the hook attached to ctx, returning
+// nil if there is none.
+func startRawIO(ctx context.Context, provider, model, url string, body []byte) *rawIOCall {
+	hook := RawIOHookFromContext(ctx)
+	if hook == nil {
+		return nil
+	}
+	call := &rawIOCall{
+		request: RawRequest{
+			ID:       uuid.NewString(),
+			Provider: provider,
+			Model:    model,
+			URL:      url,
+			Body:     bytes.Clone(body),
+			Time:     time.Now(),
+		},
+		responses: make(chan RawResponse, 1),
+	}
+	// The provider call may be cancelled long before the hook runs
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		hook.OnRequest(ctx, call.request)
+		hook.OnResponse(ctx, <-call.responses)
+	}()
+	return call
+}
+
+// finish reports the response. Only the first call has an effect.
+func (c *rawIOCall) finish(statusCode int, body []byte, err error) {
+	if c == nil {
+		return
+	}
+	c.once.Do(func() {
+		c.responses <- RawResponse{
+			ID:         c.request.ID,
+			Provider:   c.request.Provider,
+			Model:      c.request.Model,
+			StatusCode: statusCode,
+			Body:       bytes.Clone(body),
+			Duration:   time.Since(c.request.Time),
+			Err:        err,
+		}
+	})
+}
+
+// capture arranges for resp's body to be reported once it is closed.
+func (c *rawIOCall) capture(resp *http.Response) {
+	if c == nil {
+		return
+	}
+	resp.Body = &capturedBody{ReadCloser: resp.Body, call: c, statusCode: resp.StatusCode}
+}
+
+// capturedBody records the bytes read from a response body.
+type capturedBody struct {
+	io.ReadCloser
+	call       *rawIOCall
+	statusCode int
+	data       bytes.Buffer
+	err        error
+}
+
+func (b *capturedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.data.Write(p[:n])
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *capturedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.call.finish(b.statusCode, b.data.Bytes(), b.err)
+	return err
+}
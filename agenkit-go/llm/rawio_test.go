@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// recordingHook delivers what it observes on channels.
+type recordingHook struct {
+	requests  chan RawRequest
+	responses chan RawResponse
+	block     chan struct{}
+}
+
+func newRecordingHook() *recordingHook {
+	return &recordingHook{requests: make(chan RawRequest, 10), responses: make(chan RawResponse, 10)}
+}
+
+func (h *recordingHook) OnRequest(ctx context.Context, request RawRequest) {
+	if h.block != nil {
+		<-h.block
+	}
+	h.requests <- request
+}
+
+func (h *recordingHook) OnResponse(ctx context.Context, response RawResponse) {
+	h.responses <- response
+}
+
+func receive[T any](t *testing.T, ch chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("Expected the hook to be called")
+		var zero T
+		return zero
+	}
+}
+
+func TestRawIOHookSeesPayloads(t *testing.T) {
+	server := captureServer(t, http.StatusOK, nil, `{"model":"gpt-4o","choices":[{"message":{"content":"hello"}}]}`, nil)
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "secret-key", BaseURL: server.URL})
+	hook := newRecordingHook()
+	ctx := WithRawIOHook(context.Background(), hook)
+
+	if _, err := provider.Complete(ctx, &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "hi there")}}); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	request := receive(t, hook.requests)
+	if request.Provider != "openai" || !strings.Contains(string(request.Body), "hi there") || strings.Contains(string(request.Body), "secret-key") {
+		t.Errorf("Expected the request body without credentials, got %+v", request)
+	}
+	response := receive(t, hook.responses)
+	if response.ID != request.ID || response.StatusCode != http.StatusOK || !strings.Contains(string(response.Body), "hello") {
+		t.Errorf("Expected the matching raw response, got %+v", response)
+	}
+}
+
+func TestRawIOHookDoesNotBlockProvider(t *testing.T) {
+	server := captureServer(t, http.StatusTooManyRequests, nil, `{"error":{"message":"slow down"}}`, nil)
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL})
+	hook := newRecordingHook()
+	hook.block = make(chan struct{})
+	ctx := WithRawIOHook(context.Background(), hook)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := provider.Complete(ctx, &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "hi")}})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the rate limit error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the call to finish while the hook is blocked")
+	}
+
+	close(hook.block)
+	receive(t, hook.requests)
+	if response := receive(t, hook.responses); response.StatusCode != http.StatusTooManyRequests || !strings.Contains(string(response.Body), "slow down") {
+		t.Errorf("Expected the error response, got %+v", response)
+	}
+}
+
+func TestRedactingHook(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	hook := NewRedactingHook(LogRawIO(logger),
+		Redaction{Pattern: regexp.MustCompile(`[\w.]+@[\w.]+`), Replacement: "[email]"},
+		Redaction{Pattern: regexp.MustCompile(`(card )\d+`), Replacement: "${1}[number]"},
+	)
+
+	hook.OnRequest(context.Background(), RawRequest{ID: "1", Body: []byte(`mail jo@example.com about card 4111111111111111`)})
+	hook.OnResponse(context.Background(), RawResponse{ID: "1", Body: []byte(`sent to jo@example.com`)})
+
+	out := logs.String()
+	if strings.Contains(out, "jo@example.com") || strings.Contains(out, "4111") {
+		t.Errorf("Expected personal data redacted, got %s", out)
+	}
+	if !strings.Contains(out, "mail [email] about card [number]") || !strings.Contains(out, "sent to [email]") {
+		t.Errorf("Expected redacted payloads logged, got %s", out)
+	}
+}
@@ -0,0 +1,315 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Transcript is a recorded sequence of provider interactions, written by a
+// Recorder and served by a ReplayProvider.
+type Transcript struct {
+	// Model is the recorded provider's model.
+	Model string `json:"model"`
+
+	// Interactions are the recorded calls, in the order they were made.
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded provider call.
+type Interaction struct {
+	// Key identifies the request; see ReplayKey.
+	Key string `json:"key"`
+
+	// Messages are the request's messages, after redaction.
+	Messages []*agenkit.Message `json:"messages"`
+
+	// Temperature is the request's temperature.
+	Temperature float64 `json:"temperature"`
+
+	// Response is the reply, after redaction.
+	Response *agenkit.Message `json:"response"`
+
+	// ToolCalls are the tool calls the model requested.
+	ToolCalls []agenkit.ToolCall `json:"tool_calls,omitempty"`
+
+	// Usage is the usage the provider reported.
+	Usage Usage `json:"usage"`
+
+	// Model is the model that served the request.
+	Model string `json:"model"`
+}
+
+// ReplayOptions control how requests are redacted and matched. A Recorder
+// and the ReplayProvider serving its transcript must use the same options.
+type ReplayOptions struct {
+	// Redactions are applied to the content of recorded requests and
+	// responses, so secrets and personal data never reach the transcript.
+	// Requests are redacted before they are matched.
+	Redactions []Redaction
+
+	// RedactMetadata lists metadata keys whose values are replaced with
+	// "[redacted]" in recorded messages.
+	RedactMetadata []string
+
+	// Normalize rewrites the content of requests with Temperature 0 before
+	// they are matched, so prompts differing only in ways that cannot
+	// change a deterministic answer still match. Requests with a higher
+	// temperature match only exactly.
+	// Default: NormalizeWhitespace
+	Normalize func(content string) string
+}
+
+// NormalizeWhitespace trims content and collapses runs of white space into
+// single spaces.
+func NormalizeWhitespace(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// ReplayKey returns the key under which request is recorded and matched:
+// a hash of the redacted, and for Temperature 0 normalized, request as in
+// CacheKey.
+func ReplayKey(request *Request, options ReplayOptions) (string, error) {
+	normalize := options.Normalize
+	if normalize == nil {
+		normalize = NormalizeWhitespace
+	}
+	keyed := *request
+	keyed.Messages = make([]*agenkit.Message, len(request.Messages))
+	for i, msg := range request.Messages {
+		copied := *msg
+		copied.Content = options.redact(msg.Content)
+		if request.Temperature == 0 {
+			copied.Content = normalize(copied.Content)
+		}
+		keyed.Messages[i] = &copied
+	}
+	return CacheKey(request.Model, &keyed)
+}
+
+// redact applies the redactions to content.
+func (o ReplayOptions) redact(content string) string {
+	for _, r := range o.Redactions {
+		content = r.Pattern.ReplaceAllString(content, r.Replacement)
+	}
+	return content
+}
+
+// redactMessage returns a redacted copy of message.
+func (o ReplayOptions) redactMessage(message *agenkit.Message) *agenkit.Message {
+	copied := copyMessage(message)
+	copied.Content = o.redact(message.Content)
+	for _, key := range o.RedactMetadata {
+		if _, ok := copied.Metadata[key]; ok {
+			copied.Metadata[key] = "[redacted]"
+		}
+	}
+	return copied
+}
+
+// Recorder is a Provider that records every successful call to the
+// provider it wraps, for later replay with a ReplayProvider. Failed calls
+// are not recorded. Streams are passed through to the wrapped provider and
+// recorded once complete. Recorder is not a FormatProvider, so response
+// formats are recorded as the instructions Agent adds in their place, just
+// as they are replayed.
+type Recorder struct {
+	provider Provider
+	options  ReplayOptions
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Verify that Recorder implements Provider and StreamingProvider interfaces.
+var (
+	_ Provider          = (*Recorder)(nil)
+	_ StreamingProvider = (*Recorder)(nil)
+)
+
+// NewRecorder wraps provider, recording its calls.
+func NewRecorder(provider Provider, options ReplayOptions) *Recorder {
+	return &Recorder{provider: provider, options: options}
+}
+
+// Model returns the wrapped provider's model.
+func (r *Recorder) Model() string {
+	return r.provider.Model()
+}
+
+// Complete calls the wrapped provider and records the interaction.
+func (r *Recorder) Complete(ctx context.Context, request *Request) (*Response, error) {
+	return r.record(request, func() (*Response, error) {
+		return r.provider.Complete(ctx, request)
+	})
+}
+
+// Stream streams the reply from the wrapped provider and records the
+// interaction once the stream completes.
+func (r *Recorder) Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	return r.record(request, func() (*Response, error) {
+		return streamFrom(ctx, r.provider, request, emit)
+	})
+}
+
+// record implements Complete and Stream, recording the reply of call.
+func (r *Recorder) record(request *Request, call func() (*Response, error)) (*Response, error) {
+	key, err := ReplayKey(request, r.options)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+	response, err := call()
+	if err != nil || response.Message == nil {
+		return response, err
+	}
+
+	interaction := Interaction{
+		Key:         key,
+		Messages:    make([]*agenkit.Message, len(request.Messages)),
+		Temperature: request.Temperature,
+		Response:    r.options.redactMessage(response.Message),
+		ToolCalls:   response.ToolCalls,
+		Usage:       response.Usage,
+		Model:       response.Model,
+	}
+	for i, msg := range request.Messages {
+		interaction.Messages[i] = r.options.redactMessage(msg)
+	}
+	delete(interaction.Response.Metadata, ToolCallsMetadataKey)
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return response, nil
+}
+
+// Transcript returns the interactions recorded so far.
+func (r *Recorder) Transcript() *Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Transcript{
+		Model:        r.provider.Model(),
+		Interactions: append([]Interaction(nil), r.interactions...),
+	}
+}
+
+// Save writes the transcript to path as JSON.
+func (r *Recorder) Save(path string) error {
+	data, err := json.MarshalIndent(r.Transcript(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// LoadTranscript reads a transcript written by Recorder.Save.
+func LoadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript %s: %w", path, err)
+	}
+	return &transcript, nil
+}
+
+// UnrecordedRequestError reports a request a ReplayProvider has no
+// recording for.
+type UnrecordedRequestError struct {
+	Key string
+
+	// Prompt is the content of the request's last message.
+	Prompt string
+}
+
+// Error implements the error interface.
+func (e *UnrecordedRequestError) Error() string {
+	return fmt.Sprintf("replay: no recorded response for request %s (last message %q)", e.Key, e.Prompt)
+}
+
+// ReplayProvider is a Provider that serves the responses of a transcript
+// instead of calling a model, for deterministic offline tests.
+//
+// Requests are matched by ReplayKey. When a request was recorded several
+// times, its responses are served in recorded order, and the last one is
+// repeated once they run out. A request that was never recorded fails with
+// an *UnrecordedRequestError.
+type ReplayProvider struct {
+	transcript *Transcript
+	options    ReplayOptions
+
+	mu     sync.Mutex
+	byKey  map[string][]int
+	served map[string]int
+}
+
+// Verify that ReplayProvider implements Provider interface.
+var _ Provider = (*ReplayProvider)(nil)
+
+// NewReplayProvider creates a provider serving transcript's responses.
+func NewReplayProvider(transcript *Transcript, options ReplayOptions) *ReplayProvider {
+	p := &ReplayProvider{
+		transcript: transcript,
+		options:    options,
+		byKey:      make(map[string][]int),
+		served:     make(map[string]int),
+	}
+	for i, interaction := range transcript.Interactions {
+		p.byKey[interaction.Key] = append(p.byKey[interaction.Key], i)
+	}
+	return p
+}
+
+// Model returns the recorded provider's model.
+func (p *ReplayProvider) Model() string {
+	return p.transcript.Model
+}
+
+// Complete returns the recorded response for request.
+func (p *ReplayProvider) Complete(ctx context.Context, request *Request) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key, err := ReplayKey(request, p.options)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+
+	p.mu.Lock()
+	indexes := p.byKey[key]
+	if len(indexes) == 0 {
+		p.mu.Unlock()
+		var prompt string
+		if n := len(request.Messages); n > 0 {
+			prompt = request.Messages[n-1].Content
+		}
+		return nil, &UnrecordedRequestError{Key: key, Prompt: prompt}
+	}
+	next := p.served[key]
+	if next < len(indexes)-1 {
+		p.served[key] = next + 1
+	}
+	interaction := p.transcript.Interactions[indexes[next]]
+	p.mu.Unlock()
+
+	message := copyMessage(interaction.Response)
+	if len(interaction.ToolCalls) > 0 {
+		message.Metadata[ToolCallsMetadataKey] = interaction.ToolCalls
+	}
+	return &Response{
+		Message:   message,
+		Usage:     interaction.Usage,
+		Model:     interaction.Model,
+		ToolCalls: interaction.ToolCalls,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestRecorderReplaysTranscript(t *testing.T) {
+	options := ReplayOptions{
+		Redactions: []Redaction{{Pattern: regexp.MustCompile(`sk-\w+`), Replacement: "[key]"}},
+	}
+	recorder := NewRecorder(&fakeProvider{usage: Usage{InputTokens: 4, OutputTokens: 2}}, options)
+	agent := NewAgent("assistant", recorder, AgentConfig{})
+
+	ctx := context.Background()
+	if _, err := agent.Process(ctx, agenkit.NewMessage("user", "my key is sk-abc123")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	transcript, err := LoadTranscript(path)
+	if err != nil {
+		t.Fatalf("LoadTranscript failed: %v", err)
+	}
+	recorded := transcript.Interactions[0]
+	if strings.Contains(recorded.Response.Content, "sk-abc123") || strings.Contains(recorded.Messages[0].Content, "sk-abc123") {
+		t.Errorf("Expected the key to be redacted, got %+v", recorded)
+	}
+
+	replay := NewAgent("assistant", NewReplayProvider(transcript, options), AgentConfig{})
+	// Extra white space is normalized away at temperature 0
+	response, err := replay.Process(ctx, agenkit.NewMessage("user", "  my key is   sk-other"))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if response.Content != "echo: my key is [key]" {
+		t.Errorf("Expected the recorded response, got '%s'", response.Content)
+	}
+	if transcript.Model != "fake-model" || recorded.Usage.InputTokens != 4 {
+		t.Errorf("Expected the model and usage to be recorded, got %+v", transcript)
+	}
+
+	_, err = replay.Process(ctx, agenkit.NewMessage("user", "something new"))
+	var unrecorded *UnrecordedRequestError
+	if !errors.As(err, &unrecorded) || unrecorded.Prompt != "something new" {
+		t.Errorf("Expected an UnrecordedRequestError, got %v", err)
+	}
+}
+
+func TestRecorderStream(t *testing.T) {
+	recorder := NewRecorder(&streamingFake{}, ReplayOptions{})
+
+	if deltas := streamDeltas(t, recorder, cacheRequest("hi", 0)); len(deltas) != 2 {
+		t.Errorf("Expected the stream to pass through, got %q", deltas)
+	}
+	interactions := recorder.Transcript().Interactions
+	if len(interactions) != 1 || interactions[0].Response.Content != "echo: hi" {
+		t.Errorf("Expected the streamed reply to be recorded, got %+v", interactions)
+	}
+}
+
+func TestReplayServesRepeatsInOrder(t *testing.T) {
+	transcript := &Transcript{Model: "m"}
+	request := &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "roll a die")}, Temperature: 1}
+	key, _ := ReplayKey(request, ReplayOptions{})
+	for _, answer := range []string{"3", "5"} {
+		transcript.Interactions = append(transcript.Interactions, Interaction{Key: key, Response: agenkit.NewMessage("agent", answer)})
+	}
+
+	replay := NewReplayProvider(transcript, ReplayOptions{})
+	var got []string
+	for i := 0; i < 3; i++ {
+		response, err := replay.Complete(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Complete failed: %v", err)
+		}
+		got = append(got, response.Message.Content)
+	}
+	if strings.Join(got, ",") != "3,5,5" {
+		t.Errorf("Expected 3,5,5, got %s", strings.Join(got, ","))
+	}
+
+	// At a nonzero temperature prompts must match exactly
+	loose := &Request{Messages: []*agenkit.Message{agenkit.NewMessage("user", "roll  a die")}, Temperature: 1}
+	if _, err := replay.Complete(context.Background(), loose); err == nil {
+		t.Error("Expected an unnormalized prompt not to match")
+	}
+}
@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// StreamingProvider is implemented by providers that can stream a
+// completion as it is generated.
+type StreamingProvider interface {
+	Provider
+
+	// Stream sends request and calls emit, synchronously and in order,
+	// with a chunk for each text delta (Delta) and each tool call fragment
+	// (ToolCallDelta) the model produces. It returns the same Response
+	// Complete would, with the tool calls fully assembled. When ctx is
+	// cancelled, Stream stops calling emit and returns promptly, aborting
+	// the request upstream.
+	Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error)
+}
+
+// streamFrom streams request from provider if it is a StreamingProvider,
+// and otherwise completes it without calling emit. Wrapping providers use
+// it to stream whatever they wrap; Agent delivers a reply that was not
+// streamed as a single chunk.
+func streamFrom(ctx context.Context, provider Provider, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	if streamer, ok := provider.(StreamingProvider); ok {
+		return streamer.Stream(ctx, request, emit)
+	}
+	return provider.Complete(ctx, request)
+}
+
+// PartialStreamError reports a stream whose connection failed after part of
+// the reply had been emitted. Such a stream cannot be retried without
+// repeating that output, and neither provider API can resume a completion,
+// so the failure is returned instead, and ends up on the terminal chunk of
+// agents streaming the reply.
+type PartialStreamError struct {
+	Provider string
+
+	// Content is the text emitted before the failure.
+	Content string
+
+	// Err is the transport failure.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PartialStreamError) Error() string {
+	return fmt.Sprintf("%s stream interrupted after partial output (%d characters): %v", e.Provider, len(e.Content), e.Err)
+}
+
+// Unwrap returns the transport failure.
+func (e *PartialStreamError) Unwrap() error {
+	return e.Err
+}
+
+// retryStream runs attempt, which makes one streaming request and reports
+// whether it failed because the connection did. Such failures are retried
+// up to retries times, waiting for backoff in between, as long as nothing
+// was emitted yet; after output was emitted they are returned as a
+// *PartialStreamError. Errors from the API, and any failure once ctx is
+// done, are returned as they are.
+func retryStream(ctx context.Context, provider string, retries int, backoff *agenkit.Backoff, emit func(agenkit.StreamChunk),
+	attempt func(emit func(agenkit.StreamChunk)) (*Response, bool, error)) (*Response, error) {
+	for n := 1; ; n++ {
+		var emitted strings.Builder
+		started := false
+		response, transport, err := attempt(func(chunk agenkit.StreamChunk) {
+			started = true
+			emitted.WriteString(chunk.Delta)
+			emit(chunk)
+		})
+		if err == nil || !transport || ctx.Err() != nil {
+			return response, err
+		}
+		if started {
+			return nil, &PartialStreamError{Provider: provider, Content: emitted.String(), Err: err}
+		}
+		if n > retries {
+			return nil, err
+		}
+		if err := backoff.Sleep(ctx, n); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isTransportError reports whether err comes from failing to reach the API
+// at all, as opposed to an error status it returned.
+func isTransportError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// ToolCallAccumulator assembles streamed tool call fragments into complete
+// calls. Providers use it to implement Stream; fragments of different calls
+// may arrive interleaved. It is not safe for concurrent use.
+type ToolCallAccumulator struct {
+	calls map[int]*pendingToolCall
+}
+
+// pendingToolCall is a tool call whose fragments are still arriving.
+type pendingToolCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// Add records a fragment.
+func (a *ToolCallAccumulator) Add(delta agenkit.ToolCallDelta) {
+	if a.calls == nil {
+		a.calls = make(map[int]*pendingToolCall)
+	}
+	call, ok := a.calls[delta.Index]
+	if !ok {
+		call = &pendingToolCall{}
+		a.calls[delta.Index] = call
+	}
+	if delta.ID != "" {
+		call.id = delta.ID
+	}
+	if delta.Name != "" {
+		call.name = delta.Name
+	}
+	call.arguments.WriteString(delta.ArgumentsDelta)
+}
+
+// ToolCalls returns the assembled calls in index order. It fails if a
+// call has no name or its arguments are not a JSON object, as happens when
+// a stream is cut short.
+func (a *ToolCallAccumulator) ToolCalls() ([]agenkit.ToolCall, error) {
+	indexes := make([]int, 0, len(a.calls))
+	for index := range a.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	calls := make([]agenkit.ToolCall, 0, len(indexes))
+	for _, index := range indexes {
+		pending := a.calls[index]
+		if pending.name == "" {
+			return nil, fmt.Errorf("tool call %d has no name", index)
+		}
+		params, err := decodeArguments([]byte(pending.arguments.String()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments for tool call %s: %w", pending.name, err)
+		}
+		calls = append(calls, agenkit.ToolCall{ID: pending.id, ToolName: pending.name, Parameters: params})
+	}
+	return calls, nil
+}
+
+// readSSE reads a server-sent event stream, calling fn with each event's
+// name and data until the stream ends or fn returns an error.
+//
+// Cancelling ctx closes body, so a read blocked on a slow model returns at
+// once and the connection is torn down, and no further events are passed
+// to fn; readSSE then returns ctx's error.
+func readSSE(ctx context.Context, body io.ReadCloser, fn func(event, data string) error) error {
+	stop := context.AfterFunc(ctx, func() { body.Close() })
+	defer stop()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var event string
+	var data []string
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = ""
+			return nil
+		}
+		// Events already buffered must not outrun a cancellation
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := fn(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment, used as a keep-alive
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
@@ -0,0 +1,301 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Two tool calls whose argument fragments arrive interleaved.
+const openAIToolStream = `data: {"model":"gpt-4o-2024","choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"model":"gpt-4o-2024","choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","function":{"name":"get_time","arguments":"{\"zone\":"}}]}}]}
+
+data: {"model":"gpt-4o-2024","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}
+
+data: {"model":"gpt-4o-2024","choices":[{"delta":{"tool_calls":[{"index":1,"function":{"arguments":"\"CET\"}"}}]}}]}
+
+data: {"model":"gpt-4o-2024","choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}
+
+data: {"model":"gpt-4o-2024","choices":[{"delta":{},"finish_reason":"tool_calls"}]}
+
+data: {"model":"gpt-4o-2024","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":9}}
+
+data: [DONE]
+
+`
+
+const anthropicToolStream = `event: message_start
+data: {"type":"message_start","message":{"model":"claude-sonnet-4","usage":{"input_tokens":20}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+// streamingFake streams fakeProvider's reply one word at a time.
+type streamingFake struct {
+	fakeProvider
+}
+
+func (s *streamingFake) Stream(ctx context.Context, request *Request, emit func(agenkit.StreamChunk)) (*Response, error) {
+	response, err := s.Complete(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.SplitAfter(response.Message.Content, " ") {
+		emit(agenkit.StreamChunk{Delta: word})
+	}
+	return response, nil
+}
+
+// streamDeltas streams request from provider and returns the deltas.
+func streamDeltas(t *testing.T, provider StreamingProvider, request *Request) []string {
+	t.Helper()
+	var deltas []string
+	if _, err := provider.Stream(context.Background(), request, func(chunk agenkit.StreamChunk) {
+		deltas = append(deltas, chunk.Delta)
+	}); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	return deltas
+}
+
+func collectChunks(t *testing.T, agent agenkit.ChunkStreamingAgent) []agenkit.StreamChunk {
+	t.Helper()
+	stream, err := agent.ProcessStream(context.Background(), agenkit.NewMessage("user", "weather in Paris?"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+	var chunks []agenkit.StreamChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 || !chunks[len(chunks)-1].Done {
+		t.Fatalf("Expected a terminal chunk, got %+v", chunks)
+	}
+	return chunks
+}
+
+func TestOpenAIStreamAssemblesInterleavedToolCalls(t *testing.T) {
+	var body map[string]any
+	server := captureServer(t, http.StatusOK, http.Header{"Content-Type": {"text/event-stream"}}, openAIToolStream, &body)
+	agent := NewAgent("assistant", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	chunks := collectChunks(t, agent)
+	if body["stream"] != true {
+		t.Errorf("Expected a streaming request, got %v", body)
+	}
+
+	deltas := 0
+	var completed []string
+	for _, chunk := range chunks {
+		if chunk.ToolCallDelta != nil {
+			deltas++
+		}
+		if chunk.ToolCall != nil {
+			completed = append(completed, chunk.ToolCall.ID)
+		}
+	}
+	if deltas != 5 {
+		t.Errorf("Expected 5 tool call deltas, got %d", deltas)
+	}
+	if strings.Join(completed, ",") != "call_1,call_2" {
+		t.Errorf("Expected completed calls in index order, got %v", completed)
+	}
+
+	final := chunks[len(chunks)-1]
+	if final.Err != nil {
+		t.Fatalf("Expected success, got %v", final.Err)
+	}
+	calls := ToolCallsFromMessage(final.Message)
+	if len(calls) != 2 || calls[0].Parameters["city"] != "Paris" || calls[1].Parameters["zone"] != "CET" {
+		t.Errorf("Expected both calls assembled, got %+v", calls)
+	}
+	if usage, _ := final.Message.Metadata["usage"].(Usage); usage.InputTokens != 12 || usage.OutputTokens != 9 {
+		t.Errorf("Expected streamed usage, got %+v", usage)
+	}
+}
+
+func TestAnthropicStreamAssemblesToolCalls(t *testing.T) {
+	server := captureServer(t, http.StatusOK, http.Header{"Content-Type": {"text/event-stream"}}, anthropicToolStream, nil)
+	agent := NewAgent("assistant", NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	chunks := collectChunks(t, agent)
+	if chunks[0].Delta != "Checking" {
+		t.Errorf("Expected the text delta first, got %+v", chunks[0])
+	}
+	first := chunks[1].ToolCallDelta
+	if first == nil || first.Index != 0 || first.Name != "get_weather" || first.ID != "toolu_1" {
+		t.Errorf("Expected the tool call announced with index 0, got %+v", chunks[1])
+	}
+
+	final := chunks[len(chunks)-1]
+	if final.Err != nil {
+		t.Fatalf("Expected success, got %v", final.Err)
+	}
+	if final.Message.Content != "Checking" || final.Message.Metadata["finish_reason"] != "tool_use" {
+		t.Errorf("Expected the assembled reply, got %+v", final.Message)
+	}
+	calls := ToolCallsFromMessage(final.Message)
+	if len(calls) != 1 || calls[0].Parameters["city"] != "Paris" {
+		t.Errorf("Expected the call assembled, got %+v", calls)
+	}
+}
+
+func TestStreamRejectsIncompleteToolArguments(t *testing.T) {
+	truncated := strings.Replace(openAIToolStream, `"\"Paris\"}"`, `"\"Par"`, 1)
+	server := captureServer(t, http.StatusOK, nil, truncated, nil)
+	agent := NewAgent("assistant", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	chunks := collectChunks(t, agent)
+	for _, chunk := range chunks {
+		if chunk.ToolCall != nil {
+			t.Errorf("Expected no completed call, got %+v", chunk.ToolCall)
+		}
+	}
+	if err := chunks[len(chunks)-1].Err; err == nil || !strings.Contains(err.Error(), "get_weather") {
+		t.Errorf("Expected an invalid arguments error, got %v", err)
+	}
+
+	// A stream cut off before [DONE] fails too
+	cut := strings.Split(openAIToolStream, "data: [DONE]")[0]
+	server = captureServer(t, http.StatusOK, nil, cut, nil)
+	agent = NewAgent("assistant", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+	chunks = collectChunks(t, agent)
+	if err := chunks[len(chunks)-1].Err; err == nil {
+		t.Error("Expected an error for a truncated stream")
+	}
+}
+
+func TestProcessStreamWithoutStreamingProvider(t *testing.T) {
+	agent := NewAgent("assistant", &fakeProvider{}, AgentConfig{})
+
+	chunks := collectChunks(t, agent)
+	if len(chunks) != 2 || chunks[0].Delta != "echo: weather in Paris?" || chunks[1].Message.Content != "echo: weather in Paris?" {
+		t.Errorf("Expected one delta then the reply, got %+v", chunks)
+	}
+}
+
+func TestProcessStreamCancelAbortsProviderRequest(t *testing.T) {
+	// The model keeps generating until the request is torn down
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; ; i++ {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"token%d \"}}]}\n\n", i)
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				close(aborted)
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+	agent := NewAgent("assistant", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := agent.ProcessStream(ctx, agenkit.NewMessage("user", "write forever"))
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+	first := <-stream
+	if first.Delta != "token0 " {
+		t.Fatalf("Expected the first token, got %+v", first)
+	}
+	cancel()
+
+	for chunk := range stream {
+		t.Errorf("Expected no chunks after cancelling, got %+v", chunk)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the provider request to be cancelled")
+	}
+}
+
+func TestStreamRetriesDroppedConnections(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if attempts.Add(1) < 3 {
+			// Drop the connection before any event
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hello\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+	fast := agenkit.NewExponentialBackoff(time.Millisecond, time.Millisecond, 2)
+	agent := NewAgent("assistant", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL, StreamBackoff: fast}), AgentConfig{})
+
+	chunks := collectChunks(t, agent)
+	if final := chunks[len(chunks)-1]; final.Err != nil || final.Message.Content != "hello" {
+		t.Errorf("Expected the third attempt to succeed, got %+v", final)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// Without retries the drop is reported
+	attempts.Store(0)
+	agent = NewAgent("assistant", NewOpenAIProvider(OpenAIConfig{APIKey: "k", BaseURL: server.URL, StreamRetries: -1}), AgentConfig{})
+	if chunks := collectChunks(t, agent); chunks[len(chunks)-1].Err == nil || attempts.Load() != 1 {
+		t.Errorf("Expected one failed attempt, got %d", attempts.Load())
+	}
+}
+
+func TestStreamReportsPartialOutput(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Once upon\"}}\n\n")
+	}))
+	defer server.Close()
+	agent := NewAgent("assistant", NewAnthropicProvider(AnthropicConfig{APIKey: "k", BaseURL: server.URL}), AgentConfig{})
+
+	chunks := collectChunks(t, agent)
+	var partial *PartialStreamError
+	if !errors.As(chunks[len(chunks)-1].Err, &partial) || partial.Content != "Once upon" {
+		t.Fatalf("Expected a PartialStreamError, got %v", chunks[len(chunks)-1].Err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("Expected no retry after output was emitted, got %d attempts", n)
+	}
+}
@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// TokenizerFunc adapts a counting function to the Tokenizer interface, for
+// example to plug in a tiktoken encoding:
+//
+//	enc, _ := tiktoken.EncodingForModel("gpt-4o")
+//	llm.RegisterTokenizer("gpt-4o*", llm.TokenizerFunc(func(text string) int {
+//		return len(enc.Encode(text, nil, nil))
+//	}))
+type TokenizerFunc func(text string) int
+
+// Count calls f.
+func (f TokenizerFunc) Count(text string) int {
+	return f(text)
+}
+
+// TokenizerRegistry maps model names to the tokenizers that count tokens
+// for them.
+//
+// Tokenizers are registered by model name prefix; the longest registered
+// prefix of a model wins, so "gpt-4o" can override "gpt-4". Models matching
+// no prefix fall back to HeuristicTokenizer, with a warning logged once per
+// model. A registry is safe for concurrent use.
+type TokenizerRegistry struct {
+	logger *slog.Logger
+
+	mu         sync.RWMutex
+	tokenizers map[string]Tokenizer
+	warned     map[string]bool
+}
+
+// NewTokenizerRegistry creates an empty registry that logs fallback
+// warnings to logger (slog.Default if nil).
+func NewTokenizerRegistry(logger *slog.Logger) *TokenizerRegistry {
+	return &TokenizerRegistry{
+		logger:     logger,
+		tokenizers: make(map[string]Tokenizer),
+		warned:     make(map[string]bool),
+	}
+}
+
+// Register sets the tokenizer for models starting with modelPrefix. A
+// trailing "*" is accepted and ignored, so "gpt-4*" and "gpt-4" are the
+// same prefix.
+func (r *TokenizerRegistry) Register(modelPrefix string, tokenizer Tokenizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokenizers[strings.TrimSuffix(modelPrefix, "*")] = tokenizer
+}
+
+// Lookup returns the tokenizer registered for model, or HeuristicTokenizer.
+func (r *TokenizerRegistry) Lookup(model string) Tokenizer {
+	r.mu.RLock()
+	best, found := "", false
+	for prefix := range r.tokenizers {
+		if strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best)) {
+			best, found = prefix, true
+		}
+	}
+	tokenizer := r.tokenizers[best]
+	warned := r.warned[model]
+	r.mu.RUnlock()
+	if found {
+		return tokenizer
+	}
+
+	if !warned {
+		r.mu.Lock()
+		warned = r.warned[model]
+		r.warned[model] = true
+		r.mu.Unlock()
+		if !warned {
+			logger := r.logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.Warn("no tokenizer registered for model, using heuristic token counts", "model", model)
+		}
+	}
+	return HeuristicTokenizer{}
+}
+
+// defaultTokenizers is the registry used by LLM agents unless configured
+// otherwise.
+var defaultTokenizers = NewTokenizerRegistry(nil)
+
+// DefaultTokenizers returns the package-wide registry, which LLM agents
+// consult when AgentConfig.Tokenizer is unset.
+func DefaultTokenizers() *TokenizerRegistry {
+	return defaultTokenizers
+}
+
+// RegisterTokenizer sets the tokenizer for models starting with
+// modelPrefix in the package-wide registry.
+func RegisterTokenizer(modelPrefix string, tokenizer Tokenizer) {
+	defaultTokenizers.Register(modelPrefix, tokenizer)
+}
+
+// CountRequestTokens returns an upper bound on the tokens a request will
+// consume, counting its messages with tokenizer (HeuristicTokenizer if nil)
+// and adding the MaxTokens reserved for the reply.
+func CountRequestTokens(request *Request, tokenizer Tokenizer) int {
+	if tokenizer == nil {
+		tokenizer = HeuristicTokenizer{}
+	}
+	total := request.MaxTokens
+	for _, msg := range request.Messages {
+		total += tokenizer.Count(msg.Content)
+	}
+	return total
+}
+
+// estimateUsage counts a call's usage with tokenizer, for providers that do
+// not report it.
+func estimateUsage(request *Request, reply *agenkit.Message, tokenizer Tokenizer) Usage {
+	return Usage{
+		InputTokens:  CountRequestTokens(request, tokenizer) - request.MaxTokens,
+		OutputTokens: tokenizer.Count(reply.Content),
+	}
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// constTokenizer counts every text as n tokens.
+type constTokenizer int
+
+func (c constTokenizer) Count(text string) int { return int(c) }
+
+func TestTokenizerRegistryLongestPrefix(t *testing.T) {
+	registry := NewTokenizerRegistry(nil)
+	registry.Register("gpt-4*", constTokenizer(1))
+	registry.Register("gpt-4o", constTokenizer(2))
+
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4-turbo", 1},
+		{"gpt-4o-mini", 2},
+		{"gpt-4", 1},
+	}
+	for _, tt := range tests {
+		if got := registry.Lookup(tt.model).Count("x"); got != tt.want {
+			t.Errorf("%s: expected tokenizer %d, got %d", tt.model, tt.want, got)
+		}
+	}
+}
+
+func TestTokenizerRegistryFallbackWarnsOnce(t *testing.T) {
+	var logs bytes.Buffer
+	registry := NewTokenizerRegistry(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	for i := 0; i < 3; i++ {
+		if _, ok := registry.Lookup("mystery-model").(HeuristicTokenizer); !ok {
+			t.Fatal("Expected the heuristic fallback for an unknown model")
+		}
+	}
+	registry.Lookup("other-model")
+
+	if n := strings.Count(logs.String(), "mystery-model"); n != 1 {
+		t.Errorf("Expected one warning for the unknown model, got %d:\n%s", n, logs.String())
+	}
+	if !strings.Contains(logs.String(), "other-model") {
+		t.Error("Expected a separate warning for each unknown model")
+	}
+}
+
+func TestAgentResolvesTokenizerByModel(t *testing.T) {
+	registry := NewTokenizerRegistry(nil)
+	registry.Register("fake-", constTokenizer(100))
+
+	// The provider reports no usage, so the registered tokenizer estimates it
+	provider := &fakeProvider{}
+	agent := NewAgent("assistant", provider, AgentConfig{Tokenizers: registry})
+	budget := NewTokenBudget(TokenBudgetConfig{Limit: 1000})
+	ctx := WithTokenBudget(context.Background(), budget)
+
+	reply, err := agent.Process(ctx, agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	usage := reply.Metadata["usage"].(Usage)
+	if usage.InputTokens != 100 || usage.OutputTokens != 100 || reply.Metadata["usage_estimated"] != true {
+		t.Errorf("Expected usage estimated with the model's tokenizer, got %+v", usage)
+	}
+	if budget.Used() != 200 {
+		t.Errorf("Expected the estimate charged to the budget, got %d", budget.Used())
+	}
+
+	// Budget checks count with the same tokenizer
+	small := WithTokenBudget(context.Background(), NewTokenBudget(TokenBudgetConfig{Limit: 50}))
+	if _, err := agent.Process(small, agenkit.NewMessage("user", "hi")); err == nil {
+		t.Error("Expected the budget check to use the model's tokenizer")
+	}
+}
+
+func TestAgentReportedUsageIsNotEstimated(t *testing.T) {
+	agent := NewAgent("assistant", &fakeProvider{usage: Usage{InputTokens: 3, OutputTokens: 4}}, AgentConfig{})
+	reply, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if _, ok := reply.Metadata["usage_estimated"]; ok {
+		t.Error("Expected provider-reported usage to be used as-is")
+	}
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Message metadata keys used for tool calling.
+//
+// A reply that calls tools carries its []agenkit.ToolCall under
+// ToolCallsMetadataKey, in the order the model issued them; this is the
+// shape tools.ToolAgent consumes. To send results back, append that reply
+// to the conversation followed by one message per call with role "tool",
+// the result as content, and the call's ID under ToolCallIDMetadataKey.
+const (
+	ToolCallsMetadataKey  = "tool_calls"
+	ToolCallIDMetadataKey = "tool_call_id"
+)
+
+// ToolCaller is implemented by providers that support native function calling.
+type ToolCaller interface {
+	Provider
+
+	// CallWithTools completes messages, letting the model call any of tools.
+	// Requested calls are returned in Response.ToolCalls.
+	CallWithTools(ctx context.Context, messages []*agenkit.Message, tools []agenkit.Tool) (*Response, error)
+}
+
+// SchemaTool is implemented by tools that describe their parameters with a
+// JSON Schema, such as MCP tools. Tools without a schema are advertised as
+// accepting any JSON object.
+type SchemaTool interface {
+	agenkit.Tool
+	InputSchema() map[string]any
+}
+
+// ToolSchema returns the JSON Schema of tool's parameters.
+func ToolSchema(tool agenkit.Tool) map[string]any {
+	if st, ok := tool.(SchemaTool); ok {
+		if schema := st.InputSchema(); schema != nil {
+			return schema
+		}
+	}
+	return map[string]any{"type": "object"}
+}
+
+// ToolCallsFromMessage returns the tool calls carried by message, if any.
+func ToolCallsFromMessage(message *agenkit.Message) []agenkit.ToolCall {
+	if message == nil || message.Metadata == nil {
+		return nil
+	}
+	calls, _ := message.Metadata[ToolCallsMetadataKey].([]agenkit.ToolCall)
+	return calls
+}
+
+// NewToolResultMessage returns the message reporting call's result to the model.
+func NewToolResultMessage(call agenkit.ToolCall, content string) *agenkit.Message {
+	return agenkit.NewMessage("tool", content).
+		WithMetadata(ToolCallIDMetadataKey, call.ID).
+		WithMetadata("tool_name", call.ToolName)
+}
+
+// decodeArguments parses a tool call's JSON arguments, tolerating an empty string.
+func decodeArguments(data []byte) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if len(data) == 0 || string(data) == `""` {
+		return params, nil
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
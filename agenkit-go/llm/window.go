@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrContextWindowExceeded is returned when the pinned system messages alone
+// do not fit in the available context window.
+var ErrContextWindowExceeded = errors.New("context window exceeded")
+
+// Tokenizer counts the tokens in a piece of text.
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// HeuristicTokenizer counts tokens with EstimateTokens. It is cheap but only
+// approximate; use a model-specific tokenizer where accuracy matters.
+type HeuristicTokenizer struct{}
+
+// Count returns the estimated number of tokens in text.
+func (HeuristicTokenizer) Count(text string) int {
+	return EstimateTokens(text)
+}
+
+// FitToWindow returns the messages that fit within maxTokens, counted with
+// tokenizer (HeuristicTokenizer if nil).
+//
+// Leading system messages are pinned and always kept. The rest are kept
+// newest first while they fit; the first message that does not fit whole is
+// cut short to the remaining budget, keeping its beginning, and every older
+// non-pinned message is dropped. A cut message is a copy with "truncated"
+// set in its metadata; the input slice and messages are not modified.
+//
+// FitToWindow returns ErrContextWindowExceeded if the pinned messages alone
+// exceed maxTokens.
+func FitToWindow(messages []*agenkit.Message, maxTokens int, tokenizer Tokenizer) ([]*agenkit.Message, error) {
+	if tokenizer == nil {
+		tokenizer = HeuristicTokenizer{}
+	}
+
+	pinned := 0
+	used := 0
+	for pinned < len(messages) && messages[pinned].Role == "system" {
+		used += tokenizer.Count(messages[pinned].Content)
+		pinned++
+	}
+	if used > maxTokens {
+		return nil, fmt.Errorf("%w: system prompt needs %d tokens, window is %d", ErrContextWindowExceeded, used, maxTokens)
+	}
+
+	start := len(messages)
+	var cut *agenkit.Message
+	for start > pinned {
+		msg := messages[start-1]
+		tokens := tokenizer.Count(msg.Content)
+		if used+tokens > maxTokens {
+			cut = truncateMessage(msg, maxTokens-used, tokenizer)
+			break
+		}
+		used += tokens
+		start--
+	}
+
+	fitted := make([]*agenkit.Message, 0, pinned+len(messages)-start+1)
+	fitted = append(fitted, messages[:pinned]...)
+	if cut != nil {
+		fitted = append(fitted, cut)
+	}
+	return append(fitted, messages[start:]...), nil
+}
+
+// truncateMessage returns a copy of message cut to the longest prefix that
+// fits in budget tokens, or nil if no non-empty prefix fits.
+func truncateMessage(message *agenkit.Message, budget int, tokenizer Tokenizer) *agenkit.Message {
+	if budget <= 0 {
+		return nil
+	}
+	runes := []rune(message.Content)
+
+	// Binary search for the longest prefix within budget
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if tokenizer.Count(string(runes[:mid])) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	content := strings.TrimRightFunc(string(runes[:lo]), unicode.IsSpace)
+	if content == "" {
+		return nil
+	}
+
+	cut := copyMessage(message)
+	cut.Content = content
+	cut.Metadata["truncated"] = true
+	return cut
+}
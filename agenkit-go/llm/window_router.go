@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// WindowRouter sends each message to the smallest-window model that can
+// take the whole prompt, so growing conversations move up a tier list
+// (e.g. 8k, 32k, 128k) instead of failing with a context-length error.
+//
+// Tiers are Agents with ContextWindow set. Prompts are counted as each tier
+// would send them, with its system prompt, history and tokenizer, keeping
+// its MaxTokens free for the reply. When no tier can take the whole prompt,
+// the smallest tier whose window holds the system messages and the incoming
+// message is used (the largest if none does), and that agent compacts the
+// history with FitToWindow.
+//
+// Replies carry "window_model" naming the chosen model, "prompt_tokens"
+// with the estimated prompt size for it before any compaction, and
+// "compacted" reporting whether history had to be dropped.
+type WindowRouter struct {
+	name  string
+	tiers []*Agent
+}
+
+// Verify that WindowRouter implements agenkit.Agent interface.
+var _ agenkit.Agent = (*WindowRouter)(nil)
+
+// NewWindowRouter creates a router over tiers, in any order.
+func NewWindowRouter(name string, tiers ...*Agent) (*WindowRouter, error) {
+	if len(tiers) == 0 {
+		return nil, fmt.Errorf("window router requires at least one tier")
+	}
+	for _, tier := range tiers {
+		if tier.config.ContextWindow <= 0 {
+			return nil, fmt.Errorf("window router tier %s has no context window", tier.Name())
+		}
+	}
+	sorted := append([]*Agent(nil), tiers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].config.ContextWindow < sorted[j].config.ContextWindow
+	})
+	return &WindowRouter{name: name, tiers: sorted}, nil
+}
+
+// Name returns the name of the router.
+func (r *WindowRouter) Name() string {
+	return r.name
+}
+
+// Capabilities returns the capabilities of the router.
+func (r *WindowRouter) Capabilities() []string {
+	return []string{"llm", "routing"}
+}
+
+// Tiers returns the tiers, smallest window first.
+func (r *WindowRouter) Tiers() []*Agent {
+	return r.tiers
+}
+
+// Process routes the message to the first tier that fits it.
+func (r *WindowRouter) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, span := agenkit.StartSpan(ctx, "llm.window_router",
+		attribute.String("agent.name", r.name),
+	)
+	defer func() { agenkit.EndSpan(span, err) }()
+	ctx, finish := agenkit.TrackAgent(ctx, r.name, message)
+	defer func() { finish(result, err) }()
+
+	var fallback *Agent
+	fallbackTokens := 0
+	chosen, tokens := (*Agent)(nil), 0
+	for _, tier := range r.tiers {
+		messages, err := tier.requestMessages(ctx, message)
+		if err != nil {
+			return nil, fmt.Errorf("window router %s: %w", r.name, err)
+		}
+		tokenizer := tier.tokenizer()
+		budget := tier.config.ContextWindow - tier.config.MaxTokens
+		needed := CountRequestTokens(&Request{Messages: messages}, tokenizer)
+		if needed <= budget {
+			chosen, tokens = tier, needed
+			break
+		}
+		if fallback == nil && essentialTokens(messages, tokenizer) <= budget {
+			fallback, fallbackTokens = tier, needed
+		}
+	}
+
+	compacted := chosen == nil
+	if compacted {
+		chosen, tokens = fallback, fallbackTokens
+		if chosen == nil {
+			chosen = r.tiers[len(r.tiers)-1]
+			messages, _ := chosen.requestMessages(ctx, message)
+			tokens = CountRequestTokens(&Request{Messages: messages}, chosen.tokenizer())
+		}
+	}
+	span.SetAttributes(
+		attribute.String("llm.model", chosen.Model()),
+		attribute.Bool("llm.compacted", compacted),
+	)
+
+	result, err = agenkit.ProcessWithSpan(ctx, chosen, message)
+	if err != nil {
+		return nil, fmt.Errorf("window router %s: %w", r.name, err)
+	}
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["window_model"] = chosen.Model()
+	result.Metadata["prompt_tokens"] = tokens
+	result.Metadata["compacted"] = compacted
+	return result, nil
+}
+
+// essentialTokens counts the messages compaction should keep whole: the
+// leading system messages and the incoming, last message.
+func essentialTokens(messages []*agenkit.Message, tokenizer Tokenizer) int {
+	total := 0
+	pinned := 0
+	for pinned < len(messages)-1 && messages[pinned].Role == "system" {
+		total += tokenizer.Count(messages[pinned].Content)
+		pinned++
+	}
+	return total + tokenizer.Count(messages[len(messages)-1].Content)
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func newTier(provider Provider, model string, window int) *Agent {
+	return NewAgent(model, provider, AgentConfig{Model: model, SystemPrompt: "sys", MaxTokens: 2, ContextWindow: window, Tokenizer: wordTokenizer{}})
+}
+
+func TestWindowRouterPicksSmallestFittingTier(t *testing.T) {
+	provider := &fakeProvider{}
+	router, err := NewWindowRouter("router", newTier(provider, "large", 40), newTier(provider, "small", 8), newTier(provider, "medium", 16))
+	if err != nil {
+		t.Fatalf("NewWindowRouter failed: %v", err)
+	}
+
+	// sys + 3 words + 2 reserved fits the small tier
+	result, err := router.Process(context.Background(), agenkit.NewMessage("user", "one two three"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Metadata["window_model"] != "small" || result.Metadata["compacted"] != false || result.Metadata["prompt_tokens"] != 4 {
+		t.Errorf("Expected the small tier without compaction, got %v", result.Metadata)
+	}
+
+	// Twelve words of history move the conversation up to the large tier
+	history := fixedHistory{agenkit.NewMessage("user", strings.Repeat("word ", 12))}
+	result, err = router.Process(WithHistory(context.Background(), history), agenkit.NewMessage("user", "one two three"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Metadata["window_model"] != "large" || result.Metadata["compacted"] != false {
+		t.Errorf("Expected the large tier, got %v", result.Metadata)
+	}
+	if got := provider.requests[1].Model; got != "large" {
+		t.Errorf("Expected the large model requested, got %s", got)
+	}
+}
+
+func TestWindowRouterCompactsWhenNothingFits(t *testing.T) {
+	provider := &fakeProvider{}
+	router, _ := NewWindowRouter("router", newTier(provider, "small", 8), newTier(provider, "medium", 16))
+
+	history := fixedHistory{agenkit.NewMessage("user", strings.Repeat("word ", 30))}
+	result, err := router.Process(WithHistory(context.Background(), history), agenkit.NewMessage("user", "one two three"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Metadata["window_model"] != "small" || result.Metadata["compacted"] != true {
+		t.Errorf("Expected compaction on the smallest adequate tier, got %v", result.Metadata)
+	}
+	if got := CountRequestTokens(provider.requests[0], wordTokenizer{}); got > 8 {
+		t.Errorf("Expected the request fitted to the small window, got %d tokens", got)
+	}
+
+	// A message too big for the small tier needs the medium one
+	result, err = router.Process(WithHistory(context.Background(), history), agenkit.NewMessage("user", strings.Repeat("x ", 8)))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Metadata["window_model"] != "medium" || result.Metadata["compacted"] != true {
+		t.Errorf("Expected compaction on the medium tier, got %v", result.Metadata)
+	}
+}
+
+func TestNewWindowRouterRequiresWindows(t *testing.T) {
+	if _, err := NewWindowRouter("router"); err == nil {
+		t.Error("Expected an error without tiers")
+	}
+	if _, err := NewWindowRouter("router", NewAgent("a", &fakeProvider{}, AgentConfig{})); err == nil {
+		t.Error("Expected an error for a tier without a context window")
+	}
+}
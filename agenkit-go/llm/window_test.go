@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// wordTokenizer counts one token per whitespace-separated word.
+type wordTokenizer struct{}
+
+func (wordTokenizer) Count(text string) int { return len(strings.Fields(text)) }
+
+func contents(messages []*agenkit.Message) []string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.Content
+	}
+	return out
+}
+
+func TestFitToWindowDropsMiddle(t *testing.T) {
+	messages := []*agenkit.Message{
+		agenkit.NewMessage("system", "be brief"),
+		agenkit.NewMessage("user", "one two three"),
+		agenkit.NewMessage("agent", "four five six"),
+		agenkit.NewMessage("user", "seven eight"),
+	}
+
+	fitted, err := FitToWindow(messages, 7, wordTokenizer{})
+	if err != nil {
+		t.Fatalf("FitToWindow failed: %v", err)
+	}
+	got := strings.Join(contents(fitted), "|")
+	if got != "be brief|four five six|seven eight" {
+		t.Errorf("Expected oldest turn dropped, got %q", got)
+	}
+}
+
+func TestFitToWindowTruncatesBoundary(t *testing.T) {
+	messages := []*agenkit.Message{
+		agenkit.NewMessage("system", "sys"),
+		agenkit.NewMessage("user", "alpha beta gamma delta"),
+		agenkit.NewMessage("user", "last"),
+	}
+
+	fitted, err := FitToWindow(messages, 4, wordTokenizer{})
+	if err != nil {
+		t.Fatalf("FitToWindow failed: %v", err)
+	}
+	if len(fitted) != 3 {
+		t.Fatalf("Expected 3 messages, got %v", contents(fitted))
+	}
+	if fitted[1].Content != "alpha beta" || fitted[1].Metadata["truncated"] != true {
+		t.Errorf("Expected boundary message cut to 'alpha beta', got %q (%v)", fitted[1].Content, fitted[1].Metadata)
+	}
+	if messages[1].Content != "alpha beta gamma delta" {
+		t.Error("Expected input message to be left unmodified")
+	}
+}
+
+func TestFitToWindowSystemTooLarge(t *testing.T) {
+	messages := []*agenkit.Message{
+		agenkit.NewMessage("system", "a b c d e"),
+		agenkit.NewMessage("user", "hi"),
+	}
+	_, err := FitToWindow(messages, 3, wordTokenizer{})
+	if !errors.Is(err, ErrContextWindowExceeded) {
+		t.Errorf("Expected ErrContextWindowExceeded, got %v", err)
+	}
+}
+
+func TestFitToWindowFitsUnchanged(t *testing.T) {
+	messages := []*agenkit.Message{
+		agenkit.NewMessage("user", "hello"),
+		agenkit.NewMessage("agent", "hi"),
+	}
+	fitted, err := FitToWindow(messages, 100, nil)
+	if err != nil {
+		t.Fatalf("FitToWindow failed: %v", err)
+	}
+	if len(fitted) != 2 || fitted[0] != messages[0] || fitted[1] != messages[1] {
+		t.Errorf("Expected messages unchanged, got %v", contents(fitted))
+	}
+}
+
+// fixedHistory is a static History.
+type fixedHistory []*agenkit.Message
+
+func (h fixedHistory) Messages(ctx context.Context) ([]*agenkit.Message, error) { return h, nil }
+
+func TestAgentContextWindow(t *testing.T) {
+	provider := &fakeProvider{}
+	agent := NewAgent("llm", provider, AgentConfig{
+		SystemPrompt:  "sys",
+		MaxTokens:     2,
+		ContextWindow: 5,
+		Tokenizer:     wordTokenizer{},
+	})
+
+	history := fixedHistory{
+		agenkit.NewMessage("user", "old question"),
+		agenkit.NewMessage("agent", "old answer"),
+	}
+	ctx := WithHistory(context.Background(), history)
+	if _, err := agent.Process(ctx, agenkit.NewMessage("user", "new")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	got := strings.Join(contents(provider.requests[0].Messages), "|")
+	if got != "sys|old|new" {
+		t.Errorf("Expected request fitted to 3 tokens, got %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/middleware"
+	"github.com/agenkit/agenkit-go/reasoning"
+)
+
+// AugmentConfig configures MemoryAugment.
+type AugmentConfig struct {
+	// TopK is the number of artifacts retrieved for each message.
+	// Default: 3
+	TopK int
+
+	// MinScore is the relevance score below which retrieved artifacts are
+	// not injected.
+	// Default: 0 (inject every retrieved artifact)
+	MinScore float64
+
+	// MaxContextTokens caps the size of the injected context. Artifacts are
+	// added most relevant first until the next one would exceed the cap.
+	// Default: 500
+	MaxContextTokens int
+
+	// Tokenizer counts tokens for MaxContextTokens.
+	// Default: llm.HeuristicTokenizer
+	Tokenizer llm.Tokenizer
+}
+
+// MemoryAugment gives an agent relevant context from memory.
+//
+// Before each call, the memory is searched with the message content and the
+// relevant complete artifacts (leaving out checkpoints) are attached to a copy of the message under
+// llm.ContextMetadataKey, which LLM agents send as a system message; the
+// message content is left unmodified. After a successful call, the exchange
+// is stored back into the memory as an artifact with technique "exchange",
+// so later calls can recall it. The IDs of the injected artifacts are
+// recorded on the response under "memory_artifacts".
+func MemoryAugment(memory ScoredMemory, config AugmentConfig) middleware.AgentMiddleware {
+	if config.TopK <= 0 {
+		config.TopK = 3
+	}
+	if config.MaxContextTokens <= 0 {
+		config.MaxContextTokens = 500
+	}
+	if config.Tokenizer == nil {
+		config.Tokenizer = llm.HeuristicTokenizer{}
+	}
+
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return middleware.Wrap(agent, func(ctx context.Context, message *agenkit.Message, next middleware.ProcessFunc) (*agenkit.Message, error) {
+			results, err := memory.Search(ctx, message.Content, config.TopK)
+			if err != nil {
+				return nil, fmt.Errorf("memory search failed: %w", err)
+			}
+
+			text, ids := buildAugmentedContext(results, config)
+			input := message
+			if text != "" {
+				input = withMetadata(message, llm.ContextMetadataKey, text)
+			}
+
+			response, err := next(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+
+			exchange := reasoning.NewArtifact("exchange", message.Content)
+			exchange.Answer = response.Content
+			if err := memory.Store(ctx, exchange); err != nil {
+				return nil, fmt.Errorf("failed to store exchange: %w", err)
+			}
+
+			if len(ids) > 0 {
+				response = withMetadata(response, "memory_artifacts", ids)
+			}
+			return response, nil
+		})
+	}
+}
+
+// buildAugmentedContext formats the relevant results as context within the
+// token cap, returning the text and the IDs of the artifacts included.
+func buildAugmentedContext(results []ScoredArtifact, config AugmentConfig) (string, []string) {
+	const header = "Relevant context from memory:"
+
+	var b strings.Builder
+	var ids []string
+	used := config.Tokenizer.Count(header)
+	for _, result := range results {
+		if result.Score < config.MinScore || !result.Artifact.Complete {
+			continue
+		}
+		entry := formatArtifact(result.Artifact)
+		cost := config.Tokenizer.Count(entry)
+		if used+cost > config.MaxContextTokens {
+			break
+		}
+		used += cost
+		b.WriteString("\n\n")
+		b.WriteString(entry)
+		ids = append(ids, result.Artifact.ID)
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return header + b.String(), ids
+}
+
+// formatArtifact renders an artifact as a question and answer pair.
+func formatArtifact(artifact *reasoning.Artifact) string {
+	if artifact.Answer == "" {
+		return artifact.Query
+	}
+	return "Q: " + artifact.Query + "\nA: " + artifact.Answer
+}
+
+// withMetadata returns a copy of message with key set in its metadata.
+func withMetadata(message *agenkit.Message, key string, value interface{}) *agenkit.Message {
+	result := *message
+	result.Metadata = make(map[string]interface{}, len(message.Metadata)+1)
+	for k, v := range message.Metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata[key] = value
+	return &result
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+func TestMemoryAugmentInjectsContextAndStoresExchange(t *testing.T) {
+	m := newTestMemory()
+	cats := storeArtifact(t, m, "what do cats eat", "cat food")
+	storeArtifact(t, m, "filing taxes", "tax forms")
+
+	provider := testutil.NewMockProvider(t, "model")
+	provider.Expect("", "Cats like fish too.")
+	agent := MemoryAugment(m, AugmentConfig{TopK: 2, MinScore: 0.5})(llm.NewAgent("chat", provider, llm.AgentConfig{SystemPrompt: "Be brief."}))
+
+	message := agenkit.NewMessage("user", "do cats eat fish")
+	response, err := agent.Process(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	sent := provider.Requests()[0].Messages
+	if len(sent) != 3 || sent[1].Role != "system" {
+		t.Fatalf("Expected system prompt, context and message, got %d messages", len(sent))
+	}
+	if !strings.Contains(sent[1].Content, "Q: what do cats eat\nA: cat food") {
+		t.Errorf("Expected relevant artifact in context, got:\n%s", sent[1].Content)
+	}
+	if strings.Contains(sent[1].Content, "taxes") {
+		t.Errorf("Expected irrelevant artifact below threshold to be left out, got:\n%s", sent[1].Content)
+	}
+	if sent[2].Content != "do cats eat fish" {
+		t.Errorf("Expected message content unmodified, got '%s'", sent[2].Content)
+	}
+	if _, ok := message.Metadata[llm.ContextMetadataKey]; ok {
+		t.Error("Expected the caller's message not to be modified")
+	}
+
+	ids, _ := response.Metadata["memory_artifacts"].([]string)
+	if len(ids) != 1 || ids[0] != cats.ID {
+		t.Errorf("Expected injected artifact IDs [%s], got %v", cats.ID, ids)
+	}
+	if m.Len() != 3 {
+		t.Errorf("Expected exchange to be stored, got %d artifacts", m.Len())
+	}
+}
+
+func TestMemoryAugmentTokenCap(t *testing.T) {
+	m := newTestMemory()
+	storeArtifact(t, m, "cat", strings.Repeat("cat ", 200))
+	storeArtifact(t, m, "cat dog", "pets")
+
+	inner := testutil.NewMockAgent(t, "inner")
+	inner.Expect("", "ok")
+	agent := MemoryAugment(m, AugmentConfig{MaxContextTokens: 50})(inner)
+
+	response, err := agent.Process(context.Background(), agenkit.NewMessage("user", "cat"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if _, ok := inner.Calls()[0].Metadata[llm.ContextMetadataKey]; ok {
+		t.Error("Expected no context when the most relevant artifact exceeds the cap, even if less relevant ones fit")
+	}
+	if _, ok := response.Metadata["memory_artifacts"]; ok {
+		t.Errorf("Expected no injected artifacts, got %v", response.Metadata["memory_artifacts"])
+	}
+}
+
+func TestMemoryAugmentEmptyMemory(t *testing.T) {
+	m := newTestMemory()
+	inner := testutil.NewMockAgent(t, "inner")
+	inner.Expect("", "first answer")
+	agent := MemoryAugment(m, AugmentConfig{})(inner)
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "dog walking")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if _, ok := inner.Calls()[0].Metadata[llm.ContextMetadataKey]; ok {
+		t.Error("Expected no context from an empty memory")
+	}
+
+	results, _ := m.Search(context.Background(), "dog", 1)
+	if len(results) != 1 || results[0].Artifact.Answer != "first answer" {
+		t.Errorf("Expected the exchange to be recallable, got %v", results)
+	}
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// embeddingMetadataKey is the metadata key holding a cached vector.
+const embeddingMetadataKey = "embedding"
+
+// cachingEmbedder is the Embedder returned by CachingEmbedder.
+type cachingEmbedder struct {
+	inner Embedder
+	cache llm.Cache
+	model string
+
+	mu       sync.Mutex
+	inflight map[string]*embedCall
+}
+
+// embedCall is an embedding in progress, shared by concurrent callers
+// asking for the same text.
+type embedCall struct {
+	done   chan struct{}
+	vector []float32
+	err    error
+}
+
+// CachingEmbedder wraps inner so each distinct text is embedded once.
+// Vectors are cached in cache, or in an LRU cache of 1000 entries if cache
+// is nil, and hits skip inner entirely.
+//
+// model names the embedding model inner uses. Keys hash the text and are
+// namespaced by model, so embedders of different models sharing a cache
+// never see each other's vectors. The result is safe for concurrent use;
+// concurrent calls for the same uncached text share one call to inner.
+func CachingEmbedder(inner Embedder, model string, cache llm.Cache) Embedder {
+	if cache == nil {
+		cache = llm.NewLRUCache(1000)
+	}
+	return &cachingEmbedder{
+		inner:    inner,
+		cache:    cache,
+		model:    model,
+		inflight: make(map[string]*embedCall),
+	}
+}
+
+// Model returns the wrapped embedder's model.
+func (c *cachingEmbedder) Model() string {
+	return c.model
+}
+
+// Embed returns the cached vector for text, embedding it on a miss.
+func (c *cachingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	sum := sha256.Sum256([]byte(text))
+	key := "embedding:" + c.model + ":" + hex.EncodeToString(sum[:])
+
+	if cached, ok := c.cache.Get(key); ok {
+		if vector, ok := cached.Metadata[embeddingMetadataKey].([]float32); ok {
+			return copyVector(vector), nil
+		}
+	}
+
+	c.mu.Lock()
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &embedCall{done: make(chan struct{})}
+		c.inflight[key] = call
+	}
+	c.mu.Unlock()
+
+	if ok {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		return copyVector(call.vector), nil
+	}
+
+	call.vector, call.err = c.inner.Embed(ctx, text)
+	if call.err == nil {
+		entry := agenkit.NewMessage("embedding", "")
+		entry.Metadata[embeddingMetadataKey] = copyVector(call.vector)
+		c.cache.Set(key, entry, 0)
+	}
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		return nil, call.err
+	}
+	return copyVector(call.vector), nil
+}
+
+// copyVector returns a copy of vector, so callers that modify an embedding
+// cannot corrupt the cache.
+func copyVector(vector []float32) []float32 {
+	return append([]float32(nil), vector...)
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// countingEmbedder embeds text as its length and counts calls.
+type countingEmbedder struct {
+	model string
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls.Add(1)
+	time.Sleep(e.delay)
+	return []float32{float32(len(text)), 1}, nil
+}
+
+func TestCachingEmbedderSkipsRepeatedText(t *testing.T) {
+	inner := &countingEmbedder{model: "small"}
+	embedder := CachingEmbedder(inner, inner.model, nil)
+	ctx := context.Background()
+
+	first, err := embedder.Embed(ctx, "hello")
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	first[0] = 99 // must not corrupt the cache
+
+	second, _ := embedder.Embed(ctx, "hello")
+	if second[0] != 5 {
+		t.Errorf("Expected the cached vector, got %v", second)
+	}
+	embedder.Embed(ctx, "world!")
+	if got := inner.calls.Load(); got != 2 {
+		t.Errorf("Expected 2 inner calls, got %d", got)
+	}
+}
+
+func TestCachingEmbedderNamespacesByModel(t *testing.T) {
+	cache := llm.NewLRUCache(10)
+	small := &countingEmbedder{model: "small"}
+	large := &countingEmbedder{model: "large"}
+	ctx := context.Background()
+
+	CachingEmbedder(small, small.model, cache).Embed(ctx, "hello")
+	CachingEmbedder(large, large.model, cache).Embed(ctx, "hello")
+	if small.calls.Load() != 1 || large.calls.Load() != 1 {
+		t.Errorf("Expected each model to embed once, got %d and %d", small.calls.Load(), large.calls.Load())
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 cache entries, got %d", cache.Len())
+	}
+}
+
+func TestCachingEmbedderSharesConcurrentCalls(t *testing.T) {
+	inner := &countingEmbedder{model: "small", delay: 20 * time.Millisecond}
+	embedder := CachingEmbedder(inner, inner.model, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if vector, err := embedder.Embed(context.Background(), "same text"); err != nil || vector[0] != 9 {
+				t.Errorf("Expected the shared vector, got %v (%v)", vector, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := inner.calls.Load(); got != 1 {
+		t.Errorf("Expected 1 inner call, got %d", got)
+	}
+}
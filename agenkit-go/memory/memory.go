@@ -0,0 +1,53 @@
+// Package memory provides stores that let agents recall past reasoning and
+// conversations.
+//
+// Memories hold reasoning.Artifact values so that the output of any
+// technique can be persisted and later retrieved as context for new queries.
+// WindowMemory keeps a conversation history that stays within a token
+// budget by summarizing older turns.
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/agenkit/agenkit-go/reasoning"
+)
+
+// ErrDimensionMismatch is returned when an embedding's length differs from
+// the dimension of vectors already held by a memory.
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// Memory stores artifacts and retrieves the ones most relevant to a query.
+type Memory interface {
+	// Store adds an artifact, replacing any existing artifact with the same ID.
+	Store(ctx context.Context, artifact *reasoning.Artifact) error
+
+	// Retrieve returns up to topK artifacts ordered by relevance to query.
+	Retrieve(ctx context.Context, query string, topK int) ([]*reasoning.Artifact, error)
+}
+
+// ScoredArtifact is a retrieved artifact with its relevance score; higher
+// is more relevant.
+type ScoredArtifact struct {
+	Artifact *reasoning.Artifact
+	Score    float64
+}
+
+// ScoredMemory is a Memory that reports how relevant each result is.
+type ScoredMemory interface {
+	Memory
+
+	// Search returns up to topK artifacts with their scores, most relevant first.
+	Search(ctx context.Context, query string, topK int) ([]ScoredArtifact, error)
+}
+
+// Verify that Memory can seed reasoning techniques such as GraphOfThought.
+var _ reasoning.ArtifactRetriever = (Memory)(nil)
+
+// Embedder converts text into a vector embedding. Embedders may also
+// report their model with a Model() string method, which CachingEmbedder
+// uses to keep each model's vectors apart.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
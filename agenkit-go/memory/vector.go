@@ -0,0 +1,479 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/reasoning"
+)
+
+// VectorMemoryConfig configures a VectorMemory.
+type VectorMemoryConfig struct {
+	// BestEffort keeps embedding failures from failing callers. Search and
+	// Retrieve return no results, and Store queues the artifact to be
+	// embedded later. Failures are logged to agenkit.Logger of the call's
+	// context. Otherwise embedding failures are returned.
+	// Default: false
+	BestEffort bool
+
+	// MaxPending is the number of artifacts queued for embedding in
+	// best-effort mode. Once it is reached, the oldest is dropped.
+	// Default: 100
+	MaxPending int
+
+	// RetryInterval is how often queued artifacts are retried, and how long
+	// each retry may take. They are also retried as soon as any embedding
+	// succeeds, until Close is called.
+	// Default: 30s
+	RetryInterval time.Duration
+}
+
+// VectorMemory is an in-memory Memory that retrieves artifacts by cosine
+// similarity between embeddings.
+//
+// Vectors are normalized once when stored, so retrieval is a dot product.
+// All stored vectors share the dimension of the first one; embeddings of any
+// other length are rejected with ErrDimensionMismatch. In best-effort mode,
+// Close stops the background retries of queued artifacts.
+type VectorMemory struct {
+	embedder Embedder
+	config   VectorMemoryConfig
+
+	mu        sync.RWMutex
+	dimension int
+	entries   []vectorEntry
+	index     map[string]int // artifact ID -> position in entries
+
+	pendingMu sync.Mutex
+	pending   []pendingArtifact
+	retrying  bool
+	closed    bool
+	wake      chan struct{}
+	stop      chan struct{}
+}
+
+// pendingArtifact is an artifact queued for embedding in best-effort mode.
+type pendingArtifact struct {
+	artifact *reasoning.Artifact
+	logger   *slog.Logger
+}
+
+// vectorEntry pairs an artifact with its normalized embedding.
+type vectorEntry struct {
+	Artifact *reasoning.Artifact `json:"artifact"`
+	Vector   []float32           `json:"vector"`
+}
+
+// vectorSnapshot is the serialized form of a VectorMemory.
+type vectorSnapshot struct {
+	Dimension int           `json:"dimension"`
+	Entries   []vectorEntry `json:"entries"`
+}
+
+// Verify that VectorMemory implements Memory, ScoredMemory and
+// reasoning.CheckpointStore interfaces.
+var (
+	_ Memory                    = (*VectorMemory)(nil)
+	_ ScoredMemory              = (*VectorMemory)(nil)
+	_ reasoning.CheckpointStore = (*VectorMemory)(nil)
+)
+
+// NewVectorMemory creates an empty vector memory using the given embedder.
+func NewVectorMemory(embedder Embedder) *VectorMemory {
+	return NewVectorMemoryWithConfig(embedder, VectorMemoryConfig{})
+}
+
+// NewVectorMemoryWithConfig creates an empty vector memory using the given
+// embedder and configuration.
+func NewVectorMemoryWithConfig(embedder Embedder, config VectorMemoryConfig) *VectorMemory {
+	if config.MaxPending <= 0 {
+		config.MaxPending = 100
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 30 * time.Second
+	}
+	return &VectorMemory{
+		embedder: embedder,
+		config:   config,
+		index:    make(map[string]int),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Store embeds the artifact's query and answer and adds it to the index.
+// In best-effort mode an artifact that cannot be embedded is queued for a
+// later retry and Store returns nil.
+func (m *VectorMemory) Store(ctx context.Context, artifact *reasoning.Artifact) error {
+	if artifact == nil {
+		return fmt.Errorf("cannot store nil artifact")
+	}
+
+	vector, err := m.embed(ctx, artifactText(artifact))
+	if err != nil {
+		if !m.config.BestEffort {
+			return err
+		}
+		logger := agenkit.Logger(ctx)
+		logger.WarnContext(ctx, "memory store deferred", "artifact_id", artifact.ID, "error", err)
+		m.enqueue(logger, artifact)
+		return nil
+	}
+	m.dequeue(artifact.ID)
+	return m.insert(artifact, vector)
+}
+
+// insert adds an embedded artifact to the index.
+func (m *VectorMemory) insert(artifact *reasoning.Artifact, vector []float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkDimension(len(vector)); err != nil {
+		return err
+	}
+	if m.dimension == 0 {
+		m.dimension = len(vector)
+	}
+
+	entry := vectorEntry{Artifact: artifact, Vector: vector}
+	if i, ok := m.index[artifact.ID]; ok {
+		m.entries[i] = entry
+		return nil
+	}
+	m.index[artifact.ID] = len(m.entries)
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// Retrieve returns up to topK artifacts ranked by cosine similarity to query.
+func (m *VectorMemory) Retrieve(ctx context.Context, query string, topK int) ([]*reasoning.Artifact, error) {
+	results, err := m.Search(ctx, query, topK)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := make([]*reasoning.Artifact, len(results))
+	for i, result := range results {
+		artifacts[i] = result.Artifact
+	}
+	return artifacts, nil
+}
+
+// Search returns up to topK artifacts ranked by cosine similarity to
+// query, with their similarity scores. In best-effort mode a query that
+// cannot be embedded has no results.
+func (m *VectorMemory) Search(ctx context.Context, query string, topK int) ([]ScoredArtifact, error) {
+	if topK <= 0 {
+		return nil, fmt.Errorf("topK must be positive, got %d", topK)
+	}
+
+	vector, err := m.embed(ctx, query)
+	if err != nil {
+		if !m.config.BestEffort {
+			return nil, err
+		}
+		agenkit.Logger(ctx).WarnContext(ctx, "memory search skipped", "error", err)
+		return []ScoredArtifact{}, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.entries) == 0 {
+		return []ScoredArtifact{}, nil
+	}
+	if err := m.checkDimension(len(vector)); err != nil {
+		return nil, err
+	}
+
+	results := make([]ScoredArtifact, len(m.entries))
+	for i, entry := range m.entries {
+		results[i] = ScoredArtifact{Artifact: entry.Artifact, Score: float64(dot(vector, entry.Vector))}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if topK > len(results) {
+		topK = len(results)
+	}
+	return results[:topK], nil
+}
+
+// Get returns the artifact with the given ID, if stored.
+func (m *VectorMemory) Get(ctx context.Context, id string) (*reasoning.Artifact, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i, ok := m.index[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return m.entries[i].Artifact, true, nil
+}
+
+// Len returns the number of stored artifacts.
+func (m *VectorMemory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
+// Pending returns the number of artifacts queued for embedding in
+// best-effort mode.
+func (m *VectorMemory) Pending() int {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	return len(m.pending)
+}
+
+// Flush embeds and stores the queued artifacts in order, stopping at the
+// first embedding failure, which it returns. Artifacts whose embeddings
+// cannot be stored, such as zero vectors, are logged and dropped.
+func (m *VectorMemory) Flush(ctx context.Context) error {
+	for {
+		m.pendingMu.Lock()
+		if len(m.pending) == 0 {
+			m.pendingMu.Unlock()
+			return nil
+		}
+		next := m.pending[0]
+		m.pendingMu.Unlock()
+
+		vector, err := m.embedder.Embed(ctx, artifactText(next.artifact))
+		if err != nil {
+			return fmt.Errorf("embedding failed: %w", err)
+		}
+
+		m.pendingMu.Lock()
+		if len(m.pending) > 0 && m.pending[0].artifact == next.artifact {
+			m.pending = m.pending[1:]
+		}
+		m.pendingMu.Unlock()
+		// Unusable vectors will not improve with retries
+		if vector, err = checkVector(vector); err == nil {
+			err = m.insert(next.artifact, vector)
+		}
+		if err != nil {
+			next.logger.Error("dropping queued memory artifact", "artifact_id", next.artifact.ID, "error", err)
+		}
+	}
+}
+
+// enqueue queues artifact for embedding, replacing any queued artifact
+// with the same ID, and starts the retry loop if needed. Later failures to
+// store it are logged to logger.
+func (m *VectorMemory) enqueue(logger *slog.Logger, artifact *reasoning.Artifact) {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+
+	m.removePendingLocked(artifact.ID)
+	if len(m.pending) >= m.config.MaxPending {
+		dropped := m.pending[0]
+		m.pending = m.pending[1:]
+		dropped.logger.Error("dropping queued memory artifact", "artifact_id", dropped.artifact.ID, "error", "queue full")
+	}
+	m.pending = append(m.pending, pendingArtifact{artifact: artifact, logger: logger})
+	if !m.retrying && !m.closed {
+		m.retrying = true
+		go m.retryLoop()
+	}
+}
+
+// dequeue drops any queued artifact with the given ID, so a stale copy
+// does not later replace one just stored.
+func (m *VectorMemory) dequeue(id string) {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	m.removePendingLocked(id)
+}
+
+// signalRecovered wakes the retry loop after an embedding succeeds.
+func (m *VectorMemory) signalRecovered() {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	if !m.retrying {
+		return
+	}
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// removePendingLocked removes the queued artifact with the given ID.
+// Callers must hold m.pendingMu.
+func (m *VectorMemory) removePendingLocked(id string) {
+	for i, p := range m.pending {
+		if p.artifact.ID == id {
+			m.pending = append(m.pending[:i:i], m.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close stops retrying queued artifacts in the background, cancelling a
+// retry in progress. Queued artifacts are kept and can still be stored
+// with Flush. It is safe to call Close more than once.
+func (m *VectorMemory) Close() error {
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.stop)
+	}
+	return nil
+}
+
+// retryLoop flushes the queue on every tick or wake-up until it is empty
+// or the memory is closed. Each flush is bounded by the retry interval so
+// a hanging embedder cannot stall the loop.
+func (m *VectorMemory) retryLoop() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(m.config.RetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.wake:
+		case <-ctx.Done():
+		}
+		if ctx.Err() == nil {
+			flushCtx, cancelFlush := context.WithTimeout(ctx, m.config.RetryInterval)
+			_ = m.Flush(flushCtx)
+			cancelFlush()
+		}
+
+		m.pendingMu.Lock()
+		if len(m.pending) == 0 || m.closed {
+			m.retrying = false
+			m.pendingMu.Unlock()
+			return
+		}
+		m.pendingMu.Unlock()
+	}
+}
+
+// Snapshot serializes the index, including embeddings, so it can be
+// persisted and later passed to Restore without re-embedding.
+func (m *VectorMemory) Snapshot() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return json.Marshal(vectorSnapshot{
+		Dimension: m.dimension,
+		Entries:   m.entries,
+	})
+}
+
+// Restore replaces the index with one produced by Snapshot.
+func (m *VectorMemory) Restore(data []byte) error {
+	var snapshot vectorSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("invalid vector memory snapshot: %w", err)
+	}
+
+	index := make(map[string]int, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		if entry.Artifact == nil {
+			return fmt.Errorf("invalid vector memory snapshot: entry %d has no artifact", i)
+		}
+		if len(entry.Vector) != snapshot.Dimension {
+			return fmt.Errorf("invalid vector memory snapshot: entry %d: %w: expected %d, got %d",
+				i, ErrDimensionMismatch, snapshot.Dimension, len(entry.Vector))
+		}
+		index[entry.Artifact.ID] = i
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dimension = snapshot.Dimension
+	m.entries = snapshot.Entries
+	m.index = index
+	return nil
+}
+
+// embed embeds text and normalizes the result to unit length.
+func (m *VectorMemory) embed(ctx context.Context, text string) ([]float32, error) {
+	vector, err := m.embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	vector, err = checkVector(vector)
+	if err != nil {
+		return nil, err
+	}
+	m.signalRecovered()
+	return vector, nil
+}
+
+// checkVector validates an embedding and normalizes it to unit length.
+func checkVector(vector []float32) ([]float32, error) {
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("embedder returned an empty vector")
+	}
+	normalized, ok := normalize(vector)
+	if !ok {
+		return nil, fmt.Errorf("embedder returned a zero vector")
+	}
+	return normalized, nil
+}
+
+// checkDimension reports whether n matches the stored dimension.
+// Callers must hold m.mu.
+func (m *VectorMemory) checkDimension(n int) error {
+	if m.dimension != 0 && n != m.dimension {
+		return fmt.Errorf("%w: expected %d, got %d", ErrDimensionMismatch, m.dimension, n)
+	}
+	return nil
+}
+
+// artifactText returns the text embedded for an artifact.
+func artifactText(artifact *reasoning.Artifact) string {
+	if artifact.Answer == "" {
+		return artifact.Query
+	}
+	return artifact.Query + "\n" + artifact.Answer
+}
+
+// normalize returns a unit-length copy of v, leaving v untouched in case the
+// embedder reuses it. It returns false for a zero vector.
+func normalize(v []float32) ([]float32, bool) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return nil, false
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out, true
+}
+
+// dot returns the dot product of two equal-length vectors.
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
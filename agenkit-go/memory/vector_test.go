@@ -0,0 +1,356 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/reasoning"
+)
+
+// keywordEmbedder embeds text as counts of a fixed vocabulary.
+type keywordEmbedder struct {
+	vocabulary []string
+}
+
+func (e *keywordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	text = strings.ToLower(text)
+	vector := make([]float32, len(e.vocabulary))
+	for i, word := range e.vocabulary {
+		vector[i] = float32(strings.Count(text, word))
+	}
+	return vector, nil
+}
+
+// fixedEmbedder returns the same vector for every input.
+type fixedEmbedder struct {
+	vector []float32
+	err    error
+}
+
+func (e *fixedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.vector, e.err
+}
+
+func newTestMemory() *VectorMemory {
+	return NewVectorMemory(&keywordEmbedder{vocabulary: []string{"cat", "dog", "fish", "tax"}})
+}
+
+func storeArtifact(t *testing.T, m *VectorMemory, query, answer string) *reasoning.Artifact {
+	t.Helper()
+	artifact := reasoning.NewArtifact("test", query)
+	artifact.Answer = answer
+	if err := m.Store(context.Background(), artifact); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	return artifact
+}
+
+func TestVectorMemoryRetrieveRanksBySimilarity(t *testing.T) {
+	m := newTestMemory()
+	cats := storeArtifact(t, m, "what do cats eat", "cat food")
+	dogs := storeArtifact(t, m, "how to walk a dog", "dog leash")
+	storeArtifact(t, m, "filing taxes", "tax forms")
+
+	results, err := m.Retrieve(context.Background(), "my cat and my dog, mostly cat", 2)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].ID != cats.ID {
+		t.Errorf("Expected cat artifact first, got '%s'", results[0].Query)
+	}
+	if results[1].ID != dogs.ID {
+		t.Errorf("Expected dog artifact second, got '%s'", results[1].Query)
+	}
+}
+
+func TestVectorMemoryTopKLargerThanIndex(t *testing.T) {
+	m := newTestMemory()
+	storeArtifact(t, m, "cat", "")
+
+	results, err := m.Retrieve(context.Background(), "cat", 10)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result, got %d", len(results))
+	}
+}
+
+func TestVectorMemoryRetrieveEmpty(t *testing.T) {
+	results, err := newTestMemory().Retrieve(context.Background(), "cat", 3)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+}
+
+func TestVectorMemoryInvalidTopK(t *testing.T) {
+	if _, err := newTestMemory().Retrieve(context.Background(), "cat", 0); err == nil {
+		t.Fatal("Expected error for non-positive topK")
+	}
+}
+
+func TestVectorMemoryStoreReplacesByID(t *testing.T) {
+	m := newTestMemory()
+	artifact := storeArtifact(t, m, "cat", "")
+	artifact.Query = "dog"
+	if err := m.Store(context.Background(), artifact); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Expected 1 artifact after replacing, got %d", m.Len())
+	}
+}
+
+func TestVectorMemoryNormalizesOnInsert(t *testing.T) {
+	raw := []float32{3, 4}
+	m := NewVectorMemory(&fixedEmbedder{vector: raw})
+	storeArtifact(t, m, "q", "")
+
+	vector := m.entries[0].Vector
+	length := math.Sqrt(float64(vector[0]*vector[0] + vector[1]*vector[1]))
+	if math.Abs(length-1) > 1e-6 {
+		t.Errorf("Expected unit-length vector, got length %f", length)
+	}
+	if raw[0] != 3 || raw[1] != 4 {
+		t.Errorf("Expected embedder's vector to be left untouched, got %v", raw)
+	}
+}
+
+func TestVectorMemoryDimensionMismatch(t *testing.T) {
+	embedder := &fixedEmbedder{vector: []float32{1, 0, 0}}
+	m := NewVectorMemory(embedder)
+	storeArtifact(t, m, "q", "")
+
+	embedder.vector = []float32{1, 0}
+	err := m.Store(context.Background(), reasoning.NewArtifact("test", "other"))
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch on store, got %v", err)
+	}
+
+	_, err = m.Retrieve(context.Background(), "q", 1)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch on retrieve, got %v", err)
+	}
+}
+
+func TestVectorMemoryRejectsZeroVector(t *testing.T) {
+	m := NewVectorMemory(&fixedEmbedder{vector: []float32{0, 0}})
+	if err := m.Store(context.Background(), reasoning.NewArtifact("test", "q")); err == nil {
+		t.Fatal("Expected error for zero vector")
+	}
+}
+
+func TestVectorMemoryEmbedderError(t *testing.T) {
+	m := NewVectorMemory(&fixedEmbedder{err: errors.New("embedding service down")})
+	err := m.Store(context.Background(), reasoning.NewArtifact("test", "q"))
+	if err == nil || !strings.Contains(err.Error(), "embedding service down") {
+		t.Errorf("Expected wrapped embedder error, got %v", err)
+	}
+}
+
+// flakyEmbedder fails while down is set and otherwise embeds keywords.
+type flakyEmbedder struct {
+	keywordEmbedder
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls.Add(1)
+	if e.down.Load() {
+		return nil, errors.New("embedding service down")
+	}
+	return e.keywordEmbedder.Embed(ctx, text)
+}
+
+func TestVectorMemoryBestEffortSearch(t *testing.T) {
+	embedder := &flakyEmbedder{keywordEmbedder: keywordEmbedder{vocabulary: []string{"cat", "dog"}}}
+	m := NewVectorMemoryWithConfig(embedder, VectorMemoryConfig{BestEffort: true})
+	ctx := context.Background()
+	if err := m.Store(ctx, reasoning.NewArtifact("test", "cat")); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	embedder.down.Store(true)
+	results, err := m.Retrieve(ctx, "cat", 1)
+	if err != nil {
+		t.Fatalf("Expected no error in best-effort mode, got %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+	if _, err := m.Retrieve(ctx, "cat", 0); err == nil {
+		t.Error("Expected error for invalid topK in best-effort mode")
+	}
+}
+
+func TestVectorMemoryBestEffortStoreQueues(t *testing.T) {
+	embedder := &flakyEmbedder{keywordEmbedder: keywordEmbedder{vocabulary: []string{"cat", "dog"}}}
+	embedder.down.Store(true)
+	m := NewVectorMemoryWithConfig(embedder, VectorMemoryConfig{BestEffort: true, RetryInterval: time.Hour})
+	ctx := context.Background()
+
+	cat := reasoning.NewArtifact("test", "cat")
+	if err := m.Store(ctx, cat); err != nil {
+		t.Fatalf("Expected no error in best-effort mode, got %v", err)
+	}
+	if m.Len() != 0 || m.Pending() != 1 {
+		t.Fatalf("Expected 0 stored and 1 pending, got %d and %d", m.Len(), m.Pending())
+	}
+	if err := m.Flush(ctx); err == nil {
+		t.Error("Expected flush to fail while the embedder is down")
+	}
+
+	embedder.down.Store(false)
+	if err := m.Store(ctx, reasoning.NewArtifact("test", "dog")); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if m.Pending() != 0 {
+		t.Fatalf("Expected queue to flush once the embedder recovered, %d pending", m.Pending())
+	}
+	if _, ok, _ := m.Get(ctx, cat.ID); !ok {
+		t.Error("Expected queued artifact to be stored")
+	}
+}
+
+func TestVectorMemoryBestEffortRetryInterval(t *testing.T) {
+	embedder := &flakyEmbedder{keywordEmbedder: keywordEmbedder{vocabulary: []string{"cat"}}}
+	embedder.down.Store(true)
+	m := NewVectorMemoryWithConfig(embedder, VectorMemoryConfig{BestEffort: true, RetryInterval: 10 * time.Millisecond})
+	ctx := context.Background()
+	if err := m.Store(ctx, reasoning.NewArtifact("test", "cat")); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	embedder.down.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if m.Len() != 1 || m.Pending() != 0 {
+		t.Errorf("Expected queued artifact stored on retry, got %d stored and %d pending", m.Len(), m.Pending())
+	}
+}
+
+func TestVectorMemoryCloseStopsRetries(t *testing.T) {
+	embedder := &flakyEmbedder{keywordEmbedder: keywordEmbedder{vocabulary: []string{"cat"}}}
+	embedder.down.Store(true)
+	m := NewVectorMemoryWithConfig(embedder, VectorMemoryConfig{BestEffort: true, RetryInterval: 5 * time.Millisecond})
+	ctx := context.Background()
+	if err := m.Store(ctx, reasoning.NewArtifact("test", "cat")); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	calls := embedder.calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	if got := embedder.calls.Load(); got != calls {
+		t.Errorf("Expected no retries after Close, got %d more embeddings", got-calls)
+	}
+	if m.Pending() != 1 {
+		t.Errorf("Expected the artifact to stay queued, got %d pending", m.Pending())
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Expected a second Close to succeed, got %v", err)
+	}
+}
+
+func TestVectorMemoryBestEffortQueueBounded(t *testing.T) {
+	embedder := &flakyEmbedder{keywordEmbedder: keywordEmbedder{vocabulary: []string{"cat"}}}
+	embedder.down.Store(true)
+	m := NewVectorMemoryWithConfig(embedder, VectorMemoryConfig{BestEffort: true, MaxPending: 2, RetryInterval: time.Hour})
+	ctx := context.Background()
+
+	first := reasoning.NewArtifact("test", "cat 1")
+	for _, query := range []string{"cat 2", "cat 3"} {
+		_ = m.Store(ctx, reasoning.NewArtifact("test", query))
+	}
+	_ = m.Store(ctx, first)
+	_ = m.Store(ctx, first)
+	if m.Pending() != 2 {
+		t.Fatalf("Expected 2 pending, got %d", m.Pending())
+	}
+
+	embedder.down.Store(false)
+	if err := m.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, ok, _ := m.Get(ctx, first.ID); !ok || m.Len() != 2 {
+		t.Errorf("Expected the newest 2 artifacts stored, got %d", m.Len())
+	}
+}
+
+func TestVectorMemorySnapshotRestore(t *testing.T) {
+	m := newTestMemory()
+	cats := storeArtifact(t, m, "cat", "")
+	storeArtifact(t, m, "dog", "")
+
+	data, err := m.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	restored := newTestMemory()
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.Len() != 2 {
+		t.Fatalf("Expected 2 artifacts after restore, got %d", restored.Len())
+	}
+
+	results, err := restored.Retrieve(context.Background(), "cat", 1)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if results[0].ID != cats.ID {
+		t.Errorf("Expected cat artifact after restore, got '%s'", results[0].Query)
+	}
+
+	// Replacing by ID must still work after restore
+	if err := restored.Store(context.Background(), cats); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if restored.Len() != 2 {
+		t.Errorf("Expected restored index to dedupe by ID, got %d artifacts", restored.Len())
+	}
+}
+
+func TestVectorMemoryRestoreRejectsInconsistentSnapshot(t *testing.T) {
+	data := []byte(`{"dimension":3,"entries":[{"artifact":{"id":"a"},"vector":[1,0]}]}`)
+	err := newTestMemory().Restore(data)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch, got %v", err)
+	}
+}
+
+func TestVectorMemoryGet(t *testing.T) {
+	m := newTestMemory()
+	stored := storeArtifact(t, m, "what do cats eat", "cat food")
+
+	artifact, ok, err := m.Get(context.Background(), stored.ID)
+	if err != nil || !ok || artifact.Answer != "cat food" {
+		t.Errorf("Expected stored artifact, got %v (ok %v, err %v)", artifact, ok, err)
+	}
+	if _, ok, _ := m.Get(context.Background(), "missing"); ok {
+		t.Error("Expected missing ID not to be found")
+	}
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// WindowMemoryConfig configures a WindowMemory.
+type WindowMemoryConfig struct {
+	// WindowSize is the number of most recent messages always kept verbatim.
+	// Default: 20
+	WindowSize int
+
+	// MaxTokens is the estimated history size above which messages older
+	// than the window are compacted.
+	// Default: 4000
+	MaxTokens int
+
+	// Summarizer condenses older messages into a summary. If nil, older
+	// messages are dropped instead.
+	Summarizer agenkit.Agent
+}
+
+// WindowMemory is a conversation history that keeps the most recent
+// messages verbatim and compacts older ones once the history grows too
+// large.
+//
+// Compaction is lazy: nothing happens as messages are added, and Messages
+// compacts only when the estimated token count exceeds MaxTokens and there
+// are messages outside the window. The older messages are then folded,
+// together with any previous summary, into a single system message. If the
+// first message added is a system message, it is pinned and never
+// summarized away.
+//
+// WindowMemory implements llm.History, so LLM agents can read the
+// compacted history via llm.WithHistory; ConversationAgent wires this up.
+type WindowMemory struct {
+	config WindowMemoryConfig
+
+	compactMu sync.Mutex // serializes compaction
+
+	mu       sync.RWMutex
+	pinned   *agenkit.Message
+	summary  *agenkit.Message
+	messages []*agenkit.Message
+	started  bool
+}
+
+// Verify that WindowMemory implements llm.History interface.
+var _ llm.History = (*WindowMemory)(nil)
+
+// NewWindowMemory creates an empty conversation memory.
+func NewWindowMemory(config WindowMemoryConfig) *WindowMemory {
+	if config.WindowSize <= 0 {
+		config.WindowSize = 20
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = 4000
+	}
+	return &WindowMemory{config: config}
+}
+
+// Add appends messages to the history.
+func (m *WindowMemory) Add(messages ...*agenkit.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range messages {
+		if !m.started {
+			m.started = true
+			if msg.Role == "system" {
+				m.pinned = msg
+				continue
+			}
+		}
+		m.messages = append(m.messages, msg)
+	}
+}
+
+// Messages returns the history, compacting it first if it is over budget.
+func (m *WindowMemory) Messages(ctx context.Context) ([]*agenkit.Message, error) {
+	if err := m.compact(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	history := make([]*agenkit.Message, 0, len(m.messages)+2)
+	if m.pinned != nil {
+		history = append(history, m.pinned)
+	}
+	if m.summary != nil {
+		history = append(history, m.summary)
+	}
+	return append(history, m.messages...), nil
+}
+
+// TokenCount returns the estimated token count of the history as it would
+// currently be sent, including the pinned message and summary.
+func (m *WindowMemory) TokenCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tokenCountLocked()
+}
+
+// Len returns the number of verbatim messages held, excluding the pinned
+// message and summary.
+func (m *WindowMemory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.messages)
+}
+
+// tokenCountLocked estimates the history size. The caller must hold m.mu.
+func (m *WindowMemory) tokenCountLocked() int {
+	total := 0
+	for _, msg := range []*agenkit.Message{m.pinned, m.summary} {
+		if msg != nil {
+			total += llm.EstimateTokens(msg.Content)
+		}
+	}
+	for _, msg := range m.messages {
+		total += llm.EstimateTokens(msg.Content)
+	}
+	return total
+}
+
+// compact folds the messages older than the window into the summary if the
+// history is over budget.
+func (m *WindowMemory) compact(ctx context.Context) error {
+	m.compactMu.Lock()
+	defer m.compactMu.Unlock()
+
+	m.mu.RLock()
+	overflow := len(m.messages) - m.config.WindowSize
+	if overflow <= 0 || m.tokenCountLocked() <= m.config.MaxTokens {
+		m.mu.RUnlock()
+		return nil
+	}
+	older := append([]*agenkit.Message(nil), m.messages[:overflow]...)
+	previous := m.summary
+	m.mu.RUnlock()
+
+	var summary *agenkit.Message
+	if m.config.Summarizer != nil {
+		response, err := m.config.Summarizer.Process(ctx, agenkit.NewMessage("user", buildSummaryPrompt(previous, older)))
+		if err != nil {
+			return fmt.Errorf("window memory: summarizer failed: %w", err)
+		}
+		summary = agenkit.NewMessage("system", "Summary of the earlier conversation:\n"+strings.TrimSpace(response.Content))
+	}
+
+	// Only appends happen outside compaction, so the compacted messages are
+	// still at the front
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append([]*agenkit.Message(nil), m.messages[overflow:]...)
+	if summary != nil {
+		m.summary = summary
+	}
+	return nil
+}
+
+// buildSummaryPrompt asks the summarizer to fold older messages into the
+// running summary.
+func buildSummaryPrompt(previous *agenkit.Message, older []*agenkit.Message) string {
+	var sb strings.Builder
+	sb.WriteString("Summarize the following conversation concisely, preserving facts, decisions, and open questions that later turns may depend on.\n\n")
+	if previous != nil {
+		sb.WriteString(previous.Content)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Conversation:\n")
+	for _, msg := range older {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+	return sb.String()
+}
+
+// ConversationAgent gives an agent a running conversation history.
+//
+// Each call makes the memory available to LLM agents through
+// llm.WithHistory, then records the incoming message and the response.
+type ConversationAgent struct {
+	agent  agenkit.Agent
+	memory *WindowMemory
+}
+
+// Verify that ConversationAgent implements Agent interface.
+var _ agenkit.Agent = (*ConversationAgent)(nil)
+
+// NewConversationAgent wraps agent with the given memory.
+func NewConversationAgent(agent agenkit.Agent, memory *WindowMemory) *ConversationAgent {
+	return &ConversationAgent{agent: agent, memory: memory}
+}
+
+// Name returns the wrapped agent's name.
+func (c *ConversationAgent) Name() string {
+	return c.agent.Name()
+}
+
+// Capabilities returns the wrapped agent's capabilities.
+func (c *ConversationAgent) Capabilities() []string {
+	return c.agent.Capabilities()
+}
+
+// Unwrap returns the underlying agent.
+func (c *ConversationAgent) Unwrap() agenkit.Agent {
+	return c.agent
+}
+
+// Memory returns the conversation memory.
+func (c *ConversationAgent) Memory() *WindowMemory {
+	return c.memory
+}
+
+// Process calls the wrapped agent with the history and records the exchange.
+func (c *ConversationAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	response, err := c.agent.Process(llm.WithHistory(ctx, c.memory), message)
+	if err != nil {
+		return nil, err
+	}
+	c.memory.Add(message, response)
+	return response, nil
+}
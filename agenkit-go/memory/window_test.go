@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// addTurns adds n user messages of roughly tokens tokens each.
+func addTurns(m *WindowMemory, n, tokens int) {
+	for i := 0; i < n; i++ {
+		m.Add(agenkit.NewMessage("user", strings.Repeat("word", tokens)))
+	}
+}
+
+func TestWindowMemoryKeepsEverythingUnderBudget(t *testing.T) {
+	summarizer := testutil.NewMockAgent(t, "summarizer")
+	m := NewWindowMemory(WindowMemoryConfig{WindowSize: 2, MaxTokens: 1000, Summarizer: summarizer})
+	addTurns(m, 5, 10)
+
+	history, err := m.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("Messages failed: %v", err)
+	}
+	if len(history) != 5 {
+		t.Errorf("Expected all 5 messages under budget, got %d", len(history))
+	}
+	if len(summarizer.Calls()) != 0 {
+		t.Error("Expected no summarization under budget")
+	}
+}
+
+func TestWindowMemorySummarizesOlderMessages(t *testing.T) {
+	summarizer := testutil.NewMockAgent(t, "summarizer")
+	summarizer.Expect("", "the user said many words")
+
+	m := NewWindowMemory(WindowMemoryConfig{WindowSize: 2, MaxTokens: 30, Summarizer: summarizer})
+	m.Add(agenkit.NewMessage("system", "You are helpful."))
+	addTurns(m, 5, 10)
+
+	history, err := m.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("Messages failed: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("Expected pinned + summary + 2 recent messages, got %d", len(history))
+	}
+	if history[0].Content != "You are helpful." {
+		t.Errorf("Expected pinned system message first, got '%s'", history[0].Content)
+	}
+	if history[1].Role != "system" || !strings.Contains(history[1].Content, "the user said many words") {
+		t.Errorf("Expected summary system message, got %+v", history[1])
+	}
+	if m.Len() != 2 {
+		t.Errorf("Expected 2 verbatim messages after compaction, got %d", m.Len())
+	}
+	if prompt := summarizer.Calls()[0].Content; strings.Contains(prompt, "You are helpful.") {
+		t.Error("Expected pinned message not to be summarized")
+	}
+	summarizer.AssertExpectationsMet()
+}
+
+func TestWindowMemoryCompactsLazily(t *testing.T) {
+	summarizer := testutil.NewMockAgent(t, "summarizer")
+	summarizer.Expect("", "first summary")
+	summarizer.Expect("", "second summary")
+
+	m := NewWindowMemory(WindowMemoryConfig{WindowSize: 2, MaxTokens: 30, Summarizer: summarizer})
+	addTurns(m, 5, 10)
+	m.Messages(context.Background())
+
+	// Reading again without new overflow must not summarize
+	m.Messages(context.Background())
+	if calls := len(summarizer.Calls()); calls != 1 {
+		t.Fatalf("Expected 1 summarization, got %d", calls)
+	}
+
+	addTurns(m, 3, 10)
+	history, _ := m.Messages(context.Background())
+	if calls := summarizer.Calls(); len(calls) != 2 || !strings.Contains(calls[1].Content, "first summary") {
+		t.Errorf("Expected second summarization to build on the first, got %d calls", len(calls))
+	}
+	if !strings.Contains(history[0].Content, "second summary") {
+		t.Errorf("Expected updated summary, got '%s'", history[0].Content)
+	}
+}
+
+func TestWindowMemoryDropsWithoutSummarizer(t *testing.T) {
+	m := NewWindowMemory(WindowMemoryConfig{WindowSize: 2, MaxTokens: 30})
+	addTurns(m, 5, 10)
+
+	history, err := m.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("Messages failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Expected only the window to remain, got %d messages", len(history))
+	}
+}
+
+func TestWindowMemorySummarizerError(t *testing.T) {
+	summarizer := testutil.NewMockAgent(t, "summarizer")
+	summarizer.Expect("", "").WithError(errors.New("model down"))
+
+	m := NewWindowMemory(WindowMemoryConfig{WindowSize: 1, MaxTokens: 5, Summarizer: summarizer})
+	addTurns(m, 3, 10)
+
+	if _, err := m.Messages(context.Background()); err == nil {
+		t.Fatal("Expected summarizer error")
+	}
+	if m.Len() != 3 {
+		t.Errorf("Expected history to be left intact on failure, got %d messages", m.Len())
+	}
+}
+
+func TestWindowMemoryTokenCount(t *testing.T) {
+	m := NewWindowMemory(WindowMemoryConfig{})
+	m.Add(agenkit.NewMessage("system", strings.Repeat("a", 8)), agenkit.NewMessage("user", strings.Repeat("b", 4)))
+	if n := m.TokenCount(); n != 3 {
+		t.Errorf("Expected 3 estimated tokens, got %d", n)
+	}
+}
+
+func TestConversationAgentSendsHistory(t *testing.T) {
+	provider := testutil.NewMockProvider(t, "mock-model")
+	provider.Expect("", "Hello Ada")
+	provider.Expect("", "Your name is Ada")
+
+	conversation := NewConversationAgent(llm.NewAgent("assistant", provider, llm.AgentConfig{}), NewWindowMemory(WindowMemoryConfig{}))
+	conversation.Process(context.Background(), agenkit.NewMessage("user", "I am Ada"))
+	conversation.Process(context.Background(), agenkit.NewMessage("user", "What is my name?"))
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	second := requests[1].Messages
+	if len(second) != 3 || second[0].Content != "I am Ada" || second[1].Content != "Hello Ada" {
+		t.Errorf("Expected prior exchange before the new message, got %d messages", len(second))
+	}
+	if conversation.Memory().Len() != 4 {
+		t.Errorf("Expected 4 messages recorded, got %d", conversation.Memory().Len())
+	}
+}
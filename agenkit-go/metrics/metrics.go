@@ -0,0 +1,252 @@
+// Package metrics exports agent call metrics to Prometheus.
+//
+// It is kept separate from the core packages so that only programs that
+// want Prometheus depend on its client library. Create the collectors
+// against a registerer, then wrap agents with MetricsMiddleware:
+//
+//	collectors, err := metrics.NewCollectors(registry, metrics.Config{})
+//	if err != nil {
+//		return err
+//	}
+//	agent = middleware.Chain(agent, metrics.MetricsMiddleware(collectors))
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/middleware"
+)
+
+// Config configures the Prometheus collectors.
+type Config struct {
+	// Namespace prefixes every metric name.
+	// Default: "agenkit"
+	Namespace string
+
+	// Buckets are the call duration histogram buckets, in seconds.
+	// Default: prometheus.DefBuckets
+	Buckets []float64
+
+	// Pattern names the pattern label for an agent.
+	// Default: the agent's last capability, which composition patterns and
+	// reasoning techniques set to their own marker (such as "sequential" or
+	// "reflexion"), or "agent" if it has none
+	Pattern func(agent agenkit.Agent) string
+
+	// ExemplarKeys are the request metadata keys (see agenkit.WithMetadata)
+	// attached to duration observations as exemplars, linking latency to
+	// individual traces without adding label cardinality. Keys that are not
+	// valid label names are ignored, and observations whose exemplar would
+	// exceed prometheus.ExemplarMaxRunes are recorded without one.
+	// Default: every metadata key
+	ExemplarKeys []string
+}
+
+// Collectors holds the Prometheus collectors recorded by MetricsMiddleware.
+//
+// The metrics are:
+//
+//   - <namespace>_agent_call_duration_seconds: a histogram of Process
+//     durations, labeled by "agent" and "pattern"
+//   - <namespace>_tokens_total: a counter of tokens, labeled by "model" and
+//     "direction" ("input" or "output")
+//   - <namespace>_errors_total: a counter of failed calls, labeled by
+//     "agent" and "error_type"
+type Collectors struct {
+	Duration *prometheus.HistogramVec
+	Tokens   *prometheus.CounterVec
+	Errors   *prometheus.CounterVec
+
+	pattern      func(agent agenkit.Agent) string
+	exemplarKeys []string
+}
+
+// NewCollectors creates the collectors and registers them with registerer.
+// A nil registerer uses prometheus.DefaultRegisterer.
+func NewCollectors(registerer prometheus.Registerer, config Config) (*Collectors, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	if config.Namespace == "" {
+		config.Namespace = "agenkit"
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = prometheus.DefBuckets
+	}
+	if config.Pattern == nil {
+		config.Pattern = defaultPattern
+	}
+
+	c := &Collectors{
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "agent_call_duration_seconds",
+			Help:      "Duration of agent Process calls.",
+			Buckets:   config.Buckets,
+		}, []string{"agent", "pattern"}),
+		Tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "tokens_total",
+			Help:      "Tokens used by model calls.",
+		}, []string{"model", "direction"}),
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "errors_total",
+			Help:      "Failed agent Process calls.",
+		}, []string{"agent", "error_type"}),
+		pattern:      config.Pattern,
+		exemplarKeys: config.ExemplarKeys,
+	}
+
+	for _, collector := range []prometheus.Collector{c.Duration, c.Tokens, c.Errors} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("failed to register collector: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// MetricsMiddleware records each call's duration and errors, and the
+// token usage reported on the response.
+//
+// Token usage is read from the "model" and "usage" metadata that
+// llm.Agent sets on its replies, so only the response of the wrapped agent
+// is counted; wrap each LLM agent to count the tokens of every call inside
+// a composite.
+func MetricsMiddleware(collectors *Collectors) middleware.AgentMiddleware {
+	return func(agent agenkit.Agent) agenkit.Agent {
+		name := agent.Name()
+		pattern := collectors.pattern(agent)
+		return middleware.Wrap(agent, func(ctx context.Context, message *agenkit.Message, next middleware.ProcessFunc) (*agenkit.Message, error) {
+			start := time.Now()
+			response, err := next(ctx, message)
+			collectors.observeDuration(ctx, name, pattern, time.Since(start))
+
+			if err != nil {
+				collectors.Errors.WithLabelValues(name, ErrorType(err)).Inc()
+				return response, err
+			}
+			collectors.recordUsage(response)
+			return response, nil
+		})
+	}
+}
+
+// observeDuration records a call's duration, with the context's metadata as
+// an exemplar when there is any.
+func (c *Collectors) observeDuration(ctx context.Context, name, pattern string, duration time.Duration) {
+	observer := c.Duration.WithLabelValues(name, pattern)
+	exemplarObserver, ok := observer.(prometheus.ExemplarObserver)
+	if labels := c.exemplar(ctx); ok && labels != nil {
+		exemplarObserver.ObserveWithExemplar(duration.Seconds(), labels)
+		return
+	}
+	observer.Observe(duration.Seconds())
+}
+
+// exemplar returns the exemplar labels for the context's metadata, or nil
+// if there are none or they do not fit in an exemplar.
+func (c *Collectors) exemplar(ctx context.Context) prometheus.Labels {
+	metadata := agenkit.MetadataFrom(ctx)
+	if len(metadata) == 0 {
+		return nil
+	}
+	if c.exemplarKeys != nil {
+		selected := make(map[string]string, len(c.exemplarKeys))
+		for _, key := range c.exemplarKeys {
+			if value, ok := metadata[key]; ok {
+				selected[key] = value
+			}
+		}
+		metadata = selected
+	}
+
+	labels := make(prometheus.Labels, len(metadata))
+	runes := 0
+	for key, value := range metadata {
+		if !validLabelName(key) || !utf8.ValidString(value) {
+			continue
+		}
+		labels[key] = value
+		runes += utf8.RuneCountInString(key) + utf8.RuneCountInString(value)
+	}
+	if len(labels) == 0 || runes > prometheus.ExemplarMaxRunes {
+		return nil
+	}
+	return labels
+}
+
+// validLabelName reports whether name is a valid Prometheus label name.
+func validLabelName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// recordUsage counts the token usage carried by a response.
+func (c *Collectors) recordUsage(response *agenkit.Message) {
+	if response == nil || response.Metadata == nil {
+		return
+	}
+	usage, ok := response.Metadata["usage"].(llm.Usage)
+	if !ok {
+		return
+	}
+	model, _ := response.Metadata["model"].(string)
+	if model == "" {
+		model = "unknown"
+	}
+	c.Tokens.WithLabelValues(model, "input").Add(float64(usage.InputTokens))
+	c.Tokens.WithLabelValues(model, "output").Add(float64(usage.OutputTokens))
+}
+
+// ErrorType classifies an error for the error_type label: "timeout",
+// "cancelled", "rate_limit", "context_length", "provider", "tool" or
+// "other".
+func ErrorType(err error) string {
+	var rateLimit *agenkit.RateLimitError
+	var contextLength *agenkit.ContextLengthError
+	var provider *agenkit.ProviderError
+	var tool *agenkit.ToolError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.As(err, &rateLimit):
+		return "rate_limit"
+	case errors.As(err, &contextLength), errors.Is(err, llm.ErrContextWindowExceeded):
+		return "context_length"
+	case errors.As(err, &provider):
+		return "provider"
+	case errors.As(err, &tool):
+		return "tool"
+	default:
+		return "other"
+	}
+}
+
+// defaultPattern labels an agent with its last capability.
+func defaultPattern(agent agenkit.Agent) string {
+	caps := agent.Capabilities()
+	if len(caps) == 0 {
+		return "agent"
+	}
+	return caps[len(caps)-1]
+}
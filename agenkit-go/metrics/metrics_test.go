@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/composition"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+func TestMetricsMiddlewareRecordsDurationAndTokens(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collectors, err := NewCollectors(registry, Config{})
+	if err != nil {
+		t.Fatalf("NewCollectors failed: %v", err)
+	}
+
+	provider := testutil.NewMockProvider(t, "test-model")
+	provider.Expect("", "hi")
+	agent := MetricsMiddleware(collectors)(llm.NewAgent("chat", provider, llm.AgentConfig{}))
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hello there")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if count := promtestutil.CollectAndCount(collectors.Duration); count != 1 {
+		t.Errorf("Expected 1 duration series, got %d", count)
+	}
+	labels := map[string]string{"agent": "chat", "pattern": "llm"}
+	if count := sampleCount(t, registry, "agenkit_agent_call_duration_seconds", labels); count != 1 {
+		t.Errorf("Expected 1 observation for chat/llm, got %d", count)
+	}
+
+	input := promtestutil.ToFloat64(collectors.Tokens.WithLabelValues("test-model", "input"))
+	output := promtestutil.ToFloat64(collectors.Tokens.WithLabelValues("test-model", "output"))
+	if input <= 0 || output <= 0 {
+		t.Errorf("Expected input and output tokens to be counted, got %v and %v", input, output)
+	}
+}
+
+func TestMetricsMiddlewarePatternLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collectors, _ := NewCollectors(registry, Config{})
+
+	inner := testutil.NewMockAgent(t, "step")
+	inner.Expect("", "done")
+	seq, _ := composition.NewSequentialAgent("pipeline", inner)
+	agent := MetricsMiddleware(collectors)(seq)
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "go")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	labels := map[string]string{"agent": "pipeline", "pattern": "sequential"}
+	if count := sampleCount(t, registry, "agenkit_agent_call_duration_seconds", labels); count != 1 {
+		t.Errorf("Expected observation labeled pipeline/sequential, got %d", count)
+	}
+}
+
+func TestMetricsMiddlewareCountsErrorsByType(t *testing.T) {
+	collectors, _ := NewCollectors(prometheus.NewRegistry(), Config{Namespace: "test"})
+
+	agent := testutil.NewMockAgent(t, "flaky")
+	agent.Expect("", "").WithError(&agenkit.RateLimitError{Provider: "p", RetryAfter: time.Second})
+	agent.Expect("", "").WithError(errors.New("boom"))
+	wrapped := MetricsMiddleware(collectors)(agent)
+
+	for i := 0; i < 2; i++ {
+		if _, err := wrapped.Process(context.Background(), agenkit.NewMessage("user", "x")); err == nil {
+			t.Fatal("Expected error to be passed through")
+		}
+	}
+
+	if v := promtestutil.ToFloat64(collectors.Errors.WithLabelValues("flaky", "rate_limit")); v != 1 {
+		t.Errorf("Expected 1 rate_limit error, got %v", v)
+	}
+	if v := promtestutil.ToFloat64(collectors.Errors.WithLabelValues("flaky", "other")); v != 1 {
+		t.Errorf("Expected 1 other error, got %v", v)
+	}
+}
+
+func TestMetricsMiddlewareRecordsMetadataExemplars(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collectors, err := NewCollectors(registry, Config{ExemplarKeys: []string{"trace_id"}})
+	if err != nil {
+		t.Fatalf("NewCollectors failed: %v", err)
+	}
+
+	mock := testutil.NewMockAgent(t, "chat")
+	mock.Expect("", "hello")
+	agent := MetricsMiddleware(collectors)(mock)
+	ctx := agenkit.WithMetadata(context.Background(), map[string]string{"trace_id": "t-1", "user_id": "u-7"})
+	agent.Process(ctx, agenkit.NewMessage("user", "hi"))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	var exemplars []map[string]string
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, bucket := range m.GetHistogram().GetBucket() {
+				if exemplar := bucket.GetExemplar(); exemplar != nil {
+					labels := make(map[string]string)
+					for _, label := range exemplar.GetLabel() {
+						labels[label.GetName()] = label.GetValue()
+					}
+					exemplars = append(exemplars, labels)
+				}
+			}
+		}
+	}
+	if len(exemplars) != 1 || exemplars[0]["trace_id"] != "t-1" || len(exemplars[0]) != 1 {
+		t.Errorf("Expected one exemplar with only the trace ID, got %v", exemplars)
+	}
+}
+
+func TestNewCollectorsIsolatedRegistries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := NewCollectors(registry, Config{}); err != nil {
+		t.Fatalf("NewCollectors failed: %v", err)
+	}
+	if _, err := NewCollectors(registry, Config{}); err == nil {
+		t.Error("Expected duplicate registration on the same registry to fail")
+	}
+	if _, err := NewCollectors(prometheus.NewRegistry(), Config{}); err != nil {
+		t.Errorf("Expected a separate registry to accept the collectors, got %v", err)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), "timeout"},
+		{context.Canceled, "cancelled"},
+		{&agenkit.ContextLengthError{Provider: "p"}, "context_length"},
+		{fmt.Errorf("fit: %w", llm.ErrContextWindowExceeded), "context_length"},
+		{&agenkit.ProviderError{Provider: "p", StatusCode: 500}, "provider"},
+		{&agenkit.ToolError{ToolName: "search", Err: errors.New("bad input")}, "tool"},
+		{errors.New("other"), "other"},
+	}
+	for _, tc := range cases {
+		if got := ErrorType(tc.err); got != tc.want {
+			t.Errorf("Expected %s for %v, got %s", tc.want, tc.err, got)
+		}
+	}
+}
+
+// sampleCount returns the number of observations in the histogram family
+// name whose labels match labels.
+func sampleCount(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) uint64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// AdaptiveTimeoutConfig configures an AdaptiveTimeoutDecorator.
+type AdaptiveTimeoutConfig struct {
+	// Percentile is the quantile of recent durations, in (0, 1], that the
+	// timeout is based on.
+	// Default: 0.95
+	Percentile float64
+
+	// Multiplier scales the percentile to give slower calls headroom.
+	// Default: 1.5
+	Multiplier float64
+
+	// Floor is the shortest timeout allowed.
+	// Default: 0 (no floor)
+	Floor time.Duration
+
+	// Ceiling is the longest timeout allowed.
+	// Default: 2m
+	Ceiling time.Duration
+
+	// DefaultTimeout applies until MinSamples durations are recorded.
+	// Default: 30s
+	DefaultTimeout time.Duration
+
+	// WindowSize is the number of recent durations kept.
+	// Default: 100
+	WindowSize int
+
+	// MinSamples is the number of durations needed before the timeout
+	// adapts.
+	// Default: 10
+	MinSamples int
+}
+
+// AdaptiveTimeoutStats is a snapshot of an AdaptiveTimeoutDecorator.
+type AdaptiveTimeoutStats struct {
+	// CurrentTimeout is the timeout the next call gets.
+	CurrentTimeout time.Duration
+
+	// Samples is the number of durations in the window.
+	Samples int
+
+	// Timeouts is the number of calls that timed out.
+	Timeouts int64
+}
+
+// AdaptiveTimeoutDecorator limits each call to a timeout that follows the
+// agent's recent latency: a percentile of the durations of the last
+// WindowSize successful calls, times Multiplier, kept within Floor and
+// Ceiling. Until MinSamples calls have succeeded, DefaultTimeout applies.
+//
+// Calls that time out are counted in Stats but add no duration, since the
+// time they ran says only that they hit the timeout; calls that fail
+// otherwise are not recorded either. As in TimeoutDecorator, a timed-out
+// call returns a *TimeoutError, and a sooner caller deadline still wins.
+type AdaptiveTimeoutDecorator struct {
+	agent  agenkit.Agent
+	config AdaptiveTimeoutConfig
+
+	mu        sync.Mutex
+	durations []time.Duration // ring of recent durations
+	next      int
+	timeouts  int64
+}
+
+// Verify that AdaptiveTimeoutDecorator implements Agent interface.
+var _ agenkit.Agent = (*AdaptiveTimeoutDecorator)(nil)
+
+// NewAdaptiveTimeoutDecorator creates a new adaptive timeout decorator.
+func NewAdaptiveTimeoutDecorator(agent agenkit.Agent, config AdaptiveTimeoutConfig) *AdaptiveTimeoutDecorator {
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = 0.95
+	}
+	if config.Multiplier <= 0 {
+		config.Multiplier = 1.5
+	}
+	if config.Ceiling <= 0 {
+		config.Ceiling = 2 * time.Minute
+	}
+	if config.DefaultTimeout <= 0 {
+		config.DefaultTimeout = 30 * time.Second
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = 100
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 10
+	}
+	if config.MinSamples > config.WindowSize {
+		config.MinSamples = config.WindowSize
+	}
+
+	return &AdaptiveTimeoutDecorator{
+		agent:     agent,
+		config:    config,
+		durations: make([]time.Duration, 0, config.WindowSize),
+	}
+}
+
+// Name returns the name of the underlying agent.
+func (a *AdaptiveTimeoutDecorator) Name() string {
+	return a.agent.Name()
+}
+
+// Capabilities returns the capabilities of the underlying agent.
+func (a *AdaptiveTimeoutDecorator) Capabilities() []string {
+	return a.agent.Capabilities()
+}
+
+// Unwrap returns the underlying agent.
+func (a *AdaptiveTimeoutDecorator) Unwrap() agenkit.Agent {
+	return a.agent
+}
+
+// CurrentTimeout returns the timeout the next call gets.
+func (a *AdaptiveTimeoutDecorator) CurrentTimeout() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.currentTimeoutLocked()
+}
+
+// Stats returns a snapshot of the decorator's state.
+func (a *AdaptiveTimeoutDecorator) Stats() AdaptiveTimeoutStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return AdaptiveTimeoutStats{
+		CurrentTimeout: a.currentTimeoutLocked(),
+		Samples:        len(a.durations),
+		Timeouts:       a.timeouts,
+	}
+}
+
+// currentTimeoutLocked computes the timeout. The caller must hold a.mu.
+func (a *AdaptiveTimeoutDecorator) currentTimeoutLocked() time.Duration {
+	if len(a.durations) < a.config.MinSamples {
+		return a.config.DefaultTimeout
+	}
+	sorted := append([]time.Duration(nil), a.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// Nearest-rank percentile
+	rank := int(math.Ceil(a.config.Percentile*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	timeout := time.Duration(float64(sorted[rank]) * a.config.Multiplier)
+	if timeout < a.config.Floor {
+		timeout = a.config.Floor
+	}
+	if timeout > a.config.Ceiling {
+		timeout = a.config.Ceiling
+	}
+	return timeout
+}
+
+// record adds the duration of a successful call to the window.
+func (a *AdaptiveTimeoutDecorator) record(duration time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.durations) < a.config.WindowSize {
+		a.durations = append(a.durations, duration)
+		return
+	}
+	a.durations[a.next] = duration
+	a.next = (a.next + 1) % a.config.WindowSize
+}
+
+// Process runs the agent under the current timeout.
+func (a *AdaptiveTimeoutDecorator) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	start := time.Now()
+	timeout := a.CurrentTimeout()
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	allowed := timeout
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(start) < allowed {
+		allowed = deadline.Sub(start)
+	}
+
+	type result struct {
+		msg *agenkit.Message
+		err error
+	}
+	done := make(chan result, 1)
+	// Run in a goroutine so agents ignoring their context still time out
+	go func() {
+		msg, err := a.agent.Process(timeoutCtx, message)
+		done <- result{msg, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-timeoutCtx.Done():
+		res.err = timeoutCtx.Err()
+	}
+
+	switch {
+	case res.err == nil:
+		a.record(time.Since(start))
+		return res.msg, nil
+	case ctx.Err() == context.Canceled:
+		// The caller gave up; this is not a timeout
+		return nil, ctx.Err()
+	case timeoutCtx.Err() == context.DeadlineExceeded:
+		if ctx.Err() == nil {
+			a.mu.Lock()
+			a.timeouts++
+			a.mu.Unlock()
+		}
+		return nil, &TimeoutError{AgentName: a.Name(), Timeout: allowed}
+	default:
+		return nil, res.err
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestAdaptiveTimeoutFollowsLatency(t *testing.T) {
+	agent := NewFastAgent(0)
+	adaptive := NewAdaptiveTimeoutDecorator(agent, AdaptiveTimeoutConfig{
+		Percentile:     0.9,
+		Multiplier:     2,
+		DefaultTimeout: time.Second,
+		MinSamples:     3,
+	})
+	if got := adaptive.CurrentTimeout(); got != time.Second {
+		t.Errorf("Expected the default before enough samples, got %v", got)
+	}
+
+	for _, d := range []time.Duration{10, 20, 30, 40} {
+		adaptive.record(d * time.Millisecond)
+	}
+	// p90 of 10..40ms is 40ms, doubled
+	if got := adaptive.CurrentTimeout(); got != 80*time.Millisecond {
+		t.Errorf("Expected 80ms, got %v", got)
+	}
+
+	clamped := NewAdaptiveTimeoutDecorator(agent, AdaptiveTimeoutConfig{
+		MinSamples: 1,
+		Floor:      50 * time.Millisecond,
+		Ceiling:    time.Second,
+	})
+	clamped.record(time.Millisecond)
+	if got := clamped.CurrentTimeout(); got != 50*time.Millisecond {
+		t.Errorf("Expected the floor, got %v", got)
+	}
+	clamped.record(time.Hour)
+	if got := clamped.CurrentTimeout(); got != time.Second {
+		t.Errorf("Expected the ceiling, got %v", got)
+	}
+}
+
+func TestAdaptiveTimeoutWindowDropsOldSamples(t *testing.T) {
+	adaptive := NewAdaptiveTimeoutDecorator(NewFastAgent(0), AdaptiveTimeoutConfig{
+		Percentile: 1,
+		Multiplier: 1,
+		WindowSize: 2,
+		MinSamples: 2,
+	})
+	for _, d := range []time.Duration{90, 10, 20} {
+		adaptive.record(d * time.Millisecond)
+	}
+	if got := adaptive.CurrentTimeout(); got != 20*time.Millisecond {
+		t.Errorf("Expected the slow sample to leave the window, got %v", got)
+	}
+}
+
+func TestAdaptiveTimeoutRecordsTimeoutsSeparately(t *testing.T) {
+	agent := NewSlowAgent(200 * time.Millisecond)
+	adaptive := NewAdaptiveTimeoutDecorator(agent, AdaptiveTimeoutConfig{DefaultTimeout: 20 * time.Millisecond})
+
+	_, err := adaptive.Process(context.Background(), &agenkit.Message{Role: "user", Content: "x"})
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Timeout != 20*time.Millisecond {
+		t.Fatalf("Expected a TimeoutError after 20ms, got %v", err)
+	}
+	stats := adaptive.Stats()
+	if stats.Timeouts != 1 || stats.Samples != 0 || stats.CurrentTimeout != 20*time.Millisecond {
+		t.Errorf("Expected one timeout and no samples, got %+v", stats)
+	}
+
+	fast := NewAdaptiveTimeoutDecorator(NewFastAgent(time.Millisecond), AdaptiveTimeoutConfig{})
+	if _, err := fast.Process(context.Background(), &agenkit.Message{Role: "user", Content: "x"}); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if stats := fast.Stats(); stats.Samples != 1 || stats.Timeouts != 0 {
+		t.Errorf("Expected a successful call to add a sample, got %+v", stats)
+	}
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrDenied is matched by errors returned when an approver rejects an action.
+var ErrDenied = errors.New("action denied")
+
+// DenialError is returned when an approver rejects an action.
+type DenialError struct {
+	// Reason is the approver's explanation, if any.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *DenialError) Error() string {
+	if e.Reason == "" {
+		return ErrDenied.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrDenied, e.Reason)
+}
+
+// Unwrap returns ErrDenied so callers can use errors.Is.
+func (e *DenialError) Unwrap() error {
+	return ErrDenied
+}
+
+// Deny returns an error an Approver can return alongside false to give a
+// reason for the denial.
+func Deny(reason string) error {
+	return &DenialError{Reason: reason}
+}
+
+// ApprovalRequest describes an action awaiting approval.
+type ApprovalRequest struct {
+	// AgentName is the agent (or tool) whose action needs approval.
+	AgentName string
+
+	// Action is "process" for agent calls or "tool_call" for tool executions.
+	Action string
+
+	// Message is the message about to be processed, for "process" actions.
+	Message *agenkit.Message
+
+	// ToolCall is the tool invocation about to run, for "tool_call" actions.
+	ToolCall *agenkit.ToolCall
+}
+
+// Approver decides whether an action may proceed.
+//
+// Returning false denies the action; return Deny(reason) as the error to
+// explain why. Any other error is treated as a failure to decide.
+// Approve should honor ctx, as a human may take a while to respond.
+type Approver interface {
+	Approve(ctx context.Context, req ApprovalRequest) (bool, error)
+}
+
+// ApproverFunc adapts a function to the Approver interface.
+type ApproverFunc func(ctx context.Context, req ApprovalRequest) (bool, error)
+
+// Approve calls f.
+func (f ApproverFunc) Approve(ctx context.Context, req ApprovalRequest) (bool, error) {
+	return f(ctx, req)
+}
+
+// ApprovalConfig configures approval gating.
+type ApprovalConfig struct {
+	// Approver decides on actions that are not auto-approved. Required.
+	Approver Approver
+
+	// AutoApprove, if set, approves low-risk actions without consulting
+	// the Approver.
+	AutoApprove func(req ApprovalRequest) bool
+}
+
+// ApprovalGate wraps an agent so each call must be approved before it is
+// forwarded.
+//
+// Approved responses carry metadata "approval" set to "auto" or
+// "approved". Denials return a *DenialError matching ErrDenied.
+type ApprovalGate struct {
+	agent  agenkit.Agent
+	config ApprovalConfig
+}
+
+// Verify that ApprovalGate implements Agent interface.
+var _ agenkit.Agent = (*ApprovalGate)(nil)
+
+// NewApprovalGate creates a new approval gate around agent.
+func NewApprovalGate(agent agenkit.Agent, config ApprovalConfig) (*ApprovalGate, error) {
+	if agent == nil {
+		return nil, fmt.Errorf("approval gate requires an agent")
+	}
+	if config.Approver == nil {
+		return nil, fmt.Errorf("approval gate requires an approver")
+	}
+	return &ApprovalGate{agent: agent, config: config}, nil
+}
+
+// Name returns the name of the underlying agent.
+func (g *ApprovalGate) Name() string {
+	return g.agent.Name()
+}
+
+// Capabilities returns the capabilities of the underlying agent.
+func (g *ApprovalGate) Capabilities() []string {
+	return g.agent.Capabilities()
+}
+
+// Unwrap returns the underlying agent.
+func (g *ApprovalGate) Unwrap() agenkit.Agent {
+	return g.agent
+}
+
+// Process waits for approval, then forwards the message to the agent.
+func (g *ApprovalGate) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	decision, err := awaitApproval(ctx, g.config, ApprovalRequest{
+		AgentName: g.agent.Name(),
+		Action:    "process",
+		Message:   message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: %w", g.agent.Name(), err)
+	}
+
+	response, err := g.agent.Process(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
+	}
+	response.Metadata["approval"] = decision
+	return response, nil
+}
+
+// approvalTool wraps a tool so each execution must be approved.
+type approvalTool struct {
+	tool   agenkit.Tool
+	config ApprovalConfig
+}
+
+// NewApprovalTool wraps tool so each execution must be approved.
+//
+// A denial is returned as a failed ToolResult rather than an error, so the
+// calling agent can observe it and react; the result's metadata holds
+// "approval_denied" and "approval_reason".
+func NewApprovalTool(tool agenkit.Tool, config ApprovalConfig) (agenkit.Tool, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("approval tool requires a tool")
+	}
+	if config.Approver == nil {
+		return nil, fmt.Errorf("approval tool requires an approver")
+	}
+	return &approvalTool{tool: tool, config: config}, nil
+}
+
+// Name returns the name of the underlying tool.
+func (a *approvalTool) Name() string {
+	return a.tool.Name()
+}
+
+// Description returns the description of the underlying tool.
+func (a *approvalTool) Description() string {
+	return a.tool.Description()
+}
+
+// Execute waits for approval, then runs the tool.
+func (a *approvalTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	_, err := awaitApproval(ctx, a.config, ApprovalRequest{
+		AgentName: a.tool.Name(),
+		Action:    "tool_call",
+		ToolCall:  &agenkit.ToolCall{ToolName: a.tool.Name(), Parameters: params},
+	})
+
+	var denial *DenialError
+	if errors.As(err, &denial) {
+		return agenkit.NewToolError(denial.Error()).
+			WithMetadata("approval_denied", true).
+			WithMetadata("approval_reason", denial.Reason), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", a.tool.Name(), err)
+	}
+	return a.tool.Execute(ctx, params)
+}
+
+// awaitApproval blocks until req is approved, denied, or ctx is done.
+// It returns "auto" or "approved" on approval.
+func awaitApproval(ctx context.Context, config ApprovalConfig, req ApprovalRequest) (string, error) {
+	if config.AutoApprove != nil && config.AutoApprove(req) {
+		return "auto", nil
+	}
+
+	type decision struct {
+		approved bool
+		err      error
+	}
+	// Buffered so the approver goroutine can finish even if we stop waiting
+	done := make(chan decision, 1)
+	go func() {
+		approved, err := config.Approver.Approve(ctx, req)
+		done <- decision{approved: approved, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("approval cancelled: %w", ctx.Err())
+	case d := <-done:
+		var denial *DenialError
+		switch {
+		case errors.As(d.err, &denial):
+			return "", denial
+		case d.err != nil:
+			return "", fmt.Errorf("approval failed: %w", d.err)
+		case !d.approved:
+			return "", &DenialError{}
+		}
+		return "approved", nil
+	}
+}
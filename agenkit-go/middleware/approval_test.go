@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// recordingApprover returns a fixed decision and records requests.
+type recordingApprover struct {
+	approved bool
+	err      error
+	requests []ApprovalRequest
+}
+
+func (r *recordingApprover) Approve(ctx context.Context, req ApprovalRequest) (bool, error) {
+	r.requests = append(r.requests, req)
+	return r.approved, r.err
+}
+
+// deleteTool is a sensitive tool for approval tests.
+type deleteTool struct {
+	calls int
+}
+
+func (d *deleteTool) Name() string        { return "delete_file" }
+func (d *deleteTool) Description() string { return "Deletes a file" }
+
+func (d *deleteTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	d.calls++
+	return agenkit.NewToolResult("deleted"), nil
+}
+
+func TestApprovalGateApproved(t *testing.T) {
+	agent := NewTestAgent("echo")
+	approver := &recordingApprover{approved: true}
+	gate, err := NewApprovalGate(agent, ApprovalConfig{Approver: approver})
+	if err != nil {
+		t.Fatalf("Failed to create gate: %v", err)
+	}
+
+	response, err := gate.Process(context.Background(), agenkit.NewMessage("user", "send email"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Metadata["approval"] != "approved" {
+		t.Errorf("Expected approval metadata, got %v", response.Metadata["approval"])
+	}
+	if len(approver.requests) != 1 || approver.requests[0].Message.Content != "send email" {
+		t.Errorf("Expected approver to see the message, got %+v", approver.requests)
+	}
+}
+
+func TestApprovalGateDenied(t *testing.T) {
+	agent := NewTestAgent("echo")
+	gate, _ := NewApprovalGate(agent, ApprovalConfig{
+		Approver: &recordingApprover{approved: false, err: Deny("outside business hours")},
+	})
+
+	_, err := gate.Process(context.Background(), agenkit.NewMessage("user", "send email"))
+	if !errors.Is(err, ErrDenied) {
+		t.Fatalf("Expected ErrDenied, got %v", err)
+	}
+	var denial *DenialError
+	if !errors.As(err, &denial) || denial.Reason != "outside business hours" {
+		t.Errorf("Expected denial reason, got %v", err)
+	}
+	if agent.callCount != 0 {
+		t.Errorf("Expected denied call not to reach the agent, got %d calls", agent.callCount)
+	}
+}
+
+func TestApprovalGateAutoApprove(t *testing.T) {
+	approver := &recordingApprover{approved: false}
+	gate, _ := NewApprovalGate(NewTestAgent("echo"), ApprovalConfig{
+		Approver: approver,
+		AutoApprove: func(req ApprovalRequest) bool {
+			return strings.HasPrefix(req.Message.Content, "read")
+		},
+	})
+
+	response, err := gate.Process(context.Background(), agenkit.NewMessage("user", "read inbox"))
+	if err != nil {
+		t.Fatalf("Expected low-risk action to be auto-approved, got %v", err)
+	}
+	if response.Metadata["approval"] != "auto" {
+		t.Errorf("Expected auto approval metadata, got %v", response.Metadata["approval"])
+	}
+	if len(approver.requests) != 0 {
+		t.Errorf("Expected approver not to be consulted, got %d requests", len(approver.requests))
+	}
+}
+
+func TestApprovalGateApproverError(t *testing.T) {
+	gate, _ := NewApprovalGate(NewTestAgent("echo"), ApprovalConfig{
+		Approver: &recordingApprover{err: errors.New("slack unavailable")},
+	})
+
+	_, err := gate.Process(context.Background(), agenkit.NewMessage("user", "x"))
+	if err == nil || errors.Is(err, ErrDenied) {
+		t.Errorf("Expected approver failure distinct from denial, got %v", err)
+	}
+}
+
+func TestApprovalGateCancelledWhileWaiting(t *testing.T) {
+	gate, _ := NewApprovalGate(NewTestAgent("echo"), ApprovalConfig{
+		// An approver that ignores ctx must not block the gate
+		Approver: ApproverFunc(func(ctx context.Context, req ApprovalRequest) (bool, error) {
+			time.Sleep(time.Second)
+			return true, nil
+		}),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := gate.Process(ctx, agenkit.NewMessage("user", "x"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected gate to stop waiting when the context is done")
+	}
+}
+
+func TestApprovalToolDenied(t *testing.T) {
+	tool := &deleteTool{}
+	approver := &recordingApprover{approved: false, err: Deny("protected path")}
+	gated, _ := NewApprovalTool(tool, ApprovalConfig{Approver: approver})
+
+	result, err := gated.Execute(context.Background(), map[string]interface{}{"path": "/etc"})
+	if err != nil {
+		t.Fatalf("Expected denial as tool result, got error %v", err)
+	}
+	if result.Success || result.Metadata["approval_reason"] != "protected path" {
+		t.Errorf("Expected failed result with reason, got %+v", result)
+	}
+	if tool.calls != 0 {
+		t.Errorf("Expected denied tool not to run, got %d calls", tool.calls)
+	}
+	if call := approver.requests[0].ToolCall; call == nil || call.Parameters["path"] != "/etc" {
+		t.Errorf("Expected approver to see the tool call, got %+v", approver.requests[0])
+	}
+}
+
+func TestApprovalToolApproved(t *testing.T) {
+	tool := &deleteTool{}
+	gated, _ := NewApprovalTool(tool, ApprovalConfig{Approver: &recordingApprover{approved: true}})
+
+	result, err := gated.Execute(context.Background(), nil)
+	if err != nil || !result.Success {
+		t.Fatalf("Expected approved tool to run, got %+v, %v", result, err)
+	}
+	if tool.calls != 1 {
+		t.Errorf("Expected 1 tool call, got %d", tool.calls)
+	}
+}
@@ -108,6 +108,7 @@ func (m *BatchingMetrics) AvgWaitTime() time.Duration {
 
 // batchRequest represents a single request in a batch.
 type batchRequest struct {
+	ctx        context.Context
 	message    *agenkit.Message
 	resultChan chan batchResult
 	enqueuedAt time.Time
@@ -157,6 +158,11 @@ func (d *BatchingDecorator) Capabilities() []string {
 	return d.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (d *BatchingDecorator) Unwrap() agenkit.Agent {
+	return d.agent
+}
+
 // Metrics returns the batching metrics.
 func (d *BatchingDecorator) Metrics() *BatchingMetrics {
 	return d.metrics
@@ -166,6 +172,7 @@ func (d *BatchingDecorator) Metrics() *BatchingMetrics {
 func (d *BatchingDecorator) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
 	// Create batch request with result channel
 	req := &batchRequest{
+		ctx:        ctx,
 		message:    message,
 		resultChan: make(chan batchResult, 1),
 		enqueuedAt: time.Now(),
@@ -264,8 +271,9 @@ func (d *BatchingDecorator) processBatch(batch []*batchRequest) {
 		go func(idx int, request *batchRequest) {
 			defer wg.Done()
 
-			// Process the request
-			msg, err := d.agent.Process(context.Background(), request.message)
+			// Process the request, keeping the caller's context values
+			// but not its cancellation, which belongs to the caller's wait
+			msg, err := d.agent.Process(context.WithoutCancel(request.ctx), request.message)
 			results[idx] = batchResult{message: msg, err: err}
 		}(i, req)
 	}
@@ -236,6 +236,11 @@ func (c *CachingDecorator) Capabilities() []string {
 	return c.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (c *CachingDecorator) Unwrap() agenkit.Agent {
+	return c.agent
+}
+
 // Metrics returns the caching metrics.
 func (c *CachingDecorator) Metrics() *CachingMetrics {
 	return c.metrics
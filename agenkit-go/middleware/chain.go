@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// AgentMiddleware wraps an agent to add behavior around Process.
+type AgentMiddleware func(agenkit.Agent) agenkit.Agent
+
+// Chain wraps an agent with middlewares.
+//
+// The first middleware is the outermost: on the way in, middlewares see the
+// message in the order given; on the way out, they see the response in
+// reverse order, matching typical HTTP middleware semantics.
+//
+// Example:
+//
+//	agent := middleware.Chain(base,
+//		middleware.RecoverMiddleware(),
+//		middleware.LoggingMiddleware(logger),
+//		middleware.TimeoutMiddleware(10*time.Second),
+//	)
+func Chain(agent agenkit.Agent, middlewares ...AgentMiddleware) agenkit.Agent {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		agent = middlewares[i](agent)
+	}
+	return agent
+}
+
+// ProcessFunc is the signature of Agent.Process.
+type ProcessFunc func(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error)
+
+// HookFunc intercepts a Process call. It may inspect or replace the message
+// before calling next, and inspect or replace the response and error after.
+type HookFunc func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error)
+
+// Wrap returns an agent that runs hook around the inner agent's Process.
+// Name and Capabilities are delegated to the inner agent.
+func Wrap(agent agenkit.Agent, hook HookFunc) agenkit.Agent {
+	return &hookedAgent{agent: agent, hook: hook}
+}
+
+// hookedAgent is the agent returned by Wrap.
+type hookedAgent struct {
+	agent agenkit.Agent
+	hook  HookFunc
+}
+
+// Name returns the name of the underlying agent.
+func (h *hookedAgent) Name() string {
+	return h.agent.Name()
+}
+
+// Capabilities returns the capabilities of the underlying agent.
+func (h *hookedAgent) Capabilities() []string {
+	return h.agent.Capabilities()
+}
+
+// Process runs the hook around the underlying agent.
+func (h *hookedAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return h.hook(ctx, message, h.agent.Process)
+}
+
+// Unwrap returns the underlying agent.
+func (h *hookedAgent) Unwrap() agenkit.Agent {
+	return h.agent
+}
+
+// LoggingMiddleware logs each call's start, duration, and outcome. Metadata
+// in the call's context (see agenkit.WithMetadata) is added to every record
+// as a "metadata" group.
+func LoggingMiddleware(logger *slog.Logger) AgentMiddleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+			logger := withMetadata(logger, ctx)
+			start := time.Now()
+			logger.DebugContext(ctx, "agent call started",
+				slog.String("agent", agent.Name()),
+				slog.String("role", message.Role),
+				slog.Int("content_length", len(message.Content)),
+			)
+
+			response, err := next(ctx, message)
+
+			duration := time.Since(start)
+			if err != nil {
+				logger.ErrorContext(ctx, "agent call failed",
+					slog.String("agent", agent.Name()),
+					slog.Duration("duration", duration),
+					slog.String("error", err.Error()),
+				)
+				return nil, err
+			}
+
+			logger.InfoContext(ctx, "agent call completed",
+				slog.String("agent", agent.Name()),
+				slog.Duration("duration", duration),
+			)
+			return response, nil
+		})
+	}
+}
+
+// withMetadata returns logger with the context's metadata attached as a
+// "metadata" group, or logger itself if there is none.
+func withMetadata(logger *slog.Logger, ctx context.Context) *slog.Logger {
+	metadata := agenkit.MetadataFrom(ctx)
+	if len(metadata) == 0 {
+		return logger
+	}
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.String(k, metadata[k])
+	}
+	return logger.With(slog.Group("metadata", attrs...))
+}
+
+// TimeoutMiddleware enforces a maximum duration on each call.
+// See TimeoutDecorator for details.
+func TimeoutMiddleware(timeout time.Duration) AgentMiddleware {
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return NewTimeoutDecorator(agent, TimeoutConfig{Timeout: timeout})
+	}
+}
+
+// PanicError is returned by RecoverMiddleware when an agent panics.
+type PanicError struct {
+	AgentName string
+	Value     interface{}
+	Stack     []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("agent '%s' panicked: %v", e.AgentName, e.Value)
+}
+
+// RecoverMiddleware converts panics in Process into *PanicError values.
+func RecoverMiddleware() AgentMiddleware {
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (response *agenkit.Message, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					response = nil
+					err = &PanicError{
+						AgentName: agent.Name(),
+						Value:     r,
+						Stack:     debug.Stack(),
+					}
+				}
+			}()
+			return next(ctx, message)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// PanickingAgent panics on every call.
+type PanickingAgent struct{}
+
+func (p *PanickingAgent) Name() string {
+	return "panicking-agent"
+}
+
+func (p *PanickingAgent) Capabilities() []string {
+	return []string{}
+}
+
+func (p *PanickingAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	panic("something went very wrong")
+}
+
+// recordingMiddleware appends markers to a shared trace around each call.
+func recordingMiddleware(name string, trace *[]string) AgentMiddleware {
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+			*trace = append(*trace, name+":in")
+			message.Content += "+" + name
+			response, err := next(ctx, message)
+			*trace = append(*trace, name+":out")
+			return response, err
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var trace []string
+	agent := Chain(NewTestAgent("echo"),
+		recordingMiddleware("outer", &trace),
+		recordingMiddleware("inner", &trace),
+	)
+
+	response, err := agent.Process(context.Background(), agenkit.NewMessage("user", "msg"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	expected := "outer:in,inner:in,inner:out,outer:out"
+	if strings.Join(trace, ",") != expected {
+		t.Errorf("Expected order %s, got %s", expected, strings.Join(trace, ","))
+	}
+	if response.Content != "echo: msg+outer+inner" {
+		t.Errorf("Expected middlewares to mutate inbound message in order, got '%s'", response.Content)
+	}
+}
+
+func TestChainNoMiddlewares(t *testing.T) {
+	base := NewTestAgent("echo")
+	if Chain(base) != agenkit.Agent(base) {
+		t.Error("Expected Chain with no middlewares to return the agent unchanged")
+	}
+}
+
+func TestWrapCanRewriteResponse(t *testing.T) {
+	agent := Wrap(NewTestAgent("echo"), func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+		response, err := next(ctx, message)
+		if err != nil {
+			return nil, err
+		}
+		response.Content = strings.ToUpper(response.Content)
+		return response, nil
+	})
+
+	response, _ := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if response.Content != "ECHO: HI" {
+		t.Errorf("Expected rewritten response, got '%s'", response.Content)
+	}
+	if agent.Name() != "test" {
+		t.Errorf("Expected wrapped agent to keep inner name, got '%s'", agent.Name())
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	agent := Chain(&PanickingAgent{}, RecoverMiddleware())
+
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Expected PanicError, got %v", err)
+	}
+	if panicErr.AgentName != "panicking-agent" || panicErr.Value != "something went very wrong" {
+		t.Errorf("Unexpected panic error: %+v", panicErr)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Error("Expected stack trace to be captured")
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	agent := Chain(NewTestAgent("echo"), LoggingMiddleware(logger))
+	_, _ = agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+
+	output := buf.String()
+	if !strings.Contains(output, "agent call started") || !strings.Contains(output, "agent call completed") {
+		t.Errorf("Expected start and completion logs, got: %s", output)
+	}
+
+	buf.Reset()
+	failing := Chain(&FailingAgent{failCount: 1, failureMsg: "nope"}, LoggingMiddleware(logger))
+	_, _ = failing.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if !strings.Contains(buf.String(), "agent call failed") || !strings.Contains(buf.String(), "nope") {
+		t.Errorf("Expected failure log with error, got: %s", buf.String())
+	}
+}
+
+func TestLoggingMiddlewareIncludesMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	agent := Chain(NewTestAgent("echo"), LoggingMiddleware(logger))
+	ctx := agenkit.WithMetadata(context.Background(), map[string]string{"trace_id": "t-1", "user_id": "u-7"})
+	_, _ = agent.Process(ctx, agenkit.NewMessage("user", "hi"))
+
+	if !strings.Contains(buf.String(), "metadata.trace_id=t-1 metadata.user_id=u-7") {
+		t.Errorf("Expected the metadata in the log record, got: %s", buf.String())
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	agent := Chain(&DelayAgent{delay: 200 * time.Millisecond}, TimeoutMiddleware(20*time.Millisecond))
+
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected TimeoutError, got %v", err)
+	}
+}
@@ -43,7 +43,8 @@ type CircuitBreakerConfig struct {
 	// Default: 5
 	FailureThreshold int
 
-	// RecoveryTimeout is the duration before attempting recovery from open state.
+	// RecoveryTimeout is the cooldown period the circuit stays open before
+	// letting a probe request through in half-open state.
 	// Default: 60s
 	RecoveryTimeout time.Duration
 
@@ -54,6 +55,10 @@ type CircuitBreakerConfig struct {
 	// Timeout is the request timeout duration.
 	// Default: 30s
 	Timeout time.Duration
+
+	// OnStateChange, if set, is called after every state transition.
+	// It runs outside the breaker's lock, so it may call State.
+	OnStateChange func(from, to CircuitState)
 }
 
 // DefaultCircuitBreakerConfig returns a circuit breaker config with sensible defaults.
@@ -86,6 +91,10 @@ func NewCircuitBreakerMetrics() *CircuitBreakerMetrics {
 	}
 }
 
+// ErrCircuitOpen is matched by errors returned when the circuit breaker
+// rejects a request without calling the agent.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
 // CircuitBreakerError is returned when the circuit breaker is open.
 type CircuitBreakerError struct {
 	FailureCount int
@@ -96,6 +105,11 @@ func (e *CircuitBreakerError) Error() string {
 	return fmt.Sprintf("circuit breaker is OPEN (failed %d times)", e.FailureCount)
 }
 
+// Unwrap returns ErrCircuitOpen so callers can use errors.Is.
+func (e *CircuitBreakerError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
 // CircuitBreakerDecorator wraps an agent with circuit breaker protection.
 //
 // The circuit breaker prevents cascading failures by failing fast when
@@ -110,15 +124,25 @@ func (e *CircuitBreakerError) Error() string {
 // - OPEN -> HALF_OPEN: After RecoveryTimeout seconds
 // - HALF_OPEN -> CLOSED: After SuccessThreshold consecutive successes
 // - HALF_OPEN -> OPEN: On any failure
+//
+// In HALF_OPEN only one probe request is in flight at a time; concurrent
+// requests are rejected until the probe completes.
 type CircuitBreakerDecorator struct {
-	agent            agenkit.Agent
-	config           CircuitBreakerConfig
-	mu               sync.Mutex
-	state            CircuitState
-	failureCount     int
-	successCount     int
-	lastFailureTime  *time.Time
-	metrics          *CircuitBreakerMetrics
+	agent           agenkit.Agent
+	config          CircuitBreakerConfig
+	mu              sync.Mutex
+	state           CircuitState
+	failureCount    int
+	successCount    int
+	lastFailureTime *time.Time
+	probeInFlight   bool
+	transitions     []stateTransition // pending OnStateChange notifications
+	metrics         *CircuitBreakerMetrics
+}
+
+// stateTransition records a state change awaiting notification.
+type stateTransition struct {
+	from, to CircuitState
 }
 
 // Verify that CircuitBreakerDecorator implements Agent interface.
@@ -158,6 +182,11 @@ func (c *CircuitBreakerDecorator) Capabilities() []string {
 	return c.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (c *CircuitBreakerDecorator) Unwrap() agenkit.Agent {
+	return c.agent
+}
+
 // State returns the current circuit breaker state.
 func (c *CircuitBreakerDecorator) State() CircuitState {
 	c.mu.Lock()
@@ -184,6 +213,21 @@ func (c *CircuitBreakerDecorator) changeState(newState CircuitState) {
 		transition := fmt.Sprintf("%s->%s", oldState, newState)
 		c.metrics.StateChanges[transition]++
 		c.metrics.mu.Unlock()
+
+		if c.config.OnStateChange != nil {
+			c.transitions = append(c.transitions, stateTransition{from: oldState, to: newState})
+		}
+	}
+}
+
+// unlock releases c.mu and then delivers pending state change notifications.
+func (c *CircuitBreakerDecorator) unlock() {
+	transitions := c.transitions
+	c.transitions = nil
+	c.mu.Unlock()
+
+	for _, t := range transitions {
+		c.config.OnStateChange(t.from, t.to)
 	}
 }
 
@@ -248,22 +292,27 @@ func (c *CircuitBreakerDecorator) Process(ctx context.Context, message *agenkit.
 	c.metrics.mu.Unlock()
 
 	// Check if circuit is open
-	if c.state == StateOpen {
-		// Check if we should attempt recovery
-		if c.shouldAttemptReset() {
-			c.changeState(StateHalfOpen)
-			c.successCount = 0
-		} else {
-			// Still open, fail fast
-			c.metrics.mu.Lock()
-			c.metrics.RejectedRequests++
-			c.metrics.mu.Unlock()
-			c.mu.Unlock()
-			return nil, &CircuitBreakerError{FailureCount: c.failureCount}
-		}
+	if c.state == StateOpen && c.shouldAttemptReset() {
+		c.changeState(StateHalfOpen)
+		c.successCount = 0
 	}
 
-	c.mu.Unlock()
+	// Fail fast while open, or while a half-open probe is already running
+	if c.state == StateOpen || (c.state == StateHalfOpen && c.probeInFlight) {
+		c.metrics.mu.Lock()
+		c.metrics.RejectedRequests++
+		c.metrics.mu.Unlock()
+		failureCount := c.failureCount
+		c.unlock()
+		return nil, &CircuitBreakerError{FailureCount: failureCount}
+	}
+
+	probe := c.state == StateHalfOpen
+	if probe {
+		c.probeInFlight = true
+	}
+
+	c.unlock()
 
 	// Create timeout context
 	timeoutCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
@@ -273,7 +322,11 @@ func (c *CircuitBreakerDecorator) Process(ctx context.Context, message *agenkit.
 	response, err := c.agent.Process(timeoutCtx, message)
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+
+	if probe {
+		c.probeInFlight = false
+	}
 
 	if err != nil {
 		// Check if it's a timeout error
@@ -409,3 +409,129 @@ func TestCircuitBreakerMetrics(t *testing.T) {
 		t.Error("Expected LastStateChange to be set")
 	}
 }
+
+// TestCircuitBreakerErrCircuitOpen tests that rejections match ErrCircuitOpen.
+func TestCircuitBreakerErrCircuitOpen(t *testing.T) {
+	ctx := context.Background()
+	agent := &UnreliableAgent{failurePattern: []bool{true}}
+
+	cb := NewCircuitBreakerDecorator(agent, CircuitBreakerConfig{
+		FailureThreshold: 1,
+		RecoveryTimeout:  1 * time.Second,
+	})
+
+	cb.Process(ctx, agenkit.NewMessage("user", "test"))
+
+	_, err := cb.Process(ctx, agenkit.NewMessage("user", "test"))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if agent.attempts != 1 {
+		t.Errorf("Expected open circuit not to call agent, got %d attempts", agent.attempts)
+	}
+}
+
+// gatedAgent blocks each call until released.
+type gatedAgent struct {
+	started chan struct{}
+	release chan struct{}
+	fail    bool
+}
+
+func (g *gatedAgent) Name() string {
+	return "gated-agent"
+}
+
+func (g *gatedAgent) Capabilities() []string {
+	return []string{}
+}
+
+func (g *gatedAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	if g.fail {
+		return nil, errors.New("simulated failure")
+	}
+	g.started <- struct{}{}
+	<-g.release
+	return agenkit.NewMessage("agent", "success"), nil
+}
+
+// TestCircuitBreakerSingleProbe tests that half-open admits one request at a time.
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	ctx := context.Background()
+	agent := &gatedAgent{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+		fail:    true,
+	}
+
+	cb := NewCircuitBreakerDecorator(agent, CircuitBreakerConfig{
+		FailureThreshold: 1,
+		RecoveryTimeout:  20 * time.Millisecond,
+		SuccessThreshold: 1,
+	})
+
+	cb.Process(ctx, agenkit.NewMessage("user", "test"))
+	time.Sleep(30 * time.Millisecond)
+	agent.fail = false
+
+	probeDone := make(chan error, 1)
+	go func() {
+		_, err := cb.Process(ctx, agenkit.NewMessage("user", "probe"))
+		probeDone <- err
+	}()
+	<-agent.started
+
+	_, err := cb.Process(ctx, agenkit.NewMessage("user", "concurrent"))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected concurrent request to be rejected during probe, got %v", err)
+	}
+
+	close(agent.release)
+	if err := <-probeDone; err != nil {
+		t.Fatalf("Expected probe to succeed, got %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("Expected StateClosed after successful probe, got %s", cb.State())
+	}
+}
+
+// TestCircuitBreakerOnStateChange tests the state change callback.
+func TestCircuitBreakerOnStateChange(t *testing.T) {
+	ctx := context.Background()
+	agent := &UnreliableAgent{failurePattern: []bool{true, true, false}}
+
+	var cb *CircuitBreakerDecorator
+	var transitions []string
+	cb = NewCircuitBreakerDecorator(agent, CircuitBreakerConfig{
+		FailureThreshold: 1,
+		RecoveryTimeout:  20 * time.Millisecond,
+		SuccessThreshold: 1,
+		OnStateChange: func(from, to CircuitState) {
+			// Must not deadlock when inspecting the breaker
+			if cb.State() != to {
+				t.Errorf("Expected State() to report %s in callback, got %s", to, cb.State())
+			}
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+
+	cb.Process(ctx, agenkit.NewMessage("user", "test")) // closed -> open
+	time.Sleep(30 * time.Millisecond)
+	cb.Process(ctx, agenkit.NewMessage("user", "test")) // open -> half_open -> open
+	time.Sleep(30 * time.Millisecond)
+	cb.Process(ctx, agenkit.NewMessage("user", "test")) // open -> half_open -> closed
+
+	expected := []string{
+		"closed->open",
+		"open->half_open", "half_open->open",
+		"open->half_open", "half_open->closed",
+	}
+	if len(transitions) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("Transition %d: expected %s, got %s", i, expected[i], transitions[i])
+		}
+	}
+}
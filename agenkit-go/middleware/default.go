@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// WithDefault returns an agent for optional steps, such as enrichment, that
+// must not hold up or fail a response.
+//
+// Each call runs agent under timeout; if it errors or does not finish in
+// time, a copy of fallback is returned instead, or a copy of the input
+// message if fallback is nil, so a pipeline carries on without the step.
+// The agent's context is cancelled when the timeout fires, so an agent
+// honoring its context stops rather than running on unobserved. A timeout
+// of zero or less disables the timeout. If the caller's own context is
+// done first, its error is returned.
+//
+// The returned message's metadata has "used_fallback" set to whether the
+// fallback was used and, when it was, "fallback_reason" set to "timeout" or
+// "error" and "fallback_error" to the error's text.
+func WithDefault(agent agenkit.Agent, timeout time.Duration, fallback *agenkit.Message) agenkit.Agent {
+	return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+		runCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			runCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		defer cancel()
+
+		type outcome struct {
+			result *agenkit.Message
+			err    error
+		}
+		done := make(chan outcome, 1)
+		go func() {
+			result, err := next(runCtx, message)
+			done <- outcome{result, err}
+		}()
+
+		var o outcome
+		select {
+		case o = <-done:
+		case <-runCtx.Done():
+			o.err = runCtx.Err()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if o.err == nil && o.result != nil {
+			return withFallbackMetadata(o.result, false), nil
+		}
+
+		source := fallback
+		if source == nil {
+			source = message
+		}
+		result := withFallbackMetadata(source, true)
+		if o.err == nil {
+			result.Metadata["fallback_reason"] = "error"
+			result.Metadata["fallback_error"] = "agent returned no message"
+		} else {
+			reason := "error"
+			if runCtx.Err() != nil {
+				reason = "timeout"
+			}
+			result.Metadata["fallback_reason"] = reason
+			result.Metadata["fallback_error"] = o.err.Error()
+		}
+		return result, nil
+	})
+}
+
+// withFallbackMetadata returns a copy of message recording whether it is
+// a fallback.
+func withFallbackMetadata(message *agenkit.Message, used bool) *agenkit.Message {
+	result := *message
+	result.Metadata = make(map[string]interface{}, len(message.Metadata)+3)
+	for k, v := range message.Metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata["used_fallback"] = used
+	return &result
+}
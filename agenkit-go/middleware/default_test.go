@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// hangingAgent blocks until its context is done, then reports its exit.
+type hangingAgent struct {
+	exited chan struct{}
+}
+
+func (h *hangingAgent) Name() string           { return "hanging" }
+func (h *hangingAgent) Capabilities() []string { return nil }
+
+func (h *hangingAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	<-ctx.Done()
+	close(h.exited)
+	return nil, ctx.Err()
+}
+
+func TestWithDefaultPassesResultThrough(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "enrich")
+	agent.Expect("", "enriched")
+	wrapped := WithDefault(agent, time.Second, agenkit.NewMessage("agent", "plain"))
+
+	result, err := wrapped.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "enriched" || result.Metadata["used_fallback"] != false {
+		t.Errorf("Expected the real result, got %s with %v", result.Content, result.Metadata)
+	}
+}
+
+func TestWithDefaultFallsBackOnTimeout(t *testing.T) {
+	agent := &hangingAgent{exited: make(chan struct{})}
+	wrapped := WithDefault(agent, 20*time.Millisecond, agenkit.NewMessage("agent", "plain"))
+
+	result, err := wrapped.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Expected the fallback, got error: %v", err)
+	}
+	if result.Content != "plain" || result.Metadata["used_fallback"] != true || result.Metadata["fallback_reason"] != "timeout" {
+		t.Errorf("Expected the fallback after a timeout, got %s with %v", result.Content, result.Metadata)
+	}
+	select {
+	case <-agent.exited:
+	case <-time.After(time.Second):
+		t.Error("Expected the agent's context cancelled")
+	}
+}
+
+func TestWithDefaultFallsBackOnError(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "enrich")
+	agent.Expect("", "").WithError(errors.New("service down"))
+	wrapped := WithDefault(agent, time.Second, nil)
+
+	result, err := wrapped.Process(context.Background(), agenkit.NewMessage("user", "hi"))
+	if err != nil {
+		t.Fatalf("Expected the fallback, got error: %v", err)
+	}
+	if result.Content != "hi" || result.Metadata["fallback_reason"] != "error" || result.Metadata["fallback_error"] != "service down" {
+		t.Errorf("Expected the input passed on after an error, got %s with %v", result.Content, result.Metadata)
+	}
+}
+
+func TestWithDefaultHonorsCallerCancellation(t *testing.T) {
+	agent := &hangingAgent{exited: make(chan struct{})}
+	wrapped := WithDefault(agent, time.Second, agenkit.NewMessage("agent", "plain"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := wrapped.Process(ctx, agenkit.NewMessage("user", "hi")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the caller's deadline error, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey marks calls made with ctx as retries of one logical
+// request, so agents wrapped with IdempotencyMiddleware run it at most once.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key attached to ctx, if any.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key, ok && key != ""
+}
+
+// IdempotencyStore records the responses of keyed calls.
+type IdempotencyStore interface {
+	// Get returns the response stored under key, if present and unexpired.
+	Get(ctx context.Context, key string) (*agenkit.Message, bool, error)
+
+	// Set stores response under key for ttl.
+	Set(ctx context.Context, key string, response *agenkit.Message, ttl time.Duration) error
+}
+
+// IdempotencyConfig configures IdempotencyMiddleware.
+type IdempotencyConfig struct {
+	// Store holds completed responses.
+	// Default: a new MemoryIdempotencyStore
+	Store IdempotencyStore
+
+	// TTL is how long a response is replayed for its key.
+	// Default: 24 hours
+	TTL time.Duration
+}
+
+// IdempotencyMiddleware replays the first successful response for calls
+// carrying the same idempotency key (see WithIdempotencyKey), so retried
+// requests do not repeat an agent's side effects.
+//
+// Keys are scoped by agent name, so one key can flow through a pipeline of
+// wrapped agents without them sharing responses. While a keyed call is
+// running, later calls with the same key wait for it and then replay its
+// response; waiting callers give up when their context is done. The wait
+// only covers calls through this middleware in this process; a shared Store
+// still replays completed responses across processes. Failed calls and
+// calls returning no response are not recorded, so they can be retried; a
+// response the Store fails to record is still returned, and the failure is
+// logged to agenkit.Logger of the call's context. Replayed responses carry
+// "idempotent_replay" metadata. Calls without a key pass straight through.
+func IdempotencyMiddleware(config IdempotencyConfig) AgentMiddleware {
+	if config.Store == nil {
+		config.Store = NewMemoryIdempotencyStore()
+	}
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+
+	var mu sync.Mutex
+	running := make(map[string]chan struct{})
+
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+			key, ok := IdempotencyKeyFromContext(ctx)
+			if !ok {
+				return next(ctx, message)
+			}
+			key = agent.Name() + ":" + key
+
+			for {
+				if response, found, err := config.Store.Get(ctx, key); err != nil {
+					return nil, fmt.Errorf("agent %s: idempotency lookup failed: %w", agent.Name(), err)
+				} else if found {
+					return replayed(response), nil
+				}
+
+				mu.Lock()
+				done, busy := running[key]
+				if !busy {
+					done = make(chan struct{})
+					running[key] = done
+				}
+				mu.Unlock()
+				if !busy {
+					break
+				}
+
+				// Another call with this key is running; check again once it ends
+				select {
+				case <-done:
+				case <-ctx.Done():
+					return nil, fmt.Errorf("agent %s: waiting for idempotent call: %w", agent.Name(), ctx.Err())
+				}
+			}
+
+			defer func() {
+				mu.Lock()
+				close(running[key])
+				delete(running, key)
+				mu.Unlock()
+			}()
+
+			response, err := next(ctx, message)
+			if err != nil || response == nil {
+				return response, err
+			}
+			// The agent has already run, so its response stands even if it
+			// cannot be recorded
+			if err := config.Store.Set(ctx, key, response, config.TTL); err != nil {
+				agenkit.Logger(ctx).ErrorContext(ctx, "failed to record idempotent response",
+					slog.String(agenkit.LogKeyAgent, agent.Name()),
+					slog.String(agenkit.LogKeyError, err.Error()),
+				)
+			}
+			return response, nil
+		})
+	}
+}
+
+// replayed returns a copy of a stored response marked as a replay.
+func replayed(response *agenkit.Message) *agenkit.Message {
+	result := copyMessage(response)
+	result.Metadata["idempotent_replay"] = true
+	return result
+}
+
+// copyMessage returns a copy of message with its own metadata map.
+func copyMessage(message *agenkit.Message) *agenkit.Message {
+	result := *message
+	result.Metadata = make(map[string]interface{}, len(message.Metadata)+1)
+	for k, v := range message.Metadata {
+		result.Metadata[k] = v
+	}
+	return &result
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore. It is safe for
+// concurrent use.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+}
+
+// idempotencyEntry is a stored response and its expiry.
+type idempotencyEntry struct {
+	response  *agenkit.Message
+	expiresAt time.Time
+}
+
+// Verify that MemoryIdempotencyStore implements IdempotencyStore interface.
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+// NewMemoryIdempotencyStore creates an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry)}
+}
+
+// Get returns the unexpired response stored under key.
+func (m *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*agenkit.Message, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return copyMessage(entry.response), true, nil
+}
+
+// Set stores a copy of response under key for ttl. Expired entries are
+// removed as they are found.
+func (m *MemoryIdempotencyStore) Set(ctx context.Context, key string, response *agenkit.Message, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = idempotencyEntry{response: copyMessage(response), expiresAt: now.Add(ttl)}
+	return nil
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// sideEffectAgent counts its runs, replying with the run number.
+type sideEffectAgent struct {
+	name  string
+	runs  atomic.Int32
+	delay time.Duration
+	err   error
+}
+
+func (s *sideEffectAgent) Name() string           { return s.name }
+func (s *sideEffectAgent) Capabilities() []string { return nil }
+
+func (s *sideEffectAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	n := s.runs.Add(1)
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return agenkit.NewMessage("agent", fmt.Sprintf("run %d", n)), nil
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	inner := &sideEffectAgent{name: "charge"}
+	agent := Chain(inner, IdempotencyMiddleware(IdempotencyConfig{}))
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	first, err := agent.Process(ctx, agenkit.NewMessage("user", "charge $5"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	second, err := agent.Process(ctx, agenkit.NewMessage("user", "charge $5"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if inner.runs.Load() != 1 {
+		t.Errorf("Expected the agent to run once, got %d runs", inner.runs.Load())
+	}
+	if second.Content != first.Content || second.Metadata["idempotent_replay"] != true {
+		t.Errorf("Expected a marked replay of '%s', got '%s' (%v)", first.Content, second.Content, second.Metadata)
+	}
+	if _, ok := first.Metadata["idempotent_replay"]; ok {
+		t.Error("Expected the first response not to be marked as a replay")
+	}
+
+	// Other keys and unkeyed calls run normally
+	agent.Process(WithIdempotencyKey(context.Background(), "req-2"), agenkit.NewMessage("user", "charge $5"))
+	agent.Process(context.Background(), agenkit.NewMessage("user", "charge $5"))
+	agent.Process(context.Background(), agenkit.NewMessage("user", "charge $5"))
+	if inner.runs.Load() != 4 {
+		t.Errorf("Expected new keys and unkeyed calls to run, got %d runs", inner.runs.Load())
+	}
+}
+
+func TestIdempotencyConcurrentCallsWait(t *testing.T) {
+	inner := &sideEffectAgent{name: "charge", delay: 20 * time.Millisecond}
+	agent := Chain(inner, IdempotencyMiddleware(IdempotencyConfig{}))
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	var wg sync.WaitGroup
+	contents := make([]string, 10)
+	for i := range contents {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := agent.Process(ctx, agenkit.NewMessage("user", "charge"))
+			if err != nil {
+				t.Errorf("Process failed: %v", err)
+				return
+			}
+			contents[i] = response.Content
+		}(i)
+	}
+	wg.Wait()
+
+	if inner.runs.Load() != 1 {
+		t.Errorf("Expected concurrent duplicates to run once, got %d runs", inner.runs.Load())
+	}
+	for i, content := range contents {
+		if content != "run 1" {
+			t.Errorf("Call %d: expected the first run's response, got '%s'", i, content)
+		}
+	}
+}
+
+func TestIdempotencyWaitHonorsContext(t *testing.T) {
+	inner := &sideEffectAgent{name: "charge", delay: 200 * time.Millisecond}
+	agent := Chain(inner, IdempotencyMiddleware(IdempotencyConfig{}))
+
+	go agent.Process(WithIdempotencyKey(context.Background(), "req-1"), agenkit.NewMessage("user", "charge"))
+	for inner.runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(WithIdempotencyKey(context.Background(), "req-1"), 10*time.Millisecond)
+	defer cancel()
+	if _, err := agent.Process(ctx, agenkit.NewMessage("user", "charge")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded while waiting, got %v", err)
+	}
+}
+
+func TestIdempotencyDoesNotRecordFailures(t *testing.T) {
+	inner := &sideEffectAgent{name: "charge", err: errors.New("card declined")}
+	agent := Chain(inner, IdempotencyMiddleware(IdempotencyConfig{}))
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	if _, err := agent.Process(ctx, agenkit.NewMessage("user", "charge")); err == nil {
+		t.Fatal("Expected the failure to be returned")
+	}
+	inner.err = nil
+	if _, err := agent.Process(ctx, agenkit.NewMessage("user", "charge")); err != nil {
+		t.Fatalf("Expected the retry to run, got %v", err)
+	}
+	if inner.runs.Load() != 2 {
+		t.Errorf("Expected a failed call to be retried, got %d runs", inner.runs.Load())
+	}
+}
+
+// failingStore is an IdempotencyStore whose writes fail.
+type failingStore struct {
+	*MemoryIdempotencyStore
+}
+
+func (f *failingStore) Set(ctx context.Context, key string, response *agenkit.Message, ttl time.Duration) error {
+	return errors.New("store unavailable")
+}
+
+func TestIdempotencyReturnsResponseWhenStoreFails(t *testing.T) {
+	inner := &sideEffectAgent{name: "charge"}
+	store := &failingStore{MemoryIdempotencyStore: NewMemoryIdempotencyStore()}
+	agent := Chain(inner, IdempotencyMiddleware(IdempotencyConfig{Store: store}))
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	response, err := agent.Process(ctx, agenkit.NewMessage("user", "charge"))
+	if err != nil {
+		t.Fatalf("Expected the response despite the store failure, got %v", err)
+	}
+	if response.Content != "run 1" {
+		t.Errorf("Expected 'run 1', got '%s'", response.Content)
+	}
+}
+
+// silentAgent returns neither a response nor an error.
+type silentAgent struct{}
+
+func (s *silentAgent) Name() string           { return "silent" }
+func (s *silentAgent) Capabilities() []string { return nil }
+
+func (s *silentAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return nil, nil
+}
+
+func TestIdempotencySkipsMissingResponse(t *testing.T) {
+	agent := Chain(&silentAgent{}, IdempotencyMiddleware(IdempotencyConfig{}))
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	response, err := agent.Process(ctx, agenkit.NewMessage("user", "hi"))
+	if err != nil || response != nil {
+		t.Errorf("Expected the missing response to pass through, got %v, %v", response, err)
+	}
+}
+
+func TestIdempotencyScopesKeysByAgent(t *testing.T) {
+	idempotent := IdempotencyMiddleware(IdempotencyConfig{})
+	a := &sideEffectAgent{name: "a"}
+	b := &sideEffectAgent{name: "b"}
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	Chain(a, idempotent).Process(ctx, agenkit.NewMessage("user", "x"))
+	Chain(b, idempotent).Process(ctx, agenkit.NewMessage("user", "x"))
+	if a.runs.Load() != 1 || b.runs.Load() != 1 {
+		t.Errorf("Expected each agent to run once for the shared key, got %d and %d", a.runs.Load(), b.runs.Load())
+	}
+}
+
+func TestMemoryIdempotencyStoreTTL(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	ctx := context.Background()
+	store.Set(ctx, "k", agenkit.NewMessage("agent", "done"), 10*time.Millisecond)
+
+	if response, ok, _ := store.Get(ctx, "k"); !ok || response.Content != "done" {
+		t.Fatalf("Expected the stored response, got %v", response)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := store.Get(ctx, "k"); ok {
+		t.Error("Expected the entry to expire")
+	}
+}
@@ -105,6 +105,11 @@ func (m *MetricsDecorator) Capabilities() []string {
 	return m.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (m *MetricsDecorator) Unwrap() agenkit.Agent {
+	return m.agent
+}
+
 // GetMetrics returns the current metrics.
 func (m *MetricsDecorator) GetMetrics() *Metrics {
 	return m.metrics
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig configures a shared request-rate budget.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained request rate.
+	// Default: 10
+	RequestsPerSecond float64
+
+	// Burst is the maximum number of requests allowed at once.
+	// Default: 1
+	Burst int
+
+	// OnWait, if set, is called after each request acquires its slot with
+	// the name of the agent and how long it waited (zero when not throttled).
+	OnWait func(agentName string, wait time.Duration)
+}
+
+// DefaultRateLimitConfig returns the default rate limit configuration.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerSecond: 10,
+		Burst:             1,
+	}
+}
+
+// RateLimit is a request-rate budget backed by a token bucket.
+//
+// A single RateLimit can be shared by any number of agents via
+// RateLimitMiddleware, so fan-out patterns that hit the same provider key
+// respect one global budget. It is safe for concurrent use.
+type RateLimit struct {
+	limiter *rate.Limiter
+	onWait  func(agentName string, wait time.Duration)
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// NewRateLimit creates a new shared rate limit.
+func NewRateLimit(config RateLimitConfig) *RateLimit {
+	if config.RequestsPerSecond <= 0 {
+		config.RequestsPerSecond = 10
+	}
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	return &RateLimit{
+		limiter: rate.NewLimiter(rate.Limit(config.RequestsPerSecond), config.Burst),
+		onWait:  config.OnWait,
+	}
+}
+
+// Wait blocks until a request slot is available or ctx is done.
+//
+// If ctx has a deadline that would pass before a slot frees up, Wait returns
+// immediately with an error wrapping context.DeadlineExceeded and the slot
+// is not consumed.
+func (l *RateLimit) Wait(ctx context.Context, agentName string) error {
+	reservation := l.limiter.Reserve()
+	delay := reservation.Delay()
+
+	l.mu.Lock()
+	if pause := time.Until(l.pausedUntil); pause > delay {
+		delay = pause
+	}
+	l.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		reservation.Cancel()
+		return fmt.Errorf("agent %s: rate limit wait of %v exceeds deadline: %w", agentName, delay, context.DeadlineExceeded)
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			reservation.Cancel()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if l.onWait != nil {
+		l.onWait(agentName, delay)
+	}
+	return nil
+}
+
+// Pause holds back every request for d, for example while an upstream
+// service's Retry-After window runs. Overlapping pauses extend to the
+// latest end time.
+func (l *RateLimit) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// RateLimitMiddleware blocks each call until limit grants a request slot.
+//
+// If a call fails with an agenkit.RateLimitError carrying RetryAfter, the
+// whole limit is paused for that long, so agents sharing it back off
+// together instead of each hitting the upstream limit in turn.
+//
+// Pass the same RateLimit to every agent that shares an upstream quota:
+//
+//	limit := middleware.NewRateLimit(middleware.RateLimitConfig{RequestsPerSecond: 5, Burst: 5})
+//	for i, a := range agents {
+//		agents[i] = middleware.Chain(a, middleware.RateLimitMiddleware(limit))
+//	}
+func RateLimitMiddleware(limit *RateLimit) AgentMiddleware {
+	return func(agent agenkit.Agent) agenkit.Agent {
+		return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+			if err := limit.Wait(ctx, agent.Name()); err != nil {
+				return nil, err
+			}
+			response, err := next(ctx, message)
+			if after, ok := agenkit.RetryAfter(err); ok {
+				limit.Pause(after)
+			}
+			return response, err
+		})
+	}
+}
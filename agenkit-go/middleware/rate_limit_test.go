@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestRateLimitMiddlewareAllowsBurst(t *testing.T) {
+	agent := &SimpleAgent{}
+	limited := Chain(agent, RateLimitMiddleware(NewRateLimit(RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             3,
+	})))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := limited.Process(context.Background(), agenkit.NewMessage("user", "test")); err != nil {
+			t.Fatalf("Request %d: Expected success, got error: %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected burst to pass without waiting, took %v", elapsed)
+	}
+	if agent.callCount != 3 {
+		t.Errorf("Expected 3 calls, got %d", agent.callCount)
+	}
+}
+
+func TestRateLimitMiddlewareBlocksUntilTokenAvailable(t *testing.T) {
+	var waits []time.Duration
+	limit := NewRateLimit(RateLimitConfig{
+		RequestsPerSecond: 10,
+		Burst:             1,
+		OnWait: func(agentName string, wait time.Duration) {
+			waits = append(waits, wait)
+		},
+	})
+	limited := Chain(&SimpleAgent{}, RateLimitMiddleware(limit))
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := limited.Process(context.Background(), agenkit.NewMessage("user", "test")); err != nil {
+			t.Fatalf("Request %d: Expected success, got error: %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected second request to wait ~100ms, took %v", elapsed)
+	}
+
+	if len(waits) != 2 {
+		t.Fatalf("Expected 2 wait reports, got %d", len(waits))
+	}
+	if waits[0] != 0 {
+		t.Errorf("Expected first request not to wait, got %v", waits[0])
+	}
+	if waits[1] <= 0 {
+		t.Errorf("Expected second request to report a wait, got %v", waits[1])
+	}
+}
+
+func TestRateLimitMiddlewareSharedAcrossAgents(t *testing.T) {
+	limit := NewRateLimit(RateLimitConfig{RequestsPerSecond: 20, Burst: 2})
+
+	agents := make([]agenkit.Agent, 6)
+	for i := range agents {
+		agents[i] = Chain(&SimpleAgent{}, RateLimitMiddleware(limit))
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, a := range agents {
+		wg.Add(1)
+		go func(a agenkit.Agent) {
+			defer wg.Done()
+			if _, err := a.Process(context.Background(), agenkit.NewMessage("user", "test")); err != nil {
+				t.Errorf("Expected success, got error: %v", err)
+			}
+		}(a)
+	}
+	wg.Wait()
+
+	// 2 burst + 4 more at 20/s => at least ~200ms across all agents
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected shared limiter to throttle fan-out, took only %v", elapsed)
+	}
+}
+
+func TestRateLimitMiddlewareDeadlineExceeded(t *testing.T) {
+	agent := &SimpleAgent{}
+	limited := Chain(agent, RateLimitMiddleware(NewRateLimit(RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             1,
+	})))
+
+	if _, err := limited.Process(context.Background(), agenkit.NewMessage("user", "test")); err != nil {
+		t.Fatalf("Expected first request to succeed, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := limited.Process(ctx, agenkit.NewMessage("user", "test"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected immediate failure without waiting, took %v", elapsed)
+	}
+	if agent.callCount != 1 {
+		t.Errorf("Expected the throttled call not to reach the agent, got %d calls", agent.callCount)
+	}
+}
+
+func TestRateLimitMiddlewareContextCancelled(t *testing.T) {
+	limited := Chain(&SimpleAgent{}, RateLimitMiddleware(NewRateLimit(RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             1,
+	})))
+	limited.Process(context.Background(), agenkit.NewMessage("user", "test"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := limited.Process(ctx, agenkit.NewMessage("user", "test"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRateLimitMiddlewarePausesOnRetryAfter(t *testing.T) {
+	limit := NewRateLimit(RateLimitConfig{RequestsPerSecond: 1000, Burst: 10})
+	calls := 0
+	agent := Wrap(&SimpleAgent{}, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+		calls++
+		if calls == 1 {
+			return nil, &agenkit.RateLimitError{Provider: "p", RetryAfter: 60 * time.Millisecond}
+		}
+		return next(ctx, message)
+	})
+	limited := Chain(agent, RateLimitMiddleware(limit))
+
+	_, err := limited.Process(context.Background(), agenkit.NewMessage("user", "first"))
+	var rl *agenkit.RateLimitError
+	if !errors.As(err, &rl) {
+		t.Fatalf("Expected the RateLimitError to pass through, got %v", err)
+	}
+
+	start := time.Now()
+	if _, err := limited.Process(context.Background(), agenkit.NewMessage("user", "second")); err != nil {
+		t.Fatalf("Expected second call to succeed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the limit to pause for RetryAfter, waited %v", elapsed)
+	}
+}
@@ -4,6 +4,7 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -126,6 +127,11 @@ func (r *RateLimiterDecorator) Capabilities() []string {
 	return r.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (r *RateLimiterDecorator) Unwrap() agenkit.Agent {
+	return r.agent
+}
+
 // Metrics returns the rate limiter metrics.
 func (r *RateLimiterDecorator) Metrics() *RateLimiterMetrics {
 	return r.metrics
@@ -172,9 +178,10 @@ func (r *RateLimiterDecorator) acquireTokens(ctx context.Context, tokensNeeded i
 		}
 	}
 
-	// Calculate wait time for tokens
+	// Calculate wait time for tokens. Round up so the bucket has refilled
+	// enough by the time the timer fires.
 	tokensDeficit := float64(tokensNeeded) - r.tokens
-	waitDuration := time.Duration(tokensDeficit/r.config.Rate*1000) * time.Millisecond
+	waitDuration := time.Duration(math.Ceil(tokensDeficit / r.config.Rate * float64(time.Second)))
 
 	r.mu.Unlock()
 
@@ -191,8 +198,13 @@ func (r *RateLimiterDecorator) acquireTokens(ctx context.Context, tokensNeeded i
 
 	r.refillTokens()
 
-	if r.tokens >= float64(tokensNeeded) {
+	// Tolerate floating point error in the refill computation
+	const epsilon = 1e-9
+	if r.tokens+epsilon >= float64(tokensNeeded) {
 		r.tokens -= float64(tokensNeeded)
+		if r.tokens < 0 {
+			r.tokens = 0
+		}
 		r.metrics.mu.Lock()
 		r.metrics.CurrentTokens = r.tokens
 		r.metrics.TotalWaitTime += waitDuration
@@ -200,7 +212,7 @@ func (r *RateLimiterDecorator) acquireTokens(ctx context.Context, tokensNeeded i
 		return nil
 	}
 
-	// Should not happen, but handle defensively
+	// Another caller consumed the refilled tokens while we were waiting
 	return &RateLimitError{
 		TokensNeeded:    tokensNeeded,
 		TokensAvailable: r.tokens,
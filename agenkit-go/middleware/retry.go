@@ -28,7 +28,7 @@ type RetryConfig struct {
 	BackoffMultiplier float64
 
 	// ShouldRetry determines if an error should trigger a retry.
-	// If nil, all errors trigger retries.
+	// If nil, errors trigger retries when agenkit.IsRetryable reports true.
 	ShouldRetry func(error) bool
 }
 
@@ -39,7 +39,7 @@ func DefaultRetryConfig() RetryConfig {
 		InitialBackoff:    100 * time.Millisecond,
 		MaxBackoff:        10 * time.Second,
 		BackoffMultiplier: 2.0,
-		ShouldRetry:       nil, // Retry all errors
+		ShouldRetry:       nil, // Retry retryable errors
 	}
 }
 
@@ -84,6 +84,11 @@ func (r *RetryDecorator) Capabilities() []string {
 	return r.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (r *RetryDecorator) Unwrap() agenkit.Agent {
+	return r.agent
+}
+
 // Process implements the Agent interface with retry logic.
 func (r *RetryDecorator) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
 	var lastErr error
@@ -102,7 +107,11 @@ func (r *RetryDecorator) Process(ctx context.Context, message *agenkit.Message)
 		lastErr = err
 
 		// Check if we should retry this error
-		if r.config.ShouldRetry != nil && !r.config.ShouldRetry(err) {
+		shouldRetry := r.config.ShouldRetry
+		if shouldRetry == nil {
+			shouldRetry = agenkit.IsRetryable
+		}
+		if !shouldRetry(err) {
 			return nil, fmt.Errorf("non-retryable error on attempt %d/%d: %w", attempt, r.config.MaxAttempts, err)
 		}
 
@@ -111,11 +120,15 @@ func (r *RetryDecorator) Process(ctx context.Context, message *agenkit.Message)
 			break
 		}
 
-		// Wait before retrying
+		// Wait before retrying, at least as long as a rate limit asks
+		wait := backoff
+		if after, ok := agenkit.RetryAfter(err); ok && after > wait {
+			wait = after
+		}
 		select {
 		case <-ctx.Done():
 			return nil, fmt.Errorf("retry cancelled after %d attempts: %w", attempt, ctx.Err())
-		case <-time.After(backoff):
+		case <-time.After(wait):
 			// Calculate next backoff
 			backoff = time.Duration(float64(backoff) * r.config.BackoffMultiplier)
 			if backoff > r.config.MaxBackoff {
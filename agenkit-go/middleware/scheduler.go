@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrQueueFull is returned by SchedulerDecorator when its queue is full and
+// the request's priority is too low to wait anyway.
+var ErrQueueFull = errors.New("scheduler queue is full")
+
+// Priority orders the requests waiting for a SchedulerDecorator; higher
+// priorities are admitted first.
+type Priority int
+
+// Priority classes. Other values may be used; they order numerically.
+const (
+	PriorityLow    Priority = -10
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10
+)
+
+type priorityContextKey struct{}
+
+// WithPriority returns a context whose requests a SchedulerDecorator admits
+// with priority p. Requests without one have PriorityNormal.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, p)
+}
+
+// PriorityFromContext returns the priority attached to ctx, or
+// PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityContextKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// SchedulerConfig configures a SchedulerDecorator.
+type SchedulerConfig struct {
+	// MaxConcurrency is the number of calls that run at once.
+	// Default: 4
+	MaxConcurrency int
+
+	// MaxQueue is the number of calls that may wait for a slot. Once it is
+	// reached, calls below WaitPriority fail with ErrQueueFull.
+	// Default: 100
+	MaxQueue int
+
+	// WaitPriority is the priority from which calls queue even when the
+	// queue is full, waiting until their context is done.
+	// Default: PriorityHigh
+	WaitPriority *Priority
+}
+
+// PriorityStats describes the calls of one priority class.
+type PriorityStats struct {
+	// Queued is the number of calls currently waiting.
+	Queued int
+
+	// Admitted is the number of calls that got a slot.
+	Admitted int64
+
+	// Rejected is the number of calls that failed with ErrQueueFull.
+	Rejected int64
+
+	// Abandoned is the number of calls whose context ended while waiting.
+	Abandoned int64
+
+	// TotalWait and MaxWait cover the time admitted calls spent queued.
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// AverageWait returns the mean time admitted calls spent queued.
+func (s PriorityStats) AverageWait() time.Duration {
+	if s.Admitted == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Admitted)
+}
+
+// SchedulerStats is a snapshot of a SchedulerDecorator.
+type SchedulerStats struct {
+	// Running is the number of calls holding a slot.
+	Running int
+
+	// Queued is the number of calls waiting for one.
+	Queued int
+
+	// ByPriority breaks the calls down by priority.
+	ByPriority map[Priority]PriorityStats
+}
+
+// SchedulerDecorator limits the calls running on an agent at once and
+// admits waiting calls by priority (see WithPriority), so interactive
+// traffic is not starved by batch work. Calls of equal priority are
+// admitted in arrival order.
+//
+// A call that finds the queue full fails at once with ErrQueueFull unless
+// its priority is at least WaitPriority, in which case it queues anyway. A
+// waiting call whose context is done gives up with an error wrapping the
+// context's error, without calling the agent.
+type SchedulerDecorator struct {
+	agent        agenkit.Agent
+	config       SchedulerConfig
+	waitPriority Priority
+
+	mu      sync.Mutex
+	running int
+	queue   waiterQueue
+	seq     int64
+	stats   map[Priority]*PriorityStats
+}
+
+// Verify that SchedulerDecorator implements Agent interface.
+var _ agenkit.Agent = (*SchedulerDecorator)(nil)
+
+// NewSchedulerDecorator creates a new scheduler decorator.
+func NewSchedulerDecorator(agent agenkit.Agent, config SchedulerConfig) *SchedulerDecorator {
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = 4
+	}
+	if config.MaxQueue <= 0 {
+		config.MaxQueue = 100
+	}
+	waitPriority := PriorityHigh
+	if config.WaitPriority != nil {
+		waitPriority = *config.WaitPriority
+	}
+	return &SchedulerDecorator{
+		agent:        agent,
+		config:       config,
+		waitPriority: waitPriority,
+		stats:        make(map[Priority]*PriorityStats),
+	}
+}
+
+// Name returns the name of the underlying agent.
+func (s *SchedulerDecorator) Name() string {
+	return s.agent.Name()
+}
+
+// Capabilities returns the capabilities of the underlying agent.
+func (s *SchedulerDecorator) Capabilities() []string {
+	return s.agent.Capabilities()
+}
+
+// Unwrap returns the underlying agent.
+func (s *SchedulerDecorator) Unwrap() agenkit.Agent {
+	return s.agent
+}
+
+// Stats returns a snapshot of the decorator's state.
+func (s *SchedulerDecorator) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := SchedulerStats{
+		Running:    s.running,
+		Queued:     s.queue.Len(),
+		ByPriority: make(map[Priority]PriorityStats, len(s.stats)),
+	}
+	for p, ps := range s.stats {
+		stats.ByPriority[p] = *ps
+	}
+	return stats
+}
+
+// Process waits for a slot by the context's priority, then calls the agent.
+func (s *SchedulerDecorator) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+	return s.agent.Process(ctx, message)
+}
+
+// waiter is a call queued for a slot.
+type waiter struct {
+	priority Priority
+	seq      int64
+	queuedAt time.Time
+	ready    chan struct{}
+	index    int // position in the heap, or -1 once granted
+}
+
+// acquire takes a slot, queueing until one is handed over.
+func (s *SchedulerDecorator) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("agent %s: waiting for scheduler: %w", s.agent.Name(), err)
+	}
+	priority := PriorityFromContext(ctx)
+
+	s.mu.Lock()
+	stats := s.statsLocked(priority)
+	if s.running < s.config.MaxConcurrency && s.queue.Len() == 0 {
+		s.running++
+		stats.Admitted++
+		s.mu.Unlock()
+		return nil
+	}
+	if s.queue.Len() >= s.config.MaxQueue && priority < s.waitPriority {
+		stats.Rejected++
+		s.mu.Unlock()
+		return fmt.Errorf("agent %s: %w", s.agent.Name(), ErrQueueFull)
+	}
+	s.seq++
+	w := &waiter{priority: priority, seq: s.seq, queuedAt: time.Now(), ready: make(chan struct{})}
+	heap.Push(&s.queue, w)
+	stats.Queued++
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	granted := w.index < 0
+	if !granted {
+		heap.Remove(&s.queue, w.index)
+		stats.Queued--
+		stats.Abandoned++
+	}
+	s.mu.Unlock()
+	if granted {
+		// The slot arrived as the context ended; pass it on
+		s.release()
+	}
+	return fmt.Errorf("agent %s: waiting for scheduler: %w", s.agent.Name(), ctx.Err())
+}
+
+// release hands the slot to the highest-priority waiter, or frees it.
+func (s *SchedulerDecorator) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue.Len() == 0 {
+		s.running--
+		return
+	}
+	w := heap.Pop(&s.queue).(*waiter)
+	wait := time.Since(w.queuedAt)
+	stats := s.statsLocked(w.priority)
+	stats.Queued--
+	stats.Admitted++
+	stats.TotalWait += wait
+	if wait > stats.MaxWait {
+		stats.MaxWait = wait
+	}
+	close(w.ready)
+}
+
+// statsLocked returns the stats of a priority class. The caller must hold
+// s.mu.
+func (s *SchedulerDecorator) statsLocked(p Priority) *PriorityStats {
+	stats, ok := s.stats[p]
+	if !ok {
+		stats = &PriorityStats{}
+		s.stats[p] = stats
+	}
+	return stats
+}
+
+// waiterQueue is a heap of waiters, highest priority first, then oldest.
+type waiterQueue []*waiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// queueAgent records the order of calls and blocks each until released.
+type queueAgent struct {
+	mu      sync.Mutex
+	order   []string
+	release chan struct{}
+}
+
+func (q *queueAgent) Name() string           { return "queue" }
+func (q *queueAgent) Capabilities() []string { return nil }
+
+func (q *queueAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	q.mu.Lock()
+	q.order = append(q.order, message.Content)
+	q.mu.Unlock()
+	<-q.release
+	return message, nil
+}
+
+// waitForQueued waits until the scheduler has n calls queued.
+func waitForQueued(t *testing.T, s *SchedulerDecorator, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.Stats().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d queued calls, got %+v", n, s.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerAdmitsByPriority(t *testing.T) {
+	agent := &queueAgent{release: make(chan struct{})}
+	scheduler := NewSchedulerDecorator(agent, SchedulerConfig{MaxConcurrency: 1})
+
+	var wg sync.WaitGroup
+	call := func(content string, p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.Process(WithPriority(context.Background(), p), &agenkit.Message{Role: "user", Content: content})
+		}()
+	}
+	call("first", PriorityLow)
+	for scheduler.Stats().Running != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	call("batch", PriorityLow)
+	waitForQueued(t, scheduler, 1)
+	call("interactive", PriorityHigh)
+	waitForQueued(t, scheduler, 2)
+
+	close(agent.release)
+	wg.Wait()
+
+	got := agent.order
+	if len(got) != 3 || got[1] != "interactive" || got[2] != "batch" {
+		t.Errorf("Expected the high-priority call to jump the queue, got %v", got)
+	}
+	stats := scheduler.Stats()
+	if low := stats.ByPriority[PriorityLow]; low.Admitted != 2 || low.MaxWait <= 0 {
+		t.Errorf("Expected two admitted low-priority calls with a wait, got %+v", low)
+	}
+	if stats.Running != 0 || stats.Queued != 0 {
+		t.Errorf("Expected the scheduler to be idle, got %+v", stats)
+	}
+}
+
+func TestSchedulerQueueFull(t *testing.T) {
+	agent := &queueAgent{release: make(chan struct{})}
+	defer close(agent.release)
+	scheduler := NewSchedulerDecorator(agent, SchedulerConfig{MaxConcurrency: 1, MaxQueue: 1})
+	message := &agenkit.Message{Role: "user", Content: "x"}
+
+	go scheduler.Process(context.Background(), message)
+	for scheduler.Stats().Running != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go scheduler.Process(context.Background(), message)
+	waitForQueued(t, scheduler, 1)
+
+	if _, err := scheduler.Process(WithPriority(context.Background(), PriorityLow), message); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	// High priority waits past the bound, until its deadline
+	ctx, cancel := context.WithTimeout(WithPriority(context.Background(), PriorityHigh), 20*time.Millisecond)
+	defer cancel()
+	if _, err := scheduler.Process(ctx, message); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to end the wait, got %v", err)
+	}
+	stats := scheduler.Stats()
+	if stats.ByPriority[PriorityLow].Rejected != 1 || stats.ByPriority[PriorityHigh].Abandoned != 1 || stats.Queued != 1 {
+		t.Errorf("Expected one rejection and one abandoned wait, got %+v", stats)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Serialize returns an agent that runs one Process call of agent at a time,
+// for agents that hold mutable state and are not safe for concurrent use.
+//
+// Callers waiting for their turn give up when their context is done,
+// returning an error wrapping the context's error without calling agent.
+// Stream is not serialized; the wrapper exposes only Process.
+func Serialize(agent agenkit.Agent) agenkit.Agent {
+	turn := make(chan struct{}, 1)
+	return Wrap(agent, func(ctx context.Context, message *agenkit.Message, next ProcessFunc) (*agenkit.Message, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("agent %s: waiting for serialized access: %w", agent.Name(), err)
+		}
+		select {
+		case turn <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("agent %s: waiting for serialized access: %w", agent.Name(), ctx.Err())
+		}
+		defer func() { <-turn }()
+		return next(ctx, message)
+	})
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// statefulAgent keeps unsynchronized state, so concurrent calls race
+// unless serialized. It also records whether two calls ever overlapped.
+type statefulAgent struct {
+	history  []string
+	inside   atomic.Int32
+	overlaps atomic.Int32
+	release  chan struct{}
+}
+
+func (s *statefulAgent) Name() string           { return "stateful" }
+func (s *statefulAgent) Capabilities() []string { return nil }
+
+func (s *statefulAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	if s.inside.Add(1) > 1 {
+		s.overlaps.Add(1)
+	}
+	defer s.inside.Add(-1)
+	if s.release != nil {
+		<-s.release
+	}
+	s.history = append(s.history, message.Content)
+	return agenkit.NewMessage("agent", message.Content), nil
+}
+
+func TestSerializeIsRaceFree(t *testing.T) {
+	inner := &statefulAgent{}
+	agent := Serialize(inner)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "hi")); err != nil {
+				t.Errorf("Process failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(inner.history) != 50 {
+		t.Errorf("Expected 50 recorded calls, got %d", len(inner.history))
+	}
+	if overlaps := inner.overlaps.Load(); overlaps != 0 {
+		t.Errorf("Expected calls never to overlap, got %d overlaps", overlaps)
+	}
+	if agent.Name() != "stateful" {
+		t.Errorf("Expected the inner agent's name, got '%s'", agent.Name())
+	}
+}
+
+func TestSerializeAbandonsWaitOnCancel(t *testing.T) {
+	inner := &statefulAgent{release: make(chan struct{})}
+	agent := Serialize(inner)
+
+	// Hold the lock with a call that blocks until released
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		agent.Process(context.Background(), agenkit.NewMessage("user", "first"))
+	}()
+	for inner.inside.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := agent.Process(ctx, agenkit.NewMessage("user", "second"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded while waiting, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the wait to be abandoned, took %v", elapsed)
+	}
+
+	close(inner.release)
+	<-done
+	if len(inner.history) != 1 || inner.history[0] != "first" {
+		t.Errorf("Expected only the first call to run, got %v", inner.history)
+	}
+}
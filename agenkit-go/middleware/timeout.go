@@ -96,9 +96,13 @@ func (m *TimeoutMetrics) AvgDuration() time.Duration {
 }
 
 // TimeoutError is returned when a request exceeds the configured timeout.
+// It unwraps to context.DeadlineExceeded.
 type TimeoutError struct {
 	AgentName string
-	Timeout   time.Duration
+
+	// Timeout is the time the request was allowed: the configured timeout,
+	// or less if the caller's deadline was sooner.
+	Timeout time.Duration
 }
 
 // Error implements the error interface.
@@ -106,6 +110,11 @@ func (e *TimeoutError) Error() string {
 	return fmt.Sprintf("Request to agent '%s' timed out after %v", e.AgentName, e.Timeout)
 }
 
+// Unwrap returns context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
 // TimeoutDecorator wraps an agent with timeout protection.
 //
 // The timeout middleware prevents long-running requests from blocking resources
@@ -154,6 +163,12 @@ func NewTimeoutDecorator(agent agenkit.Agent, config TimeoutConfig) *TimeoutDeco
 	}
 }
 
+// WithTimeout wraps agent so that each call is limited to d, or to the
+// caller's deadline if that is sooner.
+func WithTimeout(agent agenkit.Agent, d time.Duration) agenkit.Agent {
+	return NewTimeoutDecorator(agent, TimeoutConfig{Timeout: d})
+}
+
 // Name returns the name of the underlying agent.
 func (t *TimeoutDecorator) Name() string {
 	return t.agent.Name()
@@ -164,6 +179,11 @@ func (t *TimeoutDecorator) Capabilities() []string {
 	return t.agent.Capabilities()
 }
 
+// Unwrap returns the underlying agent.
+func (t *TimeoutDecorator) Unwrap() agenkit.Agent {
+	return t.agent
+}
+
 // Metrics returns the timeout metrics.
 func (t *TimeoutDecorator) Metrics() *TimeoutMetrics {
 	return t.metrics
@@ -186,10 +206,15 @@ func (t *TimeoutDecorator) Metrics() *TimeoutMetrics {
 func (t *TimeoutDecorator) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
 	startTime := time.Now()
 
-	// Create a context with timeout
+	// Create a context with timeout; a sooner parent deadline still wins
 	timeoutCtx, cancel := context.WithTimeout(ctx, t.config.Timeout)
 	defer cancel()
 
+	allowed := t.config.Timeout
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(startTime) < allowed {
+		allowed = deadline.Sub(startTime)
+	}
+
 	// Pre-declare result struct to avoid allocation in hot path
 	type result struct {
 		msg *agenkit.Message
@@ -216,7 +241,7 @@ func (t *TimeoutDecorator) Process(ctx context.Context, message *agenkit.Message
 				t.metrics.RecordTimeout(duration)
 				return nil, &TimeoutError{
 					AgentName: t.Name(),
-					Timeout:   t.config.Timeout,
+					Timeout:   allowed,
 				}
 			}
 
@@ -231,10 +256,15 @@ func (t *TimeoutDecorator) Process(ctx context.Context, message *agenkit.Message
 
 	case <-timeoutCtx.Done():
 		duration := time.Since(startTime)
+		if timeoutCtx.Err() == context.Canceled {
+			// The caller gave up; this is not a timeout
+			t.metrics.RecordFailure(duration)
+			return nil, ctx.Err()
+		}
 		t.metrics.RecordTimeout(duration)
 		return nil, &TimeoutError{
 			AgentName: t.Name(),
-			Timeout:   t.config.Timeout,
+			Timeout:   allowed,
 		}
 	}
 }
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -530,3 +531,61 @@ func TestTimeoutDefaultIs30Seconds(t *testing.T) {
 		t.Errorf("Expected default timeout 30s, got %v", config.Timeout)
 	}
 }
+
+func TestWithTimeoutInterruptsSlowAgent(t *testing.T) {
+	agent := WithTimeout(NewSlowAgent(5*time.Second), 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "test"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the slow agent to be interrupted, took %v", elapsed)
+	}
+
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected TimeoutError, got %v", err)
+	}
+	if timeoutErr.AgentName != "slow-agent" {
+		t.Errorf("Expected agent name 'slow-agent', got '%s'", timeoutErr.AgentName)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected TimeoutError to unwrap to context.DeadlineExceeded")
+	}
+}
+
+func TestTimeoutUsesSoonerParentDeadline(t *testing.T) {
+	agent := WithTimeout(NewSlowAgent(5*time.Second), 10*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := agent.Process(ctx, agenkit.NewMessage("user", "test"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the parent deadline to apply, took %v", elapsed)
+	}
+
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected TimeoutError, got %v", err)
+	}
+	if timeoutErr.Timeout > 50*time.Millisecond {
+		t.Errorf("Expected the reported timeout to be the parent's, got %v", timeoutErr.Timeout)
+	}
+}
+
+func TestTimeoutParentCancellationIsNotTimeout(t *testing.T) {
+	agent := WithTimeout(NewSlowAgent(5*time.Second), 10*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := agent.Process(ctx, agenkit.NewMessage("user", "test"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		t.Error("Expected cancellation not to be reported as a timeout")
+	}
+}
@@ -0,0 +1,280 @@
+// Package prompt provides templates for building agent prompts.
+//
+// PromptTemplate wraps text/template with checks suited to prompts: every
+// variable a template prints must be supplied, so a typo surfaces as an
+// error naming the missing keys instead of "<no value>" in the prompt.
+// Shared fragments (personas, output format instructions) can be registered
+// once as partials and included from any template with
+// {{template "name" .}}.
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// funcs are the helper functions available to every template and partial.
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"indent": func(spaces int, s string) string {
+		pad := strings.Repeat(" ", spaces)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+}
+
+var (
+	partialsMu sync.RWMutex
+	partials   = make(map[string]*template.Template)
+)
+
+// RegisterPartial parses body and makes it available to all templates under
+// name. Registering an existing name replaces it.
+func RegisterPartial(name, body string) error {
+	if name == "" {
+		return fmt.Errorf("partial name cannot be empty")
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(body)
+	if err != nil {
+		return fmt.Errorf("partial %s: %w", name, err)
+	}
+
+	partialsMu.Lock()
+	defer partialsMu.Unlock()
+	partials[name] = tmpl
+	return nil
+}
+
+// PromptTemplate is a parsed prompt template. It is safe for concurrent use.
+type PromptTemplate struct {
+	name string
+	tmpl *template.Template
+}
+
+// NewPromptTemplate parses body as a text/template.
+func NewPromptTemplate(name, body string) (*PromptTemplate, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	return &PromptTemplate{name: name, tmpl: tmpl}, nil
+}
+
+// Name returns the template's name.
+func (p *PromptTemplate) Name() string {
+	return p.name
+}
+
+// Variables returns the sorted names of the top-level variables the template
+// references, including those referenced by partials it includes.
+func (p *PromptTemplate) Variables() []string {
+	refs := p.references()
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes the template with vars.
+//
+// Every referenced variable must be present in vars, except variables used
+// only as the condition of an {{if}} or {{with}}, which may be omitted.
+// Missing variables are reported together in a single error.
+func (p *PromptTemplate) Render(vars map[string]any) (string, error) {
+	var missing []string
+	for name, required := range p.references() {
+		if _, ok := vars[name]; required && !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("prompt %s: missing variables: %s", p.name, strings.Join(missing, ", "))
+	}
+
+	tmpl, err := p.withPartials()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("prompt %s: %w", p.name, err)
+	}
+	return sb.String(), nil
+}
+
+// MustRender is like Render but panics on error. It is intended for static
+// templates whose variables are known to be supplied.
+func (p *PromptTemplate) MustRender(vars map[string]any) string {
+	out, err := p.Render(vars)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// withPartials returns a copy of the template with the registered partials
+// attached, so partials registered after parsing are still available.
+func (p *PromptTemplate) withPartials() (*template.Template, error) {
+	tmpl, err := p.tmpl.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s: %w", p.name, err)
+	}
+
+	partialsMu.RLock()
+	defer partialsMu.RUnlock()
+	for name, partial := range partials {
+		if name == p.name {
+			continue
+		}
+		for _, t := range partial.Templates() {
+			if _, err := tmpl.AddParseTree(t.Name(), t.Tree); err != nil {
+				return nil, fmt.Errorf("prompt %s: partial %s: %w", p.name, name, err)
+			}
+		}
+	}
+	return tmpl, nil
+}
+
+// references maps each top-level variable to whether it is required.
+func (p *PromptTemplate) references() map[string]bool {
+	partialsMu.RLock()
+	defer partialsMu.RUnlock()
+
+	w := &walker{
+		refs:     make(map[string]bool),
+		guarded:  make(map[string]int),
+		visiting: map[string]bool{p.name: true},
+	}
+	w.walk(p.tmpl.Tree.Root, true)
+	return w.refs
+}
+
+// walker collects variable references from a template parse tree.
+type walker struct {
+	refs     map[string]bool
+	guarded  map[string]int  // variables tested by an enclosing if/with
+	visiting map[string]bool // partials on the current path, to stop recursion
+}
+
+// walk visits node. topDot reports whether dot is the top-level data map.
+func (w *walker) walk(node parse.Node, topDot bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			w.walk(child, topDot)
+		}
+	case *parse.ActionNode:
+		w.pipe(n.Pipe, topDot, true)
+	case *parse.IfNode:
+		release := w.guard(n.Pipe, topDot)
+		w.walk(n.List, topDot)
+		release()
+		w.walk(n.ElseList, topDot)
+	case *parse.WithNode:
+		release := w.guard(n.Pipe, topDot)
+		w.walk(n.List, false)
+		release()
+		w.walk(n.ElseList, topDot)
+	case *parse.RangeNode:
+		w.pipe(n.Pipe, topDot, true)
+		w.walk(n.List, false)
+		w.walk(n.ElseList, topDot)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe, topDot, true)
+		w.partial(n, topDot)
+	}
+}
+
+// guard records an if/with condition's variables as optional, and treats
+// them as optional within the guarded block too, so {{if .x}}{{.x}}{{end}}
+// does not require x. The returned func ends the block.
+func (w *walker) guard(pipe *parse.PipeNode, topDot bool) func() {
+	cond := &walker{refs: make(map[string]bool), guarded: w.guarded, visiting: w.visiting}
+	cond.pipe(pipe, topDot, false)
+	for name := range cond.refs {
+		w.add(name, false)
+		w.guarded[name]++
+	}
+	return func() {
+		for name := range cond.refs {
+			w.guarded[name]--
+		}
+	}
+}
+
+// partial descends into an included partial when it receives the top-level data.
+func (w *walker) partial(n *parse.TemplateNode, topDot bool) {
+	if !topDot || !passesDot(n.Pipe) || w.visiting[n.Name] {
+		return
+	}
+	tmpl, ok := partials[n.Name]
+	if !ok {
+		return
+	}
+	w.visiting[n.Name] = true
+	w.walk(tmpl.Tree.Root, true)
+	delete(w.visiting, n.Name)
+}
+
+// pipe records the variables referenced by a pipeline.
+func (w *walker) pipe(pipe *parse.PipeNode, topDot, required bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			w.arg(arg, topDot, required)
+		}
+	}
+}
+
+// arg records the variable referenced by a single pipeline argument.
+func (w *walker) arg(arg parse.Node, topDot, required bool) {
+	switch a := arg.(type) {
+	case *parse.FieldNode:
+		if topDot {
+			w.add(a.Ident[0], required)
+		}
+	case *parse.VariableNode:
+		// $ always refers to the top-level data
+		if len(a.Ident) > 1 && a.Ident[0] == "$" {
+			w.add(a.Ident[1], required)
+		}
+	case *parse.ChainNode:
+		if p, ok := a.Node.(*parse.PipeNode); ok {
+			w.pipe(p, topDot, required)
+		}
+	case *parse.PipeNode:
+		w.pipe(a, topDot, required)
+	}
+}
+
+// add records a reference; a required use wins over an optional one.
+func (w *walker) add(name string, required bool) {
+	if w.guarded[name] > 0 {
+		required = false
+	}
+	w.refs[name] = w.refs[name] || required
+}
+
+// passesDot reports whether a template invocation passes dot as its data.
+func passesDot(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	_, ok := pipe.Cmds[0].Args[0].(*parse.DotNode)
+	return ok
+}
@@ -0,0 +1,122 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptTemplateRender(t *testing.T) {
+	tmpl, err := NewPromptTemplate("greet", "Hello {{.name}}, you have {{len .tasks}} tasks: {{join .tasks \", \"}}")
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	out, err := tmpl.Render(map[string]any{"name": "Ada", "tasks": []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if out != "Hello Ada, you have 2 tasks: a, b" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
+
+func TestPromptTemplateMissingVariables(t *testing.T) {
+	tmpl, _ := NewPromptTemplate("task", "{{.role}}: {{.task}} ({{.format}})")
+
+	_, err := tmpl.Render(map[string]any{"task": "summarize"})
+	if err == nil {
+		t.Fatal("Expected error for missing variables")
+	}
+	if !strings.Contains(err.Error(), "missing variables: format, role") {
+		t.Errorf("Expected error listing missing keys, got: %v", err)
+	}
+}
+
+func TestPromptTemplateOptionalConditionals(t *testing.T) {
+	tmpl, _ := NewPromptTemplate("ctx", "{{if .context}}Context: {{.context}}\n{{end}}Q: {{.question}}")
+
+	out, err := tmpl.Render(map[string]any{"question": "why?"})
+	if err != nil {
+		t.Fatalf("Expected conditional variable to be optional, got: %v", err)
+	}
+	if out != "Q: why?" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+
+	out, _ = tmpl.Render(map[string]any{"question": "why?", "context": "sky"})
+	if out != "Context: sky\nQ: why?" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
+
+func TestPromptTemplateVariables(t *testing.T) {
+	tmpl, _ := NewPromptTemplate("vars", `{{.b}}{{range .items}}{{.ignored}}{{$.a}}{{end}}{{with .user}}{{.name}}{{end}}{{if .c}}x{{end}}`)
+
+	got := strings.Join(tmpl.Variables(), ",")
+	if got != "a,b,c,items,user" {
+		t.Errorf("Expected variables a,b,c,items,user, got %s", got)
+	}
+}
+
+func TestPromptTemplatePartials(t *testing.T) {
+	tmpl, err := NewPromptTemplate("with-partial", `{{template "test-persona" .}} Answer: {{.question}}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	// Partials may be registered after templates that use them
+	if err := RegisterPartial("test-persona", "You are {{.persona}}."); err != nil {
+		t.Fatalf("Failed to register partial: %v", err)
+	}
+
+	if got := strings.Join(tmpl.Variables(), ","); got != "persona,question" {
+		t.Errorf("Expected partial variables to be included, got %s", got)
+	}
+
+	_, err = tmpl.Render(map[string]any{"question": "2+2?"})
+	if err == nil || !strings.Contains(err.Error(), "persona") {
+		t.Errorf("Expected missing partial variable error, got %v", err)
+	}
+
+	out, err := tmpl.Render(map[string]any{"persona": "a tutor", "question": "2+2?"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if out != "You are a tutor. Answer: 2+2?" {
+		t.Errorf("Unexpected output: %q", out)
+	}
+}
+
+func TestPromptTemplateRecursivePartial(t *testing.T) {
+	if err := RegisterPartial("test-loop", `{{.x}}{{template "test-loop" .}}`); err != nil {
+		t.Fatalf("Failed to register partial: %v", err)
+	}
+	tmpl, _ := NewPromptTemplate("loop", `{{template "test-loop" .}}`)
+
+	if got := strings.Join(tmpl.Variables(), ","); got != "x" {
+		t.Errorf("Expected variable x, got %s", got)
+	}
+}
+
+func TestPromptTemplateParseError(t *testing.T) {
+	if _, err := NewPromptTemplate("bad", "{{.unclosed"); err == nil {
+		t.Fatal("Expected parse error")
+	}
+	if err := RegisterPartial("", "x"); err == nil {
+		t.Fatal("Expected error for empty partial name")
+	}
+}
+
+func TestPromptTemplateMustRender(t *testing.T) {
+	tmpl, _ := NewPromptTemplate("static", "Be {{upper .tone}}.")
+	if out := tmpl.MustRender(map[string]any{"tone": "brief"}); out != "Be BRIEF." {
+		t.Errorf("Unexpected output: %q", out)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustRender to panic on missing variables")
+		}
+	}()
+	tmpl.MustRender(nil)
+}
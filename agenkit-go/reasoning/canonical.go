@@ -0,0 +1,107 @@
+package reasoning
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// artifactJSON has Artifact's fields without its methods, so encoding it
+// does not recurse into MarshalJSON.
+type artifactJSON Artifact
+
+// MarshalJSON encodes the artifact in canonical form; see CanonicalJSON.
+func (a Artifact) MarshalJSON() ([]byte, error) {
+	return a.CanonicalJSON()
+}
+
+// CanonicalJSON returns the artifact's JSON encoding in a canonical form,
+// so artifacts from runs that reasoned the same way encode to the same
+// bytes and snapshot diffs show only real changes.
+//
+// Object keys are sorted at every level, graph and tree collections under
+// the "tree", "nodes" and "edges" metadata keys are ordered by node ID
+// (edges by source, then target), and every number is written the way
+// encoding/json writes a float64 of its value, so 1.0 and 1 encode alike.
+// The ID and CreatedAt of two runs always differ; clear them on a copy
+// before comparing.
+func (a *Artifact) CanonicalJSON() ([]byte, error) {
+	copied := artifactJSON(*a)
+	if a.Metadata != nil {
+		copied.Metadata = make(map[string]interface{}, len(a.Metadata))
+		for k, v := range a.Metadata {
+			copied.Metadata[k] = sortedCollection(v)
+		}
+	}
+	data, err := json.Marshal(copied)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	value, err = canonicalNumbers(value)
+	if err != nil {
+		return nil, err
+	}
+	// Maps encode with sorted keys
+	return json.Marshal(value)
+}
+
+// sortedCollection returns a sorted copy of the node and edge slices
+// techniques record, and any other value unchanged.
+func sortedCollection(v interface{}) interface{} {
+	switch collection := v.(type) {
+	case []ThoughtNode:
+		sorted := append([]ThoughtNode(nil), collection...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		return sorted
+	case []GraphNode:
+		sorted := append([]GraphNode(nil), collection...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+		return sorted
+	case []GraphEdge:
+		sorted := append([]GraphEdge(nil), collection...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].From != sorted[j].From {
+				return sorted[i].From < sorted[j].From
+			}
+			return sorted[i].To < sorted[j].To
+		})
+		return sorted
+	}
+	return v
+}
+
+// canonicalNumbers replaces the numbers in a decoded JSON value with the
+// float64 they denote, leaving integers too large for one untouched.
+func canonicalNumbers(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			canonical, err := canonicalNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = canonical
+		}
+	case []interface{}:
+		for i, item := range value {
+			canonical, err := canonicalNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = canonical
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil && (n > 1<<53 || n < -(1<<53)) {
+			return value, nil
+		}
+		return value.Float64()
+	}
+	return v, nil
+}
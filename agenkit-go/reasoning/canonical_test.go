@@ -0,0 +1,59 @@
+package reasoning
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalJSONIsStable(t *testing.T) {
+	build := func(reversed bool) *Artifact {
+		artifact := NewArtifact("graph_of_thought", "q")
+		artifact.ID = "fixed"
+		artifact.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		nodes := []GraphNode{{ID: 0, Thought: "root"}, {ID: 1, Thought: "a", Score: 0.5}, {ID: 2, Thought: "b", Score: 1}}
+		edges := []GraphEdge{{From: 0, To: 1, Confidence: 0.5}, {From: 0, To: 2, Confidence: 1}}
+		if reversed {
+			nodes[0], nodes[2] = nodes[2], nodes[0]
+			edges[0], edges[1] = edges[1], edges[0]
+		}
+		artifact.Metadata["nodes"] = nodes
+		artifact.Metadata["edges"] = edges
+		artifact.Metadata["votes"] = map[string]int{"b": 2, "a": 1, "c": 3}
+		return artifact
+	}
+
+	first, err := build(false).CanonicalJSON()
+	if err != nil {
+		t.Fatalf("CanonicalJSON failed: %v", err)
+	}
+	second, _ := build(true).CanonicalJSON()
+	if string(first) != string(second) {
+		t.Errorf("Expected identical encodings, got\n%s\n%s", first, second)
+	}
+
+	marshaled, _ := json.Marshal(build(true))
+	if string(marshaled) != string(first) {
+		t.Errorf("Expected json.Marshal to produce the canonical form, got %s", marshaled)
+	}
+	if !strings.Contains(string(first), `"votes":{"a":1,"b":2,"c":3}`) {
+		t.Errorf("Expected sorted keys, got %s", first)
+	}
+}
+
+func TestCanonicalJSONNormalizesNumbers(t *testing.T) {
+	var decoded Artifact
+	if err := json.Unmarshal([]byte(`{"id":"x","metadata":{"score":1.0,"ratio":2.50,"big":12345678901234567890}}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	data, err := decoded.CanonicalJSON()
+	if err != nil {
+		t.Fatalf("CanonicalJSON failed: %v", err)
+	}
+	for _, want := range []string{`"score":1}`, `"ratio":2.5`, `"big":12345678901234567000`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in %s", want, data)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package reasoning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/session"
+)
+
+// CheckpointMetadataKey is the artifact metadata key holding a checkpoint's
+// technique-specific search state.
+const CheckpointMetadataKey = "checkpoint"
+
+// CheckpointStore persists the progress of long-running techniques so that
+// an interrupted run can resume. memory.VectorMemory satisfies it.
+type CheckpointStore interface {
+	// Store adds an artifact, replacing any existing artifact with the same ID.
+	Store(ctx context.Context, artifact *Artifact) error
+
+	// Get returns the artifact with the given ID, if present.
+	Get(ctx context.Context, id string) (*Artifact, bool, error)
+}
+
+// checkpointRun tracks the checkpoints of one technique run.
+//
+// Checkpoints are keyed by the technique name and the ID of the session
+// attached to the context, so a rerun in the same session finds them. Only
+// the latest state matters, so writes happen in the background and a
+// checkpoint still waiting to be written is replaced by a newer one; the
+// search never waits on the store.
+type checkpointRun struct {
+	store     CheckpointStore
+	id        string
+	technique string
+	query     string
+
+	mu      sync.Mutex
+	next    *Artifact
+	failed  int
+	closed  bool
+	wake    chan struct{}
+	stopped chan struct{}
+}
+
+// startCheckpoints begins checkpointing a run, or returns nil when store is
+// nil or ctx carries no session.
+func startCheckpoints(ctx context.Context, store CheckpointStore, name, technique, query string) *checkpointRun {
+	if store == nil {
+		return nil
+	}
+	s := session.SessionFromContext(ctx)
+	if s == nil {
+		return nil
+	}
+	return &checkpointRun{
+		store:     store,
+		id:        fmt.Sprintf("checkpoint:%s:%s", name, s.ID()),
+		technique: technique,
+		query:     query,
+	}
+}
+
+// resume loads the run's last checkpoint for the same query, if any.
+func (c *checkpointRun) resume(ctx context.Context) (*Artifact, error) {
+	if c == nil {
+		return nil, nil
+	}
+	artifact, ok, err := c.store.Get(ctx, c.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	if !ok || artifact.Technique != c.technique || artifact.Query != c.query {
+		return nil, nil
+	}
+	return artifact, nil
+}
+
+// save queues state to be written as an incomplete checkpoint.
+func (c *checkpointRun) save(ctx context.Context, state interface{}) {
+	if c == nil {
+		return
+	}
+	artifact := NewArtifact(c.technique, c.query)
+	artifact.ID = c.id
+	artifact.Complete = false
+	artifact.Metadata[CheckpointMetadataKey] = state
+	c.queue(ctx, artifact)
+}
+
+// queue hands artifact to the background writer, starting it if needed.
+func (c *checkpointRun) queue(ctx context.Context, artifact *Artifact) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.next = artifact
+	if c.wake == nil {
+		c.wake = make(chan struct{}, 1)
+		c.stopped = make(chan struct{})
+		go c.write(context.WithoutCancel(ctx))
+	}
+	select {
+	case c.wake <- struct{}{}:
+	default: // the writer has yet to pick up the previous checkpoint
+	}
+}
+
+// write stores queued checkpoints until the run finishes.
+func (c *checkpointRun) write(ctx context.Context) {
+	defer close(c.stopped)
+	for range c.wake {
+		c.mu.Lock()
+		artifact := c.next
+		c.next = nil
+		c.mu.Unlock()
+		if artifact == nil {
+			continue
+		}
+		if err := c.store.Store(ctx, artifact); err != nil {
+			c.mu.Lock()
+			c.failed++
+			c.mu.Unlock()
+		}
+	}
+}
+
+// finish stores a copy of the completed artifact under the checkpoint ID,
+// so a rerun returns it instead of searching again, and waits for pending
+// writes. It returns the number of checkpoint writes that failed.
+func (c *checkpointRun) finish(ctx context.Context, artifact *Artifact) int {
+	if c == nil {
+		return 0
+	}
+	final := *artifact
+	final.ID = c.id
+	final.Metadata = copyMetadata(artifact.Metadata)
+	c.queue(ctx, &final)
+	return c.stop()
+}
+
+// stop waits for pending writes and ends the background writer. Later
+// checkpoints are ignored. It returns the number of writes that failed.
+func (c *checkpointRun) stop() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		if c.wake != nil {
+			close(c.wake)
+		}
+	}
+	stopped := c.stopped
+	c.mu.Unlock()
+	if stopped != nil {
+		<-stopped
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failed
+}
+
+// resumedArtifact returns a copy of a completed run's artifact, marked as
+// resumed.
+func resumedArtifact(artifact *Artifact) *Artifact {
+	result := *artifact
+	result.Metadata = copyMetadata(artifact.Metadata)
+	result.Metadata["resumed"] = true
+	return &result
+}
+
+// decodeCheckpoint decodes a checkpoint's state into state. The state is
+// converted through JSON so that checkpoints restored from a serialized
+// store decode the same as ones held in memory.
+func decodeCheckpoint(artifact *Artifact, state interface{}) error {
+	data, err := json.Marshal(artifact.Metadata[CheckpointMetadataKey])
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return nil
+}
+
+// copyMetadata returns a shallow copy of metadata.
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		result[k] = v
+	}
+	return result
+}
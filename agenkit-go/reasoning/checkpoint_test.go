@@ -0,0 +1,240 @@
+package reasoning
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/session"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// mapStore is an in-memory CheckpointStore. If release is set, Store waits
+// for it to be closed.
+type mapStore struct {
+	mu        sync.Mutex
+	artifacts map[string]*Artifact
+	writes    []*Artifact
+	release   chan struct{}
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{artifacts: make(map[string]*Artifact)}
+}
+
+func (s *mapStore) Store(ctx context.Context, artifact *Artifact) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.artifacts[artifact.ID] = artifact
+	s.writes = append(s.writes, artifact)
+	return nil
+}
+
+func (s *mapStore) Get(ctx context.Context, id string) (*Artifact, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	artifact, ok := s.artifacts[id]
+	return artifact, ok, nil
+}
+
+func newCheckpointedTree(t *testing.T, model agenkit.Agent, store CheckpointStore) *TreeOfThought {
+	t.Helper()
+	tot, err := NewTreeOfThought("tot", model, TreeOfThoughtConfig{
+		Branching:       2,
+		BeamWidth:       1,
+		MaxDepth:        2,
+		Checkpoints:     store,
+		CheckpointEvery: 1,
+		Scorer: tableScorer(map[string]float64{
+			"step A": 0.2, "step B": 0.8, "41": 0.4, "42": 1.0,
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TreeOfThought: %v", err)
+	}
+	return tot
+}
+
+func TestTreeOfThoughtResumesFromCheckpoint(t *testing.T) {
+	store := newMapStore()
+	ctx := session.WithSession(context.Background(), session.NewSession("run-1"))
+	question := agenkit.NewMessage("user", "What is 6*7?")
+
+	// The first attempt fails after the first depth level
+	crashing := testutil.NewMockAgent(t, "model")
+	crashing.Expect("", "step A")
+	crashing.Expect("", "step B")
+	crashing.Expect("", "").WithError(errors.New("process killed"))
+	if _, err := newCheckpointedTree(t, crashing, store).Reason(ctx, question); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	saved, ok, _ := store.Get(ctx, "checkpoint:tot:run-1")
+	if !ok || saved.Complete {
+		t.Fatalf("Expected an incomplete checkpoint, got %+v", saved)
+	}
+
+	// The retry only searches the remaining depth
+	retry := testutil.NewMockAgent(t, "model")
+	retry.Expect("", "Final Answer: 41")
+	retry.Expect("", "Final Answer: 42")
+	artifact, err := newCheckpointedTree(t, retry, store).Reason(ctx, question)
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "42" || artifact.Metadata["resumed"] != true {
+		t.Errorf("Expected resumed answer '42', got '%s' (resumed %v)", artifact.Answer, artifact.Metadata["resumed"])
+	}
+	if artifact.Metadata["nodes"] != 4 {
+		t.Errorf("Expected the restored nodes to be kept, got %v nodes", artifact.Metadata["nodes"])
+	}
+	retry.AssertExpectationsMet()
+
+	saved, _, _ = store.Get(ctx, "checkpoint:tot:run-1")
+	if !saved.Complete || saved.Answer != "42" {
+		t.Errorf("Expected the finished artifact to be saved as complete, got %+v", saved)
+	}
+
+	// A finished run is returned without searching again
+	idle := testutil.NewMockAgent(t, "model")
+	artifact, err = newCheckpointedTree(t, idle, store).Reason(ctx, question)
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "42" || len(idle.Calls()) != 0 {
+		t.Errorf("Expected the saved answer without model calls, got '%s' after %d calls", artifact.Answer, len(idle.Calls()))
+	}
+}
+
+func TestTreeOfThoughtCheckpointsRequireSession(t *testing.T) {
+	store := newMapStore()
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Final Answer: 41")
+	model.Expect("", "Final Answer: 42")
+	tot := newCheckpointedTree(t, model, store)
+
+	artifact, err := tot.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Metadata["resumed"] != false || len(store.writes) != 0 {
+		t.Errorf("Expected no checkpoints without a session, got %d writes", len(store.writes))
+	}
+
+	if _, err := NewTreeOfThought("tot", model, TreeOfThoughtConfig{Checkpoints: store}); err == nil {
+		t.Error("Expected error for checkpoints without CheckpointEvery")
+	}
+}
+
+func TestCheckpointWritesDoNotBlockSearch(t *testing.T) {
+	store := newMapStore()
+	store.release = make(chan struct{})
+	ctx := session.WithSession(context.Background(), session.NewSession("slow"))
+
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "step A")
+	model.Expect("", "step B")
+	model.Expect("", "Final Answer: 41")
+	model.Expect("", "Final Answer: 42")
+	tot := newCheckpointedTree(t, model, store)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := tot.Reason(ctx, agenkit.NewMessage("user", "q"))
+		done <- err
+	}()
+
+	// The search reaches the second depth level while the first write hangs
+	deadline := time.Now().Add(time.Second)
+	for len(model.Calls()) < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the search to continue during a slow write, got %d calls", len(model.Calls()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(store.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+
+	// Checkpoints queued behind the slow write were superseded, so the
+	// final artifact is written last and only the latest state waited
+	writes := len(store.writes)
+	if writes == 0 || writes > 2 || !store.writes[writes-1].Complete {
+		t.Errorf("Expected at most one checkpoint before the complete artifact, got %d writes", writes)
+	}
+}
+
+func TestGraphOfThoughtResumesFromCheckpoint(t *testing.T) {
+	store := newMapStore()
+	ctx := session.WithSession(context.Background(), session.NewSession("run-2"))
+	question := agenkit.NewMessage("user", "q")
+	config := GraphOfThoughtConfig{
+		Iterations:      2,
+		Branching:       2,
+		BeamWidth:       1,
+		Checkpoints:     store,
+		CheckpointEvery: 1,
+		Scorer: tableScorer(map[string]float64{
+			"idea A": 0.3, "idea B": 0.5, "combined": 0.8, "one": 0.9, "two": 0.95, "merged": 0.99,
+		}),
+	}
+
+	crashing := testutil.NewMockAgent(t, "model")
+	crashing.Expect("", "idea A")
+	crashing.Expect("", "idea B")
+	crashing.Expect("", "combined")
+	crashing.Expect("", "").WithError(errors.New("process killed"))
+	got, _ := NewGraphOfThought("got", crashing, config)
+	if _, err := got.Reason(ctx, question); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+
+	retry := testutil.NewMockAgent(t, "model")
+	retry.Expect("", "Final Answer: one")
+	retry.Expect("", "Final Answer: two")
+	retry.Expect("", "Final Answer: merged")
+	got, _ = NewGraphOfThought("got", retry, config)
+	artifact, err := got.Reason(ctx, question)
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "merged" || artifact.Metadata["resumed"] != true {
+		t.Errorf("Expected resumed answer 'merged', got '%s' (resumed %v)", artifact.Answer, artifact.Metadata["resumed"])
+	}
+	nodes := artifact.Metadata["nodes"].([]GraphNode)
+	if len(nodes) != 7 || nodes[3].Thought != "combined" {
+		t.Errorf("Expected the restored graph to be extended, got %d nodes", len(nodes))
+	}
+	if len(retry.Calls()) != 3 || retry.Calls()[0].Content != buildGraphExpandPrompt("q", nodes[3]) {
+		t.Errorf("Expected the retry to expand the restored best node")
+	}
+}
+
+func TestGraphOfThoughtSkipsIncompleteSeeds(t *testing.T) {
+	partial := NewArtifact("tree_of_thought", "q")
+	partial.Complete = false
+	partial.Answer = "half done"
+
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Final Answer: fresh")
+	got, _ := NewGraphOfThought("got", model, GraphOfThoughtConfig{
+		Iterations: 1,
+		Branching:  1,
+		Memory:     staticRetriever{partial},
+		Scorer:     tableScorer(map[string]float64{"fresh": 0.5}),
+	})
+
+	artifact, err := got.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if seeds := artifact.Metadata["seeds"].([]SeedRecord); len(seeds) != 0 {
+		t.Errorf("Expected incomplete artifacts not to be seeded, got %v", seeds)
+	}
+}
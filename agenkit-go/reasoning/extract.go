@@ -0,0 +1,59 @@
+package reasoning
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// AnswerExtractor pulls a comparable final answer out of a chain's response.
+// An empty result means no answer could be extracted.
+type AnswerExtractor func(*agenkit.Message) string
+
+// ExactAnswer returns the trimmed response content, for categorical answers.
+func ExactAnswer(message *agenkit.Message) string {
+	return strings.TrimSpace(message.Content)
+}
+
+var numberPattern = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?`)
+
+// NumericAnswer returns the last number in the response, with thousands
+// separators removed, for arithmetic tasks where the answer follows the working.
+func NumericAnswer(message *agenkit.Message) string {
+	matches := numberPattern.FindAllString(message.Content, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.ReplaceAll(matches[len(matches)-1], ",", "")
+}
+
+// RegexAnswer returns an extractor yielding the first capture group of the
+// last match of pattern (or the whole match if the pattern has no groups).
+func RegexAnswer(pattern string) (AnswerExtractor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(message *agenkit.Message) string {
+		matches := re.FindAllStringSubmatch(message.Content, -1)
+		if len(matches) == 0 {
+			return ""
+		}
+		last := matches[len(matches)-1]
+		if len(last) > 1 {
+			return strings.TrimSpace(last[1])
+		}
+		return strings.TrimSpace(last[0])
+	}, nil
+}
+
+// FinalAnswer returns the text after the last "Final Answer:" marker, or the
+// trimmed content if there is none.
+func FinalAnswer(message *agenkit.Message) string {
+	content := message.Content
+	if idx := strings.LastIndex(content, "Final Answer:"); idx >= 0 {
+		return strings.TrimSpace(content[idx+len("Final Answer:"):])
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,576 @@
+package reasoning
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ArtifactRetriever returns stored artifacts relevant to a query.
+// memory.Memory satisfies it.
+type ArtifactRetriever interface {
+	Retrieve(ctx context.Context, query string, topK int) ([]*Artifact, error)
+}
+
+// GraphNode is a thought in a graph of thought.
+type GraphNode struct {
+	// ID identifies the node; the root (the question) is 0.
+	ID int `json:"id"`
+
+	// Parents lists the nodes this thought was derived from.
+	Parents []int `json:"parents"`
+
+	// Thought is the node's text; for the root, the question.
+	Thought string `json:"thought"`
+
+	// Path holds the thoughts leading to and including this one.
+	Path []string `json:"path"`
+
+	// Score is the node's confidence in [0, 1].
+	Score float64 `json:"score"`
+
+	// Aggregated reports whether the node merges several parents.
+	Aggregated bool `json:"aggregated,omitempty"`
+
+	// SeedArtifactID names the stored artifact a seeded node came from.
+	SeedArtifactID string `json:"seed_artifact_id,omitempty"`
+}
+
+// GraphEdge links a thought to one derived from it.
+type GraphEdge struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+
+	// Confidence is the target node's score; for seeded edges, the decayed
+	// prior confidence.
+	Confidence float64 `json:"confidence"`
+
+	// RawConfidence is a seeded edge's confidence before decay.
+	RawConfidence float64 `json:"raw_confidence,omitempty"`
+}
+
+// SeedRecord explains how a stored artifact was treated when seeding.
+type SeedRecord struct {
+	ArtifactID        string        `json:"artifact_id"`
+	Answer            string        `json:"answer"`
+	Age               time.Duration `json:"age"`
+	RawConfidence     float64       `json:"raw_confidence"`
+	DecayedConfidence float64       `json:"decayed_confidence"`
+
+	// Seeded is false when the decayed confidence fell below the pruning
+	// threshold.
+	Seeded bool `json:"seeded"`
+
+	// NodeID is the seeded node, or -1 if the artifact was pruned.
+	NodeID int `json:"node_id"`
+}
+
+// GraphOfThoughtConfig configures the GraphOfThought technique.
+type GraphOfThoughtConfig struct {
+	// Iterations is the number of generate/aggregate rounds.
+	// Default: 3
+	Iterations int
+
+	// Branching is the number of new thoughts generated from each expanded node.
+	// Default: 2
+	Branching int
+
+	// BeamWidth is the number of best unexpanded nodes expanded per round.
+	// Default: 2
+	BeamWidth int
+
+	// AggregateSize is the number of best nodes merged into one thought at
+	// the end of each round.
+	// Default: 2
+	AggregateSize int
+
+	// MaxNodes caps the number of nodes in the graph, seeds included.
+	// Default: 20
+	MaxNodes int
+
+	// Scorer rates each generated node.
+	// Default: ModelScorer using the technique's model
+	Scorer Scorer
+
+	// ExtractAnswer pulls the answer out of the best node's thought.
+	// Default: FinalAnswer
+	ExtractAnswer AnswerExtractor
+
+	// Memory, if set, supplies prior artifacts for the query, which are
+	// added to the graph as already-scored thoughts before exploration.
+	Memory ArtifactRetriever
+
+	// SeedCount is the number of prior artifacts retrieved from Memory.
+	// Default: 3
+	SeedCount int
+
+	// DecayHalfLife ages seeded confidences: an artifact's confidence is
+	// multiplied by 0.5^(age/DecayHalfLife).
+	// Default: 0 (no decay)
+	DecayHalfLife time.Duration
+
+	// PruneThreshold is the decayed confidence below which a prior artifact
+	// is not seeded.
+	// Default: 0 (seed every retrieved artifact)
+	PruneThreshold float64
+
+	// Checkpoints, if set, receives the graph as the run progresses, so that
+	// a run interrupted by a crash can resume. Runs are identified by the
+	// session attached to the context (see session.WithSession); runs
+	// without a session are not checkpointed.
+	Checkpoints CheckpointStore
+
+	// CheckpointEvery is the number of nodes added between checkpoints.
+	// Checkpoints are taken at the end of a round, once at least this many
+	// nodes have been added since the last one. Required when Checkpoints
+	// is set.
+	// Default: 0 (no checkpoints)
+	CheckpointEvery int
+}
+
+// graphCheckpoint is the search state saved in a GraphOfThought checkpoint.
+type graphCheckpoint struct {
+	Round        int          `json:"round"`
+	Nodes        []GraphNode  `json:"nodes"`
+	Edges        []GraphEdge  `json:"edges"`
+	Expanded     []int        `json:"expanded"`
+	Aggregated   []string     `json:"aggregated"`
+	Seeds        []SeedRecord `json:"seeds"`
+	LimitReached bool         `json:"limit_reached"`
+}
+
+// GraphOfThought explores reasoning as a graph rather than a tree.
+//
+// Each round expands the BeamWidth best unexpanded nodes with Branching new
+// thoughts, then merges the AggregateSize best nodes into a combined thought,
+// so promising lines of reasoning can be joined. Every new node is scored,
+// and the highest-scoring node gives the answer, with its score as the
+// artifact's Confidence. The artifact metadata records:
+//
+//   - "nodes": every []GraphNode, root first
+//   - "edges": every []GraphEdge
+//   - "seeds": a []SeedRecord per artifact retrieved from Memory
+//   - "best_node": the ID of the node that gave the answer
+//   - "node_limit_reached": whether MaxNodes cut the search short
+//   - "resumed": whether the run continued from a checkpoint
+//   - "checkpoint_errors": the number of failed checkpoint writes, if any
+//
+// Incomplete artifacts in Memory, such as checkpoints, are not seeded.
+// With checkpointing configured, the graph is saved every CheckpointEvery
+// nodes without pausing the search, and the finished artifact is saved as
+// complete. Rerunning the same question in the same session resumes from
+// the last checkpoint, or returns the saved artifact if the earlier run
+// finished.
+type GraphOfThought struct {
+	name   string
+	model  agenkit.Agent
+	config GraphOfThoughtConfig
+}
+
+// Verify that GraphOfThought implements Technique and
+// agenkit.DeterminismReporter interfaces.
+var (
+	_ Technique                   = (*GraphOfThought)(nil)
+	_ agenkit.DeterminismReporter = (*GraphOfThought)(nil)
+)
+
+// NewGraphOfThought creates a new graph-of-thought technique driven by model.
+func NewGraphOfThought(name string, model agenkit.Agent, config GraphOfThoughtConfig) (*GraphOfThought, error) {
+	if model == nil {
+		return nil, fmt.Errorf("graph of thought requires a model agent")
+	}
+	if config.Iterations <= 0 {
+		config.Iterations = 3
+	}
+	if config.Branching <= 0 {
+		config.Branching = 2
+	}
+	if config.BeamWidth <= 0 {
+		config.BeamWidth = 2
+	}
+	if config.AggregateSize <= 0 {
+		config.AggregateSize = 2
+	}
+	if config.MaxNodes <= 0 {
+		config.MaxNodes = 20
+	}
+	if config.Scorer == nil {
+		config.Scorer = ModelScorer(model)
+	}
+	if config.ExtractAnswer == nil {
+		config.ExtractAnswer = FinalAnswer
+	}
+	if config.SeedCount <= 0 {
+		config.SeedCount = 3
+	}
+	if config.Checkpoints != nil && config.CheckpointEvery <= 0 {
+		return nil, fmt.Errorf("checkpoint every must be positive when checkpoints are set, got %d", config.CheckpointEvery)
+	}
+	return &GraphOfThought{
+		name:   name,
+		model:  model,
+		config: config,
+	}, nil
+}
+
+// Name returns the name of the technique.
+func (g *GraphOfThought) Name() string {
+	return g.name
+}
+
+// Capabilities returns the model's capabilities plus the technique markers.
+func (g *GraphOfThought) Capabilities() []string {
+	return append(g.model.Capabilities(), "reasoning", "graph_of_thought")
+}
+
+// Deterministic reports false when the graph is seeded from Memory, whose
+// artifacts change between runs. Otherwise the model, reached through
+// GetAgents, decides; a custom Scorer is assumed deterministic.
+func (g *GraphOfThought) Deterministic() bool {
+	return g.config.Memory == nil
+}
+
+// GetAgents returns the model driving the search.
+func (g *GraphOfThought) GetAgents() []agenkit.Agent {
+	return []agenkit.Agent{g.model}
+}
+
+// Process runs the technique and returns the best answer.
+func (g *GraphOfThought) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, finish := agenkit.TrackAgent(ctx, g.name, message)
+	defer func() { finish(result, err) }()
+
+	artifact, err := g.Reason(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return artifact.ToMessage(), nil
+}
+
+// thoughtGraph is the state of one run.
+type thoughtGraph struct {
+	question   string
+	nodes      []GraphNode
+	edges      []GraphEdge
+	expanded   map[int]bool
+	aggregated map[string]bool
+}
+
+// Reason seeds the graph from memory, explores it, and returns the best node's answer.
+func (g *GraphOfThought) Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error) {
+	checkpoints := startCheckpoints(ctx, g.config.Checkpoints, g.name, "graph_of_thought", message.Content)
+	saved, err := checkpoints.resume(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("graph of thought: %w", err)
+	}
+	if saved != nil && saved.Complete {
+		return resumedArtifact(saved), nil
+	}
+	defer checkpoints.stop()
+
+	graph := &thoughtGraph{
+		question:   message.Content,
+		nodes:      []GraphNode{{ID: 0, Thought: message.Content}},
+		expanded:   make(map[int]bool),
+		aggregated: make(map[string]bool),
+	}
+
+	var state graphCheckpoint
+	if saved != nil {
+		if err := decodeCheckpoint(saved, &state); err != nil {
+			return nil, fmt.Errorf("graph of thought: %w", err)
+		}
+		graph.restore(state)
+	} else {
+		state.Seeds, err = g.seed(ctx, graph)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	limitReached := state.LimitReached
+	checkpointed := len(graph.nodes)
+	for round := state.Round + 1; round <= g.config.Iterations && !limitReached; round++ {
+		limitReached, err = g.round(ctx, graph, round)
+		if err != nil {
+			return nil, err
+		}
+		if len(graph.nodes)-checkpointed >= max(g.config.CheckpointEvery, 1) {
+			checkpoints.save(ctx, graph.checkpoint(round, state.Seeds, limitReached))
+			checkpointed = len(graph.nodes)
+		}
+	}
+
+	best := graph.best(1, nil)
+	if len(best) == 0 {
+		return nil, fmt.Errorf("graph of thought produced no thoughts")
+	}
+
+	artifact := NewArtifact("graph_of_thought", message.Content)
+	artifact.Answer = g.config.ExtractAnswer(agenkit.NewMessage("agent", best[0].Thought))
+	artifact.Confidence = best[0].Score
+	artifact.Metadata["nodes"] = graph.nodes
+	artifact.Metadata["edges"] = graph.edges
+	artifact.Metadata["seeds"] = state.Seeds
+	artifact.Metadata["best_node"] = best[0].ID
+	artifact.Metadata["node_limit_reached"] = limitReached
+	artifact.Metadata["resumed"] = saved != nil
+	if failed := checkpoints.finish(ctx, artifact); failed > 0 {
+		artifact.Metadata["checkpoint_errors"] = failed
+	}
+	return artifact, nil
+}
+
+// round expands the best unexpanded nodes and aggregates the best group,
+// reporting whether MaxNodes was reached.
+func (g *GraphOfThought) round(ctx context.Context, graph *thoughtGraph, round int) (bool, error) {
+	frontier := graph.best(g.config.BeamWidth, func(n GraphNode) bool { return !graph.expanded[n.ID] })
+	if len(frontier) == 0 && round == 1 {
+		frontier = []GraphNode{graph.nodes[0]}
+	}
+	for _, parent := range frontier {
+		graph.expanded[parent.ID] = true
+		for b := 0; b < g.config.Branching; b++ {
+			if len(graph.nodes) >= g.config.MaxNodes {
+				return true, nil
+			}
+			response, err := g.call(ctx, buildGraphExpandPrompt(graph.question, parent))
+			if err != nil {
+				return false, fmt.Errorf("graph of thought round %d: %w", round, err)
+			}
+			if err := g.add(ctx, graph, response, []GraphNode{parent}); err != nil {
+				return false, fmt.Errorf("graph of thought round %d: %w", round, err)
+			}
+		}
+	}
+
+	group := graph.best(g.config.AggregateSize, nil)
+	key := groupKey(group)
+	if len(group) < 2 || graph.aggregated[key] {
+		return false, nil
+	}
+	if len(graph.nodes) >= g.config.MaxNodes {
+		return true, nil
+	}
+	graph.aggregated[key] = true
+	response, err := g.call(ctx, buildGraphAggregatePrompt(graph.question, group))
+	if err != nil {
+		return false, fmt.Errorf("graph of thought round %d: aggregation: %w", round, err)
+	}
+	if err := g.add(ctx, graph, response, group); err != nil {
+		return false, fmt.Errorf("graph of thought round %d: aggregation: %w", round, err)
+	}
+	return false, nil
+}
+
+// seed adds prior artifacts from memory to the graph, decaying their
+// confidence by age and pruning those below the threshold.
+func (g *GraphOfThought) seed(ctx context.Context, graph *thoughtGraph) ([]SeedRecord, error) {
+	if g.config.Memory == nil {
+		return nil, nil
+	}
+	priors, err := g.config.Memory.Retrieve(ctx, graph.question, g.config.SeedCount)
+	if err != nil {
+		return nil, fmt.Errorf("graph of thought: failed to retrieve prior artifacts: %w", err)
+	}
+
+	now := time.Now()
+	records := make([]SeedRecord, 0, len(priors))
+	for _, prior := range priors {
+		if !prior.Complete {
+			continue
+		}
+		age := max(now.Sub(prior.CreatedAt), 0)
+		record := SeedRecord{
+			ArtifactID:        prior.ID,
+			Answer:            prior.Answer,
+			Age:               age,
+			RawConfidence:     prior.Confidence,
+			DecayedConfidence: decayConfidence(prior.Confidence, age, g.config.DecayHalfLife),
+			NodeID:            -1,
+		}
+		if record.DecayedConfidence >= g.config.PruneThreshold && len(graph.nodes) < g.config.MaxNodes {
+			node := GraphNode{
+				ID:             len(graph.nodes),
+				Parents:        []int{0},
+				Thought:        prior.Answer,
+				Path:           []string{prior.Answer},
+				Score:          record.DecayedConfidence,
+				SeedArtifactID: prior.ID,
+			}
+			graph.nodes = append(graph.nodes, node)
+			graph.edges = append(graph.edges, GraphEdge{
+				From:          0,
+				To:            node.ID,
+				Confidence:    record.DecayedConfidence,
+				RawConfidence: record.RawConfidence,
+			})
+			record.Seeded = true
+			record.NodeID = node.ID
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// decayConfidence halves confidence every halfLife of age. A non-positive
+// halfLife disables decay.
+func decayConfidence(confidence float64, age, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return confidence
+	}
+	return confidence * math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// call sends a prompt to the model and returns the trimmed reply.
+func (g *GraphOfThought) call(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("cancelled: %w", err)
+	}
+	response, err := g.model.Process(ctx, agenkit.NewMessage("user", prompt))
+	if err != nil {
+		return "", fmt.Errorf("model failed: %w", err)
+	}
+	return strings.TrimSpace(response.Content), nil
+}
+
+// add scores a new thought derived from parents and adds it to the graph.
+func (g *GraphOfThought) add(ctx context.Context, graph *thoughtGraph, thought string, parents []GraphNode) error {
+	node := GraphNode{
+		ID:         len(graph.nodes),
+		Thought:    thought,
+		Aggregated: len(parents) > 1,
+	}
+	depth := 0
+	for _, parent := range parents {
+		node.Parents = append(node.Parents, parent.ID)
+		depth = max(depth, len(parent.Path))
+	}
+	node.Path = append(append([]string(nil), parents[0].Path...), thought)
+
+	score, err := g.config.Scorer(ctx, ThoughtNode{
+		ID:       node.ID,
+		ParentID: parents[0].ID,
+		Depth:    depth + 1,
+		Question: graph.question,
+		Thought:  thought,
+		Path:     node.Path,
+	})
+	if err != nil {
+		return fmt.Errorf("scorer failed: %w", err)
+	}
+	node.Score = score
+
+	graph.nodes = append(graph.nodes, node)
+	for _, parent := range parents {
+		graph.edges = append(graph.edges, GraphEdge{From: parent.ID, To: node.ID, Confidence: score})
+	}
+	agenkit.Emit(ctx, agenkit.EventThoughtGenerated, g.name, map[string]interface{}{
+		"node":       node.ID,
+		"parents":    node.Parents,
+		"thought":    node.Thought,
+		"score":      node.Score,
+		"aggregated": node.Aggregated,
+	})
+	return nil
+}
+
+// checkpoint captures the graph after round.
+func (t *thoughtGraph) checkpoint(round int, seeds []SeedRecord, limitReached bool) graphCheckpoint {
+	state := graphCheckpoint{
+		Round:        round,
+		Nodes:        t.nodes[:len(t.nodes):len(t.nodes)],
+		Edges:        t.edges[:len(t.edges):len(t.edges)],
+		Seeds:        seeds,
+		LimitReached: limitReached,
+	}
+	for id := range t.expanded {
+		state.Expanded = append(state.Expanded, id)
+	}
+	sort.Ints(state.Expanded)
+	for key := range t.aggregated {
+		state.Aggregated = append(state.Aggregated, key)
+	}
+	sort.Strings(state.Aggregated)
+	return state
+}
+
+// restore replaces the graph with a checkpoint's state.
+func (t *thoughtGraph) restore(state graphCheckpoint) {
+	t.nodes = state.Nodes
+	t.edges = state.Edges
+	for _, id := range state.Expanded {
+		t.expanded[id] = true
+	}
+	for _, key := range state.Aggregated {
+		t.aggregated[key] = true
+	}
+}
+
+// best returns up to n non-root nodes accepted by keep (all if nil),
+// highest score first.
+func (t *thoughtGraph) best(n int, keep func(GraphNode) bool) []GraphNode {
+	var candidates []GraphNode
+	for _, node := range t.nodes[1:] {
+		if keep == nil || keep(node) {
+			candidates = append(candidates, node)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// groupKey identifies a set of nodes regardless of order.
+func groupKey(nodes []GraphNode) string {
+	ids := make([]int, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// buildGraphExpandPrompt asks for a thought building on parent.
+func buildGraphExpandPrompt(question string, parent GraphNode) string {
+	var sb strings.Builder
+	sb.WriteString("Solve the problem below.\n\nProblem:\n")
+	sb.WriteString(question)
+	sb.WriteString("\n")
+	if len(parent.Path) > 0 {
+		sb.WriteString("\nBuild on this reasoning:\n")
+		for i, step := range parent.Path {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+		}
+		sb.WriteString("\nWrite the next step or an improved approach.")
+	} else {
+		sb.WriteString("\nWrite a first step toward a solution.")
+	}
+	sb.WriteString(" If the problem is solved, end with \"Final Answer: <answer>\".")
+	return sb.String()
+}
+
+// buildGraphAggregatePrompt asks for a thought merging several nodes.
+func buildGraphAggregatePrompt(question string, nodes []GraphNode) string {
+	var sb strings.Builder
+	sb.WriteString("Combine the partial solutions below into a single, better solution.\n\nProblem:\n")
+	sb.WriteString(question)
+	sb.WriteString("\n\nPartial solutions:\n")
+	for i, node := range nodes {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, node.Thought))
+	}
+	sb.WriteString("\nIf the problem is solved, end with \"Final Answer: <answer>\".")
+	return sb.String()
+}
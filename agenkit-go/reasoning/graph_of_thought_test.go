@@ -0,0 +1,150 @@
+package reasoning
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// staticRetriever returns fixed artifacts.
+type staticRetriever []*Artifact
+
+func (s staticRetriever) Retrieve(ctx context.Context, query string, topK int) ([]*Artifact, error) {
+	if len(s) > topK {
+		return s[:topK], nil
+	}
+	return s, nil
+}
+
+func TestGraphOfThoughtExpandsAndAggregates(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "idea A")
+	model.Expect("", "idea B")
+	model.Expect("", "Final Answer: merged")
+
+	got, err := NewGraphOfThought("got", model, GraphOfThoughtConfig{
+		Iterations: 1,
+		Branching:  2,
+		Scorer:     tableScorer(map[string]float64{"idea A": 0.4, "idea B": 0.6, "merged": 0.9}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create GraphOfThought: %v", err)
+	}
+
+	artifact, err := got.Reason(context.Background(), agenkit.NewMessage("user", "Plan a trip"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "merged" || artifact.Confidence != 0.9 {
+		t.Errorf("Expected 'merged' with confidence 0.9, got '%s' (%v)", artifact.Answer, artifact.Confidence)
+	}
+
+	nodes := artifact.Metadata["nodes"].([]GraphNode)
+	if len(nodes) != 4 {
+		t.Fatalf("Expected root, two ideas and a merge, got %d nodes", len(nodes))
+	}
+	merged := nodes[3]
+	if !merged.Aggregated || len(merged.Parents) != 2 || merged.Parents[0] != 2 {
+		t.Errorf("Expected merge of both ideas, best first, got %+v", merged)
+	}
+	if edges := artifact.Metadata["edges"].([]GraphEdge); len(edges) != 4 {
+		t.Errorf("Expected 4 edges, got %d", len(edges))
+	}
+	aggregatePrompt := model.Calls()[2].Content
+	if !strings.Contains(aggregatePrompt, "1. idea B\n2. idea A") {
+		t.Errorf("Expected aggregation prompt to list both ideas, got:\n%s", aggregatePrompt)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestGraphOfThoughtSeedsWithDecay(t *testing.T) {
+	fresh := NewArtifact("reflexion", "q")
+	fresh.Answer = "fresh plan"
+	fresh.Confidence = 0.8
+	stale := NewArtifact("reflexion", "q")
+	stale.Answer = "stale plan"
+	stale.Confidence = 0.8
+	stale.CreatedAt = time.Now().Add(-2 * time.Hour)
+
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "refined plan")
+	model.Expect("", "Final Answer: combined")
+
+	got, _ := NewGraphOfThought("got", model, GraphOfThoughtConfig{
+		Iterations:     1,
+		Branching:      1,
+		BeamWidth:      1,
+		Memory:         staticRetriever{fresh, stale},
+		DecayHalfLife:  time.Hour,
+		PruneThreshold: 0.3,
+		Scorer:         tableScorer(map[string]float64{"refined": 0.5, "combined": 0.95}),
+	})
+
+	artifact, err := got.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+
+	seeds := artifact.Metadata["seeds"].([]SeedRecord)
+	if len(seeds) != 2 {
+		t.Fatalf("Expected 2 seed records, got %d", len(seeds))
+	}
+	if !seeds[0].Seeded || seeds[0].NodeID != 1 {
+		t.Errorf("Expected the fresh artifact to be seeded as node 1, got %+v", seeds[0])
+	}
+	if seeds[1].Seeded || seeds[1].NodeID != -1 {
+		t.Errorf("Expected the stale artifact to be pruned, got %+v", seeds[1])
+	}
+	if seeds[1].RawConfidence != 0.8 || math.Abs(seeds[1].DecayedConfidence-0.2) > 0.001 {
+		t.Errorf("Expected stale confidence 0.8 decayed to ~0.2, got %+v", seeds[1])
+	}
+
+	edges := artifact.Metadata["edges"].([]GraphEdge)
+	if edges[0].From != 0 || edges[0].To != 1 || edges[0].RawConfidence != 0.8 {
+		t.Errorf("Expected seeded edge from the root with raw confidence, got %+v", edges[0])
+	}
+	if !strings.Contains(model.Calls()[0].Content, "fresh plan") {
+		t.Errorf("Expected expansion to build on the seeded thought, got:\n%s", model.Calls()[0].Content)
+	}
+	if artifact.Answer != "combined" {
+		t.Errorf("Expected answer 'combined', got '%s'", artifact.Answer)
+	}
+}
+
+func TestGraphOfThoughtMaxNodes(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "one")
+	model.Expect("", "two")
+
+	got, _ := NewGraphOfThought("got", model, GraphOfThoughtConfig{
+		Branching: 4,
+		MaxNodes:  3,
+		Scorer:    tableScorer(map[string]float64{"one": 0.3, "two": 0.7}),
+	})
+
+	artifact, err := got.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Metadata["node_limit_reached"] != true {
+		t.Error("Expected node_limit_reached to be true")
+	}
+	if artifact.Answer != "two" {
+		t.Errorf("Expected best thought 'two', got '%s'", artifact.Answer)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestDecayConfidence(t *testing.T) {
+	if got := decayConfidence(0.8, time.Hour, 0); got != 0.8 {
+		t.Errorf("Expected no decay without a half-life, got %v", got)
+	}
+	if got := decayConfidence(0.8, time.Hour, time.Hour); math.Abs(got-0.4) > 1e-9 {
+		t.Errorf("Expected 0.4 after one half-life, got %v", got)
+	}
+}
@@ -0,0 +1,245 @@
+package reasoning
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// LeastToMostStep records one solved subproblem.
+type LeastToMostStep struct {
+	Subproblem string `json:"subproblem"`
+	Answer     string `json:"answer"`
+}
+
+// LeastToMostConfig configures the LeastToMost technique.
+type LeastToMostConfig struct {
+	// DecomposePrompt builds the prompt asking the model to break the
+	// problem into subproblems, one per line, easiest first.
+	// Default: a prompt asking for a numbered list
+	DecomposePrompt func(problem string) string
+
+	// SolvePrompt builds the prompt for one subproblem, given the
+	// subproblems already solved.
+	// Default: a prompt listing the problem and the earlier answers
+	SolvePrompt func(problem, subproblem string, solved []LeastToMostStep) string
+
+	// ComposePrompt builds the prompt combining the subproblem answers into
+	// the full solution.
+	// Default: a prompt listing every subproblem and its answer
+	ComposePrompt func(problem string, solved []LeastToMostStep) string
+
+	// MaxSubproblems caps the number of subproblems solved; later ones
+	// are dropped.
+	// Default: 8
+	MaxSubproblems int
+
+	// ExtractAnswer pulls the answer out of the final reply.
+	// Default: FinalAnswer
+	ExtractAnswer AnswerExtractor
+}
+
+// LeastToMost solves a problem by breaking it into simpler subproblems and
+// solving them in order, easiest first.
+//
+// The model is first asked for the subproblems, which it lists one per
+// line; list markers such as "1." and "-" are stripped. Each subproblem is
+// then solved with the answers to the earlier ones in the prompt, and a
+// final call composes the full solution from all of them. If the
+// decomposition yields at most one subproblem, the problem is solved
+// directly instead. The artifact metadata records:
+//
+//   - "subproblems": the []string decomposition, after MaxSubproblems
+//   - "steps": the []LeastToMostStep answers, in order
+//   - "decomposed": whether the problem was split rather than solved directly
+type LeastToMost struct {
+	name   string
+	model  agenkit.Agent
+	config LeastToMostConfig
+}
+
+// Verify that LeastToMost implements Technique interface.
+var _ Technique = (*LeastToMost)(nil)
+
+// NewLeastToMost creates a new least-to-most technique driven by model.
+func NewLeastToMost(name string, model agenkit.Agent, config LeastToMostConfig) (*LeastToMost, error) {
+	if model == nil {
+		return nil, fmt.Errorf("least to most requires a model agent")
+	}
+	if config.DecomposePrompt == nil {
+		config.DecomposePrompt = buildDecomposePrompt
+	}
+	if config.SolvePrompt == nil {
+		config.SolvePrompt = buildSubproblemPrompt
+	}
+	if config.ComposePrompt == nil {
+		config.ComposePrompt = buildComposePrompt
+	}
+	if config.MaxSubproblems <= 0 {
+		config.MaxSubproblems = 8
+	}
+	if config.ExtractAnswer == nil {
+		config.ExtractAnswer = FinalAnswer
+	}
+	return &LeastToMost{
+		name:   name,
+		model:  model,
+		config: config,
+	}, nil
+}
+
+// Name returns the name of the technique.
+func (l *LeastToMost) Name() string {
+	return l.name
+}
+
+// Capabilities returns the model's capabilities plus the technique markers.
+func (l *LeastToMost) Capabilities() []string {
+	return append(l.model.Capabilities(), "reasoning", "least_to_most")
+}
+
+// Process runs the technique and returns the composed answer.
+func (l *LeastToMost) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, finish := agenkit.TrackAgent(ctx, l.name, message)
+	defer func() { finish(result, err) }()
+
+	artifact, err := l.Reason(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return artifact.ToMessage(), nil
+}
+
+// Reason decomposes the problem, solves the subproblems in order, and
+// composes their answers.
+func (l *LeastToMost) Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error) {
+	problem := message.Content
+	decomposition, err := l.call(ctx, message.Role, l.config.DecomposePrompt(problem))
+	if err != nil {
+		return nil, fmt.Errorf("least to most decomposition failed: %w", err)
+	}
+	subproblems := parseSubproblems(decomposition)
+	if len(subproblems) > l.config.MaxSubproblems {
+		subproblems = subproblems[:l.config.MaxSubproblems]
+	}
+
+	artifact := NewArtifact("least_to_most", problem)
+	artifact.Metadata["subproblems"] = subproblems
+	if len(subproblems) <= 1 {
+		// Nothing to build up from; solve the problem as asked
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("least to most cancelled: %w", err)
+		}
+		answer, err := l.model.Process(ctx, message)
+		if err != nil {
+			return nil, fmt.Errorf("least to most solve failed: %w", err)
+		}
+		artifact.Answer = l.config.ExtractAnswer(answer)
+		artifact.Metadata["steps"] = []LeastToMostStep{{Subproblem: problem, Answer: artifact.Answer}}
+		artifact.Metadata["decomposed"] = false
+		return artifact, nil
+	}
+
+	steps := make([]LeastToMostStep, 0, len(subproblems))
+	for i, subproblem := range subproblems {
+		answer, err := l.call(ctx, message.Role, l.config.SolvePrompt(problem, subproblem, steps))
+		if err != nil {
+			return nil, fmt.Errorf("least to most subproblem %d failed: %w", i+1, err)
+		}
+		steps = append(steps, LeastToMostStep{Subproblem: subproblem, Answer: strings.TrimSpace(answer)})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("least to most cancelled: %w", err)
+	}
+	composed, err := l.model.Process(ctx, agenkit.NewMessage(message.Role, l.config.ComposePrompt(problem, steps)))
+	if err != nil {
+		return nil, fmt.Errorf("least to most composition failed: %w", err)
+	}
+	artifact.Answer = l.config.ExtractAnswer(composed)
+	artifact.Metadata["steps"] = steps
+	artifact.Metadata["decomposed"] = true
+	return artifact, nil
+}
+
+// call sends prompt to the model and returns the reply's content.
+func (l *LeastToMost) call(ctx context.Context, role, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	response, err := l.model.Process(ctx, agenkit.NewMessage(role, prompt))
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// listMarker matches a leading list marker: "1.", "2)", "-", "*" or "•".
+var listMarker = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*•])\s*`)
+
+// parseSubproblems returns the non-empty lines of a decomposition with
+// list markers removed. When some lines are list items, only those are
+// kept, so a preamble such as "Here are the steps:" is skipped.
+func parseSubproblems(text string) []string {
+	var items, lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if marker := listMarker.FindString(line); marker != "" {
+			if item := strings.TrimSpace(line[len(marker):]); item != "" {
+				items = append(items, item)
+			}
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if len(items) > 0 {
+		return items
+	}
+	return lines
+}
+
+// buildDecomposePrompt asks for the subproblems of problem.
+func buildDecomposePrompt(problem string) string {
+	return fmt.Sprintf(`Break the problem below into the simpler subproblems that must be solved to answer it, ordered so that each can be solved using the answers to the ones before it. The last subproblem should be the problem itself.
+
+Problem:
+%s
+
+Reply with a numbered list, one subproblem per line, and nothing else. If the problem cannot be broken down, reply with the problem alone.`, problem)
+}
+
+// buildSubproblemPrompt asks for the answer to one subproblem.
+func buildSubproblemPrompt(problem, subproblem string, solved []LeastToMostStep) string {
+	var sb strings.Builder
+	sb.WriteString("Problem:\n")
+	sb.WriteString(problem)
+	sb.WriteString("\n")
+	if len(solved) > 0 {
+		sb.WriteString("\nSolved so far:\n")
+		for i, step := range solved {
+			sb.WriteString(fmt.Sprintf("%d. %s\n   Answer: %s\n", i+1, step.Subproblem, step.Answer))
+		}
+	}
+	sb.WriteString("\nNow solve:\n")
+	sb.WriteString(subproblem)
+	sb.WriteString("\n\nReply with the answer to this subproblem only.")
+	return sb.String()
+}
+
+// buildComposePrompt asks for the full solution built from the answers.
+func buildComposePrompt(problem string, solved []LeastToMostStep) string {
+	var sb strings.Builder
+	sb.WriteString("Problem:\n")
+	sb.WriteString(problem)
+	sb.WriteString("\n\nSubproblems and their answers:\n")
+	for i, step := range solved {
+		sb.WriteString(fmt.Sprintf("%d. %s\n   Answer: %s\n", i+1, step.Subproblem, step.Answer))
+	}
+	sb.WriteString("\nUsing these answers, write the full solution to the problem and end with \"Final Answer: <answer>\".")
+	return sb.String()
+}
@@ -0,0 +1,116 @@
+package reasoning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+func TestLeastToMostSolvesSubproblemsInOrder(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Here are the subproblems:\n1. How long is one trip?\n2) How many trips fit in 60 minutes?")
+	model.Expect("", "15 minutes")
+	model.Expect("", "4 trips")
+	model.Expect("", "She can ride 4 times.\nFinal Answer: 4")
+
+	technique, err := NewLeastToMost("ltm", model, LeastToMostConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create LeastToMost: %v", err)
+	}
+
+	artifact, err := technique.Reason(context.Background(), agenkit.NewMessage("user", "How many slide rides fit in an hour?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "4" {
+		t.Errorf("Expected '4', got '%s'", artifact.Answer)
+	}
+	if artifact.Metadata["decomposed"] != true {
+		t.Error("Expected decomposed to be true")
+	}
+	subproblems := artifact.Metadata["subproblems"].([]string)
+	if len(subproblems) != 2 || subproblems[0] != "How long is one trip?" {
+		t.Errorf("Expected the two listed subproblems, got %q", subproblems)
+	}
+	steps := artifact.Metadata["steps"].([]LeastToMostStep)
+	if len(steps) != 2 || steps[1].Answer != "4 trips" {
+		t.Errorf("Unexpected steps: %+v", steps)
+	}
+
+	// The second subproblem sees the first answer, and composition sees both
+	calls := model.Calls()
+	if !strings.Contains(calls[2].Content, "15 minutes") || strings.Contains(calls[1].Content, "Solved so far") {
+		t.Errorf("Expected only earlier answers in subproblem prompts, got:\n%s", calls[2].Content)
+	}
+	if !strings.Contains(calls[3].Content, "15 minutes") || !strings.Contains(calls[3].Content, "4 trips") {
+		t.Errorf("Expected every answer in the composition prompt, got:\n%s", calls[3].Content)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestLeastToMostSolvesDirectlyWithoutDecomposition(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "1. What is 2 + 2?")
+	model.Expect("What is 2 + 2?", "Final Answer: 4")
+
+	technique, _ := NewLeastToMost("ltm", model, LeastToMostConfig{})
+	artifact, err := technique.Reason(context.Background(), agenkit.NewMessage("user", "What is 2 + 2?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "4" || artifact.Metadata["decomposed"] != false {
+		t.Errorf("Expected a direct answer '4', got '%s' (decomposed %v)", artifact.Answer, artifact.Metadata["decomposed"])
+	}
+	if len(model.Calls()) != 2 {
+		t.Errorf("Expected 2 calls, got %d", len(model.Calls()))
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestLeastToMostCustomPrompts(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("split: task", "- a\n- b\n- c")
+	model.Expect("solve a after 0", "A")
+	model.Expect("solve b after 1", "B")
+	model.Expect("compose 2", "AB")
+
+	technique, _ := NewLeastToMost("ltm", model, LeastToMostConfig{
+		DecomposePrompt: func(problem string) string { return "split: " + problem },
+		SolvePrompt: func(problem, subproblem string, solved []LeastToMostStep) string {
+			return fmt.Sprintf("solve %s after %d", subproblem, len(solved))
+		},
+		ComposePrompt: func(problem string, solved []LeastToMostStep) string {
+			return fmt.Sprintf("compose %d", len(solved))
+		},
+		MaxSubproblems: 2,
+	})
+	artifact, err := technique.Reason(context.Background(), agenkit.NewMessage("user", "task"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "AB" {
+		t.Errorf("Expected 'AB', got '%s'", artifact.Answer)
+	}
+	if subproblems := artifact.Metadata["subproblems"].([]string); len(subproblems) != 2 {
+		t.Errorf("Expected MaxSubproblems to drop the third subproblem, got %q", subproblems)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestLeastToMostPropagatesErrors(t *testing.T) {
+	errDown := errors.New("model down")
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "1. a\n2. b")
+	model.Expect("", "").WithError(errDown)
+
+	technique, _ := NewLeastToMost("ltm", model, LeastToMostConfig{})
+	_, err := technique.Reason(context.Background(), agenkit.NewMessage("user", "task"))
+	if !errors.Is(err, errDown) || !strings.Contains(err.Error(), "subproblem 1") {
+		t.Errorf("Expected the failing subproblem in the error, got %v", err)
+	}
+}
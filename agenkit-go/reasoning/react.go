@@ -0,0 +1,442 @@
+package reasoning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/tools"
+)
+
+// ErrMaxStepsReached is returned when ReAct exhausts its step budget
+// without the model producing a final answer.
+var ErrMaxStepsReached = errors.New("max steps reached without a final answer")
+
+// ErrLoopDetected is returned when ReAct stops a run that keeps repeating
+// an action and the model then still gives no final answer.
+var ErrLoopDetected = errors.New("repeated action loop detected")
+
+// ReActLoopAction is what ReAct does when the model repeats an action; see
+// ReActConfig.RepeatThreshold.
+type ReActLoopAction int
+
+const (
+	// ReActLoopNudge skips the repeated action and tells the model it is
+	// repeating itself, so it may try something else.
+	ReActLoopNudge ReActLoopAction = iota
+
+	// ReActLoopStop ends the run, asking the model once for its best final
+	// answer from the observations so far.
+	ReActLoopStop
+)
+
+// DefaultReActNudge is the observation shown for a repeated action by
+// ReActLoopNudge.
+const DefaultReActNudge = "You are repeating yourself: this action was already taken with the same input. Try a different approach or give your Final Answer."
+
+// ReActConfig configures the ReAct technique.
+type ReActConfig struct {
+	// Tools are the actions the model may take.
+	Tools []agenkit.Tool
+
+	// MaxSteps is the maximum number of thought/action/observation steps.
+	// Default: 5
+	MaxSteps int
+
+	// Executor runs the tool calls, applying its timeouts and concurrency
+	// limit and validating arguments. Timed-out calls and invalid
+	// arguments become error observations, except invalid arguments to
+	// tools the executor treats as fatal, which end the run. An executor
+	// with Memoize set answers repeated calls within a run from memory.
+	// Default: an executor with no limits
+	Executor *tools.Executor
+
+	// RepeatThreshold is the number of times the same action with the same
+	// input may be taken in a run. Taking it again is treated as a loop
+	// and handled by OnLoop. Zero disables loop detection.
+	// Default: 0
+	RepeatThreshold int
+
+	// OnLoop is what to do when a loop is detected.
+	// Default: ReActLoopNudge
+	OnLoop ReActLoopAction
+
+	// Nudge is the observation shown for a repeated action by
+	// ReActLoopNudge.
+	// Default: DefaultReActNudge
+	Nudge string
+}
+
+// ReActStep is one thought/action/observation triple of a ReAct trace.
+// The final step has no action; its thought precedes the final answer.
+// Result holds the tool's structured result when the tool ran, while
+// Observation is the text the model was shown.
+type ReActStep struct {
+	Thought     string                 `json:"thought"`
+	Action      string                 `json:"action,omitempty"`
+	ActionInput map[string]interface{} `json:"action_input,omitempty"`
+	Observation string                 `json:"observation,omitempty"`
+	Result      *agenkit.ToolResult    `json:"result,omitempty"`
+}
+
+// ReAct interleaves reasoning with tool use.
+//
+// On each step the model is shown the question, the available tools, and the
+// trace so far, and must reply in the form:
+//
+//	Thought: <reasoning>
+//	Action: <tool name>
+//	Action Input: <JSON object>
+//
+// or, once it knows the answer:
+//
+//	Thought: <reasoning>
+//	Final Answer: <answer>
+//
+// The chosen tool is executed and its result fed back as the observation,
+// rendered with ToolResult.ForModel so that tools returning structured data
+// control what the model sees.
+// Replies that cannot be parsed, unknown tools, arguments that break a
+// tool's schema, and tool failures become error observations so the model
+// can correct itself. With RepeatThreshold set, an action repeated with
+// the same input too often is not run again; the model is nudged or the run
+// stops early, per OnLoop. The artifact metadata records:
+//
+//   - "trace": the []ReActStep taken
+//   - "steps": number of steps used
+//   - "loop_detected": whether a repeated action was caught
+type ReAct struct {
+	name   string
+	model  agenkit.Agent
+	tools  map[string]agenkit.Tool
+	config ReActConfig
+}
+
+// Verify that ReAct implements Technique interface.
+var _ Technique = (*ReAct)(nil)
+
+// Verify that ReAct implements agenkit.ToolUser interface.
+var _ agenkit.ToolUser = (*ReAct)(nil)
+
+// NewReAct creates a new ReAct technique driven by model.
+func NewReAct(name string, model agenkit.Agent, config ReActConfig) (*ReAct, error) {
+	if model == nil {
+		return nil, fmt.Errorf("react requires a model agent")
+	}
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = 5
+	}
+	if config.Executor == nil {
+		config.Executor = tools.NewExecutor(tools.ExecutorConfig{})
+	}
+	if config.Nudge == "" {
+		config.Nudge = DefaultReActNudge
+	}
+
+	toolMap := make(map[string]agenkit.Tool, len(config.Tools))
+	for _, tool := range config.Tools {
+		if tool == nil || tool.Name() == "" {
+			return nil, fmt.Errorf("react tools must be non-nil and named")
+		}
+		if _, exists := toolMap[tool.Name()]; exists {
+			return nil, fmt.Errorf("tool '%s' is configured more than once", tool.Name())
+		}
+		toolMap[tool.Name()] = tool
+	}
+
+	return &ReAct{
+		name:   name,
+		model:  model,
+		tools:  toolMap,
+		config: config,
+	}, nil
+}
+
+// Name returns the name of the technique.
+func (r *ReAct) Name() string {
+	return r.name
+}
+
+// Capabilities returns the model's capabilities plus the technique markers.
+func (r *ReAct) Capabilities() []string {
+	return append(r.model.Capabilities(), "reasoning", "react")
+}
+
+// Process runs the technique and returns the final answer.
+func (r *ReAct) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, finish := agenkit.TrackAgent(ctx, r.name, message)
+	defer func() { finish(result, err) }()
+
+	artifact, err := r.Reason(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return artifact.ToMessage(), nil
+}
+
+// Reason runs the thought/action/observation loop until a final answer.
+func (r *ReAct) Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error) {
+	// Repeated tool calls within this run may be memoized by the executor
+	ctx = tools.WithMemo(ctx)
+	var trace []ReActStep
+	repeats := make(map[string]int)
+	loopDetected := false
+
+	for step := 1; step <= r.config.MaxSteps; step++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("react cancelled at step %d: %w", step, err)
+		}
+
+		prompt := agenkit.NewMessage("user", r.buildPrompt(message.Content, trace))
+		response, err := r.model.Process(ctx, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("react step %d: model failed: %w", step, err)
+		}
+
+		output, parseErr := parseReActOutput(response.Content)
+		agenkit.Emit(ctx, agenkit.EventThoughtGenerated, r.name, map[string]interface{}{
+			"step":    step,
+			"thought": output.thought,
+			"action":  output.action,
+		})
+		if parseErr != nil {
+			trace = append(trace, ReActStep{
+				Thought:     output.thought,
+				Observation: "Error: " + parseErr.Error(),
+			})
+			continue
+		}
+
+		if output.final {
+			trace = append(trace, ReActStep{Thought: output.thought})
+
+			return r.newArtifact(message, output.answer, trace, step, loopDetected), nil
+		}
+
+		if r.config.RepeatThreshold > 0 {
+			key := actionKey(output.action, output.input)
+			repeats[key]++
+			if repeats[key] > r.config.RepeatThreshold {
+				loopDetected = true
+				agenkit.Logger(ctx).InfoContext(ctx, "react loop detected",
+					"agent", r.name,
+					"step", step,
+					"action", output.action,
+				)
+				trace = append(trace, ReActStep{
+					Thought:     output.thought,
+					Action:      output.action,
+					ActionInput: output.input,
+					Observation: r.config.Nudge,
+				})
+				if r.config.OnLoop == ReActLoopStop {
+					return r.stopLoop(ctx, message, trace, step)
+				}
+				continue
+			}
+		}
+
+		observation, result, err := r.act(ctx, output.action, output.input)
+		if err != nil {
+			return nil, fmt.Errorf("react step %d: %w", step, err)
+		}
+		trace = append(trace, ReActStep{
+			Thought:     output.thought,
+			Action:      output.action,
+			ActionInput: output.input,
+			Observation: observation,
+			Result:      result,
+		})
+	}
+
+	return nil, fmt.Errorf("react: %w after %d steps", ErrMaxStepsReached, r.config.MaxSteps)
+}
+
+// stopLoop ends a run caught in a loop at step, asking the model for a
+// final answer from the trace so far.
+func (r *ReAct) stopLoop(ctx context.Context, message *agenkit.Message, trace []ReActStep, step int) (*Artifact, error) {
+	prompt := r.buildPrompt(message.Content, trace) +
+		"\nYou may not take any more actions. Reply with your Thought and Final Answer now, based on the observations so far.\n"
+	response, err := r.model.Process(ctx, agenkit.NewMessage("user", prompt))
+	if err != nil {
+		return nil, fmt.Errorf("react step %d: model failed: %w", step, err)
+	}
+	output, err := parseReActOutput(response.Content)
+	if err != nil || !output.final {
+		return nil, fmt.Errorf("react: %w at step %d", ErrLoopDetected, step)
+	}
+	trace = append(trace, ReActStep{Thought: output.thought})
+	return r.newArtifact(message, output.answer, trace, step, true), nil
+}
+
+// newArtifact builds the artifact of a finished run.
+func (r *ReAct) newArtifact(message *agenkit.Message, answer string, trace []ReActStep, steps int, loopDetected bool) *Artifact {
+	artifact := NewArtifact("react", message.Content)
+	artifact.Answer = answer
+	artifact.Metadata["trace"] = trace
+	artifact.Metadata["steps"] = steps
+	artifact.Metadata["loop_detected"] = loopDetected
+	return artifact
+}
+
+// actionKey identifies an action and its input for loop detection. The
+// input is encoded as JSON, which orders its keys.
+func actionKey(action string, input map[string]interface{}) string {
+	data, _ := json.Marshal(input)
+	return action + "\x00" + string(data)
+}
+
+// act executes a tool and returns the observation along with the tool's
+// result, which is nil if the tool did not run. Invalid arguments the
+// executor treats as fatal are returned as an error.
+func (r *ReAct) act(ctx context.Context, action string, input map[string]interface{}) (string, *agenkit.ToolResult, error) {
+	tool, ok := r.tools[action]
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool '%s'. Available tools: %s", action, strings.Join(r.toolNames(), ", ")), nil, nil
+	}
+
+	result, err := r.config.Executor.Execute(ctx, tool, input)
+	var argErr *tools.ArgumentError
+	if errors.As(err, &argErr) {
+		return "", nil, err
+	}
+	if err != nil {
+		return "Error: " + err.Error(), nil, nil
+	}
+	return result.ForModel(), result, nil
+}
+
+// buildPrompt renders the instructions, tools, question, and trace so far.
+func (r *ReAct) buildPrompt(question string, trace []ReActStep) string {
+	var sb strings.Builder
+	sb.WriteString("Answer the question by reasoning step by step and using tools when needed.\n\n")
+
+	sb.WriteString("Available tools:\n")
+	for _, name := range r.toolNames() {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, r.tools[name].Description()))
+	}
+
+	sb.WriteString("\nReply with exactly one step in this format:\n")
+	sb.WriteString("Thought: <your reasoning>\n")
+	sb.WriteString("Action: <tool name>\n")
+	sb.WriteString("Action Input: <JSON object of parameters>\n\n")
+	sb.WriteString("Or, when you know the answer:\n")
+	sb.WriteString("Thought: <your reasoning>\n")
+	sb.WriteString("Final Answer: <the answer>\n\n")
+
+	sb.WriteString("Question: ")
+	sb.WriteString(question)
+	sb.WriteString("\n")
+
+	for _, step := range trace {
+		sb.WriteString("\nThought: ")
+		sb.WriteString(step.Thought)
+		sb.WriteString("\n")
+		if step.Action != "" {
+			input, _ := json.Marshal(step.ActionInput)
+			sb.WriteString(fmt.Sprintf("Action: %s\nAction Input: %s\n", step.Action, input))
+		}
+		sb.WriteString("Observation: ")
+		sb.WriteString(step.Observation)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Tools returns the configured tools in name order.
+func (r *ReAct) Tools() []agenkit.Tool {
+	tools := make([]agenkit.Tool, 0, len(r.tools))
+	for _, name := range r.toolNames() {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// toolNames returns the configured tool names in sorted order.
+func (r *ReAct) toolNames() []string {
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reactOutput is a parsed model reply.
+type reactOutput struct {
+	thought string
+	final   bool
+	answer  string
+	action  string
+	input   map[string]interface{}
+}
+
+// parseReActOutput parses a model reply into a thought plus either a final
+// answer or an action. The thought is returned even when parsing fails.
+func parseReActOutput(text string) (reactOutput, error) {
+	var out reactOutput
+
+	thoughtIdx := strings.Index(text, "Thought:")
+	actionIdx := strings.Index(text, "Action:")
+	inputIdx := strings.Index(text, "Action Input:")
+	finalIdx := strings.Index(text, "Final Answer:")
+
+	if thoughtIdx >= 0 {
+		end := len(text)
+		for _, idx := range []int{actionIdx, finalIdx} {
+			if idx > thoughtIdx && idx < end {
+				end = idx
+			}
+		}
+		out.thought = strings.TrimSpace(text[thoughtIdx+len("Thought:") : end])
+	}
+
+	if finalIdx >= 0 && (actionIdx < 0 || finalIdx < actionIdx) {
+		out.final = true
+		out.answer = strings.TrimSpace(text[finalIdx+len("Final Answer:"):])
+		if out.answer == "" {
+			return out, fmt.Errorf("final answer is empty")
+		}
+		return out, nil
+	}
+
+	if actionIdx < 0 {
+		return out, fmt.Errorf("reply must contain either 'Action:' or 'Final Answer:'")
+	}
+
+	actionLine := text[actionIdx+len("Action:"):]
+	if nl := strings.IndexByte(actionLine, '\n'); nl >= 0 {
+		actionLine = actionLine[:nl]
+	}
+	out.action = strings.TrimSpace(actionLine)
+	if out.action == "" {
+		return out, fmt.Errorf("action is empty")
+	}
+
+	out.input = map[string]interface{}{}
+	if inputIdx < 0 {
+		return out, nil
+	}
+	raw := text[inputIdx+len("Action Input:"):]
+	// Models sometimes continue with an imagined observation; ignore it
+	if obs := strings.Index(raw, "Observation:"); obs >= 0 {
+		raw = raw[:obs]
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return out, nil
+	}
+	if strings.HasPrefix(raw, "{") {
+		if err := json.Unmarshal([]byte(raw), &out.input); err != nil {
+			return out, fmt.Errorf("action input is not valid JSON: %v", err)
+		}
+		return out, nil
+	}
+	// Accept a bare value as the tool's single input
+	out.input = map[string]interface{}{"input": raw}
+	return out, nil
+}
@@ -0,0 +1,397 @@
+package reasoning
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+	"github.com/agenkit/agenkit-go/tools"
+)
+
+// calculatorTool adds two numbers.
+type calculatorTool struct {
+	calls int
+}
+
+func (c *calculatorTool) Name() string        { return "add" }
+func (c *calculatorTool) Description() string { return "Adds parameters a and b" }
+
+func (c *calculatorTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	c.calls++
+	a, okA := params["a"].(float64)
+	b, okB := params["b"].(float64)
+	if !okA || !okB {
+		return agenkit.NewToolError("a and b must be numbers"), nil
+	}
+	return agenkit.NewToolResult(a + b), nil
+}
+
+func TestReActToolLoop(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: I should add the numbers.\nAction: add\nAction Input: {\"a\": 2, \"b\": 3}")
+	model.Expect("", "Thought: The sum is 5.\nFinal Answer: 5")
+
+	tool := &calculatorTool{}
+	react, err := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{tool}})
+	if err != nil {
+		t.Fatalf("Failed to create ReAct: %v", err)
+	}
+
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "What is 2+3?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "5" {
+		t.Errorf("Expected answer '5', got '%s'", artifact.Answer)
+	}
+	if tool.calls != 1 {
+		t.Errorf("Expected 1 tool call, got %d", tool.calls)
+	}
+
+	trace := artifact.Metadata["trace"].([]ReActStep)
+	if len(trace) != 2 {
+		t.Fatalf("Expected 2 steps in trace, got %d", len(trace))
+	}
+	if trace[0].Action != "add" || trace[0].Observation != "5" {
+		t.Errorf("Expected add action observing 5, got %+v", trace[0])
+	}
+	if trace[0].Thought != "I should add the numbers." {
+		t.Errorf("Expected thought to be recorded, got '%s'", trace[0].Thought)
+	}
+
+	// The observation must be fed back to the model
+	second := model.Calls()[1].Content
+	if !strings.Contains(second, "Observation: 5") {
+		t.Errorf("Expected second prompt to include the observation, got:\n%s", second)
+	}
+	model.AssertExpectationsMet()
+}
+
+// searchHits is structured search output rendered compactly for the model.
+type searchHits []struct {
+	Title string
+	Score float64
+}
+
+func (h searchHits) ForModel() string {
+	titles := make([]string, len(h))
+	for i, hit := range h {
+		titles[i] = hit.Title
+	}
+	return strings.Join(titles, "; ")
+}
+
+// searchTool returns ranked hits as structured data.
+type searchTool struct{}
+
+func (searchTool) Name() string        { return "search" }
+func (searchTool) Description() string { return "Searches the docs" }
+
+func (searchTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	return agenkit.NewToolResult(searchHits{{"Install guide", 0.9}, {"FAQ", 0.4}}), nil
+}
+
+func TestReActStructuredToolResult(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: Look it up.\nAction: search\nAction Input: {\"q\": \"install\"}")
+	model.Expect("", "Thought: Found it.\nFinal Answer: See the install guide")
+
+	react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{searchTool{}}})
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "How do I install?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+
+	if !strings.Contains(model.Calls()[1].Content, "Observation: Install guide; FAQ\n") {
+		t.Errorf("Expected the observation rendered with ForModel, got:\n%s", model.Calls()[1].Content)
+	}
+	trace := artifact.Metadata["trace"].([]ReActStep)
+	hits, ok := trace[0].Result.Data.(searchHits)
+	if !ok || len(hits) != 2 || hits[0].Score != 0.9 {
+		t.Errorf("Expected the structured result kept in the trace, got %+v", trace[0].Result)
+	}
+	if trace[1].Result != nil {
+		t.Error("Expected no result on the final step")
+	}
+}
+
+func TestReActMalformedActionRecovers(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: Let me add.\nAction: add\nAction Input: {a: 2")
+	model.Expect("", "Thought: Oops, fix the JSON.\nAction: add\nAction Input: {\"a\": 2, \"b\": 2}")
+	model.Expect("", "Final Answer: 4")
+
+	tool := &calculatorTool{}
+	react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{tool}})
+
+	response, err := react.Process(context.Background(), agenkit.NewMessage("user", "2+2?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if response.Content != "4" {
+		t.Errorf("Expected '4', got '%s'", response.Content)
+	}
+	if tool.calls != 1 {
+		t.Errorf("Expected malformed action not to execute the tool, got %d calls", tool.calls)
+	}
+	if !strings.Contains(model.Calls()[1].Content, "Observation: Error: action input is not valid JSON") {
+		t.Errorf("Expected parse error to be fed back, got:\n%s", model.Calls()[1].Content)
+	}
+}
+
+func TestReActUnknownToolAndToolError(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: try search\nAction: search\nAction Input: cats")
+	model.Expect("", "Thought: try add\nAction: add\nAction Input: {\"a\": \"x\"}")
+	model.Expect("", "Final Answer: unknown")
+
+	react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{&calculatorTool{}}})
+
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	trace := artifact.Metadata["trace"].([]ReActStep)
+	if !strings.Contains(trace[0].Observation, "unknown tool 'search'") {
+		t.Errorf("Expected unknown tool observation, got '%s'", trace[0].Observation)
+	}
+	if trace[0].ActionInput["input"] != "cats" {
+		t.Errorf("Expected bare action input to be wrapped, got %v", trace[0].ActionInput)
+	}
+	if trace[1].Observation != "Error: a and b must be numbers" {
+		t.Errorf("Expected tool error observation, got '%s'", trace[1].Observation)
+	}
+}
+
+func TestReActMaxSteps(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: hmm")
+	model.Expect("", "Thought: still thinking")
+
+	react, _ := NewReAct("react", model, ReActConfig{MaxSteps: 2})
+
+	_, err := react.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if !errors.Is(err, ErrMaxStepsReached) {
+		t.Fatalf("Expected ErrMaxStepsReached, got %v", err)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestReActModelError(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "").WithError(errors.New("provider down"))
+
+	react, _ := NewReAct("react", model, ReActConfig{})
+	_, err := react.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err == nil || !strings.Contains(err.Error(), "provider down") {
+		t.Errorf("Expected wrapped model error, got %v", err)
+	}
+}
+
+func TestReActDuplicateTools(t *testing.T) {
+	_, err := NewReAct("react", testutil.NewMockAgent(t, "model"), ReActConfig{
+		Tools: []agenkit.Tool{&calculatorTool{}, &calculatorTool{}},
+	})
+	if err == nil {
+		t.Fatal("Expected error for duplicate tool names")
+	}
+}
+
+// hangingTool never returns until cancelled.
+type hangingTool struct{}
+
+func (h *hangingTool) Name() string        { return "hang" }
+func (h *hangingTool) Description() string { return "Never finishes" }
+func (h *hangingTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestReActToolTimeoutBecomesObservation(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: try it\nAction: hang\nAction Input: {}")
+	model.Expect("", "Thought: it timed out\nFinal Answer: gave up")
+
+	react, _ := NewReAct("react", model, ReActConfig{
+		Tools:    []agenkit.Tool{&hangingTool{}},
+		Executor: tools.NewExecutor(tools.ExecutorConfig{Timeout: 10 * time.Millisecond}),
+	})
+
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err != nil {
+		t.Fatalf("Expected the run to continue after a timeout, got %v", err)
+	}
+	trace := artifact.Metadata["trace"].([]ReActStep)
+	if !strings.Contains(trace[0].Observation, "timed out") {
+		t.Errorf("Expected timeout observation, got '%s'", trace[0].Observation)
+	}
+	if artifact.Answer != "gave up" {
+		t.Errorf("Expected final answer 'gave up', got '%s'", artifact.Answer)
+	}
+}
+
+func TestReActInvalidArgumentsBecomeObservation(t *testing.T) {
+	weather, _ := tools.ToolFromFunc("weather", "Looks up the weather", func(ctx context.Context, args struct {
+		City string `json:"city"`
+	}) (string, error) {
+		return "sunny in " + args.City, nil
+	})
+
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: check\nAction: weather\nAction Input: {\"town\": \"Oslo\"}")
+	model.Expect("", "Thought: fix it\nAction: weather\nAction Input: {\"city\": \"Oslo\"}")
+	model.Expect("", "Final Answer: sunny")
+
+	react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{weather}})
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "Weather in Oslo?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	trace := artifact.Metadata["trace"].([]ReActStep)
+	if !strings.Contains(trace[0].Observation, "$.city: required field is missing") {
+		t.Errorf("Expected the violation as the observation, got '%s'", trace[0].Observation)
+	}
+	if trace[1].Observation != "sunny in Oslo" {
+		t.Errorf("Expected the corrected call to run, got '%s'", trace[1].Observation)
+	}
+
+	// Tools marked fatal end the run instead
+	model = testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: check\nAction: weather\nAction Input: {\"town\": \"Oslo\"}")
+	react, _ = NewReAct("react", model, ReActConfig{
+		Tools:    []agenkit.Tool{weather},
+		Executor: tools.NewExecutor(tools.ExecutorConfig{FatalArgumentErrors: map[string]bool{"weather": true}}),
+	})
+	var argErr *tools.ArgumentError
+	if _, err := react.Reason(context.Background(), agenkit.NewMessage("user", "Weather in Oslo?")); !errors.As(err, &argErr) {
+		t.Errorf("Expected a fatal *ArgumentError, got %v", err)
+	}
+}
+
+func TestReActMemoizesRepeatedToolCalls(t *testing.T) {
+	script := func(model *testutil.MockAgent) {
+		model.Expect("", "Thought: Add them.\nAction: add\nAction Input: {\"a\": 2, \"b\": 3}")
+		model.Expect("", "Thought: Check again.\nAction: add\nAction Input: {\"b\": 3, \"a\": 2}")
+		model.Expect("", "Thought: Sure now.\nFinal Answer: 5")
+	}
+	tool := &calculatorTool{}
+	executor := tools.NewExecutor(tools.ExecutorConfig{Memoize: true})
+
+	// Each run memoizes on its own
+	for run := 1; run <= 2; run++ {
+		model := testutil.NewMockAgent(t, "model")
+		script(model)
+		react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{tool}, Executor: executor})
+		artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "What is 2+3?"))
+		if err != nil {
+			t.Fatalf("Reason failed: %v", err)
+		}
+		if tool.calls != run {
+			t.Errorf("Expected %d tool runs after run %d, got %d", run, run, tool.calls)
+		}
+		trace := artifact.Metadata["trace"].([]ReActStep)
+		if trace[1].Observation != "5" || trace[1].Result.Metadata["memoized"] != true {
+			t.Errorf("Expected the repeat to observe the memoized result, got %+v", trace[1])
+		}
+	}
+}
+
+func TestReActLoopNudge(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	repeat := "Thought: Add them.\nAction: add\nAction Input: {\"a\": 2, \"b\": 3}"
+	model.Expect("", repeat)
+	model.Expect("", repeat)
+	model.Expect("", "Thought: Right, it is 5.\nFinal Answer: 5")
+
+	tool := &calculatorTool{}
+	react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{tool}, RepeatThreshold: 1})
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "What is 2+3?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if tool.calls != 1 {
+		t.Errorf("Expected the repeated action not to run, got %d tool calls", tool.calls)
+	}
+	if artifact.Answer != "5" || artifact.Metadata["loop_detected"] != true {
+		t.Errorf("Expected answer 5 with loop flagged, got '%s' and %v", artifact.Answer, artifact.Metadata["loop_detected"])
+	}
+	if !strings.Contains(model.Calls()[2].Content, "Observation: "+DefaultReActNudge) {
+		t.Errorf("Expected the nudge in the next prompt, got:\n%s", model.Calls()[2].Content)
+	}
+}
+
+func TestReActLoopStop(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	repeat := "Thought: Add them.\nAction: add\nAction Input: {\"a\": 2, \"b\": 3}"
+	for i := 0; i < 3; i++ {
+		model.Expect("", repeat)
+	}
+	model.Expect("", "Thought: The tool said 5.\nFinal Answer: 5")
+
+	react, _ := NewReAct("react", model, ReActConfig{
+		Tools:           []agenkit.Tool{&calculatorTool{}},
+		MaxSteps:        10,
+		RepeatThreshold: 2,
+		OnLoop:          ReActLoopStop,
+	})
+	artifact, err := react.Reason(context.Background(), agenkit.NewMessage("user", "What is 2+3?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "5" || artifact.Metadata["loop_detected"] != true || artifact.Metadata["steps"] != 3 {
+		t.Errorf("Expected answer 5 after 3 steps with loop flagged, got %+v", artifact)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestReActLoopStopWithoutAnswer(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	repeat := "Thought: Add them.\nAction: add\nAction Input: {\"a\": 2, \"b\": 3}"
+	for i := 0; i < 3; i++ {
+		model.Expect("", repeat)
+	}
+
+	react, _ := NewReAct("react", model, ReActConfig{
+		Tools:           []agenkit.Tool{&calculatorTool{}},
+		RepeatThreshold: 1,
+		OnLoop:          ReActLoopStop,
+	})
+	_, err := react.Reason(context.Background(), agenkit.NewMessage("user", "What is 2+3?"))
+	if !errors.Is(err, ErrLoopDetected) {
+		t.Fatalf("Expected ErrLoopDetected, got %v", err)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestReActTracedSteps(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Thought: Add them.\nAction: add\nAction Input: {\"a\": 2, \"b\": 3}")
+	model.Expect("", "Thought: The sum is 5.\nFinal Answer: 5")
+
+	react, _ := NewReAct("react", model, ReActConfig{Tools: []agenkit.Tool{&calculatorTool{}}})
+	ctx := agenkit.WithTracing(context.Background())
+	if _, err := react.Process(ctx, agenkit.NewMessage("user", "What is 2+3?")); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	steps := agenkit.TraceFromContext(ctx).Steps()
+	if len(steps) != 1 || steps[0].Agent != "react" {
+		t.Fatalf("Expected one react step, got %+v", steps)
+	}
+	var tools int
+	for _, event := range steps[0].Events {
+		if event.Type == agenkit.EventToolCalled {
+			tools++
+		}
+	}
+	if tools != 1 {
+		t.Errorf("Expected the tool call on the react step, got %+v", steps[0].Events)
+	}
+	if _, ok := ArtifactFromMessage(steps[0].Output); !ok {
+		t.Error("Expected the artifact in the step's output")
+	}
+}
@@ -0,0 +1,82 @@
+// Package reasoning provides reasoning techniques built from agents.
+//
+// A technique is itself an agenkit.Agent, so it can be dropped into any
+// composition pattern. In addition to the answer, every technique records an
+// Artifact describing how the answer was reached (samples, votes, traces),
+// which callers can inspect or persist.
+package reasoning
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ArtifactMetadataKey is the response metadata key holding the technique's Artifact.
+const ArtifactMetadataKey = "artifact"
+
+// Technique is a reasoning strategy that produces an answer and a record of how it was reached.
+type Technique interface {
+	agenkit.Agent
+
+	// Reason runs the technique and returns its artifact.
+	Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error)
+}
+
+// Artifact records the outcome of a reasoning run.
+type Artifact struct {
+	// ID uniquely identifies the artifact.
+	ID string `json:"id"`
+
+	// Technique names the technique that produced the artifact.
+	Technique string `json:"technique"`
+
+	// Query is the input the technique reasoned about.
+	Query string `json:"query"`
+
+	// Answer is the final answer.
+	Answer string `json:"answer"`
+
+	// Confidence is the technique's score for the answer in [0, 1], for
+	// techniques that score their answers.
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// Complete reports whether the run that produced the artifact finished.
+	// Checkpoints written during a run are incomplete.
+	Complete bool `json:"complete"`
+
+	// Metadata holds technique-specific details (votes, traces, scores).
+	Metadata map[string]interface{} `json:"metadata"`
+
+	// CreatedAt is when the artifact was produced.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewArtifact creates an empty, complete artifact for a technique and query.
+func NewArtifact(technique, query string) *Artifact {
+	return &Artifact{
+		ID:        uuid.New().String(),
+		Technique: technique,
+		Query:     query,
+		Complete:  true,
+		Metadata:  make(map[string]interface{}),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// ToMessage converts the artifact into an agent response carrying the artifact in metadata.
+func (a *Artifact) ToMessage() *agenkit.Message {
+	return agenkit.NewMessage("agent", a.Answer).WithMetadata(ArtifactMetadataKey, a)
+}
+
+// ArtifactFromMessage returns the artifact attached to a technique's response, if any.
+func ArtifactFromMessage(message *agenkit.Message) (*Artifact, bool) {
+	if message == nil || message.Metadata == nil {
+		return nil, false
+	}
+	artifact, ok := message.Metadata[ArtifactMetadataKey].(*Artifact)
+	return artifact, ok
+}
@@ -0,0 +1,196 @@
+package reasoning
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ReflexionConfig configures the Reflexion technique.
+type ReflexionConfig struct {
+	// Critic evaluates each answer. It may be a stronger model than the
+	// generator. Required.
+	Critic agenkit.Agent
+
+	// MaxIterations is the maximum number of generate/critique rounds.
+	// Default: 3
+	MaxIterations int
+
+	// Threshold is the critic score in [0, 1] at or above which the answer
+	// is accepted and the loop stops early.
+	// Default: 0.8
+	Threshold float64
+}
+
+// ReflexionIteration records one generate/critique round.
+type ReflexionIteration struct {
+	Answer   string  `json:"answer"`
+	Critique string  `json:"critique"`
+	Score    float64 `json:"score"`
+}
+
+// Reflexion improves an answer by having a critic evaluate it and feeding
+// the critique back to the generator.
+//
+// The critic is asked to reply with a line "Score: <0-1>" followed by its
+// critique; replies without a parsable score count as 0. The loop stops
+// once a score reaches the threshold or after MaxIterations, and the
+// highest-scoring answer is returned (the latest one on ties). The artifact
+// metadata records:
+//
+//   - "iterations": the []ReflexionIteration trajectory
+//   - "score": the score of the returned answer
+//   - "satisfied": whether the threshold was reached
+type Reflexion struct {
+	name      string
+	generator agenkit.Agent
+	config    ReflexionConfig
+}
+
+// Verify that Reflexion implements Technique interface.
+var _ Technique = (*Reflexion)(nil)
+
+// NewReflexion creates a new Reflexion technique.
+func NewReflexion(name string, generator agenkit.Agent, config ReflexionConfig) (*Reflexion, error) {
+	if generator == nil {
+		return nil, fmt.Errorf("reflexion requires a generator agent")
+	}
+	if config.Critic == nil {
+		return nil, fmt.Errorf("reflexion requires a critic agent")
+	}
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = 3
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = 0.8
+	}
+	if config.Threshold > 1 {
+		return nil, fmt.Errorf("threshold must be at most 1, got %v", config.Threshold)
+	}
+	return &Reflexion{
+		name:      name,
+		generator: generator,
+		config:    config,
+	}, nil
+}
+
+// Name returns the name of the technique.
+func (r *Reflexion) Name() string {
+	return r.name
+}
+
+// Capabilities returns the generator's capabilities plus the technique markers.
+func (r *Reflexion) Capabilities() []string {
+	return append(r.generator.Capabilities(), "reasoning", "reflexion")
+}
+
+// Process runs the technique and returns the best answer.
+func (r *Reflexion) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, finish := agenkit.TrackAgent(ctx, r.name, message)
+	defer func() { finish(result, err) }()
+
+	artifact, err := r.Reason(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return artifact.ToMessage(), nil
+}
+
+// Reason alternates generation and critique until the critic is satisfied.
+func (r *Reflexion) Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error) {
+	var iterations []ReflexionIteration
+	best := -1
+	satisfied := false
+
+	prompt := message
+	for i := 1; i <= r.config.MaxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("reflexion cancelled at iteration %d: %w", i, err)
+		}
+
+		answer, err := r.generator.Process(ctx, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("reflexion iteration %d: generator failed: %w", i, err)
+		}
+
+		critique, err := r.config.Critic.Process(ctx, agenkit.NewMessage("user", buildCritiquePrompt(message.Content, answer.Content)))
+		if err != nil {
+			return nil, fmt.Errorf("reflexion iteration %d: critic failed: %w", i, err)
+		}
+
+		score, feedback := parseCritique(critique.Content)
+		iterations = append(iterations, ReflexionIteration{
+			Answer:   answer.Content,
+			Critique: feedback,
+			Score:    score,
+		})
+		if best < 0 || score >= iterations[best].Score {
+			best = len(iterations) - 1
+		}
+
+		if score >= r.config.Threshold {
+			satisfied = true
+			break
+		}
+
+		prompt = agenkit.NewMessage(message.Role, buildRevisionPrompt(message.Content, answer.Content, feedback))
+	}
+
+	artifact := NewArtifact("reflexion", message.Content)
+	artifact.Answer = iterations[best].Answer
+	artifact.Metadata["iterations"] = iterations
+	artifact.Metadata["score"] = iterations[best].Score
+	artifact.Metadata["satisfied"] = satisfied
+	return artifact, nil
+}
+
+// buildCritiquePrompt asks the critic to score an answer against the task.
+func buildCritiquePrompt(task, answer string) string {
+	return fmt.Sprintf(`Evaluate the answer to the task below.
+
+Task:
+%s
+
+Answer:
+%s
+
+Reply with a first line of the form "Score: <number between 0 and 1>", where 1 means the answer fully and correctly solves the task, followed by a critique describing any problems and how to fix them.`, task, answer)
+}
+
+// buildRevisionPrompt asks the generator to improve on a critiqued answer.
+func buildRevisionPrompt(task, answer, critique string) string {
+	return fmt.Sprintf(`%s
+
+Your previous answer was:
+%s
+
+A reviewer gave this critique:
+%s
+
+Write an improved answer that addresses the critique.`, task, answer, critique)
+}
+
+// scorePattern matches the critic's score line.
+var scorePattern = regexp.MustCompile(`(?i)score\s*:\s*(-?[0-9]*\.?[0-9]+)`)
+
+// parseCritique extracts the score and critique text from a critic reply.
+// The score is clamped to [0, 1] and is 0 when missing.
+func parseCritique(text string) (float64, string) {
+	match := scorePattern.FindStringSubmatchIndex(text)
+	if match == nil {
+		return 0, strings.TrimSpace(text)
+	}
+
+	score, err := strconv.ParseFloat(text[match[2]:match[3]], 64)
+	if err != nil {
+		score = 0
+	}
+	score = min(max(score, 0), 1)
+
+	critique := strings.TrimSpace(text[:match[0]] + text[match[1]:])
+	return score, critique
+}
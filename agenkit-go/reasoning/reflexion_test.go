@@ -0,0 +1,103 @@
+package reasoning
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+func TestReflexionImprovesUntilSatisfied(t *testing.T) {
+	generator := testutil.NewMockAgent(t, "generator")
+	generator.Expect("Write a haiku", "first draft")
+	generator.Expect("", "second draft")
+
+	critic := testutil.NewMockAgent(t, "critic")
+	critic.Expect("", "Score: 0.4\nToo many syllables.")
+	critic.Expect("", "Score: 0.9\nGood.")
+
+	reflexion, err := NewReflexion("reflexion", generator, ReflexionConfig{Critic: critic, MaxIterations: 5})
+	if err != nil {
+		t.Fatalf("Failed to create Reflexion: %v", err)
+	}
+
+	artifact, err := reflexion.Reason(context.Background(), agenkit.NewMessage("user", "Write a haiku"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "second draft" {
+		t.Errorf("Expected 'second draft', got '%s'", artifact.Answer)
+	}
+	if artifact.Metadata["satisfied"] != true {
+		t.Error("Expected satisfied to be true")
+	}
+
+	iterations := artifact.Metadata["iterations"].([]ReflexionIteration)
+	if len(iterations) != 2 {
+		t.Fatalf("Expected loop to stop after 2 iterations, got %d", len(iterations))
+	}
+	if iterations[0].Score != 0.4 || iterations[0].Critique != "Too many syllables." {
+		t.Errorf("Unexpected first iteration: %+v", iterations[0])
+	}
+
+	revision := generator.Calls()[1].Content
+	if !strings.Contains(revision, "first draft") || !strings.Contains(revision, "Too many syllables.") {
+		t.Errorf("Expected critique to be fed back, got:\n%s", revision)
+	}
+	generator.AssertExpectationsMet()
+	critic.AssertExpectationsMet()
+}
+
+func TestReflexionReturnsBestAfterMaxIterations(t *testing.T) {
+	generator := testutil.NewMockAgent(t, "generator")
+	generator.Expect("", "good")
+	generator.Expect("", "worse")
+
+	critic := testutil.NewMockAgent(t, "critic")
+	critic.Expect("", "Score: 0.6\nClose.")
+	critic.Expect("", "I don't like it.")
+
+	reflexion, _ := NewReflexion("reflexion", generator, ReflexionConfig{Critic: critic, MaxIterations: 2})
+
+	artifact, err := reflexion.Reason(context.Background(), agenkit.NewMessage("user", "task"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "good" {
+		t.Errorf("Expected highest-scoring answer 'good', got '%s'", artifact.Answer)
+	}
+	if artifact.Metadata["satisfied"] != false {
+		t.Error("Expected satisfied to be false")
+	}
+	iterations := artifact.Metadata["iterations"].([]ReflexionIteration)
+	if iterations[1].Score != 0 {
+		t.Errorf("Expected unparsable score to count as 0, got %v", iterations[1].Score)
+	}
+}
+
+func TestParseCritique(t *testing.T) {
+	tests := []struct {
+		text     string
+		score    float64
+		critique string
+	}{
+		{"Score: 0.75\nMostly right.", 0.75, "Mostly right."},
+		{"score: 1.5\nGreat", 1, "Great"},
+		{"Needs work. SCORE: .3", 0.3, "Needs work."},
+		{"no score here", 0, "no score here"},
+	}
+	for _, tt := range tests {
+		score, critique := parseCritique(tt.text)
+		if score != tt.score || critique != tt.critique {
+			t.Errorf("parseCritique(%q) = %v, %q; want %v, %q", tt.text, score, critique, tt.score, tt.critique)
+		}
+	}
+}
+
+func TestReflexionRequiresCritic(t *testing.T) {
+	if _, err := NewReflexion("reflexion", testutil.NewMockAgent(t, "generator"), ReflexionConfig{}); err == nil {
+		t.Fatal("Expected error without a critic")
+	}
+}
@@ -0,0 +1,288 @@
+package reasoning
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// SelfConsistencyConfig configures the self-consistency technique.
+type SelfConsistencyConfig struct {
+	// Samples is the number of independent chains to run.
+	// Default: 5
+	Samples int
+
+	// Temperature, if positive, overrides the sampling temperature of LLM
+	// agents in the chain so the samples actually differ.
+	// Default: 0 (use the chain's own configuration)
+	Temperature float64
+
+	// Seed, if set, makes sampling reproducible: sample i runs with seed
+	// *Seed+i (see llm.WithSeed).
+	// Default: nil (unseeded)
+	Seed *int64
+
+	// ExtractAnswer pulls the comparable final answer out of each sample.
+	// Samples yielding an empty answer do not vote.
+	// Default: ExactAnswer
+	ExtractAnswer AnswerExtractor
+
+	// Confidence, if set, scores each successful sample's response, and
+	// votes are weighted by the score instead of counted. Negative and NaN
+	// scores count as 0. If every voting sample scores 0, voting falls back
+	// to the unweighted majority.
+	// Default: nil (unweighted)
+	Confidence func(*agenkit.Message) float64
+}
+
+// SelfConsistency samples a reasoning chain several times and returns the majority answer.
+//
+// All samples run concurrently. With a Confidence hook each vote is
+// weighted by its sample's score, so one confident answer can outweigh
+// several hesitant ones. Ties are broken deterministically in favor of the
+// answer that first appeared at the lowest sample index. The artifact
+// metadata records every sample's answer and the vote distribution:
+//
+//   - "samples": number of samples requested
+//   - "answers": extracted answer per sample ("" for failed samples)
+//   - "votes": map of answer to vote count
+//   - "agreement": fraction of valid votes (or of the total weight, when
+//     weighted) won by the winning answer
+//   - "weighted": whether the winner was chosen by weight
+//   - "confidences": score per sample, when Confidence is set
+//   - "weighted_votes": map of answer to total weight, when Confidence is set
+//   - "errors": number of samples that failed
+//   - "seeds": the seed used per sample, when Seed is set
+//   - "seed_honored": when Seed is set, whether every successful sample
+//     reported that its provider honored the seed
+type SelfConsistency struct {
+	name   string
+	chain  agenkit.Agent
+	config SelfConsistencyConfig
+}
+
+// Verify that SelfConsistency implements Technique and
+// agenkit.DeterminismReporter interfaces.
+var (
+	_ Technique                   = (*SelfConsistency)(nil)
+	_ agenkit.DeterminismReporter = (*SelfConsistency)(nil)
+)
+
+// NewSelfConsistency creates a new self-consistency technique over a chain.
+func NewSelfConsistency(name string, chain agenkit.Agent, config SelfConsistencyConfig) (*SelfConsistency, error) {
+	if chain == nil {
+		return nil, fmt.Errorf("self-consistency requires a chain agent")
+	}
+	if config.Samples <= 0 {
+		config.Samples = 5
+	}
+	if config.Temperature < 0 {
+		return nil, fmt.Errorf("temperature must not be negative, got %v", config.Temperature)
+	}
+	if config.ExtractAnswer == nil {
+		config.ExtractAnswer = ExactAnswer
+	}
+	return &SelfConsistency{
+		name:   name,
+		chain:  chain,
+		config: config,
+	}, nil
+}
+
+// Name returns the name of the technique.
+func (s *SelfConsistency) Name() string {
+	return s.name
+}
+
+// Capabilities returns the chain's capabilities plus the technique markers.
+func (s *SelfConsistency) Capabilities() []string {
+	return append(s.chain.Capabilities(), "reasoning", "self_consistency")
+}
+
+// Deterministic reports false when the technique raises the chain's
+// temperature, since its samples are then meant to differ. Otherwise the
+// chain decides, and is reached through GetAgents.
+func (s *SelfConsistency) Deterministic() bool {
+	return s.config.Temperature == 0
+}
+
+// GetAgents returns the sampled chain.
+func (s *SelfConsistency) GetAgents() []agenkit.Agent {
+	return []agenkit.Agent{s.chain}
+}
+
+// Process runs the technique and returns the majority answer.
+func (s *SelfConsistency) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, finish := agenkit.TrackAgent(ctx, s.name, message)
+	defer func() { finish(result, err) }()
+
+	artifact, err := s.Reason(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return artifact.ToMessage(), nil
+}
+
+// Reason samples the chain and votes on the extracted answers.
+func (s *SelfConsistency) Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error) {
+	if s.config.Temperature > 0 {
+		ctx = llm.WithTemperatureOverride(ctx, s.config.Temperature)
+	}
+
+	answers := make([]string, s.config.Samples)
+	confidences := make([]float64, s.config.Samples)
+	errs := make([]error, s.config.Samples)
+	honored := make([]bool, s.config.Samples)
+	var seeds []int64
+	if s.config.Seed != nil {
+		seeds = make([]int64, s.config.Samples)
+		for i := range seeds {
+			seeds[i] = *s.config.Seed + int64(i)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Samples; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sampleCtx := ctx
+			if seeds != nil {
+				sampleCtx = llm.WithSeed(ctx, seeds[i])
+			}
+			response, err := s.chain.Process(sampleCtx, message)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			answers[i] = s.config.ExtractAnswer(response)
+			if s.config.Confidence != nil {
+				confidences[i] = s.config.Confidence(response)
+			}
+			honored[i], _ = response.Metadata["seed_honored"].(bool)
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("self-consistency cancelled: %w", err)
+	}
+
+	failures := 0
+	var lastErr error
+	for _, err := range errs {
+		if err != nil {
+			failures++
+			lastErr = err
+		}
+	}
+
+	winner, votes, total := majorityVote(answers)
+	if total == 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("all %d samples failed or yielded no answer: %w", s.config.Samples, lastErr)
+		}
+		return nil, fmt.Errorf("none of %d samples yielded an answer", s.config.Samples)
+	}
+
+	agreement := float64(votes[winner]) / float64(total)
+	weighted := false
+	var tally map[string]float64
+	if s.config.Confidence != nil {
+		var byWeight string
+		var weight float64
+		byWeight, tally, weight = weightedVote(answers, confidences)
+		if weight > 0 {
+			winner, weighted = byWeight, true
+			agreement = tally[winner] / weight
+		}
+	}
+
+	artifact := NewArtifact("self_consistency", message.Content)
+	artifact.Answer = winner
+	artifact.Metadata["samples"] = s.config.Samples
+	artifact.Metadata["answers"] = answers
+	artifact.Metadata["votes"] = votes
+	artifact.Metadata["agreement"] = agreement
+	artifact.Metadata["weighted"] = weighted
+	if s.config.Confidence != nil {
+		artifact.Metadata["confidences"] = confidences
+		artifact.Metadata["weighted_votes"] = tally
+	}
+	artifact.Metadata["errors"] = failures
+	if seeds != nil {
+		allHonored := true
+		for i, err := range errs {
+			if err == nil && !honored[i] {
+				allHonored = false
+			}
+		}
+		artifact.Metadata["seeds"] = seeds
+		artifact.Metadata["seed_honored"] = allHonored
+	}
+	return artifact, nil
+}
+
+// majorityVote tallies non-empty answers and returns the winner, the vote
+// counts, and the number of valid votes. Ties go to the answer whose first
+// occurrence has the lowest index.
+func majorityVote(answers []string) (string, map[string]int, int) {
+	votes := make(map[string]int)
+	var order []string
+	total := 0
+
+	for _, answer := range answers {
+		if answer == "" {
+			continue
+		}
+		if _, seen := votes[answer]; !seen {
+			order = append(order, answer)
+		}
+		votes[answer]++
+		total++
+	}
+
+	winner := ""
+	for _, answer := range order {
+		if winner == "" || votes[answer] > votes[winner] {
+			winner = answer
+		}
+	}
+	return winner, votes, total
+}
+
+// weightedVote sums the weights of non-empty answers and returns the
+// winner, the weight per answer, and the total weight. Negative and NaN
+// weights count as 0. Ties go to the answer whose first occurrence has the
+// lowest index.
+func weightedVote(answers []string, weights []float64) (string, map[string]float64, float64) {
+	tally := make(map[string]float64)
+	var order []string
+	total := 0.0
+
+	for i, answer := range answers {
+		if answer == "" {
+			continue
+		}
+		if _, seen := tally[answer]; !seen {
+			order = append(order, answer)
+		}
+		weight := weights[i]
+		if !(weight > 0) {
+			weight = 0
+		}
+		tally[answer] += weight
+		total += weight
+	}
+
+	winner := ""
+	for _, answer := range order {
+		if winner == "" || tally[answer] > tally[winner] {
+			winner = answer
+		}
+	}
+	return winner, tally, total
+}
@@ -0,0 +1,264 @@
+package reasoning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// scriptedAgent returns responses keyed by the order of calls.
+type scriptedAgent struct {
+	mu        sync.Mutex
+	responses []string
+	errs      []error
+	calls     int
+	temps     []float64
+}
+
+func (s *scriptedAgent) Name() string           { return "scripted" }
+func (s *scriptedAgent) Capabilities() []string { return nil }
+
+func (s *scriptedAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	s.mu.Lock()
+	i := s.calls
+	s.calls++
+	if temp, ok := llm.TemperatureOverrideFromContext(ctx); ok {
+		s.temps = append(s.temps, temp)
+	}
+	s.mu.Unlock()
+
+	if i < len(s.errs) && s.errs[i] != nil {
+		return nil, s.errs[i]
+	}
+	return agenkit.NewMessage("agent", s.responses[i%len(s.responses)]), nil
+}
+
+func TestSelfConsistencyMajority(t *testing.T) {
+	chain := &scriptedAgent{responses: []string{"42", "41", "42", "42", "7"}}
+	sc, err := NewSelfConsistency("sc", chain, SelfConsistencyConfig{Samples: 5})
+	if err != nil {
+		t.Fatalf("Failed to create technique: %v", err)
+	}
+
+	result, err := sc.Process(context.Background(), agenkit.NewMessage("user", "6*7?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "42" {
+		t.Errorf("Expected majority answer 42, got '%s'", result.Content)
+	}
+
+	artifact, ok := ArtifactFromMessage(result)
+	if !ok {
+		t.Fatal("Expected artifact in response metadata")
+	}
+	votes := artifact.Metadata["votes"].(map[string]int)
+	if votes["42"] != 3 || votes["41"] != 1 || votes["7"] != 1 {
+		t.Errorf("Unexpected vote distribution: %v", votes)
+	}
+	if agreement := artifact.Metadata["agreement"].(float64); agreement != 0.6 {
+		t.Errorf("Expected agreement 0.6, got %v", agreement)
+	}
+	if chain.calls != 5 {
+		t.Errorf("Expected 5 samples, got %d", chain.calls)
+	}
+}
+
+func TestSelfConsistencyNumericExtraction(t *testing.T) {
+	chain := &scriptedAgent{responses: []string{
+		"3 + 4 = 7, so the answer is 1,200",
+		"The total is 1200.",
+		"I think it's 900",
+	}}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{Samples: 3, ExtractAnswer: NumericAnswer})
+
+	artifact, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "1200" {
+		t.Errorf("Expected 1200, got '%s'", artifact.Answer)
+	}
+}
+
+func TestMajorityVoteTieLowestIndexWins(t *testing.T) {
+	winner, _, _ := majorityVote([]string{"b", "a", "a", "b", ""})
+	if winner != "b" {
+		t.Errorf("Expected tie to resolve to first-seen answer 'b', got '%s'", winner)
+	}
+}
+
+// statedConfidence reads a leading "confidence N" from a response.
+func statedConfidence(message *agenkit.Message) float64 {
+	var confidence float64
+	fmt.Sscanf(message.Content, "confidence %g", &confidence)
+	return confidence
+}
+
+func TestSelfConsistencyWeightedVote(t *testing.T) {
+	chain := &scriptedAgent{responses: []string{"confidence 0.2, answer 41", "confidence 0.9, answer 42", "confidence 0.3, answer 41"}}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{
+		Samples:       3,
+		ExtractAnswer: NumericAnswer,
+		Confidence:    statedConfidence,
+	})
+
+	artifact, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "6*7?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "42" {
+		t.Errorf("Expected the confident answer 42 to outweigh the majority, got '%s'", artifact.Answer)
+	}
+	if votes := artifact.Metadata["votes"].(map[string]int); votes["41"] != 2 || votes["42"] != 1 {
+		t.Errorf("Expected raw counts recorded, got %v", votes)
+	}
+	tally := artifact.Metadata["weighted_votes"].(map[string]float64)
+	if tally["42"] != 0.9 || tally["41"] != 0.5 {
+		t.Errorf("Expected the weighted tally recorded, got %v", tally)
+	}
+	if artifact.Metadata["weighted"] != true {
+		t.Error("Expected the artifact marked as weighted")
+	}
+}
+
+func TestSelfConsistencyWeightedFallsBackWithoutScores(t *testing.T) {
+	chain := &scriptedAgent{responses: []string{"41", "42", "41"}}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{
+		Samples:    3,
+		Confidence: func(*agenkit.Message) float64 { return 0 },
+	})
+
+	artifact, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "41" || artifact.Metadata["weighted"] != false {
+		t.Errorf("Expected the unweighted majority 41, got '%s' (weighted %v)", artifact.Answer, artifact.Metadata["weighted"])
+	}
+}
+
+func TestWeightedVoteTieLowestIndexWins(t *testing.T) {
+	winner, _, total := weightedVote([]string{"b", "a", "a", "b"}, []float64{0.5, 0.25, 0.25, math.NaN()})
+	if winner != "b" || total != 1 {
+		t.Errorf("Expected tie to resolve to first-seen answer 'b' with NaN ignored, got '%s' (total %v)", winner, total)
+	}
+}
+
+func TestSelfConsistencyPartialFailures(t *testing.T) {
+	chain := &scriptedAgent{
+		responses: []string{"yes"},
+		errs:      []error{errors.New("boom"), nil, errors.New("boom")},
+	}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{Samples: 3})
+
+	artifact, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "yes" || artifact.Metadata["errors"] != 2 {
+		t.Errorf("Unexpected artifact: answer=%s errors=%v", artifact.Answer, artifact.Metadata["errors"])
+	}
+}
+
+func TestSelfConsistencyAllFail(t *testing.T) {
+	chain := &scriptedAgent{
+		responses: []string{"unused"},
+		errs:      []error{errors.New("boom"), errors.New("boom")},
+	}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{Samples: 2})
+
+	if _, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "q")); err == nil {
+		t.Fatal("Expected error when every sample fails")
+	}
+}
+
+func TestSelfConsistencyTemperatureOverride(t *testing.T) {
+	chain := &scriptedAgent{responses: []string{"a"}}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{Samples: 3, Temperature: 0.8})
+
+	_, _ = sc.Reason(context.Background(), agenkit.NewMessage("user", "q"))
+	if len(chain.temps) != 3 || chain.temps[0] != 0.8 {
+		t.Errorf("Expected temperature override on every sample, got %v", chain.temps)
+	}
+}
+
+func TestRegexAnswer(t *testing.T) {
+	extract, err := RegexAnswer(`Answer:\s*(\w+)`)
+	if err != nil {
+		t.Fatalf("RegexAnswer failed: %v", err)
+	}
+	got := extract(agenkit.NewMessage("agent", "Answer: B\n...on reflection, Answer: C"))
+	if got != "C" {
+		t.Errorf("Expected 'C', got '%s'", got)
+	}
+	if _, err := RegexAnswer("("); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+// seededAgent answers with a function of the seed in its context, like a
+// provider with seeded sampling.
+type seededAgent struct{}
+
+func (seededAgent) Name() string           { return "seeded" }
+func (seededAgent) Capabilities() []string { return nil }
+func (seededAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	seed, ok := llm.SeedFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no seed")
+	}
+	answer := "even"
+	if seed%2 != 0 {
+		answer = "odd"
+	}
+	return agenkit.NewMessage("agent", answer).WithMetadata("seed_honored", true), nil
+}
+
+func TestSelfConsistencySeedsAreReproducible(t *testing.T) {
+	base := int64(10)
+	sc, _ := NewSelfConsistency("sc", seededAgent{}, SelfConsistencyConfig{Samples: 5, Seed: &base})
+
+	var first map[string]int
+	for run := 0; run < 3; run++ {
+		artifact, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+		if err != nil {
+			t.Fatalf("Reason failed: %v", err)
+		}
+		votes := artifact.Metadata["votes"].(map[string]int)
+		if first == nil {
+			first = votes
+		} else if votes["even"] != first["even"] || votes["odd"] != first["odd"] {
+			t.Errorf("Run %d: expected stable votes %v, got %v", run, first, votes)
+		}
+		if seeds := artifact.Metadata["seeds"].([]int64); seeds[0] != 10 || seeds[4] != 14 {
+			t.Errorf("Expected seeds 10..14, got %v", seeds)
+		}
+		if artifact.Metadata["seed_honored"] != true {
+			t.Error("Expected seed_honored to be true")
+		}
+	}
+	if first["even"] != 3 || first["odd"] != 2 {
+		t.Errorf("Expected 3 even and 2 odd votes, got %v", first)
+	}
+}
+
+func TestSelfConsistencySeedNotHonored(t *testing.T) {
+	base := int64(1)
+	chain := &scriptedAgent{responses: []string{"42"}}
+	sc, _ := NewSelfConsistency("sc", chain, SelfConsistencyConfig{Samples: 2, Seed: &base})
+
+	artifact, err := sc.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Metadata["seed_honored"] != false {
+		t.Error("Expected seed_honored to be false when samples do not confirm the seed")
+	}
+}
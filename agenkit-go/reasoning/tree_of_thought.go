@@ -0,0 +1,388 @@
+package reasoning
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ThoughtNode is one reasoning step in a tree of thought.
+type ThoughtNode struct {
+	// ID identifies the node; the root is 0.
+	ID int `json:"id"`
+
+	// ParentID is the parent's ID, or -1 for the root.
+	ParentID int `json:"parent_id"`
+
+	// Depth is the number of steps from the root.
+	Depth int `json:"depth"`
+
+	// Question is the problem being solved.
+	Question string `json:"question"`
+
+	// Thought is the step's text; empty for the root.
+	Thought string `json:"thought"`
+
+	// Path holds the thoughts from the first step up to and including this one.
+	Path []string `json:"path"`
+
+	// Score is the scorer's rating of this node.
+	Score float64 `json:"score"`
+
+	// PathScore is the mean score of the nodes along Path.
+	PathScore float64 `json:"path_score"`
+}
+
+// Scorer rates a node. Scores are expected in [0, 1]; higher is better.
+type Scorer func(ctx context.Context, node ThoughtNode) (float64, error)
+
+// ThoughtCandidate is a leaf that tied for the best path score.
+type ThoughtCandidate struct {
+	Answer string   `json:"answer"`
+	Score  float64  `json:"score"`
+	Path   []string `json:"path"`
+}
+
+// TreeOfThoughtConfig configures the TreeOfThought technique.
+type TreeOfThoughtConfig struct {
+	// Branching is the number of next steps proposed for each expanded node.
+	// Default: 3
+	Branching int
+
+	// BeamWidth is the number of best nodes kept at each depth.
+	// Default: 2
+	BeamWidth int
+
+	// MaxDepth is the maximum number of steps along any path.
+	// Default: 3
+	MaxDepth int
+
+	// MaxNodes caps the total number of nodes generated, so a bushy tree
+	// cannot run unbounded.
+	// Default: 30
+	MaxNodes int
+
+	// Scorer rates each node.
+	// Default: ModelScorer using the technique's model
+	Scorer Scorer
+
+	// ExtractAnswer pulls the answer out of a leaf's thought.
+	// Default: FinalAnswer
+	ExtractAnswer AnswerExtractor
+
+	// TieEpsilon is the path score difference within which leaves count as
+	// tied for best.
+	// Default: 0.01
+	TieEpsilon float64
+
+	// Checkpoints, if set, receives the search state as the run
+	// progresses, so that a run interrupted by a crash can resume. Runs are
+	// identified by the session attached to the context (see
+	// session.WithSession); runs without a session are not checkpointed.
+	Checkpoints CheckpointStore
+
+	// CheckpointEvery is the number of depth levels searched between
+	// checkpoints. Required when Checkpoints is set.
+	// Default: 0 (no checkpoints)
+	CheckpointEvery int
+}
+
+// treeCheckpoint is the search state saved in a TreeOfThought checkpoint.
+type treeCheckpoint struct {
+	Depth        int           `json:"depth"`
+	Tree         []ThoughtNode `json:"tree"`
+	Beam         []ThoughtNode `json:"beam"`
+	Leaves       []ThoughtNode `json:"leaves"`
+	LimitReached bool          `json:"limit_reached"`
+}
+
+// TreeOfThought explores several reasoning paths with a beam search.
+//
+// Starting from the question, the model proposes Branching next steps for
+// each node in the beam; every step is scored, and the BeamWidth paths with
+// the highest mean score continue to the next depth. A step containing
+// "Final Answer:" ends its path. The search stops at MaxDepth, when the beam
+// is empty, or once MaxNodes nodes have been generated. The leaf with the
+// highest path score becomes the answer, and its path score the artifact's
+// Confidence. The artifact metadata records:
+//
+//   - "tree": every generated []ThoughtNode, root first
+//   - "best_path": the thoughts leading to the answer
+//   - "candidates": the []ThoughtCandidate tied within TieEpsilon, best first
+//   - "nodes": the number of nodes generated
+//   - "node_limit_reached": whether MaxNodes cut the search short
+//   - "resumed": whether the run continued from a checkpoint
+//   - "checkpoint_errors": the number of failed checkpoint writes, if any
+//
+// With checkpointing configured, the search state is saved every
+// CheckpointEvery depth levels without pausing the search, and the finished
+// artifact is saved as complete. Rerunning the same question in the same
+// session resumes from the last checkpoint, or returns the saved artifact
+// if the earlier run finished.
+type TreeOfThought struct {
+	name   string
+	model  agenkit.Agent
+	config TreeOfThoughtConfig
+}
+
+// Verify that TreeOfThought implements Technique and
+// agenkit.DeterminismReporter interfaces.
+var (
+	_ Technique                   = (*TreeOfThought)(nil)
+	_ agenkit.DeterminismReporter = (*TreeOfThought)(nil)
+)
+
+// NewTreeOfThought creates a new tree-of-thought technique driven by model.
+func NewTreeOfThought(name string, model agenkit.Agent, config TreeOfThoughtConfig) (*TreeOfThought, error) {
+	if model == nil {
+		return nil, fmt.Errorf("tree of thought requires a model agent")
+	}
+	if config.Branching <= 0 {
+		config.Branching = 3
+	}
+	if config.BeamWidth <= 0 {
+		config.BeamWidth = 2
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = 3
+	}
+	if config.MaxNodes <= 0 {
+		config.MaxNodes = 30
+	}
+	if config.Scorer == nil {
+		config.Scorer = ModelScorer(model)
+	}
+	if config.ExtractAnswer == nil {
+		config.ExtractAnswer = FinalAnswer
+	}
+	if config.TieEpsilon <= 0 {
+		config.TieEpsilon = 0.01
+	}
+	if config.Checkpoints != nil && config.CheckpointEvery <= 0 {
+		return nil, fmt.Errorf("checkpoint every must be positive when checkpoints are set, got %d", config.CheckpointEvery)
+	}
+	return &TreeOfThought{
+		name:   name,
+		model:  model,
+		config: config,
+	}, nil
+}
+
+// Name returns the name of the technique.
+func (t *TreeOfThought) Name() string {
+	return t.name
+}
+
+// Capabilities returns the model's capabilities plus the technique markers.
+func (t *TreeOfThought) Capabilities() []string {
+	return append(t.model.Capabilities(), "reasoning", "tree_of_thought")
+}
+
+// Deterministic reports true: the search adds no randomness of its own,
+// so the model, reached through GetAgents, decides. A custom Scorer is
+// assumed deterministic.
+func (t *TreeOfThought) Deterministic() bool {
+	return true
+}
+
+// GetAgents returns the model driving the search.
+func (t *TreeOfThought) GetAgents() []agenkit.Agent {
+	return []agenkit.Agent{t.model}
+}
+
+// Process runs the technique and returns the best answer.
+func (t *TreeOfThought) Process(ctx context.Context, message *agenkit.Message) (result *agenkit.Message, err error) {
+	ctx, finish := agenkit.TrackAgent(ctx, t.name, message)
+	defer func() { finish(result, err) }()
+
+	artifact, err := t.Reason(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return artifact.ToMessage(), nil
+}
+
+// Reason searches the tree and returns the best-scoring leaf's answer.
+func (t *TreeOfThought) Reason(ctx context.Context, message *agenkit.Message) (*Artifact, error) {
+	checkpoints := startCheckpoints(ctx, t.config.Checkpoints, t.name, "tree_of_thought", message.Content)
+	saved, err := checkpoints.resume(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tree of thought: %w", err)
+	}
+	if saved != nil && saved.Complete {
+		return resumedArtifact(saved), nil
+	}
+	defer checkpoints.stop()
+
+	root := ThoughtNode{ID: 0, ParentID: -1, Question: message.Content}
+	state := treeCheckpoint{Tree: []ThoughtNode{root}, Beam: []ThoughtNode{root}}
+	if saved != nil {
+		if err := decodeCheckpoint(saved, &state); err != nil {
+			return nil, fmt.Errorf("tree of thought: %w", err)
+		}
+	}
+	tree, beam, leaves, limitReached := state.Tree, state.Beam, state.Leaves, state.LimitReached
+
+	for depth := state.Depth + 1; depth <= t.config.MaxDepth && len(beam) > 0 && !limitReached; depth++ {
+		var children []ThoughtNode
+	expand:
+		for _, parent := range beam {
+			for b := 0; b < t.config.Branching; b++ {
+				if len(tree)-1 >= t.config.MaxNodes {
+					limitReached = true
+					break expand
+				}
+				if err := ctx.Err(); err != nil {
+					return nil, fmt.Errorf("tree of thought cancelled at depth %d: %w", depth, err)
+				}
+
+				child, err := t.expand(ctx, parent, len(tree), depth == t.config.MaxDepth)
+				if err != nil {
+					return nil, fmt.Errorf("tree of thought depth %d: %w", depth, err)
+				}
+				tree = append(tree, child)
+				children = append(children, child)
+				agenkit.Emit(ctx, agenkit.EventThoughtGenerated, t.name, map[string]interface{}{
+					"node":    child.ID,
+					"depth":   child.Depth,
+					"thought": child.Thought,
+					"score":   child.Score,
+				})
+			}
+		}
+
+		if limitReached && depth > 1 {
+			// Parents cut off mid-expansion are still open paths
+			leaves = append(leaves, beam...)
+		}
+		sort.SliceStable(children, func(i, j int) bool { return children[i].PathScore > children[j].PathScore })
+		beam = nil
+		for _, child := range children {
+			switch {
+			case isFinal(child) || depth == t.config.MaxDepth || limitReached:
+				leaves = append(leaves, child)
+			case len(beam) < t.config.BeamWidth:
+				beam = append(beam, child)
+			}
+		}
+
+		if depth%max(t.config.CheckpointEvery, 1) == 0 {
+			checkpoints.save(ctx, treeCheckpoint{
+				Depth:        depth,
+				Tree:         tree[:len(tree):len(tree)],
+				Beam:         beam,
+				Leaves:       leaves[:len(leaves):len(leaves)],
+				LimitReached: limitReached,
+			})
+		}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].PathScore > leaves[j].PathScore })
+	best := leaves[0]
+	var candidates []ThoughtCandidate
+	for _, leaf := range leaves {
+		if best.PathScore-leaf.PathScore > t.config.TieEpsilon {
+			break
+		}
+		candidates = append(candidates, ThoughtCandidate{
+			Answer: t.config.ExtractAnswer(agenkit.NewMessage("agent", leaf.Thought)),
+			Score:  leaf.PathScore,
+			Path:   leaf.Path,
+		})
+	}
+
+	artifact := NewArtifact("tree_of_thought", message.Content)
+	artifact.Answer = candidates[0].Answer
+	artifact.Confidence = best.PathScore
+	artifact.Metadata["tree"] = tree
+	artifact.Metadata["best_path"] = best.Path
+	artifact.Metadata["candidates"] = candidates
+	artifact.Metadata["nodes"] = len(tree) - 1
+	artifact.Metadata["node_limit_reached"] = limitReached
+	artifact.Metadata["resumed"] = saved != nil
+	if failed := checkpoints.finish(ctx, artifact); failed > 0 {
+		artifact.Metadata["checkpoint_errors"] = failed
+	}
+	return artifact, nil
+}
+
+// expand asks the model for the next step after parent and scores it.
+func (t *TreeOfThought) expand(ctx context.Context, parent ThoughtNode, id int, last bool) (ThoughtNode, error) {
+	response, err := t.model.Process(ctx, agenkit.NewMessage("user", buildThoughtPrompt(parent, last)))
+	if err != nil {
+		return ThoughtNode{}, fmt.Errorf("model failed: %w", err)
+	}
+
+	thought := strings.TrimSpace(response.Content)
+	node := ThoughtNode{
+		ID:       id,
+		ParentID: parent.ID,
+		Depth:    parent.Depth + 1,
+		Question: parent.Question,
+		Thought:  thought,
+		Path:     append(append([]string(nil), parent.Path...), thought),
+	}
+
+	score, err := t.config.Scorer(ctx, node)
+	if err != nil {
+		return ThoughtNode{}, fmt.Errorf("scorer failed: %w", err)
+	}
+	node.Score = score
+	// Running mean of the scores along the path
+	node.PathScore = (parent.PathScore*float64(parent.Depth) + score) / float64(node.Depth)
+	return node, nil
+}
+
+// isFinal reports whether a node states a final answer.
+func isFinal(node ThoughtNode) bool {
+	return strings.Contains(node.Thought, "Final Answer:")
+}
+
+// buildThoughtPrompt asks for the step following parent's path.
+func buildThoughtPrompt(parent ThoughtNode, last bool) string {
+	var sb strings.Builder
+	sb.WriteString("Solve the problem one step at a time.\n\nProblem:\n")
+	sb.WriteString(parent.Question)
+	sb.WriteString("\n")
+	if len(parent.Path) > 0 {
+		sb.WriteString("\nSteps so far:\n")
+		for i, step := range parent.Path {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+		}
+	}
+	if last {
+		sb.WriteString("\nWrite the final step and end with \"Final Answer: <answer>\".")
+	} else {
+		sb.WriteString("\nWrite only the next step. If the problem is solved, end with \"Final Answer: <answer>\".")
+	}
+	return sb.String()
+}
+
+// ModelScorer returns a Scorer that asks agent to rate how promising a
+// path is. The rating is parsed from a "Score: <0-1>" line; replies without
+// one score 0.
+func ModelScorer(agent agenkit.Agent) Scorer {
+	return func(ctx context.Context, node ThoughtNode) (float64, error) {
+		var sb strings.Builder
+		sb.WriteString("Rate how likely the reasoning below is to lead to a correct solution of the problem.\n\nProblem:\n")
+		sb.WriteString(node.Question)
+		sb.WriteString("\n\nReasoning:\n")
+		for i, step := range node.Path {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+		}
+		sb.WriteString("\nReply with \"Score: <number between 0 and 1>\".")
+
+		response, err := agent.Process(ctx, agenkit.NewMessage("user", sb.String()))
+		if err != nil {
+			return 0, err
+		}
+		score, _ := parseCritique(response.Content)
+		if math.IsNaN(score) {
+			return 0, nil
+		}
+		return score, nil
+	}
+}
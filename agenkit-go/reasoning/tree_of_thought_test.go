@@ -0,0 +1,173 @@
+package reasoning
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// tableScorer scores a node by the first matching substring of its thought.
+func tableScorer(scores map[string]float64) Scorer {
+	return func(ctx context.Context, node ThoughtNode) (float64, error) {
+		for text, score := range scores {
+			if strings.Contains(node.Thought, text) {
+				return score, nil
+			}
+		}
+		return 0, nil
+	}
+}
+
+func TestTreeOfThoughtPicksBestPath(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	// Depth 1: two branches from the root
+	model.Expect("", "step A")
+	model.Expect("", "step B")
+	// Depth 2: only the best branch (B) is expanded with beam width 1
+	model.Expect("", "Final Answer: 41")
+	model.Expect("", "Final Answer: 42")
+
+	tot, err := NewTreeOfThought("tot", model, TreeOfThoughtConfig{
+		Branching: 2,
+		BeamWidth: 1,
+		MaxDepth:  2,
+		Scorer: tableScorer(map[string]float64{
+			"step A": 0.2, "step B": 0.8, "41": 0.4, "42": 1.0,
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create TreeOfThought: %v", err)
+	}
+
+	artifact, err := tot.Reason(context.Background(), agenkit.NewMessage("user", "What is 6*7?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "42" {
+		t.Errorf("Expected answer '42', got '%s'", artifact.Answer)
+	}
+	if artifact.Confidence != 0.9 {
+		t.Errorf("Expected confidence 0.9 (mean of 0.8 and 1.0), got %v", artifact.Confidence)
+	}
+	path := artifact.Metadata["best_path"].([]string)
+	if len(path) != 2 || path[0] != "step B" {
+		t.Errorf("Expected best path through 'step B', got %v", path)
+	}
+	if artifact.Metadata["nodes"] != 4 {
+		t.Errorf("Expected 4 nodes, got %v", artifact.Metadata["nodes"])
+	}
+	if !strings.Contains(model.Calls()[2].Content, "1. step B") {
+		t.Errorf("Expected expansion prompt to include the path so far, got:\n%s", model.Calls()[2].Content)
+	}
+	model.AssertExpectationsMet()
+}
+
+func TestTreeOfThoughtTiedCandidates(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Final Answer: yes")
+	model.Expect("", "Final Answer: no")
+	model.Expect("", "Final Answer: maybe")
+
+	tot, _ := NewTreeOfThought("tot", model, TreeOfThoughtConfig{
+		Branching:  3,
+		TieEpsilon: 0.05,
+		Scorer: tableScorer(map[string]float64{
+			"yes": 0.70, "no": 0.73, "maybe": 0.5,
+		}),
+	})
+
+	artifact, err := tot.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	candidates := artifact.Metadata["candidates"].([]ThoughtCandidate)
+	if len(candidates) != 2 {
+		t.Fatalf("Expected 2 tied candidates, got %+v", candidates)
+	}
+	if candidates[0].Answer != "no" || candidates[1].Answer != "yes" {
+		t.Errorf("Expected candidates ordered best first, got %+v", candidates)
+	}
+	if artifact.Answer != "no" {
+		t.Errorf("Expected answer 'no', got '%s'", artifact.Answer)
+	}
+}
+
+func TestTreeOfThoughtMaxNodes(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "step 1")
+	model.Expect("", "step 2")
+	model.Expect("", "step 3")
+
+	tot, _ := NewTreeOfThought("tot", model, TreeOfThoughtConfig{
+		Branching: 2,
+		MaxDepth:  5,
+		MaxNodes:  3,
+		Scorer:    tableScorer(map[string]float64{"1": 0.5, "2": 0.6, "3": 0.9}),
+	})
+
+	artifact, err := tot.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if len(model.Calls()) != 3 {
+		t.Errorf("Expected generation to stop at 3 nodes, got %d model calls", len(model.Calls()))
+	}
+	if artifact.Metadata["node_limit_reached"] != true {
+		t.Error("Expected node_limit_reached to be true")
+	}
+	if artifact.Answer != "step 3" {
+		t.Errorf("Expected the best open path's thought as answer, got '%s'", artifact.Answer)
+	}
+}
+
+func TestTreeOfThoughtModelScorer(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Final Answer: 4")
+	scorer := testutil.NewMockAgent(t, "scorer")
+	scorer.Expect("", "Score: 0.75")
+
+	tot, _ := NewTreeOfThought("tot", model, TreeOfThoughtConfig{
+		Branching: 1,
+		Scorer:    ModelScorer(scorer),
+	})
+
+	artifact, err := tot.Reason(context.Background(), agenkit.NewMessage("user", "2+2?"))
+	if err != nil {
+		t.Fatalf("Reason failed: %v", err)
+	}
+	if artifact.Answer != "4" || artifact.Confidence != 0.75 {
+		t.Errorf("Expected answer '4' with confidence 0.75, got '%s' (%v)", artifact.Answer, artifact.Confidence)
+	}
+	if !strings.Contains(scorer.Calls()[0].Content, "Final Answer: 4") {
+		t.Errorf("Expected scorer prompt to include the path, got:\n%s", scorer.Calls()[0].Content)
+	}
+}
+
+func TestTreeOfThoughtScorerError(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "step")
+
+	tot, _ := NewTreeOfThought("tot", model, TreeOfThoughtConfig{
+		Scorer: func(ctx context.Context, node ThoughtNode) (float64, error) {
+			return 0, errors.New("scorer down")
+		},
+	})
+
+	_, err := tot.Reason(context.Background(), agenkit.NewMessage("user", "?"))
+	if err == nil || !strings.Contains(err.Error(), "scorer down") {
+		t.Errorf("Expected wrapped scorer error, got %v", err)
+	}
+}
+
+func TestFinalAnswer(t *testing.T) {
+	if got := FinalAnswer(agenkit.NewMessage("agent", "work\nFinal Answer: 7 ")); got != "7" {
+		t.Errorf("Expected '7', got '%s'", got)
+	}
+	if got := FinalAnswer(agenkit.NewMessage("agent", " plain ")); got != "plain" {
+		t.Errorf("Expected 'plain', got '%s'", got)
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default stream resumption settings.
+const (
+	DefaultStreamBufferSize  = 256
+	DefaultStreamGracePeriod = time.Minute
+)
+
+// StreamIDHeader is the response header of /stream naming the stream, so a
+// client whose connection drops before the first event can still resume.
+const StreamIDHeader = "X-Stream-ID"
+
+// streamEvent is one server-sent event of a stream.
+type streamEvent struct {
+	index int
+	event string
+	data  []byte
+}
+
+// resumableStream holds the recent events of one stream for clients that
+// reconnect. It is safe for concurrent use.
+type resumableStream struct {
+	mu      sync.Mutex
+	size    int
+	events  []streamEvent // the most recent events, oldest first
+	next    int           // index of the next event; indexes start at 1
+	done    bool
+	changed chan struct{} // closed and replaced when events are added
+
+	cancel    context.CancelFunc // stops the generation
+	grace     time.Duration
+	followers int
+	idle      *time.Timer // cancels the generation once no client follows
+}
+
+func newResumableStream(size int, grace time.Duration, cancel context.CancelFunc) *resumableStream {
+	return &resumableStream{size: size, next: 1, changed: make(chan struct{}), grace: grace, cancel: cancel}
+}
+
+// attach registers a client following the stream.
+func (st *resumableStream) attach() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.followers++
+	if st.idle != nil {
+		st.idle.Stop()
+		st.idle = nil
+	}
+}
+
+// detach unregisters a client. If it was the last one and the stream is
+// still running, the generation is cancelled unless a client resumes
+// within the grace period.
+func (st *resumableStream) detach() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.followers--
+	if st.followers == 0 && !st.done {
+		st.idle = time.AfterFunc(st.grace, st.cancel)
+	}
+}
+
+// add appends an event, dropping the oldest once the buffer is full. A
+// terminal event marks the stream done.
+func (st *resumableStream) add(event string, value interface{}, terminal bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(errorResponse{Error: ErrorBody{Code: "encoding_error", Message: err.Error()}})
+		event, terminal = "error", true
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.done {
+		return
+	}
+	st.events = append(st.events, streamEvent{index: st.next, event: event, data: data})
+	if len(st.events) > st.size {
+		st.events = st.events[len(st.events)-st.size:]
+	}
+	st.next++
+	st.done = terminal
+	close(st.changed)
+	st.changed = make(chan struct{})
+}
+
+// since returns the buffered events after cursor, whether the stream is
+// done, and a channel closed when more events arrive. ok is false if
+// events after cursor have already been dropped.
+func (st *resumableStream) since(cursor int) (events []streamEvent, done bool, changed <-chan struct{}, ok bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	first := st.next
+	if len(st.events) > 0 {
+		first = st.events[0].index
+	}
+	if cursor+1 < first {
+		return nil, st.done, st.changed, false
+	}
+	for _, e := range st.events {
+		if e.index > cursor {
+			events = append(events, e)
+		}
+	}
+	return events, st.done, st.changed, true
+}
+
+// last returns the index of the most recent event.
+func (st *resumableStream) last() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.next - 1
+}
+
+// SetStreamBufferSize sets how many recent events each stream keeps for
+// clients resuming with Last-Event-ID.
+// Default: 256
+func (s *Server) SetStreamBufferSize(n int) {
+	if n > 0 {
+		s.streamBufferSize = n
+	}
+}
+
+// SetStreamGracePeriod sets how long a finished stream's events stay
+// available for resuming, and how long a running stream keeps generating
+// with no client connected before it is cancelled.
+// Default: 1m
+func (s *Server) SetStreamGracePeriod(d time.Duration) {
+	if d > 0 {
+		s.streamGracePeriod = d
+	}
+}
+
+// openStream registers a new stream, whose generation cancel stops, and
+// returns its ID.
+func (s *Server) openStream(cancel context.CancelFunc) (string, *resumableStream) {
+	id := newSessionID()
+	st := newResumableStream(s.streamBufferSize, s.streamGracePeriod, cancel)
+	s.streamsMu.Lock()
+	s.streams[id] = st
+	s.streamsMu.Unlock()
+	return id, st
+}
+
+// closeStream forgets a finished stream once its grace period is over.
+func (s *Server) closeStream(id string) {
+	time.AfterFunc(s.streamGracePeriod, func() {
+		s.streamsMu.Lock()
+		delete(s.streams, id)
+		s.streamsMu.Unlock()
+	})
+}
+
+// handleResume continues the stream named by a Last-Event-ID of the form
+// "<stream id>:<index>" from the event after index. Streams that have
+// expired, or have dropped events after index, cannot be resumed and get
+// 410 Gone with code "cannot_resume", telling the client to start over.
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request, flusher http.Flusher, lastEventID string) {
+	id, rawCursor, found := strings.Cut(lastEventID, ":")
+	cursor, err := strconv.Atoi(rawCursor)
+	if !found || err != nil || cursor < 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid Last-Event-ID %q", lastEventID))
+		return
+	}
+
+	s.streamsMu.Lock()
+	st := s.streams[id]
+	s.streamsMu.Unlock()
+	if st == nil {
+		writeError(w, http.StatusGone, "cannot_resume", "stream not found or expired")
+		return
+	}
+	if cursor > st.last() {
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("stream has no event %d", cursor))
+		return
+	}
+	if _, _, _, ok := st.since(cursor); !ok {
+		writeError(w, http.StatusGone, "cannot_resume", fmt.Sprintf("events after %d are no longer buffered", cursor))
+		return
+	}
+	s.follow(w, r, flusher, id, st, cursor)
+}
+
+// follow writes the stream's events after cursor as they arrive, until the
+// terminal event is written or the client goes away.
+func (s *Server) follow(w http.ResponseWriter, r *http.Request, flusher http.Flusher, id string, st *resumableStream, cursor int) {
+	st.attach()
+	defer st.detach()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(StreamIDHeader, id)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		events, done, changed, ok := st.since(cursor)
+		if !ok {
+			// This client fell further behind than the buffer holds
+			data, _ := json.Marshal(errorResponse{Error: ErrorBody{Code: "cannot_resume", Message: fmt.Sprintf("events after %d are no longer buffered", cursor)}})
+			writeEvent(w, flusher, "", "error", data)
+			return
+		}
+		for _, e := range events {
+			writeEvent(w, flusher, id+":"+strconv.Itoa(e.index), e.event, e.data)
+			cursor = e.index
+		}
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
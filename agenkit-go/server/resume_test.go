@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// gatedStreamer streams its first word, then waits for release before
+// streaming the rest.
+type gatedStreamer struct {
+	sent    chan struct{}
+	release chan struct{}
+}
+
+func (a *gatedStreamer) Name() string           { return "gated" }
+func (a *gatedStreamer) Capabilities() []string { return nil }
+func (a *gatedStreamer) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return agenkit.NewMessage("agent", message.Content), nil
+}
+func (a *gatedStreamer) ProcessStream(ctx context.Context, message *agenkit.Message) (<-chan agenkit.StreamChunk, error) {
+	out := make(chan agenkit.StreamChunk)
+	go func() {
+		defer close(out)
+		for i, word := range strings.Fields(message.Content) {
+			if !agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Delta: word + " "}) {
+				return
+			}
+			if i == 0 {
+				close(a.sent)
+				<-a.release
+			}
+		}
+		agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Message: agenkit.NewMessage("agent", message.Content)})
+	}()
+	return out, nil
+}
+
+// eventIDs returns the id lines of a server-sent event stream.
+func eventIDs(body string) []string {
+	var ids []string
+	for _, line := range strings.Split(body, "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func resume(t *testing.T, handler http.Handler, lastEventID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerStreamEventIDs(t *testing.T) {
+	s := NewServer(&wordStreamer{})
+	rec := post(t, s, "/stream", `{"message":{"content":"one two three"}}`)
+
+	id := rec.Header().Get(StreamIDHeader)
+	want := []string{id + ":1", id + ":2", id + ":3", id + ":4"}
+	if got := eventIDs(rec.Body.String()); id == "" || strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected event IDs %v, got %v", want, got)
+	}
+
+	// A client that saw the first event resumes from the second
+	resumed := resume(t, s, id+":1")
+	events := readEvents(t, resumed.Body.String())
+	if resumed.Code != http.StatusOK || len(events) != 3 || !strings.Contains(events[0][1], "two") || events[2][0] != "done" {
+		t.Errorf("Expected the events after 1, got %d %v", resumed.Code, events)
+	}
+}
+
+func TestServerStreamResumesAfterDisconnect(t *testing.T) {
+	agent := &gatedStreamer{sent: make(chan struct{}), release: make(chan struct{})}
+	s := NewServer(agent)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/stream", strings.NewReader(`{"message":{"content":"one two three"}}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		s.ServeHTTP(rec, req)
+		close(served)
+	}()
+	<-agent.sent
+	disconnect()
+	<-served
+	close(agent.release)
+
+	cursor := "0"
+	if ids := eventIDs(rec.Body.String()); len(ids) > 0 {
+		cursor = strings.Split(ids[len(ids)-1], ":")[1]
+	}
+	resumed := resume(t, s, rec.Header().Get(StreamIDHeader)+":"+cursor)
+	events := readEvents(t, resumed.Body.String())
+	if len(events) == 0 || events[len(events)-1][0] != "done" {
+		t.Fatalf("Expected the stream to finish after resuming, got %v", events)
+	}
+	var done Response
+	json.Unmarshal([]byte(events[len(events)-1][1]), &done)
+	if done.Message.Content != "one two three" {
+		t.Errorf("Expected the generation to continue while disconnected, got %v", done.Message)
+	}
+}
+
+// endlessStreamer streams one word, then waits for its context to end.
+type endlessStreamer struct {
+	cancelled chan struct{}
+}
+
+func (a *endlessStreamer) Name() string           { return "endless" }
+func (a *endlessStreamer) Capabilities() []string { return nil }
+func (a *endlessStreamer) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return agenkit.NewMessage("agent", message.Content), nil
+}
+func (a *endlessStreamer) ProcessStream(ctx context.Context, message *agenkit.Message) (<-chan agenkit.StreamChunk, error) {
+	out := make(chan agenkit.StreamChunk)
+	go func() {
+		defer close(out)
+		agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Delta: "forever "})
+		<-ctx.Done()
+		close(a.cancelled)
+	}()
+	return out, nil
+}
+
+func TestServerStreamCancelsAbandonedGeneration(t *testing.T) {
+	agent := &endlessStreamer{cancelled: make(chan struct{})}
+	s := NewServer(agent)
+	s.SetStreamGracePeriod(20 * time.Millisecond)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/stream", strings.NewReader(`{"message":{"content":"x"}}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		s.ServeHTTP(rec, req)
+		close(served)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	disconnect()
+	<-served
+
+	select {
+	case <-agent.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the generation to be cancelled once no client followed it")
+	}
+}
+
+func TestServerStreamCannotResume(t *testing.T) {
+	s := NewServer(&wordStreamer{})
+	s.SetStreamBufferSize(2)
+	s.SetStreamGracePeriod(50 * time.Millisecond)
+	rec := post(t, s, "/stream", `{"message":{"content":"one two three"}}`)
+	id := rec.Header().Get(StreamIDHeader)
+
+	if got := resume(t, s, id+":0"); got.Code != http.StatusGone || decodeError(t, got).Code != "cannot_resume" {
+		t.Errorf("Expected cannot_resume for a cursor older than the buffer, got %d %s", got.Code, got.Body.String())
+	}
+	if got := resume(t, s, id+":2"); got.Code != http.StatusOK || len(readEvents(t, got.Body.String())) != 2 {
+		t.Errorf("Expected the buffered events, got %d %s", got.Code, got.Body.String())
+	}
+	if got := resume(t, s, "unknown:1"); got.Code != http.StatusGone {
+		t.Errorf("Expected 410 for an unknown stream, got %d", got.Code)
+	}
+	if got := resume(t, s, "garbage"); got.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed ID, got %d", got.Code)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := resume(t, s, id+":2"); got.Code != http.StatusGone {
+		t.Errorf("Expected the stream to expire after its grace period, got %d", got.Code)
+	}
+}
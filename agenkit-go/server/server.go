@@ -0,0 +1,500 @@
+// Package server exposes an agent as an HTTP service.
+//
+// A Server is an http.Handler with three endpoints:
+//
+//   - POST /process: processes a message and returns the response as JSON
+//   - POST /stream: streams the response as server-sent events
+//   - GET /health: reports that the server is up
+//
+// Both POST endpoints accept a JSON body of the form
+//
+//	{"session_id": "abc", "message": {"role": "user", "content": "Hello"}}
+//
+// If a SessionStore is configured, the session is loaded before the agent
+// runs, made available through session.SessionFromContext, and saved with
+// the new exchange appended once the agent succeeds. Errors are returned as
+// {"error": {"code": "...", "message": "..."}} with a matching status code.
+//
+// Streams survive dropped connections: every event of /stream has an ID
+// "<stream id>:<index>", with indexes increasing from 1, and a client
+// reconnecting to /stream with a Last-Event-ID header resumes after that
+// event instead of starting over. The agent keeps running while no client
+// is connected, but if none reconnects within the stream grace period, as
+// when a user closes the page, it is cancelled so that model calls stop.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/middleware"
+	"github.com/agenkit/agenkit-go/session"
+)
+
+// Default server settings.
+const (
+	DefaultTimeout         = 60 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultMaxBodyBytes    = 1 << 20
+)
+
+// Request is the body accepted by /process and /stream.
+type Request struct {
+	SessionID string           `json:"session_id,omitempty"`
+	Message   *agenkit.Message `json:"message"`
+}
+
+// Response is the body returned by /process, and the payload of the
+// terminal "done" event on /stream.
+type Response struct {
+	SessionID string           `json:"session_id,omitempty"`
+	Message   *agenkit.Message `json:"message"`
+}
+
+// ErrorBody describes a failed request.
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorResponse is the JSON envelope for errors.
+type errorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// Server serves an agent over HTTP.
+type Server struct {
+	agent           agenkit.Agent
+	store           session.SessionStore
+	timeout         time.Duration
+	shutdownTimeout time.Duration
+	maxBodyBytes    int64
+	mux             *http.ServeMux
+	lifecycle       agenkit.Lifecycle
+
+	streamBufferSize  int
+	streamGracePeriod time.Duration
+	streamsMu         sync.Mutex
+	streams           map[string]*resumableStream
+}
+
+// Verify that Server implements http.Handler interface.
+var _ http.Handler = (*Server)(nil)
+
+// NewServer creates a server for agent.
+func NewServer(agent agenkit.Agent) *Server {
+	s := &Server{
+		agent:           agent,
+		timeout:         DefaultTimeout,
+		shutdownTimeout: DefaultShutdownTimeout,
+		maxBodyBytes:    DefaultMaxBodyBytes,
+		mux:             http.NewServeMux(),
+
+		streamBufferSize:  DefaultStreamBufferSize,
+		streamGracePeriod: DefaultStreamGracePeriod,
+		streams:           make(map[string]*resumableStream),
+	}
+	s.mux.HandleFunc("/process", s.handleProcess)
+	s.mux.HandleFunc("/stream", s.handleStream)
+	s.mux.HandleFunc("/health", s.handleHealth)
+	return s
+}
+
+// SetSessionStore sets the store used to load and save session history.
+// Without a store, session IDs are passed to the agent but not persisted.
+func (s *Server) SetSessionStore(store session.SessionStore) {
+	s.store = store
+}
+
+// SetTimeout sets the per-request deadline for the agent.
+// Default: 60s
+func (s *Server) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.timeout = timeout
+	}
+}
+
+// SetShutdownTimeout sets how long ListenAndServe waits for in-flight
+// requests to finish when shutting down.
+// Default: 30s
+func (s *Server) SetShutdownTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// SetMaxBodyBytes caps the size of request bodies.
+// Default: 1 MiB
+func (s *Server) SetMaxBodyBytes(n int64) {
+	if n > 0 {
+		s.maxBodyBytes = n
+	}
+}
+
+// SetLifecycle sets a component, typically a composition.Runtime, that
+// Serve starts before accepting requests and stops after shutting down.
+// Default: none
+func (s *Server) SetLifecycle(lifecycle agenkit.Lifecycle) {
+	s.lifecycle = lifecycle
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves on addr until SIGINT or SIGTERM is received, then
+// shuts down gracefully, letting in-flight requests finish.
+func (s *Server) ListenAndServe(addr string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is done, then shuts down gracefully.
+// The lifecycle set with SetLifecycle is started first, and if it fails to
+// start, Serve returns its error without serving.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) (err error) {
+	if s.lifecycle != nil {
+		if err := s.lifecycle.Start(ctx); err != nil {
+			listener.Close()
+			return fmt.Errorf("server start: %w", err)
+		}
+		defer func() {
+			stopCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+			defer cancel()
+			if stopErr := s.lifecycle.Stop(stopCtx); stopErr != nil {
+				err = errors.Join(err, fmt.Errorf("server stop: %w", stopErr))
+			}
+		}()
+	}
+
+	httpServer := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handleHealth reports liveness.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "ok",
+		"agent":  s.agent.Name(),
+	})
+}
+
+// handleProcess processes one message and returns the response.
+func (s *Server) handleProcess(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel, req, sess, ok := s.begin(w, r, r.Context())
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	response, err := s.agent.Process(ctx, req.Message)
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	if err := s.finish(ctx, sess, req.Message, response); err != nil {
+		writeError(w, http.StatusInternalServerError, "session_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, Response{SessionID: req.SessionID, Message: response})
+}
+
+// handleStream streams the response as server-sent events: "chunk" events
+// carry each StreamChunk, followed by a terminal "done" event carrying a
+// Response or an "error" event carrying an error body. Requests with a
+// Last-Event-ID header resume an earlier stream instead.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming_unsupported", "response writer cannot stream")
+		return
+	}
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		s.handleResume(w, r, flusher, lastEventID)
+		return
+	}
+
+	// The agent outlives the connection, so clients can resume
+	ctx, cancel, req, sess, ok := s.begin(w, r, context.WithoutCancel(r.Context()))
+	if !ok {
+		return
+	}
+
+	chunks, err := streamChunks(ctx, s.agent, req.Message)
+	if err != nil {
+		cancel()
+		writeAgentError(w, err)
+		return
+	}
+
+	id, st := s.openStream(cancel)
+	go func() {
+		defer cancel()
+		defer s.closeStream(id)
+		s.produce(ctx, st, req, sess, chunks)
+	}()
+	s.follow(w, r, flusher, id, st, 0)
+}
+
+// produce records the events of a stream.
+func (s *Server) produce(ctx context.Context, st *resumableStream, req *Request, sess *session.Session, chunks <-chan agenkit.StreamChunk) {
+	var content strings.Builder
+	for {
+		var chunk agenkit.StreamChunk
+		var open bool
+		select {
+		case <-ctx.Done():
+			_, body := classifyError(ctx.Err())
+			st.add("error", errorResponse{Error: body}, true)
+			return
+		case chunk, open = <-chunks:
+		}
+
+		if !open {
+			chunk = agenkit.StreamChunk{Done: true}
+		}
+		if chunk.Err != nil {
+			_, body := classifyError(chunk.Err)
+			st.add("error", errorResponse{Error: body}, true)
+			return
+		}
+		if !chunk.Done {
+			content.WriteString(chunk.Delta)
+			st.add("chunk", chunk, false)
+			continue
+		}
+
+		response := chunk.Message
+		if response == nil {
+			response = agenkit.NewMessage("agent", content.String())
+		}
+		if err := s.finish(ctx, sess, req.Message, response); err != nil {
+			st.add("error", errorResponse{Error: ErrorBody{Code: "session_error", Message: err.Error()}}, true)
+			return
+		}
+		st.add("done", Response{SessionID: req.SessionID, Message: response}, true)
+		return
+	}
+}
+
+// begin validates the request, derives the request context from parent,
+// and loads the session. It writes an error response and returns ok=false
+// on failure.
+func (s *Server) begin(w http.ResponseWriter, r *http.Request, parent context.Context) (context.Context, context.CancelFunc, *Request, *session.Session, bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use POST")
+		return nil, nil, nil, nil, false
+	}
+
+	var req Request
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("invalid JSON body: %v", err))
+		return nil, nil, nil, nil, false
+	}
+	if req.Message == nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "message is required")
+		return nil, nil, nil, nil, false
+	}
+	if req.Message.Role == "" {
+		req.Message.Role = "user"
+	}
+	if req.Message.Timestamp.IsZero() {
+		req.Message.Timestamp = time.Now().UTC()
+	}
+	if req.Message.Metadata == nil {
+		req.Message.Metadata = make(map[string]interface{})
+	}
+
+	ctx, cancel := context.WithTimeout(parent, s.timeout)
+
+	var sess *session.Session
+	if s.store != nil {
+		if req.SessionID == "" {
+			req.SessionID = newSessionID()
+		}
+		loaded, err := s.store.Load(ctx, req.SessionID)
+		switch {
+		case errors.Is(err, session.ErrSessionNotFound):
+			sess = session.NewSession(req.SessionID)
+		case err != nil:
+			cancel()
+			writeError(w, http.StatusInternalServerError, "session_error", err.Error())
+			return nil, nil, nil, nil, false
+		default:
+			sess = loaded
+		}
+		ctx = session.WithSession(ctx, sess)
+	}
+	if req.SessionID != "" {
+		req.Message.Metadata["session_id"] = req.SessionID
+	}
+
+	return ctx, cancel, &req, sess, true
+}
+
+// finish appends the exchange to the session and saves it.
+func (s *Server) finish(ctx context.Context, sess *session.Session, message, response *agenkit.Message) error {
+	if sess == nil {
+		return nil
+	}
+	sess.AddMessage(message, response)
+	return s.store.Save(ctx, sess)
+}
+
+// streamChunks streams a response from agent. StreamingAgent
+// implementations are adapted so each streamed message becomes a delta;
+// other agents go through agenkit.ProcessStream.
+func streamChunks(ctx context.Context, agent agenkit.Agent, message *agenkit.Message) (<-chan agenkit.StreamChunk, error) {
+	if _, ok := agent.(agenkit.ChunkStreamingAgent); ok {
+		return agenkit.ProcessStream(ctx, agent, message)
+	}
+	streamer, ok := agent.(agenkit.StreamingAgent)
+	if !ok {
+		return agenkit.ProcessStream(ctx, agent, message)
+	}
+
+	messages, errs := streamer.Stream(ctx, message)
+	out := make(chan agenkit.StreamChunk)
+	go func() {
+		defer close(out)
+		var content strings.Builder
+		for messages != nil || errs != nil {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					messages = nil
+					continue
+				}
+				content.WriteString(msg.Content)
+				if !agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Delta: msg.Content}) {
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if err != nil {
+					agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Err: err})
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+		agenkit.SendChunk(ctx, out, agenkit.StreamChunk{Done: true, Message: agenkit.NewMessage("agent", content.String())})
+	}()
+	return out, nil
+}
+
+// classifyError maps an agent error to an HTTP status and error body.
+func classifyError(err error) (int, ErrorBody) {
+	status, code := http.StatusInternalServerError, "agent_error"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code = http.StatusGatewayTimeout, "timeout"
+	case errors.Is(err, context.Canceled):
+		status, code = http.StatusServiceUnavailable, "cancelled"
+	case errors.Is(err, middleware.ErrCircuitOpen):
+		status, code = http.StatusServiceUnavailable, "unavailable"
+	case errors.Is(err, middleware.ErrDenied):
+		status, code = http.StatusForbidden, "denied"
+	case errors.Is(err, llm.ErrBudgetExceeded):
+		status, code = http.StatusTooManyRequests, "budget_exceeded"
+	case errors.As(err, new(*agenkit.RateLimitError)):
+		status, code = http.StatusTooManyRequests, "rate_limited"
+	case errors.As(err, new(*agenkit.ContextLengthError)), errors.Is(err, llm.ErrContextWindowExceeded):
+		status, code = http.StatusRequestEntityTooLarge, "context_length_exceeded"
+	}
+	return status, ErrorBody{Code: code, Message: err.Error()}
+}
+
+// writeAgentError writes an agent failure as an error response.
+func writeAgentError(w http.ResponseWriter, err error) {
+	status, body := classifyError(err)
+	if after, ok := agenkit.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(after.Seconds()))))
+	}
+	writeJSON(w, status, errorResponse{Error: body})
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorResponse{Error: ErrorBody{Code: code, Message: message}})
+}
+
+// writeJSON writes value as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeEvent writes one server-sent event and flushes it. An empty id is
+// omitted.
+func writeEvent(w http.ResponseWriter, flusher http.Flusher, id, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	flusher.Flush()
+}
+
+// newSessionID returns a random session identifier.
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,371 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/middleware"
+	"github.com/agenkit/agenkit-go/session"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+// historyAgent replies with the number of messages in its session.
+type historyAgent struct{}
+
+func (a *historyAgent) Name() string           { return "history" }
+func (a *historyAgent) Capabilities() []string { return nil }
+func (a *historyAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	sess := session.SessionFromContext(ctx)
+	if sess == nil {
+		return agenkit.NewMessage("agent", "no session"), nil
+	}
+	return agenkit.NewMessage("agent", strings.Repeat("x", sess.Len())), nil
+}
+
+// slowAgent blocks until its context is done.
+type slowAgent struct{}
+
+func (a *slowAgent) Name() string           { return "slow" }
+func (a *slowAgent) Capabilities() []string { return nil }
+func (a *slowAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// wordStreamer streams each word of its input as a separate message.
+type wordStreamer struct{}
+
+func (a *wordStreamer) Name() string           { return "streamer" }
+func (a *wordStreamer) Capabilities() []string { return nil }
+func (a *wordStreamer) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	return agenkit.NewMessage("agent", message.Content), nil
+}
+func (a *wordStreamer) Stream(ctx context.Context, message *agenkit.Message) (<-chan *agenkit.Message, <-chan error) {
+	messages := make(chan *agenkit.Message)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		defer close(errs)
+		for _, word := range strings.Fields(message.Content) {
+			messages <- agenkit.NewMessage("agent", word+" ")
+		}
+	}()
+	return messages, errs
+}
+
+func post(t *testing.T, handler http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) ErrorBody {
+	t.Helper()
+	var body errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON error body, got %q", rec.Body.String())
+	}
+	return body.Error
+}
+
+// readEvents parses a server-sent event stream into (event, data) pairs.
+func readEvents(t *testing.T, body string) [][2]string {
+	t.Helper()
+	var events [][2]string
+	var event string
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			events = append(events, [2]string{event, strings.TrimPrefix(line, "data: ")})
+		}
+	}
+	return events
+}
+
+func TestServerProcess(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "echo")
+	agent.Expect("hello", "hi there")
+
+	rec := post(t, NewServer(agent), "/process", `{"session_id":"s1","message":{"role":"user","content":"hello"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if resp.Message.Content != "hi there" || resp.SessionID != "s1" {
+		t.Errorf("Expected 'hi there' for session s1, got %+v", resp)
+	}
+	if calls := agent.Calls(); calls[0].Metadata["session_id"] != "s1" {
+		t.Errorf("Expected session ID attached to message, got %v", calls[0].Metadata)
+	}
+	agent.AssertExpectationsMet()
+}
+
+func TestServerProcessPersistsSession(t *testing.T) {
+	store := session.NewMemorySessionStore()
+	s := NewServer(&historyAgent{})
+	s.SetSessionStore(store)
+
+	for i, expected := range []string{"", "xx"} {
+		rec := post(t, s, "/process", `{"session_id":"s1","message":{"content":"hello"}}`)
+		var resp Response
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.Message.Content != expected {
+			t.Errorf("Request %d: Expected history length marker '%s', got '%s'", i+1, expected, resp.Message.Content)
+		}
+	}
+
+	saved, err := store.Load(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("Expected session to be saved: %v", err)
+	}
+	if saved.Len() != 4 {
+		t.Errorf("Expected 4 messages in history, got %d", saved.Len())
+	}
+}
+
+func TestServerAssignsSessionID(t *testing.T) {
+	s := NewServer(&historyAgent{})
+	s.SetSessionStore(session.NewMemorySessionStore())
+
+	rec := post(t, s, "/process", `{"message":{"content":"hello"}}`)
+	var resp Response
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.SessionID == "" {
+		t.Error("Expected a generated session ID")
+	}
+}
+
+func TestServerProcessErrors(t *testing.T) {
+	failing := testutil.NewMockAgent(t, "failing")
+	failing.Expect("", "").WithError(errors.New("boom"))
+	denied := testutil.NewMockAgent(t, "denied")
+	denied.Expect("", "").WithError(middleware.Deny("not allowed"))
+	limited := testutil.NewMockAgent(t, "limited")
+	limited.Expect("", "").WithError(&agenkit.RateLimitError{Provider: "p", RetryAfter: 1500 * time.Millisecond})
+	tooLong := testutil.NewMockAgent(t, "too-long")
+	tooLong.Expect("", "").WithError(&agenkit.ContextLengthError{Provider: "p"})
+
+	tests := []struct {
+		name   string
+		agent  agenkit.Agent
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"bad json", &historyAgent{}, http.MethodPost, `{`, http.StatusBadRequest, "invalid_request"},
+		{"missing message", &historyAgent{}, http.MethodPost, `{"session_id":"s1"}`, http.StatusBadRequest, "invalid_request"},
+		{"wrong method", &historyAgent{}, http.MethodGet, ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"agent error", failing, http.MethodPost, `{"message":{"content":"x"}}`, http.StatusInternalServerError, "agent_error"},
+		{"denied", denied, http.MethodPost, `{"message":{"content":"x"}}`, http.StatusForbidden, "denied"},
+		{"rate limited", limited, http.MethodPost, `{"message":{"content":"x"}}`, http.StatusTooManyRequests, "rate_limited"},
+		{"context length", tooLong, http.MethodPost, `{"message":{"content":"x"}}`, http.StatusRequestEntityTooLarge, "context_length_exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/process", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			NewServer(tt.agent).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if body := decodeError(t, rec); body.Code != tt.code {
+				t.Errorf("Expected code '%s', got '%s'", tt.code, body.Code)
+			}
+			if tt.code == "rate_limited" && rec.Header().Get("Retry-After") != "2" {
+				t.Errorf("Expected Retry-After 2, got '%s'", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	s := NewServer(&slowAgent{})
+	s.SetTimeout(20 * time.Millisecond)
+
+	rec := post(t, s, "/process", `{"message":{"content":"x"}}`)
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected 504, got %d", rec.Code)
+	}
+	if body := decodeError(t, rec); body.Code != "timeout" {
+		t.Errorf("Expected code 'timeout', got '%s'", body.Code)
+	}
+}
+
+func TestServerStreamStreamingAgent(t *testing.T) {
+	store := session.NewMemorySessionStore()
+	s := NewServer(&wordStreamer{})
+	s.SetSessionStore(store)
+
+	rec := post(t, s, "/stream", `{"session_id":"s1","message":{"content":"one two three"}}`)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %s", ct)
+	}
+
+	events := readEvents(t, rec.Body.String())
+	if len(events) != 4 {
+		t.Fatalf("Expected 3 chunks and a done event, got %v", events)
+	}
+	for i, word := range []string{"one", "two", "three"} {
+		var chunk agenkit.StreamChunk
+		json.Unmarshal([]byte(events[i][1]), &chunk)
+		if events[i][0] != "chunk" || chunk.Delta != word+" " {
+			t.Errorf("Event %d: Expected chunk '%s ', got %v", i, word, events[i])
+		}
+	}
+
+	var done Response
+	json.Unmarshal([]byte(events[3][1]), &done)
+	if events[3][0] != "done" || done.Message.Content != "one two three " {
+		t.Errorf("Expected done event with assembled message, got %v", events[3])
+	}
+
+	saved, err := store.Load(context.Background(), "s1")
+	if err != nil || saved.Len() != 2 {
+		t.Errorf("Expected streamed exchange to be saved, got %v, %v", saved, err)
+	}
+}
+
+func TestServerStreamNonStreamingAgent(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "plain")
+	agent.Expect("", "whole reply")
+
+	events := readEvents(t, post(t, NewServer(agent), "/stream", `{"message":{"content":"x"}}`).Body.String())
+	if len(events) != 2 || events[0][0] != "chunk" || events[1][0] != "done" {
+		t.Fatalf("Expected one chunk and a done event, got %v", events)
+	}
+}
+
+func TestServerStreamError(t *testing.T) {
+	agent := testutil.NewMockAgent(t, "failing")
+	agent.Expect("", "").WithError(errors.New("boom"))
+
+	events := readEvents(t, post(t, NewServer(agent), "/stream", `{"message":{"content":"x"}}`).Body.String())
+	if len(events) != 1 || events[0][0] != "error" || !strings.Contains(events[0][1], "boom") {
+		t.Fatalf("Expected a single error event, got %v", events)
+	}
+}
+
+func TestServerHealth(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+	NewServer(&historyAgent{}).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Errorf("Expected healthy response, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+
+	// The in-flight request must finish even though shutdown starts mid-request
+	agent := testutil.NewMockAgent(t, "slow")
+	agent.Expect("", "finished").WithDelay(100 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- NewServer(agent).Serve(ctx, listener) }()
+
+	respCh := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Post("http://"+listener.Addr().String()+"/process", "application/json",
+			strings.NewReader(`{"message":{"content":"x"}}`))
+		if err != nil {
+			t.Errorf("Request failed: %v", err)
+		}
+		respCh <- resp
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	if err := <-served; err != nil {
+		t.Fatalf("Expected clean shutdown, got %v", err)
+	}
+	resp := <-respCh
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected in-flight request to complete, got %v", resp)
+	}
+	resp.Body.Close()
+}
+
+// recordingLifecycle records Start and Stop calls.
+type recordingLifecycle struct {
+	mu       sync.Mutex
+	calls    []string
+	startErr error
+}
+
+func (l *recordingLifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, "start")
+	return l.startErr
+}
+
+func (l *recordingLifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, "stop")
+	return nil
+}
+
+func TestServerStartsAndStopsLifecycle(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	lifecycle := &recordingLifecycle{}
+	srv := NewServer(&historyAgent{})
+	srv.SetLifecycle(lifecycle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := srv.Serve(ctx, listener); err != nil {
+		t.Fatalf("Expected clean shutdown, got %v", err)
+	}
+	if got := strings.Join(lifecycle.calls, " "); got != "start stop" {
+		t.Errorf("Expected 'start stop', got '%s'", got)
+	}
+
+	// A failed start means no serving and nothing to stop
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	errDown := errors.New("database down")
+	lifecycle = &recordingLifecycle{startErr: errDown}
+	srv.SetLifecycle(lifecycle)
+	if err := srv.Serve(context.Background(), listener); !errors.Is(err, errDown) {
+		t.Fatalf("Expected the start error, got %v", err)
+	}
+	if got := strings.Join(lifecycle.calls, " "); got != "start" {
+		t.Errorf("Expected 'start', got '%s'", got)
+	}
+}
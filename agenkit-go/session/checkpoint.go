@@ -0,0 +1,123 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ErrCheckpointNotFound is returned when a checkpoint does not exist.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// CheckpointID identifies a session checkpoint.
+type CheckpointID string
+
+// CheckpointInfo describes a session checkpoint.
+type CheckpointInfo struct {
+	ID        CheckpointID
+	Len       int
+	CreatedAt time.Time
+}
+
+// checkpoint is a snapshot of a session's history and state.
+//
+// messages shares its backing array with the history it was taken from.
+// Its capacity is capped at its length, and the live history only ever
+// appends past its own length, so no snapshot is overwritten.
+type checkpoint struct {
+	ID        CheckpointID           `json:"id"`
+	Messages  []*agenkit.Message     `json:"messages"`
+	State     map[string]interface{} `json:"state"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// Checkpoint snapshots the message history and state, so the conversation
+// can later be rolled back to this point with Restore.
+//
+// Snapshots are cheap: the history is shared with the session rather than
+// copied, and only the state map is copied (shallowly). Messages are shared
+// by pointer and must not be mutated after they are added. Checkpoints are
+// serialized with the session, so they survive a SessionStore round trip;
+// delete stale ones with DeleteCheckpoint to keep stored sessions small.
+func (s *Session) Checkpoint() CheckpointID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := &checkpoint{
+		ID:        CheckpointID(uuid.NewString()),
+		Messages:  s.messages[:len(s.messages):len(s.messages)],
+		State:     copyState(s.state),
+		CreatedAt: time.Now().UTC(),
+	}
+	s.checkpoints = append(s.checkpoints, cp)
+	return cp.ID
+}
+
+// Restore rolls the history and state back to a checkpoint. The checkpoint
+// is kept, so the same point can be restored again to try another
+// continuation, and checkpoints taken after it remain available.
+func (s *Session) Restore(id CheckpointID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findCheckpoint(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrCheckpointNotFound, id)
+	}
+	cp := s.checkpoints[i]
+	// The capped slice makes the next append copy instead of writing into
+	// an array other checkpoints share
+	s.messages = cp.Messages[:len(cp.Messages):len(cp.Messages)]
+	s.state = copyState(cp.State)
+	s.updatedAt = time.Now().UTC()
+	return nil
+}
+
+// Checkpoints lists the session's checkpoints, oldest first.
+func (s *Session) Checkpoints() []CheckpointInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]CheckpointInfo, len(s.checkpoints))
+	for i, cp := range s.checkpoints {
+		infos[i] = CheckpointInfo{ID: cp.ID, Len: len(cp.Messages), CreatedAt: cp.CreatedAt}
+	}
+	return infos
+}
+
+// DeleteCheckpoint removes a checkpoint.
+func (s *Session) DeleteCheckpoint(id CheckpointID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findCheckpoint(id)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrCheckpointNotFound, id)
+	}
+	s.checkpoints = append(s.checkpoints[:i:i], s.checkpoints[i+1:]...)
+	return nil
+}
+
+// findCheckpoint returns the index of a checkpoint, or -1. The caller must
+// hold the lock.
+func (s *Session) findCheckpoint(id CheckpointID) int {
+	for i, cp := range s.checkpoints {
+		if cp.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyState returns a shallow copy of a state map.
+func copyState(state map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(state))
+	for k, v := range state {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func contents(messages []*agenkit.Message) []string {
+	result := make([]string, len(messages))
+	for i, m := range messages {
+		result[i] = m.Content
+	}
+	return result
+}
+
+func TestSessionCheckpointRestore(t *testing.T) {
+	s := NewSession("abc")
+	s.AddMessage(agenkit.NewMessage("user", "hello"), agenkit.NewMessage("agent", "hi"))
+	s.Set("mood", "curious")
+	cp := s.Checkpoint()
+
+	s.AddMessage(agenkit.NewMessage("user", "first try"))
+	s.Set("mood", "bored")
+	if err := s.Restore(cp); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := contents(s.History()); len(got) != 2 || got[1] != "hi" {
+		t.Errorf("Expected the history rolled back to 2 messages, got %v", got)
+	}
+	if mood, _ := s.Get("mood"); mood != "curious" {
+		t.Errorf("Expected the state rolled back, got mood=%v", mood)
+	}
+
+	// A second branch from the same point leaves the first intact
+	s.AddMessage(agenkit.NewMessage("user", "second try"))
+	second := s.Checkpoint()
+	if err := s.Restore(cp); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	s.AddMessage(agenkit.NewMessage("user", "third try"))
+	if err := s.Restore(second); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := contents(s.History()); len(got) != 3 || got[2] != "second try" {
+		t.Errorf("Expected the second branch to be unaffected by later appends, got %v", got)
+	}
+}
+
+func TestSessionCheckpointsAreIsolatedFromAppends(t *testing.T) {
+	s := NewSession("abc")
+	s.AddMessage(agenkit.NewMessage("user", "one"))
+	before := s.Checkpoint()
+
+	// Grow the history past the checkpoint and branch several times; the
+	// shared backing array must never overwrite the snapshot
+	for _, branch := range []string{"a", "b", "c"} {
+		s.AddMessage(agenkit.NewMessage("user", branch), agenkit.NewMessage("agent", branch))
+		if err := s.Restore(before); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+	}
+	if got := contents(s.History()); len(got) != 1 || got[0] != "one" {
+		t.Errorf("Expected the checkpointed history, got %v", got)
+	}
+}
+
+func TestSessionCheckpointListAndDelete(t *testing.T) {
+	s := NewSession("abc")
+	first := s.Checkpoint()
+	s.AddMessage(agenkit.NewMessage("user", "hello"))
+	second := s.Checkpoint()
+
+	infos := s.Checkpoints()
+	if len(infos) != 2 || infos[0].ID != first || infos[1].ID != second || infos[1].Len != 1 {
+		t.Fatalf("Expected both checkpoints oldest first, got %+v", infos)
+	}
+
+	if err := s.DeleteCheckpoint(first); err != nil {
+		t.Fatalf("DeleteCheckpoint failed: %v", err)
+	}
+	if err := s.Restore(first); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("Expected ErrCheckpointNotFound, got %v", err)
+	}
+	if err := s.DeleteCheckpoint(first); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("Expected ErrCheckpointNotFound, got %v", err)
+	}
+	if infos := s.Checkpoints(); len(infos) != 1 || infos[0].ID != second {
+		t.Errorf("Expected only the second checkpoint, got %+v", infos)
+	}
+}
+
+func TestSessionCheckpointsPersist(t *testing.T) {
+	store := NewMemorySessionStore()
+	s := NewSession("abc")
+	s.AddMessage(agenkit.NewMessage("user", "hello"))
+	cp := s.Checkpoint()
+	s.AddMessage(agenkit.NewMessage("agent", "regenerate me"))
+
+	if err := store.Save(context.Background(), s); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := store.Load(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := loaded.Restore(cp); err != nil {
+		t.Fatalf("Restore after load failed: %v", err)
+	}
+	if got := contents(loaded.History()); len(got) != 1 || got[0] != "hello" {
+		t.Errorf("Expected the restored history, got %v", got)
+	}
+}
@@ -0,0 +1,18 @@
+package session
+
+import "context"
+
+// sessionKey is the context key for the active session.
+type sessionKey struct{}
+
+// WithSession returns a context carrying s, so agents handling a request
+// can read and extend the conversation history.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFromContext returns the context's session, or nil if none is set.
+func SessionFromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
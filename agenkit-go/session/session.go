@@ -0,0 +1,161 @@
+// Package session provides conversation sessions and pluggable persistence for them.
+package session
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// Session holds the message history and key/value state of a conversation.
+//
+// A Session is safe for concurrent use: all accessors take an internal lock,
+// and History and State return copies so callers cannot mutate shared state.
+type Session struct {
+	mu        sync.RWMutex
+	id        string
+	messages  []*agenkit.Message
+	state     map[string]interface{}
+	createdAt time.Time
+	updatedAt time.Time
+
+	checkpoints []*checkpoint
+}
+
+// NewSession creates an empty session with the given ID.
+func NewSession(id string) *Session {
+	now := time.Now().UTC()
+	return &Session{
+		id:        id,
+		messages:  make([]*agenkit.Message, 0),
+		state:     make(map[string]interface{}),
+		createdAt: now,
+		updatedAt: now,
+	}
+}
+
+// ID returns the session identifier.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// CreatedAt returns when the session was created.
+func (s *Session) CreatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.createdAt
+}
+
+// UpdatedAt returns when the session was last modified.
+func (s *Session) UpdatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updatedAt
+}
+
+// AddMessage appends messages to the history.
+func (s *Session) AddMessage(messages ...*agenkit.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, messages...)
+	s.updatedAt = time.Now().UTC()
+}
+
+// History returns a copy of the message history, oldest first.
+func (s *Session) History() []*agenkit.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history := make([]*agenkit.Message, len(s.messages))
+	copy(history, s.messages)
+	return history
+}
+
+// Len returns the number of messages in the history.
+func (s *Session) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.messages)
+}
+
+// Get returns a state value.
+func (s *Session) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.state[key]
+	return value, ok
+}
+
+// Set stores a state value.
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[key] = value
+	s.updatedAt = time.Now().UTC()
+}
+
+// Delete removes a state value.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.state, key)
+	s.updatedAt = time.Now().UTC()
+}
+
+// State returns a shallow copy of the key/value state.
+func (s *Session) State() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyState(s.state)
+}
+
+// sessionJSON is the serialized form of a Session.
+type sessionJSON struct {
+	ID        string                 `json:"id"`
+	Messages  []*agenkit.Message     `json:"messages"`
+	State     map[string]interface{} `json:"state"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+
+	Checkpoints []*checkpoint `json:"checkpoints,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *Session) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(sessionJSON{
+		ID:        s.id,
+		Messages:  s.messages,
+		State:     s.state,
+		CreatedAt: s.createdAt,
+		UpdatedAt: s.updatedAt,
+
+		Checkpoints: s.checkpoints,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Session) UnmarshalJSON(data []byte) error {
+	var decoded sessionJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id = decoded.ID
+	s.messages = decoded.Messages
+	if s.messages == nil {
+		s.messages = make([]*agenkit.Message, 0)
+	}
+	s.state = decoded.State
+	if s.state == nil {
+		s.state = make(map[string]interface{})
+	}
+	s.createdAt = decoded.CreatedAt
+	s.updatedAt = decoded.UpdatedAt
+	s.checkpoints = decoded.Checkpoints
+	return nil
+}
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+func TestSessionHistoryAndState(t *testing.T) {
+	s := NewSession("abc")
+	s.AddMessage(agenkit.NewMessage("user", "hello"), agenkit.NewMessage("agent", "hi"))
+	s.Set("topic", "greetings")
+
+	if s.Len() != 2 {
+		t.Fatalf("Expected 2 messages, got %d", s.Len())
+	}
+
+	history := s.History()
+	history[0] = agenkit.NewMessage("user", "mutated")
+	if s.History()[0].Content != "hello" {
+		t.Error("History should return a copy")
+	}
+
+	if v, ok := s.Get("topic"); !ok || v != "greetings" {
+		t.Errorf("Expected topic=greetings, got %v", v)
+	}
+	s.Delete("topic")
+	if _, ok := s.Get("topic"); ok {
+		t.Error("Expected topic to be deleted")
+	}
+}
+
+func TestSessionJSONRoundTrip(t *testing.T) {
+	s := NewSession("abc")
+	s.AddMessage(agenkit.NewMessage("user", "hello"))
+	s.Set("count", 3.0)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	restored := &Session{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if restored.ID() != "abc" || restored.Len() != 1 || restored.History()[0].Content != "hello" {
+		t.Errorf("Restored session mismatch: %+v", restored.History())
+	}
+	if v, _ := restored.Get("count"); v != 3.0 {
+		t.Errorf("Expected count=3, got %v", v)
+	}
+}
+
+func TestSessionConcurrentAccess(t *testing.T) {
+	s := NewSession("abc")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.AddMessage(agenkit.NewMessage("user", fmt.Sprint(i)))
+			s.Set(fmt.Sprint(i), i)
+			_, _ = json.Marshal(s)
+			_ = s.History()
+		}(i)
+	}
+	wg.Wait()
+
+	if s.Len() != 50 {
+		t.Errorf("Expected 50 messages, got %d", s.Len())
+	}
+	if len(s.State()) != 50 {
+		t.Errorf("Expected 50 state keys, got %d", len(s.State()))
+	}
+}
+
+func TestSessionContext(t *testing.T) {
+	if SessionFromContext(context.Background()) != nil {
+		t.Error("Expected no session in empty context")
+	}
+
+	s := NewSession("abc")
+	ctx := WithSession(context.Background(), s)
+	if SessionFromContext(ctx) != s {
+		t.Error("Expected session from context")
+	}
+}
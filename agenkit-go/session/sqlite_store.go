@@ -0,0 +1,168 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SQLiteSessionStoreConfig configures a SQLite-backed session store.
+type SQLiteSessionStoreConfig struct {
+	// TableName is the table that holds sessions.
+	// Default: "agenkit_sessions"
+	TableName string
+
+	// TTL is how long a session lives after its last save.
+	// Expired sessions are invisible to Load and removed by DeleteExpired.
+	// Default: 0 (sessions never expire)
+	TTL time.Duration
+}
+
+// SQLiteSessionStore persists sessions in a SQLite database via database/sql.
+//
+// The store does not import a driver; callers open the *sql.DB with the
+// driver of their choice (e.g., modernc.org/sqlite or mattn/go-sqlite3).
+// The sessions table is created on first use.
+type SQLiteSessionStore struct {
+	db     *sql.DB
+	config SQLiteSessionStoreConfig
+
+	migrateMu sync.Mutex
+	migrated  bool
+}
+
+// Verify that SQLiteSessionStore implements SessionStore interface.
+var _ SessionStore = (*SQLiteSessionStore)(nil)
+
+// NewSQLiteSessionStore creates a new SQLite session store.
+func NewSQLiteSessionStore(db *sql.DB, config SQLiteSessionStoreConfig) (*SQLiteSessionStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("sqlite session store requires a database")
+	}
+	if config.TableName == "" {
+		config.TableName = "agenkit_sessions"
+	}
+	if config.TTL < 0 {
+		return nil, fmt.Errorf("ttl must not be negative, got %v", config.TTL)
+	}
+	return &SQLiteSessionStore{
+		db:     db,
+		config: config,
+	}, nil
+}
+
+// migrate creates the sessions table if it does not exist yet.
+// A failed migration is retried on the next call.
+func (s *SQLiteSessionStore) migrate(ctx context.Context) error {
+	s.migrateMu.Lock()
+	defer s.migrateMu.Unlock()
+
+	if s.migrated {
+		return nil
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			data TEXT NOT NULL,
+			updated_at INTEGER NOT NULL,
+			expires_at INTEGER
+		)`, s.config.TableName),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_expires_at ON %s (expires_at)`,
+			s.config.TableName, s.config.TableName),
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate session table: %w", err)
+		}
+	}
+
+	s.migrated = true
+	return nil
+}
+
+// Load returns the session with the given ID.
+func (s *SQLiteSessionStore) Load(ctx context.Context, sessionID string) (*Session, error) {
+	if err := s.migrate(ctx); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		`SELECT data FROM %s WHERE id = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		s.config.TableName,
+	)
+
+	var data string
+	err := s.db.QueryRowContext(ctx, query, sessionID, time.Now().UnixNano()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+
+	session := &Session{}
+	if err := json.Unmarshal([]byte(data), session); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", sessionID, err)
+	}
+	return session, nil
+}
+
+// Save creates or replaces the stored session, refreshing its TTL.
+func (s *SQLiteSessionStore) Save(ctx context.Context, session *Session) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", session.ID(), err)
+	}
+
+	now := time.Now()
+	var expiresAt interface{}
+	if s.config.TTL > 0 {
+		expiresAt = now.Add(s.config.TTL).UnixNano()
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, data, updated_at, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data,
+			updated_at = excluded.updated_at, expires_at = excluded.expires_at`,
+		s.config.TableName,
+	)
+	if _, err := s.db.ExecContext(ctx, query, session.ID(), string(data), now.UnixNano(), expiresAt); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", session.ID(), err)
+	}
+	return nil
+}
+
+// Delete removes the session.
+func (s *SQLiteSessionStore) Delete(ctx context.Context, sessionID string) error {
+	if err := s.migrate(ctx); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.config.TableName)
+	if _, err := s.db.ExecContext(ctx, query, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// DeleteExpired removes all sessions whose TTL has elapsed and returns how many were removed.
+func (s *SQLiteSessionStore) DeleteExpired(ctx context.Context) (int64, error) {
+	if err := s.migrate(ctx); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= ?`, s.config.TableName)
+	result, err := s.db.ExecContext(ctx, query, time.Now().UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
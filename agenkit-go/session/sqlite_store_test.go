@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s/sessions.db?_pragma=busy_timeout(5000)", t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteSessionStore(t *testing.T) {
+	store, err := NewSQLiteSessionStore(openTestDB(t), SQLiteSessionStoreConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	testStoreContract(t, store)
+}
+
+func TestSQLiteSessionStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewSQLiteSessionStore(openTestDB(t), SQLiteSessionStoreConfig{TTL: 50 * time.Millisecond})
+
+	if err := store.Save(ctx, NewSession("short-lived")); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := store.Load(ctx, "short-lived"); err != nil {
+		t.Fatalf("Expected session before expiry, got %v", err)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+
+	if _, err := store.Load(ctx, "short-lived"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected expired session to be invisible, got %v", err)
+	}
+
+	removed, err := store.DeleteExpired(ctx)
+	if err != nil {
+		t.Fatalf("DeleteExpired failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 expired session removed, got %d", removed)
+	}
+}
+
+func TestSQLiteSessionStoreConcurrentSaves(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewSQLiteSessionStore(openTestDB(t), SQLiteSessionStoreConfig{})
+
+	s := NewSession("shared")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.AddMessage(agenkit.NewMessage("user", fmt.Sprint(i)))
+			if err := store.Save(ctx, s); err != nil {
+				t.Errorf("Save failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// A final save captures every message appended above
+	if err := store.Save(ctx, s); err != nil {
+		t.Fatalf("Final save failed: %v", err)
+	}
+	loaded, err := store.Load(ctx, "shared")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Len() != 20 {
+		t.Errorf("Expected 20 messages, got %d", loaded.Len())
+	}
+}
+
+func TestSQLiteSessionStoreValidation(t *testing.T) {
+	if _, err := NewSQLiteSessionStore(nil, SQLiteSessionStoreConfig{}); err == nil {
+		t.Error("Expected error without database")
+	}
+	if _, err := NewSQLiteSessionStore(&sql.DB{}, SQLiteSessionStoreConfig{TTL: -time.Second}); err == nil {
+		t.Error("Expected error for negative TTL")
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSessionNotFound is returned when a session does not exist (or has expired).
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore persists sessions between requests.
+type SessionStore interface {
+	// Load returns the session with the given ID, or ErrSessionNotFound.
+	Load(ctx context.Context, sessionID string) (*Session, error)
+
+	// Save creates or replaces the stored session.
+	Save(ctx context.Context, session *Session) error
+
+	// Delete removes the session. Deleting a missing session is not an error.
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// MemorySessionStore is an in-process SessionStore, useful for tests and single-instance deployments.
+//
+// Sessions are stored in serialized form, so a loaded session is independent
+// of the one that was saved, matching the semantics of persistent stores.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string][]byte
+}
+
+// Verify that MemorySessionStore implements SessionStore interface.
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+// NewMemorySessionStore creates a new in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string][]byte),
+	}
+}
+
+// Load returns the session with the given ID.
+func (m *MemorySessionStore) Load(ctx context.Context, sessionID string) (*Session, error) {
+	m.mu.RLock()
+	data, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", sessionID, err)
+	}
+	return session, nil
+}
+
+// Save stores the session.
+func (m *MemorySessionStore) Save(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", session.ID(), err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID()] = data
+	return nil
+}
+
+// Delete removes the session.
+func (m *MemorySessionStore) Delete(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// testStoreContract exercises behavior every SessionStore must provide.
+func testStoreContract(t *testing.T, store SessionStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Expected ErrSessionNotFound, got %v", err)
+	}
+
+	s := NewSession("abc")
+	s.AddMessage(agenkit.NewMessage("user", "hello"))
+	s.Set("user_id", "u-1")
+	if err := store.Save(ctx, s); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Load(ctx, "abc")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Len() != 1 || loaded.History()[0].Content != "hello" {
+		t.Errorf("Loaded history mismatch: %+v", loaded.History())
+	}
+	if v, _ := loaded.Get("user_id"); v != "u-1" {
+		t.Errorf("Expected user_id=u-1, got %v", v)
+	}
+
+	// Saving again replaces the stored session
+	loaded.AddMessage(agenkit.NewMessage("agent", "hi"))
+	if err := store.Save(ctx, loaded); err != nil {
+		t.Fatalf("Second save failed: %v", err)
+	}
+	reloaded, _ := store.Load(ctx, "abc")
+	if reloaded.Len() != 2 {
+		t.Errorf("Expected 2 messages after update, got %d", reloaded.Len())
+	}
+
+	if err := store.Delete(ctx, "abc"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Load(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "abc"); err != nil {
+		t.Errorf("Deleting a missing session should not fail, got %v", err)
+	}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	testStoreContract(t, NewMemorySessionStore())
+}
@@ -0,0 +1,259 @@
+package structured
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema document.
+type Schema map[string]any
+
+// SchemaFor derives a JSON Schema for T from its Go type and json tags.
+//
+// Struct fields are required unless tagged omitempty or declared as
+// pointers; pointer fields are marked "nullable" and also accept null. Types implementing json.Marshaler or encoding.TextMarshaler are
+// described as unconstrained (or as strings for TextMarshaler), since their
+// wire format cannot be derived by reflection.
+func SchemaFor[T any]() Schema {
+	return schemaForType(reflect.TypeOf((*T)(nil)).Elem(), map[reflect.Type]bool{})
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaForType builds the schema for t. seen guards against recursive types.
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string
+			return Schema{"type": "string"}
+		}
+		return Schema{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaForType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return Schema{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	default:
+		return Schema{}
+	}
+}
+
+// structSchema builds an object schema from a struct's exported fields.
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	properties := make(map[string]any)
+	var required []string
+
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, omitempty, skip := parseJSONTag(field)
+			if skip {
+				continue
+			}
+
+			// Untagged embedded structs are flattened, as encoding/json does
+			fieldType := field.Type
+			if field.Anonymous && name == "" {
+				if fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+				if fieldType.Kind() == reflect.Struct {
+					addFields(fieldType)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			fieldSchema := schemaForType(field.Type, seen)
+			if field.Type.Kind() == reflect.Pointer {
+				fieldSchema["nullable"] = true
+			} else if !omitempty {
+				required = append(required, name)
+			}
+			properties[name] = fieldSchema
+		}
+	}
+	addFields(t)
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// parseJSONTag returns the field's JSON name, whether it is omitempty, and
+// whether it is excluded from encoding.
+func parseJSONTag(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, false
+}
+
+// ValidationError reports a value that does not conform to the schema.
+type ValidationError struct {
+	// Path locates the offending value, e.g. "$.items[2].price".
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks a decoded JSON value against schema. All violations are
+// returned joined; use errors.As to inspect the first *ValidationError.
+func (s Schema) Validate(value any) error {
+	var errs []error
+	validate(value, s, "$", &errs)
+	return errors.Join(errs...)
+}
+
+// validate appends the violations of value against schema to errs.
+func validate(value any, schema Schema, path string, errs *[]error) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil && schema["nullable"] == true {
+		return
+	}
+
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "":
+		return
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("expected object, got %s", jsonKind(value))
+			return
+		}
+		if required, ok := schema["required"].([]string); ok {
+			for _, name := range required {
+				if _, present := obj[name]; !present {
+					*errs = append(*errs, &ValidationError{Path: path + "." + name, Message: "required field is missing"})
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(Schema)
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := properties[key].(Schema); ok {
+				validate(obj[key], sub, path+"."+key, errs)
+			} else if additional != nil {
+				validate(obj[key], additional, path+"."+key, errs)
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			fail("expected array, got %s", jsonKind(value))
+			return
+		}
+		items, _ := schema["items"].(Schema)
+		for i, item := range arr {
+			validate(item, items, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			fail("expected string, got %s", jsonKind(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean, got %s", jsonKind(value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			fail("expected number, got %s", jsonKind(value))
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok {
+			fail("expected integer, got %s", jsonKind(value))
+		} else if n != math.Trunc(n) {
+			fail("expected integer, got %v", n)
+		}
+	}
+}
+
+// jsonKind names the JSON type of a decoded value.
+func jsonKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
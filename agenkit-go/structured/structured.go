@@ -0,0 +1,215 @@
+// Package structured turns free-form agent output into typed values.
+//
+// StructuredAgent asks the wrapped agent to answer with JSON matching a
+// schema derived from a Go type, validates and decodes the reply, and
+// re-prompts the agent with the validation error when the reply is not
+// usable.
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// OutputMetadataKey is the response metadata key holding the decoded value.
+const OutputMetadataKey = "structured_output"
+
+// Validator may be implemented by output types to enforce constraints the
+// schema cannot express. A non-nil error triggers a repair attempt.
+type Validator interface {
+	Validate() error
+}
+
+// StructuredConfig configures a StructuredAgent.
+type StructuredConfig struct {
+	// MaxRepairs is the number of times the agent is re-prompted after an
+	// invalid reply.
+	// Default: 2
+	MaxRepairs int
+
+	// Instructions precede the schema in the prompt.
+	// Default: "Respond only with a JSON value matching this JSON Schema:"
+	Instructions string
+
+	// NativeFormat also requests the schema as the response format of the
+	// LLM agents called (see llm.WithResponseFormat), so providers with a
+	// JSON mode enforce it. It applies to every LLM call made for a
+	// message, so it suits a wrapped agent that is a single LLM agent.
+	// Default: false
+	NativeFormat bool
+}
+
+// StructuredAgent wraps an agent so its replies decode into T.
+type StructuredAgent[T any] struct {
+	agent  agenkit.Agent
+	config StructuredConfig
+	schema Schema
+	prompt string // rendered instructions and schema
+}
+
+// NewStructuredAgent creates a structured agent producing values of type T.
+func NewStructuredAgent[T any](agent agenkit.Agent, config StructuredConfig) (*StructuredAgent[T], error) {
+	if agent == nil {
+		return nil, fmt.Errorf("structured agent requires an agent")
+	}
+	if config.MaxRepairs <= 0 {
+		config.MaxRepairs = 2
+	}
+	if config.Instructions == "" {
+		config.Instructions = "Respond only with a JSON value matching this JSON Schema:"
+	}
+
+	schema := SchemaFor[T]()
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	return &StructuredAgent[T]{
+		agent:  agent,
+		config: config,
+		schema: schema,
+		prompt: config.Instructions + "\n" + string(schemaJSON),
+	}, nil
+}
+
+// Name returns the name of the wrapped agent.
+func (s *StructuredAgent[T]) Name() string {
+	return s.agent.Name()
+}
+
+// Capabilities returns the wrapped agent's capabilities plus "structured_output".
+func (s *StructuredAgent[T]) Capabilities() []string {
+	return append(s.agent.Capabilities(), "structured_output")
+}
+
+// Schema returns the JSON Schema replies are validated against.
+func (s *StructuredAgent[T]) Schema() Schema {
+	return s.schema
+}
+
+// Process returns the wrapped agent's raw reply with the decoded value
+// stored under OutputMetadataKey.
+func (s *StructuredAgent[T]) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	_, raw, err := s.ProcessTyped(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// ProcessTyped prompts the agent and returns the decoded value together
+// with the raw reply it was decoded from.
+func (s *StructuredAgent[T]) ProcessTyped(ctx context.Context, message *agenkit.Message) (T, *agenkit.Message, error) {
+	var zero T
+
+	if s.config.NativeFormat {
+		ctx = llm.WithResponseFormat(ctx, llm.FormatJSONSchema(s.schema))
+	}
+	prompt := s.buildPrompt(message)
+	attempts := s.config.MaxRepairs + 1
+	var lastErr error
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		raw, err := s.agent.Process(ctx, prompt)
+		if err != nil {
+			return zero, nil, fmt.Errorf("structured output attempt %d: %w", attempt, err)
+		}
+
+		value, err := s.decode(raw.Content)
+		if err == nil {
+			if raw.Metadata == nil {
+				raw.Metadata = make(map[string]interface{})
+			}
+			raw.Metadata[OutputMetadataKey] = value
+			raw.Metadata["structured_attempts"] = attempt
+			return value, raw, nil
+		}
+
+		lastErr = err
+		prompt = s.buildRepairPrompt(message, raw.Content, err)
+	}
+
+	return zero, nil, fmt.Errorf("structured output invalid after %d attempts: %w", attempts, lastErr)
+}
+
+// decode extracts, validates, and unmarshals a reply.
+func (s *StructuredAgent[T]) decode(content string) (T, error) {
+	var value T
+
+	data := []byte(ExtractJSON(content))
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return value, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := s.schema.Validate(generic); err != nil {
+		return value, err
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, fmt.Errorf("invalid JSON: %w", err)
+	}
+	// The pointer's method set covers both value and pointer receivers
+	if v, ok := any(&value).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return value, err
+		}
+	}
+	return value, nil
+}
+
+// buildPrompt appends the schema instructions to the user's message.
+func (s *StructuredAgent[T]) buildPrompt(message *agenkit.Message) *agenkit.Message {
+	prompt := agenkit.NewMessage(message.Role, message.Content+"\n\n"+s.prompt)
+	for k, v := range message.Metadata {
+		prompt.Metadata[k] = v
+	}
+	return prompt
+}
+
+// buildRepairPrompt asks the agent to correct an invalid reply.
+func (s *StructuredAgent[T]) buildRepairPrompt(message *agenkit.Message, reply string, err error) *agenkit.Message {
+	var sb strings.Builder
+	sb.WriteString(message.Content)
+	sb.WriteString("\n\n")
+	sb.WriteString(s.prompt)
+	sb.WriteString("\n\nYour previous reply was:\n")
+	sb.WriteString(reply)
+	sb.WriteString("\n\nIt was rejected because:\n")
+	sb.WriteString(err.Error())
+	sb.WriteString("\n\nReply again with only the corrected JSON.")
+
+	prompt := agenkit.NewMessage(message.Role, sb.String())
+	for k, v := range message.Metadata {
+		prompt.Metadata[k] = v
+	}
+	return prompt
+}
+
+// ExtractJSON returns the JSON payload of a model reply, stripping a
+// surrounding markdown code fence (```json ... ```) if present.
+func ExtractJSON(content string) string {
+	trimmed := strings.TrimSpace(content)
+
+	start := strings.Index(trimmed, "```")
+	if start < 0 {
+		return trimmed
+	}
+	body := trimmed[start+3:]
+	// Drop the info string (e.g. "json") on the opening fence line
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	} else {
+		return trimmed
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
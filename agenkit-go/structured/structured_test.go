@@ -0,0 +1,206 @@
+package structured
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+	"github.com/agenkit/agenkit-go/testutil"
+)
+
+type lineItem struct {
+	SKU      string  `json:"sku"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+}
+
+type order struct {
+	Customer string     `json:"customer"`
+	Items    []lineItem `json:"items"`
+	Note     *string    `json:"note"`
+	Tags     []string   `json:"tags,omitempty"`
+}
+
+func (o *order) Validate() error {
+	if len(o.Items) == 0 {
+		return errors.New("order must have at least one item")
+	}
+	return nil
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema := SchemaFor[order]()
+
+	if schema["type"] != "object" {
+		t.Fatalf("Expected object schema, got %v", schema["type"])
+	}
+	required := strings.Join(schema["required"].([]string), ",")
+	if required != "customer,items" {
+		t.Errorf("Expected required customer,items, got %s", required)
+	}
+
+	properties := schema["properties"].(map[string]any)
+	items := properties["items"].(Schema)["items"].(Schema)
+	if items["properties"].(map[string]any)["quantity"].(Schema)["type"] != "integer" {
+		t.Errorf("Expected quantity to be an integer, got %v", items)
+	}
+	if properties["note"].(Schema)["nullable"] != true {
+		t.Errorf("Expected pointer field to be nullable")
+	}
+}
+
+func TestSchemaValidatePaths(t *testing.T) {
+	schema := SchemaFor[order]()
+	value := map[string]any{
+		"items": []any{
+			map[string]any{"sku": "a", "price": 1.0, "quantity": 1.0},
+			map[string]any{"sku": "b", "price": "free", "quantity": 1.5},
+		},
+	}
+
+	err := schema.Validate(value)
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %T", err)
+	}
+	for _, want := range []string{
+		"$.customer: required field is missing",
+		"$.items[1].price: expected number, got string",
+		"$.items[1].quantity: expected integer, got 1.5",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a":1}`:                          `{"a":1}`,
+		"```json\n{\"a\":1}\n```":          `{"a":1}`,
+		"Here you go:\n```\n[1,2]\n```\n!": `[1,2]`,
+		"  {\"a\":1}  \n":                  `{"a":1}`,
+	}
+	for input, want := range tests {
+		if got := ExtractJSON(input); got != want {
+			t.Errorf("ExtractJSON(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestStructuredAgentParsesFencedJSON(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "```json\n{\"customer\": \"ada\", \"items\": [{\"sku\": \"x\", \"price\": 2.5, \"quantity\": 2}]}\n```")
+
+	agent, err := NewStructuredAgent[order](model, StructuredConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create structured agent: %v", err)
+	}
+
+	value, raw, err := agent.ProcessTyped(context.Background(), agenkit.NewMessage("user", "Order two x for ada"))
+	if err != nil {
+		t.Fatalf("ProcessTyped failed: %v", err)
+	}
+	if value.Customer != "ada" || len(value.Items) != 1 || value.Items[0].Quantity != 2 {
+		t.Errorf("Unexpected value: %+v", value)
+	}
+	if raw.Metadata[OutputMetadataKey].(order).Customer != "ada" {
+		t.Errorf("Expected decoded value in metadata")
+	}
+
+	prompt := model.Calls()[0].Content
+	if !strings.HasPrefix(prompt, "Order two x for ada") || !strings.Contains(prompt, `"required"`) {
+		t.Errorf("Expected schema instructions in prompt, got:\n%s", prompt)
+	}
+}
+
+func TestStructuredAgentRepairs(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", "Sure! The customer is ada.")
+	model.Expect("", `{"customer": "ada", "items": []}`)
+	model.Expect("", `{"customer": "ada", "items": [{"sku": "x", "price": 1, "quantity": 1}]}`)
+
+	agent, _ := NewStructuredAgent[order](model, StructuredConfig{MaxRepairs: 2})
+
+	value, raw, err := agent.ProcessTyped(context.Background(), agenkit.NewMessage("user", "order"))
+	if err != nil {
+		t.Fatalf("ProcessTyped failed: %v", err)
+	}
+	if value.Items[0].SKU != "x" {
+		t.Errorf("Unexpected value: %+v", value)
+	}
+	if raw.Metadata["structured_attempts"] != 3 {
+		t.Errorf("Expected 3 attempts, got %v", raw.Metadata["structured_attempts"])
+	}
+
+	calls := model.Calls()
+	if !strings.Contains(calls[1].Content, "invalid JSON") {
+		t.Errorf("Expected parse error in first repair prompt, got:\n%s", calls[1].Content)
+	}
+	if !strings.Contains(calls[2].Content, "order must have at least one item") {
+		t.Errorf("Expected Validate error in second repair prompt, got:\n%s", calls[2].Content)
+	}
+}
+
+func TestStructuredAgentGivesUp(t *testing.T) {
+	model := testutil.NewMockAgent(t, "model")
+	model.Expect("", `{"items": []}`)
+	model.Expect("", `{"items": []}`)
+
+	agent, _ := NewStructuredAgent[order](model, StructuredConfig{MaxRepairs: 1})
+
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "order"))
+	if err == nil {
+		t.Fatal("Expected error after exhausting repairs")
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Path != "$.customer" {
+		t.Errorf("Expected validation error at $.customer, got %v", err)
+	}
+	model.AssertExpectationsMet()
+}
+
+// formatProvider replies with a fixed order and records the formats
+// requested.
+type formatProvider struct {
+	formats []llm.ResponseFormat
+}
+
+func (p *formatProvider) Model() string                                 { return "json-model" }
+func (p *formatProvider) SupportsFormat(format llm.ResponseFormat) bool { return true }
+func (p *formatProvider) SupportsForceTool() bool                       { return false }
+
+func (p *formatProvider) Complete(ctx context.Context, request *llm.Request) (*llm.Response, error) {
+	p.formats = append(p.formats, request.ResponseFormat)
+	reply := `{"customer": "acme", "items": [{"sku": "a", "price": 1, "quantity": 2}]}`
+	return &llm.Response{Message: agenkit.NewMessage("agent", reply)}, nil
+}
+
+func TestStructuredAgentNativeFormat(t *testing.T) {
+	provider := &formatProvider{}
+	agent, err := NewStructuredAgent[order](llm.NewAgent("orders", provider, llm.AgentConfig{}), StructuredConfig{NativeFormat: true})
+	if err != nil {
+		t.Fatalf("NewStructuredAgent failed: %v", err)
+	}
+
+	value, _, err := agent.ProcessTyped(context.Background(), agenkit.NewMessage("user", "Order two a"))
+	if err != nil {
+		t.Fatalf("ProcessTyped failed: %v", err)
+	}
+	if value.Customer != "acme" {
+		t.Errorf("Expected customer 'acme', got '%s'", value.Customer)
+	}
+	if len(provider.formats) != 1 || provider.formats[0].Kind != llm.FormatKindJSONSchema {
+		t.Fatalf("Expected the schema as response format, got %+v", provider.formats)
+	}
+	if provider.formats[0].Schema["type"] != "object" {
+		t.Errorf("Expected the order schema, got %v", provider.formats[0].Schema)
+	}
+}
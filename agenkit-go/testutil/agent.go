@@ -0,0 +1,88 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// MockAgent is an agent that replays scripted responses and records the
+// messages it receives.
+//
+// Example:
+//
+//	agent := testutil.NewMockAgent(t, "summarizer")
+//	agent.Expect("long text", "short text")
+//	agent.Expect("", "").WithError(errors.New("rate limited"))
+type MockAgent struct {
+	name         string
+	capabilities []string
+	script       *script
+
+	mu    sync.Mutex
+	calls []*agenkit.Message
+}
+
+// Verify that MockAgent implements Agent interface.
+var _ agenkit.Agent = (*MockAgent)(nil)
+
+// NewMockAgent creates a mock agent that reports unexpected calls to t.
+func NewMockAgent(t testing.TB, name string, capabilities ...string) *MockAgent {
+	return &MockAgent{
+		name:         name,
+		capabilities: capabilities,
+		script:       &script{t: t, owner: "mock agent " + name},
+	}
+}
+
+// Expect queues a response. The next call must have content equal to input,
+// or any content if input is empty, and responds with output.
+func (m *MockAgent) Expect(input, output string) *Expectation {
+	return m.script.expect(input, output)
+}
+
+// Name returns the agent's name.
+func (m *MockAgent) Name() string {
+	return m.name
+}
+
+// Capabilities returns the capabilities given at construction.
+func (m *MockAgent) Capabilities() []string {
+	return m.capabilities
+}
+
+// Process records the message and replays the next expectation.
+func (m *MockAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, message)
+	m.mu.Unlock()
+
+	e, err := m.script.next(message.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.wait(ctx); err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return agenkit.NewMessage("agent", e.output), nil
+}
+
+// Calls returns the messages received so far, in order.
+func (m *MockAgent) Calls() []*agenkit.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*agenkit.Message(nil), m.calls...)
+}
+
+// AssertExpectationsMet fails the test if any queued expectation was not consumed.
+func (m *MockAgent) AssertExpectationsMet() {
+	m.script.t.Helper()
+	if n := m.script.remaining(); n > 0 {
+		m.script.t.Errorf("%s: %d expected call(s) not received", m.script.owner, n)
+	}
+}
@@ -0,0 +1,90 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// MockProvider is an llm.Provider that replays a scripted sequence of
+// completions. Prompts are matched against the content of the request's
+// last message.
+type MockProvider struct {
+	model  string
+	script *script
+
+	mu       sync.Mutex
+	requests []*llm.Request
+}
+
+// Verify that MockProvider implements Provider interface.
+var _ llm.Provider = (*MockProvider)(nil)
+
+// NewMockProvider creates a mock provider that reports unexpected prompts to t.
+func NewMockProvider(t testing.TB, model string) *MockProvider {
+	return &MockProvider{
+		model:  model,
+		script: &script{t: t, owner: "mock provider " + model},
+	}
+}
+
+// Expect queues a completion. The next request's last message must equal
+// prompt, or be anything if prompt is empty.
+func (p *MockProvider) Expect(prompt, completion string) *Expectation {
+	return p.script.expect(prompt, completion)
+}
+
+// Model returns the model name given at construction.
+func (p *MockProvider) Model() string {
+	return p.model
+}
+
+// Complete records the request and replays the next expectation.
+// Usage is estimated from the prompt and completion lengths.
+func (p *MockProvider) Complete(ctx context.Context, request *llm.Request) (*llm.Response, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, request)
+	p.mu.Unlock()
+
+	prompt := ""
+	if n := len(request.Messages); n > 0 {
+		prompt = request.Messages[n-1].Content
+	}
+
+	e, err := p.script.next(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.wait(ctx); err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &llm.Response{
+		Message: agenkit.NewMessage("agent", e.output),
+		Usage: llm.Usage{
+			InputTokens:  llm.EstimateRequestTokens(request),
+			OutputTokens: llm.EstimateTokens(e.output),
+		},
+		Model: p.model,
+	}, nil
+}
+
+// Requests returns the requests received so far, in order.
+func (p *MockProvider) Requests() []*llm.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*llm.Request(nil), p.requests...)
+}
+
+// AssertExpectationsMet fails the test if any queued completion was not consumed.
+func (p *MockProvider) AssertExpectationsMet() {
+	p.script.t.Helper()
+	if n := p.script.remaining(); n > 0 {
+		p.script.t.Errorf("%s: %d expected request(s) not received", p.script.owner, n)
+	}
+}
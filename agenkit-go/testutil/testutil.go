@@ -0,0 +1,98 @@
+// Package testutil provides deterministic test doubles for agents and LLM
+// providers, so patterns can be unit tested without network access.
+//
+// Both MockAgent and MockProvider replay a queue of expectations in order.
+// Each expectation can match its input, return an error, or add latency to
+// exercise timeout and retry paths. Calls that match no expectation fail the
+// test via the testing.TB passed at construction.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Expectation is a single scripted call. Configure it with the chaining
+// methods returned from Expect.
+type Expectation struct {
+	input  string
+	output string
+	err    error
+	delay  time.Duration
+}
+
+// WithError makes the call fail with err instead of returning the output.
+func (e *Expectation) WithError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// WithDelay makes the call wait for d before responding. The wait honors
+// context cancellation.
+func (e *Expectation) WithDelay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// script is an ordered queue of expectations shared by the mocks.
+type script struct {
+	t            testing.TB
+	owner        string
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// expect queues a new expectation.
+func (s *script) expect(input, output string) *Expectation {
+	e := &Expectation{input: input, output: output}
+	s.mu.Lock()
+	s.expectations = append(s.expectations, e)
+	s.mu.Unlock()
+	return e
+}
+
+// next pops the expectation for input, failing the test if none matches.
+func (s *script) next(input string) (*Expectation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.expectations) == 0 {
+		s.t.Helper()
+		s.t.Errorf("%s: unexpected call with input %q", s.owner, input)
+		return nil, fmt.Errorf("%s: unexpected call with input %q", s.owner, input)
+	}
+
+	e := s.expectations[0]
+	if e.input != "" && e.input != input {
+		s.t.Helper()
+		s.t.Errorf("%s: expected input %q, got %q", s.owner, e.input, input)
+		return nil, fmt.Errorf("%s: expected input %q, got %q", s.owner, e.input, input)
+	}
+	s.expectations = s.expectations[1:]
+	return e, nil
+}
+
+// remaining returns the number of unconsumed expectations.
+func (s *script) remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.expectations)
+}
+
+// wait sleeps for the expectation's delay, honoring ctx.
+func (e *Expectation) wait(ctx context.Context) error {
+	if e.delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(e.delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
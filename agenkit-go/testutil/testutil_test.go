@@ -0,0 +1,135 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+	"github.com/agenkit/agenkit-go/composition"
+	"github.com/agenkit/agenkit-go/llm"
+)
+
+// recordingTB captures failures instead of failing the enclosing test.
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestMockAgentSequential(t *testing.T) {
+	first := NewMockAgent(t, "first")
+	second := NewMockAgent(t, "second")
+	third := NewMockAgent(t, "third")
+	first.Expect("input", "a")
+	second.Expect("a", "b")
+	third.Expect("b", "c")
+
+	seq, err := composition.NewSequentialAgent("pipeline", first, second, third)
+	if err != nil {
+		t.Fatalf("Failed to create sequential agent: %v", err)
+	}
+
+	result, err := seq.Process(context.Background(), agenkit.NewMessage("user", "input"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "c" {
+		t.Errorf("Expected 'c', got '%s'", result.Content)
+	}
+
+	for _, m := range []*MockAgent{first, second, third} {
+		m.AssertExpectationsMet()
+		if len(m.Calls()) != 1 {
+			t.Errorf("Expected 1 call to %s, got %d", m.Name(), len(m.Calls()))
+		}
+	}
+}
+
+func TestMockAgentError(t *testing.T) {
+	agent := NewMockAgent(t, "flaky")
+	agent.Expect("", "").WithError(errors.New("transient"))
+	agent.Expect("", "ok")
+
+	_, err := agent.Process(context.Background(), agenkit.NewMessage("user", "x"))
+	if err == nil || err.Error() != "transient" {
+		t.Errorf("Expected scripted error, got %v", err)
+	}
+	result, err := agent.Process(context.Background(), agenkit.NewMessage("user", "x"))
+	if err != nil || result.Content != "ok" {
+		t.Errorf("Expected 'ok' on second call, got %v, %v", result, err)
+	}
+}
+
+func TestMockAgentDelayHonorsContext(t *testing.T) {
+	agent := NewMockAgent(t, "slow")
+	agent.Expect("", "late").WithDelay(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := agent.Process(ctx, agenkit.NewMessage("user", "x"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestMockAgentUnexpectedCalls(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	agent := NewMockAgent(tb, "strict")
+	agent.Expect("hello", "hi")
+
+	if _, err := agent.Process(context.Background(), agenkit.NewMessage("user", "bye")); err == nil {
+		t.Error("Expected error for mismatched input")
+	}
+	agent.AssertExpectationsMet()
+
+	if len(tb.failures) != 2 {
+		t.Errorf("Expected mismatch and unmet expectation failures, got %v", tb.failures)
+	}
+}
+
+func TestMockProviderScript(t *testing.T) {
+	provider := NewMockProvider(t, "mock-model")
+	provider.Expect("what is 2+2?", "4")
+
+	agent := llm.NewAgent("math", provider, llm.AgentConfig{SystemPrompt: "answer briefly"})
+	result, err := agent.Process(context.Background(), agenkit.NewMessage("user", "what is 2+2?"))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if result.Content != "4" {
+		t.Errorf("Expected '4', got '%s'", result.Content)
+	}
+	if result.Metadata["model"] != "mock-model" {
+		t.Errorf("Expected model metadata, got %v", result.Metadata["model"])
+	}
+
+	requests := provider.Requests()
+	if len(requests) != 1 || len(requests[0].Messages) != 2 {
+		t.Errorf("Expected one request with system prompt, got %+v", requests)
+	}
+	provider.AssertExpectationsMet()
+}
+
+func TestMockProviderUnexpectedPrompt(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	provider := NewMockProvider(tb, "mock-model")
+
+	_, err := provider.Complete(context.Background(), &llm.Request{
+		Messages: []*agenkit.Message{agenkit.NewMessage("user", "surprise")},
+	})
+	if err == nil {
+		t.Error("Expected error for unexpected prompt")
+	}
+	if len(tb.failures) != 1 {
+		t.Errorf("Expected 1 test failure, got %v", tb.failures)
+	}
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// agentTool is a Tool backed by an agent; see AgentAsTool.
+type agentTool struct {
+	name        string
+	description string
+	agent       agenkit.Agent
+	schema      map[string]any
+}
+
+// Verify that agentTool implements Tool interface.
+var _ agenkit.Tool = (*agentTool)(nil)
+
+// AgentAsTool creates a tool that delegates to agent, so a model can hand
+// subtasks to it through ordinary tool calls. schema is the JSON Schema of
+// the tool's parameters; a nil schema accepts a single required string
+// parameter, "input".
+//
+// When called, the parameters become a user message: if there is exactly
+// one parameter and it is a string, that string is the content, otherwise
+// the content is the parameters encoded as JSON. Either way the message's
+// "tool_arguments" metadata holds the parameters. The agent's response
+// content is the result data, and its metadata, plus "agent" naming the
+// agent, is the result metadata. An error from the agent is returned as is.
+//
+// The agent runs with the caller's context, so token budgets, cost
+// tracking, request metadata, event sinks and traces cover its work as part
+// of the parent run.
+func AgentAsTool(name, description string, agent agenkit.Agent, schema map[string]any) agenkit.Tool {
+	if schema == nil {
+		schema = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"input": map[string]any{
+					"type":        "string",
+					"description": "The task or question for " + agent.Name(),
+				},
+			},
+			"required": []any{"input"},
+		}
+	}
+	return &agentTool{name: name, description: description, agent: agent, schema: schema}
+}
+
+// Name returns the tool's name.
+func (t *agentTool) Name() string {
+	return t.name
+}
+
+// Description returns the tool's description.
+func (t *agentTool) Description() string {
+	return t.description
+}
+
+// InputSchema returns the JSON Schema of the tool's parameters.
+func (t *agentTool) InputSchema() map[string]any {
+	return t.schema
+}
+
+// Execute runs the agent on a message built from params.
+func (t *agentTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	content, err := agentToolContent(params)
+	if err != nil {
+		return agenkit.NewToolError(fmt.Sprintf("invalid arguments: %v", err)), nil
+	}
+	message := agenkit.NewMessage("user", content).WithMetadata("tool_arguments", params)
+
+	response, err := agenkit.ProcessWithSpan(ctx, t.agent, message)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("agent '%s' returned no message", t.agent.Name())
+	}
+
+	result := agenkit.NewToolResult(response.Content)
+	for k, v := range response.Metadata {
+		result.Metadata[k] = v
+	}
+	result.Metadata["agent"] = t.agent.Name()
+	return result, nil
+}
+
+// agentToolContent renders params as message content.
+func agentToolContent(params map[string]interface{}) (string, error) {
+	if len(params) == 1 {
+		for _, v := range params {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+		}
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// workerAgent answers with its input and the request metadata it saw.
+type workerAgent struct {
+	inputs []*agenkit.Message
+	err    error
+}
+
+func (w *workerAgent) Name() string           { return "worker" }
+func (w *workerAgent) Capabilities() []string { return nil }
+
+func (w *workerAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
+	w.inputs = append(w.inputs, message)
+	if w.err != nil {
+		return nil, w.err
+	}
+	return agenkit.NewMessage("agent", "done: "+message.Content).
+		WithMetadata("request_id", agenkit.MetadataFrom(ctx)["request_id"]), nil
+}
+
+func TestAgentAsToolDelegates(t *testing.T) {
+	worker := &workerAgent{}
+	tool := AgentAsTool("delegate", "Hands work to the worker", worker, nil)
+	if requiredParam(tool) != "input" {
+		t.Errorf("Expected the default schema to require input")
+	}
+
+	ctx := agenkit.WithMetadata(context.Background(), map[string]string{"request_id": "r-1"})
+	result, err := NewExecutor(ExecutorConfig{}).Execute(ctx, tool, map[string]interface{}{"input": "summarize"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Data != "done: summarize" {
+		t.Errorf("Expected the worker's answer, got %v", result.Data)
+	}
+	if result.Metadata["agent"] != "worker" || result.Metadata["request_id"] != "r-1" {
+		t.Errorf("Expected the worker's metadata and the caller's context, got %v", result.Metadata)
+	}
+
+	structured := AgentAsTool("delegate", "", worker, map[string]any{"type": "object"})
+	if _, err := structured.Execute(ctx, map[string]interface{}{"city": "Paris", "days": 3}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := worker.inputs[1].Content; got != `{"city":"Paris","days":3}` {
+		t.Errorf("Expected JSON-encoded arguments, got '%s'", got)
+	}
+	if args, _ := worker.inputs[1].Metadata["tool_arguments"].(map[string]interface{}); args["city"] != "Paris" {
+		t.Errorf("Expected the arguments in metadata, got %v", worker.inputs[1].Metadata)
+	}
+}
+
+func TestAgentAsToolReturnsAgentErrors(t *testing.T) {
+	failure := errors.New("worker unavailable")
+	tool := AgentAsTool("delegate", "", &workerAgent{err: failure}, nil)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"input": "x"}); !errors.Is(err, failure) {
+		t.Errorf("Expected the worker's error, got %v", err)
+	}
+}
+
+// requiredParam returns the first required parameter of tool's schema.
+func requiredParam(tool agenkit.Tool) string {
+	schema := tool.(interface{ InputSchema() map[string]any }).InputSchema()
+	required, _ := schema["required"].([]any)
+	if len(required) == 0 {
+		return ""
+	}
+	name, _ := required[0].(string)
+	return name
+}
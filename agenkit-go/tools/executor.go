@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ToolTimeoutError reports a tool call that exceeded its timeout.
+type ToolTimeoutError struct {
+	ToolName string
+	Timeout  time.Duration
+}
+
+// Error implements the error interface.
+func (e *ToolTimeoutError) Error() string {
+	return fmt.Sprintf("tool '%s' timed out after %v", e.ToolName, e.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (e *ToolTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// ExecutorConfig configures an Executor.
+type ExecutorConfig struct {
+	// Timeout bounds each tool call.
+	// Default: 0 (no timeout)
+	Timeout time.Duration
+
+	// ToolTimeouts overrides Timeout for individual tools, keyed by name.
+	ToolTimeouts map[string]time.Duration
+
+	// MaxConcurrentTools caps the number of tool calls running at once
+	// across everything sharing the executor.
+	// Default: 0 (unlimited)
+	MaxConcurrentTools int
+
+	// SkipValidation disables checking arguments against tool schemas
+	// (see ValidateArguments).
+	// Default: false (arguments are validated)
+	SkipValidation bool
+
+	// FatalArgumentErrors lists tools, by name, whose invalid arguments
+	// fail the call with an *ArgumentError instead of returning a failed
+	// ToolResult, for tools where letting the model retry is pointless.
+	FatalArgumentErrors map[string]bool
+
+	// Memoize answers a call repeating an earlier successful one, to the
+	// same tool with equal arguments, with the earlier result instead of
+	// running the tool again. Only calls made with contexts carrying the
+	// same memo (see WithMemo) share results, so nothing leaks across runs.
+	// Default: false
+	Memoize bool
+
+	// NoMemoize lists tools, by name, that always run even when Memoize is
+	// set, for tools with side effects where every call matters.
+	NoMemoize map[string]bool
+}
+
+// Executor runs tool calls with timeouts and a shared concurrency limit.
+//
+// Arguments are checked against the tool's schema before it runs. Invalid
+// arguments return a failed ToolResult whose Error lists the violations and
+// whose "error" metadata holds the *ArgumentError, so the model can correct
+// the call; tools in FatalArgumentErrors return the *ArgumentError instead.
+// A call that exceeds its timeout likewise returns a failed ToolResult
+// whose Error describes the timeout and whose "error" metadata holds the
+// *ToolTimeoutError, so a reasoning loop can feed it back to the model as an
+// observation instead of aborting. A call waiting for a concurrency slot
+// gives up with the context's error if the context is done first.
+//
+// A slot is held until the tool actually returns, even after a timeout, so
+// a tool that ignores cancellation keeps counting against the limit while
+// it is still talking to its downstream service.
+//
+// With Memoize set, a repeated call within a run returns a copy of the
+// earlier result with "memoized" set in its metadata; the tool does not
+// run and no events are emitted. Failed calls are not memoized.
+type Executor struct {
+	config ExecutorConfig
+	slots  chan struct{}
+}
+
+// NewExecutor creates a tool executor.
+func NewExecutor(config ExecutorConfig) *Executor {
+	e := &Executor{config: config}
+	if config.MaxConcurrentTools > 0 {
+		e.slots = make(chan struct{}, config.MaxConcurrentTools)
+	}
+	return e
+}
+
+// Execute runs tool with params. Errors returned by the tool are wrapped in
+// an *agenkit.ToolError. If the context has an EventSink or a Trace, the
+// call is reported with EventToolCalled and EventToolReturned events.
+func (e *Executor) Execute(ctx context.Context, tool agenkit.Tool, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	if e.config.Memoize && !e.config.NoMemoize[tool.Name()] {
+		if m := memoFromContext(ctx); m != nil {
+			if key, ok := memoKey(tool.Name(), params); ok {
+				if result, found := m.get(key); found {
+					return result, nil
+				}
+				result, err := e.run(ctx, tool, params)
+				if err == nil && result != nil && result.Success {
+					m.put(key, result)
+				}
+				return result, err
+			}
+		}
+	}
+	return e.run(ctx, tool, params)
+}
+
+// run executes the call, reporting it to the context's EventSink and Trace.
+func (e *Executor) run(ctx context.Context, tool agenkit.Tool, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	if agenkit.EventSinkFromContext(ctx) == nil && agenkit.TraceFromContext(ctx) == nil {
+		return e.execute(ctx, tool, params)
+	}
+
+	agenkit.Emit(ctx, agenkit.EventToolCalled, "", map[string]interface{}{
+		"tool":       tool.Name(),
+		"parameters": params,
+	})
+	start := time.Now()
+	result, err := e.execute(ctx, tool, params)
+
+	payload := map[string]interface{}{
+		"tool":     tool.Name(),
+		"success":  err == nil && result != nil && result.Success,
+		"duration": time.Since(start),
+	}
+	if err != nil {
+		payload["error"] = err.Error()
+	} else if result != nil && !result.Success {
+		payload["error"] = result.Error
+	}
+	agenkit.Emit(ctx, agenkit.EventToolReturned, "", payload)
+	return result, err
+}
+
+// execute validates params and runs tool with them, applying the
+// concurrency limit and timeout.
+func (e *Executor) execute(ctx context.Context, tool agenkit.Tool, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	if !e.config.SkipValidation {
+		if err := ValidateArguments(tool, params); err != nil {
+			if e.config.FatalArgumentErrors[tool.Name()] {
+				return nil, err
+			}
+			return agenkit.NewToolError(err.Error()).WithMetadata("error", err), nil
+		}
+	}
+
+	if e.slots != nil {
+		select {
+		case e.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting to run tool '%s': %w", tool.Name(), ctx.Err())
+		}
+	}
+	release := func() {
+		if e.slots != nil {
+			<-e.slots
+		}
+	}
+
+	timeout := e.timeoutFor(tool.Name())
+	if timeout <= 0 {
+		defer release()
+		result, err := tool.Execute(ctx, params)
+		if err != nil {
+			return nil, &agenkit.ToolError{ToolName: tool.Name(), Err: err}
+		}
+		return result, nil
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result *agenkit.ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		result, err := tool.Execute(callCtx, params)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case out := <-done:
+		if out.err != nil && ctx.Err() == nil && callCtx.Err() == context.DeadlineExceeded {
+			return timeoutResult(tool.Name(), timeout), nil
+		}
+		if out.err != nil {
+			return nil, &agenkit.ToolError{ToolName: tool.Name(), Err: out.err}
+		}
+		return out.result, nil
+	case <-callCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return timeoutResult(tool.Name(), timeout), nil
+	}
+}
+
+// timeoutFor returns the timeout that applies to the named tool.
+func (e *Executor) timeoutFor(name string) time.Duration {
+	if timeout, ok := e.config.ToolTimeouts[name]; ok {
+		return timeout
+	}
+	return e.config.Timeout
+}
+
+// timeoutResult builds the failed result for a timed-out call.
+func timeoutResult(name string, timeout time.Duration) *agenkit.ToolResult {
+	err := &ToolTimeoutError{ToolName: name, Timeout: timeout}
+	return agenkit.NewToolError(err.Error()).WithMetadata("error", err)
+}
@@ -0,0 +1,248 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// slowTool sleeps before succeeding and tracks peak concurrency.
+type slowTool struct {
+	name       string
+	delay      time.Duration
+	ignoreCtx  bool
+	active     atomic.Int64
+	peakActive atomic.Int64
+}
+
+func (s *slowTool) Name() string        { return s.name }
+func (s *slowTool) Description() string { return "sleeps" }
+
+func (s *slowTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		peak := s.peakActive.Load()
+		if n <= peak || s.peakActive.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	if s.ignoreCtx {
+		time.Sleep(s.delay)
+		return agenkit.NewToolResult("done"), nil
+	}
+	select {
+	case <-time.After(s.delay):
+		return agenkit.NewToolResult("done"), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestExecutorNoLimits(t *testing.T) {
+	result, err := NewExecutor(ExecutorConfig{}).Execute(context.Background(), &slowTool{name: "t"}, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("Expected success, got %+v, %v", result, err)
+	}
+}
+
+func TestExecutorTimeoutIsObservation(t *testing.T) {
+	executor := NewExecutor(ExecutorConfig{Timeout: 20 * time.Millisecond})
+
+	for _, ignoreCtx := range []bool{false, true} {
+		start := time.Now()
+		result, err := executor.Execute(context.Background(), &slowTool{name: "slow", delay: 200 * time.Millisecond, ignoreCtx: ignoreCtx}, nil)
+		if err != nil {
+			t.Fatalf("Expected timeout as a result, got error: %v", err)
+		}
+		if result.Success {
+			t.Fatal("Expected failed result on timeout")
+		}
+		var timeoutErr *ToolTimeoutError
+		if e, ok := result.Metadata["error"].(error); !ok || !errors.As(e, &timeoutErr) || timeoutErr.ToolName != "slow" {
+			t.Errorf("Expected ToolTimeoutError in metadata, got %v", result.Metadata["error"])
+		}
+		if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+			t.Errorf("Expected call to return at the timeout (ignoreCtx=%v), took %v", ignoreCtx, elapsed)
+		}
+	}
+}
+
+func TestExecutorPerToolTimeout(t *testing.T) {
+	executor := NewExecutor(ExecutorConfig{
+		Timeout:      10 * time.Millisecond,
+		ToolTimeouts: map[string]time.Duration{"patient": time.Second},
+	})
+
+	result, _ := executor.Execute(context.Background(), &slowTool{name: "patient", delay: 30 * time.Millisecond}, nil)
+	if !result.Success {
+		t.Errorf("Expected per-tool timeout to override the default, got %+v", result)
+	}
+	result, _ = executor.Execute(context.Background(), &slowTool{name: "other", delay: 30 * time.Millisecond}, nil)
+	if result.Success {
+		t.Error("Expected default timeout to apply to other tools")
+	}
+}
+
+func TestExecutorConcurrencyLimit(t *testing.T) {
+	executor := NewExecutor(ExecutorConfig{MaxConcurrentTools: 2})
+	tool := &slowTool{name: "t", delay: 20 * time.Millisecond}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := executor.Execute(context.Background(), tool, nil); err != nil {
+				t.Errorf("Execute failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := tool.peakActive.Load(); peak > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, got %d", peak)
+	}
+}
+
+func TestExecutorWaitRespectsContext(t *testing.T) {
+	executor := NewExecutor(ExecutorConfig{MaxConcurrentTools: 1})
+	blocker := &slowTool{name: "blocker", delay: 200 * time.Millisecond}
+	go executor.Execute(context.Background(), blocker, nil)
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := executor.Execute(ctx, &slowTool{name: "waiter"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context error while waiting for a slot, got %v", err)
+	}
+}
+
+func TestToolAgentUsesExecutor(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(&slowTool{name: "slow", delay: time.Second})
+
+	agent := NewToolAgent(&MockAgent{}, registry)
+	agent.SetExecutor(NewExecutor(ExecutorConfig{Timeout: 10 * time.Millisecond}))
+
+	message := agenkit.NewMessage("user", "")
+	message.Metadata["tool_calls"] = []map[string]interface{}{{"tool_name": "slow", "parameters": map[string]interface{}{}}}
+	response, err := agent.Process(context.Background(), message)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	results := response.Metadata["tool_results"].([]*agenkit.ToolResult)
+	if results[0].Success {
+		t.Error("Expected tool call to time out")
+	}
+}
+
+// brokenTool always returns an error.
+type brokenTool struct{}
+
+func (b *brokenTool) Name() string        { return "broken" }
+func (b *brokenTool) Description() string { return "fails" }
+func (b *brokenTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	return nil, errors.New("disk full")
+}
+
+func TestExecutorWrapsToolErrors(t *testing.T) {
+	for _, config := range []ExecutorConfig{{}, {Timeout: time.Second}} {
+		_, err := NewExecutor(config).Execute(context.Background(), &brokenTool{}, nil)
+		var toolErr *agenkit.ToolError
+		if !errors.As(err, &toolErr) {
+			t.Fatalf("Expected ToolError, got %v", err)
+		}
+		if toolErr.ToolName != "broken" || toolErr.Err.Error() != "disk full" {
+			t.Errorf("Expected ToolError for 'broken' wrapping 'disk full', got %+v", toolErr)
+		}
+	}
+}
+
+func TestExecutorPublishesToolEvents(t *testing.T) {
+	bus := agenkit.NewEventBus()
+	sub := bus.Subscribe(0)
+	ctx := agenkit.WithEventSink(context.Background(), bus)
+
+	NewExecutor(ExecutorConfig{}).Execute(ctx, &brokenTool{}, map[string]interface{}{"path": "/tmp"})
+
+	called := <-sub.Events()
+	returned := <-sub.Events()
+	if called.Type != agenkit.EventToolCalled || called.Payload["tool"] != "broken" {
+		t.Errorf("Expected tool_called for 'broken', got %+v", called)
+	}
+	if returned.Type != agenkit.EventToolReturned || returned.Payload["success"] != false {
+		t.Errorf("Expected failed tool_returned, got %+v", returned)
+	}
+	if !strings.Contains(returned.Payload["error"].(string), "disk full") {
+		t.Errorf("Expected error in payload, got %v", returned.Payload["error"])
+	}
+}
+
+// countingTool echoes its query and counts its calls.
+type countingTool struct {
+	name  string
+	calls atomic.Int64
+}
+
+func (c *countingTool) Name() string        { return c.name }
+func (c *countingTool) Description() string { return "counts calls" }
+
+func (c *countingTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	c.calls.Add(1)
+	return agenkit.NewToolResult(params["query"]), nil
+}
+
+func TestExecutorMemoizesWithinRun(t *testing.T) {
+	search := &countingTool{name: "search"}
+	executor := NewExecutor(ExecutorConfig{Memoize: true})
+
+	ctx := WithMemo(context.Background())
+	first, _ := executor.Execute(ctx, search, map[string]interface{}{"query": "go", "limit": 3})
+	repeat, err := executor.Execute(ctx, search, map[string]interface{}{"limit": 3, "query": "go"})
+	if err != nil || repeat.Data != "go" || repeat.Metadata["memoized"] != true {
+		t.Fatalf("Expected the memoized result, got %+v, %v", repeat, err)
+	}
+	if first.Metadata["memoized"] != nil {
+		t.Errorf("Expected the first call to run, got %+v", first)
+	}
+	executor.Execute(ctx, search, map[string]interface{}{"query": "rust"})
+	if n := search.calls.Load(); n != 2 {
+		t.Errorf("Expected 2 runs for 2 distinct calls, got %d", n)
+	}
+
+	// A new run starts with an empty memo, and calls without one always run
+	executor.Execute(WithMemo(context.Background()), search, map[string]interface{}{"query": "go"})
+	executor.Execute(context.Background(), search, map[string]interface{}{"query": "go"})
+	if n := search.calls.Load(); n != 4 {
+		t.Errorf("Expected memos not to be shared across runs, got %d runs", n)
+	}
+}
+
+func TestExecutorNoMemoize(t *testing.T) {
+	send := &countingTool{name: "send_email"}
+	executor := NewExecutor(ExecutorConfig{Memoize: true, NoMemoize: map[string]bool{"send_email": true}})
+
+	ctx := WithMemo(context.Background())
+	executor.Execute(ctx, send, map[string]interface{}{"query": "hi"})
+	executor.Execute(ctx, send, map[string]interface{}{"query": "hi"})
+	if n := send.calls.Load(); n != 2 {
+		t.Errorf("Expected a side-effecting tool to run every time, got %d runs", n)
+	}
+
+	// Without Memoize the memo is ignored
+	plain := &countingTool{name: "search"}
+	NewExecutor(ExecutorConfig{}).Execute(ctx, plain, nil)
+	NewExecutor(ExecutorConfig{}).Execute(ctx, plain, nil)
+	if n := plain.calls.Load(); n != 2 {
+		t.Errorf("Expected no memoization by default, got %d runs", n)
+	}
+}
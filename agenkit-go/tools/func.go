@@ -0,0 +1,316 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// funcTool is a Tool backed by a Go function; see ToolFromFunc.
+type funcTool struct {
+	name        string
+	description string
+	schema      map[string]any
+	fn          reflect.Value
+	argsType    reflect.Type
+}
+
+// ToolFromFunc creates a tool that calls fn, deriving the tool's parameter
+// schema from fn's argument type.
+//
+// fn must have the form func(context.Context, Args) (Result, error), where
+// Args is a struct or a pointer to one. Each exported field becomes a
+// parameter named by its json tag (fields tagged "-" are skipped, and
+// embedded structs are flattened as encoding/json does). A field is
+// required unless it is a pointer or its json tag has omitempty. A
+// jsonschema tag adds detail to the field's schema as comma-separated
+// options. A description takes the rest of the tag, so it may contain
+// commas and must be the last option:
+//
+//	City  string `json:"city" jsonschema:"description=City, state or country"`
+//	Units string `json:"units,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+//
+// Field types that cannot be expressed in JSON, such as channels and
+// functions, are rejected here rather than when the tool is called.
+//
+// When called, the parameters are decoded into a new Args; parameters that
+// do not fit Args produce an error result the model can react to. fn's
+// Result becomes the result data, and an error from fn is returned as is.
+// The returned tool implements InputSchema() map[string]any, so providers
+// advertise the derived schema.
+func ToolFromFunc(name, description string, fn any) (agenkit.Tool, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tool name is required")
+	}
+	value := reflect.ValueOf(fn)
+	if !value.IsValid() || value.Kind() != reflect.Func {
+		return nil, fmt.Errorf("tool %s: expected a function, got %T", name, fn)
+	}
+	typ := value.Type()
+	if typ.NumIn() != 2 || typ.In(0) != contextType || typ.NumOut() != 2 || typ.Out(1) != errorType {
+		return nil, fmt.Errorf("tool %s: function must have the form func(context.Context, Args) (Result, error), got %v", name, typ)
+	}
+
+	argsType := typ.In(1)
+	structType := argsType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tool %s: arguments must be a struct or a pointer to one, got %v", name, argsType)
+	}
+
+	schema, err := structSchema(structType, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", name, err)
+	}
+
+	return &funcTool{
+		name:        name,
+		description: description,
+		schema:      schema,
+		fn:          value,
+		argsType:    argsType,
+	}, nil
+}
+
+// Name returns the tool's name.
+func (t *funcTool) Name() string {
+	return t.name
+}
+
+// Description returns the tool's description.
+func (t *funcTool) Description() string {
+	return t.description
+}
+
+// InputSchema returns the JSON Schema derived from the function's arguments.
+func (t *funcTool) InputSchema() map[string]any {
+	return t.schema
+}
+
+// Execute decodes params into the function's arguments and calls it.
+func (t *funcTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return agenkit.NewToolError(fmt.Sprintf("invalid arguments: %v", err)), nil
+	}
+
+	args := reflect.New(t.argsType)
+	if t.argsType.Kind() == reflect.Pointer {
+		args.Elem().Set(reflect.New(t.argsType.Elem()))
+		err = json.Unmarshal(data, args.Elem().Interface())
+	} else {
+		err = json.Unmarshal(data, args.Interface())
+	}
+	if err != nil {
+		return agenkit.NewToolError(fmt.Sprintf("invalid arguments: %v", err)), nil
+	}
+
+	out := t.fn.Call([]reflect.Value{reflect.ValueOf(ctx), args.Elem()})
+	if errValue := out[1].Interface(); errValue != nil {
+		return nil, errValue.(error)
+	}
+	return agenkit.NewToolResult(out[0].Interface()), nil
+}
+
+// structSchema returns the object schema of a struct type. seen holds the
+// struct types being expanded, to reject recursive types.
+func structSchema(typ reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	if seen[typ] {
+		return nil, fmt.Errorf("recursive type %v is not supported", typ)
+	}
+	seen[typ] = true
+	defer delete(seen, typ)
+
+	properties := map[string]any{}
+	required := []string{}
+	if err := addFields(typ, seen, properties, &required); err != nil {
+		return nil, err
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// addFields adds the schema of each of typ's fields to properties,
+// flattening embedded structs.
+func addFields(typ reflect.Type, seen map[reflect.Type]bool, properties map[string]any, required *[]string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, omitempty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			embedded := fieldType
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addFields(embedded, seen, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := typeSchema(fieldType, seen)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if err := applySchemaTag(schema, field.Tag.Get("jsonschema")); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		properties[name] = schema
+
+		if !omitempty && fieldType.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// jsonName reads a field's json tag, reporting its name (empty if not
+// renamed), whether it has omitempty, and whether the field is skipped.
+func jsonName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, false
+}
+
+// typeSchema returns the schema of a Go type.
+func typeSchema(typ reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	if typ == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return typeSchema(typ.Elem(), seen)
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(typ.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys must be strings, got %v", typ.Key())
+		}
+		values, err := typeSchema(typ.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return structSchema(typ, seen)
+	default:
+		return nil, fmt.Errorf("unsupported type %v", typ)
+	}
+}
+
+// applySchemaTag adds the options of a jsonschema tag to schema. The
+// supported options are description=..., enum=... (repeatable), minimum=...
+// and maximum=...; numeric enum values are converted for number and
+// integer schemas. A description runs to the end of the tag.
+func applySchemaTag(schema map[string]any, tag string) error {
+	if tag == "" {
+		return nil
+	}
+	var enum []any
+	for rest := tag; rest != ""; {
+		var option string
+		if strings.HasPrefix(rest, "description=") {
+			option, rest = rest, ""
+		} else {
+			option, rest, _ = strings.Cut(rest, ",")
+		}
+		key, value, ok := strings.Cut(option, "=")
+		if !ok {
+			return fmt.Errorf("invalid jsonschema option %q", option)
+		}
+		switch key {
+		case "description":
+			schema["description"] = value
+		case "enum":
+			enum = append(enum, enumValue(schema["type"], value))
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("jsonschema %s must be a number, got %q", key, value)
+			}
+			schema[key] = n
+		default:
+			return fmt.Errorf("unknown jsonschema option %q", key)
+		}
+	}
+	if len(enum) > 0 {
+		schema["enum"] = enum
+	}
+	return nil
+}
+
+// enumValue converts an enum value to the schema's type where possible.
+func enumValue(schemaType any, value string) any {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type weatherArgs struct {
+	City    string   `json:"city" jsonschema:"description=City to look up"`
+	Units   string   `json:"units,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+	Days    *int     `json:"days" jsonschema:"minimum=1,maximum=7"`
+	Tags    []string `json:"tags,omitempty"`
+	Ignored string   `json:"-"`
+	hidden  string
+}
+
+type weatherReport struct {
+	City  string `json:"city"`
+	Units string `json:"units"`
+	Days  int    `json:"days"`
+}
+
+func lookupWeather(ctx context.Context, args weatherArgs) (weatherReport, error) {
+	days := 1
+	if args.Days != nil {
+		days = *args.Days
+	}
+	return weatherReport{City: args.City, Units: args.Units, Days: days}, nil
+}
+
+func TestToolFromFuncSchema(t *testing.T) {
+	tool, err := ToolFromFunc("weather", "Looks up the weather", lookupWeather)
+	if err != nil {
+		t.Fatalf("ToolFromFunc failed: %v", err)
+	}
+	if tool.Name() != "weather" || tool.Description() != "Looks up the weather" {
+		t.Errorf("Expected name and description to be kept, got %s / %s", tool.Name(), tool.Description())
+	}
+
+	schema := tool.(interface{ InputSchema() map[string]any }).InputSchema()
+	properties := schema["properties"].(map[string]any)
+	if len(properties) != 4 {
+		t.Fatalf("Expected 4 properties, got %v", properties)
+	}
+
+	city := properties["city"].(map[string]any)
+	if city["type"] != "string" || city["description"] != "City to look up" {
+		t.Errorf("Expected described string city, got %v", city)
+	}
+	units := properties["units"].(map[string]any)
+	if !reflect.DeepEqual(units["enum"], []any{"celsius", "fahrenheit"}) {
+		t.Errorf("Expected units enum, got %v", units["enum"])
+	}
+	days := properties["days"].(map[string]any)
+	if days["type"] != "integer" || days["minimum"] != 1.0 || days["maximum"] != 7.0 {
+		t.Errorf("Expected bounded integer days, got %v", days)
+	}
+	tags := properties["tags"].(map[string]any)
+	if tags["type"] != "array" || tags["items"].(map[string]any)["type"] != "string" {
+		t.Errorf("Expected string array tags, got %v", tags)
+	}
+
+	if !reflect.DeepEqual(schema["required"], []string{"city"}) {
+		t.Errorf("Expected only city to be required, got %v", schema["required"])
+	}
+}
+
+func TestToolFromFuncDescriptionWithCommas(t *testing.T) {
+	type placeArgs struct {
+		Place string `json:"place" jsonschema:"enum=here,description=City, state or country"`
+	}
+	tool, err := ToolFromFunc("place", "", func(ctx context.Context, a placeArgs) (any, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("ToolFromFunc failed: %v", err)
+	}
+
+	schema := tool.(interface{ InputSchema() map[string]any }).InputSchema()
+	place := schema["properties"].(map[string]any)["place"].(map[string]any)
+	if place["description"] != "City, state or country" {
+		t.Errorf("Expected the whole description, got %v", place["description"])
+	}
+	if !reflect.DeepEqual(place["enum"], []any{"here"}) {
+		t.Errorf("Expected options before the description to apply, got %v", place["enum"])
+	}
+}
+
+func TestToolFromFuncExecute(t *testing.T) {
+	tool, _ := ToolFromFunc("weather", "", lookupWeather)
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"city": "Oslo", "units": "celsius", "days": 3.0,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Expected success, got error '%s'", result.Error)
+	}
+	report := result.Data.(weatherReport)
+	if report.City != "Oslo" || report.Units != "celsius" || report.Days != 3 {
+		t.Errorf("Expected decoded arguments to reach fn, got %+v", report)
+	}
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"city": 42})
+	if err != nil {
+		t.Fatalf("Expected bad arguments as an error result, got %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "invalid arguments") {
+		t.Errorf("Expected invalid arguments error result, got %+v", result)
+	}
+}
+
+func TestToolFromFuncPointerArgsAndError(t *testing.T) {
+	type args struct {
+		Name string
+	}
+	fn := func(ctx context.Context, a *args) (string, error) {
+		if a.Name == "" {
+			return "", errors.New("name is empty")
+		}
+		return "hello " + a.Name, nil
+	}
+	tool, err := ToolFromFunc("greet", "", fn)
+	if err != nil {
+		t.Fatalf("ToolFromFunc failed: %v", err)
+	}
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"Name": "Ada"})
+	if err != nil || result.Data != "hello Ada" {
+		t.Errorf("Expected 'hello Ada', got %v (err %v)", result, err)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{}); err == nil || err.Error() != "name is empty" {
+		t.Errorf("Expected fn error to be returned, got %v", err)
+	}
+}
+
+func TestToolFromFuncEmbeddedAndNested(t *testing.T) {
+	type Paging struct {
+		Limit int `json:"limit,omitempty"`
+	}
+	type filter struct {
+		Field string `json:"field"`
+	}
+	type args struct {
+		Paging
+		Query   string            `json:"query"`
+		Filter  filter            `json:"filter"`
+		Options map[string]string `json:"options,omitempty"`
+	}
+	tool, err := ToolFromFunc("search", "", func(ctx context.Context, a args) (any, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("ToolFromFunc failed: %v", err)
+	}
+
+	properties := tool.(*funcTool).InputSchema()["properties"].(map[string]any)
+	if _, ok := properties["limit"]; !ok {
+		t.Errorf("Expected embedded field to be flattened, got %v", properties)
+	}
+	nested := properties["filter"].(map[string]any)
+	if nested["type"] != "object" || !reflect.DeepEqual(nested["required"], []string{"field"}) {
+		t.Errorf("Expected nested object schema, got %v", nested)
+	}
+	options := properties["options"].(map[string]any)
+	if options["additionalProperties"].(map[string]any)["type"] != "string" {
+		t.Errorf("Expected map of strings, got %v", options)
+	}
+}
+
+func TestToolFromFuncRejectsInvalid(t *testing.T) {
+	type withChan struct {
+		Updates chan int `json:"updates"`
+	}
+	type withFunc struct {
+		Callback func() `json:"callback"`
+	}
+	type recursive struct {
+		Children []recursive `json:"children"`
+	}
+	type badTag struct {
+		Name string `jsonschema:"pattern=x"`
+	}
+
+	cases := map[string]any{
+		"not a function":   42,
+		"wrong signature":  func(a withChan) error { return nil },
+		"non-struct args":  func(ctx context.Context, s string) (string, error) { return s, nil },
+		"channel field":    func(ctx context.Context, a withChan) (any, error) { return nil, nil },
+		"func field":       func(ctx context.Context, a withFunc) (any, error) { return nil, nil },
+		"recursive type":   func(ctx context.Context, a recursive) (any, error) { return nil, nil },
+		"unknown tag":      func(ctx context.Context, a badTag) (any, error) { return nil, nil },
+		"missing ctx type": func(ctx int, a badTag) (any, error) { return nil, nil },
+	}
+	for name, fn := range cases {
+		if _, err := ToolFromFunc("tool", "", fn); err == nil {
+			t.Errorf("%s: expected registration error", name)
+		}
+	}
+
+	_, err := ToolFromFunc("tool", "", func(ctx context.Context, a withChan) (any, error) { return nil, nil })
+	if err == nil || !strings.Contains(err.Error(), "field Updates: unsupported type chan int") {
+		t.Errorf("Expected error naming the field and type, got %v", err)
+	}
+}
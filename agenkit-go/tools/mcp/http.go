@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// httpTransport speaks MCP's streamable HTTP transport: each message is
+// POSTed to a single endpoint, and responses arrive as JSON or as a
+// server-sent event stream.
+type httpTransport struct {
+	url    string
+	client *http.Client
+	nextID atomic.Int64
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// NewHTTPTransport creates a transport for the MCP endpoint at url.
+// If client is nil, http.DefaultClient is used.
+func NewHTTPTransport(url string, client *http.Client) Transport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTransport{url: url, client: client}
+}
+
+// Call POSTs a request and reads its response.
+func (t *httpTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := json.RawMessage(fmt.Sprintf("%d", t.nextID.Add(1)))
+	resp, err := t.post(ctx, &jsonrpcMessage{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readEventStream(resp.Body, id)
+	}
+
+	var msg jsonrpcMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("mcp http: invalid response to %s: %w", method, err)
+	}
+	return resultOf(&msg)
+}
+
+// Notify POSTs a notification.
+func (t *httpTransport) Notify(ctx context.Context, method string, params any) error {
+	resp, err := t.post(ctx, &jsonrpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Close ends the session, if the server assigned one.
+func (t *httpTransport) Close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// post sends one message, tracking the session ID assigned by the server.
+func (t *httpTransport) post(ctx context.Context, msg *jsonrpcMessage) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("mcp http: failed to encode %s: %w", msg.Method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("MCP-Protocol-Version", ProtocolVersion)
+
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrTransportClosed, err)
+	}
+
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && sessionID != "":
+		// The server forgot our session; a new one must be initialized
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: session expired", ErrTransportClosed)
+	case resp.StatusCode >= 300:
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("mcp http: %s returned %s: %s", msg.Method, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// readEventStream reads server-sent events until the response with id arrives.
+func readEventStream(r io.Reader, id json.RawMessage) (json.RawMessage, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case line == "" && data.Len() > 0:
+			// A blank line ends the event
+			var msg jsonrpcMessage
+			if err := json.Unmarshal([]byte(data.String()), &msg); err == nil &&
+				msg.isResponse() && string(msg.ID) == string(id) {
+				return resultOf(&msg)
+			}
+			data.Reset()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTransportClosed, err)
+	}
+	return nil, fmt.Errorf("%w: event stream ended without a response", ErrTransportClosed)
+}
@@ -0,0 +1,443 @@
+// Package mcp exposes tools served over the Model Context Protocol as
+// agenkit tools.
+//
+// An MCPToolProvider connects to an MCP server over stdio or streamable
+// HTTP, lists the server's tools, and wraps each one as an agenkit.Tool.
+// Executing a wrapped tool sends a tools/call request and translates the
+// result back into an agenkit.ToolResult:
+//
+//	provider, err := mcp.NewMCPToolProvider(ctx, mcp.MCPConfig{
+//		Connect: mcp.Stdio("my-mcp-server", "--flag"),
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer provider.Close()
+//
+//	tools, err := provider.Tools(ctx)
+//
+// The provider implements agenkit.Lifecycle. Created with
+// NewDeferredMCPToolProvider and handed to a composition.Runtime, it
+// connects when the runtime starts and disconnects when it stops.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ProtocolVersion is the MCP protocol revision requested by the client.
+const ProtocolVersion = "2025-06-18"
+
+// MCPConfig configures an MCPToolProvider.
+type MCPConfig struct {
+	// Connect opens a transport to the server. It is called on startup and
+	// again whenever the connection has dropped. Required.
+	Connect func(ctx context.Context) (Transport, error)
+
+	// ClientName identifies this client to the server.
+	// Default: "agenkit"
+	ClientName string
+
+	// ClientVersion is reported alongside ClientName.
+	// Default: "0.1.0"
+	ClientVersion string
+}
+
+// Stdio returns a Connect function that launches command as a subprocess
+// and speaks MCP over its stdin and stdout.
+func Stdio(command string, args ...string) func(ctx context.Context) (Transport, error) {
+	return func(ctx context.Context) (Transport, error) {
+		return NewStdioTransport(command, args...)
+	}
+}
+
+// HTTP returns a Connect function for the streamable HTTP endpoint at url.
+// If client is nil, http.DefaultClient is used.
+func HTTP(url string, client *http.Client) func(ctx context.Context) (Transport, error) {
+	return func(ctx context.Context) (Transport, error) {
+		return NewHTTPTransport(url, client), nil
+	}
+}
+
+// ServerInfo describes the connected server, as reported during initialization.
+type ServerInfo struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	ProtocolVersion string `json:"-"`
+}
+
+// MCPToolProvider connects to an MCP server and exposes its tools.
+//
+// If the connection drops, the provider reconnects and re-initializes on
+// the next request. A tools/call that was in flight when the connection
+// dropped is not retried, since the tool may already have run; it fails
+// with an error wrapping ErrTransportClosed.
+type MCPToolProvider struct {
+	config MCPConfig
+
+	mu        sync.Mutex
+	transport Transport
+	server    ServerInfo
+	closed    bool
+}
+
+// Verify that MCPToolProvider implements agenkit.Lifecycle interface.
+var _ agenkit.Lifecycle = (*MCPToolProvider)(nil)
+
+// NewMCPToolProvider connects to a server and performs the MCP handshake.
+func NewMCPToolProvider(ctx context.Context, config MCPConfig) (*MCPToolProvider, error) {
+	p, err := NewDeferredMCPToolProvider(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Start(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewDeferredMCPToolProvider creates a provider without connecting. The
+// connection is opened by Start, or else by the first request.
+func NewDeferredMCPToolProvider(config MCPConfig) (*MCPToolProvider, error) {
+	if config.Connect == nil {
+		return nil, fmt.Errorf("mcp provider requires a Connect function")
+	}
+	if config.ClientName == "" {
+		config.ClientName = "agenkit"
+	}
+	if config.ClientVersion == "" {
+		config.ClientVersion = "0.1.0"
+	}
+	return &MCPToolProvider{config: config}, nil
+}
+
+// Server returns information about the connected server.
+func (p *MCPToolProvider) Server() ServerInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.server
+}
+
+// Tools lists the server's tools, wrapped as agenkit tools.
+func (p *MCPToolProvider) Tools(ctx context.Context) ([]agenkit.Tool, error) {
+	var tools []agenkit.Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		// Listing is idempotent, so it is safe to retry after a reconnect
+		raw, err := p.call(ctx, "tools/list", params, true)
+		if err != nil {
+			return nil, fmt.Errorf("mcp tools/list: %w", err)
+		}
+
+		var page struct {
+			Tools []struct {
+				Name        string         `json:"name"`
+				Description string         `json:"description"`
+				InputSchema map[string]any `json:"inputSchema"`
+			} `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("mcp tools/list: invalid result: %w", err)
+		}
+		for _, t := range page.Tools {
+			tools = append(tools, &Tool{
+				provider:    p,
+				name:        t.Name,
+				description: t.Description,
+				inputSchema: t.InputSchema,
+			})
+		}
+
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool by name.
+//
+// Tool failures reported by the server, either as a result with isError
+// set or as a JSON-RPC error, are returned as a failed ToolResult so the
+// agent can observe and react to them. The returned error is reserved for
+// transport and protocol failures.
+func (p *MCPToolProvider) CallTool(ctx context.Context, name string, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	raw, err := p.call(ctx, "tools/call", map[string]any{"name": name, "arguments": params}, false)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return agenkit.NewToolError(rpcErr.Error()).
+			WithMetadata("mcp_error_code", rpcErr.Code), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mcp tools/call %s: %w", name, err)
+	}
+	return translateResult(raw)
+}
+
+// Start connects to the server if the provider is not connected, so a
+// composition.Runtime can open the connection before serving rather than
+// on the first request. It reopens a closed provider.
+func (p *MCPToolProvider) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = false
+	if p.transport != nil {
+		return nil
+	}
+	_, err := p.connectLocked(ctx)
+	return err
+}
+
+// Stop closes the connection, as Close does.
+func (p *MCPToolProvider) Stop(ctx context.Context) error {
+	return p.Close()
+}
+
+// Close closes the connection. The provider cannot be used afterwards.
+func (p *MCPToolProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.transport == nil {
+		return nil
+	}
+	err := p.transport.Close()
+	p.transport = nil
+	return err
+}
+
+// call sends a request, reconnecting first if the connection has dropped.
+// When retry is set, a request that fails because the connection dropped
+// is retried once on a fresh connection.
+func (p *MCPToolProvider) call(ctx context.Context, method string, params any, retry bool) (json.RawMessage, error) {
+	transport, err := p.currentTransport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := transport.Call(ctx, method, params)
+	if !errors.Is(err, ErrTransportClosed) {
+		return raw, err
+	}
+
+	p.dropTransport(transport)
+	if !retry {
+		return nil, err
+	}
+	transport, err = p.currentTransport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = transport.Call(ctx, method, params)
+	if errors.Is(err, ErrTransportClosed) {
+		p.dropTransport(transport)
+	}
+	return raw, err
+}
+
+// currentTransport returns the live transport, connecting if needed.
+func (p *MCPToolProvider) currentTransport(ctx context.Context) (Transport, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("mcp provider is closed")
+	}
+	if p.transport != nil {
+		return p.transport, nil
+	}
+	return p.connectLocked(ctx)
+}
+
+// dropTransport discards transport if it is still current, so the next
+// request reconnects.
+func (p *MCPToolProvider) dropTransport(transport Transport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.transport == transport {
+		_ = transport.Close()
+		p.transport = nil
+	}
+}
+
+// connectLocked opens a transport and performs the initialize handshake.
+// The caller must hold p.mu.
+func (p *MCPToolProvider) connectLocked(ctx context.Context) (Transport, error) {
+	transport, err := p.config.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("mcp connect: %w", err)
+	}
+
+	raw, err := transport.Call(ctx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo": map[string]any{
+			"name":    p.config.ClientName,
+			"version": p.config.ClientVersion,
+		},
+	})
+	if err != nil {
+		transport.Close()
+		return nil, fmt.Errorf("mcp initialize: %w", err)
+	}
+
+	var result struct {
+		ProtocolVersion string     `json:"protocolVersion"`
+		ServerInfo      ServerInfo `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		transport.Close()
+		return nil, fmt.Errorf("mcp initialize: invalid result: %w", err)
+	}
+
+	if err := transport.Notify(ctx, "notifications/initialized", nil); err != nil {
+		transport.Close()
+		return nil, fmt.Errorf("mcp initialize: %w", err)
+	}
+
+	p.server = result.ServerInfo
+	p.server.ProtocolVersion = result.ProtocolVersion
+	p.transport = transport
+	return transport, nil
+}
+
+// translateResult converts an MCP CallToolResult into a ToolResult.
+// Text content becomes the result data; the raw content blocks and any
+// structured content are kept in metadata.
+func translateResult(raw json.RawMessage) (*agenkit.ToolResult, error) {
+	var result struct {
+		Content           []map[string]any `json:"content"`
+		StructuredContent any              `json:"structuredContent"`
+		IsError           bool             `json:"isError"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("mcp tools/call: invalid result: %w", err)
+	}
+
+	var texts []string
+	for _, block := range result.Content {
+		if block["type"] == "text" {
+			if text, ok := block["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	text := strings.Join(texts, "\n")
+
+	var toolResult *agenkit.ToolResult
+	if result.IsError {
+		if text == "" {
+			text = "tool reported an error"
+		}
+		toolResult = agenkit.NewToolError(text)
+	} else {
+		toolResult = agenkit.NewToolResult(text)
+	}
+
+	toolResult.WithMetadata("mcp_content", result.Content)
+	if result.StructuredContent != nil {
+		toolResult.WithMetadata("mcp_structured_content", result.StructuredContent)
+	}
+	return toolResult, nil
+}
+
+// Tool is an MCP server tool exposed as an agenkit.Tool.
+type Tool struct {
+	provider    *MCPToolProvider
+	name        string
+	description string
+	inputSchema map[string]any
+}
+
+// Verify that Tool implements agenkit.Tool interface.
+var _ agenkit.Tool = (*Tool)(nil)
+
+// Name returns the tool's name.
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the server's description followed by a summary of
+// the tool's parameters, so agents that only see descriptions know what
+// to pass.
+func (t *Tool) Description() string {
+	params := describeParameters(t.inputSchema)
+	if params == "" {
+		return t.description
+	}
+	if t.description == "" {
+		return "Parameters: " + params
+	}
+	return t.description + " Parameters: " + params
+}
+
+// InputSchema returns the JSON Schema of the tool's parameters.
+func (t *Tool) InputSchema() map[string]any {
+	return t.inputSchema
+}
+
+// Execute calls the tool on the server.
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	return t.provider.CallTool(ctx, t.name, params)
+}
+
+// describeParameters summarizes an object schema's properties as
+// "name (type, required): description; ...", in sorted order.
+func describeParameters(schema map[string]any) string {
+	properties, _ := schema["properties"].(map[string]any)
+	if len(properties) == 0 {
+		return ""
+	}
+
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]any)
+		typ, _ := prop["type"].(string)
+		if typ == "" {
+			typ = "any"
+		}
+		part := fmt.Sprintf("%s (%s", name, typ)
+		if required[name] {
+			part += ", required"
+		}
+		part += ")"
+		if desc, ok := prop["description"].(string); ok && desc != "" {
+			part += ": " + desc
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,398 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer is an in-process MCP server speaking newline-delimited JSON-RPC.
+type fakeServer struct {
+	mu       sync.Mutex
+	connects int
+	calls    []string
+	conns    []io.Closer
+
+	// handle answers tools/call; it returns a result or an RPC error
+	handle func(name string, args map[string]any) (any, *RPCError)
+	// dropOnCall closes the connection instead of answering tools/call
+	dropOnCall bool
+}
+
+func (s *fakeServer) connect(ctx context.Context) (Transport, error) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	s.mu.Lock()
+	s.connects++
+	s.conns = append(s.conns, serverW)
+	s.mu.Unlock()
+
+	go s.serve(serverR, serverW)
+	return NewStreamTransport(clientR, clientW), nil
+}
+
+// drop closes every open connection from the server side.
+func (s *fakeServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeServer) serve(r io.Reader, w *io.PipeWriter) {
+	defer w.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || len(req.ID) == 0 {
+			continue
+		}
+		s.mu.Lock()
+		s.calls = append(s.calls, req.Method)
+		s.mu.Unlock()
+
+		result, rpcErr := s.respond(req.Method, req.Params)
+		if result == nil && rpcErr == nil {
+			return
+		}
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			resp["error"] = rpcErr
+		} else {
+			resp["result"] = result
+		}
+		data, _ := json.Marshal(resp)
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) respond(method string, params json.RawMessage) (any, *RPCError) {
+	switch method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fake", "version": "1.0"},
+		}, nil
+	case "tools/list":
+		var p struct {
+			Cursor string `json:"cursor"`
+		}
+		json.Unmarshal(params, &p)
+		if p.Cursor == "" {
+			return map[string]any{
+				"tools": []any{map[string]any{
+					"name":        "add",
+					"description": "Adds two numbers.",
+					"inputSchema": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"a": map[string]any{"type": "number", "description": "first"},
+							"b": map[string]any{"type": "number"},
+						},
+						"required": []any{"a"},
+					},
+				}},
+				"nextCursor": "page2",
+			}, nil
+		}
+		return map[string]any{
+			"tools": []any{map[string]any{"name": "ping", "inputSchema": map[string]any{"type": "object"}}},
+		}, nil
+	case "tools/call":
+		if s.dropOnCall {
+			return nil, nil
+		}
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		json.Unmarshal(params, &p)
+		return s.handle(p.Name, p.Arguments)
+	}
+	return nil, &RPCError{Code: -32601, Message: "method not found"}
+}
+
+func (s *fakeServer) connectCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connects
+}
+
+func textResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []any{map[string]any{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func newTestProvider(t *testing.T, server *fakeServer) *MCPToolProvider {
+	t.Helper()
+	provider, err := NewMCPToolProvider(context.Background(), MCPConfig{Connect: server.connect})
+	if err != nil {
+		t.Fatalf("NewMCPToolProvider failed: %v", err)
+	}
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+func TestNewMCPToolProviderRequiresConnect(t *testing.T) {
+	if _, err := NewMCPToolProvider(context.Background(), MCPConfig{}); err == nil {
+		t.Fatal("Expected error without Connect")
+	}
+}
+
+func TestMCPToolProviderHandshake(t *testing.T) {
+	server := &fakeServer{}
+	provider := newTestProvider(t, server)
+
+	info := provider.Server()
+	if info.Name != "fake" || info.ProtocolVersion != ProtocolVersion {
+		t.Errorf("Expected server info from initialize, got %+v", info)
+	}
+}
+
+func TestMCPToolProviderListsToolsAcrossPages(t *testing.T) {
+	provider := newTestProvider(t, &fakeServer{})
+
+	tools, err := provider.Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("Expected 2 tools, got %d", len(tools))
+	}
+	if tools[0].Name() != "add" || tools[1].Name() != "ping" {
+		t.Errorf("Expected add and ping, got %s and %s", tools[0].Name(), tools[1].Name())
+	}
+
+	expected := "Adds two numbers. Parameters: a (number, required): first; b (number)"
+	if tools[0].Description() != expected {
+		t.Errorf("Expected description '%s', got '%s'", expected, tools[0].Description())
+	}
+	if tools[0].(*Tool).InputSchema()["type"] != "object" {
+		t.Error("Expected input schema to be exposed")
+	}
+}
+
+func TestMCPToolExecute(t *testing.T) {
+	server := &fakeServer{handle: func(name string, args map[string]any) (any, *RPCError) {
+		return textResult(fmt.Sprintf("%v", args["a"].(float64)+args["b"].(float64)), false), nil
+	}}
+	tools, err := newTestProvider(t, server).Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+
+	result, err := tools[0].Execute(context.Background(), map[string]interface{}{"a": 2, "b": 3})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Success || result.Data != "5" {
+		t.Errorf("Expected successful result '5', got %+v", result)
+	}
+	if _, ok := result.Metadata["mcp_content"]; !ok {
+		t.Error("Expected raw content in metadata")
+	}
+}
+
+func TestMCPToolErrorResult(t *testing.T) {
+	server := &fakeServer{handle: func(name string, args map[string]any) (any, *RPCError) {
+		return textResult("division by zero", true), nil
+	}}
+	result, err := newTestProvider(t, server).CallTool(context.Background(), "div", nil)
+	if err != nil {
+		t.Fatalf("Expected tool error as result, got error: %v", err)
+	}
+	if result.Success || result.Error != "division by zero" {
+		t.Errorf("Expected failed result 'division by zero', got %+v", result)
+	}
+}
+
+func TestMCPToolRPCError(t *testing.T) {
+	server := &fakeServer{handle: func(name string, args map[string]any) (any, *RPCError) {
+		return nil, &RPCError{Code: -32602, Message: "unknown tool: nope"}
+	}}
+	result, err := newTestProvider(t, server).CallTool(context.Background(), "nope", nil)
+	if err != nil {
+		t.Fatalf("Expected RPC error as result, got error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "unknown tool: nope") {
+		t.Errorf("Expected failed result with server message, got %+v", result)
+	}
+	if result.Metadata["mcp_error_code"] != -32602 {
+		t.Errorf("Expected error code in metadata, got %v", result.Metadata["mcp_error_code"])
+	}
+}
+
+func TestMCPToolProviderReconnectsAfterDrop(t *testing.T) {
+	server := &fakeServer{handle: func(name string, args map[string]any) (any, *RPCError) {
+		return textResult("ok", false), nil
+	}}
+	provider := newTestProvider(t, server)
+
+	server.drop()
+
+	if _, err := provider.Tools(context.Background()); err != nil {
+		t.Fatalf("Expected tools/list to succeed after reconnect, got %v", err)
+	}
+	if server.connectCount() != 2 {
+		t.Errorf("Expected 2 connections, got %d", server.connectCount())
+	}
+
+	result, err := provider.CallTool(context.Background(), "add", nil)
+	if err != nil || !result.Success {
+		t.Errorf("Expected tool call on new connection to succeed, got %+v, %v", result, err)
+	}
+}
+
+func TestMCPToolCallNotRetriedWhenConnectionDrops(t *testing.T) {
+	server := &fakeServer{dropOnCall: true}
+	provider := newTestProvider(t, server)
+
+	_, err := provider.CallTool(context.Background(), "add", nil)
+	if !errors.Is(err, ErrTransportClosed) {
+		t.Fatalf("Expected ErrTransportClosed, got %v", err)
+	}
+
+	server.mu.Lock()
+	calls := 0
+	for _, m := range server.calls {
+		if m == "tools/call" {
+			calls++
+		}
+	}
+	server.mu.Unlock()
+	if calls != 1 {
+		t.Errorf("Expected the in-flight call not to be retried, got %d calls", calls)
+	}
+
+	// The next request reconnects
+	server.dropOnCall = false
+	if _, err := provider.Tools(context.Background()); err != nil {
+		t.Fatalf("Expected reconnect on next request, got %v", err)
+	}
+	if server.connectCount() != 2 {
+		t.Errorf("Expected 2 connections, got %d", server.connectCount())
+	}
+}
+
+func TestMCPToolProviderClosed(t *testing.T) {
+	provider := newTestProvider(t, &fakeServer{})
+	provider.Close()
+	if _, err := provider.Tools(context.Background()); err == nil {
+		t.Fatal("Expected error after Close")
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	fake := &fakeServer{handle: func(name string, args map[string]any) (any, *RPCError) {
+		return textResult("hello "+args["name"].(string), false), nil
+	}}
+
+	var sessionHeaders []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		sessionHeaders = append(sessionHeaders, r.Header.Get("Mcp-Session-Id"))
+		mu.Unlock()
+
+		if len(req.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", "session-1")
+		}
+
+		result, rpcErr := fake.respond(req.Method, req.Params)
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result, "error": rpcErr})
+
+		if req.Method == "tools/call" {
+			// Answer tool calls as an event stream with a notification first
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	provider, err := NewMCPToolProvider(context.Background(), MCPConfig{Connect: HTTP(srv.URL, nil)})
+	if err != nil {
+		t.Fatalf("NewMCPToolProvider failed: %v", err)
+	}
+	defer provider.Close()
+
+	result, err := provider.CallTool(context.Background(), "greet", map[string]interface{}{"name": "world"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.Data != "hello world" {
+		t.Errorf("Expected 'hello world', got %v", result.Data)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sessionHeaders[0] != "" {
+		t.Errorf("Expected no session on initialize, got '%s'", sessionHeaders[0])
+	}
+	for i, h := range sessionHeaders[1:] {
+		if h != "session-1" {
+			t.Errorf("Request %d: Expected session header 'session-1', got '%s'", i+2, h)
+		}
+	}
+}
+
+func TestDeferredMCPToolProviderConnectsOnStart(t *testing.T) {
+	server := &fakeServer{}
+	provider, err := NewDeferredMCPToolProvider(MCPConfig{Connect: server.connect})
+	if err != nil {
+		t.Fatalf("NewDeferredMCPToolProvider failed: %v", err)
+	}
+	if server.connectCount() != 0 {
+		t.Fatalf("Expected no connection before Start, got %d", server.connectCount())
+	}
+
+	if err := provider.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if server.connectCount() != 1 || provider.Server().Name != "fake" {
+		t.Errorf("Expected Start to connect once, got %d connects", server.connectCount())
+	}
+	if err := provider.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if _, err := provider.Tools(context.Background()); err == nil {
+		t.Error("Expected error after Stop")
+	}
+}
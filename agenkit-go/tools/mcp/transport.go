@@ -0,0 +1,238 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrTransportClosed is returned when the connection to the server has been
+// lost or closed. MCPToolProvider reconnects on the next call.
+var ErrTransportClosed = errors.New("mcp transport closed")
+
+// RPCError is a JSON-RPC error response from the server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// Transport carries JSON-RPC messages to and from an MCP server.
+type Transport interface {
+	// Call sends a request and waits for its result. JSON-RPC error
+	// responses are returned as *RPCError.
+	Call(ctx context.Context, method string, params any) (json.RawMessage, error)
+
+	// Notify sends a notification, which has no response.
+	Notify(ctx context.Context, method string, params any) error
+
+	// Close releases the connection.
+	Close() error
+}
+
+// jsonrpcMessage is the wire format of every JSON-RPC 2.0 message.
+type jsonrpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// isResponse reports whether the message answers a request.
+func (m *jsonrpcMessage) isResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// streamTransport speaks newline-delimited JSON-RPC over a byte stream.
+type streamTransport struct {
+	w      io.WriteCloser
+	closer func() error
+
+	writeMu sync.Mutex
+	nextID  atomic.Int64
+
+	mu      sync.Mutex
+	pending map[string]chan *jsonrpcMessage
+	err     error // set once the read loop stops
+	done    chan struct{}
+}
+
+// NewStreamTransport creates a transport exchanging newline-delimited
+// JSON-RPC messages over r and w, as used by MCP's stdio transport.
+// Closing the transport closes w.
+func NewStreamTransport(r io.Reader, w io.WriteCloser) Transport {
+	return newStreamTransport(r, w, nil)
+}
+
+func newStreamTransport(r io.Reader, w io.WriteCloser, closer func() error) *streamTransport {
+	t := &streamTransport{
+		w:       w,
+		closer:  closer,
+		pending: make(map[string]chan *jsonrpcMessage),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(r)
+	return t
+}
+
+// NewStdioTransport starts command and speaks MCP over its stdin and stdout.
+// Closing the transport terminates the process.
+func NewStdioTransport(command string, args ...string) (Transport, error) {
+	cmd := exec.Command(command, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp stdio: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp stdio: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp stdio: failed to start %s: %w", command, err)
+	}
+
+	closer := func() error {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil
+	}
+	return newStreamTransport(stdout, stdin, closer), nil
+}
+
+// Call sends a request and waits for the matching response.
+func (t *streamTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := fmt.Sprintf("%d", t.nextID.Add(1))
+	ch := make(chan *jsonrpcMessage, 1)
+
+	t.mu.Lock()
+	if t.err != nil {
+		err := t.err
+		t.mu.Unlock()
+		return nil, err
+	}
+	t.pending[id] = ch
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(&jsonrpcMessage{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-t.done:
+		// The read loop may have delivered our response before stopping
+		select {
+		case msg := <-ch:
+			return resultOf(msg)
+		default:
+		}
+		return nil, t.closedErr()
+	case msg := <-ch:
+		return resultOf(msg)
+	}
+}
+
+// Notify sends a notification.
+func (t *streamTransport) Notify(ctx context.Context, method string, params any) error {
+	if err := t.closedErr(); err != nil {
+		return err
+	}
+	return t.write(&jsonrpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// Close closes the connection.
+func (t *streamTransport) Close() error {
+	err := t.w.Close()
+	if t.closer != nil {
+		if cerr := t.closer(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// write encodes one message followed by a newline.
+func (t *streamTransport) write(msg *jsonrpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("mcp: failed to encode %s: %w", msg.Method, err)
+	}
+	data = append(data, '\n')
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.w.Write(data); err != nil {
+		return fmt.Errorf("%w: %v", ErrTransportClosed, err)
+	}
+	return nil
+}
+
+// readLoop dispatches responses to waiting calls until the stream ends.
+func (t *streamTransport) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var msg jsonrpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			ch, ok := t.pending[string(msg.ID)]
+			t.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case msg.Method == "ping" && len(msg.ID) > 0:
+			// Servers may ping clients to check liveness
+			_ = t.write(&jsonrpcMessage{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("{}")})
+		}
+	}
+
+	err := ErrTransportClosed
+	if scanErr := scanner.Err(); scanErr != nil {
+		err = fmt.Errorf("%w: %v", ErrTransportClosed, scanErr)
+	}
+	t.mu.Lock()
+	t.err = err
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// closedErr returns the read loop's terminal error, if it has stopped.
+func (t *streamTransport) closedErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// resultOf returns a response's result or its error.
+func resultOf(msg *jsonrpcMessage) (json.RawMessage, error) {
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	return msg.Result, nil
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// memo holds the successful tool results of one run.
+type memo struct {
+	mu      sync.Mutex
+	results map[string]*agenkit.ToolResult
+}
+
+type memoContextKey struct{}
+
+// WithMemo returns a context carrying a fresh, empty memo of tool results.
+// Executors with Memoize set record successful calls made with the context
+// and answer repeated ones from it, so the memo lasts exactly as long as
+// the run the context belongs to. ReAct and ToolAgent attach one in each
+// call to Process.
+func WithMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, memoContextKey{}, &memo{results: make(map[string]*agenkit.ToolResult)})
+}
+
+// memoFromContext returns the memo attached to ctx, if any.
+func memoFromContext(ctx context.Context) *memo {
+	m, _ := ctx.Value(memoContextKey{}).(*memo)
+	return m
+}
+
+// memoKey identifies a call by tool name and a hash of its arguments.
+// Maps encode with sorted keys, so equal arguments hash alike. ok is false
+// for arguments that cannot be encoded.
+func memoKey(name string, params map[string]interface{}) (key string, ok bool) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return name + ":" + hex.EncodeToString(sum[:]), true
+}
+
+// get returns a copy of the recorded result for key, marked "memoized" in
+// its metadata.
+func (m *memo) get(key string) (*agenkit.ToolResult, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result, ok := m.results[key]
+	if !ok {
+		return nil, false
+	}
+	copied := copyResult(result)
+	copied.Metadata["memoized"] = true
+	return copied, true
+}
+
+// put records a copy of result for key, so callers changing the result
+// they were given do not change later answers.
+func (m *memo) put(key string, result *agenkit.ToolResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[key] = copyResult(result)
+}
+
+// copyResult copies result and its metadata map.
+func copyResult(result *agenkit.ToolResult) *agenkit.ToolResult {
+	copied := *result
+	copied.Metadata = make(map[string]interface{}, len(result.Metadata)+1)
+	for k, v := range result.Metadata {
+		copied.Metadata[k] = v
+	}
+	return &copied
+}
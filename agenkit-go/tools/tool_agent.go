@@ -4,7 +4,9 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/agenkit/agenkit-go/agenkit"
@@ -68,28 +70,36 @@ func (r *ToolRegistry) GetToolDescriptions() string {
 }
 
 // ToolCall represents a request to execute a tool.
-type ToolCall struct {
-	ToolName   string                 `json:"tool_name"`
-	Parameters map[string]interface{} `json:"parameters"`
-}
+type ToolCall = agenkit.ToolCall
 
 // ToolAgent wraps an agent with tool calling capabilities.
 type ToolAgent struct {
 	agent    agenkit.Agent
 	registry *ToolRegistry
+	executor *Executor
 }
 
 // Verify that ToolAgent implements Agent interface.
 var _ agenkit.Agent = (*ToolAgent)(nil)
 
+// Verify that ToolAgent implements agenkit.ToolUser interface.
+var _ agenkit.ToolUser = (*ToolAgent)(nil)
+
 // NewToolAgent creates a new tool-enabled agent.
 func NewToolAgent(agent agenkit.Agent, registry *ToolRegistry) *ToolAgent {
 	return &ToolAgent{
 		agent:    agent,
 		registry: registry,
+		executor: NewExecutor(ExecutorConfig{}),
 	}
 }
 
+// SetExecutor sets the executor that runs tool calls, e.g. to apply
+// timeouts or share a concurrency limit with other agents.
+func (t *ToolAgent) SetExecutor(executor *Executor) {
+	t.executor = executor
+}
+
 // Name returns the name of the underlying agent.
 func (t *ToolAgent) Name() string {
 	return t.agent.Name()
@@ -103,7 +113,9 @@ func (t *ToolAgent) Capabilities() []string {
 
 // Process handles a message with tool calling support.
 // If the message contains tool calls in metadata, it executes them and returns results.
-// Otherwise, it passes the message to the underlying agent.
+// Otherwise, it passes the message to the underlying agent. Calls with
+// invalid arguments to tools the executor treats as fatal fail the whole
+// message with the *ArgumentError.
 func (t *ToolAgent) Process(ctx context.Context, message *agenkit.Message) (*agenkit.Message, error) {
 	// Check if message contains tool calls
 	toolCallsData, hasToolCalls := message.Metadata["tool_calls"]
@@ -118,10 +130,16 @@ func (t *ToolAgent) Process(ctx context.Context, message *agenkit.Message) (*age
 		return nil, fmt.Errorf("failed to parse tool calls: %w", err)
 	}
 
-	// Execute tool calls
+	// Execute tool calls, repeats sharing one result if the executor memoizes
+	ctx = WithMemo(ctx)
 	results := make([]*agenkit.ToolResult, len(toolCalls))
 	for i, call := range toolCalls {
 		result, err := t.executeTool(ctx, call)
+		var argErr *ArgumentError
+		if errors.As(err, &argErr) {
+			// Only returned for tools whose invalid arguments are fatal
+			return nil, err
+		}
 		if err != nil {
 			results[i] = agenkit.NewToolError(err.Error())
 		} else {
@@ -158,7 +176,7 @@ func (t *ToolAgent) executeTool(ctx context.Context, call *ToolCall) (*agenkit.T
 		return nil, fmt.Errorf("tool '%s' not found", call.ToolName)
 	}
 
-	return tool.Execute(ctx, call.Parameters)
+	return t.executor.Execute(ctx, tool, call.Parameters)
 }
 
 // formatToolResults formats tool results into a readable message.
@@ -179,3 +197,19 @@ func (t *ToolAgent) formatToolResults(results []*agenkit.ToolResult) string {
 func (t *ToolAgent) GetRegistry() *ToolRegistry {
 	return t.registry
 }
+
+// Tools returns the registered tools in name order.
+func (t *ToolAgent) Tools() []agenkit.Tool {
+	names := t.registry.List()
+	sort.Strings(names)
+	tools := make([]agenkit.Tool, len(names))
+	for i, name := range names {
+		tools[i] = t.registry.tools[name]
+	}
+	return tools
+}
+
+// Unwrap returns the underlying agent.
+func (t *ToolAgent) Unwrap() agenkit.Agent {
+	return t.agent
+}
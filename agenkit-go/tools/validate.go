@@ -0,0 +1,258 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ArgumentViolation is one way tool arguments break the tool's schema.
+type ArgumentViolation struct {
+	// Path locates the offending argument, e.g. "$.filters[0].field".
+	Path string `json:"path"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// ArgumentError reports tool arguments that do not conform to the tool's
+// JSON schema.
+type ArgumentError struct {
+	ToolName   string
+	Violations []ArgumentViolation
+}
+
+// Error implements the error interface.
+func (e *ArgumentError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Path + ": " + v.Message
+	}
+	return fmt.Sprintf("invalid arguments for tool '%s': %s", e.ToolName, strings.Join(parts, "; "))
+}
+
+// ValidateArguments checks params against tool's JSON schema, for tools
+// implementing InputSchema() map[string]any, and returns an *ArgumentError
+// listing every violation. Tools without a schema accept any arguments.
+//
+// Required properties, types (including lists of types), enum membership,
+// numeric minimum and maximum, array items and additionalProperties are
+// checked, recursively; other keywords are ignored.
+func ValidateArguments(tool agenkit.Tool, params map[string]interface{}) error {
+	st, ok := tool.(interface{ InputSchema() map[string]any })
+	if !ok {
+		return nil
+	}
+	schema := st.InputSchema()
+	if schema == nil {
+		return nil
+	}
+
+	// Compare in JSON terms, so Go callers passing ints or typed slices
+	// are checked the same way as decoded model output
+	value, err := normalizeJSON(params)
+	if err != nil {
+		return &ArgumentError{ToolName: tool.Name(), Violations: []ArgumentViolation{{Path: "$", Message: err.Error()}}}
+	}
+	if value == nil {
+		value = map[string]any{}
+	}
+
+	var violations []ArgumentViolation
+	validateValue(value, schema, "$", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ArgumentError{ToolName: tool.Name(), Violations: violations}
+}
+
+// validateValue appends the violations of value against schema.
+func validateValue(value any, schema map[string]any, path string, violations *[]ArgumentViolation) {
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, ArgumentViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesType(value, types) {
+		fail("expected %s, got %s", strings.Join(types, " or "), kindOf(value))
+		return
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		allowed, err := normalizeJSON(enum)
+		if list, isList := allowed.([]any); err == nil && isList && !containsValue(list, value) {
+			quoted := make([]string, len(list))
+			for i, v := range list {
+				data, _ := json.Marshal(v)
+				quoted[i] = string(data)
+			}
+			got, _ := json.Marshal(value)
+			fail("must be one of %s, got %s", strings.Join(quoted, ", "), got)
+		}
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if minimum, ok := toFloat(schema["minimum"]); ok && v < minimum {
+			fail("must be at least %v, got %v", minimum, v)
+		}
+		if maximum, ok := toFloat(schema["maximum"]); ok && v > maximum {
+			fail("must be at most %v, got %v", maximum, v)
+		}
+
+	case map[string]any:
+		for _, name := range stringList(schema["required"]) {
+			if _, present := v[name]; !present {
+				*violations = append(*violations, ArgumentViolation{Path: path + "." + name, Message: "required field is missing"})
+			}
+		}
+		properties, _ := asSchema(schema["properties"])
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := asSchema(properties[key]); ok {
+				validateValue(v[key], sub, path+"."+key, violations)
+			} else if _, declared := properties[key]; declared {
+				continue
+			} else if schema["additionalProperties"] == false {
+				*violations = append(*violations, ArgumentViolation{Path: path + "." + key, Message: "unknown field"})
+			} else if additional, ok := asSchema(schema["additionalProperties"]); ok {
+				validateValue(v[key], additional, path+"."+key, violations)
+			}
+		}
+
+	case []any:
+		if items, ok := asSchema(schema["items"]); ok {
+			for i, item := range v {
+				validateValue(item, items, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	}
+}
+
+// schemaTypes returns the types allowed by a schema's "type" keyword.
+func schemaTypes(typ any) []string {
+	if s, ok := typ.(string); ok {
+		return []string{s}
+	}
+	return stringList(typ)
+}
+
+// matchesType reports whether value has one of the JSON types.
+func matchesType(value any, types []string) bool {
+	kind := kindOf(value)
+	for _, typ := range types {
+		switch {
+		case typ == kind:
+			return true
+		case typ == "number" && kind == "integer":
+			return true
+		}
+	}
+	return false
+}
+
+// kindOf names the JSON type of a normalized value, reporting whole
+// numbers as "integer".
+func kindOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// containsValue reports whether list holds value.
+func containsValue(list []any, value any) bool {
+	for _, v := range list {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeJSON round-trips v through encoding/json, yielding the types
+// json.Unmarshal produces for an any.
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// asSchema returns v as a schema object. Schemas decoded from JSON and
+// named map types such as structured.Schema are both accepted.
+func asSchema(v any) (map[string]any, bool) {
+	if m, ok := v.(map[string]any); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// stringList returns v as a list of strings, accepting []string and the
+// []any of decoded JSON.
+func stringList(v any) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []any:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// toFloat returns a numeric schema keyword as a float64.
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
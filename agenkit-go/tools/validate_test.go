@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// schemaTool has a hand-written schema, as decoded from JSON by MCP.
+type schemaTool struct {
+	schema map[string]any
+	calls  int
+}
+
+func (s *schemaTool) Name() string                { return "query" }
+func (s *schemaTool) Description() string         { return "Runs a query" }
+func (s *schemaTool) InputSchema() map[string]any { return s.schema }
+
+func (s *schemaTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	s.calls++
+	return agenkit.NewToolResult("rows"), nil
+}
+
+func violationPaths(err error) []string {
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) {
+		return nil
+	}
+	paths := make([]string, len(argErr.Violations))
+	for i, v := range argErr.Violations {
+		paths[i] = v.Path
+	}
+	return paths
+}
+
+func TestValidateArgumentsFuncTool(t *testing.T) {
+	tool, _ := ToolFromFunc("weather", "Looks up the weather", lookupWeather)
+
+	if err := ValidateArguments(tool, map[string]interface{}{"city": "Oslo", "units": "celsius", "days": 3}); err != nil {
+		t.Errorf("Expected valid arguments to pass, got %v", err)
+	}
+
+	err := ValidateArguments(tool, map[string]interface{}{"units": "kelvin", "days": 9, "tags": []interface{}{"a", 2}})
+	want := []string{"$.city", "$.days", "$.tags[1]", "$.units"}
+	if got := violationPaths(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected violations at %v, got %v (%v)", want, got, err)
+	}
+	if !strings.Contains(err.Error(), `must be one of "celsius", "fahrenheit", got "kelvin"`) {
+		t.Errorf("Expected the allowed values in the error, got %v", err)
+	}
+}
+
+func TestValidateArgumentsDecodedSchema(t *testing.T) {
+	tool := &schemaTool{schema: map[string]any{
+		"type":     "object",
+		"required": []any{"table"},
+		"properties": map[string]any{
+			"table": map[string]any{"type": "string"},
+			"limit": map[string]any{"type": []any{"integer", "null"}},
+			"order": map[string]any{"enum": []any{"asc", "desc"}},
+		},
+		"additionalProperties": false,
+	}}
+
+	if err := ValidateArguments(tool, map[string]interface{}{"table": "users", "limit": nil, "order": "asc"}); err != nil {
+		t.Errorf("Expected valid arguments to pass, got %v", err)
+	}
+	err := ValidateArguments(tool, map[string]interface{}{"table": 7, "limit": 2.5, "where": "x"})
+	if got := violationPaths(err); !reflect.DeepEqual(got, []string{"$.limit", "$.table", "$.where"}) {
+		t.Errorf("Expected type and unknown field violations, got %v (%v)", got, err)
+	}
+
+	if err := ValidateArguments(&slowTool{name: "plain"}, map[string]interface{}{"anything": true}); err != nil {
+		t.Errorf("Expected tools without a schema to accept anything, got %v", err)
+	}
+}
+
+func TestExecutorValidatesArguments(t *testing.T) {
+	tool := &schemaTool{schema: map[string]any{"type": "object", "required": []any{"table"}}}
+	executor := NewExecutor(ExecutorConfig{})
+
+	result, err := executor.Execute(context.Background(), tool, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Expected a recoverable result, got %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "$.table: required field is missing") {
+		t.Errorf("Expected a failed result describing the violation, got %+v", result)
+	}
+	if _, ok := result.Metadata["error"].(*ArgumentError); !ok {
+		t.Error("Expected the *ArgumentError in the result metadata")
+	}
+	if tool.calls != 0 {
+		t.Error("Expected the tool not to run with invalid arguments")
+	}
+
+	fatal := NewExecutor(ExecutorConfig{FatalArgumentErrors: map[string]bool{"query": true}})
+	var argErr *ArgumentError
+	if _, err := fatal.Execute(context.Background(), tool, map[string]interface{}{}); !errors.As(err, &argErr) {
+		t.Errorf("Expected a fatal *ArgumentError, got %v", err)
+	}
+
+	unchecked := NewExecutor(ExecutorConfig{SkipValidation: true})
+	if result, _ := unchecked.Execute(context.Background(), tool, map[string]interface{}{}); !result.Success || tool.calls != 1 {
+		t.Error("Expected validation to be skippable")
+	}
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// ToolDisagreementError reports that the implementations behind a
+// VerifiedTool did not agree on a result.
+type ToolDisagreementError struct {
+	ToolName string
+
+	// Results holds each implementation's result, in the order the
+	// implementations were given. Implementations that returned an error
+	// appear as failed results carrying the error's message.
+	Results []agenkit.ToolResult
+}
+
+// Error implements the error interface.
+func (e *ToolDisagreementError) Error() string {
+	return fmt.Sprintf("tool '%s': %d implementations disagreed", e.ToolName, len(e.Results))
+}
+
+// verifiedTool runs several implementations of a tool and cross-checks them;
+// see VerifiedTool.
+type verifiedTool struct {
+	name  string
+	impls []agenkit.Tool
+	agree func(results []agenkit.ToolResult) (agenkit.ToolResult, bool)
+}
+
+// Verify that verifiedTool implements Tool interface.
+var _ agenkit.Tool = (*verifiedTool)(nil)
+
+// VerifiedTool creates a tool that runs every implementation in impls
+// concurrently with the same parameters and passes their results to agree.
+// If agree reports agreement, the result it returns is the tool's result;
+// otherwise the call fails with a *ToolDisagreementError carrying all the
+// individual results. A nil agree requires every implementation to agree
+// (see Quorum).
+//
+// The tool takes its description, and its parameter schema if it has one,
+// from the first implementation.
+func VerifiedTool(name string, impls []agenkit.Tool, agree func(results []agenkit.ToolResult) (agenkit.ToolResult, bool)) agenkit.Tool {
+	if agree == nil {
+		agree = Quorum(len(impls))
+	}
+	return &verifiedTool{name: name, impls: impls, agree: agree}
+}
+
+// Name returns the tool's name.
+func (t *verifiedTool) Name() string {
+	return t.name
+}
+
+// Description returns the first implementation's description.
+func (t *verifiedTool) Description() string {
+	if len(t.impls) == 0 {
+		return ""
+	}
+	return t.impls[0].Description()
+}
+
+// InputSchema returns the first implementation's parameter schema, or nil.
+func (t *verifiedTool) InputSchema() map[string]any {
+	if len(t.impls) == 0 {
+		return nil
+	}
+	if st, ok := t.impls[0].(interface{ InputSchema() map[string]any }); ok {
+		return st.InputSchema()
+	}
+	return nil
+}
+
+// Execute runs every implementation and returns the agreed result.
+func (t *verifiedTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	if len(t.impls) == 0 {
+		return nil, fmt.Errorf("tool '%s': no implementations", t.name)
+	}
+
+	results := make([]agenkit.ToolResult, len(t.impls))
+	var wg sync.WaitGroup
+	for i, impl := range t.impls {
+		wg.Add(1)
+		go func(i int, impl agenkit.Tool) {
+			defer wg.Done()
+			result, err := impl.Execute(ctx, params)
+			switch {
+			case err != nil:
+				results[i] = *agenkit.NewToolError(err.Error())
+			case result == nil:
+				results[i] = *agenkit.NewToolError("no result")
+			default:
+				results[i] = *result
+			}
+		}(i, impl)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	agreed, ok := t.agree(results)
+	if !ok {
+		return nil, &ToolDisagreementError{ToolName: t.name, Results: results}
+	}
+	return &agreed, nil
+}
+
+// Quorum returns an agreement function for VerifiedTool that accepts a
+// result when at least n successful results have equal data, compared by
+// their JSON encoding. The first result of the largest such group is
+// returned. n below 1 is treated as 1.
+func Quorum(n int) func(results []agenkit.ToolResult) (agenkit.ToolResult, bool) {
+	if n < 1 {
+		n = 1
+	}
+	return func(results []agenkit.ToolResult) (agenkit.ToolResult, bool) {
+		counts := make(map[string]int)
+		first := make(map[string]int)
+		best := ""
+		for i, result := range results {
+			if !result.Success {
+				continue
+			}
+			data, err := json.Marshal(result.Data)
+			if err != nil {
+				continue
+			}
+			key := string(data)
+			if _, seen := first[key]; !seen {
+				first[key] = i
+			}
+			counts[key]++
+			if counts[key] > counts[best] {
+				best = key
+			}
+		}
+		if counts[best] < n {
+			return agenkit.ToolResult{}, false
+		}
+		return results[first[best]], true
+	}
+}
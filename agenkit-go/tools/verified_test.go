@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/agenkit/agenkit-go/agenkit"
+)
+
+// constTool returns a fixed value, or fails if err is set.
+type constTool struct {
+	value interface{}
+	err   error
+}
+
+func (c *constTool) Name() string        { return "calc" }
+func (c *constTool) Description() string { return "adds numbers" }
+
+func (c *constTool) Execute(ctx context.Context, params map[string]interface{}) (*agenkit.ToolResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return agenkit.NewToolResult(c.value), nil
+}
+
+func TestVerifiedToolReturnsAgreedResult(t *testing.T) {
+	impls := []agenkit.Tool{&constTool{value: 4}, &constTool{value: 4.0}, &constTool{value: 5}}
+	tool := VerifiedTool("calc", impls, Quorum(2))
+	if tool.Name() != "calc" || tool.Description() != "adds numbers" {
+		t.Errorf("Expected the first implementation's description, got %s", tool.Description())
+	}
+
+	result, err := tool.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Success || result.Data != 4 {
+		t.Errorf("Expected the majority result 4, got %+v", result)
+	}
+}
+
+func TestVerifiedToolReportsDisagreement(t *testing.T) {
+	impls := []agenkit.Tool{&constTool{value: 4}, &constTool{value: 5}, &constTool{err: errors.New("overflow")}}
+	// A nil agreement function requires every implementation to agree
+	executor := NewExecutor(ExecutorConfig{})
+	_, err := executor.Execute(context.Background(), VerifiedTool("calc", impls, nil), nil)
+
+	var disagreement *ToolDisagreementError
+	if !errors.As(err, &disagreement) {
+		t.Fatalf("Expected a ToolDisagreementError, got %v", err)
+	}
+	if len(disagreement.Results) != 3 {
+		t.Fatalf("Expected all 3 results, got %d", len(disagreement.Results))
+	}
+	if disagreement.Results[1].Data != 5 || disagreement.Results[2].Success || disagreement.Results[2].Error != "overflow" {
+		t.Errorf("Expected the individual results in order, got %+v", disagreement.Results)
+	}
+}